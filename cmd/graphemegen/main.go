@@ -0,0 +1,117 @@
+// graphemegen generates driver/lexer/grapheme.go, the table of the grapheme classes that the lexer uses to count
+// columns in grapheme clusters, from the Grapheme_Cluster_Break and Extended_Pictographic properties of the ucd
+// package. `go generate` runs it in the ucd directory after ucdgen so that the table follows the UCD.
+//
+// The lexer core depends only on the standard library, so the generated file holds the plain table rather than
+// importing the ucd package.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"strings"
+
+	"github.com/nihei9/vartan/ucd"
+)
+
+var output = flag.String("o", "grapheme.go", "file to write the table to")
+
+func main() {
+	flag.Parse()
+	err := gen()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// classNames maps the Grapheme_Cluster_Break values to the names of the grapheme classes of the lexer. The lexer
+// computes the classes of Hangul syllables, LV and LVT, so they have no names.
+var classNames = map[string]string{
+	"cr":                "graphemeClassCR",
+	"lf":                "graphemeClassLF",
+	"control":           "graphemeClassControl",
+	"extend":            "graphemeClassExtend",
+	"zwj":               "graphemeClassZWJ",
+	"regionalindicator": "graphemeClassRegionalIndicator",
+	"prepend":           "graphemeClassPrepend",
+	"spacingmark":       "graphemeClassSpacingMark",
+	"l":                 "graphemeClassL",
+	"v":                 "graphemeClassV",
+	"t":                 "graphemeClassT",
+}
+
+const (
+	hangulSyllableFirst = 0xAC00
+	hangulSyllableLast  = 0xD7A3
+	// hangulTCount is the number of the trailing consonants plus one for a syllable without them.
+	hangulTCount = 28
+)
+
+func gen() error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `// Code generated by cmd/graphemegen; DO NOT EDIT.
+
+package lexer
+
+// graphemeClassRanges holds the code point ranges of the grapheme classes in the order of their first code points.
+// Code points out of the ranges are graphemeClassOther. The table has no Hangul syllables because graphemeClassOf
+// computes whether they are LV or LVT.
+var graphemeClassRanges = []graphemeClassRange{
+`)
+	from := rune(-1)
+	class := ""
+	flush := func(to rune) {
+		if class != "" {
+			fmt.Fprintf(&b, "{0x%04X, 0x%04X, %v},\n", from, to, class)
+		}
+	}
+	for r := rune(0); r <= 0x10FFFF; r++ {
+		c, err := classOf(r)
+		if err != nil {
+			return err
+		}
+		if c == class {
+			continue
+		}
+		flush(r - 1)
+		from = r
+		class = c
+	}
+	flush(0x10FFFF)
+	fmt.Fprintf(&b, "}\n")
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*output, src, 0644)
+}
+
+// classOf returns the name of the grapheme class of a code point, or an empty string for graphemeClassOther and
+// Hangul syllables.
+func classOf(r rune) (string, error) {
+	gcb := ucd.GraphemeClusterBreak(r)
+	switch gcb {
+	case "other":
+		// UAX #29 uses Extended_Pictographic as another class in the rule GB11.
+		if ucd.IsExtendedPictographic(r) {
+			return "graphemeClassExtPict", nil
+		}
+		return "", nil
+	case "lv", "lvt":
+		// The lexer computes these classes, so they must follow the composition of Hangul syllables.
+		isLV := (r-hangulSyllableFirst)%hangulTCount == 0
+		if r < hangulSyllableFirst || r > hangulSyllableLast || isLV != (gcb == "lv") {
+			return "", fmt.Errorf("%U doesn't follow the composition of Hangul syllables: %v", r, gcb)
+		}
+		return "", nil
+	}
+	name, ok := classNames[gcb]
+	if !ok {
+		return "", fmt.Errorf("unknown Grapheme_Cluster_Break value of %U: %v", r, gcb)
+	}
+	return name, nil
+}
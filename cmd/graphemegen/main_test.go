@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassOf(t *testing.T) {
+	tests := []struct {
+		r     rune
+		class string
+	}{
+		{r: 'a', class: ""},
+		{r: '\r', class: "graphemeClassCR"},
+		{r: 0x0301, class: "graphemeClassExtend"},
+		{r: 0x1F1EF, class: "graphemeClassRegionalIndicator"},
+		{r: 0x1F600, class: "graphemeClassExtPict"},
+		// The lexer computes the classes of Hangul syllables.
+		{r: 0xAC00, class: ""},
+		{r: 0xAC01, class: ""},
+	}
+	for _, tt := range tests {
+		class, err := classOf(tt.r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if class != tt.class {
+			t.Errorf("unexpected class of %U; want: %q, got: %q", tt.r, tt.class, class)
+		}
+	}
+}
+
+func TestGeneratedTableIsUpToDate(t *testing.T) {
+	defer func(o string) {
+		*output = o
+	}(*output)
+	*output = filepath.Join(t.TempDir(), "grapheme.go")
+	err := gen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := os.ReadFile(*output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := os.ReadFile("../../driver/lexer/grapheme.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated, committed) {
+		t.Fatal("driver/lexer/grapheme.go is out of date; run `go generate` in the ucd directory")
+	}
+}
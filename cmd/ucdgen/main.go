@@ -1,16 +1,52 @@
+// ucdgen generates ucd/codepoint.go from the text files of the Unicode Character Database (UCD). `go generate` runs it
+// in the ucd directory.
+//
+// ucdgen reads the UCD files from the data directory and verifies them against the SHA-256 checksums in ucd.sha256, so
+// generating the code needs no network access and always produces the same code. ucdgen fails when the checksum file
+// doesn't exist rather than trusting whatever files it reads. -download option makes ucdgen download files missing in
+// the directory from unicode.org and save them in the directory; `go generate` doesn't pass it. To upgrade the UCD,
+// replace the files, check them against the ones unicode.org publishes, and record their checksums using
+// -write-checksums option. Commit the checksum file so that later runs verify the files.
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/nihei9/vartan/ucd"
 )
 
+const ucdBaseURL = "https://www.unicode.org/Public/13.0.0/ucd/"
+
+const (
+	fileNamePropertyValueAliases = "PropertyValueAliases.txt"
+	fileNameUnicodeData          = "UnicodeData.txt"
+	fileNameScripts              = "Scripts.txt"
+	fileNamePropList             = "PropList.txt"
+	fileNameGraphemeBreakProp    = "auxiliary/GraphemeBreakProperty.txt"
+	fileNameEmojiData            = "emoji/emoji-data.txt"
+)
+
+var (
+	dir            = flag.String("dir", "data", "directory containing the UCD files")
+	checksumsPath  = flag.String("checksums", "ucd.sha256", "file of the SHA-256 checksums of the UCD files in the format of sha256sum")
+	download       = flag.Bool("download", false, "download the UCD files missing in the directory from unicode.org and save them in the directory")
+	writeChecksums = flag.Bool("write-checksums", false, "write the checksums of the UCD files to the checksum file instead of verifying them")
+)
+
 func main() {
+	flag.Parse()
 	err := gen()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -19,71 +55,106 @@ func main() {
 }
 
 func gen() error {
+	files, err := newUCDFiles()
+	if err != nil {
+		return err
+	}
+
 	var propValAliases *ucd.PropertyValueAliases
 	{
-		resp, err := http.Get("https://www.unicode.org/Public/13.0.0/ucd/PropertyValueAliases.txt")
+		r, err := files.open(fileNamePropertyValueAliases)
 		if err != nil {
 			return err
 		}
-		defer resp.Body.Close()
-		propValAliases, err = ucd.ParsePropertyValueAliases(resp.Body)
+		propValAliases, err = ucd.ParsePropertyValueAliases(r)
 		if err != nil {
 			return err
 		}
 	}
 	var unicodeData *ucd.UnicodeData
 	{
-		resp, err := http.Get("https://www.unicode.org/Public/13.0.0/ucd/UnicodeData.txt")
+		r, err := files.open(fileNameUnicodeData)
 		if err != nil {
 			return err
 		}
-		defer resp.Body.Close()
-		unicodeData, err = ucd.ParseUnicodeData(resp.Body, propValAliases)
+		unicodeData, err = ucd.ParseUnicodeData(r, propValAliases)
 		if err != nil {
 			return err
 		}
 	}
 	var scripts *ucd.Scripts
 	{
-		resp, err := http.Get("https://www.unicode.org/Public/13.0.0/ucd/Scripts.txt")
+		r, err := files.open(fileNameScripts)
 		if err != nil {
 			return err
 		}
-		defer resp.Body.Close()
-		scripts, err = ucd.ParseScripts(resp.Body, propValAliases)
+		scripts, err = ucd.ParseScripts(r, propValAliases)
 		if err != nil {
 			return err
 		}
 	}
 	var propList *ucd.PropList
 	{
-		resp, err := http.Get("https://www.unicode.org/Public/13.0.0/ucd/PropList.txt")
+		r, err := files.open(fileNamePropList)
+		if err != nil {
+			return err
+		}
+		propList, err = ucd.ParsePropList(r)
+		if err != nil {
+			return err
+		}
+	}
+	var graphemeBreakProp *ucd.GraphemeBreakProperty
+	{
+		r, err := files.open(fileNameGraphemeBreakProp)
+		if err != nil {
+			return err
+		}
+		graphemeBreakProp, err = ucd.ParseGraphemeBreakProperty(r)
+		if err != nil {
+			return err
+		}
+	}
+	var emojiData *ucd.EmojiData
+	{
+		r, err := files.open(fileNameEmojiData)
 		if err != nil {
 			return err
 		}
-		defer resp.Body.Close()
-		propList, err = ucd.ParsePropList(resp.Body)
+		emojiData, err = ucd.ParseEmojiData(r)
 		if err != nil {
 			return err
 		}
 	}
+	if *writeChecksums {
+		err := files.writeChecksums()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "recorded the checksums of the UCD files in %v; commit it to verify the files in later runs\n", *checksumsPath)
+	}
+
 	tmpl, err := template.ParseFiles("../ucd/codepoint.go.tmpl")
 	if err != nil {
 		return err
 	}
 	var b strings.Builder
 	err = tmpl.Execute(&b, struct {
-		GeneratorName        string
-		UnicodeData          *ucd.UnicodeData
-		Scripts              *ucd.Scripts
-		PropList             *ucd.PropList
-		PropertyValueAliases *ucd.PropertyValueAliases
+		GeneratorName         string
+		UnicodeData           *ucd.UnicodeData
+		Scripts               *ucd.Scripts
+		PropList              *ucd.PropList
+		PropertyValueAliases  *ucd.PropertyValueAliases
+		GraphemeBreakProperty *ucd.GraphemeBreakProperty
+		EmojiData             *ucd.EmojiData
 	}{
-		GeneratorName:        "generator/main.go",
-		UnicodeData:          unicodeData,
-		Scripts:              scripts,
-		PropList:             propList,
-		PropertyValueAliases: propValAliases,
+		GeneratorName:         "generator/main.go",
+		UnicodeData:           unicodeData,
+		Scripts:               scripts,
+		PropList:              propList,
+		PropertyValueAliases:  propValAliases,
+		GraphemeBreakProperty: graphemeBreakProp,
+		EmojiData:             emojiData,
 	})
 	if err != nil {
 		return err
@@ -96,3 +167,141 @@ func gen() error {
 	fmt.Fprint(f, b.String())
 	return nil
 }
+
+// ucdFiles reads the UCD files and verifies their checksums.
+type ucdFiles struct {
+	// checksums holds the hex-encoded SHA-256 checksums indexed by file names. It is nil when -write-checksums option
+	// makes ucdgen record the checksums instead of verifying them.
+	checksums map[string]string
+
+	// readChecksums holds the checksums of the files ucdgen has read.
+	readChecksums map[string]string
+}
+
+func newUCDFiles() (*ucdFiles, error) {
+	files := &ucdFiles{
+		readChecksums: map[string]string{},
+	}
+	if *writeChecksums {
+		return files, nil
+	}
+
+	f, err := os.Open(*checksumsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("cannot read the checksums of the UCD files: %w; record the checksums of the files you checked using -write-checksums option", err)
+		}
+		return nil, fmt.Errorf("cannot read the checksums of the UCD files: %w", err)
+	}
+	defer f.Close()
+	files.checksums, err = parseChecksums(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", *checksumsPath, err)
+	}
+	return files, nil
+}
+
+// parseChecksums parses checksums in the format of sha256sum and returns them indexed by file names.
+func parseChecksums(r io.Reader) (map[string]string, error) {
+	checksums := map[string]string{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// The format of sha256sum is `<checksum> <mode><file name>`, where the mode is ` ` (text) or `*` (binary).
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line: %v", line)
+		}
+		sum := strings.ToLower(fields[0])
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid SHA-256 checksum: %v", fields[0])
+		}
+		checksums[strings.TrimPrefix(fields[1], "*")] = sum
+	}
+	err := s.Err()
+	if err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// verifyChecksum returns an error when the checksum of `data` doesn't match the one recorded for a file `name`.
+func verifyChecksum(checksums map[string]string, name string, data []byte) error {
+	expected, ok := checksums[name]
+	if !ok {
+		return fmt.Errorf("no checksum of %v is recorded", name)
+	}
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	if actual != expected {
+		return fmt.Errorf("the checksum of %v doesn't match; expected: %v, actual: %v", name, expected, actual)
+	}
+	return nil
+}
+
+// open reads a UCD file from the directory, or downloads it when -download option is specified and the directory
+// doesn't have it, and returns its content after verifying the checksum.
+func (fs *ucdFiles) open(name string) (io.Reader, error) {
+	path := filepath.Join(*dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) || !*download {
+			return nil, fmt.Errorf("cannot read a UCD file: %w; put the file in the directory or use -download option", err)
+		}
+		data, err = fetch(ucdBaseURL + name)
+		if err != nil {
+			return nil, err
+		}
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(path, data, 0644)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	fs.readChecksums[name] = hex.EncodeToString(sum[:])
+	if fs.checksums != nil {
+		err := verifyChecksum(fs.checksums, name, data)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w; if you upgraded the UCD, record the checksums using -write-checksums option", *checksumsPath, err)
+		}
+	}
+	return bytes.NewReader(data), nil
+}
+
+func (fs *ucdFiles) writeChecksums() error {
+	return os.WriteFile(*checksumsPath, []byte(formatChecksums(fs.readChecksums)), 0644)
+}
+
+// formatChecksums formats checksums in the format of sha256sum, sorted by file names.
+func formatChecksums(checksums map[string]string) string {
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%v  %v\n", checksums[name], name)
+	}
+	return b.String()
+}
+
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot download %v: %v", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseChecksums(t *testing.T) {
+	sumA := sha256Hex("a")
+	sumB := sha256Hex("b")
+	tests := []struct {
+		caption   string
+		src       string
+		checksums map[string]string
+		ok        bool
+	}{
+		{
+			caption: "the text and binary modes of sha256sum, comments, and blank lines",
+			src: "# UCD 13.0.0\n" +
+				sumA + "  A.txt\n" +
+				"\n" +
+				strings.ToUpper(sumB) + " *B.txt\n",
+			checksums: map[string]string{
+				"A.txt": sumA,
+				"B.txt": sumB,
+			},
+			ok: true,
+		},
+		{
+			caption: "a line must have a checksum and a file name",
+			src:     sumA + "\n",
+		},
+		{
+			caption: "a checksum must be a SHA-256 checksum",
+			src:     "0123  A.txt\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			checksums, err := parseChecksums(strings.NewReader(tt.src))
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("an expected error didn't occur")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(checksums) != len(tt.checksums) {
+				t.Fatalf("unexpected checksums; want: %v, got: %v", tt.checksums, checksums)
+			}
+			for name, sum := range tt.checksums {
+				if checksums[name] != sum {
+					t.Fatalf("unexpected checksum of %v; want: %v, got: %v", name, sum, checksums[name])
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	checksums := map[string]string{
+		"A.txt": sha256Hex("a"),
+	}
+	err := verifyChecksum(checksums, "A.txt", []byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = verifyChecksum(checksums, "A.txt", []byte("modified"))
+	if err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Fatalf("a modified file must fail verification: %v", err)
+	}
+	err = verifyChecksum(checksums, "B.txt", []byte("b"))
+	if err == nil || !strings.Contains(err.Error(), "no checksum") {
+		t.Fatalf("a file without a checksum must fail verification: %v", err)
+	}
+}
+
+func TestFormatChecksums(t *testing.T) {
+	checksums := map[string]string{
+		"B.txt": sha256Hex("b"),
+		"A.txt": sha256Hex("a"),
+	}
+	formatted := formatChecksums(checksums)
+	expected := sha256Hex("a") + "  A.txt\n" + sha256Hex("b") + "  B.txt\n"
+	if formatted != expected {
+		t.Fatalf("unexpected checksums; want: %q, got: %q", expected, formatted)
+	}
+	parsed, err := parseChecksums(strings.NewReader(formatted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, sum := range checksums {
+		if parsed[name] != sum {
+			t.Fatalf("the formatted checksums must round-trip; want: %v, got: %v", checksums, parsed)
+		}
+	}
+}
+
+func TestNewUCDFiles_MissingChecksums(t *testing.T) {
+	orig := *checksumsPath
+	defer func() {
+		*checksumsPath = orig
+	}()
+	*checksumsPath = filepath.Join(t.TempDir(), "ucd.sha256")
+
+	// ucdgen must not trust the UCD files it reads when no checksum is recorded.
+	_, err := newUCDFiles()
+	if err == nil || !strings.Contains(err.Error(), "-write-checksums") {
+		t.Fatalf("a missing checksum file must be an error: %v", err)
+	}
+}
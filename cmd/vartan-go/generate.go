@@ -21,10 +21,11 @@ var generateFlags = struct {
 }{}
 
 var generateCmd = &cobra.Command{
-	Use:           "vartan-go",
-	Short:         "Generate a parser for Go",
-	Long:          `vartan-go generates a parser for Go.`,
-	Example:       `  vartan-go grammar.json`,
+	Use:   "vartan-go",
+	Short: "Generate a parser for Go",
+	Long:  `vartan-go generates a parser for Go.`,
+	Example: `  vartan-go grammar.json
+  vartan compile grammar.vartan -o - | vartan-go -`,
 	Args:          cobra.ExactArgs(1),
 	RunE:          runGenerate,
 	SilenceErrors: true,
@@ -61,6 +62,11 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// A lexer-only grammar needs neither a parser nor a semantic action set.
+	if cgram.IsLexerOnly() {
+		return nil
+	}
+
 	{
 		b, err := parser.GenParser(cgram, *generateFlags.pkgName)
 		if err != nil {
@@ -104,12 +110,15 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// readCompiledGrammar reads a compiled grammar from a file. When the path is "-", it reads stdin.
 func readCompiledGrammar(path string) (*spec.CompiledGrammar, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
 	}
-	data, err := io.ReadAll(f)
 	if err != nil {
 		return nil, err
 	}
@@ -118,5 +127,13 @@ func readCompiledGrammar(path string) (*spec.CompiledGrammar, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = cgram.CheckCompatibility()
+	if err != nil {
+		return nil, err
+	}
+	err = cgram.Validate()
+	if err != nil {
+		return nil, err
+	}
 	return cgram, nil
 }
@@ -0,0 +1,88 @@
+//go:build js && wasm
+
+// vartan-wasm exposes the compiler and the driver to JavaScript. Once a JavaScript host runs this program, it defines
+// the global `vartan` object having the following functions. Every function receives and returns JSON strings so that
+// the bindings stay thin.
+//
+//   - vartan.compile(grammar) compiles a source of a grammar and returns a playground.CompileResult.
+//   - vartan.parse(compiledGrammar, request) parses the input of a playground.Request with a compiled grammar, which
+//     is JSON vartan.compile or `vartan compile` command outputs, and returns a playground.Response.
+//   - vartan.run(request) compiles the grammar of a playground.Request, parses its input, and returns
+//     a playground.Response.
+//
+// When a function fails before it gets a result, such as when it receives a malformed request, it returns an object
+// having an `error` field.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	"github.com/nihei9/vartan/playground"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+func main() {
+	js.Global().Set("vartan", js.ValueOf(map[string]interface{}{
+		"compile": js.FuncOf(compile),
+		"parse":   js.FuncOf(parse),
+		"run":     js.FuncOf(run),
+	}))
+
+	// Keep the program running so that JavaScript can call the functions.
+	select {}
+}
+
+func compile(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		return errorResult(fmt.Errorf("compile takes 1 argument: %v", len(args)))
+	}
+	return result(playground.Compile(args[0].String()))
+}
+
+func parse(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return errorResult(fmt.Errorf("parse takes 2 arguments: %v", len(args)))
+	}
+	cg := &spec.CompiledGrammar{}
+	err := json.Unmarshal([]byte(args[0].String()), cg)
+	if err != nil {
+		return errorResult(fmt.Errorf("invalid compiled grammar: %w", err))
+	}
+	req := &playground.Request{}
+	err = json.Unmarshal([]byte(args[1].String()), req)
+	if err != nil {
+		return errorResult(fmt.Errorf("invalid request: %w", err))
+	}
+	return result(playground.Parse(cg, req))
+}
+
+func run(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		return errorResult(fmt.Errorf("run takes 1 argument: %v", len(args)))
+	}
+	req := &playground.Request{}
+	err := json.Unmarshal([]byte(args[0].String()), req)
+	if err != nil {
+		return errorResult(fmt.Errorf("invalid request: %w", err))
+	}
+	return result(playground.Run(req))
+}
+
+func result(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return errorResult(err)
+	}
+	return string(b)
+}
+
+func errorResult(err error) interface{} {
+	b, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{
+		Error: err.Error(),
+	})
+	return string(b)
+}
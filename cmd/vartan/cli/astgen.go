@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/grammar/astgen"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+	"github.com/spf13/cobra"
+)
+
+var astgenFlags = struct {
+	lang       *string
+	pkgName    *string
+	standalone *bool
+	output     *string
+}{}
+
+func newAstgenCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "astgen <grammar file path>",
+		Short:   "Generate typed AST definitions from a grammar",
+		Example: `  vartan astgen grammar.vartan --lang go --package main -o ast.go`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runASTGen,
+	}
+	astgenFlags.lang = cmd.Flags().String("lang", "go", "target language (only go is supported)")
+	astgenFlags.pkgName = cmd.Flags().String("package", "main", "package name")
+	astgenFlags.standalone = cmd.Flags().Bool("standalone", false, "refer to the Node type in the same package that vartan-go generates")
+	astgenFlags.output = cmd.Flags().StringP("output", "o", "", "output file path (default stdout)")
+	return cmd
+}
+
+func runASTGen(cmd *cobra.Command, args []string) error {
+	if *astgenFlags.lang != "go" {
+		return fmt.Errorf("unsupported language: %v", *astgenFlags.lang)
+	}
+
+	f, err := openInput(args[0])
+	if err != nil {
+		return fmt.Errorf("Cannot open the grammar file %s: %w", args[0], err)
+	}
+	defer f.Close()
+
+	ast, err := parser.Parse(f)
+	if err != nil {
+		return err
+	}
+
+	// Generate code only for a valid grammar.
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	_, _, err = b.Build()
+	if err != nil {
+		return err
+	}
+
+	var opts []astgen.GoOption
+	if *astgenFlags.standalone {
+		opts = append(opts, astgen.Standalone())
+	}
+	src, err := astgen.GenGo(ast, *astgenFlags.pkgName, opts...)
+	if err != nil {
+		return err
+	}
+
+	if *astgenFlags.output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*astgenFlags.output, src, 0644)
+}
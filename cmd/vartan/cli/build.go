@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/grammar"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/workspace"
+	"github.com/spf13/cobra"
+)
+
+var buildFlags = struct {
+	manifest *string
+	force    *bool
+}{}
+
+func newBuildCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "Compile the grammars a vartan.toml manifest lists",
+		Long: `build compiles the grammars listed in a manifest file, vartan.toml, with their own outputs and compile options.
+A grammar can use the fragments of the fragment libraries the manifest declares. build records a fingerprint of each
+grammar in .vartan-build.json next to the manifest and skips a grammar when neither the grammar, its fragment libraries,
+its options, nor the version of vartan has changed since the last build and its outputs exist.
+See the documentation of the workspace package for the format of the manifest.`,
+		Example: `  vartan build
+  vartan build --manifest path/to/vartan.toml --force`,
+		Args: cobra.NoArgs,
+		RunE: runBuild,
+	}
+	buildFlags.manifest = cmd.Flags().StringP("manifest", "m", workspace.ManifestFileName, "manifest file path")
+	buildFlags.force = cmd.Flags().Bool("force", false, "compile all grammars even if they are up to date")
+	return cmd
+}
+
+func runBuild(cmd *cobra.Command, args []string) error {
+	m, err := workspace.Load(*buildFlags.manifest)
+	if err != nil {
+		return fmt.Errorf("Cannot read the manifest: %w", err)
+	}
+	state, err := workspace.LoadState(m)
+	if err != nil {
+		return fmt.Errorf("Cannot read the build state: %w", err)
+	}
+
+	failed := 0
+	for _, g := range m.Grammars {
+		status, err := buildManifestGrammar(m, g, state)
+		if err != nil {
+			failed++
+			if _, ok := err.(verr.SpecErrors); ok {
+				reportError("", err)
+			} else {
+				reportError(g.Path, err)
+			}
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "%v: %v\n", g.Path, status)
+	}
+
+	err = state.Save(m)
+	if err != nil {
+		return fmt.Errorf("Cannot write the build state: %w", err)
+	}
+	if failed > 0 {
+		return summaryError(fmt.Sprintf("%v of %v grammars failed", failed, len(m.Grammars)))
+	}
+	return nil
+}
+
+// buildManifestGrammar compiles a grammar of a manifest unless it is up to date, and it returns a status message.
+func buildManifestGrammar(m *workspace.Manifest, g *workspace.Grammar, state *workspace.State) (string, error) {
+	src, err := g.ReadSource()
+	if err != nil {
+		return "", err
+	}
+	fingerprint, err := g.Fingerprint(src, vartanVersion())
+	if err != nil {
+		return "", err
+	}
+	if !*buildFlags.force && state.UpToDate(g, fingerprint) {
+		return "up to date", nil
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: src.AST,
+	}
+	cg, report, err := b.Build(append(g.BuildOptions(), grammar.EnableReporting(), grammar.SourceName(g.Path))...)
+	for _, w := range b.Warnings() {
+		src.Locate(w)
+		reportSpecWarning(w)
+	}
+	if err != nil {
+		if specErrs, ok := err.(verr.SpecErrors); ok {
+			for _, e := range specErrs {
+				src.Locate(e)
+			}
+		}
+		return "", err
+	}
+	if g.EmbedSource {
+		cg.BuildInfo = &spec.BuildInfo{
+			Source:        string(src.Grammar),
+			VartanVersion: vartanVersion(),
+			Options:       manifestCompileOptions(g),
+		}
+		report.BuildInfo = cg.BuildInfo
+	}
+
+	// Unlike `vartan compile`, build creates the directories of the outputs because the manifest declares them.
+	out := g.Output
+	outDir := filepath.Dir(g.Output)
+	if out == "" {
+		out = m.OutDir
+		outDir = m.OutDir
+	}
+	err = os.MkdirAll(outDir, 0755)
+	if err != nil {
+		return "", err
+	}
+	cgPath, reportPath, err := makeOutputFilePaths(cg.Name, out)
+	if err != nil {
+		return "", err
+	}
+	err = writeCompiledGrammarAndReport(cg, report, out)
+	if err != nil {
+		return "", fmt.Errorf("Cannot write an output files: %w", err)
+	}
+	outputs := []string{cgPath, reportPath}
+	if g.ConstOut != "" {
+		err := os.MkdirAll(filepath.Dir(g.ConstOut), 0755)
+		if err != nil {
+			return "", err
+		}
+		err = writeConstants(cg, g.ConstOut, g.ConstPackage)
+		if err != nil {
+			return "", fmt.Errorf("Cannot write a constants file: %w", err)
+		}
+		outputs = append(outputs, g.ConstOut)
+	}
+	state.Record(g, fingerprint, outputs)
+
+	return fmt.Sprintf("compiled into %v", cgPath), nil
+}
+
+// manifestCompileOptions returns the options of a grammar of a manifest in the form of the options of
+// `vartan compile` command.
+func manifestCompileOptions(g *workspace.Grammar) []string {
+	opts := []string{
+		fmt.Sprintf("--duplicate-alternatives=%v", g.DuplicateAlternatives),
+		fmt.Sprintf("--Wunused=%v", g.Unused),
+		fmt.Sprintf("--Wunused-annotations=%v", g.UnusedAnnotations),
+		fmt.Sprintf("--Wright-recursion=%v", g.RightRecursion),
+		fmt.Sprintf("--Wunreachable-terminals=%v", g.UnreachableTerminals),
+		fmt.Sprintf("--lexer-table=%v", g.LexerTable),
+	}
+	if g.LazyLexer {
+		opts = append(opts, "--lazy-lexer")
+	}
+	return opts
+}
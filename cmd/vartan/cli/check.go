@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+	"github.com/spf13/cobra"
+)
+
+var checkFlags = struct {
+	dupAltPolicy *string
+	wUnused      *string
+	wUnusedAnnot *string
+	wRightRec    *string
+	wUnreachable *string
+}{}
+
+func newCheckCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check [<grammar file path>]",
+		Short: "Check a grammar for errors without generating a parsing table",
+		Long: `check reports the errors in a grammar faster than compile because it generates neither a lexer nor a parsing table.
+It doesn't report conflicts and the few errors that only a generated lexer reveals.`,
+		Example: `  vartan check grammar.vartan
+  cat grammar.vartan | vartan check --diagnostics json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runCheck,
+	}
+	checkFlags.dupAltPolicy = cmd.Flags().String("duplicate-alternatives", string(grammar.DuplicateAlternativePolicySymbols), "how to detect duplicate alternatives: one of symbols|exact")
+	checkFlags.wUnused = cmd.Flags().String("Wunused", string(grammar.SeverityError), "severity of unused terminals and productions: one of error|warn|ignore")
+	checkFlags.wUnusedAnnot = cmd.Flags().String("Wunused-annotations", string(grammar.SeverityWarn), "severity of labels and ordered symbols no directive uses: one of error|warn|ignore")
+	checkFlags.wRightRec = cmd.Flags().String("Wright-recursion", string(grammar.SeverityWarn), "severity of lists defined by right recursion: one of error|warn|ignore")
+	checkFlags.wUnreachable = cmd.Flags().String("Wunreachable-terminals", string(grammar.SeverityWarn), "severity of terminals whose lex modes no #push directive enters: one of error|warn|ignore")
+	return cmd
+}
+
+func runCheck(cmd *cobra.Command, args []string) (retErr error) {
+	src := io.Reader(os.Stdin)
+	var grmPath string
+	sourceName := "stdin"
+	if len(args) > 0 && args[0] != stdioPath {
+		grmPath = args[0]
+		sourceName = args[0]
+
+		f, err := os.Open(grmPath)
+		if err != nil {
+			return fmt.Errorf("Cannot open the grammar file %s: %w", grmPath, err)
+		}
+		defer f.Close()
+		src = f
+	}
+	defer func() {
+		if specErrs, ok := retErr.(verr.SpecErrors); ok {
+			for _, err := range specErrs {
+				err.FilePath = grmPath
+				err.SourceName = sourceName
+			}
+		}
+	}()
+
+	ast, err := parser.Parse(src)
+	if err != nil {
+		return err
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	err = b.Validate(
+		grammar.DetectDuplicateAlternativesBy(grammar.DuplicateAlternativePolicy(*checkFlags.dupAltPolicy)),
+		grammar.TreatUnusedSymbolsAs(grammar.Severity(*checkFlags.wUnused)),
+		grammar.TreatUnusedAnnotationsAs(grammar.Severity(*checkFlags.wUnusedAnnot)),
+		grammar.TreatRightRecursiveListsAs(grammar.Severity(*checkFlags.wRightRec)),
+		grammar.TreatUnreachableTerminalsAs(grammar.Severity(*checkFlags.wUnreachable)),
+	)
+	for _, w := range b.Warnings() {
+		w.FilePath = grmPath
+		w.SourceName = sourceName
+		reportSpecWarning(w)
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/nihei9/vartan/driver/parser/pretty"
+	"github.com/nihei9/vartan/tester"
+	"github.com/spf13/cobra"
+)
+
+var compareTreesFlags = struct {
+	depth *int
+}{}
+
+func newCompareTreesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compare-trees <old tree file path> <new tree file path>",
+		Short: "Compare two syntax trees structurally",
+		Long: `compare-trees compares two syntax trees that 'vartan parse --format json' prints, ignoring the positions of
+nodes. When the trees differ, it prints the path to the first diverging nodes in pre-order and the nodes, and exits
+with an error status. '-' as a file path means stdin.`,
+		Example: `  vartan parse grammar.json src --format json > new.json
+  vartan compare-trees golden.json new.json`,
+		Args: cobra.ExactArgs(2),
+		RunE: runCompareTrees,
+	}
+	compareTreesFlags.depth = cmd.Flags().Int("depth", 3, "maximum depth of the diverging nodes to print; 0 means no limit")
+	return cmd
+}
+
+func runCompareTrees(cmd *cobra.Command, args []string) error {
+	if *compareTreesFlags.depth < 0 {
+		return fmt.Errorf("--depth must be greater than or equal to 0: %v", *compareTreesFlags.depth)
+	}
+	if args[0] == stdioPath && args[1] == stdioPath {
+		return fmt.Errorf("only one of the trees can be read from stdin")
+	}
+
+	var trees [2]*driver.Node
+	for i, path := range args {
+		data, err := readInput(path)
+		if err != nil {
+			return fmt.Errorf("Cannot read a tree: %v: %w", sourceNameOf(path), err)
+		}
+		trees[i], err = tester.ReadTree(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("Cannot read a tree: %v: %w", sourceNameOf(path), err)
+		}
+	}
+
+	d := tester.CompareTrees(trees[0], trees[1])
+	if d == nil {
+		fmt.Fprintln(os.Stdout, "No difference found")
+		return nil
+	}
+
+	fmt.Fprintf(os.Stdout, "%v: %v\n", d.Path, d.Message)
+	var opts []pretty.Option
+	if *compareTreesFlags.depth > 0 {
+		opts = append(opts, pretty.MaxDepth(*compareTreesFlags.depth))
+	}
+	for i, n := range []*driver.Node{d.Old, d.New} {
+		fmt.Fprintf(os.Stdout, "\n%v:\n", sourceNameOf(args[i]))
+		err := pretty.Fprint(os.Stdout, n, opts...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.New("The trees differ")
+}
@@ -0,0 +1,463 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	driver "github.com/nihei9/vartan/driver/parser"
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/grammar"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+	"github.com/spf13/cobra"
+)
+
+var compileFlags = struct {
+	output        *string
+	dupAltPolicy  *string
+	constOut      *string
+	constPkgName  *string
+	watch         *bool
+	watchInput    *string
+	watchInterval *time.Duration
+	wUnused       *string
+	wUnusedAnnot  *string
+	wRightRec     *string
+	wUnreachable  *string
+	lazyLexer     *bool
+	lexerTable    *string
+	embedSource   *bool
+	verbose       *bool
+}{}
+
+func newCompileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compile",
+		Short: "Compile grammar you defined into a parsing table",
+		Example: `  vartan compile grammar.vartan -o grammar.json
+  vartan compile grammar.vartan -o grammar.json --watch --watch-input sample.txt
+  cat grammar.vartan | vartan compile -o - | vartan parse - src`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runCompile,
+	}
+	compileFlags.output = cmd.Flags().StringP("output", "o", "", "output file path; '-' means stdout (default stdout)")
+	compileFlags.dupAltPolicy = cmd.Flags().String("duplicate-alternatives", string(grammar.DuplicateAlternativePolicySymbols), "how to detect duplicate alternatives: one of symbols|exact")
+	compileFlags.constOut = cmd.Flags().String("const-out", "", "output file path of constants of mode IDs, kind IDs, terminals, and productions (JSON when the path ends with .json, otherwise Go)")
+	compileFlags.constPkgName = cmd.Flags().String("const-package", "", "package name of the constants file (default the name of the directory containing the file)")
+	compileFlags.wUnused = cmd.Flags().String("Wunused", string(grammar.SeverityError), "severity of unused terminals and productions: one of error|warn|ignore")
+	compileFlags.wUnusedAnnot = cmd.Flags().String("Wunused-annotations", string(grammar.SeverityWarn), "severity of labels and ordered symbols no directive uses: one of error|warn|ignore")
+	compileFlags.wRightRec = cmd.Flags().String("Wright-recursion", string(grammar.SeverityWarn), "severity of lists defined by right recursion: one of error|warn|ignore")
+	compileFlags.wUnreachable = cmd.Flags().String("Wunreachable-terminals", string(grammar.SeverityWarn), "severity of terminals whose lex modes no #push directive enters: one of error|warn|ignore")
+	compileFlags.lazyLexer = cmd.Flags().Bool("lazy-lexer", false, "store NFAs instead of DFAs of the lexer and build DFA states at run time; code generation doesn't support the output")
+	compileFlags.lexerTable = cmd.Flags().String("lexer-table", string(grammar.LexerTableRowDisplacement), "how to compress transition tables of the lexer: one of row-displacement|base-check")
+	compileFlags.embedSource = cmd.Flags().Bool("embed-source", false, "embed the grammar source, the version of vartan, and the compile options in the compiled grammar and the report")
+	compileFlags.verbose = cmd.Flags().BoolP("verbose", "v", false, "print the sizes of the transition tables of the lexer in each compression scheme to stderr")
+	compileFlags.watch = cmd.Flags().Bool("watch", false, "recompile the grammar whenever the file changes")
+	compileFlags.watchInput = cmd.Flags().String("watch-input", "", "sample input file parsed after every compilation in the watch mode; changes in its syntax tree are printed")
+	compileFlags.watchInterval = cmd.Flags().Duration("watch-interval", 500*time.Millisecond, "interval at which the watch mode checks files for changes")
+	return cmd
+}
+
+func runCompile(cmd *cobra.Command, args []string) (retErr error) {
+	var tmpDirPath string
+	defer func() {
+		if tmpDirPath == "" {
+			return
+		}
+		os.RemoveAll(tmpDirPath)
+	}()
+
+	var grmPath string
+	if len(args) > 0 && args[0] != stdioPath {
+		grmPath = args[0]
+	}
+	defer func() {
+		if retErr != nil {
+			specErrs, ok := retErr.(verr.SpecErrors)
+			if ok {
+				for _, err := range specErrs {
+					if len(args) > 0 && args[0] != stdioPath {
+						err.FilePath = grmPath
+						err.SourceName = grmPath
+					} else {
+						err.FilePath = grmPath
+						err.SourceName = "stdin"
+					}
+				}
+			}
+		}
+	}()
+
+	if *compileFlags.watch {
+		if grmPath == "" {
+			return fmt.Errorf("--watch needs a grammar file path")
+		}
+		if *compileFlags.output == "" || *compileFlags.output == stdioPath {
+			return fmt.Errorf("--watch needs --output other than stdout")
+		}
+		if *compileFlags.watchInterval <= 0 {
+			return fmt.Errorf("--watch-interval must be greater than 0: %v", *compileFlags.watchInterval)
+		}
+		return watchGrammar(grmPath, *compileFlags.watchInput, *compileFlags.watchInterval)
+	}
+	if *compileFlags.watchInput != "" {
+		return fmt.Errorf("--watch-input is available only with --watch")
+	}
+
+	if grmPath == "" {
+		var err error
+		tmpDirPath, err = os.MkdirTemp("", "vartan-compile-*")
+		if err != nil {
+			return err
+		}
+
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		grmPath = filepath.Join(tmpDirPath, "stdin.vartan")
+		err = os.WriteFile(grmPath, src, 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	sourceName := "stdin"
+	if len(args) > 0 && args[0] != stdioPath {
+		sourceName = grmPath
+	}
+	_, err := compileGrammar(grmPath, sourceName)
+	return err
+}
+
+// compileGrammar compiles a grammar and writes the outputs the flags specify. It returns the compiled grammar.
+// `sourceName` is a name of the grammar that diagnostics show.
+func compileGrammar(grmPath string, sourceName string) (*spec.CompiledGrammar, error) {
+	opts := []grammar.BuildOption{
+		grammar.DetectDuplicateAlternativesBy(grammar.DuplicateAlternativePolicy(*compileFlags.dupAltPolicy)),
+		grammar.TreatUnusedSymbolsAs(grammar.Severity(*compileFlags.wUnused)),
+		grammar.TreatUnusedAnnotationsAs(grammar.Severity(*compileFlags.wUnusedAnnot)),
+		grammar.TreatRightRecursiveListsAs(grammar.Severity(*compileFlags.wRightRec)),
+		grammar.TreatUnreachableTerminalsAs(grammar.Severity(*compileFlags.wUnreachable)),
+		grammar.CompressLexerTablesBy(grammar.LexerTable(*compileFlags.lexerTable)),
+	}
+	if *compileFlags.lazyLexer {
+		opts = append(opts, grammar.BuildLexerLazily())
+	}
+	gram, report, err := readGrammarAs(grmPath, sourceName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if *compileFlags.embedSource {
+		src, err := os.ReadFile(grmPath)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the grammar file %s: %w", grmPath, err)
+		}
+		gram.BuildInfo = &spec.BuildInfo{
+			Source:        string(src),
+			VartanVersion: vartanVersion(),
+			Options:       compileOptions(),
+		}
+		report.BuildInfo = gram.BuildInfo
+	}
+
+	err = writeCompiledGrammarAndReport(gram, report, *compileFlags.output)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot write an output files: %w", err)
+	}
+
+	if *compileFlags.constOut != "" {
+		err := writeConstants(gram, *compileFlags.constOut, *compileFlags.constPkgName)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot write a constants file: %w", err)
+		}
+	}
+
+	var implicitlyResolvedCount int
+	for _, s := range report.States {
+		for _, c := range s.SRConflict {
+			if c.ResolvedBy == grammar.ResolvedByShift.Int() {
+				implicitlyResolvedCount++
+				if jsonDiagnostics() {
+					addWarning(&verr.Diagnostic{
+						Message: fmt.Sprintf("shift/reduce conflict on %v in state %v; resolved by shift", report.Terminals[c.Symbol].Name, s.Number),
+						File:    sourceName,
+					})
+				}
+			}
+		}
+		for _, c := range s.RRConflict {
+			if c.ResolvedBy == grammar.ResolvedByProdOrder.Int() {
+				implicitlyResolvedCount++
+				if jsonDiagnostics() {
+					addWarning(&verr.Diagnostic{
+						Message: fmt.Sprintf("reduce/reduce conflict on %v in state %v between productions %v and %v; resolved by production order (adopted %v)", report.Terminals[c.Symbol].Name, s.Number, c.Production1, c.Production2, c.AdoptedProduction),
+						File:    sourceName,
+					})
+				}
+			}
+		}
+	}
+	// When the compiled grammar goes to stdout, messages go to stderr so that they don't mix with the grammar.
+	msgW := os.Stdout
+	if *compileFlags.output == "" || *compileFlags.output == stdioPath {
+		msgW = os.Stderr
+	}
+	if implicitlyResolvedCount > 0 && !jsonDiagnostics() {
+		fmt.Fprintf(msgW, "%v conflicts\n", implicitlyResolvedCount)
+	}
+
+	if *compileFlags.verbose && report.Lexical != nil {
+		printLexTableSizes(os.Stderr, report.Lexical, grammar.LexerTable(*compileFlags.lexerTable))
+	}
+
+	// A terminal that never matches is a common silent bug, so warn about it.
+	if report.Lexical != nil {
+		for _, mode := range report.Lexical.Modes {
+			for _, kind := range mode.Kinds {
+				if kind.AcceptingStateCount > 0 {
+					continue
+				}
+				msg := fmt.Sprintf("terminal %v never matches in %v mode because %v always %v", kind.Name, mode.Name, strings.Join(kind.ShadowedBy, ", "), winVerb(kind.ShadowedBy))
+				if jsonDiagnostics() {
+					addWarning(&verr.Diagnostic{
+						Message: msg,
+						File:    sourceName,
+					})
+				} else {
+					fmt.Fprintf(msgW, "warning: %v\n", msg)
+				}
+			}
+			// Unbounded backtracking makes tokenizing take quadratic time, so warn about it.
+			for _, b := range mode.Backtracks {
+				if b.MaxRescan >= 0 {
+					continue
+				}
+				msg := fmt.Sprintf("in %v mode, %v", mode.Name, describeBacktrack(b))
+				if jsonDiagnostics() {
+					addWarning(&verr.Diagnostic{
+						Message: msg,
+						File:    sourceName,
+					})
+				} else {
+					fmt.Fprintf(msgW, "warning: %v\n", msg)
+				}
+			}
+		}
+	}
+
+	return gram, nil
+}
+
+// compileOptions returns the options that affect a compiled grammar in the form of command-line options. It includes
+// the options a user didn't specify so that the result describes the compilation completely.
+func compileOptions() []string {
+	opts := []string{
+		fmt.Sprintf("--duplicate-alternatives=%v", *compileFlags.dupAltPolicy),
+		fmt.Sprintf("--Wunused=%v", *compileFlags.wUnused),
+		fmt.Sprintf("--Wunused-annotations=%v", *compileFlags.wUnusedAnnot),
+		fmt.Sprintf("--Wright-recursion=%v", *compileFlags.wRightRec),
+		fmt.Sprintf("--Wunreachable-terminals=%v", *compileFlags.wUnreachable),
+		fmt.Sprintf("--lexer-table=%v", *compileFlags.lexerTable),
+	}
+	if *compileFlags.lazyLexer {
+		opts = append(opts, "--lazy-lexer")
+	}
+	return opts
+}
+
+// vartanVersion returns the module version vartan was built from. It is "(devel)" when vartan was built from
+// a source tree.
+func vartanVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" {
+		return "(devel)"
+	}
+	return info.Main.Version
+}
+
+// printLexTableSizes prints the number of integers the transition table of each lex mode consists of in each
+// compression scheme so that users can choose a scheme by --lexer-table.
+func printLexTableSizes(w io.Writer, report *spec.LexicalReport, inUse grammar.LexerTable) {
+	for _, mode := range report.Modes {
+		if mode.TableSizes == nil {
+			fmt.Fprintf(w, "lexer table of %v mode: built at run time\n", mode.Name)
+			continue
+		}
+		fmt.Fprintf(w, "lexer table of %v mode (%v states, in integers):\n", mode.Name, mode.StateCount)
+		for _, s := range []struct {
+			name  string
+			size  int
+			table grammar.LexerTable
+		}{
+			{name: "uncompressed", size: mode.TableSizes.Uncompressed},
+			{name: "unique entries", size: mode.TableSizes.UniqueEntries},
+			{name: "row-displacement", size: mode.TableSizes.RowDisplacement, table: grammar.LexerTableRowDisplacement},
+			{name: "base-check", size: mode.TableSizes.BaseCheck, table: grammar.LexerTableBaseCheck},
+		} {
+			mark := ""
+			if s.table == inUse {
+				mark = " (in use)"
+			}
+			fmt.Fprintf(w, "  %-16v %10v%v\n", s.name, s.size, mark)
+		}
+	}
+}
+
+func readGrammar(path string, opts ...grammar.BuildOption) (*spec.CompiledGrammar, *spec.Report, error) {
+	return readGrammarAs(path, sourceNameOf(path), opts...)
+}
+
+// readGrammarAs reads and builds a grammar like readGrammar, and it prints warnings of the grammar as the ones of
+// a source named `sourceName`.
+func readGrammarAs(path string, sourceName string, opts ...grammar.BuildOption) (*spec.CompiledGrammar, *spec.Report, error) {
+	f, err := openInput(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Cannot open the grammar file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	ast, err := parser.Parse(f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, report, err := b.Build(append(opts, grammar.EnableReporting(), grammar.SourceName(sourceName))...)
+	for _, w := range b.Warnings() {
+		w.FilePath = path
+		w.SourceName = sourceName
+		reportSpecWarning(w)
+	}
+	return cg, report, err
+}
+
+// writeConstants writes constants of a compiled grammar to a file. When the file has the .json extension, this
+// function writes them in JSON. Otherwise, it writes Go constants, and when pkgName is empty, it uses the name of the
+// directory containing the file as the package name.
+func writeConstants(cgram *spec.CompiledGrammar, path string, pkgName string) error {
+	if filepath.Ext(path) == ".json" {
+		src, err := driver.GenConstantsJSON(cgram)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, src, 0644)
+	}
+
+	if pkgName == "" {
+		var err error
+		pkgName, err = constPackageName(path, "--const-package")
+		if err != nil {
+			return err
+		}
+	}
+
+	src, err := driver.GenConstants(cgram, pkgName)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, src, 0644)
+}
+
+// constPackageName returns the name of the directory containing a file as a package name. flag is the option that
+// the error message suggests.
+func constPackageName(path string, flag string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	pkgName := filepath.Base(filepath.Dir(absPath))
+	if !token.IsIdentifier(pkgName) {
+		return "", fmt.Errorf("the directory name cannot be a package name: %v; please use %v", pkgName, flag)
+	}
+	return pkgName, nil
+}
+
+// writeCompiledGrammarAndReport writes a compiled grammar and a report to a files located at a specified path.
+// This function selects one of the following output methods depending on how the path is specified.
+//
+//  1. When the path is a directory path, this function writes the compiled grammar and the report to
+//     <path>/<grammar-name>.json and <path>/<grammar-name>-report.json files, respectively.
+//     <grammar-name>-report.json as the output files.
+//  2. When the path is a file path or a non-exitent path, this function asumes that the path represents a file
+//     path for the compiled grammar. Then it also writes the report in the same directory as the compiled grammar.
+//     The report file is named <grammar-name>.json.
+//  3. When the path is an empty string or stdioPath, this function writes the compiled grammar to the stdout and writes
+//     the report to a file named <current-directory>/<grammar-name>-report.json.
+func writeCompiledGrammarAndReport(cgram *spec.CompiledGrammar, report *spec.Report, path string) error {
+	cgramPath, reportPath, err := makeOutputFilePaths(cgram.Name, path)
+	if err != nil {
+		return err
+	}
+
+	{
+		var cgramW io.Writer
+		if cgramPath != "" {
+			cgramFile, err := os.OpenFile(cgramPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			defer cgramFile.Close()
+			cgramW = cgramFile
+		} else {
+			cgramW = os.Stdout
+		}
+
+		b, err := json.Marshal(cgram)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cgramW, "%v\n", string(b))
+	}
+
+	{
+		reportFile, err := os.OpenFile(reportPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		defer reportFile.Close()
+
+		b, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(reportFile, "%v\n", string(b))
+	}
+
+	return nil
+}
+
+func makeOutputFilePaths(gramName string, path string) (string, string, error) {
+	reportFileName := gramName + "-report.json"
+
+	if path == "" || path == stdioPath {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", "", err
+		}
+		return "", filepath.Join(wd, reportFileName), nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", err
+	}
+	if os.IsNotExist(err) || !fi.IsDir() {
+		dir, _ := filepath.Split(path)
+		return path, filepath.Join(dir, reportFileName), nil
+	}
+
+	return filepath.Join(path, gramName+".json"), filepath.Join(path, reportFileName), nil
+}
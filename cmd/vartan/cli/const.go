@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/spf13/cobra"
+)
+
+var constFlags = struct {
+	format  *string
+	pkgName *string
+	output  *string
+}{}
+
+func newConstCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "const <compiled grammar file path>",
+		Short: "Generate constants of mode IDs, kind IDs, terminals, and productions from a compiled grammar",
+		Long: `const generates constants of mode IDs, kind IDs, terminal numbers, and production numbers of a compiled grammar
+in Go or JSON, the same as 'vartan compile --const-out' does.`,
+		Example: `  vartan const grammar.json -o exprconst/consts.go
+  vartan const grammar.json --format json`,
+		Args: cobra.ExactArgs(1),
+		RunE: runConst,
+	}
+	constFlags.format = cmd.Flags().String("format", "", "output format: go or json (default json when the output file path ends with .json, otherwise go)")
+	constFlags.pkgName = cmd.Flags().String("package", "", "package name of Go constants (default the name of the directory containing the output file)")
+	constFlags.output = cmd.Flags().StringP("output", "o", "", "output file path (default stdout)")
+	return cmd
+}
+
+func runConst(cmd *cobra.Command, args []string) error {
+	format := *constFlags.format
+	if format == "" {
+		format = "go"
+		if filepath.Ext(*constFlags.output) == ".json" {
+			format = "json"
+		}
+	}
+	if format != "go" && format != "json" {
+		return fmt.Errorf("invalid format: %v; it must be go or json", format)
+	}
+
+	cg, err := readCompiledGrammar(args[0])
+	if err != nil {
+		return fmt.Errorf("Cannot read a compiled grammar: %w", err)
+	}
+
+	var src []byte
+	if format == "json" {
+		src, err = driver.GenConstantsJSON(cg)
+	} else {
+		pkgName := *constFlags.pkgName
+		if pkgName == "" {
+			if *constFlags.output == "" || *constFlags.output == stdioPath {
+				return fmt.Errorf("please use --package to write Go constants to stdout")
+			}
+			pkgName, err = constPackageName(*constFlags.output, "--package")
+			if err != nil {
+				return err
+			}
+		}
+		src, err = driver.GenConstants(cg, pkgName)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *constFlags.output == "" || *constFlags.output == stdioPath {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*constFlags.output, src, 0644)
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	driver "github.com/nihei9/vartan/driver/parser"
+	verr "github.com/nihei9/vartan/error"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/spf13/cobra"
+)
+
+const (
+	diagnosticsFormatText = "text"
+	diagnosticsFormatJSON = "json"
+)
+
+var diagnosticsFormat *string
+
+func init() {
+	diagnosticsFormat = rootCmd.PersistentFlags().String("diagnostics", diagnosticsFormatText, "format of errors and warnings: one of text|json; json diagnostics are written to stderr")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if *diagnosticsFormat != diagnosticsFormatText && *diagnosticsFormat != diagnosticsFormatJSON {
+			return fmt.Errorf("invalid diagnostics format: %v", *diagnosticsFormat)
+		}
+		return nil
+	}
+}
+
+// pendingDiagnostics holds diagnostics that flushDiagnostics hasn't written yet.
+var pendingDiagnostics []*verr.Diagnostic
+
+func jsonDiagnostics() bool {
+	return *diagnosticsFormat == diagnosticsFormatJSON
+}
+
+// summaryError is an error summarizing errors that have been reported already. The JSON format omits it.
+type summaryError string
+
+func (e summaryError) Error() string {
+	return string(e)
+}
+
+// sourceError is an error about a source file. Its message is the same as the wrapped error, and the JSON format
+// records the file name in the diagnostics of the error.
+type sourceError struct {
+	file string
+	err  error
+}
+
+func (e *sourceError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sourceError) Unwrap() error {
+	return e.err
+}
+
+// syntaxErrors is an error consisting of syntax errors in a source. limit is not nil when the parser stopped parsing
+// because the syntax errors exceeded the maximum count.
+type syntaxErrors struct {
+	cg    *spec.CompiledGrammar
+	errs  []*driver.SyntaxError
+	limit *driver.LimitError
+}
+
+func (e *syntaxErrors) Error() string {
+	var b strings.Builder
+	writeSyntaxErrorMessage(&b, e.cg, e.errs[0])
+	for _, synErr := range e.errs[1:] {
+		fmt.Fprintf(&b, "\n")
+		writeSyntaxErrorMessage(&b, e.cg, synErr)
+	}
+	if e.limit != nil {
+		fmt.Fprintf(&b, "\n%v", e.limit)
+	}
+	return b.String()
+}
+
+// reportError prints an error. When `file` isn't empty, the error is about the file. In the JSON format, this function
+// keeps diagnostics of the error until flushDiagnostics writes them.
+func reportError(file string, err error) {
+	if !jsonDiagnostics() {
+		if file != "" {
+			fmt.Fprintf(os.Stderr, "%v: %v\n", file, err)
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return
+	}
+	pendingDiagnostics = append(pendingDiagnostics, toDiagnostics(file, err)...)
+}
+
+// addWarning keeps a warning until flushDiagnostics writes it. Only the JSON format prints warnings one by one, so
+// callers need to print a text form of warnings by themselves.
+func addWarning(d *verr.Diagnostic) {
+	d.Severity = verr.SeverityWarning
+	pendingDiagnostics = append(pendingDiagnostics, d)
+}
+
+// reportSpecWarning prints a warning in a grammar. The text format prints it to stderr immediately.
+func reportSpecWarning(w *verr.SpecError) {
+	if !jsonDiagnostics() {
+		fmt.Fprintln(os.Stderr, w)
+		return
+	}
+	addWarning(w.Diagnostic())
+}
+
+// flushDiagnostics writes diagnostics that reportError and reportWarning kept. In the text format, it writes nothing.
+func flushDiagnostics(w io.Writer) error {
+	if !jsonDiagnostics() {
+		return nil
+	}
+	diags := pendingDiagnostics
+	pendingDiagnostics = nil
+	return verr.WriteDiagnostics(w, diags)
+}
+
+func toDiagnostics(file string, err error) []*verr.Diagnostic {
+	var diags []*verr.Diagnostic
+	switch e := err.(type) {
+	case summaryError:
+		return nil
+	case *sourceError:
+		if file == "" {
+			file = e.file
+		}
+		return toDiagnostics(file, e.err)
+	case verr.SpecErrors:
+		diags = e.Diagnostics()
+	case *verr.SpecError:
+		diags = []*verr.Diagnostic{e.Diagnostic()}
+	case *syntaxErrors:
+		for _, synErr := range e.errs {
+			diags = append(diags, syntaxErrorToDiagnostic(e.cg, synErr))
+		}
+		if e.limit != nil {
+			diags = append(diags, &verr.Diagnostic{
+				Severity: verr.SeverityError,
+				Message:  fmt.Sprintf("the input exceeds the maximum %v: %v", e.limit.Limit, e.limit.Max),
+				Row:      e.limit.Row + 1,
+				Col:      e.limit.Col + 1,
+				EndRow:   e.limit.Row + 1,
+				EndCol:   e.limit.Col + 1,
+			})
+		}
+	default:
+		diags = []*verr.Diagnostic{
+			{
+				Severity: verr.SeverityError,
+				Message:  err.Error(),
+			},
+		}
+	}
+	for _, d := range diags {
+		if d.File == "" {
+			d.File = file
+		}
+		for _, r := range d.Related {
+			if r.File == "" {
+				r.File = file
+			}
+		}
+	}
+	return diags
+}
+
+func syntaxErrorToDiagnostic(cg *spec.CompiledGrammar, synErr *driver.SyntaxError) *verr.Diagnostic {
+	var b strings.Builder
+	writeSyntaxErrorDetail(&b, cg, synErr)
+
+	// The driver counts rows and columns from 0, but diagnostics count them from 1.
+	row := synErr.Row + 1
+	col := synErr.Col + 1
+	endRow := row
+	endCol := col
+	if tok := synErr.Token; !tok.EOF() {
+		// Move the end position to the last character of the lexeme.
+		afterNewline := false
+		for i, c := range []rune(string(tok.Lexeme())) {
+			switch {
+			case i == 0:
+			case afterNewline:
+				endRow++
+				endCol = 1
+			default:
+				endCol++
+			}
+			afterNewline = c == '\n'
+		}
+	}
+	return &verr.Diagnostic{
+		Severity: verr.SeverityError,
+		Message:  b.String(),
+		Row:      row,
+		Col:      col,
+		EndRow:   endRow,
+		EndCol:   endCol,
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToDiagnostics_SourceError(t *testing.T) {
+	tests := []struct {
+		caption  string
+		file     string
+		err      error
+		expected string
+	}{
+		{
+			caption: "a source error records its file",
+			err: &sourceError{
+				file: "src",
+				err:  errors.New("an error"),
+			},
+			expected: "src",
+		},
+		{
+			caption: "stdin is recorded as -",
+			err: &sourceError{
+				file: stdioPath,
+				err:  errors.New("an error"),
+			},
+			expected: "-",
+		},
+		{
+			caption: "a file given by the caller takes precedence",
+			file:    "other",
+			err: &sourceError{
+				file: "src",
+				err:  errors.New("an error"),
+			},
+			expected: "other",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			diags := toDiagnostics(tt.file, tt.err)
+			if len(diags) != 1 {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if diags[0].File != tt.expected {
+				t.Fatalf("unexpected file; want: %v, got: %v", tt.expected, diags[0].File)
+			}
+			if diags[0].Message != "an error" {
+				t.Fatalf("unexpected message: %v", diags[0].Message)
+			}
+		})
+	}
+}
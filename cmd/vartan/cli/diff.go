@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/nihei9/vartan/sentence"
+	"github.com/spf13/cobra"
+)
+
+var diffFlags = struct {
+	seed     *int64
+	maxDepth *int
+	samples  *int
+}{}
+
+func newDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <old grammar file path> <new grammar file path>",
+		Short: "Search for an input that distinguishes two grammars",
+		Long: `diff searches for a short input that one grammar accepts and the other doesn't,
+or that the grammars parse into different syntax trees. Because diff samples inputs randomly,
+finding no difference doesn't prove the grammars are equivalent.`,
+		Example: `  vartan diff old.vartan new.vartan --max-depth 8 --samples 500`,
+		Args:    cobra.ExactArgs(2),
+		RunE:    runDiff,
+	}
+	diffFlags.seed = cmd.Flags().Int64("seed", 0, "seed of the random number generator (default the current time)")
+	diffFlags.maxDepth = cmd.Flags().Int("max-depth", 10, "max depth of derivation trees of inputs")
+	diffFlags.samples = cmd.Flags().Int("samples", 100, "number of inputs each grammar generates per depth")
+	return cmd
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	seed := *diffFlags.seed
+	if !cmd.Flags().Changed("seed") {
+		seed = time.Now().UnixNano()
+	}
+
+	var gens [2]*sentence.Generator
+	for i, path := range args {
+		cg, report, err := readGrammar(path)
+		if err != nil {
+			return fmt.Errorf("Cannot read a grammar: %v: %w", path, err)
+		}
+		gens[i], err = sentence.NewGenerator(cg, report,
+			sentence.Seed(seed),
+			sentence.MaxDepth(*diffFlags.maxDepth),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	d, err := sentence.Diff(gens[0], gens[1], *diffFlags.samples)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		fmt.Fprintln(os.Stdout, "No difference found")
+		return nil
+	}
+
+	switch d.Kind {
+	case sentence.DifferenceKindOnlyOldAccepts:
+		fmt.Fprintf(os.Stdout, "Only %v accepts the input:\n", args[0])
+	case sentence.DifferenceKindOnlyNewAccepts:
+		fmt.Fprintf(os.Stdout, "Only %v accepts the input:\n", args[1])
+	case sentence.DifferenceKindTreesDiffer:
+		fmt.Fprintln(os.Stdout, "The grammars parse the input into different trees:")
+	}
+	fmt.Fprintf(os.Stdout, "%s\n", d.Input)
+	if d.OldTree != nil {
+		fmt.Fprintf(os.Stdout, "\n%v:\n", args[0])
+		driver.PrintTree(os.Stdout, d.OldTree)
+	}
+	if d.NewTree != nil {
+		fmt.Fprintf(os.Stdout, "\n%v:\n", args[1])
+		driver.PrintTree(os.Stdout, d.NewTree)
+	}
+
+	return errors.New("The grammars differ")
+}
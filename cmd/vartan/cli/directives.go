@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/spf13/cobra"
+)
+
+var directivesFlags = struct {
+	json *bool
+}{}
+
+func newDirectivesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "directives",
+		Short:   "Print directives available in grammars",
+		Example: `  vartan directives --json`,
+		Args:    cobra.NoArgs,
+		RunE:    runDirectives,
+	}
+	directivesFlags.json = cmd.Flags().Bool("json", false, "print the directives in JSON format")
+	return cmd
+}
+
+func runDirectives(cmd *cobra.Command, args []string) error {
+	dirs := grammar.Directives()
+
+	if *directivesFlags.json {
+		b, err := json.Marshal(dirs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, string(b))
+		return nil
+	}
+
+	writeDirectives(os.Stdout, dirs)
+
+	return nil
+}
+
+func writeDirectives(w io.Writer, dirs []*grammar.Directive) {
+	for i, d := range dirs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "#%v", d.Name)
+		for _, p := range d.Parameters {
+			if p.Optional {
+				fmt.Fprintf(&b, " [<%v>]", p.Name)
+			} else {
+				fmt.Fprintf(&b, " <%v>", p.Name)
+			}
+			if p.Repeatable {
+				fmt.Fprintf(&b, "...")
+			}
+		}
+		fmt.Fprintln(w, b.String())
+
+		ctxs := make([]string, len(d.Contexts))
+		for i, c := range d.Contexts {
+			ctxs[i] = string(c)
+		}
+		fmt.Fprintf(w, "    contexts: %v\n", strings.Join(ctxs, ", "))
+		for _, p := range d.Parameters {
+			tys := make([]string, len(p.Types))
+			for i, t := range p.Types {
+				tys[i] = string(t)
+			}
+			fmt.Fprintf(w, "    %v: %v\n", p.Name, strings.Join(tys, " | "))
+		}
+		fmt.Fprintf(w, "    %v\n", d.Description)
+	}
+}
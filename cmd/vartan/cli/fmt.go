@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+	"github.com/spf13/cobra"
+)
+
+var fmtFlags = struct {
+	write *bool
+	list  *bool
+}{}
+
+func newFmtCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fmt [<grammar file path>...]",
+		Short: "Format grammar files in the canonical layout",
+		Long: `fmt reprints grammars in the canonical layout, keeping comments.
+When no file is given, fmt formats a grammar read from stdin and writes it to stdout.`,
+		Example: `  vartan fmt grammar.vartan
+  vartan fmt -w grammar.vartan
+  vartan fmt -l *.vartan`,
+		RunE: runFmt,
+	}
+	fmtFlags.write = cmd.Flags().BoolP("write", "w", false, "write results to the files instead of stdout")
+	fmtFlags.list = cmd.Flags().BoolP("list", "l", false, "list the files whose formatting differs from the canonical layout instead of printing the results")
+	return cmd
+}
+
+func runFmt(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 || len(args) == 1 && args[0] == stdioPath {
+		if *fmtFlags.write || *fmtFlags.list {
+			return fmt.Errorf("--write and --list need grammar file paths")
+		}
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		formatted, err := formatGrammar(src, "", "stdin")
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(formatted)
+		return err
+	}
+
+	for _, path := range args {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Cannot read the grammar file %s: %w", path, err)
+		}
+		formatted, err := formatGrammar(src, path, path)
+		if err != nil {
+			return err
+		}
+		if !*fmtFlags.list && !*fmtFlags.write {
+			_, err := os.Stdout.Write(formatted)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if bytes.Equal(src, formatted) {
+			continue
+		}
+		if *fmtFlags.list {
+			fmt.Fprintln(os.Stdout, path)
+		}
+		if *fmtFlags.write {
+			err := os.WriteFile(path, formatted, 0644)
+			if err != nil {
+				return fmt.Errorf("Cannot write the grammar file %s: %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// formatGrammar formats a grammar source. When the source has syntax errors, the errors refer to the file `path` and
+// the source named `sourceName`.
+func formatGrammar(src []byte, path string, sourceName string) ([]byte, error) {
+	ast, err := parser.Parse(bytes.NewReader(src))
+	if err != nil {
+		if specErrs, ok := err.(verr.SpecErrors); ok {
+			for _, e := range specErrs {
+				e.FilePath = path
+				e.SourceName = sourceName
+			}
+		}
+		return nil, err
+	}
+	var b bytes.Buffer
+	err = parser.Format(&b, ast)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
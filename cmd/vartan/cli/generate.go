@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/nihei9/vartan/skeleton"
+	"github.com/spf13/cobra"
+)
+
+var generateFlags = struct {
+	template *string
+	pkgName  *string
+	output   *string
+}{}
+
+func newGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate <compiled grammar file path>",
+		Short: "Generate code from a compiled grammar using a custom template",
+		Long: `generate applies a skeleton, which is a template of Go's text/template package, to a compiled grammar and writes
+the output. Skeletons make it possible to generate parsers for other runtimes or languages. A skeleton receives
+the modes, kinds, terminal symbols, non-terminal symbols, productions, and parsing tables of the grammar; see
+the skeleton package for the data model, which is a stable contract, and the functions available in skeletons.`,
+		Example: `  vartan generate grammar.json --template parser.tmpl -o parser.py
+  vartan generate grammar.json --template parser.go.tmpl --package expr -o expr/parser.go`,
+		Args: cobra.ExactArgs(1),
+		RunE: runGenerate,
+	}
+	generateFlags.template = cmd.Flags().StringP("template", "t", "", "skeleton file path (required)")
+	generateFlags.pkgName = cmd.Flags().String("package", "", "package name a skeleton receives as .Package")
+	generateFlags.output = cmd.Flags().StringP("output", "o", "", "output file path (default stdout)")
+	return cmd
+}
+
+func runGenerate(cmd *cobra.Command, args []string) error {
+	if *generateFlags.template == "" {
+		return fmt.Errorf("please specify a skeleton using --template")
+	}
+	src, err := os.ReadFile(*generateFlags.template)
+	if err != nil {
+		return fmt.Errorf("Cannot read a skeleton: %w", err)
+	}
+	tmpl, err := skeleton.Parse(*generateFlags.template, string(src))
+	if err != nil {
+		return err
+	}
+
+	cg, err := readCompiledGrammar(args[0])
+	if err != nil {
+		return fmt.Errorf("Cannot read a compiled grammar: %w", err)
+	}
+
+	// The command writes nothing when a skeleton fails halfway.
+	var b bytes.Buffer
+	err = skeleton.Execute(&b, tmpl, cg, *generateFlags.pkgName)
+	if err != nil {
+		return err
+	}
+
+	if *generateFlags.output == "" || *generateFlags.output == stdioPath {
+		_, err = os.Stdout.Write(b.Bytes())
+		return err
+	}
+	return os.WriteFile(*generateFlags.output, b.Bytes(), 0644)
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/nihei9/vartan/sentence"
+	"github.com/spf13/cobra"
+)
+
+var generateInputFlags = struct {
+	count    *int
+	seed     *int64
+	maxDepth *int
+	weights  *map[string]int
+}{}
+
+func newGenerateInputCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate-input <grammar file path>",
+		Short: "Generate random sentences a grammar accepts",
+		Example: `  vartan generate-input grammar.vartan -n 10 --seed 1
+  vartan generate-input grammar.vartan --max-depth 5 --weight 3=10 --weight 4=0`,
+		Args: cobra.ExactArgs(1),
+		RunE: runGenerateInput,
+	}
+	generateInputFlags.count = cmd.Flags().IntP("count", "n", 1, "number of sentences to generate")
+	generateInputFlags.seed = cmd.Flags().Int64("seed", 0, "seed of the random number generator (default the current time)")
+	generateInputFlags.maxDepth = cmd.Flags().Int("max-depth", 10, "max depth of derivation trees; the generator exceeds it only when the grammar needs a deeper tree")
+	generateInputFlags.weights = cmd.Flags().StringToInt("weight", nil, "weight of a production in the form <production number>=<weight> (default 1); see the report of the grammar for the production numbers")
+	return cmd
+}
+
+func runGenerateInput(cmd *cobra.Command, args []string) error {
+	if *generateInputFlags.count < 0 {
+		return fmt.Errorf("--count must be greater than or equal to 0: %v", *generateInputFlags.count)
+	}
+
+	cg, report, err := readGrammar(args[0])
+	if err != nil {
+		return err
+	}
+
+	seed := *generateInputFlags.seed
+	if !cmd.Flags().Changed("seed") {
+		seed = time.Now().UnixNano()
+	}
+	weights := map[int]int{}
+	for k, w := range *generateInputFlags.weights {
+		prod, err := strconv.Atoi(k)
+		if err != nil {
+			return fmt.Errorf("invalid production number: %v", k)
+		}
+		weights[prod] = w
+	}
+
+	g, err := sentence.NewGenerator(cg, report,
+		sentence.Seed(seed),
+		sentence.MaxDepth(*generateInputFlags.maxDepth),
+		sentence.Weights(weights),
+	)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < *generateInputFlags.count; i++ {
+		src, err := g.Generate()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, string(src))
+	}
+
+	return nil
+}
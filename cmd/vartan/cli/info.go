@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/spf13/cobra"
+)
+
+var infoFlags = struct {
+	source *bool
+}{}
+
+func newInfoCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info <grammar file path>",
+		Short: "Print the name and metadata of a compiled grammar",
+		Example: `  vartan info grammar.json
+  vartan info --source grammar.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: runInfo,
+	}
+	infoFlags.source = cmd.Flags().Bool("source", false, "print the grammar source embedded by 'vartan compile --embed-source'")
+	return cmd
+}
+
+func runInfo(cmd *cobra.Command, args []string) error {
+	cg, err := readCompiledGrammar(args[0])
+	if err != nil {
+		return fmt.Errorf("Cannot read a compiled grammar: %w", err)
+	}
+
+	if *infoFlags.source {
+		if cg.BuildInfo == nil || cg.BuildInfo.Source == "" {
+			return fmt.Errorf("%v has no grammar source; compile the grammar with --embed-source", args[0])
+		}
+		_, err := io.WriteString(os.Stdout, cg.BuildInfo.Source)
+		return err
+	}
+
+	writeInfo(os.Stdout, cg)
+
+	return nil
+}
+
+func writeInfo(w io.Writer, cg *spec.CompiledGrammar) {
+	fmt.Fprintf(w, "name: %v\n", cg.Name)
+	fmt.Fprintf(w, "format version: %v\n", cg.FormatVersion)
+	if cg.Hash != "" {
+		fmt.Fprintf(w, "hash: %v\n", cg.Hash)
+	}
+	fmt.Fprint(w, formatMetadata(cg.Metadata))
+	if cg.Lexical != nil {
+		fmt.Fprintf(w, "modes: %v\n", len(cg.Lexical.ModeNames)-1)
+	}
+	if info := cg.BuildInfo; info != nil {
+		if info.VartanVersion != "" {
+			fmt.Fprintf(w, "compiled by: vartan %v\n", info.VartanVersion)
+		}
+		if len(info.Options) > 0 {
+			fmt.Fprintf(w, "compile options: %v\n", strings.Join(info.Options, " "))
+		}
+		if info.Source != "" {
+			fmt.Fprintf(w, "source: embedded (%v bytes)\n", len(info.Source))
+		}
+	}
+	if cg.Syntactic != nil {
+		// The counts exclude the nil symbol, EOF, and the augmented start symbol because a user doesn't define them.
+		fmt.Fprintf(w, "terminals: %v\n", cg.Syntactic.TerminalCount-2)
+		fmt.Fprintf(w, "non-terminals: %v\n", cg.Syntactic.NonTerminalCount-2)
+		fmt.Fprintf(w, "states: %v\n", cg.Syntactic.StateCount)
+	}
+}
+
+// formatMetadata returns lines of key/value pairs of metadata. When metadata has no value, formatMetadata returns
+// an empty string.
+func formatMetadata(meta *spec.Metadata) string {
+	if meta == nil {
+		return ""
+	}
+	var b strings.Builder
+	if meta.Author != "" {
+		fmt.Fprintf(&b, "author: %v\n", meta.Author)
+	}
+	if meta.License != "" {
+		fmt.Fprintf(&b, "license: %v\n", meta.License)
+	}
+	if meta.Version != "" {
+		fmt.Fprintf(&b, "version: %v\n", meta.Version)
+	}
+	return b.String()
+}
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	"github.com/nihei9/vartan/normalizer"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/spf13/cobra"
+)
+
+var lexFlags = struct {
+	format     *string
+	ignoreCase *bool
+	tabWidth   *int
+	columnUnit *string
+}{}
+
+// columnUnits maps the values of --column-unit option to the units the lexer counts columns in.
+var columnUnits = map[string]lexer.ColumnUnit{
+	"codepoint": lexer.ColumnUnitCodePoint,
+	"byte":      lexer.ColumnUnitByte,
+	"utf16":     lexer.ColumnUnitUTF16,
+	"grapheme":  lexer.ColumnUnitGrapheme,
+}
+
+func newLexCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lex <grammar file path> [<source file path>]",
+		Short: "Tokenize a text stream",
+		Long: `lex tokenizes a source with the lexer of a compiled grammar and prints the tokens. It reads the source from stdin
+when no source file is specified. Each token has its kind, its lexeme, its position, the lex mode the lexer recognized
+it in, and the class a #class directive assigns to its kind. Positions count from 1, and the end position points to
+the last character of a token.
+The lexer runs without the parser, so lex doesn't show the mode transitions that #push and #pop directives of
+alternatives perform and the tokens that #layout directive synthesizes. When the kind of a token has transformations
+such as #trim, lex also prints the value of the token after the transformations. When the pattern of the kind has
+capture groups such as (?<int>[0-9]+), lex also prints the sub-spans of the lexeme that the groups match.`,
+		Example: `  vartan lex grammar.json src
+  cat src | vartan lex grammar.json --format json`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runLex,
+	}
+	lexFlags.format = cmd.Flags().StringP("format", "f", "text", "output format: one of text|json")
+	lexFlags.ignoreCase = cmd.Flags().Bool("ignore-case", false, "match case-configurable terminals case-insensitively")
+	lexFlags.tabWidth = cmd.Flags().Int("tab-width", 0, "width of tab stops used to count columns (default a tab occupies one column)")
+	lexFlags.columnUnit = cmd.Flags().String("column-unit", "codepoint", "unit to count columns in: one of codepoint|byte|utf16|grapheme")
+	return cmd
+}
+
+// lexToken is the JSON form of a token that `vartan lex` prints.
+type lexToken struct {
+	Kind    string `json:"kind"`
+	KindID  int    `json:"kind_id"`
+	Class   string `json:"class,omitempty"`
+	Mode    string `json:"mode"`
+	Lexeme  string `json:"lexeme"`
+	Row     int    `json:"row"`
+	Col     int    `json:"col"`
+	EndRow  int    `json:"end_row"`
+	EndCol  int    `json:"end_col"`
+	BytePos int    `json:"byte_pos"`
+	ByteLen int    `json:"byte_len"`
+	Invalid bool   `json:"invalid,omitempty"`
+
+	// Value is the value of a token after the transformations of its kind. It is nil when the kind has no
+	// transformation.
+	Value *string `json:"value,omitempty"`
+
+	// Captures holds the sub-spans of the lexeme that the capture groups of the pattern match.
+	Captures []*lexCapture `json:"captures,omitempty"`
+}
+
+// lexCapture is the JSON form of a sub-span of a lexeme that a capture group matches. BytePos is relative to the start
+// of the lexeme, and it is -1 when the group matches no bytes.
+type lexCapture struct {
+	Name    string `json:"name"`
+	Text    string `json:"text"`
+	BytePos int    `json:"byte_pos"`
+	ByteLen int    `json:"byte_len"`
+}
+
+func runLex(cmd *cobra.Command, args []string) error {
+	if *lexFlags.format != outputFormatText && *lexFlags.format != outputFormatJSON {
+		return fmt.Errorf("invalid output format: %v", *lexFlags.format)
+	}
+	if *lexFlags.tabWidth < 0 {
+		return fmt.Errorf("--tab-width must be greater than or equal to 0: %v", *lexFlags.tabWidth)
+	}
+	if _, ok := columnUnits[*lexFlags.columnUnit]; !ok {
+		return fmt.Errorf("invalid column unit: %v", *lexFlags.columnUnit)
+	}
+	srcPath := stdioPath
+	if len(args) > 1 {
+		srcPath = args[1]
+	}
+	if args[0] == stdioPath && srcPath == stdioPath {
+		return fmt.Errorf("only one of the grammar and the source can be read from stdin")
+	}
+
+	cg, err := readCompiledGrammar(args[0])
+	if err != nil {
+		return fmt.Errorf("Cannot read a compiled grammar: %w", err)
+	}
+
+	src, err := openInput(srcPath)
+	if err != nil {
+		return fmt.Errorf("Cannot open the source file %s: %w", srcPath, err)
+	}
+	defer src.Close()
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	invalid, err := writeTokens(w, cg, src)
+	if err != nil {
+		return err
+	}
+	if invalid > 0 {
+		return summaryError(fmt.Sprintf("invalid tokens: %v", invalid))
+	}
+	return nil
+}
+
+// writeTokens tokenizes a source and writes the tokens in the format of `vartan lex`. It returns the number of invalid
+// tokens.
+func writeTokens(w io.Writer, cg *spec.CompiledGrammar, src io.Reader) (int, error) {
+	var opts []lexer.LexerOption
+	if *lexFlags.ignoreCase {
+		opts = append(opts, lexer.DisableCaseSensitivity())
+	}
+	if *lexFlags.tabWidth > 0 {
+		opts = append(opts, lexer.TabWidth(*lexFlags.tabWidth))
+	}
+	opts = append(opts, lexer.CountColumnsIn(columnUnits[*lexFlags.columnUnit]), lexer.NormalizeWith(normalizer.Default))
+	lexSpec := lexer.NewLexSpec(cg.Lexical)
+	lex, err := lexer.NewLexer(lexSpec, src, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	invalid := 0
+	for {
+		tok, err := lex.Next()
+		if err != nil {
+			return invalid, err
+		}
+		if tok.EOF {
+			return invalid, nil
+		}
+
+		t := &lexToken{
+			Mode:    lexSpec.ModeName(tok.ModeID),
+			Lexeme:  string(tok.Lexeme),
+			Row:     tok.Row + 1,
+			Col:     tok.Col + 1,
+			EndRow:  tok.EndRow + 1,
+			EndCol:  tok.EndCol + 1,
+			BytePos: tok.BytePos,
+			ByteLen: tok.ByteLen,
+			Invalid: tok.Invalid,
+		}
+		if tok.Invalid {
+			t.Kind = "<invalid>"
+			invalid++
+		} else {
+			kindID, kindName := lexSpec.KindIDAndName(tok.ModeID, tok.ModeKindID)
+			t.Kind = kindName
+			t.KindID = kindID.Int()
+			t.Class = lexSpec.KindClass(kindID)
+			if len(lexSpec.Transformations(kindID)) > 0 {
+				v := string(tok.Value)
+				t.Value = &v
+			}
+			for _, c := range tok.Captures {
+				text, _ := tok.Capture(c.Name)
+				t.Captures = append(t.Captures, &lexCapture{
+					Name:    c.Name,
+					Text:    string(text),
+					BytePos: c.Pos,
+					ByteLen: c.Len,
+				})
+			}
+		}
+
+		if *lexFlags.format == outputFormatJSON {
+			err = enc.Encode(t)
+		} else {
+			err = writeLexToken(w, t)
+		}
+		if err != nil {
+			return invalid, err
+		}
+	}
+}
+
+func writeLexToken(w io.Writer, t *lexToken) error {
+	kind := t.Kind
+	if t.Class != "" {
+		kind = fmt.Sprintf("%v (%v)", t.Kind, t.Class)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v:%v-%v:%v %v %v %q", t.Row, t.Col, t.EndRow, t.EndCol, t.Mode, kind, t.Lexeme)
+	if t.Value != nil {
+		fmt.Fprintf(&b, " -> %q", *t.Value)
+	}
+	for _, c := range t.Captures {
+		fmt.Fprintf(&b, " %v=%q", c.Name, c.Text)
+	}
+	_, err := fmt.Fprintln(w, b.String())
+	return err
+}
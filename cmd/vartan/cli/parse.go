@@ -0,0 +1,463 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/nihei9/vartan/driver/parser/pretty"
+	"github.com/nihei9/vartan/normalizer"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/tester"
+	"github.com/spf13/cobra"
+)
+
+var parseFlags = struct {
+	source     *string
+	onlyParse  *bool
+	cst        *bool
+	disableLAC *bool
+	format     *string
+	ignoreCase *bool
+	jobs       *int
+	maxDepth   *int
+	maxTokens  *int
+	maxErrors  *int
+	depth      *int
+	noText     *bool
+	color      *bool
+	resilient  *bool
+	sync       *[]string
+	start      *string
+	tabWidth   *int
+	trace      *string
+}{}
+
+const (
+	outputFormatText = "text"
+	outputFormatTree = "tree"
+	outputFormatJSON = "json"
+)
+
+func newParseCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "parse <grammar file path> [<source file path>...]",
+		Short: "Parse a text stream",
+		Long: `parse parses sources with a compiled grammar and prints their syntax trees. It reads a source from stdin when
+no source file is specified. '-' as a file path means stdin, so you can pipe a compiled grammar into parse.`,
+		Example: `  cat src | vartan parse grammar.json
+  vartan parse grammar.json src1 src2 src3 --jobs 4
+  vartan compile grammar.vartan -o - | vartan parse - src
+  vartan parse grammar.json src --trace trace.jsonl`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runParse,
+	}
+	parseFlags.source = cmd.Flags().StringP("source", "s", "", "source file path (default stdin)")
+	parseFlags.jobs = cmd.Flags().IntP("jobs", "j", runtime.NumCPU(), "number of files to parse concurrently")
+	parseFlags.onlyParse = cmd.Flags().Bool("only-parse", false, "when this option is enabled, the parser performs only parse and doesn't semantic actions")
+	parseFlags.cst = cmd.Flags().Bool("cst", false, "when this option is enabled, the parser generates a CST")
+	parseFlags.disableLAC = cmd.Flags().Bool("disable-lac", false, "disable LAC (lookahead correction)")
+	parseFlags.format = cmd.Flags().StringP("format", "f", "text", "output format: one of text|tree|json")
+	parseFlags.ignoreCase = cmd.Flags().Bool("ignore-case", false, "match case-configurable terminals case-insensitively")
+	parseFlags.tabWidth = cmd.Flags().Int("tab-width", 0, "width of tab stops used to count columns, including the indentation of a grammar with #layout (default a tab occupies one column)")
+	parseFlags.start = cmd.Flags().String("start", "", "non-terminal symbol to start parsing at; it must be the start symbol or a symbol declared by #start (default the start symbol)")
+	cmd.Flags().StringVar(parseFlags.start, "rule", "", "same as --start")
+	parseFlags.resilient = cmd.Flags().Bool("resilient", false, "never give up parsing and print a syntax tree covering the whole input")
+	parseFlags.sync = cmd.Flags().StringSlice("sync", nil, "terminal symbols the parser resynchronizes on in the resilient mode (default every terminal)")
+	parseFlags.maxDepth = cmd.Flags().Int("max-stack-depth", 0, "maximum depth of the state stack; the parser stops parsing an input exceeding it (default no limit)")
+	parseFlags.maxTokens = cmd.Flags().Int("max-tokens", 0, "maximum number of tokens in an input, including skipped ones; the parser stops parsing an input exceeding it (default no limit)")
+	parseFlags.depth = cmd.Flags().Int("depth", 0, "maximum depth of a tree the text format prints; deeper nodes are elided (default no limit)")
+	parseFlags.noText = cmd.Flags().Bool("no-text", false, "omit lexemes of tokens in the text format")
+	parseFlags.color = cmd.Flags().Bool("color", false, "color a tree in the text format")
+	parseFlags.maxErrors = cmd.Flags().Int("max-errors", 0, "maximum number of syntax errors to report; the parser stops parsing an input having more (default no limit)")
+	parseFlags.trace = cmd.Flags().String("trace", "", "file path to write the actions of the parser to as JSON lines")
+	return cmd
+}
+
+func runParse(cmd *cobra.Command, args []string) error {
+	if *parseFlags.onlyParse && *parseFlags.cst {
+		return fmt.Errorf("You cannot enable --only-parse and --cst at the same time")
+	}
+	if *parseFlags.format != outputFormatText &&
+		*parseFlags.format != outputFormatTree &&
+		*parseFlags.format != outputFormatJSON {
+		return fmt.Errorf("invalid output format: %v", *parseFlags.format)
+	}
+	if len(*parseFlags.sync) > 0 && !*parseFlags.resilient {
+		return fmt.Errorf("--sync is available only with --resilient")
+	}
+	if *parseFlags.tabWidth < 0 {
+		return fmt.Errorf("--tab-width must be greater than or equal to 0: %v", *parseFlags.tabWidth)
+	}
+	if *parseFlags.maxDepth < 0 {
+		return fmt.Errorf("--max-stack-depth must be greater than or equal to 0: %v", *parseFlags.maxDepth)
+	}
+	if *parseFlags.maxTokens < 0 {
+		return fmt.Errorf("--max-tokens must be greater than or equal to 0: %v", *parseFlags.maxTokens)
+	}
+	if *parseFlags.depth < 0 {
+		return fmt.Errorf("--depth must be greater than or equal to 0: %v", *parseFlags.depth)
+	}
+	if *parseFlags.maxErrors < 0 {
+		return fmt.Errorf("--max-errors must be greater than or equal to 0: %v", *parseFlags.maxErrors)
+	}
+	if *parseFlags.jobs < 1 {
+		return fmt.Errorf("--jobs must be greater than or equal to 1: %v", *parseFlags.jobs)
+	}
+
+	srcPaths := args[1:]
+	if *parseFlags.source != "" {
+		srcPaths = append([]string{*parseFlags.source}, srcPaths...)
+	}
+	stdinCount := 0
+	for _, path := range append([]string{args[0]}, srcPaths...) {
+		if path == stdioPath {
+			stdinCount++
+		}
+	}
+	if len(srcPaths) == 0 {
+		stdinCount++
+	}
+	if stdinCount > 1 {
+		return fmt.Errorf("only one of the grammar and the sources can be read from stdin")
+	}
+	if *parseFlags.trace != "" && len(srcPaths) > 1 {
+		return fmt.Errorf("--trace can be used only when parsing one source")
+	}
+
+	cg, err := readCompiledGrammar(args[0])
+	if err != nil {
+		return fmt.Errorf("Cannot read a compiled grammar: %w", err)
+	}
+	if cg.IsLexerOnly() {
+		return fmt.Errorf("%v is a lexer-only grammar. It cannot parse a source", cg.Name)
+	}
+
+	shared, err := driver.NewSharedGrammar(cg)
+	if err != nil {
+		return err
+	}
+
+	if len(srcPaths) <= 1 {
+		name := stdioPath
+		src := io.Reader(os.Stdin)
+		if len(srcPaths) == 1 {
+			name = srcPaths[0]
+			f, err := openInput(name)
+			if err != nil {
+				return fmt.Errorf("Cannot open the source file %s: %w", name, err)
+			}
+			defer f.Close()
+			src = f
+		}
+		if *parseFlags.trace == "" {
+			err = parseSource(shared, cg, src, os.Stdout, nil)
+		} else {
+			err = parseSourceWithTrace(shared, cg, src, *parseFlags.trace)
+		}
+		if err != nil {
+			return &sourceError{
+				file: name,
+				err:  err,
+			}
+		}
+		return nil
+	}
+
+	return parseFiles(shared, cg, srcPaths, *parseFlags.jobs)
+}
+
+type parseResult struct {
+	out bytes.Buffer
+	err error
+}
+
+// parsedFile is the result of a file that the JSON format prints when parsing multiple files. Tree is null when the
+// parser constructs no tree.
+type parsedFile struct {
+	File string          `json:"file"`
+	Tree json.RawMessage `json:"tree"`
+}
+
+// parseFiles parses files concurrently using a compiled grammar shared among the workers. It prints the result of
+// each file in the order of the paths as soon as the results of all preceding files are available. The JSON format
+// prints a parsedFile per line instead of headers and trees, so that the output is a stream of JSON objects.
+func parseFiles(shared *driver.SharedGrammar, cg *spec.CompiledGrammar, paths []string, jobs int) error {
+	results := make([]chan *parseResult, len(paths))
+	for i := range results {
+		results[i] = make(chan *parseResult, 1)
+	}
+
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := range paths {
+			indexes <- i
+		}
+	}()
+	for j := 0; j < jobs; j++ {
+		go func() {
+			for i := range indexes {
+				r := &parseResult{}
+				f, err := openInput(paths[i])
+				if err != nil {
+					r.err = fmt.Errorf("Cannot open the source file %s: %w", paths[i], err)
+				} else {
+					r.err = parseSource(shared, cg, f, &r.out, nil)
+					f.Close()
+				}
+				results[i] <- r
+			}
+		}()
+	}
+
+	failed := 0
+	for i, path := range paths {
+		r := <-results[i]
+		if *parseFlags.format == outputFormatJSON {
+			b, err := json.Marshal(&parsedFile{
+				File: path,
+				Tree: bytes.TrimSpace(r.out.Bytes()),
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stdout, string(b))
+		} else {
+			if i > 0 {
+				fmt.Fprintln(os.Stdout)
+			}
+			fmt.Fprintf(os.Stdout, "==> %v <==\n", path)
+			os.Stdout.Write(r.out.Bytes())
+		}
+		if r.err != nil {
+			failed++
+			reportError(path, r.err)
+		}
+	}
+	if failed > 0 {
+		return summaryError(fmt.Sprintf("%v of %v files failed", failed, len(paths)))
+	}
+
+	return nil
+}
+
+// parseSourceWithTrace parses a source like parseSource and writes the actions of the parser to a file.
+func parseSourceWithTrace(shared *driver.SharedGrammar, cg *spec.CompiledGrammar, src io.Reader, tracePath string) error {
+	f, err := os.OpenFile(tracePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("Cannot open the trace file %s: %w", tracePath, err)
+	}
+	defer f.Close()
+	trace := bufio.NewWriter(f)
+
+	// Even when parsing fails, the trace up to the failure helps to find the cause.
+	parseErr := parseSource(shared, cg, src, os.Stdout, trace)
+	err = trace.Flush()
+	if err != nil {
+		return fmt.Errorf("Cannot write the trace file %s: %w", tracePath, err)
+	}
+	return parseErr
+}
+
+// parseSource parses a source and writes a syntax tree to w. When trace isn't nil, it also writes the actions of the
+// parser to trace. This function is safe to call concurrently with the same shared grammar.
+func parseSource(shared *driver.SharedGrammar, cg *spec.CompiledGrammar, src io.Reader, w io.Writer, trace io.Writer) error {
+	var p *driver.Parser
+	var treeAct *driver.SyntaxTreeActionSet
+	var tb *driver.DefaultSyntaxTreeBuilder
+	{
+		gram := shared.Grammar()
+
+		var opts []driver.ParserOption
+		{
+			switch {
+			case *parseFlags.cst:
+				tb = driver.NewDefaultSyntaxTreeBuilder()
+				treeAct = driver.NewCSTActionSet(gram, tb)
+			case !*parseFlags.onlyParse:
+				tb = driver.NewDefaultSyntaxTreeBuilder()
+				treeAct = driver.NewASTActionSet(gram, tb)
+			}
+			if treeAct != nil {
+				opts = append(opts, driver.SemanticAction(treeAct))
+			}
+
+			if *parseFlags.disableLAC {
+				opts = append(opts, driver.DisableLAC())
+			}
+			if *parseFlags.resilient {
+				opts = append(opts, driver.Resilient(*parseFlags.sync...))
+			}
+			if *parseFlags.start != "" {
+				opts = append(opts, driver.EntryPoint(*parseFlags.start))
+			}
+			if *parseFlags.maxDepth > 0 {
+				opts = append(opts, driver.MaxStackDepth(*parseFlags.maxDepth))
+			}
+			if *parseFlags.maxTokens > 0 {
+				opts = append(opts, driver.MaxTokens(*parseFlags.maxTokens))
+			}
+			if *parseFlags.maxErrors > 0 {
+				opts = append(opts, driver.MaxSyntaxErrors(*parseFlags.maxErrors))
+			}
+			if trace != nil {
+				opts = append(opts, driver.Trace(trace))
+			}
+		}
+
+		lexOpts := []lexer.LexerOption{
+			lexer.NormalizeWith(normalizer.Default),
+		}
+		if *parseFlags.ignoreCase {
+			lexOpts = append(lexOpts, lexer.DisableCaseSensitivity())
+		}
+		if *parseFlags.tabWidth > 0 {
+			lexOpts = append(lexOpts, lexer.TabWidth(*parseFlags.tabWidth))
+		}
+
+		toks, err := shared.NewTokenStream(src, lexOpts...)
+		if err != nil {
+			return err
+		}
+
+		p, err = driver.NewParser(toks, gram, opts...)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := p.Parse()
+	if err != nil {
+		// When the syntax errors exceed the maximum count, report the errors found so far along with the limit.
+		var limitErr *driver.LimitError
+		if errors.As(err, &limitErr) && limitErr.Limit == driver.LimitSyntaxErrors {
+			return &syntaxErrors{
+				cg:    cg,
+				errs:  p.SyntaxErrors(),
+				limit: limitErr,
+			}
+		}
+		return err
+	}
+
+	if !*parseFlags.onlyParse {
+		// A parser can construct a parse tree even if syntax errors occur.
+		// When therer is a parse tree, print it.
+		if tree := tb.Tree(); tree != nil {
+			switch *parseFlags.format {
+			case "tree":
+				b := tester.ConvertSyntaxTreeToTestableTree(tree).Format()
+				fmt.Fprintln(w, string(b))
+			case "json":
+				b, err := json.Marshal(tree)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(w, string(b))
+			default:
+				var opts []pretty.Option
+				if *parseFlags.depth > 0 {
+					opts = append(opts, pretty.MaxDepth(*parseFlags.depth))
+				}
+				if *parseFlags.noText {
+					opts = append(opts, pretty.ElideText())
+				}
+				if *parseFlags.color {
+					opts = append(opts, pretty.Color())
+				}
+				err := pretty.Fprint(w, tree, opts...)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if len(p.SyntaxErrors()) > 0 {
+		return &syntaxErrors{
+			cg:   cg,
+			errs: p.SyntaxErrors(),
+		}
+	}
+
+	return nil
+}
+
+// readCompiledGrammar reads a compiled grammar from a file. When the path is stdioPath, it reads stdin.
+func readCompiledGrammar(path string) (*spec.CompiledGrammar, error) {
+	data, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+	cg := &spec.CompiledGrammar{}
+	err = json.Unmarshal(data, cg)
+	if err != nil {
+		return nil, err
+	}
+	err = cg.CheckCompatibility()
+	if err != nil {
+		return nil, err
+	}
+	err = cg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return cg, nil
+}
+
+func writeSyntaxErrorMessage(b *strings.Builder, cgram *spec.CompiledGrammar, synErr *driver.SyntaxError) {
+	fmt.Fprintf(b, "%v:%v: ", synErr.Row+1, synErr.Col+1)
+	writeSyntaxErrorDetail(b, cgram, synErr)
+}
+
+// writeSyntaxErrorDetail writes a syntax error without its position.
+func writeSyntaxErrorDetail(b *strings.Builder, cgram *spec.CompiledGrammar, synErr *driver.SyntaxError) {
+	fmt.Fprintf(b, "%v: ", synErr.Message)
+
+	tok := synErr.Token
+	switch {
+	case tok.EOF():
+		fmt.Fprintf(b, "<eof>")
+	case tok.Invalid():
+		fmt.Fprintf(b, "'%v' (<invalid>)", string(tok.Lexeme()))
+	default:
+		if kind := cgram.Syntactic.Terminals[tok.TerminalID()]; kind != "" {
+			fmt.Fprintf(b, "'%v' (%v)", string(tok.Lexeme()), kind)
+		} else {
+			fmt.Fprintf(b, "'%v'", string(tok.Lexeme()))
+		}
+	}
+
+	if len(synErr.ExpectedTerminals) == 0 {
+		return
+	}
+	fmt.Fprintf(b, ": expected: ")
+	for i, t := range synErr.ExpectedTerminals {
+		if i > 0 {
+			fmt.Fprintf(b, ", ")
+		}
+		if i < len(synErr.ExpectedTerminalIDs) {
+			t = expectedTerminalText(cgram, t, synErr.ExpectedTerminalIDs[i])
+		}
+		fmt.Fprintf(b, "%v", t)
+	}
+}
+
+// expectedTerminalText returns the text of an expected terminal symbol in a syntax error message. A terminal symbol
+// defined by a string literal appears as the quoted literal because users write it so.
+func expectedTerminalText(cgram *spec.CompiledGrammar, name string, id int) string {
+	lits := cgram.Syntactic.TerminalLiterals
+	if id < len(lits) && lits[id] != "" {
+		return fmt.Sprintf("'%v'", lits[id])
+	}
+	return name
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/nihei9/vartan/driver/parser/query"
+	"github.com/nihei9/vartan/normalizer"
+	"github.com/spf13/cobra"
+)
+
+var queryFlags = struct {
+	source *string
+	cst    *bool
+}{}
+
+func newQueryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query <grammar file path> <query>",
+		Short: "Search a syntax tree for nodes matching a path expression",
+		Example: `  cat src | vartan query grammar.json '//assignment[child::id="x"]'
+  vartan query grammar.json '//id' -s src`,
+		Args: cobra.ExactArgs(2),
+		RunE: runQuery,
+	}
+	queryFlags.source = cmd.Flags().StringP("source", "s", "", "source file path (default stdin)")
+	queryFlags.cst = cmd.Flags().Bool("cst", false, "search a CST instead of an AST")
+	return cmd
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	if args[0] == stdioPath && (*queryFlags.source == "" || *queryFlags.source == stdioPath) {
+		return fmt.Errorf("only one of the grammar and the source can be read from stdin")
+	}
+
+	q, err := query.Compile(args[1])
+	if err != nil {
+		return err
+	}
+
+	cg, err := readCompiledGrammar(args[0])
+	if err != nil {
+		return fmt.Errorf("Cannot read a compiled grammar: %w", err)
+	}
+	if cg.IsLexerOnly() {
+		return fmt.Errorf("%v is a lexer-only grammar. It cannot parse a source", cg.Name)
+	}
+
+	src := io.Reader(os.Stdin)
+	if *queryFlags.source != "" {
+		f, err := openInput(*queryFlags.source)
+		if err != nil {
+			return fmt.Errorf("Cannot open the source file %s: %w", *queryFlags.source, err)
+		}
+		defer f.Close()
+		src = f
+	}
+
+	gram := driver.NewGrammar(cg)
+	tb := driver.NewDefaultSyntaxTreeBuilder()
+	var treeAct *driver.SyntaxTreeActionSet
+	if *queryFlags.cst {
+		treeAct = driver.NewCSTActionSet(gram, tb)
+	} else {
+		treeAct = driver.NewASTActionSet(gram, tb)
+	}
+
+	toks, err := driver.NewTokenStream(cg, src, lexer.NormalizeWith(normalizer.Default))
+	if err != nil {
+		return err
+	}
+	p, err := driver.NewParser(toks, gram, driver.SemanticAction(treeAct))
+	if err != nil {
+		return err
+	}
+	err = p.Parse()
+	if err != nil {
+		return err
+	}
+
+	if synErrs := p.SyntaxErrors(); len(synErrs) > 0 {
+		var b strings.Builder
+		writeSyntaxErrorMessage(&b, cg, synErrs[0])
+		for _, synErr := range synErrs[1:] {
+			fmt.Fprintf(&b, "\n")
+			writeSyntaxErrorMessage(&b, cg, synErr)
+		}
+		return fmt.Errorf(b.String())
+	}
+
+	for _, n := range q.Eval(tb.Tree()) {
+		row, col, ok := nodePosition(n)
+		if !ok {
+			fmt.Fprintf(os.Stdout, "-: %v: %q\n", n.KindName, query.Text(n))
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "%v:%v: %v: %q\n", row+1, col+1, n.KindName, query.Text(n))
+	}
+
+	return nil
+}
+
+// nodePosition returns the position of the first terminal node in a tree.
+func nodePosition(n *driver.Node) (int, int, bool) {
+	if n.Type == driver.NodeTypeTerminal {
+		return n.Row, n.Col, true
+	}
+	for _, c := range n.Children {
+		if row, col, ok := nodePosition(c); ok {
+			return row, col, true
+		}
+	}
+	return 0, 0, false
+}
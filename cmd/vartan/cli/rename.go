@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/grammar"
+	"github.com/spf13/cobra"
+)
+
+var renameFlags = struct {
+	mode *bool
+}{}
+
+func newRenameCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename <old name> <new name> <grammar file path>",
+		Short: "Rename a symbol, a label, an ordered symbol, or a lex mode in a grammar",
+		Long: `rename renames a symbol in productions and in the directives referring to it and writes the grammar back.
+Labels and lex modes having the same name as the symbol are kept as they are. A name that isn't a symbol is renamed as
+a label. Specify an ordered symbol with the leading $. rename refuses a new name conflicting with another identifier.
+When the file path is '-', rename reads the grammar from stdin and writes the result to stdout.`,
+		Example: `  vartan rename expr expression grammar.vartan
+  vartan rename '$high' '$top' grammar.vartan
+  vartan rename --mode str string grammar.vartan`,
+		Args: cobra.ExactArgs(3),
+		RunE: runRename,
+	}
+	renameFlags.mode = cmd.Flags().Bool("mode", false, "rename a lex mode instead of a symbol")
+	return cmd
+}
+
+func runRename(cmd *cobra.Command, args []string) error {
+	oldName, newName, path := args[0], args[1], args[2]
+
+	src, err := readInput(path)
+	if err != nil {
+		return fmt.Errorf("Cannot read the grammar file %s: %w", path, err)
+	}
+	var renamed []byte
+	if *renameFlags.mode {
+		renamed, err = grammar.RenameMode(src, oldName, newName)
+	} else {
+		renamed, err = grammar.RenameSymbol(src, oldName, newName)
+	}
+	if err != nil {
+		if specErrs, ok := err.(verr.SpecErrors); ok {
+			for _, e := range specErrs {
+				e.FilePath = path
+				e.SourceName = sourceNameOf(path)
+			}
+			return err
+		}
+		return fmt.Errorf("Cannot rename %v in %s: %w", oldName, path, err)
+	}
+
+	if path == stdioPath {
+		_, err := os.Stdout.Write(renamed)
+		return err
+	}
+	err = os.WriteFile(path, renamed, 0644)
+	if err != nil {
+		return fmt.Errorf("Cannot write the grammar file %s: %w", path, err)
+	}
+	return nil
+}
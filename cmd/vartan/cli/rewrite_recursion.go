@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+	"github.com/spf13/cobra"
+)
+
+var rewriteRecursionFlags = struct {
+	write     *bool
+	keepTrees *bool
+}{}
+
+func newRewriteRecursionCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rewrite-recursion [<grammar file path>]",
+		Short: "Rewrite lists defined by right recursion into left recursion",
+		Long: `rewrite-recursion rewrites productions like 'list: elem comma list | elem' into 'list: list comma elem | elem'
+and prints the grammar in the canonical layout. The parser stack grows with the length of a right-recursive list, while
+a left-recursive one keeps it shallow. When the #ast directive of a list flattens the list, like
+'#ast elem list...', the command reorders its parameters so that list nodes keep their children. Otherwise, the
+nesting of list nodes reverses. rewrite-recursion prints the rewritten productions to stderr.`,
+		Example: `  vartan rewrite-recursion grammar.vartan
+  vartan rewrite-recursion -w --keep-trees grammar.vartan`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runRewriteRecursion,
+	}
+	rewriteRecursionFlags.write = cmd.Flags().BoolP("write", "w", false, "write the result to the file instead of stdout")
+	rewriteRecursionFlags.keepTrees = cmd.Flags().Bool("keep-trees", false, "rewrite only the lists whose syntax trees the rewrite keeps")
+	return cmd
+}
+
+func runRewriteRecursion(cmd *cobra.Command, args []string) (retErr error) {
+	var src []byte
+	var grmPath string
+	sourceName := "stdin"
+	if len(args) > 0 && args[0] != stdioPath {
+		grmPath = args[0]
+		sourceName = args[0]
+
+		var err error
+		src, err = os.ReadFile(grmPath)
+		if err != nil {
+			return fmt.Errorf("Cannot read the grammar file %s: %w", grmPath, err)
+		}
+	} else {
+		if *rewriteRecursionFlags.write {
+			return fmt.Errorf("--write needs a grammar file path")
+		}
+		var err error
+		src, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+	}
+	defer func() {
+		if specErrs, ok := retErr.(verr.SpecErrors); ok {
+			for _, err := range specErrs {
+				err.FilePath = grmPath
+				err.SourceName = sourceName
+			}
+		}
+	}()
+
+	ast, err := parser.Parse(bytes.NewReader(src))
+	if err != nil {
+		return err
+	}
+	for _, list := range grammar.FindRightRecursiveLists(ast) {
+		lhs := list.Production.LHS
+		switch {
+		case !list.Rewritable:
+			fmt.Fprintf(os.Stderr, "%v: skipped because the recursive alternative has directives other than #ast\n", lhs)
+		case !list.KeepsTree && *rewriteRecursionFlags.keepTrees:
+			fmt.Fprintf(os.Stderr, "%v: skipped because the rewrite would reverse the nesting of list nodes\n", lhs)
+		case !list.KeepsTree:
+			grammar.RewriteRightRecursiveList(list)
+			fmt.Fprintf(os.Stderr, "%v: rewritten; the nesting of list nodes reverses\n", lhs)
+		default:
+			grammar.RewriteRightRecursiveList(list)
+			fmt.Fprintf(os.Stderr, "%v: rewritten\n", lhs)
+		}
+	}
+
+	var b bytes.Buffer
+	err = parser.Format(&b, ast)
+	if err != nil {
+		return err
+	}
+	rewritten := b.Bytes()
+	if *rewriteRecursionFlags.write {
+		if bytes.Equal(src, rewritten) {
+			return nil
+		}
+		err := os.WriteFile(grmPath, rewritten, 0644)
+		if err != nil {
+			return fmt.Errorf("Cannot write the grammar file %s: %w", grmPath, err)
+		}
+		return nil
+	}
+	_, err = os.Stdout.Write(rewritten)
+	return err
+}
@@ -0,0 +1,114 @@
+// Package cli implements the subcommands of the vartan command. Downstream projects can embed the commands into their
+// own CLIs and add custom subcommands, such as a company-specific code generator, without patching the vartan command:
+//
+//	cli.Register(cli.CommandFunc(func() *cobra.Command {
+//		return &cobra.Command{
+//			Use:  "mygen <compiled grammar file path>",
+//			RunE: runMyGen,
+//		}
+//	}))
+//	os.Exit(cli.Execute())
+//
+// To make vartan a subcommand of another CLI, add Root to the root command of the CLI and run it using Run, which
+// reports errors in the format that `--diagnostics` option specifies.
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "vartan",
+	Short: "Generate a portable LALR(1) parsing table from grammar you defined",
+	Long: `vartan provides two features:
+- Generate a portable LALR(1) parsing table from grammar you defined.
+- Parse a text stream according to the grammar.`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+// Command is a subcommand of vartan.
+type Command interface {
+	// Command returns the cobra command implementing the subcommand. Register calls it once.
+	Command() *cobra.Command
+}
+
+// CommandFunc is an adapter to allow the use of a function as a Command.
+type CommandFunc func() *cobra.Command
+
+func (f CommandFunc) Command() *cobra.Command {
+	return f()
+}
+
+// builtinCommands are the subcommands that vartan provides.
+var builtinCommands = []Command{
+	CommandFunc(newAstgenCommand),
+	CommandFunc(newBuildCommand),
+	CommandFunc(newCheckCommand),
+	CommandFunc(newCompareTreesCommand),
+	CommandFunc(newCompileCommand),
+	CommandFunc(newConstCommand),
+	CommandFunc(newDiffCommand),
+	CommandFunc(newDirectivesCommand),
+	CommandFunc(newFmtCommand),
+	CommandFunc(newGenerateCommand),
+	CommandFunc(newGenerateInputCommand),
+	CommandFunc(newInfoCommand),
+	CommandFunc(newLexCommand),
+	CommandFunc(newParseCommand),
+	CommandFunc(newQueryCommand),
+	CommandFunc(newRenameCommand),
+	CommandFunc(newRewriteRecursionCommand),
+	CommandFunc(newServeCommand),
+	CommandFunc(newShowCommand),
+	CommandFunc(newSimplifyCommand),
+	CommandFunc(newTestCommand),
+}
+
+func init() {
+	Register(builtinCommands...)
+}
+
+// Register adds subcommands to the root command. A subcommand having the same name as an existing one is an error
+// that panics because it is a programming error.
+func Register(cmds ...Command) {
+	for _, c := range cmds {
+		cmd := c.Command()
+		for _, existing := range rootCmd.Commands() {
+			if existing.Name() == cmd.Name() {
+				panic(fmt.Sprintf("a subcommand is already registered: %v", cmd.Name()))
+			}
+		}
+		rootCmd.AddCommand(cmd)
+	}
+}
+
+// Root returns the root command of vartan, which has all the registered subcommands. The root command is a singleton,
+// so all callers get the same command.
+func Root() *cobra.Command {
+	return rootCmd
+}
+
+// Execute runs the root command with the command-line arguments and returns an exit status.
+func Execute() int {
+	return Run(rootCmd)
+}
+
+// Run runs a command, which is the root command or a command containing it, and reports an error the command returns.
+// It returns an exit status.
+func Run(cmd *cobra.Command) int {
+	err := cmd.Execute()
+	if err != nil {
+		reportError("", err)
+	}
+	if ferr := flushDiagnostics(os.Stderr); ferr != nil {
+		fmt.Fprintln(os.Stderr, ferr)
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/nihei9/vartan/playground"
+	"github.com/spf13/cobra"
+)
+
+var serveFlags = struct {
+	addr *string
+}{}
+
+func newServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a web playground to try grammars in a browser",
+		Example: `  vartan serve
+  vartan serve --addr localhost:3000`,
+		Args: cobra.NoArgs,
+		RunE: runServe,
+	}
+	serveFlags.addr = cmd.Flags().String("addr", "localhost:8080", "TCP address the server listens on")
+	return cmd
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	fmt.Fprintf(os.Stdout, "serving a playground on http://%v/\n", *serveFlags.addr)
+	return http.ListenAndServe(*serveFlags.addr, playground.NewHandler())
+}
@@ -0,0 +1,631 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/nihei9/vartan/grammar"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/spf13/cobra"
+)
+
+var showFlags = struct {
+	explainConflicts *bool
+	source           *bool
+	interactive      *bool
+	format           *string
+	output           *string
+}{}
+
+func newShowCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print a report in a readable format",
+		Example: `  vartan show grammar-report.json
+  vartan show --explain-conflicts grammar-report.json
+  vartan show --source grammar-report.json
+  vartan show --interactive grammar-report.json
+  vartan show --format html -o report.html grammar-report.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: runShow,
+	}
+	showFlags.explainConflicts = cmd.Flags().Bool("explain-conflicts", false, "explain why each conflict was resolved as it was")
+	showFlags.format = cmd.Flags().StringP("format", "f", "text", "output format: one of text|html")
+	showFlags.output = cmd.Flags().StringP("output", "o", "", "output file path (default stdout)")
+	showFlags.interactive = cmd.Flags().Bool("interactive", false, "browse the LALR states in a read-eval-print loop reading commands from stdin; type h for help")
+	showFlags.source = cmd.Flags().Bool("source", false, "print the rules of the grammar source verbatim, using the source embedded by 'vartan compile --embed-source' if any")
+	return cmd
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	if *showFlags.format != outputFormatText && *showFlags.format != outputFormatHTML {
+		return fmt.Errorf("invalid output format: %v", *showFlags.format)
+	}
+	if *showFlags.interactive && (*showFlags.format != outputFormatText || *showFlags.output != "") {
+		return fmt.Errorf("--interactive option cannot be used with --format or --output option")
+	}
+
+	report, err := readReport(args[0])
+	if err != nil {
+		return err
+	}
+
+	opts := &reportOptions{
+		explainConflicts: *showFlags.explainConflicts,
+	}
+	if *showFlags.source {
+		src, err := readReportSource(report)
+		if err != nil {
+			return err
+		}
+		opts.sourceLines = strings.Split(src, "\n")
+	}
+
+	if *showFlags.interactive {
+		if args[0] == stdioPath {
+			return fmt.Errorf("--interactive option reads commands from stdin, so it cannot read the report from stdin")
+		}
+		b, err := newStateBrowser(os.Stdout, report, opts, isTerminal(os.Stdin) && isTerminal(os.Stdout))
+		if err != nil {
+			return err
+		}
+		return b.run(os.Stdin)
+	}
+
+	write := writeReport
+	if *showFlags.format == outputFormatHTML {
+		write = writeHTMLReport
+	}
+	if *showFlags.output == "" || *showFlags.output == stdioPath {
+		return write(os.Stdout, report, opts)
+	}
+
+	// The command writes nothing when formatting the report fails halfway.
+	var b bytes.Buffer
+	err = write(&b, report, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*showFlags.output, b.Bytes(), 0644)
+}
+
+type reportOptions struct {
+	explainConflicts bool
+
+	// sourceLines holds the lines of the grammar source. When it is non-nil, the report contains the rules verbatim.
+	sourceLines []string
+}
+
+// readReportSource returns the grammar source of a report. It prefers the source embedded by `--embed-source` option
+// because the file may have changed since the compilation.
+func readReportSource(report *spec.Report) (string, error) {
+	if report.BuildInfo != nil && report.BuildInfo.Source != "" {
+		return report.BuildInfo.Source, nil
+	}
+	if report.SourceMap == nil || report.SourceMap.File == "" {
+		return "", fmt.Errorf("the report doesn't know the grammar source; compile the grammar again with this version of vartan")
+	}
+	src, err := os.ReadFile(report.SourceMap.File)
+	if err != nil {
+		return "", fmt.Errorf("Cannot read the grammar source %s: %w", report.SourceMap.File, err)
+	}
+	return string(src), nil
+}
+
+func readReport(path string) (*spec.Report, error) {
+	f, err := openInput(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot open the report %s: %w", path, err)
+	}
+	defer f.Close()
+
+	d, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &spec.Report{}
+	err = json.Unmarshal(d, report)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+const reportTemplate = `{{ with .Metadata }}# Metadata
+
+{{ formatMetadata . }}
+{{ end }}# Conflicts
+
+{{ printConflictSummary . }}
+{{ if explainConflicts }}{{ range .States }}{{ $state := .Number }}{{ range .SRConflict -}}
+{{ explainSRConflict $state . }}
+{{ end }}{{ range .RRConflict -}}
+{{ explainRRConflict $state . }}
+{{ end }}{{ end }}{{ end }}
+# Terminals
+
+{{ range slice .Terminals 1 -}}
+{{ printTerminal . }}
+{{ end }}
+{{ with .Lexical }}# Lex Modes
+{{ range .Modes }}
+## Mode {{ .Name }}
+
+{{ with .Priorities -}}
+priority order: {{ printLexPriorities . }}
+
+{{ end -}}
+{{ if .Lazy -}}
+the lexer builds DFA states at run time
+{{ else -}}
+{{ .StateCount }} states
+
+{{ range .Kinds -}}
+{{ printLexKind . }}
+{{ end }}
+{{ range .Backtracks -}}
+{{ printLexBacktrack . }}
+{{ else -}}
+the lexer never backtracks
+{{ end -}}
+{{ end -}}
+{{ end }}
+{{ end -}}
+{{ if .Productions }}# Productions
+
+{{ range slice .Productions 1 -}}
+{{ printProduction . }}
+{{ end }}
+{{ with rules }}# Rules
+{{ range . }}
+{{ . }}
+{{ end }}
+{{ end -}}
+# States
+{{ range .States }}
+## State {{ .Number }}
+
+{{ range .Kernel -}}
+{{ printItem . }}
+{{ end }}
+{{ range .Shift -}}
+{{ printShift . }}
+{{ end -}}
+{{ range .Reduce -}}
+{{ printReduce . }}
+{{ end -}}
+{{ range .GoTo -}}
+{{ printGoTo . }}
+{{ end }}
+{{ range .SRConflict -}}
+{{ printSRConflict . }}
+{{ end -}}
+{{ range .RRConflict -}}
+{{ printRRConflict . }}
+{{ end -}}
+{{ end }}{{ end }}`
+
+func writeReport(w io.Writer, report *spec.Report, opts *reportOptions) error {
+	tmpl, err := template.New("").Funcs(reportFuncs(report, opts)).Parse(reportTemplate)
+	if err != nil {
+		return err
+	}
+
+	err = tmpl.Execute(w, report)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// reportFuncs returns the functions formatting the elements of a report.
+func reportFuncs(report *spec.Report, opts *reportOptions) template.FuncMap {
+	termName := func(sym int) string {
+		return report.Terminals[sym].Name
+	}
+
+	nonTermName := func(sym int) string {
+		return report.NonTerminals[sym].Name
+	}
+
+	termAssoc := func(sym int) string {
+		switch report.Terminals[sym].Associativity {
+		case "l":
+			return "left"
+		case "r":
+			return "right"
+		case "n":
+			return "non"
+		default:
+			return "no"
+		}
+	}
+
+	prodAssoc := func(prod int) string {
+		switch report.Productions[prod].Associativity {
+		case "l":
+			return "left"
+		case "r":
+			return "right"
+		case "n":
+			return "non"
+		default:
+			return "no"
+		}
+	}
+
+	prodText := func(prod int) string {
+		p := report.Productions[prod]
+		var b strings.Builder
+		fmt.Fprintf(&b, "%v →", nonTermName(p.LHS))
+		if len(p.RHS) > 0 {
+			for _, e := range p.RHS {
+				if e > 0 {
+					fmt.Fprintf(&b, " %v", termName(e))
+				} else {
+					fmt.Fprintf(&b, " %v", nonTermName(e*-1))
+				}
+			}
+		} else {
+			fmt.Fprintf(&b, " ε")
+		}
+		if p.Label != "" {
+			return fmt.Sprintf("production %v @%v \"%v\"", prod, p.Label, b.String())
+		}
+		return fmt.Sprintf("production %v \"%v\"", prod, b.String())
+	}
+
+	assocText := func(assoc string) string {
+		switch assoc {
+		case "l":
+			return "left-associative"
+		case "r":
+			return "right-associative"
+		case "n":
+			return "non-associative"
+		default:
+			return "declared without associativity"
+		}
+	}
+
+	return template.FuncMap{
+		"formatMetadata": formatMetadata,
+		"explainConflicts": func() bool {
+			return opts.explainConflicts
+		},
+		"rules": func() []string {
+			if opts.sourceLines == nil {
+				return nil
+			}
+			var ranges []*spec.SourceRange
+			for _, r := range report.SourceMap.NonTerminals {
+				if r != nil {
+					ranges = append(ranges, r)
+				}
+			}
+			for _, r := range report.SourceMap.Kinds {
+				if r != nil {
+					ranges = append(ranges, r)
+				}
+			}
+			sort.Slice(ranges, func(i, j int) bool {
+				if ranges[i].Row != ranges[j].Row {
+					return ranges[i].Row < ranges[j].Row
+				}
+				return ranges[i].Col < ranges[j].Col
+			})
+			rules := make([]string, len(ranges))
+			for i, r := range ranges {
+				rules[i] = sourceText(opts.sourceLines, r)
+			}
+			return rules
+		},
+		"explainSRConflict": func(state int, sr spec.SRConflict) string {
+			term := fmt.Sprintf("terminal %v", termName(sr.Symbol))
+			prod := prodText(sr.Production)
+			var chosen string
+			switch {
+			case sr.AdoptedProduction != nil:
+				chosen = fmt.Sprintf("reduce %v", *sr.AdoptedProduction)
+			case sr.AdoptedState != nil:
+				chosen = fmt.Sprintf("shift %v", sr.State)
+			default:
+				chosen = "error"
+			}
+
+			var reason string
+			switch sr.ResolvedBy {
+			case grammar.ResolvedByPrec.Int():
+				if sr.AdoptedProduction != nil {
+					reason = fmt.Sprintf("%v (prec %v) has higher precedence than %v (prec %v)", prod, sr.ProductionPrecedence, term, sr.SymbolPrecedence)
+				} else {
+					reason = fmt.Sprintf("%v (prec %v) has higher precedence than %v (prec %v)", term, sr.SymbolPrecedence, prod, sr.ProductionPrecedence)
+				}
+			case grammar.ResolvedByAssoc.Int():
+				reason = fmt.Sprintf("%v and %v have the same precedence (prec %v), and the production is %v", term, prod, sr.ProductionPrecedence, assocText(sr.ProductionAssociativity))
+			case grammar.ResolvedByShift.Int():
+				switch {
+				case sr.SymbolPrecedence == 0 && sr.ProductionPrecedence == 0:
+					reason = fmt.Sprintf("neither %v nor %v has a precedence (default rule)", term, prod)
+				case sr.SymbolPrecedence == 0:
+					reason = fmt.Sprintf("%v has no precedence (default rule)", term)
+				default:
+					reason = fmt.Sprintf("%v has no precedence (default rule)", prod)
+				}
+			case grammar.ResolvedByPreference.Int():
+				reason = fmt.Sprintf("%v has a #prefer directive", prod)
+			default:
+				reason = "?" // This is a bug.
+			}
+
+			return fmt.Sprintf("state %v: shift/reduce conflict on %v: %v chosen because %v", state, termName(sr.Symbol), chosen, reason)
+		},
+		"explainRRConflict": func(state int, rr spec.RRConflict) string {
+			adopted := rr.Production1
+			other := rr.Production2
+			if adopted != rr.AdoptedProduction {
+				adopted, other = other, adopted
+			}
+
+			var reason string
+			switch rr.ResolvedBy {
+			case grammar.ResolvedByProdOrder.Int():
+				reason = fmt.Sprintf("%v is defined before %v (default rule)", prodText(adopted), prodText(other))
+			default:
+				reason = "?" // This is a bug.
+			}
+
+			return fmt.Sprintf("state %v: reduce/reduce conflict on %v: reduce %v chosen because %v", state, termName(rr.Symbol), adopted, reason)
+		},
+		"printConflictSummary": func(report *spec.Report) string {
+			var implicitlyResolvedCount int
+			var explicitlyResolvedCount int
+			for _, s := range report.States {
+				for _, c := range s.SRConflict {
+					if c.ResolvedBy == grammar.ResolvedByShift.Int() {
+						implicitlyResolvedCount++
+					} else {
+						explicitlyResolvedCount++
+					}
+				}
+				for _, c := range s.RRConflict {
+					if c.ResolvedBy == grammar.ResolvedByProdOrder.Int() {
+						implicitlyResolvedCount++
+					} else {
+						explicitlyResolvedCount++
+					}
+				}
+			}
+
+			var b strings.Builder
+			if implicitlyResolvedCount == 1 {
+				fmt.Fprintf(&b, "%v conflict occurred and resolved implicitly.\n", implicitlyResolvedCount)
+			} else if implicitlyResolvedCount > 1 {
+				fmt.Fprintf(&b, "%v conflicts occurred and resolved implicitly.\n", implicitlyResolvedCount)
+			}
+			if explicitlyResolvedCount == 1 {
+				fmt.Fprintf(&b, "%v conflict occurred and resolved explicitly.\n", explicitlyResolvedCount)
+			} else if explicitlyResolvedCount > 1 {
+				fmt.Fprintf(&b, "%v conflicts occurred and resolved explicitly.\n", explicitlyResolvedCount)
+			}
+			if implicitlyResolvedCount == 0 && explicitlyResolvedCount == 0 {
+				fmt.Fprintf(&b, "No conflict")
+			}
+			return b.String()
+		},
+		"printTerminal": func(term spec.Terminal) string {
+			var prec string
+			if term.Precedence != 0 {
+				prec = fmt.Sprintf("%2v", term.Precedence)
+			} else {
+				prec = " -"
+			}
+
+			var assoc string
+			if term.Associativity != "" {
+				assoc = term.Associativity
+			} else {
+				assoc = "-"
+			}
+
+			if term.Literal != "" {
+				return fmt.Sprintf("%4v %v %v %v '%v'", term.Number, prec, assoc, term.Name, term.Literal)
+			}
+			return fmt.Sprintf("%4v %v %v %v", term.Number, prec, assoc, term.Name)
+		},
+		"printLexKind": func(kind spec.LexKindReport) string {
+			var b strings.Builder
+			fmt.Fprintf(&b, "%v: %v accepting states, %v reaching states", kind.Name, kind.AcceptingStateCount, kind.ReachingStateCount)
+			if len(kind.ShadowedBy) > 0 {
+				if kind.AcceptingStateCount == 0 {
+					fmt.Fprintf(&b, "; never matches because %v always %v", strings.Join(kind.ShadowedBy, ", "), winVerb(kind.ShadowedBy))
+				} else {
+					fmt.Fprintf(&b, "; %v %v on some inputs", strings.Join(kind.ShadowedBy, ", "), winVerb(kind.ShadowedBy))
+				}
+			}
+			return b.String()
+		},
+		"printLexPriorities": func(priorities []*spec.LexPriorityReport) string {
+			ps := make([]string, len(priorities))
+			for i, p := range priorities {
+				ps[i] = fmt.Sprintf("%v (%v)", p.Kind, p.Priority)
+			}
+			return strings.Join(ps, ", ")
+		},
+		"printLexBacktrack": func(b spec.LexBacktrackReport) string {
+			return "backtrack: " + describeBacktrack(&b)
+		},
+		"printProduction": func(prod spec.Production) string {
+			var prec string
+			if prod.Precedence != 0 {
+				prec = fmt.Sprintf("%2v", prod.Precedence)
+			} else {
+				prec = " -"
+			}
+
+			var assoc string
+			if prod.Associativity != "" {
+				assoc = prod.Associativity
+			} else {
+				assoc = "-"
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "%v →", nonTermName(prod.LHS))
+			if len(prod.RHS) > 0 {
+				for _, e := range prod.RHS {
+					if e > 0 {
+						fmt.Fprintf(&b, " %v", termName(e))
+					} else {
+						fmt.Fprintf(&b, " %v", nonTermName(e*-1))
+					}
+				}
+			} else {
+				fmt.Fprintf(&b, " ε")
+			}
+			if prod.Label != "" {
+				fmt.Fprintf(&b, " @%v", prod.Label)
+			}
+
+			if opts.sourceLines != nil && prod.Number < len(report.SourceMap.Productions) {
+				if r := report.SourceMap.Productions[prod.Number]; r != nil {
+					fmt.Fprintf(&b, " (%v:%v:%v)", report.SourceMap.File, r.Row, r.Col)
+				}
+			}
+
+			return fmt.Sprintf("%4v %v %v %v", prod.Number, prec, assoc, b.String())
+		},
+		"printItem": func(item spec.Item) string {
+			prod := report.Productions[item.Production]
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "%v →", nonTermName(prod.LHS))
+			for i, e := range prod.RHS {
+				if i == item.Dot {
+					fmt.Fprintf(&b, " ・")
+				}
+				if e > 0 {
+					fmt.Fprintf(&b, " %v", termName(e))
+				} else {
+					fmt.Fprintf(&b, " %v", nonTermName(e*-1))
+				}
+			}
+			if item.Dot >= len(prod.RHS) {
+				fmt.Fprintf(&b, " ・")
+			}
+
+			return fmt.Sprintf("%4v %v", prod.Number, b.String())
+		},
+		"printShift": func(tran spec.Transition) string {
+			return fmt.Sprintf("shift  %4v on %v", tran.State, termName(tran.Symbol))
+		},
+		"printReduce": func(reduce spec.Reduce) string {
+			var b strings.Builder
+			{
+				fmt.Fprintf(&b, "%v", termName(reduce.LookAhead[0]))
+				for _, a := range reduce.LookAhead[1:] {
+					fmt.Fprintf(&b, ", %v", termName(a))
+				}
+			}
+			return fmt.Sprintf("reduce %4v on %v", reduce.Production, b.String())
+		},
+		"printGoTo": func(tran spec.Transition) string {
+			return fmt.Sprintf("goto   %4v on %v", tran.State, nonTermName(tran.Symbol))
+		},
+		"printSRConflict": func(sr spec.SRConflict) string {
+			var adopted string
+			switch {
+			case sr.AdoptedState != nil:
+				adopted = fmt.Sprintf("shift %v", *sr.AdoptedState)
+			case sr.AdoptedProduction != nil:
+				adopted = fmt.Sprintf("reduce %v", *sr.AdoptedProduction)
+			default:
+				adopted = "error"
+			}
+			var resolvedBy string
+			switch sr.ResolvedBy {
+			case grammar.ResolvedByPrec.Int():
+				if sr.AdoptedState != nil {
+					resolvedBy = fmt.Sprintf("symbol %v has higher precedence than production %v", termName(sr.Symbol), sr.Production)
+				} else {
+					resolvedBy = fmt.Sprintf("production %v has higher precedence than symbol %v", sr.Production, termName(sr.Symbol))
+				}
+			case grammar.ResolvedByAssoc.Int():
+				if sr.AdoptedState != nil {
+					resolvedBy = fmt.Sprintf("symbol %v and production %v has the same precedence, and symbol %v has %v associativity", termName(sr.Symbol), sr.Production, termName(sr.Symbol), termAssoc(sr.Symbol))
+				} else if sr.AdoptedProduction == nil {
+					resolvedBy = fmt.Sprintf("production %v and symbol %v has the same precedence, and production %v has non associativity", sr.Production, termName(sr.Symbol), sr.Production)
+				} else {
+					resolvedBy = fmt.Sprintf("production %v and symbol %v has the same precedence, and production %v has %v associativity", sr.Production, termName(sr.Symbol), sr.Production, prodAssoc(sr.Production))
+				}
+			case grammar.ResolvedByShift.Int():
+				resolvedBy = fmt.Sprintf("symbol %v and production %v don't define a precedence comparison (default rule)", sr.Symbol, sr.Production)
+			case grammar.ResolvedByPreference.Int():
+				resolvedBy = fmt.Sprintf("production %v has a #prefer directive", sr.Production)
+			default:
+				resolvedBy = "?" // This is a bug.
+			}
+			return fmt.Sprintf("shift/reduce conflict (shift %v, reduce %v) on %v: %v adopted because %v", sr.State, sr.Production, termName(sr.Symbol), adopted, resolvedBy)
+		},
+		"printRRConflict": func(rr spec.RRConflict) string {
+			var resolvedBy string
+			switch rr.ResolvedBy {
+			case grammar.ResolvedByProdOrder.Int():
+				resolvedBy = fmt.Sprintf("production %v and %v don't define a precedence comparison (default rule)", rr.Production1, rr.Production2)
+			default:
+				resolvedBy = "?" // This is a bug.
+			}
+			return fmt.Sprintf("reduce/reduce conflict (%v, %v) on %v: reduce %v adopted because %v", rr.Production1, rr.Production2, termName(rr.Symbol), rr.AdoptedProduction, resolvedBy)
+		},
+	}
+}
+
+// winVerb returns a form of the verb "win" agreeing with the number of kinds.
+// describeBacktrack explains how many bytes the lexer may read again after going back to the end of a lexeme.
+func describeBacktrack(b *spec.LexBacktrackReport) string {
+	if b.MaxRescan < 0 {
+		return fmt.Sprintf("after %v %v, the lexer may read %v and more without limit and go back, so tokenizing can take quadratic time", b.Kind, b.Lexeme, b.Beyond)
+	}
+	unit := "bytes"
+	if b.MaxRescan == 1 {
+		unit = "byte"
+	}
+	return fmt.Sprintf("after %v %v, the lexer may read %v and go back; it reads at most %v %v again", b.Kind, b.Lexeme, b.Beyond, b.MaxRescan, unit)
+}
+
+func winVerb(kinds []string) string {
+	if len(kinds) == 1 {
+		return "wins"
+	}
+	return "win"
+}
+
+// sourceText returns the text of a grammar source within a range. Columns count runes.
+func sourceText(lines []string, r *spec.SourceRange) string {
+	if r.Row < 1 || r.EndRow < r.Row || r.EndRow > len(lines) {
+		return ""
+	}
+	text := make([]string, 0, r.EndRow-r.Row+1)
+	for row := r.Row; row <= r.EndRow; row++ {
+		line := []rune(strings.TrimSuffix(lines[row-1], "\r"))
+		end := len(line)
+		if row == r.EndRow && r.EndCol <= end {
+			end = r.EndCol
+		}
+		begin := 0
+		if row == r.Row && r.Col-1 <= end {
+			begin = r.Col - 1
+		}
+		text = append(text, string(line[begin:end]))
+	}
+	return strings.Join(text, "\n")
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+const outputFormatHTML = "html"
+
+// htmlReportTemplate is a standalone HTML page, which needs no style sheet or script file, so users can share
+// the report as one file. Each state and production has an anchor, `state-<number>` and `prod-<number>`.
+const htmlReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ with name }}{{ . }}: {{ end }}vartan report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; line-height: 1.4; }
+code, pre, td.sym, li.line { font-family: monospace; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; vertical-align: top; }
+section.state { border: 1px solid #ccc; border-radius: 4px; margin: 1em 0; padding: 0 1em 0.5em; }
+section.conflict { border-color: #d33; background: #fff4f4; }
+section:target { outline: 3px solid #36c; }
+.conflict-text { color: #b00; }
+ul.lines { list-style: none; padding-left: 0; margin: 0.5em 0; }
+.dot { color: #36c; font-weight: bold; }
+nav a { margin-right: 1em; }
+</style>
+</head>
+<body>
+<h1>{{ with name }}{{ . }}: {{ end }}vartan report</h1>
+<nav><a href="#conflicts">Conflicts</a><a href="#terminals">Terminals</a>{{ if .Productions }}<a href="#productions">Productions</a>{{ end }}{{ if .Lexical }}<a href="#lex-modes">Lex Modes</a>{{ end }}{{ if .Productions }}<a href="#states">States</a>{{ end }}</nav>
+{{ with .Metadata }}
+<h2 id="metadata">Metadata</h2>
+<pre>{{ formatMetadata . }}</pre>
+{{ end }}
+<h2 id="conflicts">Conflicts</h2>
+<p>{{ printConflictSummary . }}</p>
+{{ with conflictStates }}<p>States having conflicts:{{ range . }} <a href="#state-{{ . }}">{{ . }}</a>{{ end }}</p>{{ end }}
+{{ if explainConflicts }}<ul class="lines">
+{{ range .States }}{{ $state := .Number }}{{ range .SRConflict }}<li class="line conflict-text">{{ explainSRConflict $state . }}</li>
+{{ end }}{{ range .RRConflict }}<li class="line conflict-text">{{ explainRRConflict $state . }}</li>
+{{ end }}{{ end }}</ul>
+{{ end }}
+<h2 id="terminals">Terminals</h2>
+<table>
+<tr><th>Number</th><th>Precedence</th><th>Associativity</th><th>Name</th><th>Literal</th></tr>
+{{ range slice .Terminals 1 }}<tr><td>{{ .Number }}</td><td>{{ if .Precedence }}{{ .Precedence }}{{ else }}-{{ end }}</td><td>{{ if .Associativity }}{{ .Associativity }}{{ else }}-{{ end }}</td><td class="sym">{{ .Name }}</td><td class="sym">{{ .Literal }}</td></tr>
+{{ end }}</table>
+{{ if .Productions }}
+<h2 id="productions">Productions</h2>
+<table>
+<tr><th>Number</th><th>Precedence</th><th>Associativity</th><th>Production</th><th>Kernel of states</th></tr>
+{{ range slice .Productions 1 }}<tr id="prod-{{ .Number }}"><td>{{ .Number }}</td><td>{{ if .Precedence }}{{ .Precedence }}{{ else }}-{{ end }}</td><td>{{ if .Associativity }}{{ .Associativity }}{{ else }}-{{ end }}</td><td class="sym">{{ productionText . }}</td><td>{{ range kernelStates .Number }}<a href="#state-{{ . }}">{{ . }}</a> {{ end }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ with rules }}
+<h2 id="rules">Rules</h2>
+{{ range . }}<pre>{{ . }}</pre>
+{{ end }}{{ end }}
+{{ with .Lexical }}
+<h2 id="lex-modes">Lex Modes</h2>
+{{ range .Modes }}
+<h3>Mode {{ .Name }}</h3>
+{{ with .Priorities }}<p>priority order: {{ printLexPriorities . }}</p>
+{{ end }}{{ if .Lazy }}<p>the lexer builds DFA states at run time</p>
+{{ else }}<p>{{ .StateCount }} states</p>
+<ul class="lines">
+{{ range .Kinds }}<li class="line">{{ printLexKind . }}</li>
+{{ end }}</ul>
+<ul class="lines">
+{{ range .Backtracks }}<li class="line">{{ printLexBacktrack . }}</li>
+{{ else }}<li class="line">the lexer never backtracks</li>
+{{ end }}</ul>
+{{ end }}{{ end }}{{ end }}
+{{ if .Productions }}<h2 id="states">States</h2>
+{{ end }}{{ range .States }}{{ $state := .Number }}
+<section id="state-{{ .Number }}" class="state{{ if or .SRConflict .RRConflict }} conflict{{ end }}">
+<h3>State {{ .Number }}</h3>
+<ul class="lines">
+{{ range .Kernel }}<li class="line"><a href="#prod-{{ .Production }}">{{ printf "%4v" .Production }}</a> {{ itemHTML . }}</li>
+{{ end }}</ul>
+<ul class="lines">
+{{ range .Shift }}<li class="line">shift  <a href="#state-{{ .State }}">{{ printf "%4v" .State }}</a> on {{ termName .Symbol }}</li>
+{{ end }}{{ range .Reduce }}<li class="line">reduce <a href="#prod-{{ .Production }}">{{ printf "%4v" .Production }}</a> on {{ lookAheads . }}</li>
+{{ end }}{{ range .GoTo }}<li class="line">goto   <a href="#state-{{ .State }}">{{ printf "%4v" .State }}</a> on {{ nonTermName .Symbol }}</li>
+{{ end }}</ul>
+{{ if or .SRConflict .RRConflict }}<ul class="lines">
+{{ range .SRConflict }}<li class="line conflict-text">{{ if explainConflicts }}{{ explainSRConflict $state . }}{{ else }}{{ printSRConflict . }}{{ end }}</li>
+{{ end }}{{ range .RRConflict }}<li class="line conflict-text">{{ if explainConflicts }}{{ explainRRConflict $state . }}{{ else }}{{ printRRConflict . }}{{ end }}</li>
+{{ end }}</ul>
+{{ end }}</section>
+{{ end }}
+</body>
+</html>
+`
+
+// writeHTMLReport writes a report as a standalone HTML page. Transitions link to the states they go to, items and
+// reductions link to their productions, and productions link to the states having them in their kernels.
+func writeHTMLReport(w io.Writer, report *spec.Report, opts *reportOptions) error {
+	termName := func(sym int) string {
+		return report.Terminals[sym].Name
+	}
+
+	nonTermName := func(sym int) string {
+		return report.NonTerminals[sym].Name
+	}
+
+	symbolName := func(sym int) string {
+		if sym > 0 {
+			return termName(sym)
+		}
+		return nonTermName(sym * -1)
+	}
+
+	kernelStates := map[int][]int{}
+	var conflictStates []int
+	for _, state := range report.States {
+		for _, item := range state.Kernel {
+			// A kernel may have several items of the same production at different positions.
+			states := kernelStates[item.Production]
+			if len(states) > 0 && states[len(states)-1] == state.Number {
+				continue
+			}
+			kernelStates[item.Production] = append(states, state.Number)
+		}
+		if len(state.SRConflict) > 0 || len(state.RRConflict) > 0 {
+			conflictStates = append(conflictStates, state.Number)
+		}
+	}
+
+	fns := htmltemplate.FuncMap(reportFuncs(report, opts))
+	fns["name"] = func() string {
+		if report.SourceMap != nil {
+			return report.SourceMap.File
+		}
+		return ""
+	}
+	fns["termName"] = termName
+	fns["nonTermName"] = nonTermName
+	fns["conflictStates"] = func() []int {
+		return conflictStates
+	}
+	fns["kernelStates"] = func(prod int) []int {
+		return kernelStates[prod]
+	}
+	fns["productionText"] = func(prod spec.Production) string {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%v →", nonTermName(prod.LHS))
+		if len(prod.RHS) > 0 {
+			for _, e := range prod.RHS {
+				fmt.Fprintf(&b, " %v", symbolName(e))
+			}
+		} else {
+			fmt.Fprintf(&b, " ε")
+		}
+		if prod.Label != "" {
+			fmt.Fprintf(&b, " @%v", prod.Label)
+		}
+		return b.String()
+	}
+	fns["itemHTML"] = func(item spec.Item) htmltemplate.HTML {
+		prod := report.Productions[item.Production]
+		var b strings.Builder
+		b.WriteString(htmltemplate.HTMLEscapeString(nonTermName(prod.LHS)))
+		b.WriteString(" →")
+		for i, e := range prod.RHS {
+			if i == item.Dot {
+				b.WriteString(` <span class="dot">・</span>`)
+			}
+			b.WriteString(" ")
+			b.WriteString(htmltemplate.HTMLEscapeString(symbolName(e)))
+		}
+		if item.Dot >= len(prod.RHS) {
+			b.WriteString(` <span class="dot">・</span>`)
+		}
+		return htmltemplate.HTML(b.String())
+	}
+	fns["lookAheads"] = func(reduce spec.Reduce) string {
+		names := make([]string, len(reduce.LookAhead))
+		for i, a := range reduce.LookAhead {
+			names[i] = termName(a)
+		}
+		return strings.Join(names, ", ")
+	}
+
+	tmpl, err := htmltemplate.New("").Funcs(fns).Parse(htmlReportTemplate)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, report)
+}
@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+const browserHelp = `Commands:
+  <number>, state <number>  show a state
+  go <symbol>               follow the shift or goto transition on a symbol from the current state
+  back, b                   go back to the previous state
+  list, l                   list the states with their first kernel items
+  conflicts, c              list the states having conflicts
+  find <text>, /<text>      list the productions containing a text and the states having them in their kernels
+  help, h, ?                show this help
+  quit, q                   quit
+`
+
+const browserStateTemplate = `# State {{ .Number }}
+
+{{ range .Kernel -}}
+{{ printItem . }}
+{{ end }}
+{{ range .Shift -}}
+{{ printShift . }}
+{{ end -}}
+{{ range .Reduce -}}
+{{ printReduce . }}
+{{ end -}}
+{{ range .GoTo -}}
+{{ printGoTo . }}
+{{ end }}
+{{- if or .SRConflict .RRConflict }}
+{{ $state := .Number }}{{ range .SRConflict -}}
+{{ if explainConflicts }}{{ explainSRConflict $state . }}{{ else }}{{ printSRConflict . }}{{ end }}
+{{ end -}}
+{{ range .RRConflict -}}
+{{ if explainConflicts }}{{ explainRRConflict $state . }}{{ else }}{{ printRRConflict . }}{{ end }}
+{{ end -}}
+{{ end }}`
+
+// stateBrowser is a read-eval-print loop to navigate the LALR states of a report. It reads commands line by line, so it
+// works on any terminal without a terminal library, and also reads commands from a pipe for scripting.
+type stateBrowser struct {
+	report      *spec.Report
+	fns         template.FuncMap
+	stateTmpl   *template.Template
+	w           io.Writer
+	interactive bool
+
+	// current is the number of the state being shown, and history holds the states shown before it.
+	current int
+	history []int
+}
+
+func newStateBrowser(w io.Writer, report *spec.Report, opts *reportOptions, interactive bool) (*stateBrowser, error) {
+	if len(report.States) == 0 {
+		return nil, fmt.Errorf("the report has no states; the grammar may be lexer-only")
+	}
+	fns := reportFuncs(report, opts)
+	tmpl, err := template.New("").Funcs(fns).Parse(browserStateTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &stateBrowser{
+		report:      report,
+		fns:         fns,
+		stateTmpl:   tmpl,
+		w:           w,
+		interactive: interactive,
+	}, nil
+}
+
+// run shows the initial state and then executes commands read from `r` until it reads `quit` or reaches EOF.
+func (b *stateBrowser) run(r io.Reader) error {
+	err := b.showState(b.current)
+	if err != nil {
+		return err
+	}
+	s := bufio.NewScanner(r)
+	for {
+		if b.interactive {
+			fmt.Fprintf(b.w, "state %v (h for help)> ", b.current)
+		}
+		if !s.Scan() {
+			break
+		}
+		quit, err := b.exec(strings.TrimSpace(s.Text()))
+		if err != nil {
+			return err
+		}
+		if quit {
+			return nil
+		}
+	}
+	if b.interactive {
+		fmt.Fprintln(b.w)
+	}
+	return s.Err()
+}
+
+// exec executes a command. Mistakes in a command aren't errors; it tells the user what went wrong and goes on.
+func (b *stateBrowser) exec(line string) (bool, error) {
+	if line == "" {
+		return false, nil
+	}
+	if strings.HasPrefix(line, "/") {
+		return false, b.find(strings.TrimSpace(line[1:]))
+	}
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch cmd {
+	case "quit", "q":
+		return true, nil
+	case "help", "h", "?":
+		b.clear()
+		fmt.Fprint(b.w, browserHelp)
+	case "state":
+		return false, b.jump(arg)
+	case "go":
+		return false, b.follow(arg)
+	case "back", "b":
+		if len(b.history) == 0 {
+			fmt.Fprintln(b.w, "no previous state")
+			return false, nil
+		}
+		b.current = b.history[len(b.history)-1]
+		b.history = b.history[:len(b.history)-1]
+		return false, b.showState(b.current)
+	case "list", "l":
+		b.clear()
+		printItem := b.fns["printItem"].(func(spec.Item) string)
+		for _, state := range b.report.States {
+			var item string
+			if len(state.Kernel) > 0 {
+				item = strings.TrimSpace(printItem(*state.Kernel[0]))
+			}
+			fmt.Fprintf(b.w, "%4v  %v\n", state.Number, item)
+		}
+	case "conflicts", "c":
+		b.clear()
+		var found bool
+		for _, state := range b.report.States {
+			if len(state.SRConflict) == 0 && len(state.RRConflict) == 0 {
+				continue
+			}
+			found = true
+			fmt.Fprintf(b.w, "%4v  %v shift/reduce, %v reduce/reduce\n", state.Number, len(state.SRConflict), len(state.RRConflict))
+		}
+		if !found {
+			fmt.Fprintln(b.w, "No conflict")
+		}
+	case "find":
+		return false, b.find(arg)
+	default:
+		return false, b.jump(line)
+	}
+	return false, nil
+}
+
+func (b *stateBrowser) jump(arg string) error {
+	num, err := strconv.Atoi(arg)
+	if err != nil {
+		fmt.Fprintf(b.w, "unknown command: %v; type h for help\n", arg)
+		return nil
+	}
+	if num < 0 || num >= len(b.report.States) {
+		fmt.Fprintf(b.w, "no such state: %v\n", num)
+		return nil
+	}
+	return b.visit(num)
+}
+
+func (b *stateBrowser) follow(sym string) error {
+	state := b.report.States[b.current]
+	for _, tran := range state.Shift {
+		if b.report.Terminals[tran.Symbol].Name == sym {
+			return b.visit(tran.State)
+		}
+	}
+	for _, tran := range state.GoTo {
+		if b.report.NonTerminals[tran.Symbol].Name == sym {
+			return b.visit(tran.State)
+		}
+	}
+	fmt.Fprintf(b.w, "state %v has no transition on %v\n", b.current, sym)
+	return nil
+}
+
+func (b *stateBrowser) visit(num int) error {
+	if num != b.current {
+		b.history = append(b.history, b.current)
+		b.current = num
+	}
+	return b.showState(num)
+}
+
+func (b *stateBrowser) find(text string) error {
+	if text == "" {
+		fmt.Fprintln(b.w, "find needs a text to search for")
+		return nil
+	}
+	b.clear()
+	printProduction := b.fns["printProduction"].(func(spec.Production) string)
+	var found bool
+	for _, prod := range b.report.Productions[1:] {
+		p := printProduction(*prod)
+		if !strings.Contains(p, text) {
+			continue
+		}
+		found = true
+		var states []string
+		for _, state := range b.report.States {
+			for _, item := range state.Kernel {
+				if item.Production == prod.Number {
+					states = append(states, strconv.Itoa(state.Number))
+					break
+				}
+			}
+		}
+		fmt.Fprintln(b.w, p)
+		if len(states) > 0 {
+			fmt.Fprintf(b.w, "     in the kernels of states %v\n", strings.Join(states, ", "))
+		}
+	}
+	if !found {
+		fmt.Fprintf(b.w, "no production contains %v\n", text)
+	}
+	return nil
+}
+
+func (b *stateBrowser) showState(num int) error {
+	b.clear()
+	return b.stateTmpl.Execute(b.w, b.report.States[num])
+}
+
+// clear clears the screen so that the output of each command starts at the top.
+func (b *stateBrowser) clear() {
+	if b.interactive {
+		fmt.Fprint(b.w, "\x1b[H\x1b[2J")
+	}
+}
+
+// isTerminal reports whether a file is a terminal (a character device).
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStateBrowser(t *testing.T) {
+	report := buildReport(t, `
+#name test;
+
+s
+	: s add foo
+	| foo
+	;
+
+add
+	: '+';
+foo
+	: 'foo';
+`)
+
+	tests := []struct {
+		caption  string
+		commands string
+		// outputs are the texts the output must contain in order.
+		outputs []string
+		// excludes are the texts the output must not contain.
+		excludes []string
+	}{
+		{
+			caption:  "the browser shows state 0 first and follows transitions",
+			commands: "go foo\nback\n",
+			outputs: []string{
+				"# State 0\n",
+				"shift     2 on foo\n",
+				"# State 2\n",
+				"s → foo ・\n",
+				"# State 0\n",
+			},
+		},
+		{
+			caption:  "the browser jumps to a state and lists states",
+			commands: "1\nlist\nconflicts\n",
+			outputs: []string{
+				"# State 1\n",
+				"s' → s ・\n",
+				"   0  1 s' → ・ s\n",
+				"No conflict\n",
+			},
+		},
+		{
+			caption:  "the browser finds productions containing a text",
+			commands: "find add\n/foo\n",
+			outputs: []string{
+				"s → s add foo\n",
+				"in the kernels of states 1, 3, 4\n",
+				"s → s add foo\n",
+				"s → foo\n",
+			},
+		},
+		{
+			caption:  "mistakes in commands aren't errors",
+			commands: "9\nstate x\ngo bar\nback\nfind\n",
+			outputs: []string{
+				"no such state: 9\n",
+				"unknown command: x; type h for help\n",
+				"state 0 has no transition on bar\n",
+				"no previous state\n",
+				"find needs a text to search for\n",
+			},
+		},
+		{
+			caption:  "the browser stops at quit",
+			commands: "help\nquit\n2\n",
+			outputs: []string{
+				"Commands:\n",
+			},
+			excludes: []string{
+				"# State 2\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			var w strings.Builder
+			b, err := newStateBrowser(&w, report, &reportOptions{}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = b.run(strings.NewReader(tt.commands))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := w.String()
+			if strings.Contains(out, "\x1b[") {
+				t.Fatalf("a non-interactive browser must not write escape sequences:\n%v", out)
+			}
+			rest := out
+			for _, o := range tt.outputs {
+				i := strings.Index(rest, o)
+				if i < 0 {
+					t.Fatalf("the output doesn't contain %q in order:\n%v", o, out)
+				}
+				rest = rest[i+len(o):]
+			}
+			for _, e := range tt.excludes {
+				if strings.Contains(out, e) {
+					t.Fatalf("the output must not contain %q:\n%v", e, out)
+				}
+			}
+		})
+	}
+}
+
+func TestStateBrowser_LexerOnly(t *testing.T) {
+	report := buildReport(t, `
+#name test;
+
+foo
+	: 'foo';
+`)
+	_, err := newStateBrowser(&strings.Builder{}, report, &reportOptions{}, false)
+	if err == nil {
+		t.Fatal("a browser of a lexer-only grammar must fail")
+	}
+}
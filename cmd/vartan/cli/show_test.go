@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func buildReport(t *testing.T, src string) *spec.Report {
+	t.Helper()
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	_, report, err := b.Build(grammar.EnableReporting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	tests := []struct {
+		caption  string
+		src      string
+		contains []string
+		excludes []string
+	}{
+		{
+			caption: "a report of a grammar having productions",
+			src: `
+#name test;
+
+s
+	: foo
+	;
+
+foo
+	: 'foo';
+`,
+			contains: []string{
+				`<h2 id="terminals">`,
+				`<h2 id="productions">`,
+				`<h2 id="states">`,
+				`<section id="state-0"`,
+			},
+		},
+		{
+			caption: "a report of a lexer-only grammar",
+			src: `
+#name test;
+
+foo
+	: 'foo';
+`,
+			contains: []string{
+				`<h2 id="terminals">`,
+				`<h2 id="lex-modes">`,
+			},
+			excludes: []string{
+				`<h2 id="productions">`,
+				`<h2 id="states">`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			var b strings.Builder
+			err := writeHTMLReport(&b, buildReport(t, tt.src), &reportOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			html := b.String()
+			for _, s := range tt.contains {
+				if !strings.Contains(html, s) {
+					t.Errorf("the report must contain %v", s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(html, s) {
+					t.Errorf("the report must not contain %v", s)
+				}
+			}
+		})
+	}
+}
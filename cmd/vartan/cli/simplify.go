@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/grammar"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+	"github.com/spf13/cobra"
+)
+
+var simplifyFlags = struct {
+	write *bool
+}{}
+
+func newSimplifyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simplify [<grammar file path>]",
+		Short: "Inline trivial non-terminal symbols and remove unit alternatives and duplicate alternatives",
+		Long: `simplify transforms a grammar into an equivalent smaller one and prints it in the canonical layout.
+It inlines trivial non-terminal symbols, replaces alternatives consisting only of a non-terminal symbol with the
+alternatives of the symbol, and merges identical alternatives. The simplified grammar accepts the same language, but
+the nodes of the removed symbols disappear from syntax trees.
+simplify prints a summary of the transformations and the sizes of the parsing tables to stderr. It refuses to simplify
+a grammar when the result has more conflicts than the original.`,
+		Example: `  vartan simplify grammar.vartan
+  vartan simplify -w grammar.vartan`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runSimplify,
+	}
+	simplifyFlags.write = cmd.Flags().BoolP("write", "w", false, "write the result to the file instead of stdout")
+	return cmd
+}
+
+func runSimplify(cmd *cobra.Command, args []string) (retErr error) {
+	var src []byte
+	var grmPath string
+	sourceName := "stdin"
+	if len(args) > 0 && args[0] != stdioPath {
+		grmPath = args[0]
+		sourceName = args[0]
+
+		var err error
+		src, err = os.ReadFile(grmPath)
+		if err != nil {
+			return fmt.Errorf("Cannot read the grammar file %s: %w", grmPath, err)
+		}
+	} else {
+		if *simplifyFlags.write {
+			return fmt.Errorf("--write needs a grammar file path")
+		}
+		var err error
+		src, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+	}
+	defer func() {
+		if specErrs, ok := retErr.(verr.SpecErrors); ok {
+			for _, err := range specErrs {
+				err.FilePath = grmPath
+				err.SourceName = sourceName
+			}
+		}
+	}()
+
+	cgOrig, reportOrig, err := buildGrammarSource(src)
+	if err != nil {
+		return err
+	}
+
+	ast, err := parser.Parse(bytes.NewReader(src))
+	if err != nil {
+		return err
+	}
+	result := grammar.Simplify(ast)
+	var b bytes.Buffer
+	err = parser.Format(&b, ast)
+	if err != nil {
+		return err
+	}
+	simplified := b.Bytes()
+	cg, report, err := buildGrammarSource(simplified)
+	if err != nil {
+		return fmt.Errorf("the simplified grammar is invalid: %w", err)
+	}
+
+	writeSimplification(os.Stderr, result, cgOrig, reportOrig, cg, report)
+	if n, nOrig := countConflicts(report), countConflicts(reportOrig); n > nOrig {
+		return fmt.Errorf("the simplified grammar has more conflicts than the original: %v -> %v", nOrig, n)
+	}
+
+	if *simplifyFlags.write {
+		if bytes.Equal(src, simplified) {
+			return nil
+		}
+		err := os.WriteFile(grmPath, simplified, 0644)
+		if err != nil {
+			return fmt.Errorf("Cannot write the grammar file %s: %w", grmPath, err)
+		}
+		return nil
+	}
+	_, err = os.Stdout.Write(simplified)
+	return err
+}
+
+func buildGrammarSource(src []byte) (*spec.CompiledGrammar, *spec.Report, error) {
+	ast, err := parser.Parse(bytes.NewReader(src))
+	if err != nil {
+		return nil, nil, err
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	// Grammars converted from other formats may have identical alternatives, and simplify merges them.
+	return b.Build(grammar.EnableReporting(), grammar.DetectDuplicateAlternativesBy(grammar.DuplicateAlternativePolicyExact))
+}
+
+func writeSimplification(w io.Writer, result *grammar.Simplification, cgOrig *spec.CompiledGrammar, reportOrig *spec.Report, cg *spec.CompiledGrammar, report *spec.Report) {
+	if len(result.InlinedProductions) > 0 {
+		fmt.Fprintf(w, "inlined: %v\n", strings.Join(result.InlinedProductions, ", "))
+	}
+	if result.UnitAlternatives > 0 {
+		fmt.Fprintf(w, "unit alternatives replaced: %v\n", result.UnitAlternatives)
+	}
+	if len(result.RemovedProductions) > 0 {
+		fmt.Fprintf(w, "removed: %v\n", strings.Join(result.RemovedProductions, ", "))
+	}
+	if result.MergedAlternatives > 0 {
+		fmt.Fprintf(w, "duplicate alternatives merged: %v\n", result.MergedAlternatives)
+	}
+	if cgOrig.Syntactic == nil || cg.Syntactic == nil {
+		return
+	}
+	for _, s := range []struct {
+		name       string
+		orig, simp int
+	}{
+		{name: "non-terminals", orig: cgOrig.Syntactic.NonTerminalCount - 2, simp: cg.Syntactic.NonTerminalCount - 2},
+		{name: "productions", orig: len(reportOrig.Productions), simp: len(report.Productions)},
+		{name: "states", orig: cgOrig.Syntactic.StateCount, simp: cg.Syntactic.StateCount},
+		{name: "table entries", orig: len(cgOrig.Syntactic.Action) + len(cgOrig.Syntactic.GoTo), simp: len(cg.Syntactic.Action) + len(cg.Syntactic.GoTo)},
+		{name: "conflicts", orig: countConflicts(reportOrig), simp: countConflicts(report)},
+	} {
+		fmt.Fprintf(w, "%-14v %8v -> %v\n", s.name+":", s.orig, s.simp)
+	}
+}
+
+func countConflicts(report *spec.Report) int {
+	n := 0
+	for _, s := range report.States {
+		n += len(s.SRConflict) + len(s.RRConflict)
+	}
+	return n
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"io"
+	"os"
+)
+
+// stdioPath is a file path that means stdin where a command reads a file and stdout where a command writes a file, so
+// commands can be chained with pipes like `vartan compile g.vartan -o - | vartan parse - src`.
+const stdioPath = "-"
+
+// openInput opens a file to read. When the path is stdioPath, it returns stdin, and closing it does nothing.
+func openInput(path string) (io.ReadCloser, error) {
+	if path == stdioPath {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// readInput reads a whole file. When the path is stdioPath, it reads stdin.
+func readInput(path string) ([]byte, error) {
+	if path == stdioPath {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// sourceNameOf returns a name of a file that diagnostics show.
+func sourceNameOf(path string) string {
+	if path == stdioPath {
+		return "stdin"
+	}
+	return path
+}
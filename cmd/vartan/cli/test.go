@@ -1,4 +1,4 @@
-package main
+package cli
 
 import (
 	"errors"
@@ -9,15 +9,22 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func init() {
+var testFlags = struct {
+	start *string
+}{}
+
+func newTestCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "test <grammar file path> <test file path>|<test directory path>",
-		Short:   "Test a grammar",
-		Example: `  vartan test grammar.vartan test`,
-		Args:    cobra.ExactArgs(2),
-		RunE:    runTest,
+		Use:   "test <grammar file path> <test file path>|<test directory path>",
+		Short: "Test a grammar",
+		Example: `  vartan test grammar.vartan test
+  vartan test grammar.vartan test/expr --rule expr`,
+		Args: cobra.ExactArgs(2),
+		RunE: runTest,
 	}
-	rootCmd.AddCommand(cmd)
+	testFlags.start = cmd.Flags().String("start", "", "non-terminal symbol to start parsing test cases at; it must be the start symbol or a symbol declared by #start (default the start symbol)")
+	cmd.Flags().StringVar(testFlags.start, "rule", "", "same as --start")
+	return cmd
 }
 
 func runTest(cmd *cobra.Command, args []string) error {
@@ -42,8 +49,9 @@ func runTest(cmd *cobra.Command, args []string) error {
 	}
 
 	t := &tester.Tester{
-		Grammar: gram,
-		Cases:   cs,
+		Grammar:    gram,
+		Cases:      cs,
+		EntryPoint: *testFlags.start,
 	}
 	rs := t.Run()
 	testFailed := false
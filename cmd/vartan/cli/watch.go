@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	driver "github.com/nihei9/vartan/driver/parser"
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/normalizer"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+// watchGrammar recompiles a grammar whenever the grammar file or the sample input file changes. When a sample input is
+// given, it parses the input after every successful compilation and prints changes in the syntax tree. This function
+// runs until the process is interrupted.
+func watchGrammar(grmPath string, inputPath string, interval time.Duration) error {
+	var grmStamp, inputStamp fileStamp
+	var lastTree []string
+	for {
+		grmChanged := grmStamp.update(grmPath)
+		inputChanged := inputPath != "" && inputStamp.update(inputPath)
+		if grmChanged || inputChanged {
+			fmt.Fprintf(os.Stdout, "==> %v compiling %v <==\n", time.Now().Format("15:04:05"), grmPath)
+			cg, err := compileGrammar(grmPath, grmPath)
+			if err != nil {
+				if specErrs, ok := err.(verr.SpecErrors); ok {
+					for _, e := range specErrs {
+						e.FilePath = grmPath
+						e.SourceName = grmPath
+					}
+				}
+				reportError("", err)
+			} else {
+				fmt.Fprintf(os.Stdout, "compiled %v\n", cg.Name)
+				if inputPath != "" {
+					tree, err := parseSampleInput(cg, inputPath)
+					if err != nil {
+						reportError(inputPath, err)
+					}
+					if tree != nil {
+						printTreeDiff(lastTree, tree)
+						lastTree = tree
+					}
+				}
+			}
+			// In the JSON format, each compilation writes its own diagnostics.
+			err = flushDiagnostics(os.Stderr)
+			if err != nil {
+				return err
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// fileStamp identifies a version of a file by its modification time and size.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// update reads the current stamp of a file and returns true when it differs from the previous one. A file that
+// doesn't exist has a stamp too, so removing or creating a file is also a change.
+func (s *fileStamp) update(path string) bool {
+	next := fileStamp{}
+	if fi, err := os.Stat(path); err == nil {
+		next = fileStamp{
+			modTime: fi.ModTime(),
+			size:    fi.Size(),
+			exists:  true,
+		}
+	}
+	first := s.modTime.IsZero() && !s.exists
+	changed := first || next != *s
+	*s = next
+	return changed
+}
+
+// parseSampleInput parses an input and returns the lines of the printed syntax tree. Even when syntax errors occur,
+// this function returns a tree if the parser makes one.
+func parseSampleInput(cg *spec.CompiledGrammar, path string) ([]string, error) {
+	if cg.IsLexerOnly() {
+		return nil, fmt.Errorf("%v is a lexer-only grammar. It cannot parse a source", cg.Name)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot open the source file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	toks, err := driver.NewTokenStream(cg, f, lexer.NormalizeWith(normalizer.Default))
+	if err != nil {
+		return nil, err
+	}
+	gram := driver.NewGrammar(cg)
+	tb := driver.NewDefaultSyntaxTreeBuilder()
+	p, err := driver.NewParser(toks, gram, driver.SemanticAction(driver.NewASTActionSet(gram, tb)))
+	if err != nil {
+		return nil, err
+	}
+	err = p.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	var tree []string
+	if t := tb.Tree(); t != nil {
+		var b strings.Builder
+		driver.PrintTree(&b, t)
+		tree = strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	}
+
+	if len(p.SyntaxErrors()) > 0 {
+		return tree, &syntaxErrors{
+			cg:   cg,
+			errs: p.SyntaxErrors(),
+		}
+	}
+
+	return tree, nil
+}
+
+// printTreeDiff prints lines of a syntax tree marking lines added since the previous tree with `+` and lines removed
+// with `-`. When there is no previous tree, this function prints the whole tree.
+func printTreeDiff(prev, next []string) {
+	if prev == nil {
+		for _, l := range next {
+			fmt.Fprintln(os.Stdout, l)
+		}
+		return
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of prev[i:] and next[j:].
+	lcs := make([][]int, len(prev)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(next)+1)
+	}
+	for i := len(prev) - 1; i >= 0; i-- {
+		for j := len(next) - 1; j >= 0; j-- {
+			switch {
+			case prev[i] == next[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	changed := false
+	var b strings.Builder
+	i, j := 0, 0
+	for i < len(prev) || j < len(next) {
+		switch {
+		case i < len(prev) && j < len(next) && prev[i] == next[j]:
+			fmt.Fprintf(&b, "  %v\n", prev[i])
+			i++
+			j++
+		case i < len(prev) && (j == len(next) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&b, "- %v\n", prev[i])
+			changed = true
+			i++
+		default:
+			fmt.Fprintf(&b, "+ %v\n", next[j])
+			changed = true
+			j++
+		}
+	}
+	if !changed {
+		fmt.Fprintln(os.Stdout, "the syntax tree didn't change")
+		return
+	}
+	fmt.Fprint(os.Stdout, b.String())
+}
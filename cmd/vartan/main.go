@@ -1,14 +1,11 @@
 package main
 
 import (
-	"fmt"
 	"os"
+
+	"github.com/nihei9/vartan/cmd/vartan/cli"
 )
 
 func main() {
-	err := Execute()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
+	os.Exit(cli.Execute())
 }
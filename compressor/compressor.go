@@ -39,6 +39,7 @@ type Compressor interface {
 var (
 	_ Compressor = &UniqueEntriesTable{}
 	_ Compressor = &RowDisplacementTable{}
+	_ Compressor = &BaseCheckTable{}
 )
 
 type UniqueEntriesTable struct {
@@ -212,3 +213,173 @@ func (tab *RowDisplacementTable) Compress(orig *OriginalTable) error {
 
 	return nil
 }
+
+// BaseCheckTable is a two-level table in which each row consists of a default row and the entries differing from it.
+// The entries of all rows share a single array, and `Check` tells which row each entry belongs to. When a row doesn't
+// have an entry in a column, a lookup continues in its default row.
+type BaseCheckTable struct {
+	OriginalRowCount int
+	OriginalColCount int
+	EmptyValue       int
+
+	// Base holds the offset of each row in `Next` and `Check`.
+	Base []int
+
+	// Default holds the default row of each row. ForbiddenValue means that a row doesn't have a default row, and
+	// entries absent from it are empty.
+	Default []int
+
+	Next  []int
+	Check []int
+}
+
+func NewBaseCheckTable(emptyValue int) *BaseCheckTable {
+	return &BaseCheckTable{
+		EmptyValue: emptyValue,
+	}
+}
+
+func (tab *BaseCheckTable) Lookup(row int, col int) (int, error) {
+	if row < 0 || row >= tab.OriginalRowCount || col < 0 || col >= tab.OriginalColCount {
+		return tab.EmptyValue, fmt.Errorf("indexes are out of range: [%v, %v]", row, col)
+	}
+	for row != ForbiddenValue {
+		i := tab.Base[row] + col
+		if tab.Check[i] == row {
+			return tab.Next[i], nil
+		}
+		row = tab.Default[row]
+	}
+	return tab.EmptyValue, nil
+}
+
+func (tab *BaseCheckTable) OriginalTableSize() (int, int) {
+	return tab.OriginalRowCount, tab.OriginalColCount
+}
+
+func (tab *BaseCheckTable) Compress(orig *OriginalTable) error {
+	rowOf := func(row int) []int {
+		return orig.entries[row*orig.colCount : (row+1)*orig.colCount]
+	}
+
+	// Choose the row differing from each row in the fewest columns among the preceding rows as its default row. Since
+	// a default row always precedes a row, chains of default rows never loop.
+	defaults := make([]int, orig.rowCount)
+	diffCols := make([][]int, orig.rowCount)
+	{
+		nonEmptyCounts := make([]int, orig.rowCount)
+		for row := 0; row < orig.rowCount; row++ {
+			for _, v := range rowOf(row) {
+				if v != tab.EmptyValue {
+					nonEmptyCounts[row]++
+				}
+			}
+		}
+
+		for row := 0; row < orig.rowCount; row++ {
+			entries := rowOf(row)
+			defaults[row] = ForbiddenValue
+			minDiff := nonEmptyCounts[row]
+			for cand := 0; cand < row && minDiff > 0; cand++ {
+				// The difference of the numbers of non-empty entries is a lower bound of the number of the differing
+				// columns.
+				if d := nonEmptyCounts[cand] - nonEmptyCounts[row]; d >= minDiff || -d >= minDiff {
+					continue
+				}
+				diff := 0
+				for col, v := range rowOf(cand) {
+					if v == entries[col] {
+						continue
+					}
+					diff++
+					if diff >= minDiff {
+						break
+					}
+				}
+				if diff < minDiff {
+					minDiff = diff
+					defaults[row] = cand
+				}
+			}
+
+			if defaults[row] == ForbiddenValue {
+				for col, v := range entries {
+					if v != tab.EmptyValue {
+						diffCols[row] = append(diffCols[row], col)
+					}
+				}
+				continue
+			}
+			for col, v := range rowOf(defaults[row]) {
+				if v != entries[col] {
+					diffCols[row] = append(diffCols[row], col)
+				}
+			}
+		}
+	}
+
+	// Place the rows with more entries first, and fit each row into the lowest offset where its entries don't overlap
+	// the entries already placed.
+	rows := make([]int, orig.rowCount)
+	for i := range rows {
+		rows[i] = i
+	}
+	sort.SliceStable(rows, func(i int, j int) bool {
+		return len(diffCols[rows[i]]) > len(diffCols[rows[j]])
+	})
+
+	base := make([]int, orig.rowCount)
+	next := make([]int, orig.colCount)
+	check := make([]int, orig.colCount)
+	for i := range check {
+		next[i] = tab.EmptyValue
+		check[i] = ForbiddenValue
+	}
+	firstFree := 0
+	for _, row := range rows {
+		cols := diffCols[row]
+		if len(cols) == 0 {
+			continue
+		}
+
+		b := firstFree - cols[0]
+		if b < 0 {
+			b = 0
+		}
+		for ; ; b++ {
+			fits := true
+			for _, col := range cols {
+				if b+col < len(check) && check[b+col] != ForbiddenValue {
+					fits = false
+					break
+				}
+			}
+			if fits {
+				break
+			}
+		}
+
+		base[row] = b
+		for len(check) < b+orig.colCount {
+			next = append(next, tab.EmptyValue)
+			check = append(check, ForbiddenValue)
+		}
+		entries := rowOf(row)
+		for _, col := range cols {
+			next[b+col] = entries[col]
+			check[b+col] = row
+		}
+		for firstFree < len(check) && check[firstFree] != ForbiddenValue {
+			firstFree++
+		}
+	}
+
+	tab.OriginalRowCount = orig.rowCount
+	tab.OriginalColCount = orig.colCount
+	tab.Base = base
+	tab.Default = defaults
+	tab.Next = next
+	tab.Check = check
+
+	return nil
+}
@@ -12,6 +12,7 @@ func TestCompressor_Compress(t *testing.T) {
 		return []Compressor{
 			NewUniqueEntriesTable(),
 			NewRowDisplacementTable(x),
+			NewBaseCheckTable(x),
 		}
 	}
 
@@ -61,6 +62,18 @@ func TestCompressor_Compress(t *testing.T) {
 			colCount:    5,
 			compressors: allCompressors(),
 		},
+		{
+			original: []int{
+				1, 2, 3, 4, 5,
+				1, 2, 3, 4, x,
+				1, 2, x, 4, x,
+				x, x, x, x, 5,
+				1, 2, x, 4, x,
+			},
+			rowCount:    5,
+			colCount:    5,
+			compressors: allCompressors(),
+		},
 	}
 	for i, tt := range tests {
 		for _, comp := range tt.compressors {
@@ -0,0 +1,78 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar/lexical"
+)
+
+// genBenchUCDInput generates a source consisting of words in several scripts, numbers in several scripts, and
+// punctuation, so that a lexer goes through many states of the DFAs of Unicode character properties.
+func genBenchUCDInput() []byte {
+	words := []string{
+		"hello", "wörld", "héllo_1", "Ελληνικά", "русский", "ひらがな", "カタカナ", "العربية", "हिन्दी", "ไทย",
+		"42", "٣٤٥", "१२३", "!", ",", "«", "»", "。", "、",
+	}
+	var b bytes.Buffer
+	for i := 0; b.Len() < 64*1024; i++ {
+		b.WriteString(words[i%len(words)])
+		b.WriteByte(' ')
+		if i%16 == 15 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.Bytes()
+}
+
+// BenchmarkLexer_Next_UCD measures the throughput of the lexer on patterns of Unicode character properties at each
+// compression level and reports the number of integers the transition table consists of as `ints`, for instance:
+//
+//	go test -run '^$' -bench Lexer_Next_UCD -benchmem ./driver/lexer
+func BenchmarkLexer_Next_UCD(b *testing.B) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("ws", `[\u{0009}\u{0020}\n]+`),
+			newLexEntryDefaultNOP("id", `[\p{L}_][\p{L}\p{Mn}\p{Mc}\p{Nd}_]*`),
+			newLexEntryDefaultNOP("num", `\p{Nd}+`),
+			newLexEntryDefaultNOP("punct", `\p{P}`),
+		},
+	}
+	src := genBenchUCDInput()
+	for compLv := lexical.CompressionLevelMin; compLv <= lexical.CompressionLevelMax; compLv++ {
+		clspec, report, err, _ := lexical.CompileAndReport(lspec, compLv)
+		if err != nil {
+			b.Fatal(err)
+		}
+		sizes := report.Modes[0].TableSizes
+		size := []int{
+			sizes.Uncompressed,
+			sizes.UniqueEntries,
+			sizes.RowDisplacement,
+			sizes.BaseCheck,
+		}[compLv]
+		b.Run(fmt.Sprintf("level-%v", compLv), func(b *testing.B) {
+			b.SetBytes(int64(len(src)))
+			b.ReportMetric(float64(size), "ints")
+			for i := 0; i < b.N; i++ {
+				lexer, err := NewLexer(NewLexSpec(clspec), bytes.NewReader(src))
+				if err != nil {
+					b.Fatal(err)
+				}
+				for {
+					tok, err := lexer.Next()
+					if err != nil {
+						b.Fatal(err)
+					}
+					if tok.Invalid {
+						b.Fatalf("unexpected invalid token: %q", tok.Lexeme)
+					}
+					if tok.EOF {
+						break
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,633 @@
+// Code generated by cmd/graphemegen; DO NOT EDIT.
+
+package lexer
+
+// graphemeClassRanges holds the code point ranges of the grapheme classes in the order of their first code points.
+// Code points out of the ranges are graphemeClassOther. The table has no Hangul syllables because graphemeClassOf
+// computes whether they are LV or LVT.
+var graphemeClassRanges = []graphemeClassRange{
+	{0x0000, 0x0009, graphemeClassControl},
+	{0x000A, 0x000A, graphemeClassLF},
+	{0x000B, 0x000C, graphemeClassControl},
+	{0x000D, 0x000D, graphemeClassCR},
+	{0x000E, 0x001F, graphemeClassControl},
+	{0x007F, 0x009F, graphemeClassControl},
+	{0x00A9, 0x00A9, graphemeClassExtPict},
+	{0x00AD, 0x00AD, graphemeClassControl},
+	{0x00AE, 0x00AE, graphemeClassExtPict},
+	{0x0300, 0x036F, graphemeClassExtend},
+	{0x0483, 0x0489, graphemeClassExtend},
+	{0x0591, 0x05BD, graphemeClassExtend},
+	{0x05BF, 0x05BF, graphemeClassExtend},
+	{0x05C1, 0x05C2, graphemeClassExtend},
+	{0x05C4, 0x05C5, graphemeClassExtend},
+	{0x05C7, 0x05C7, graphemeClassExtend},
+	{0x0600, 0x0605, graphemeClassPrepend},
+	{0x0610, 0x061A, graphemeClassExtend},
+	{0x061C, 0x061C, graphemeClassControl},
+	{0x064B, 0x065F, graphemeClassExtend},
+	{0x0670, 0x0670, graphemeClassExtend},
+	{0x06D6, 0x06DC, graphemeClassExtend},
+	{0x06DD, 0x06DD, graphemeClassPrepend},
+	{0x06DF, 0x06E4, graphemeClassExtend},
+	{0x06E7, 0x06E8, graphemeClassExtend},
+	{0x06EA, 0x06ED, graphemeClassExtend},
+	{0x070F, 0x070F, graphemeClassPrepend},
+	{0x0711, 0x0711, graphemeClassExtend},
+	{0x0730, 0x074A, graphemeClassExtend},
+	{0x07A6, 0x07B0, graphemeClassExtend},
+	{0x07EB, 0x07F3, graphemeClassExtend},
+	{0x07FD, 0x07FD, graphemeClassExtend},
+	{0x0816, 0x0819, graphemeClassExtend},
+	{0x081B, 0x0823, graphemeClassExtend},
+	{0x0825, 0x0827, graphemeClassExtend},
+	{0x0829, 0x082D, graphemeClassExtend},
+	{0x0859, 0x085B, graphemeClassExtend},
+	{0x08D3, 0x08E1, graphemeClassExtend},
+	{0x08E2, 0x08E2, graphemeClassPrepend},
+	{0x08E3, 0x0902, graphemeClassExtend},
+	{0x0903, 0x0903, graphemeClassSpacingMark},
+	{0x093A, 0x093A, graphemeClassExtend},
+	{0x093B, 0x093B, graphemeClassSpacingMark},
+	{0x093C, 0x093C, graphemeClassExtend},
+	{0x093E, 0x0940, graphemeClassSpacingMark},
+	{0x0941, 0x0948, graphemeClassExtend},
+	{0x0949, 0x094C, graphemeClassSpacingMark},
+	{0x094D, 0x094D, graphemeClassExtend},
+	{0x094E, 0x094F, graphemeClassSpacingMark},
+	{0x0951, 0x0957, graphemeClassExtend},
+	{0x0962, 0x0963, graphemeClassExtend},
+	{0x0981, 0x0981, graphemeClassExtend},
+	{0x0982, 0x0983, graphemeClassSpacingMark},
+	{0x09BC, 0x09BC, graphemeClassExtend},
+	{0x09BE, 0x09BE, graphemeClassExtend},
+	{0x09BF, 0x09C0, graphemeClassSpacingMark},
+	{0x09C1, 0x09C4, graphemeClassExtend},
+	{0x09C7, 0x09C8, graphemeClassSpacingMark},
+	{0x09CB, 0x09CC, graphemeClassSpacingMark},
+	{0x09CD, 0x09CD, graphemeClassExtend},
+	{0x09D7, 0x09D7, graphemeClassExtend},
+	{0x09E2, 0x09E3, graphemeClassExtend},
+	{0x09FE, 0x09FE, graphemeClassExtend},
+	{0x0A01, 0x0A02, graphemeClassExtend},
+	{0x0A03, 0x0A03, graphemeClassSpacingMark},
+	{0x0A3C, 0x0A3C, graphemeClassExtend},
+	{0x0A3E, 0x0A40, graphemeClassSpacingMark},
+	{0x0A41, 0x0A42, graphemeClassExtend},
+	{0x0A47, 0x0A48, graphemeClassExtend},
+	{0x0A4B, 0x0A4D, graphemeClassExtend},
+	{0x0A51, 0x0A51, graphemeClassExtend},
+	{0x0A70, 0x0A71, graphemeClassExtend},
+	{0x0A75, 0x0A75, graphemeClassExtend},
+	{0x0A81, 0x0A82, graphemeClassExtend},
+	{0x0A83, 0x0A83, graphemeClassSpacingMark},
+	{0x0ABC, 0x0ABC, graphemeClassExtend},
+	{0x0ABE, 0x0AC0, graphemeClassSpacingMark},
+	{0x0AC1, 0x0AC5, graphemeClassExtend},
+	{0x0AC7, 0x0AC8, graphemeClassExtend},
+	{0x0AC9, 0x0AC9, graphemeClassSpacingMark},
+	{0x0ACB, 0x0ACC, graphemeClassSpacingMark},
+	{0x0ACD, 0x0ACD, graphemeClassExtend},
+	{0x0AE2, 0x0AE3, graphemeClassExtend},
+	{0x0AFA, 0x0AFF, graphemeClassExtend},
+	{0x0B01, 0x0B01, graphemeClassExtend},
+	{0x0B02, 0x0B03, graphemeClassSpacingMark},
+	{0x0B3C, 0x0B3C, graphemeClassExtend},
+	{0x0B3E, 0x0B3F, graphemeClassExtend},
+	{0x0B40, 0x0B40, graphemeClassSpacingMark},
+	{0x0B41, 0x0B44, graphemeClassExtend},
+	{0x0B47, 0x0B48, graphemeClassSpacingMark},
+	{0x0B4B, 0x0B4C, graphemeClassSpacingMark},
+	{0x0B4D, 0x0B4D, graphemeClassExtend},
+	{0x0B55, 0x0B57, graphemeClassExtend},
+	{0x0B62, 0x0B63, graphemeClassExtend},
+	{0x0B82, 0x0B82, graphemeClassExtend},
+	{0x0BBE, 0x0BBE, graphemeClassExtend},
+	{0x0BBF, 0x0BBF, graphemeClassSpacingMark},
+	{0x0BC0, 0x0BC0, graphemeClassExtend},
+	{0x0BC1, 0x0BC2, graphemeClassSpacingMark},
+	{0x0BC6, 0x0BC8, graphemeClassSpacingMark},
+	{0x0BCA, 0x0BCC, graphemeClassSpacingMark},
+	{0x0BCD, 0x0BCD, graphemeClassExtend},
+	{0x0BD7, 0x0BD7, graphemeClassExtend},
+	{0x0C00, 0x0C00, graphemeClassExtend},
+	{0x0C01, 0x0C03, graphemeClassSpacingMark},
+	{0x0C04, 0x0C04, graphemeClassExtend},
+	{0x0C3E, 0x0C40, graphemeClassExtend},
+	{0x0C41, 0x0C44, graphemeClassSpacingMark},
+	{0x0C46, 0x0C48, graphemeClassExtend},
+	{0x0C4A, 0x0C4D, graphemeClassExtend},
+	{0x0C55, 0x0C56, graphemeClassExtend},
+	{0x0C62, 0x0C63, graphemeClassExtend},
+	{0x0C81, 0x0C81, graphemeClassExtend},
+	{0x0C82, 0x0C83, graphemeClassSpacingMark},
+	{0x0CBC, 0x0CBC, graphemeClassExtend},
+	{0x0CBE, 0x0CBE, graphemeClassSpacingMark},
+	{0x0CBF, 0x0CBF, graphemeClassExtend},
+	{0x0CC0, 0x0CC1, graphemeClassSpacingMark},
+	{0x0CC2, 0x0CC2, graphemeClassExtend},
+	{0x0CC3, 0x0CC4, graphemeClassSpacingMark},
+	{0x0CC6, 0x0CC6, graphemeClassExtend},
+	{0x0CC7, 0x0CC8, graphemeClassSpacingMark},
+	{0x0CCA, 0x0CCB, graphemeClassSpacingMark},
+	{0x0CCC, 0x0CCD, graphemeClassExtend},
+	{0x0CD5, 0x0CD6, graphemeClassExtend},
+	{0x0CE2, 0x0CE3, graphemeClassExtend},
+	{0x0D00, 0x0D01, graphemeClassExtend},
+	{0x0D02, 0x0D03, graphemeClassSpacingMark},
+	{0x0D3B, 0x0D3C, graphemeClassExtend},
+	{0x0D3E, 0x0D3E, graphemeClassExtend},
+	{0x0D3F, 0x0D40, graphemeClassSpacingMark},
+	{0x0D41, 0x0D44, graphemeClassExtend},
+	{0x0D46, 0x0D48, graphemeClassSpacingMark},
+	{0x0D4A, 0x0D4C, graphemeClassSpacingMark},
+	{0x0D4D, 0x0D4D, graphemeClassExtend},
+	{0x0D4E, 0x0D4E, graphemeClassPrepend},
+	{0x0D57, 0x0D57, graphemeClassExtend},
+	{0x0D62, 0x0D63, graphemeClassExtend},
+	{0x0D81, 0x0D81, graphemeClassExtend},
+	{0x0D82, 0x0D83, graphemeClassSpacingMark},
+	{0x0DCA, 0x0DCA, graphemeClassExtend},
+	{0x0DCF, 0x0DCF, graphemeClassExtend},
+	{0x0DD0, 0x0DD1, graphemeClassSpacingMark},
+	{0x0DD2, 0x0DD4, graphemeClassExtend},
+	{0x0DD6, 0x0DD6, graphemeClassExtend},
+	{0x0DD8, 0x0DDE, graphemeClassSpacingMark},
+	{0x0DDF, 0x0DDF, graphemeClassExtend},
+	{0x0DF2, 0x0DF3, graphemeClassSpacingMark},
+	{0x0E31, 0x0E31, graphemeClassExtend},
+	{0x0E33, 0x0E33, graphemeClassSpacingMark},
+	{0x0E34, 0x0E3A, graphemeClassExtend},
+	{0x0E47, 0x0E4E, graphemeClassExtend},
+	{0x0EB1, 0x0EB1, graphemeClassExtend},
+	{0x0EB3, 0x0EB3, graphemeClassSpacingMark},
+	{0x0EB4, 0x0EBC, graphemeClassExtend},
+	{0x0EC8, 0x0ECD, graphemeClassExtend},
+	{0x0F18, 0x0F19, graphemeClassExtend},
+	{0x0F35, 0x0F35, graphemeClassExtend},
+	{0x0F37, 0x0F37, graphemeClassExtend},
+	{0x0F39, 0x0F39, graphemeClassExtend},
+	{0x0F3E, 0x0F3F, graphemeClassSpacingMark},
+	{0x0F71, 0x0F7E, graphemeClassExtend},
+	{0x0F7F, 0x0F7F, graphemeClassSpacingMark},
+	{0x0F80, 0x0F84, graphemeClassExtend},
+	{0x0F86, 0x0F87, graphemeClassExtend},
+	{0x0F8D, 0x0F97, graphemeClassExtend},
+	{0x0F99, 0x0FBC, graphemeClassExtend},
+	{0x0FC6, 0x0FC6, graphemeClassExtend},
+	{0x102D, 0x1030, graphemeClassExtend},
+	{0x1031, 0x1031, graphemeClassSpacingMark},
+	{0x1032, 0x1037, graphemeClassExtend},
+	{0x1039, 0x103A, graphemeClassExtend},
+	{0x103B, 0x103C, graphemeClassSpacingMark},
+	{0x103D, 0x103E, graphemeClassExtend},
+	{0x1056, 0x1057, graphemeClassSpacingMark},
+	{0x1058, 0x1059, graphemeClassExtend},
+	{0x105E, 0x1060, graphemeClassExtend},
+	{0x1071, 0x1074, graphemeClassExtend},
+	{0x1082, 0x1082, graphemeClassExtend},
+	{0x1084, 0x1084, graphemeClassSpacingMark},
+	{0x1085, 0x1086, graphemeClassExtend},
+	{0x108D, 0x108D, graphemeClassExtend},
+	{0x109D, 0x109D, graphemeClassExtend},
+	{0x1100, 0x115F, graphemeClassL},
+	{0x1160, 0x11A7, graphemeClassV},
+	{0x11A8, 0x11FF, graphemeClassT},
+	{0x135D, 0x135F, graphemeClassExtend},
+	{0x1712, 0x1714, graphemeClassExtend},
+	{0x1732, 0x1733, graphemeClassExtend},
+	{0x1734, 0x1734, graphemeClassSpacingMark},
+	{0x1752, 0x1753, graphemeClassExtend},
+	{0x1772, 0x1773, graphemeClassExtend},
+	{0x17B4, 0x17B5, graphemeClassExtend},
+	{0x17B6, 0x17B6, graphemeClassSpacingMark},
+	{0x17B7, 0x17BD, graphemeClassExtend},
+	{0x17BE, 0x17C5, graphemeClassSpacingMark},
+	{0x17C6, 0x17C6, graphemeClassExtend},
+	{0x17C7, 0x17C8, graphemeClassSpacingMark},
+	{0x17C9, 0x17D3, graphemeClassExtend},
+	{0x17DD, 0x17DD, graphemeClassExtend},
+	{0x180B, 0x180D, graphemeClassExtend},
+	{0x180E, 0x180E, graphemeClassControl},
+	{0x1885, 0x1886, graphemeClassExtend},
+	{0x18A9, 0x18A9, graphemeClassExtend},
+	{0x1920, 0x1922, graphemeClassExtend},
+	{0x1923, 0x1926, graphemeClassSpacingMark},
+	{0x1927, 0x1928, graphemeClassExtend},
+	{0x1929, 0x192B, graphemeClassSpacingMark},
+	{0x1930, 0x1931, graphemeClassSpacingMark},
+	{0x1932, 0x1932, graphemeClassExtend},
+	{0x1933, 0x1938, graphemeClassSpacingMark},
+	{0x1939, 0x193B, graphemeClassExtend},
+	{0x1A17, 0x1A18, graphemeClassExtend},
+	{0x1A19, 0x1A1A, graphemeClassSpacingMark},
+	{0x1A1B, 0x1A1B, graphemeClassExtend},
+	{0x1A55, 0x1A55, graphemeClassSpacingMark},
+	{0x1A56, 0x1A56, graphemeClassExtend},
+	{0x1A57, 0x1A57, graphemeClassSpacingMark},
+	{0x1A58, 0x1A5E, graphemeClassExtend},
+	{0x1A60, 0x1A60, graphemeClassExtend},
+	{0x1A62, 0x1A62, graphemeClassExtend},
+	{0x1A65, 0x1A6C, graphemeClassExtend},
+	{0x1A6D, 0x1A72, graphemeClassSpacingMark},
+	{0x1A73, 0x1A7C, graphemeClassExtend},
+	{0x1A7F, 0x1A7F, graphemeClassExtend},
+	{0x1AB0, 0x1AC0, graphemeClassExtend},
+	{0x1B00, 0x1B03, graphemeClassExtend},
+	{0x1B04, 0x1B04, graphemeClassSpacingMark},
+	{0x1B34, 0x1B3A, graphemeClassExtend},
+	{0x1B3B, 0x1B3B, graphemeClassSpacingMark},
+	{0x1B3C, 0x1B3C, graphemeClassExtend},
+	{0x1B3D, 0x1B41, graphemeClassSpacingMark},
+	{0x1B42, 0x1B42, graphemeClassExtend},
+	{0x1B43, 0x1B44, graphemeClassSpacingMark},
+	{0x1B6B, 0x1B73, graphemeClassExtend},
+	{0x1B80, 0x1B81, graphemeClassExtend},
+	{0x1B82, 0x1B82, graphemeClassSpacingMark},
+	{0x1BA1, 0x1BA1, graphemeClassSpacingMark},
+	{0x1BA2, 0x1BA5, graphemeClassExtend},
+	{0x1BA6, 0x1BA7, graphemeClassSpacingMark},
+	{0x1BA8, 0x1BA9, graphemeClassExtend},
+	{0x1BAA, 0x1BAA, graphemeClassSpacingMark},
+	{0x1BAB, 0x1BAD, graphemeClassExtend},
+	{0x1BE6, 0x1BE6, graphemeClassExtend},
+	{0x1BE7, 0x1BE7, graphemeClassSpacingMark},
+	{0x1BE8, 0x1BE9, graphemeClassExtend},
+	{0x1BEA, 0x1BEC, graphemeClassSpacingMark},
+	{0x1BED, 0x1BED, graphemeClassExtend},
+	{0x1BEE, 0x1BEE, graphemeClassSpacingMark},
+	{0x1BEF, 0x1BF1, graphemeClassExtend},
+	{0x1BF2, 0x1BF3, graphemeClassSpacingMark},
+	{0x1C24, 0x1C2B, graphemeClassSpacingMark},
+	{0x1C2C, 0x1C33, graphemeClassExtend},
+	{0x1C34, 0x1C35, graphemeClassSpacingMark},
+	{0x1C36, 0x1C37, graphemeClassExtend},
+	{0x1CD0, 0x1CD2, graphemeClassExtend},
+	{0x1CD4, 0x1CE0, graphemeClassExtend},
+	{0x1CE1, 0x1CE1, graphemeClassSpacingMark},
+	{0x1CE2, 0x1CE8, graphemeClassExtend},
+	{0x1CED, 0x1CED, graphemeClassExtend},
+	{0x1CF4, 0x1CF4, graphemeClassExtend},
+	{0x1CF7, 0x1CF7, graphemeClassSpacingMark},
+	{0x1CF8, 0x1CF9, graphemeClassExtend},
+	{0x1DC0, 0x1DF9, graphemeClassExtend},
+	{0x1DFB, 0x1DFF, graphemeClassExtend},
+	{0x200B, 0x200B, graphemeClassControl},
+	{0x200C, 0x200C, graphemeClassExtend},
+	{0x200D, 0x200D, graphemeClassZWJ},
+	{0x200E, 0x200F, graphemeClassControl},
+	{0x2028, 0x202E, graphemeClassControl},
+	{0x203C, 0x203C, graphemeClassExtPict},
+	{0x2049, 0x2049, graphemeClassExtPict},
+	{0x2060, 0x206F, graphemeClassControl},
+	{0x20D0, 0x20F0, graphemeClassExtend},
+	{0x2122, 0x2122, graphemeClassExtPict},
+	{0x2139, 0x2139, graphemeClassExtPict},
+	{0x2194, 0x2199, graphemeClassExtPict},
+	{0x21A9, 0x21AA, graphemeClassExtPict},
+	{0x231A, 0x231B, graphemeClassExtPict},
+	{0x2328, 0x2328, graphemeClassExtPict},
+	{0x2388, 0x2388, graphemeClassExtPict},
+	{0x23CF, 0x23CF, graphemeClassExtPict},
+	{0x23E9, 0x23F3, graphemeClassExtPict},
+	{0x23F8, 0x23FA, graphemeClassExtPict},
+	{0x24C2, 0x24C2, graphemeClassExtPict},
+	{0x25AA, 0x25AB, graphemeClassExtPict},
+	{0x25B6, 0x25B6, graphemeClassExtPict},
+	{0x25C0, 0x25C0, graphemeClassExtPict},
+	{0x25FB, 0x25FE, graphemeClassExtPict},
+	{0x2600, 0x2605, graphemeClassExtPict},
+	{0x2607, 0x2612, graphemeClassExtPict},
+	{0x2614, 0x2685, graphemeClassExtPict},
+	{0x2690, 0x2705, graphemeClassExtPict},
+	{0x2708, 0x2712, graphemeClassExtPict},
+	{0x2714, 0x2714, graphemeClassExtPict},
+	{0x2716, 0x2716, graphemeClassExtPict},
+	{0x271D, 0x271D, graphemeClassExtPict},
+	{0x2721, 0x2721, graphemeClassExtPict},
+	{0x2728, 0x2728, graphemeClassExtPict},
+	{0x2733, 0x2734, graphemeClassExtPict},
+	{0x2744, 0x2744, graphemeClassExtPict},
+	{0x2747, 0x2747, graphemeClassExtPict},
+	{0x274C, 0x274C, graphemeClassExtPict},
+	{0x274E, 0x274E, graphemeClassExtPict},
+	{0x2753, 0x2755, graphemeClassExtPict},
+	{0x2757, 0x2757, graphemeClassExtPict},
+	{0x2763, 0x2767, graphemeClassExtPict},
+	{0x2795, 0x2797, graphemeClassExtPict},
+	{0x27A1, 0x27A1, graphemeClassExtPict},
+	{0x27B0, 0x27B0, graphemeClassExtPict},
+	{0x27BF, 0x27BF, graphemeClassExtPict},
+	{0x2934, 0x2935, graphemeClassExtPict},
+	{0x2B05, 0x2B07, graphemeClassExtPict},
+	{0x2B1B, 0x2B1C, graphemeClassExtPict},
+	{0x2B50, 0x2B50, graphemeClassExtPict},
+	{0x2B55, 0x2B55, graphemeClassExtPict},
+	{0x2CEF, 0x2CF1, graphemeClassExtend},
+	{0x2D7F, 0x2D7F, graphemeClassExtend},
+	{0x2DE0, 0x2DFF, graphemeClassExtend},
+	{0x302A, 0x302F, graphemeClassExtend},
+	{0x3030, 0x3030, graphemeClassExtPict},
+	{0x303D, 0x303D, graphemeClassExtPict},
+	{0x3099, 0x309A, graphemeClassExtend},
+	{0x3297, 0x3297, graphemeClassExtPict},
+	{0x3299, 0x3299, graphemeClassExtPict},
+	{0xA66F, 0xA672, graphemeClassExtend},
+	{0xA674, 0xA67D, graphemeClassExtend},
+	{0xA69E, 0xA69F, graphemeClassExtend},
+	{0xA6F0, 0xA6F1, graphemeClassExtend},
+	{0xA802, 0xA802, graphemeClassExtend},
+	{0xA806, 0xA806, graphemeClassExtend},
+	{0xA80B, 0xA80B, graphemeClassExtend},
+	{0xA823, 0xA824, graphemeClassSpacingMark},
+	{0xA825, 0xA826, graphemeClassExtend},
+	{0xA827, 0xA827, graphemeClassSpacingMark},
+	{0xA82C, 0xA82C, graphemeClassExtend},
+	{0xA880, 0xA881, graphemeClassSpacingMark},
+	{0xA8B4, 0xA8C3, graphemeClassSpacingMark},
+	{0xA8C4, 0xA8C5, graphemeClassExtend},
+	{0xA8E0, 0xA8F1, graphemeClassExtend},
+	{0xA8FF, 0xA8FF, graphemeClassExtend},
+	{0xA926, 0xA92D, graphemeClassExtend},
+	{0xA947, 0xA951, graphemeClassExtend},
+	{0xA952, 0xA953, graphemeClassSpacingMark},
+	{0xA960, 0xA97C, graphemeClassL},
+	{0xA980, 0xA982, graphemeClassExtend},
+	{0xA983, 0xA983, graphemeClassSpacingMark},
+	{0xA9B3, 0xA9B3, graphemeClassExtend},
+	{0xA9B4, 0xA9B5, graphemeClassSpacingMark},
+	{0xA9B6, 0xA9B9, graphemeClassExtend},
+	{0xA9BA, 0xA9BB, graphemeClassSpacingMark},
+	{0xA9BC, 0xA9BD, graphemeClassExtend},
+	{0xA9BE, 0xA9C0, graphemeClassSpacingMark},
+	{0xA9E5, 0xA9E5, graphemeClassExtend},
+	{0xAA29, 0xAA2E, graphemeClassExtend},
+	{0xAA2F, 0xAA30, graphemeClassSpacingMark},
+	{0xAA31, 0xAA32, graphemeClassExtend},
+	{0xAA33, 0xAA34, graphemeClassSpacingMark},
+	{0xAA35, 0xAA36, graphemeClassExtend},
+	{0xAA43, 0xAA43, graphemeClassExtend},
+	{0xAA4C, 0xAA4C, graphemeClassExtend},
+	{0xAA4D, 0xAA4D, graphemeClassSpacingMark},
+	{0xAA7C, 0xAA7C, graphemeClassExtend},
+	{0xAAB0, 0xAAB0, graphemeClassExtend},
+	{0xAAB2, 0xAAB4, graphemeClassExtend},
+	{0xAAB7, 0xAAB8, graphemeClassExtend},
+	{0xAABE, 0xAABF, graphemeClassExtend},
+	{0xAAC1, 0xAAC1, graphemeClassExtend},
+	{0xAAEB, 0xAAEB, graphemeClassSpacingMark},
+	{0xAAEC, 0xAAED, graphemeClassExtend},
+	{0xAAEE, 0xAAEF, graphemeClassSpacingMark},
+	{0xAAF5, 0xAAF5, graphemeClassSpacingMark},
+	{0xAAF6, 0xAAF6, graphemeClassExtend},
+	{0xABE3, 0xABE4, graphemeClassSpacingMark},
+	{0xABE5, 0xABE5, graphemeClassExtend},
+	{0xABE6, 0xABE7, graphemeClassSpacingMark},
+	{0xABE8, 0xABE8, graphemeClassExtend},
+	{0xABE9, 0xABEA, graphemeClassSpacingMark},
+	{0xABEC, 0xABEC, graphemeClassSpacingMark},
+	{0xABED, 0xABED, graphemeClassExtend},
+	{0xD7B0, 0xD7C6, graphemeClassV},
+	{0xD7CB, 0xD7FB, graphemeClassT},
+	{0xFB1E, 0xFB1E, graphemeClassExtend},
+	{0xFE00, 0xFE0F, graphemeClassExtend},
+	{0xFE20, 0xFE2F, graphemeClassExtend},
+	{0xFEFF, 0xFEFF, graphemeClassControl},
+	{0xFF9E, 0xFF9F, graphemeClassExtend},
+	{0xFFF0, 0xFFFB, graphemeClassControl},
+	{0x101FD, 0x101FD, graphemeClassExtend},
+	{0x102E0, 0x102E0, graphemeClassExtend},
+	{0x10376, 0x1037A, graphemeClassExtend},
+	{0x10A01, 0x10A03, graphemeClassExtend},
+	{0x10A05, 0x10A06, graphemeClassExtend},
+	{0x10A0C, 0x10A0F, graphemeClassExtend},
+	{0x10A38, 0x10A3A, graphemeClassExtend},
+	{0x10A3F, 0x10A3F, graphemeClassExtend},
+	{0x10AE5, 0x10AE6, graphemeClassExtend},
+	{0x10D24, 0x10D27, graphemeClassExtend},
+	{0x10EAB, 0x10EAC, graphemeClassExtend},
+	{0x10F46, 0x10F50, graphemeClassExtend},
+	{0x11000, 0x11000, graphemeClassSpacingMark},
+	{0x11001, 0x11001, graphemeClassExtend},
+	{0x11002, 0x11002, graphemeClassSpacingMark},
+	{0x11038, 0x11046, graphemeClassExtend},
+	{0x1107F, 0x11081, graphemeClassExtend},
+	{0x11082, 0x11082, graphemeClassSpacingMark},
+	{0x110B0, 0x110B2, graphemeClassSpacingMark},
+	{0x110B3, 0x110B6, graphemeClassExtend},
+	{0x110B7, 0x110B8, graphemeClassSpacingMark},
+	{0x110B9, 0x110BA, graphemeClassExtend},
+	{0x110BD, 0x110BD, graphemeClassPrepend},
+	{0x110CD, 0x110CD, graphemeClassPrepend},
+	{0x11100, 0x11102, graphemeClassExtend},
+	{0x11127, 0x1112B, graphemeClassExtend},
+	{0x1112C, 0x1112C, graphemeClassSpacingMark},
+	{0x1112D, 0x11134, graphemeClassExtend},
+	{0x11145, 0x11146, graphemeClassSpacingMark},
+	{0x11173, 0x11173, graphemeClassExtend},
+	{0x11180, 0x11181, graphemeClassExtend},
+	{0x11182, 0x11182, graphemeClassSpacingMark},
+	{0x111B3, 0x111B5, graphemeClassSpacingMark},
+	{0x111B6, 0x111BE, graphemeClassExtend},
+	{0x111BF, 0x111C0, graphemeClassSpacingMark},
+	{0x111C2, 0x111C3, graphemeClassPrepend},
+	{0x111C9, 0x111CC, graphemeClassExtend},
+	{0x111CE, 0x111CE, graphemeClassSpacingMark},
+	{0x111CF, 0x111CF, graphemeClassExtend},
+	{0x1122C, 0x1122E, graphemeClassSpacingMark},
+	{0x1122F, 0x11231, graphemeClassExtend},
+	{0x11232, 0x11233, graphemeClassSpacingMark},
+	{0x11234, 0x11234, graphemeClassExtend},
+	{0x11235, 0x11235, graphemeClassSpacingMark},
+	{0x11236, 0x11237, graphemeClassExtend},
+	{0x1123E, 0x1123E, graphemeClassExtend},
+	{0x112DF, 0x112DF, graphemeClassExtend},
+	{0x112E0, 0x112E2, graphemeClassSpacingMark},
+	{0x112E3, 0x112EA, graphemeClassExtend},
+	{0x11300, 0x11301, graphemeClassExtend},
+	{0x11302, 0x11303, graphemeClassSpacingMark},
+	{0x1133B, 0x1133C, graphemeClassExtend},
+	{0x1133E, 0x1133E, graphemeClassExtend},
+	{0x1133F, 0x1133F, graphemeClassSpacingMark},
+	{0x11340, 0x11340, graphemeClassExtend},
+	{0x11341, 0x11344, graphemeClassSpacingMark},
+	{0x11347, 0x11348, graphemeClassSpacingMark},
+	{0x1134B, 0x1134D, graphemeClassSpacingMark},
+	{0x11357, 0x11357, graphemeClassExtend},
+	{0x11362, 0x11363, graphemeClassSpacingMark},
+	{0x11366, 0x1136C, graphemeClassExtend},
+	{0x11370, 0x11374, graphemeClassExtend},
+	{0x11435, 0x11437, graphemeClassSpacingMark},
+	{0x11438, 0x1143F, graphemeClassExtend},
+	{0x11440, 0x11441, graphemeClassSpacingMark},
+	{0x11442, 0x11444, graphemeClassExtend},
+	{0x11445, 0x11445, graphemeClassSpacingMark},
+	{0x11446, 0x11446, graphemeClassExtend},
+	{0x1145E, 0x1145E, graphemeClassExtend},
+	{0x114B0, 0x114B0, graphemeClassExtend},
+	{0x114B1, 0x114B2, graphemeClassSpacingMark},
+	{0x114B3, 0x114B8, graphemeClassExtend},
+	{0x114B9, 0x114B9, graphemeClassSpacingMark},
+	{0x114BA, 0x114BA, graphemeClassExtend},
+	{0x114BB, 0x114BC, graphemeClassSpacingMark},
+	{0x114BD, 0x114BD, graphemeClassExtend},
+	{0x114BE, 0x114BE, graphemeClassSpacingMark},
+	{0x114BF, 0x114C0, graphemeClassExtend},
+	{0x114C1, 0x114C1, graphemeClassSpacingMark},
+	{0x114C2, 0x114C3, graphemeClassExtend},
+	{0x115AF, 0x115AF, graphemeClassExtend},
+	{0x115B0, 0x115B1, graphemeClassSpacingMark},
+	{0x115B2, 0x115B5, graphemeClassExtend},
+	{0x115B8, 0x115BB, graphemeClassSpacingMark},
+	{0x115BC, 0x115BD, graphemeClassExtend},
+	{0x115BE, 0x115BE, graphemeClassSpacingMark},
+	{0x115BF, 0x115C0, graphemeClassExtend},
+	{0x115DC, 0x115DD, graphemeClassExtend},
+	{0x11630, 0x11632, graphemeClassSpacingMark},
+	{0x11633, 0x1163A, graphemeClassExtend},
+	{0x1163B, 0x1163C, graphemeClassSpacingMark},
+	{0x1163D, 0x1163D, graphemeClassExtend},
+	{0x1163E, 0x1163E, graphemeClassSpacingMark},
+	{0x1163F, 0x11640, graphemeClassExtend},
+	{0x116AB, 0x116AB, graphemeClassExtend},
+	{0x116AC, 0x116AC, graphemeClassSpacingMark},
+	{0x116AD, 0x116AD, graphemeClassExtend},
+	{0x116AE, 0x116AF, graphemeClassSpacingMark},
+	{0x116B0, 0x116B5, graphemeClassExtend},
+	{0x116B6, 0x116B6, graphemeClassSpacingMark},
+	{0x116B7, 0x116B7, graphemeClassExtend},
+	{0x1171D, 0x1171F, graphemeClassExtend},
+	{0x11722, 0x11725, graphemeClassExtend},
+	{0x11726, 0x11726, graphemeClassSpacingMark},
+	{0x11727, 0x1172B, graphemeClassExtend},
+	{0x1182C, 0x1182E, graphemeClassSpacingMark},
+	{0x1182F, 0x11837, graphemeClassExtend},
+	{0x11838, 0x11838, graphemeClassSpacingMark},
+	{0x11839, 0x1183A, graphemeClassExtend},
+	{0x11930, 0x11930, graphemeClassExtend},
+	{0x11931, 0x11935, graphemeClassSpacingMark},
+	{0x11937, 0x11938, graphemeClassSpacingMark},
+	{0x1193B, 0x1193C, graphemeClassExtend},
+	{0x1193D, 0x1193D, graphemeClassSpacingMark},
+	{0x1193E, 0x1193E, graphemeClassExtend},
+	{0x1193F, 0x1193F, graphemeClassPrepend},
+	{0x11940, 0x11940, graphemeClassSpacingMark},
+	{0x11941, 0x11941, graphemeClassPrepend},
+	{0x11942, 0x11942, graphemeClassSpacingMark},
+	{0x11943, 0x11943, graphemeClassExtend},
+	{0x119D1, 0x119D3, graphemeClassSpacingMark},
+	{0x119D4, 0x119D7, graphemeClassExtend},
+	{0x119DA, 0x119DB, graphemeClassExtend},
+	{0x119DC, 0x119DF, graphemeClassSpacingMark},
+	{0x119E0, 0x119E0, graphemeClassExtend},
+	{0x119E4, 0x119E4, graphemeClassSpacingMark},
+	{0x11A01, 0x11A0A, graphemeClassExtend},
+	{0x11A33, 0x11A38, graphemeClassExtend},
+	{0x11A39, 0x11A39, graphemeClassSpacingMark},
+	{0x11A3A, 0x11A3A, graphemeClassPrepend},
+	{0x11A3B, 0x11A3E, graphemeClassExtend},
+	{0x11A47, 0x11A47, graphemeClassExtend},
+	{0x11A51, 0x11A56, graphemeClassExtend},
+	{0x11A57, 0x11A58, graphemeClassSpacingMark},
+	{0x11A59, 0x11A5B, graphemeClassExtend},
+	{0x11A84, 0x11A89, graphemeClassPrepend},
+	{0x11A8A, 0x11A96, graphemeClassExtend},
+	{0x11A97, 0x11A97, graphemeClassSpacingMark},
+	{0x11A98, 0x11A99, graphemeClassExtend},
+	{0x11C2F, 0x11C2F, graphemeClassSpacingMark},
+	{0x11C30, 0x11C36, graphemeClassExtend},
+	{0x11C38, 0x11C3D, graphemeClassExtend},
+	{0x11C3E, 0x11C3E, graphemeClassSpacingMark},
+	{0x11C3F, 0x11C3F, graphemeClassExtend},
+	{0x11C92, 0x11CA7, graphemeClassExtend},
+	{0x11CA9, 0x11CA9, graphemeClassSpacingMark},
+	{0x11CAA, 0x11CB0, graphemeClassExtend},
+	{0x11CB1, 0x11CB1, graphemeClassSpacingMark},
+	{0x11CB2, 0x11CB3, graphemeClassExtend},
+	{0x11CB4, 0x11CB4, graphemeClassSpacingMark},
+	{0x11CB5, 0x11CB6, graphemeClassExtend},
+	{0x11D31, 0x11D36, graphemeClassExtend},
+	{0x11D3A, 0x11D3A, graphemeClassExtend},
+	{0x11D3C, 0x11D3D, graphemeClassExtend},
+	{0x11D3F, 0x11D45, graphemeClassExtend},
+	{0x11D46, 0x11D46, graphemeClassPrepend},
+	{0x11D47, 0x11D47, graphemeClassExtend},
+	{0x11D8A, 0x11D8E, graphemeClassSpacingMark},
+	{0x11D90, 0x11D91, graphemeClassExtend},
+	{0x11D93, 0x11D94, graphemeClassSpacingMark},
+	{0x11D95, 0x11D95, graphemeClassExtend},
+	{0x11D96, 0x11D96, graphemeClassSpacingMark},
+	{0x11D97, 0x11D97, graphemeClassExtend},
+	{0x11EF3, 0x11EF4, graphemeClassExtend},
+	{0x11EF5, 0x11EF6, graphemeClassSpacingMark},
+	{0x13430, 0x13438, graphemeClassControl},
+	{0x16AF0, 0x16AF4, graphemeClassExtend},
+	{0x16B30, 0x16B36, graphemeClassExtend},
+	{0x16F4F, 0x16F4F, graphemeClassExtend},
+	{0x16F51, 0x16F87, graphemeClassSpacingMark},
+	{0x16F8F, 0x16F92, graphemeClassExtend},
+	{0x16FE4, 0x16FE4, graphemeClassExtend},
+	{0x16FF0, 0x16FF1, graphemeClassSpacingMark},
+	{0x1BC9D, 0x1BC9E, graphemeClassExtend},
+	{0x1BCA0, 0x1BCA3, graphemeClassControl},
+	{0x1D165, 0x1D165, graphemeClassExtend},
+	{0x1D166, 0x1D166, graphemeClassSpacingMark},
+	{0x1D167, 0x1D169, graphemeClassExtend},
+	{0x1D16D, 0x1D16D, graphemeClassSpacingMark},
+	{0x1D16E, 0x1D172, graphemeClassExtend},
+	{0x1D173, 0x1D17A, graphemeClassControl},
+	{0x1D17B, 0x1D182, graphemeClassExtend},
+	{0x1D185, 0x1D18B, graphemeClassExtend},
+	{0x1D1AA, 0x1D1AD, graphemeClassExtend},
+	{0x1D242, 0x1D244, graphemeClassExtend},
+	{0x1DA00, 0x1DA36, graphemeClassExtend},
+	{0x1DA3B, 0x1DA6C, graphemeClassExtend},
+	{0x1DA75, 0x1DA75, graphemeClassExtend},
+	{0x1DA84, 0x1DA84, graphemeClassExtend},
+	{0x1DA9B, 0x1DA9F, graphemeClassExtend},
+	{0x1DAA1, 0x1DAAF, graphemeClassExtend},
+	{0x1E000, 0x1E006, graphemeClassExtend},
+	{0x1E008, 0x1E018, graphemeClassExtend},
+	{0x1E01B, 0x1E021, graphemeClassExtend},
+	{0x1E023, 0x1E024, graphemeClassExtend},
+	{0x1E026, 0x1E02A, graphemeClassExtend},
+	{0x1E130, 0x1E136, graphemeClassExtend},
+	{0x1E2EC, 0x1E2EF, graphemeClassExtend},
+	{0x1E8D0, 0x1E8D6, graphemeClassExtend},
+	{0x1E944, 0x1E94A, graphemeClassExtend},
+	{0x1F000, 0x1F0FF, graphemeClassExtPict},
+	{0x1F10D, 0x1F10F, graphemeClassExtPict},
+	{0x1F12F, 0x1F12F, graphemeClassExtPict},
+	{0x1F16C, 0x1F171, graphemeClassExtPict},
+	{0x1F17E, 0x1F17F, graphemeClassExtPict},
+	{0x1F18E, 0x1F18E, graphemeClassExtPict},
+	{0x1F191, 0x1F19A, graphemeClassExtPict},
+	{0x1F1AD, 0x1F1E5, graphemeClassExtPict},
+	{0x1F1E6, 0x1F1FF, graphemeClassRegionalIndicator},
+	{0x1F201, 0x1F20F, graphemeClassExtPict},
+	{0x1F21A, 0x1F21A, graphemeClassExtPict},
+	{0x1F22F, 0x1F22F, graphemeClassExtPict},
+	{0x1F232, 0x1F23A, graphemeClassExtPict},
+	{0x1F23C, 0x1F23F, graphemeClassExtPict},
+	{0x1F249, 0x1F3FA, graphemeClassExtPict},
+	{0x1F3FB, 0x1F3FF, graphemeClassExtend},
+	{0x1F400, 0x1F53D, graphemeClassExtPict},
+	{0x1F546, 0x1F64F, graphemeClassExtPict},
+	{0x1F680, 0x1F6FF, graphemeClassExtPict},
+	{0x1F774, 0x1F77F, graphemeClassExtPict},
+	{0x1F7D5, 0x1F7FF, graphemeClassExtPict},
+	{0x1F80C, 0x1F80F, graphemeClassExtPict},
+	{0x1F848, 0x1F84F, graphemeClassExtPict},
+	{0x1F85A, 0x1F85F, graphemeClassExtPict},
+	{0x1F888, 0x1F88F, graphemeClassExtPict},
+	{0x1F8AE, 0x1F8FF, graphemeClassExtPict},
+	{0x1F90C, 0x1F93A, graphemeClassExtPict},
+	{0x1F93C, 0x1F945, graphemeClassExtPict},
+	{0x1F947, 0x1FAFF, graphemeClassExtPict},
+	{0x1FC00, 0x1FFFD, graphemeClassExtPict},
+	{0xE0000, 0xE001F, graphemeClassControl},
+	{0xE0020, 0xE007F, graphemeClassExtend},
+	{0xE0080, 0xE00FF, graphemeClassControl},
+	{0xE0100, 0xE01EF, graphemeClassExtend},
+	{0xE01F0, 0xE0FFF, graphemeClassControl},
+}
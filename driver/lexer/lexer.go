@@ -1,8 +1,11 @@
 package lexer
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"unicode/utf8"
 )
 
 type ModeID int
@@ -37,7 +40,72 @@ type LexSpec interface {
 	InitialState(mode ModeID) StateID
 	NextState(mode ModeID, state StateID, v int) (StateID, bool)
 	Accept(mode ModeID, state StateID) (ModeKindID, bool)
+	CaseInsensitiveAccept(mode ModeID, state StateID) (ModeKindID, bool)
+	Keyword(mode ModeID, modeKind ModeKindID, lexeme []byte) (ModeKindID, bool)
+	CaseInsensitiveKeyword(mode ModeID, modeKind ModeKindID, lexeme []byte) (ModeKindID, bool)
 	KindIDAndName(mode ModeID, modeKind ModeKindID) (KindID, string)
+	Transformations(kind KindID) []Transformation
+	CaptureGroups(kind KindID) *CaptureGroups
+}
+
+// Transformation is a built-in transformation of lexemes that a directive of a lexical production specifies.
+type Transformation struct {
+	// Name is the name of the transformation: trim, dedent, unescape, or normalize.
+	Name string
+
+	// Delimiter is a string that an unescape transformation removes from both ends of a lexeme before interpreting
+	// escape sequences. An empty string means the transformation removes nothing.
+	Delimiter string
+
+	// Form is a Unicode normalization form that a normalize transformation converts a lexeme into: nfc, nfd, nfkc,
+	// or nfkd.
+	Form string
+}
+
+// Normalizer converts values into Unicode normalization forms for normalize transformations. The lexer depends only on
+// the standard library, which has no normalization tables, so the lexer delegates normalization to a Normalizer that
+// NormalizeWith option passes. The normalizer package provides one using golang.org/x/text/unicode/norm.
+type Normalizer interface {
+	// Normalize converts a value `v` into a normalization form `form`: nfc, nfd, nfkc, or nfkd.
+	Normalize(form string, v []byte) ([]byte, error)
+}
+
+// CaptureGroups is a position automaton of a pattern containing capture groups `(?<name>...)`. Each position reads
+// a range of bytes or, when it is an end marker, accepts the pattern.
+type CaptureGroups struct {
+	// Names holds the names of the capture groups in the order their opening parentheses appear.
+	Names []string
+
+	// Initial holds the positions the automaton is at before reading a lexeme.
+	Initial []int
+
+	// From and To hold the range of bytes each position reads. The ranges of end markers are empty.
+	From []int
+	To   []int
+
+	// Follow holds the positions following each position.
+	Follow [][]int
+
+	// Tags holds the indexes of the capture groups containing each position.
+	Tags [][]int
+
+	// Accept is true for end markers, and Folded is true for end markers accepting a lexeme only when the lexer runs
+	// case-insensitively.
+	Accept []bool
+	Folded []bool
+}
+
+// Capture is a sub-span of a lexeme that a capture group matches.
+type Capture struct {
+	// Name is the name of the capture group.
+	Name string
+
+	// Pos is a byte position where the sub-span starts, relative to the start of the lexeme. Pos is -1 when the
+	// capture group matches no bytes.
+	Pos int
+
+	// Len is a length of the sub-span.
+	Len int
 }
 
 // Token representes a token.
@@ -53,21 +121,37 @@ type Token struct {
 	ModeKindID ModeKindID
 
 	// BytePos is a byte position where a token appears.
+	// When the lexer decodes a source using the WithEncoding option, BytePos is an offset in the original source.
 	BytePos int
 
-	// ByteLen is a length of a token.
+	// ByteLen is a length of a token. Like BytePos, it is counted in bytes of the original source.
 	ByteLen int
 
 	// Row is a row number where a token appears.
 	Row int
 
 	// Col is a column number where a token appears.
-	// Note that Col is counted in code points, not bytes.
+	// By default, Col is counted in code points, not bytes. You can change the unit using the CountColumnsIn option.
 	Col int
 
+	// EndRow is a row number where the last character of a token appears.
+	EndRow int
+
+	// EndCol is a column number where the last character of a token appears. EndCol is counted in the same unit as Col.
+	EndCol int
+
 	// Lexeme is a byte sequence matched a pattern of a lexical specification.
 	Lexeme []byte
 
+	// Value is the lexeme processed by the transformations of the kind, such as `#trim`. When the kind has no
+	// transformation, Value is the same slice as Lexeme. Unlike Lexeme, the lexer allocates memory for a transformed
+	// value even when the caller uses NextInto.
+	Value []byte
+
+	// Captures holds the sub-spans of the lexeme that the capture groups of the pattern match, in the order their
+	// opening parentheses appear. When the pattern has no capture group, Captures is nil.
+	Captures []Capture
+
 	// When this field is true, it means the token is the EOF token.
 	EOF bool
 
@@ -75,8 +159,80 @@ type Token struct {
 	Invalid bool
 }
 
+// Capture returns the sub-span of the lexeme that a capture group `name` matches. The second return value is false
+// when the pattern has no such capture group.
+func (t *Token) Capture(name string) ([]byte, bool) {
+	for _, c := range t.Captures {
+		if c.Name != name {
+			continue
+		}
+		if c.Pos < 0 {
+			return nil, true
+		}
+		return t.Lexeme[c.Pos : c.Pos+c.Len], true
+	}
+	return nil, false
+}
+
 type LexerOption func(l *Lexer) error
 
+// ColumnUnit represents a unit in which the lexer counts columns.
+type ColumnUnit int
+
+const (
+	// ColumnUnitCodePoint makes the lexer count columns in code points. This is the default unit.
+	ColumnUnitCodePoint ColumnUnit = iota
+
+	// ColumnUnitByte makes the lexer count columns in bytes.
+	ColumnUnitByte
+
+	// ColumnUnitUTF16 makes the lexer count columns in UTF-16 code units. A code point outside the BMP occupies
+	// two columns. LSP uses this unit by default.
+	ColumnUnitUTF16
+
+	// ColumnUnitGrapheme makes the lexer count columns in extended grapheme clusters, that is, user-perceived
+	// characters such as `e` followed by a combining acute accent and a flag consisting of two regional indicators.
+	// The boundaries of clusters follow UAX #29 using the Grapheme_Cluster_Break and Extended_Pictographic
+	// properties of the UCD that the ucd package holds. A character continuing a cluster has the column of the
+	// cluster, so a token starting in the middle of a cluster has the same column as the preceding token's last
+	// character.
+	ColumnUnitGrapheme
+)
+
+// CountColumnsIn makes the lexer count columns of tokens in the unit `unit`.
+func CountColumnsIn(unit ColumnUnit) LexerOption {
+	return func(l *Lexer) error {
+		switch unit {
+		case ColumnUnitCodePoint, ColumnUnitByte, ColumnUnitUTF16, ColumnUnitGrapheme:
+		default:
+			return fmt.Errorf("invalid column unit: %v", unit)
+		}
+		l.colUnit = unit
+		return nil
+	}
+}
+
+// NormalizeWith makes the lexer use a normalizer `n` for normalize transformations. A lexer without a normalizer
+// fails on a token needing normalization.
+func NormalizeWith(n Normalizer) LexerOption {
+	return func(l *Lexer) error {
+		l.normalizer = n
+		return nil
+	}
+}
+
+// TabWidth makes the lexer treat a tab (U+0009) as advancing a column to the next tab stop. Tab stops are placed
+// every `width` columns. When this option isn't passed, a tab occupies one column like other characters.
+func TabWidth(width int) LexerOption {
+	return func(l *Lexer) error {
+		if width <= 0 {
+			return fmt.Errorf("a tab width must be greater than 0: %v", width)
+		}
+		l.tabWidth = width
+		return nil
+	}
+}
+
 // DisableModeTransition disables the active mode transition. Thus, even if the lexical specification has the push and pop
 // operations, the lexer doesn't perform these operations. When the lexical specification has multiple modes, and this option is
 // enabled, you need to call the Lexer.Push and Lexer.Pop methods to perform the mode transition. You can use the Lexer.Mode method
@@ -88,31 +244,207 @@ func DisableModeTransition() LexerOption {
 	}
 }
 
+// DisableCaseSensitivity makes the lexer match the patterns of case-configurable kinds case-insensitively. The lexical
+// specification marks such kinds using the `#case_configurable` directive. Other kinds are still matched case-sensitively.
+// The lexer also looks up case-configurable keywords of `#keywords` directives case-insensitively.
+func DisableCaseSensitivity() LexerOption {
+	return func(l *Lexer) error {
+		l.caseInsensitive = true
+		return nil
+	}
+}
+
+// Region makes the lexer analyze only a byte range [start, end) of a source. The lexer still counts positions of tokens
+// from the beginning of the source, so you can analyze a region embedded in a larger document (e.g. a code block inside
+// Markdown) in place while keeping the positions relative to the document. When the lexer decodes a source using the
+// WithEncoding option, the range is in bytes of the original source.
+//
+// NewLexer buffers the source up to the end of the region.
+func Region(start, end int) LexerOption {
+	return func(l *Lexer) error {
+		if start < 0 || start > end {
+			return fmt.Errorf("invalid region: [%v, %v)", start, end)
+		}
+		l.regionStart = start
+		l.regionEnd = end
+		return nil
+	}
+}
+
+// MaxTokenLength limits the length of a token to `n` bytes. When the lexer reads more than `n` bytes to find a token,
+// Next returns an error. Since the lexer buffers only bytes of a token under analysis, this option bounds the memory
+// the lexer uses regardless of the size of a source.
+func MaxTokenLength(n int) LexerOption {
+	return func(l *Lexer) error {
+		if n <= 0 {
+			return fmt.Errorf("a maximum token length must be greater than 0: %v", n)
+		}
+		l.maxTokenLen = n
+		return nil
+	}
+}
+
+// InitialModeStack makes the lexer start with a mode stack `modes` instead of the initial mode of the lexical
+// specification. The first element is the bottom of the stack, and the last element is the mode the lexer starts with.
+func InitialModeStack(modes ...ModeID) LexerOption {
+	return func(l *Lexer) error {
+		if len(modes) == 0 {
+			return fmt.Errorf("an initial mode stack must have at least one element")
+		}
+		l.initialModeStack = make([]ModeID, len(modes))
+		copy(l.initialModeStack, modes)
+		l.modeStack = make([]ModeID, len(modes))
+		copy(l.modeStack, modes)
+		return nil
+	}
+}
+
+// DisableInvalidTokenMerging makes the lexer return an invalid token each time it fails to match a pattern. Such a token
+// consists of the bytes the lexer read until it failed, typically one character. By default, the lexer merges
+// consecutive invalid tokens into one.
+func DisableInvalidTokenMerging() LexerOption {
+	return func(l *Lexer) error {
+		l.splitInvalid = true
+		return nil
+	}
+}
+
+// OnInvalidToken makes the lexer call `fn` with each invalid token before returning it. `fn` can report the span of
+// the token, for instance. The lexer may overwrite the lexeme after `fn` returns when the caller uses NextInto, so copy
+// it when `fn` needs it later.
+func OnInvalidToken(fn func(tok *Token)) LexerOption {
+	return func(l *Lexer) error {
+		if fn == nil {
+			return fmt.Errorf("an invalid token handler must be non-nil")
+		}
+		l.onInvalid = fn
+		return nil
+	}
+}
+
+// Encoding represents a character encoding of a source.
+type Encoding int
+
+const (
+	// EncodingUTF8 is UTF-8, which the lexer reads as it is. This is the default encoding.
+	EncodingUTF8 Encoding = iota
+
+	// EncodingUTF16LE and EncodingUTF16BE are UTF-16 in little-endian and big-endian byte orders, respectively.
+	EncodingUTF16LE
+	EncodingUTF16BE
+
+	// EncodingLatin1 is ISO-8859-1, which encodes each code point from U+0000 to U+00FF in a byte of the same value.
+	EncodingLatin1
+
+	// EncodingAuto makes the lexer detect the encoding by a byte order mark (BOM) at the beginning of a source: UTF-8,
+	// UTF-16LE, or UTF-16BE. The lexer skips the BOM, and it reads a source without a BOM as UTF-8.
+	EncodingAuto
+)
+
+// WithEncoding makes the lexer read a source in the encoding `enc`. The lexer decodes the source into UTF-8, so
+// patterns match characters regardless of the encoding and lexemes are in UTF-8. On the other hand, BytePos and ByteLen
+// of tokens and the range of the Region option are in bytes of the original source. The MaxTokenLength option and
+// columns in ColumnUnitByte count bytes of the decoded lexemes.
+//
+// An unpaired surrogate in a UTF-16 source becomes U+FFFD, and a UTF-16 source having an odd number of bytes makes Next
+// return an error at the end.
+func WithEncoding(enc Encoding) LexerOption {
+	return func(l *Lexer) error {
+		switch enc {
+		case EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingLatin1, EncodingAuto:
+		default:
+			return fmt.Errorf("invalid encoding: %v", enc)
+		}
+		l.enc = enc
+		return nil
+	}
+}
+
 type lexerState struct {
 	srcPtr int
 	row    int
 	col    int
+
+	// origPtr is the offset in the original source corresponding to srcPtr. It differs from srcPtr only when the lexer
+	// decodes a source or skips a BOM.
+	origPtr int
+
+	// charRow and charCol are a position of the last character read.
+	charRow int
+	charCol int
+
+	// When the lexer counts columns in grapheme clusters, gcb is the Grapheme_Cluster_Break class of the last code
+	// point read. riCount is the number of consecutive regional indicators ending with it, and pict is the state of
+	// an emoji sequence `ExtPict Extend* ZWJ` ending with it. These are the contexts that the rules GB11 and GB12
+	// need.
+	gcb     graphemeClass
+	riCount int
+	pict    pictState
 }
 
+// readChunkSize is a size of a chunk the lexer reads from a source at once.
+const readChunkSize = 4096
+
+const (
+	// tokChunkLen is the number of tokens in a chunk the lexer allocates tokens from.
+	tokChunkLen = 128
+
+	// lexemeChunkSize is a size of a chunk the lexer allocates lexemes from. A lexeme longer than a quarter of
+	// the chunk has its own memory.
+	lexemeChunkSize = 4096
+)
+
+// Lexer analyzes a source streamingly. The lexer reads a source in chunks and keeps only bytes from the beginning of
+// a token under analysis to the furthest byte it has looked ahead, so memory usage depends on the length of tokens,
+// not on the size of the source. Use MaxTokenLength to bound the length of tokens.
+//
+// The lexer allocates tokens and lexemes in chunks, so a token a caller keeps also keeps the other tokens in the same
+// chunk from being garbage-collected.
 type Lexer struct {
-	spec              LexSpec
-	src               []byte
+	spec LexSpec
+	src  io.Reader
+
+	// buf holds bytes read from the source. bufOffset is an offset of buf[0] from the beginning of the source.
+	buf       []byte
+	bufOffset int
+	srcEOF    bool
+	readErr   error
+
+	// enc is the encoding that the WithEncoding option specifies, and srcEnc is the encoding of the current source,
+	// which the lexer detects when enc is EncodingAuto. dec decodes the source into UTF-8 unless the source is in UTF-8.
+	// bomLen is the length of the BOM the lexer skipped.
+	enc    Encoding
+	srcEnc Encoding
+	dec    *decoder
+	bomLen int
+
 	state             lexerState
 	lastAcceptedState lexerState
 	tokBuf            []*Token
 	modeStack         []ModeID
+	initialModeStack  []ModeID
+	regionStart       int
+	regionEnd         int
+	maxTokenLen       int
 	passiveModeTran   bool
+	caseInsensitive   bool
+	colUnit           ColumnUnit
+	tabWidth          int
+	normalizer        Normalizer
+	splitInvalid      bool
+	onInvalid         func(tok *Token)
+
+	// tokChunk and lexemeChunk are chunks of memory that the lexer allocates tokens and lexemes from. Allocating
+	// memory in chunks reduces allocations per token.
+	tokChunk    []Token
+	lexemeChunk []byte
 }
 
 // NewLexer returns a new lexer.
 func NewLexer(spec LexSpec, src io.Reader, opts ...LexerOption) (*Lexer, error) {
-	b, err := io.ReadAll(src)
-	if err != nil {
-		return nil, err
-	}
 	l := &Lexer{
 		spec: spec,
-		src:  b,
+		src:  src,
 		state: lexerState{
 			srcPtr: 0,
 			row:    0,
@@ -126,7 +458,15 @@ func NewLexer(spec LexSpec, src io.Reader, opts ...LexerOption) (*Lexer, error)
 		modeStack: []ModeID{
 			spec.InitialMode(),
 		},
+		initialModeStack: []ModeID{
+			spec.InitialMode(),
+		},
+		regionStart:     0,
+		regionEnd:       -1,
 		passiveModeTran: false,
+		caseInsensitive: false,
+		colUnit:         ColumnUnitCodePoint,
+		tabWidth:        0,
 	}
 	for _, opt := range opts {
 		err := opt(l)
@@ -135,57 +475,150 @@ func NewLexer(spec LexSpec, src io.Reader, opts ...LexerOption) (*Lexer, error)
 		}
 	}
 
+	err := l.setSource(src)
+	if err != nil {
+		return nil, err
+	}
+	if l.regionEnd > 0 {
+		err := l.checkRegion()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// To count positions in the same way as the lexer does while analyzing, the lexer reads the bytes before a region
+	// after all options are applied.
+	for l.state.origPtr < l.regionStart {
+		_, eof := l.read()
+		if eof {
+			break
+		}
+	}
+	if l.readErr != nil {
+		return nil, l.readErr
+	}
+	l.lastAcceptedState = l.state
+
 	return l, nil
 }
 
 // Next returns a next token.
 func (l *Lexer) Next() (*Token, error) {
+	tok := l.allocToken()
+	err := l.nextInto(tok, false)
+	if err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
+
+// NextContext returns a next token like Next, but it returns ctx.Err() without reading a source when ctx is canceled
+// or its deadline passes.
+func (l *Lexer) NextContext(ctx context.Context) (*Token, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	return l.Next()
+}
+
+// NextInto reads a next token into `tok`. Unlike Next, NextInto doesn't allocate a token, and it copies a lexeme into
+// the memory `tok.Lexeme` already has, allocating memory only when the lexeme doesn't fit in it. Thus, reusing the same
+// token for all calls makes scanning allocation-free in most cases. Because the lexeme is overwritten by the next
+// call, copy it when you need it after that. BytePos and ByteLen also locate the lexeme in the source.
+func (l *Lexer) NextInto(tok *Token) error {
+	return l.nextInto(tok, true)
+}
+
+// nextInto reads a next token into `tok`. When `reuse` is true, this method copies a lexeme into the memory
+// `tok.Lexeme` has. Otherwise, it allocates new memory for the lexeme.
+func (l *Lexer) nextInto(tok *Token, reuse bool) error {
 	if len(l.tokBuf) > 0 {
-		tok := l.tokBuf[0]
+		buffered := l.tokBuf[0]
 		l.tokBuf = l.tokBuf[1:]
-		return tok, nil
+		lexeme := buffered.Lexeme
+		if reuse {
+			lexeme = append(tok.Lexeme[:0], lexeme...)
+		}
+		*tok = *buffered
+		tok.Lexeme = lexeme
+		v, err := l.value(tok)
+		if err != nil {
+			return err
+		}
+		tok.Value = v
+		return nil
 	}
 
-	tok, err := l.nextAndTransition()
+	err := l.nextAndTransition(tok, reuse)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if !tok.Invalid {
-		return tok, nil
+		return nil
+	}
+	if l.splitInvalid {
+		if l.onInvalid != nil {
+			l.onInvalid(tok)
+		}
+		return nil
 	}
-	errTok := tok
+	// The lexer merges consecutive invalid tokens into one and buffers the valid token following them. Because this
+	// happens only on invalid inputs, the buffered token is allocated separately.
+	var next *Token
 	for {
-		tok, err = l.nextAndTransition()
+		next = &Token{}
+		err = l.nextAndTransition(next, false)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if !tok.Invalid {
+		if !next.Invalid {
 			break
 		}
-		errTok.ByteLen += tok.ByteLen
-		errTok.Lexeme = append(errTok.Lexeme, tok.Lexeme...)
+		tok.ByteLen += next.ByteLen
+		if l.maxTokenLen > 0 && tok.ByteLen > l.maxTokenLen {
+			return fmt.Errorf("%v:%v: an invalid token exceeds the maximum token length: %v bytes", tok.Row+1, tok.Col+1, l.maxTokenLen)
+		}
+		tok.Lexeme = append(tok.Lexeme, next.Lexeme...)
+		tok.Value = tok.Lexeme
+		tok.EndRow = next.EndRow
+		tok.EndCol = next.EndCol
+	}
+	l.tokBuf = append(l.tokBuf, next)
+	if l.onInvalid != nil {
+		l.onInvalid(tok)
 	}
-	l.tokBuf = append(l.tokBuf, tok)
 
-	return errTok, nil
+	return nil
 }
 
-func (l *Lexer) nextAndTransition() (*Token, error) {
-	tok, err := l.next()
+func (l *Lexer) nextAndTransition(tok *Token, reuse bool) error {
+	err := l.next(tok, reuse)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if tok.EOF || tok.Invalid {
-		return tok, nil
+		tok.Value = tok.Lexeme
+		return nil
+	}
+	mode := l.Mode()
+	if kw, ok := l.keyword(mode, tok.ModeKindID, tok.Lexeme); ok {
+		tok.ModeKindID = kw
+		tok.KindID, _ = l.spec.KindIDAndName(mode, kw)
 	}
+	v, err := l.value(tok)
+	if err != nil {
+		return err
+	}
+	tok.Value = v
+	tok.Captures = l.captures(tok)
 	if l.passiveModeTran {
-		return tok, nil
+		return nil
 	}
-	mode := l.Mode()
 	if l.spec.Pop(mode, tok.ModeKindID) {
 		err := l.PopMode()
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 	if mode, ok := l.spec.Push(mode, tok.ModeKindID); ok {
@@ -195,89 +628,498 @@ func (l *Lexer) nextAndTransition() (*Token, error) {
 	// at the same time. When the mode stack has just one element and popped it, the mode stack will be temporarily emptied.
 	// However, since a push operation may be performed immediately after it, the lexer allows the stack to be temporarily empty.
 	if len(l.modeStack) == 0 {
-		return nil, fmt.Errorf("a mode stack must have at least one element")
+		return fmt.Errorf("a mode stack must have at least one element")
 	}
-	return tok, nil
+	return nil
 }
 
-func (l *Lexer) next() (*Token, error) {
+func (l *Lexer) next(tok *Token, reuse bool) error {
 	mode := l.Mode()
 	state := l.spec.InitialState(mode)
-	buf := []byte{}
 	startPos := l.state.srcPtr
+	startOrig := l.state.origPtr
 	row := l.state.row
 	col := l.state.col
-	var tok *Token
+	// The lexer remembers the last accepting state instead of making a token every time it reaches an accepting
+	// state, so it makes a token and its lexeme just once.
+	var accepted bool
+	var acceptedModeKind ModeKindID
+	// The lexer no longer needs the bytes before the current token.
+	l.discard(startPos)
 	for {
 		v, eof := l.read()
+		// A token starting in the middle of a grapheme cluster has the column of the cluster.
+		if !eof && l.colUnit == ColumnUnitGrapheme && l.state.srcPtr == startPos+1 {
+			row = l.state.charRow
+			col = l.state.charCol
+		}
 		if eof {
-			if tok != nil {
+			if l.readErr != nil {
+				return l.readErr
+			}
+			if accepted {
 				l.revert()
-				return tok, nil
+				l.setToken(tok, reuse, mode, acceptedModeKind, startPos, startOrig, row, col)
+				return nil
 			}
-			// When `buf` has unaccepted data and reads the EOF, the lexer treats the buffered data as an invalid token.
-			if len(buf) > 0 {
-				return &Token{
-					ModeID:     mode,
-					ModeKindID: 0,
-					BytePos:    startPos,
-					ByteLen:    l.state.srcPtr - startPos,
-					Lexeme:     buf,
-					Row:        row,
-					Col:        col,
-					Invalid:    true,
-				}, nil
+			// When the lexer has read unaccepted data and reaches the EOF, the lexer treats the data as an invalid token.
+			if l.state.srcPtr > startPos {
+				l.setInvalidToken(tok, reuse, mode, startPos, startOrig, row, col)
+				return nil
 			}
-			return &Token{
+			var lexeme []byte
+			if reuse {
+				lexeme = tok.Lexeme[:0]
+			}
+			*tok = Token{
 				ModeID:     mode,
 				ModeKindID: 0,
-				BytePos:    startPos,
+				BytePos:    startOrig,
 				Row:        row,
 				Col:        col,
+				EndRow:     row,
+				EndCol:     col,
+				Lexeme:     lexeme,
 				EOF:        true,
-			}, nil
+			}
+			return nil
+		}
+		if l.maxTokenLen > 0 && l.state.srcPtr-startPos > l.maxTokenLen {
+			return fmt.Errorf("%v:%v: a token exceeds the maximum token length: %v bytes", row+1, col+1, l.maxTokenLen)
 		}
-		buf = append(buf, v)
 		nextState, ok := l.spec.NextState(mode, state, int(v))
 		if !ok {
-			if tok != nil {
+			if accepted {
 				l.revert()
-				return tok, nil
+				l.setToken(tok, reuse, mode, acceptedModeKind, startPos, startOrig, row, col)
+				return nil
 			}
-			return &Token{
-				ModeID:     mode,
-				ModeKindID: 0,
-				BytePos:    startPos,
-				ByteLen:    l.state.srcPtr - startPos,
-				Lexeme:     buf,
-				Row:        row,
-				Col:        col,
-				Invalid:    true,
-			}, nil
+			l.setInvalidToken(tok, reuse, mode, startPos, startOrig, row, col)
+			return nil
 		}
 		state = nextState
-		if modeKindID, ok := l.spec.Accept(mode, state); ok {
-			kindID, _ := l.spec.KindIDAndName(mode, modeKindID)
-			tok = &Token{
-				ModeID:     mode,
-				KindID:     kindID,
-				ModeKindID: modeKindID,
-				BytePos:    startPos,
-				ByteLen:    l.state.srcPtr - startPos,
-				Lexeme:     buf,
-				Row:        row,
-				Col:        col,
-			}
+		if modeKindID, ok := l.acceptingKind(mode, state); ok {
+			accepted = true
+			acceptedModeKind = modeKindID
 			l.accept()
 		}
 	}
 }
 
+// setToken sets a token that starts at a byte position `startPos` and ends at the current position to `tok`.
+// `startOrig` is the position in the original source corresponding to `startPos`.
+func (l *Lexer) setToken(tok *Token, reuse bool, mode ModeID, modeKind ModeKindID, startPos, startOrig, row, col int) {
+	end := &l.state
+	kindID, _ := l.spec.KindIDAndName(mode, modeKind)
+	// Assigning the fields one by one is cheaper than assigning a composite literal, which makes the runtime process
+	// all the pointer fields at once while the GC is running. The lexer makes tokens mostly through this method.
+	tok.ModeID = mode
+	tok.KindID = kindID
+	tok.ModeKindID = modeKind
+	tok.BytePos = startOrig
+	tok.ByteLen = end.origPtr - startOrig
+	tok.Lexeme = l.lexeme(tok.Lexeme, reuse, startPos, end.srcPtr)
+	tok.Row = row
+	tok.Col = col
+	tok.EndRow = end.charRow
+	tok.EndCol = end.charCol
+	tok.Value = nil
+	tok.Captures = nil
+	tok.EOF = false
+	tok.Invalid = false
+}
+
+// setInvalidToken sets an invalid token consisting of the bytes from a byte position `startPos` to the current position
+// to `tok`. `startOrig` is the position in the original source corresponding to `startPos`.
+func (l *Lexer) setInvalidToken(tok *Token, reuse bool, mode ModeID, startPos, startOrig, row, col int) {
+	*tok = Token{
+		ModeID:     mode,
+		ModeKindID: 0,
+		BytePos:    startOrig,
+		ByteLen:    l.state.origPtr - startOrig,
+		Lexeme:     l.lexeme(tok.Lexeme, reuse, startPos, l.state.srcPtr),
+		Row:        row,
+		Col:        col,
+		EndRow:     l.state.charRow,
+		EndCol:     l.state.charCol,
+		Invalid:    true,
+	}
+}
+
+// lexeme copies the bytes from a byte position `start` to `end` from the buffer. The lexeme must not share memory with
+// the buffer because the lexer overwrites the buffer. When `reuse` is true and the bytes fit in `dst`, this method
+// copies them into `dst`.
+func (l *Lexer) lexeme(dst []byte, reuse bool, start, end int) []byte {
+	src := l.buf[start-l.bufOffset : end-l.bufOffset]
+	n := end - start
+	if reuse && cap(dst) >= n {
+		return append(dst[:0], src...)
+	}
+	var b []byte
+	if n > lexemeChunkSize/4 {
+		b = make([]byte, n)
+	} else {
+		if len(l.lexemeChunk) < n {
+			l.lexemeChunk = make([]byte, lexemeChunkSize)
+		}
+		// Limiting the capacity prevents appending to the lexeme from overwriting the following lexemes.
+		b = l.lexemeChunk[:n:n]
+		l.lexemeChunk = l.lexemeChunk[n:]
+	}
+	copy(b, src)
+	return b
+}
+
+func (l *Lexer) allocToken() *Token {
+	if len(l.tokChunk) == 0 {
+		l.tokChunk = make([]Token, tokChunkLen)
+	}
+	tok := &l.tokChunk[0]
+	l.tokChunk = l.tokChunk[1:]
+	return tok
+}
+
+// value returns the lexeme of a token processed by the transformations of its kind.
+func (l *Lexer) value(tok *Token) ([]byte, error) {
+	if tok.EOF || tok.Invalid {
+		return tok.Lexeme, nil
+	}
+	v, err := Transform(tok.Lexeme, l.spec.Transformations(tok.KindID), l.normalizer)
+	if err != nil {
+		return nil, fmt.Errorf("%v:%v: %w", tok.Row+1, tok.Col+1, err)
+	}
+	return v, nil
+}
+
+// captures returns the sub-spans of the lexeme of a token that the capture groups of its kind match.
+func (l *Lexer) captures(tok *Token) []Capture {
+	g := l.spec.CaptureGroups(tok.KindID)
+	if g == nil {
+		return nil
+	}
+	return findCaptures(g, tok.Lexeme, l.caseInsensitive)
+}
+
+// findCaptures returns the sub-spans of a lexeme that capture groups `g` match. The lexeme must be one that the pattern
+// of `g` matches; otherwise, no capture group matches any bytes. When a capture group matches multiple sub-spans, as
+// one in a repetition does, the result spans from the first to the last of them. When the pattern matches the lexeme
+// in more than one way, the capture groups that open earlier take precedence.
+func findCaptures(g *CaptureGroups, lexeme []byte, caseInsensitive bool) []Capture {
+	caps := make([]Capture, len(g.Names))
+	for i, name := range g.Names {
+		caps[i] = Capture{
+			Name: name,
+			Pos:  -1,
+		}
+	}
+
+	// reachable[i*n+p] is true when the automaton can be at a position `p` before reading lexeme[i].
+	n := len(g.From)
+	reachable := make([]bool, (len(lexeme)+1)*n)
+	for _, p := range g.Initial {
+		reachable[p] = true
+	}
+	for i, b := range lexeme {
+		for p := 0; p < n; p++ {
+			if !reachable[i*n+p] || int(b) < g.From[p] || int(b) > g.To[p] {
+				continue
+			}
+			for _, q := range g.Follow[p] {
+				reachable[(i+1)*n+q] = true
+			}
+		}
+	}
+
+	// The automaton walks back from an end marker. Choosing the smallest position at each step lets the capture groups
+	// opening earlier take precedence because positions are numbered in the order they appear in the pattern.
+	q := -1
+	for p := 0; p < n; p++ {
+		if reachable[len(lexeme)*n+p] && g.Accept[p] && (caseInsensitive || !g.Folded[p]) {
+			q = p
+			break
+		}
+	}
+	if q < 0 {
+		return caps
+	}
+	ends := make([]int, len(caps))
+	for i := len(lexeme) - 1; i >= 0; i-- {
+		b := int(lexeme[i])
+		prev := -1
+		for p := 0; p < n && prev < 0; p++ {
+			if !reachable[i*n+p] || b < g.From[p] || b > g.To[p] {
+				continue
+			}
+			for _, f := range g.Follow[p] {
+				if f == q {
+					prev = p
+					break
+				}
+			}
+		}
+		if prev < 0 {
+			return caps
+		}
+		q = prev
+		for _, c := range g.Tags[q] {
+			if caps[c].Pos < 0 {
+				ends[c] = i + 1
+			}
+			caps[c].Pos = i
+		}
+	}
+	for i := range caps {
+		if caps[i].Pos >= 0 {
+			caps[i].Len = ends[i] - caps[i].Pos
+		}
+	}
+	return caps
+}
+
+// Transform applies transformations `ts` to a lexeme in order and returns the result. When `ts` is empty, Transform
+// returns the lexeme as it is. A normalizer `n` performs normalize transformations, and Transform fails when `ts` has
+// a normalize transformation and `n` is nil. The transformations are as follows:
+//
+//   - trim removes leading and trailing white spaces.
+//   - dedent removes the longest common indentation consisting of spaces and tabs from the lines. Lines consisting
+//     only of white spaces become empty and don't count toward the common indentation.
+//   - unescape removes the delimiter from both ends when both ends have it, and then it interprets escape sequences:
+//     \n, \r, \t, \u{XXXX}, and a backslash followed by a backslash, a quotation mark, a backquote, or the delimiter.
+//     It leaves other backslashes as they are.
+//   - normalize converts the lexeme into a Unicode normalization form, such as NFC.
+func Transform(lexeme []byte, ts []Transformation, n Normalizer) ([]byte, error) {
+	v := lexeme
+	for _, t := range ts {
+		switch t.Name {
+		case "trim":
+			v = bytes.TrimSpace(v)
+			// Limiting the capacity prevents appending to the value from overwriting the lexeme.
+			v = v[:len(v):len(v)]
+		case "dedent":
+			v = dedent(v)
+		case "unescape":
+			v = unescape(v, []byte(t.Delimiter))
+		case "normalize":
+			if n == nil {
+				return nil, fmt.Errorf("no normalizer converts a lexeme into %v; pass one using the NormalizeWith option", t.Form)
+			}
+			var err error
+			v, err = n.Normalize(t.Form, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+func dedent(v []byte) []byte {
+	lines := bytes.Split(v, []byte("\n"))
+	var indent []byte
+	found := false
+	for _, line := range lines {
+		body := bytes.TrimLeft(line, " \t")
+		if len(bytes.TrimSpace(body)) == 0 {
+			continue
+		}
+		lineIndent := line[:len(line)-len(body)]
+		if !found {
+			indent = lineIndent
+			found = true
+			continue
+		}
+		n := 0
+		for n < len(indent) && n < len(lineIndent) && indent[n] == lineIndent[n] {
+			n++
+		}
+		indent = indent[:n]
+	}
+
+	b := make([]byte, 0, len(v))
+	for i, line := range lines {
+		if i > 0 {
+			b = append(b, '\n')
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		b = append(b, line[len(indent):]...)
+	}
+	return b
+}
+
+func unescape(v []byte, delim []byte) []byte {
+	if len(delim) > 0 && len(v) >= 2*len(delim) && bytes.HasPrefix(v, delim) && bytes.HasSuffix(v, delim) {
+		v = v[len(delim) : len(v)-len(delim)]
+	}
+
+	b := make([]byte, 0, len(v))
+	for i := 0; i < len(v); i++ {
+		if v[i] != '\\' || i+1 >= len(v) {
+			b = append(b, v[i])
+			continue
+		}
+		switch c := v[i+1]; {
+		case c == 'n':
+			b = append(b, '\n')
+			i++
+		case c == 'r':
+			b = append(b, '\r')
+			i++
+		case c == 't':
+			b = append(b, '\t')
+			i++
+		case c == '\\' || c == '\'' || c == '"' || c == '`':
+			b = append(b, c)
+			i++
+		case len(delim) > 0 && bytes.HasPrefix(v[i+1:], delim):
+			b = append(b, delim...)
+			i += len(delim)
+		case c == 'u':
+			r, n, ok := readCodePoint(v[i+2:])
+			if !ok {
+				b = append(b, v[i])
+				continue
+			}
+			b = utf8.AppendRune(b, r)
+			i += 1 + n
+		default:
+			b = append(b, v[i])
+		}
+	}
+	return b
+}
+
+// readCodePoint reads a code point in the form of `{XXXX}`, which consists of one through six hexadecimal digits, and
+// returns the code point and the number of bytes it read.
+func readCodePoint(v []byte) (rune, int, bool) {
+	if len(v) < 3 || v[0] != '{' {
+		return 0, 0, false
+	}
+	var r rune
+	for i := 1; i < len(v) && i <= 7; i++ {
+		c := v[i]
+		switch {
+		case c == '}':
+			if i == 1 || !utf8.ValidRune(r) {
+				return 0, 0, false
+			}
+			return r, i + 1, true
+		case c >= '0' && c <= '9':
+			r = r*16 + rune(c-'0')
+		case c >= 'a' && c <= 'f':
+			r = r*16 + rune(c-'a'+10)
+		case c >= 'A' && c <= 'F':
+			r = r*16 + rune(c-'A'+10)
+		default:
+			return 0, 0, false
+		}
+	}
+	return 0, 0, false
+}
+
 // Mode returns the current lex mode.
 func (l *Lexer) Mode() ModeID {
 	return l.modeStack[len(l.modeStack)-1]
 }
 
+// ModeStack returns a copy of the mode stack. The first element is the bottom of the stack, and the last element is
+// the current lex mode.
+func (l *Lexer) ModeStack() []ModeID {
+	stack := make([]ModeID, len(l.modeStack))
+	copy(stack, l.modeStack)
+	return stack
+}
+
+// SetInitialMode replaces the mode stack with a stack having only the lex mode `mode`. The lexer also uses the mode as
+// the initial mode when Reset is called.
+func (l *Lexer) SetInitialMode(mode ModeID) {
+	l.initialModeStack = []ModeID{
+		mode,
+	}
+	l.modeStack = []ModeID{
+		mode,
+	}
+}
+
+// Reset makes the lexer read a new source `src` from the beginning. The lexer discards buffered tokens and restores
+// the mode stack to the initial one, while it keeps options passed to NewLexer except Region.
+func (l *Lexer) Reset(src io.Reader) error {
+	l.buf = l.buf[:0]
+	l.bufOffset = 0
+	l.srcEOF = false
+	l.readErr = nil
+	l.regionStart = 0
+	l.regionEnd = -1
+	l.tokBuf = nil
+	l.modeStack = make([]ModeID, len(l.initialModeStack))
+	copy(l.modeStack, l.initialModeStack)
+	return l.setSource(src)
+}
+
+// setSource makes the lexer read a source from the beginning. When the source isn't in UTF-8, this method wraps it in
+// a decoder, detecting the encoding by a BOM when the lexer has EncodingAuto.
+func (l *Lexer) setSource(src io.Reader) error {
+	l.src = src
+	l.srcEnc = l.enc
+	l.dec = nil
+	l.bomLen = 0
+	if l.enc == EncodingAuto {
+		var head [3]byte
+		n, err := io.ReadFull(src, head[:])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		switch {
+		case n >= 3 && head[0] == 0xEF && head[1] == 0xBB && head[2] == 0xBF:
+			l.srcEnc, l.bomLen = EncodingUTF8, 3
+		case n >= 2 && head[0] == 0xFF && head[1] == 0xFE:
+			l.srcEnc, l.bomLen = EncodingUTF16LE, 2
+		case n >= 2 && head[0] == 0xFE && head[1] == 0xFF:
+			l.srcEnc, l.bomLen = EncodingUTF16BE, 2
+		default:
+			l.srcEnc = EncodingUTF8
+		}
+		l.src = io.MultiReader(bytes.NewReader(head[l.bomLen:n]), src)
+	}
+	if l.srcEnc != EncodingUTF8 {
+		l.dec = &decoder{
+			src: l.src,
+			enc: l.srcEnc,
+		}
+		l.src = l.dec
+	}
+	l.state = lexerState{
+		origPtr: l.bomLen,
+	}
+	l.lastAcceptedState = l.state
+	return nil
+}
+
+// checkRegion checks that a source has the bytes up to the end of a region, buffering them.
+func (l *Lexer) checkRegion() error {
+	if l.dec == nil {
+		ok, err := l.fill(l.regionEnd - l.bomLen - 1)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("invalid region: [%v, %v) (source length: %v)", l.regionStart, l.regionEnd, l.bomLen+l.bufOffset+len(l.buf))
+		}
+		return nil
+	}
+	for l.bomLen+l.dec.decoded < l.regionEnd {
+		if l.srcEOF {
+			return fmt.Errorf("invalid region: [%v, %v) (source length: %v)", l.regionStart, l.regionEnd, l.bomLen+l.dec.decoded)
+		}
+		_, err := l.fill(l.bufOffset + len(l.buf))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // PushMode adds a lex mode onto the mode stack.
 func (l *Lexer) PushMode(mode ModeID) {
 	l.modeStack = append(l.modeStack, mode)
@@ -293,35 +1135,434 @@ func (l *Lexer) PopMode() error {
 	return nil
 }
 
+// fill reads the source until the buffer holds a byte at an offset `pos`. When the source ends before the offset,
+// this method returns false.
+func (l *Lexer) fill(pos int) (bool, error) {
+	for pos-l.bufOffset >= len(l.buf) {
+		if l.srcEOF {
+			return false, nil
+		}
+		if cap(l.buf)-len(l.buf) < readChunkSize {
+			buf := make([]byte, len(l.buf), 2*cap(l.buf)+readChunkSize)
+			copy(buf, l.buf)
+			l.buf = buf
+		}
+		n, err := l.src.Read(l.buf[len(l.buf):cap(l.buf)])
+		l.buf = l.buf[:len(l.buf)+n]
+		if err == io.EOF {
+			l.srcEOF = true
+		} else if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// discard drops bytes before an offset `pos` from the buffer. To avoid copying the buffer every time, this method
+// drops the bytes only when they occupy more than half of the buffer.
+func (l *Lexer) discard(pos int) {
+	n := pos - l.bufOffset
+	if n <= 0 || n < len(l.buf)/2 {
+		return
+	}
+	l.buf = l.buf[:copy(l.buf, l.buf[n:])]
+	l.bufOffset = pos
+}
+
 func (l *Lexer) read() (byte, bool) {
-	if l.state.srcPtr >= len(l.src) {
-		return 0, true
+	var b byte
+	// Most reads take a byte the buffer already holds from a source neither decoded nor limited to a region.
+	if i := l.state.srcPtr - l.bufOffset; i < len(l.buf) && l.dec == nil && l.regionEnd < 0 && l.readErr == nil {
+		b = l.buf[i]
+		l.state.srcPtr++
+		l.state.origPtr++
+	} else {
+		var eof bool
+		b, eof = l.readSlowly()
+		if eof {
+			return 0, true
+		}
 	}
 
-	b := l.src[l.state.srcPtr]
-	l.state.srcPtr++
+	if l.colUnit == ColumnUnitGrapheme {
+		l.countGraphemes(b)
+		return b, false
+	}
 
 	// Count the token positions.
-	// The driver treats LF as the end of lines and counts columns in code points, not bytes.
+	// The driver treats LF as the end of lines and counts columns in code points by default.
 	// To count in code points, we refer to the First Byte column in the Table 3-6.
 	//
 	// Reference:
 	// - [Table 3-6] https://www.unicode.org/versions/Unicode13.0.0/ch03.pdf > Table 3-6.  UTF-8 Bit Distribution
 	if b < 128 {
+		l.state.charRow = l.state.row
+		l.state.charCol = l.state.col
+		switch {
 		// 0x0A is LF.
-		if b == 0x0A {
+		case b == 0x0A:
 			l.state.row++
 			l.state.col = 0
-		} else {
+		// 0x09 is a tab.
+		case b == 0x09 && l.tabWidth > 0:
+			l.state.col += l.tabWidth - l.state.col%l.tabWidth
+		default:
 			l.state.col++
 		}
 	} else if b>>5 == 6 || b>>4 == 14 || b>>3 == 30 {
+		l.state.charRow = l.state.row
+		l.state.charCol = l.state.col
+		// A 4-byte sequence encodes a code point outside the BMP, which needs a surrogate pair in UTF-16.
+		if l.colUnit == ColumnUnitUTF16 && b>>3 == 30 {
+			l.state.col += 2
+		} else {
+			l.state.col++
+		}
+	} else if l.colUnit == ColumnUnitByte {
 		l.state.col++
 	}
 
 	return b, false
 }
 
+// readSlowly reads a byte filling the buffer, and it handles a region and a decoded source.
+func (l *Lexer) readSlowly() (byte, bool) {
+	if l.regionEnd >= 0 && l.state.origPtr >= l.regionEnd && l.dec == nil {
+		return 0, true
+	}
+	if l.readErr != nil {
+		return 0, true
+	}
+	ok, err := l.fill(l.state.srcPtr)
+	if err != nil {
+		l.readErr = err
+		return 0, true
+	}
+	if !ok {
+		return 0, true
+	}
+
+	b := l.buf[l.state.srcPtr-l.bufOffset]
+	// A region of a decoded source ends at a boundary of characters.
+	if l.regionEnd >= 0 && l.state.origPtr >= l.regionEnd && b>>6 != 2 {
+		return 0, true
+	}
+	l.state.srcPtr++
+	l.state.origPtr += l.origWidth(b)
+	return b, false
+}
+
+// origWidth returns the number of bytes in the original source that a byte `b` of the decoded source stands for. The
+// first byte of a character stands for the whole character, and the other bytes stand for nothing.
+func (l *Lexer) origWidth(b byte) int {
+	if l.dec == nil {
+		return 1
+	}
+	// A continuation byte belongs to the character its first byte begins.
+	if b>>6 == 2 {
+		return 0
+	}
+	if l.srcEnc == EncodingLatin1 {
+		return 1
+	}
+	// A 4-byte sequence encodes a code point outside the BMP, which needs a surrogate pair in UTF-16.
+	if b>>3 == 30 {
+		return 4
+	}
+	return 2
+}
+
+// decoder converts a source in UTF-16 or Latin-1 into UTF-8.
+type decoder struct {
+	src io.Reader
+	enc Encoding
+
+	// in holds bytes read from the source but not decoded yet, and out holds decoded bytes not returned yet. decoded is
+	// the number of bytes of the source the decoder has decoded.
+	in      []byte
+	out     []byte
+	decoded int
+	eof     bool
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.eof {
+			if len(d.in) > 0 {
+				return 0, fmt.Errorf("a UTF-16 source must consist of an even number of bytes")
+			}
+			return 0, io.EOF
+		}
+		var chunk [readChunkSize]byte
+		n, err := d.src.Read(chunk[:])
+		d.in = append(d.in, chunk[:n]...)
+		if err == io.EOF {
+			d.eof = true
+		} else if err != nil {
+			return 0, err
+		}
+		d.decode()
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// decode decodes the bytes in `in` as many as possible. It leaves an incomplete code unit or surrogate pair in `in`
+// until the source ends.
+func (d *decoder) decode() {
+	i := 0
+	if d.enc == EncodingLatin1 {
+		for ; i < len(d.in); i++ {
+			d.out = utf8.AppendRune(d.out, rune(d.in[i]))
+		}
+	} else {
+		unit := func(j int) rune {
+			if d.enc == EncodingUTF16LE {
+				return rune(d.in[j]) | rune(d.in[j+1])<<8
+			}
+			return rune(d.in[j])<<8 | rune(d.in[j+1])
+		}
+		for i+2 <= len(d.in) {
+			r, n := unit(i), 2
+			if r >= 0xD800 && r < 0xDC00 {
+				// A high surrogate needs the following code unit.
+				if i+4 > len(d.in) && !d.eof {
+					break
+				}
+				hi := r
+				r = utf8.RuneError
+				if i+4 <= len(d.in) {
+					if lo := unit(i + 2); lo >= 0xDC00 && lo < 0xE000 {
+						r, n = 0x10000+(hi-0xD800)<<10+(lo-0xDC00), 4
+					}
+				}
+			} else if r >= 0xDC00 && r < 0xE000 {
+				r = utf8.RuneError
+			}
+			d.out = utf8.AppendRune(d.out, r)
+			i += n
+		}
+	}
+	d.decoded += i
+	d.in = d.in[:copy(d.in, d.in[i:])]
+}
+
+// countGraphemes counts the token positions in grapheme clusters. A byte `b` is the byte the lexer has just read.
+func (l *Lexer) countGraphemes(b byte) {
+	// A continuation byte belongs to the code point its first byte begins.
+	if b>>6 == 2 {
+		return
+	}
+	r := rune(b)
+	if b >= 0x80 {
+		r = l.peekRune(l.state.srcPtr - 1)
+	}
+	class := graphemeClassOf(r)
+	s := &l.state
+	breaks := isGraphemeBreak(s, class)
+	if breaks {
+		s.charRow = s.row
+		s.charCol = s.col
+	}
+	switch {
+	// 0x0A is LF. It ends a row even when it follows CR in the same cluster.
+	case b == 0x0A:
+		s.row++
+		s.col = 0
+		s.gcb = graphemeClassNone
+		s.riCount = 0
+		s.pict = pictNone
+		return
+	// 0x09 is a tab, which is a control character and always begins a cluster.
+	case b == 0x09 && l.tabWidth > 0:
+		s.col += l.tabWidth - s.col%l.tabWidth
+	case breaks:
+		s.col++
+	}
+
+	if class == graphemeClassRegionalIndicator {
+		s.riCount++
+	} else {
+		s.riCount = 0
+	}
+	switch {
+	case class == graphemeClassExtPict:
+		s.pict = pictExtPict
+	case class == graphemeClassExtend && s.pict == pictExtPict:
+	case class == graphemeClassZWJ && s.pict == pictExtPict:
+		s.pict = pictZWJ
+	default:
+		s.pict = pictNone
+	}
+	s.gcb = class
+}
+
+// peekRune decodes a code point whose first byte is at a byte position `pos`. An ill-formed or truncated sequence
+// decodes to U+FFFD.
+func (l *Lexer) peekRune(pos int) rune {
+	end := pos + utf8.UTFMax
+	if l.regionEnd >= 0 && end > l.regionEnd {
+		end = l.regionEnd
+	}
+	// read reports an error of the source when it reaches the byte the error occurs at, so this method ignores it.
+	_, _ = l.fill(end - 1)
+	to := end - l.bufOffset
+	if to > len(l.buf) {
+		to = len(l.buf)
+	}
+	r, _ := utf8.DecodeRune(l.buf[pos-l.bufOffset : to])
+	return r
+}
+
+// graphemeClass is a value of the Grapheme_Cluster_Break property defined in UAX #29.
+type graphemeClass int
+
+const (
+	// graphemeClassNone is the class before the first code point of a row.
+	graphemeClassNone graphemeClass = iota
+	graphemeClassOther
+	graphemeClassCR
+	graphemeClassLF
+	graphemeClassControl
+	graphemeClassExtend
+	graphemeClassZWJ
+	graphemeClassRegionalIndicator
+	graphemeClassPrepend
+	graphemeClassSpacingMark
+	graphemeClassL
+	graphemeClassV
+	graphemeClassT
+	graphemeClassLV
+	graphemeClassLVT
+	graphemeClassExtPict
+)
+
+type pictState int
+
+const (
+	pictNone pictState = iota
+	// pictExtPict means the code points read end with `ExtPict Extend*`.
+	pictExtPict
+	// pictZWJ means the code points read end with `ExtPict Extend* ZWJ`.
+	pictZWJ
+)
+
+// graphemeClassRange is a code point range of a grapheme class. grapheme.go, which cmd/graphemegen generates from
+// the ucd package, holds the table of the ranges.
+type graphemeClassRange struct {
+	from  rune
+	to    rune
+	class graphemeClass
+}
+
+// graphemeClassOf returns the Grapheme_Cluster_Break class of a code point.
+func graphemeClassOf(r rune) graphemeClass {
+	// Hangul syllables consist of leading consonants (L), vowels (V), and trailing consonants (T). A precomposed
+	// syllable is LV when it has no trailing consonant; otherwise, it is LVT.
+	if r >= 0xAC00 && r <= 0xD7A3 {
+		if (r-0xAC00)%28 == 0 {
+			return graphemeClassLV
+		}
+		return graphemeClassLVT
+	}
+	lo, hi := 0, len(graphemeClassRanges)
+	for lo < hi {
+		m := lo + (hi-lo)/2
+		switch rng := graphemeClassRanges[m]; {
+		case r < rng.from:
+			hi = m
+		case r > rng.to:
+			lo = m + 1
+		default:
+			return rng.class
+		}
+	}
+	return graphemeClassOther
+}
+
+// isGraphemeBreak reports whether a code point of a class `next` begins a new grapheme cluster after the code points
+// that a state `s` has read. The rules are those of UAX #29.
+func isGraphemeBreak(s *lexerState, next graphemeClass) bool {
+	prev := s.gcb
+	switch {
+	// GB1: Break at the start of text. The lexer also breaks at the start of each row.
+	case prev == graphemeClassNone:
+		return true
+	// GB3: Do not break between CR and LF.
+	case prev == graphemeClassCR && next == graphemeClassLF:
+		return false
+	// GB4 and GB5: Otherwise, break before and after controls.
+	case prev == graphemeClassCR || prev == graphemeClassLF || prev == graphemeClassControl:
+		return true
+	case next == graphemeClassCR || next == graphemeClassLF || next == graphemeClassControl:
+		return true
+	// GB6, GB7, and GB8: Do not break Hangul syllable sequences.
+	case prev == graphemeClassL && (next == graphemeClassL || next == graphemeClassV || next == graphemeClassLV || next == graphemeClassLVT):
+		return false
+	case (prev == graphemeClassLV || prev == graphemeClassV) && (next == graphemeClassV || next == graphemeClassT):
+		return false
+	case (prev == graphemeClassLVT || prev == graphemeClassT) && next == graphemeClassT:
+		return false
+	// GB9, GB9a, and GB9b: Do not break before extending characters, ZWJ, and spacing marks, or after prepend
+	// characters.
+	case next == graphemeClassExtend || next == graphemeClassZWJ || next == graphemeClassSpacingMark:
+		return false
+	case prev == graphemeClassPrepend:
+		return false
+	// GB11: Do not break within emoji ZWJ sequences.
+	case s.pict == pictZWJ && next == graphemeClassExtPict:
+		return false
+	// GB12 and GB13: Do not break within emoji flag sequences, which are pairs of regional indicators.
+	case prev == graphemeClassRegionalIndicator && next == graphemeClassRegionalIndicator:
+		return s.riCount%2 == 0
+	}
+	// GB999: Otherwise, break everywhere.
+	return true
+}
+
+// Classify returns a kind of a lexeme `lexeme` when the whole lexeme matches a pattern of the kind in a lex mode `mode`.
+// Unlike the lexer, this function doesn't search for the longest match; a lexeme that only partially matches a pattern
+// isn't classified. When a lexeme matches a keyword, this function returns the keyword kind.
+func Classify(spec LexSpec, mode ModeID, lexeme []byte) (KindID, string, bool) {
+	if len(lexeme) == 0 {
+		return 0, "", false
+	}
+	state := spec.InitialState(mode)
+	for _, b := range lexeme {
+		next, ok := spec.NextState(mode, state, int(b))
+		if !ok {
+			return 0, "", false
+		}
+		state = next
+	}
+	modeKind, ok := spec.Accept(mode, state)
+	if !ok {
+		return 0, "", false
+	}
+	if kw, ok := spec.Keyword(mode, modeKind, lexeme); ok {
+		modeKind = kw
+	}
+	kindID, kindName := spec.KindIDAndName(mode, modeKind)
+	return kindID, kindName, true
+}
+
+// keyword returns a keyword that a lexeme of a kind equals according to the case sensitivity of the lexer.
+func (l *Lexer) keyword(mode ModeID, modeKind ModeKindID, lexeme []byte) (ModeKindID, bool) {
+	if l.caseInsensitive {
+		return l.spec.CaseInsensitiveKeyword(mode, modeKind, lexeme)
+	}
+	return l.spec.Keyword(mode, modeKind, lexeme)
+}
+
+// acceptingKind returns a kind a state accepts according to the case sensitivity of the lexer.
+func (l *Lexer) acceptingKind(mode ModeID, state StateID) (ModeKindID, bool) {
+	if l.caseInsensitive {
+		return l.spec.CaseInsensitiveAccept(mode, state)
+	}
+	return l.spec.Accept(mode, state)
+}
+
 // accept saves the current state.
 func (l *Lexer) accept() {
 	l.lastAcceptedState = l.state
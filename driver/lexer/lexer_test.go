@@ -3,8 +3,11 @@ package lexer
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"unicode/utf16"
 
 	"github.com/nihei9/vartan/grammar/lexical"
 	spec "github.com/nihei9/vartan/spec/grammar"
@@ -747,6 +750,69 @@ func TestLexer_Next(t *testing.T) {
 				withPos(newEOFTokenDefault(), 6, 0, 0, 6),
 			},
 		},
+		// Character properties match letters whose UTF-8 encodings span several lead bytes, such as Greek and Cyrillic
+		// letters.
+		{
+			lspec: &lexical.LexSpec{
+				Entries: []*lexical.LexEntry{
+					newLexEntryDefaultNOP("letter", `\p{L}+`),
+					newLexEntryDefaultNOP("non_letter", `[^\p{L}]+`),
+				},
+			},
+			src: `λω жя`,
+			tokens: []*Token{
+				withPos(newTokenDefault(1, 1, []byte(`λω`)), 0, 4, 0, 0),
+				withPos(newTokenDefault(2, 2, []byte(` `)), 4, 1, 0, 2),
+				withPos(newTokenDefault(1, 1, []byte(`жя`)), 5, 4, 0, 3),
+				withPos(newEOFTokenDefault(), 9, 0, 0, 5),
+			},
+		},
+		// A lexeme matching a keyword of a kind is remapped to the keyword.
+		{
+			lspec: &lexical.LexSpec{
+				Entries: []*lexical.LexEntry{
+					{
+						Kind:    "id",
+						Pattern: "[a-z]+",
+						Modes: []spec.LexModeName{
+							spec.LexModeNameDefault,
+						},
+						Keywords: []spec.LexKindName{
+							"kw_if",
+							"kw_while",
+						},
+					},
+					{
+						Kind:    "kw_if",
+						Pattern: "if",
+						Modes: []spec.LexModeName{
+							spec.LexModeNameDefault,
+						},
+						Keyword: true,
+					},
+					{
+						Kind:    "kw_while",
+						Pattern: "while",
+						Modes: []spec.LexModeName{
+							spec.LexModeNameDefault,
+						},
+						Keyword: true,
+					},
+					newLexEntryDefaultNOP("ws", " +"),
+				},
+			},
+			src: "if iff while whiles",
+			tokens: []*Token{
+				withPos(newTokenDefault(2, 2, []byte("if")), 0, 2, 0, 0),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 2, 1, 0, 2),
+				withPos(newTokenDefault(1, 1, []byte("iff")), 3, 3, 0, 3),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 6, 1, 0, 6),
+				withPos(newTokenDefault(3, 3, []byte("while")), 7, 5, 0, 7),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 12, 1, 0, 12),
+				withPos(newTokenDefault(1, 1, []byte("whiles")), 13, 6, 0, 13),
+				withPos(newEOFTokenDefault(), 19, 0, 0, 19),
+			},
+		},
 		// The driver can continue lexical analysis even after it detects an invalid token.
 		{
 			lspec: &lexical.LexSpec{
@@ -776,11 +842,87 @@ func TestLexer_Next(t *testing.T) {
 				withPos(newEOFTokenDefault(), 6, 0, 0, 6),
 			},
 		},
+		{
+			lspec: &lexical.LexSpec{
+				Entries: []*lexical.LexEntry{
+					newLexEntryDefaultNOP("consonant", "[a-z--[aeiou]]+"),
+					newLexEntryDefaultNOP("vowel", "[aeiou]"),
+				},
+			},
+			src: "bcdaxe",
+			tokens: []*Token{
+				withPos(newTokenDefault(1, 1, []byte("bcd")), 0, 3, 0, 0),
+				withPos(newTokenDefault(2, 2, []byte("a")), 3, 1, 0, 3),
+				withPos(newTokenDefault(1, 1, []byte("x")), 4, 1, 0, 4),
+				withPos(newTokenDefault(2, 2, []byte("e")), 5, 1, 0, 5),
+				withPos(newEOFTokenDefault(), 6, 0, 0, 6),
+			},
+		},
+		{
+			lspec: &lexical.LexSpec{
+				Entries: []*lexical.LexEntry{
+					newLexEntryDefaultNOP("hex_upper", "[A-Z&&[A-Fa-f]]+"),
+					newLexEntryDefaultNOP("lower", "[a-z--\\u{0078}]+"),
+					newLexEntryDefaultNOP("white_space", "[\\p{White_Space=yes}--[\\u{000A}\\u{000D}]]+"),
+					newLexEntryDefaultNOP("other", "."),
+				},
+			},
+			src: "ABGfoox \t\n",
+			tokens: []*Token{
+				withPos(newTokenDefault(1, 1, []byte("AB")), 0, 2, 0, 0),
+				withPos(newTokenDefault(4, 4, []byte("G")), 2, 1, 0, 2),
+				withPos(newTokenDefault(2, 2, []byte("foo")), 3, 3, 0, 3),
+				withPos(newTokenDefault(4, 4, []byte("x")), 6, 1, 0, 6),
+				withPos(newTokenDefault(3, 3, []byte(" \t")), 7, 2, 0, 7),
+				withPos(newTokenDefault(4, 4, []byte("\n")), 9, 1, 0, 9),
+				withPos(newEOFTokenDefault(), 10, 0, 1, 0),
+			},
+		},
+		{
+			lspec: &lexical.LexSpec{
+				Entries: []*lexical.LexEntry{
+					// An inverse bracket expression inverts the result of the set operations.
+					newLexEntryDefaultNOP("not_letter_but_x", "[^a-z--[x]]+"),
+					newLexEntryDefaultNOP("letter", "[a-z]+"),
+				},
+			},
+			src: "x1ab",
+			tokens: []*Token{
+				withPos(newTokenDefault(1, 1, []byte("x1")), 0, 2, 0, 0),
+				withPos(newTokenDefault(2, 2, []byte("ab")), 2, 2, 0, 2),
+				withPos(newEOFTokenDefault(), 4, 0, 0, 4),
+			},
+		},
+		{
+			lspec: &lexical.LexSpec{
+				Entries: []*lexical.LexEntry{
+					newLexEntryDefaultNOP("hex", "[[:xdigit:]]+"),
+					newLexEntryDefaultNOP("blank", "[[:blank:]]+"),
+					newLexEntryDefaultNOP("other", "[[:cntrl:]--[:blank:]]"),
+				},
+			},
+			src: "0aF \t\n",
+			tokens: []*Token{
+				withPos(newTokenDefault(1, 1, []byte("0aF")), 0, 3, 0, 0),
+				withPos(newTokenDefault(2, 2, []byte(" \t")), 3, 2, 0, 3),
+				withPos(newTokenDefault(3, 3, []byte("\n")), 5, 1, 0, 5),
+				withPos(newEOFTokenDefault(), 6, 0, 1, 0),
+			},
+		},
 	}
 	for i, tt := range test {
-		for compLv := lexical.CompressionLevelMin; compLv <= lexical.CompressionLevelMax; compLv++ {
-			t.Run(fmt.Sprintf("#%v-%v", i, compLv), func(t *testing.T) {
-				clspec, err, cerrs := lexical.Compile(tt.lspec, compLv)
+		for compLv := lexical.CompressionLevelMin; compLv <= lexical.CompressionLevelMax+1; compLv++ {
+			lspec := tt.lspec
+			name := fmt.Sprintf("#%v-%v", i, compLv)
+			// The extra level runs a lexer building a DFA lazily.
+			if compLv > lexical.CompressionLevelMax {
+				l := *tt.lspec
+				l.LazyDFA = true
+				lspec = &l
+				name = fmt.Sprintf("#%v-lazy", i)
+			}
+			t.Run(name, func(t *testing.T) {
+				clspec, err, cerrs := lexical.Compile(lspec, compLv%(lexical.CompressionLevelMax+1))
 				if err != nil {
 					for _, cerr := range cerrs {
 						t.Logf("%#v", cerr)
@@ -819,6 +961,168 @@ func TestLexer_Next(t *testing.T) {
 	}
 }
 
+func TestLexer_Next_CaseConfigurable(t *testing.T) {
+	kwSelect := newLexEntryDefaultNOP("kw_select", "select")
+	kwSelect.CaseConfigurable = true
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			kwSelect,
+			newLexEntryDefaultNOP("id", "[A-Za-z]+"),
+			newLexEntryDefaultNOP("ws", " +"),
+		},
+	}
+
+	test := []struct {
+		caseInsensitive bool
+		tokens          []*Token
+	}{
+		{
+			caseInsensitive: false,
+			tokens: []*Token{
+				withPos(newTokenDefault(1, 1, []byte("select")), 0, 6, 0, 0),
+				withPos(newTokenDefault(3, 3, []byte(" ")), 6, 1, 0, 6),
+				withPos(newTokenDefault(2, 2, []byte("SELECT")), 7, 6, 0, 7),
+				withPos(newTokenDefault(3, 3, []byte(" ")), 13, 1, 0, 13),
+				withPos(newTokenDefault(2, 2, []byte("Select")), 14, 6, 0, 14),
+				withPos(newTokenDefault(3, 3, []byte(" ")), 20, 1, 0, 20),
+				withPos(newTokenDefault(2, 2, []byte("SELECTED")), 21, 8, 0, 21),
+				withPos(newEOFTokenDefault(), 29, 0, 0, 29),
+			},
+		},
+		{
+			caseInsensitive: true,
+			tokens: []*Token{
+				withPos(newTokenDefault(1, 1, []byte("select")), 0, 6, 0, 0),
+				withPos(newTokenDefault(3, 3, []byte(" ")), 6, 1, 0, 6),
+				withPos(newTokenDefault(1, 1, []byte("SELECT")), 7, 6, 0, 7),
+				withPos(newTokenDefault(3, 3, []byte(" ")), 13, 1, 0, 13),
+				withPos(newTokenDefault(1, 1, []byte("Select")), 14, 6, 0, 14),
+				withPos(newTokenDefault(3, 3, []byte(" ")), 20, 1, 0, 20),
+				withPos(newTokenDefault(2, 2, []byte("SELECTED")), 21, 8, 0, 21),
+				withPos(newEOFTokenDefault(), 29, 0, 0, 29),
+			},
+		},
+	}
+	lazyLSpec := *lspec
+	lazyLSpec.LazyDFA = true
+	for i, tt := range test {
+		for compLv := lexical.CompressionLevelMin; compLv <= lexical.CompressionLevelMax+1; compLv++ {
+			lspec := lspec
+			name := fmt.Sprintf("#%v-%v", i, compLv)
+			// The extra level runs a lexer building a DFA lazily.
+			if compLv > lexical.CompressionLevelMax {
+				lspec = &lazyLSpec
+				name = fmt.Sprintf("#%v-lazy", i)
+			}
+			t.Run(name, func(t *testing.T) {
+				clspec, err, _ := lexical.Compile(lspec, compLv%(lexical.CompressionLevelMax+1))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				opts := []LexerOption{}
+				if tt.caseInsensitive {
+					opts = append(opts, DisableCaseSensitivity())
+				}
+				lexer, err := NewLexer(NewLexSpec(clspec), strings.NewReader("select SELECT Select SELECTED"), opts...)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				for _, eTok := range tt.tokens {
+					tok, err := lexer.Next()
+					if err != nil {
+						t.Fatal(err)
+					}
+					testToken(t, eTok, tok)
+					if tok.EOF {
+						break
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestLexer_Next_CaseConfigurableKeywords(t *testing.T) {
+	id := newLexEntryDefaultNOP("id", "[A-Za-z]+")
+	id.Keywords = []spec.LexKindName{"kw_select", "kw_from"}
+	kwSelect := newLexEntryDefaultNOP("kw_select", "select")
+	kwSelect.Keyword = true
+	kwSelect.CaseConfigurable = true
+	kwFrom := newLexEntryDefaultNOP("kw_from", "from")
+	kwFrom.Keyword = true
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			id,
+			kwSelect,
+			kwFrom,
+			newLexEntryDefaultNOP("ws", " +"),
+		},
+	}
+
+	test := []struct {
+		caseInsensitive bool
+		tokens          []*Token
+	}{
+		{
+			caseInsensitive: false,
+			tokens: []*Token{
+				withPos(newTokenDefault(2, 2, []byte("select")), 0, 6, 0, 0),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 6, 1, 0, 6),
+				withPos(newTokenDefault(1, 1, []byte("SELECT")), 7, 6, 0, 7),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 13, 1, 0, 13),
+				withPos(newTokenDefault(1, 1, []byte("SeLeCt")), 14, 6, 0, 14),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 20, 1, 0, 20),
+				withPos(newTokenDefault(3, 3, []byte("from")), 21, 4, 0, 21),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 25, 1, 0, 25),
+				withPos(newTokenDefault(1, 1, []byte("FROM")), 26, 4, 0, 26),
+				withPos(newEOFTokenDefault(), 30, 0, 0, 30),
+			},
+		},
+		// Only the case-configurable keyword is looked up case-insensitively.
+		{
+			caseInsensitive: true,
+			tokens: []*Token{
+				withPos(newTokenDefault(2, 2, []byte("select")), 0, 6, 0, 0),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 6, 1, 0, 6),
+				withPos(newTokenDefault(2, 2, []byte("SELECT")), 7, 6, 0, 7),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 13, 1, 0, 13),
+				withPos(newTokenDefault(2, 2, []byte("SeLeCt")), 14, 6, 0, 14),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 20, 1, 0, 20),
+				withPos(newTokenDefault(3, 3, []byte("from")), 21, 4, 0, 21),
+				withPos(newTokenDefault(4, 4, []byte(" ")), 25, 1, 0, 25),
+				withPos(newTokenDefault(1, 1, []byte("FROM")), 26, 4, 0, 26),
+				withPos(newEOFTokenDefault(), 30, 0, 0, 30),
+			},
+		},
+	}
+	for i, tt := range test {
+		t.Run(fmt.Sprintf("#%v", i), func(t *testing.T) {
+			clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			opts := []LexerOption{}
+			if tt.caseInsensitive {
+				opts = append(opts, DisableCaseSensitivity())
+			}
+			lexer, err := NewLexer(NewLexSpec(clspec), strings.NewReader("select SELECT SeLeCt from FROM"), opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, eTok := range tt.tokens {
+				tok, err := lexer.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				testToken(t, eTok, tok)
+				if tok.EOF {
+					break
+				}
+			}
+		})
+	}
+}
+
 func TestLexer_Next_WithPosition(t *testing.T) {
 	lspec := &lexical.LexSpec{
 		Entries: []*lexical.LexEntry{
@@ -930,3 +1234,1229 @@ func testToken(t *testing.T, expected, actual *Token) {
 		t.Fatalf(`unexpected token; want: %+v, got: %+v`, expected, actual)
 	}
 }
+
+func TestLexer_ModeStack(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntry([]string{"default"}, "string_open", `"`, "string", false),
+			newLexEntry([]string{"string"}, "char_seq", `[^"]+`, "", false),
+			newLexEntry([]string{"string"}, "string_close", `"`, "", true),
+		},
+	}
+
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewLexSpec(clspec)
+
+	testModeStack := func(t *testing.T, l *Lexer, expected []string) {
+		t.Helper()
+
+		stack := l.ModeStack()
+		if len(stack) != len(expected) {
+			t.Fatalf("unexpected mode stack; want: %v, got: %v", expected, stack)
+		}
+		for i, m := range stack {
+			if s.ModeName(m) != expected[i] {
+				t.Fatalf("unexpected mode stack; want: %v, got: %v", expected, stack)
+			}
+		}
+	}
+
+	testKind := func(t *testing.T, l *Lexer, expected string) {
+		t.Helper()
+
+		tok, err := l.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.EOF || tok.Invalid {
+			t.Fatalf("unexpected token: %+v", tok)
+		}
+		if _, kind := s.KindIDAndName(tok.ModeID, tok.ModeKindID); kind != expected {
+			t.Fatalf("unexpected kind; want: %v, got: %v", expected, kind)
+		}
+	}
+
+	l, err := NewLexer(s, strings.NewReader(`"foo`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testModeStack(t, l, []string{"default"})
+	testKind(t, l, "string_open")
+	testModeStack(t, l, []string{"default", "string"})
+
+	// The returned stack is a copy, so modifying it doesn't affect the lexer.
+	l.ModeStack()[0] = l.Mode()
+	testModeStack(t, l, []string{"default", "string"})
+
+	err = l.Reset(strings.NewReader(`"bar"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testModeStack(t, l, []string{"default"})
+	testKind(t, l, "string_open")
+	testKind(t, l, "char_seq")
+	testKind(t, l, "string_close")
+	testModeStack(t, l, []string{"default"})
+
+	var stringMode ModeID
+	for i, name := range clspec.ModeNames {
+		if name == "string" {
+			stringMode = ModeID(i)
+		}
+	}
+	l.SetInitialMode(stringMode)
+	testModeStack(t, l, []string{"string"})
+
+	err = l.Reset(strings.NewReader(`baz`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testModeStack(t, l, []string{"string"})
+	tok, err := l.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testToken(t, withPos(newToken(stringMode, tok.KindID, tok.ModeKindID, []byte("baz")), 0, 3, 0, 0), tok)
+}
+
+func TestLexer_Next_ColumnUnit(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("newline", `\u{000A}`),
+			newLexEntryDefaultNOP("tab", `\u{0009}`),
+			newLexEntryDefaultNOP("word", `[^\u{0009}\u{000A}]+`),
+		},
+	}
+
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// U+3042 is encoded in 3 bytes and U+1F600 is encoded in 4 bytes.
+	src := "a\tあ\U0001F600\nbc"
+
+	type pos struct {
+		row    int
+		col    int
+		endRow int
+		endCol int
+	}
+
+	tests := []struct {
+		caption  string
+		opts     []LexerOption
+		expected []pos
+	}{
+		{
+			caption: "the lexer counts columns in code points by default",
+			expected: []pos{
+				{0, 0, 0, 0},
+				{0, 1, 0, 1},
+				{0, 2, 0, 3},
+				{0, 4, 0, 4},
+				{1, 0, 1, 1},
+				{1, 2, 1, 2},
+			},
+		},
+		{
+			caption: "the lexer counts columns in bytes",
+			opts: []LexerOption{
+				CountColumnsIn(ColumnUnitByte),
+			},
+			expected: []pos{
+				{0, 0, 0, 0},
+				{0, 1, 0, 1},
+				{0, 2, 0, 5},
+				{0, 9, 0, 9},
+				{1, 0, 1, 1},
+				{1, 2, 1, 2},
+			},
+		},
+		{
+			caption: "the lexer counts columns in UTF-16 code units",
+			opts: []LexerOption{
+				CountColumnsIn(ColumnUnitUTF16),
+			},
+			expected: []pos{
+				{0, 0, 0, 0},
+				{0, 1, 0, 1},
+				{0, 2, 0, 3},
+				{0, 5, 0, 5},
+				{1, 0, 1, 1},
+				{1, 2, 1, 2},
+			},
+		},
+		{
+			caption: "a tab advances a column to the next tab stop",
+			opts: []LexerOption{
+				TabWidth(4),
+			},
+			expected: []pos{
+				{0, 0, 0, 0},
+				{0, 1, 0, 1},
+				{0, 4, 0, 5},
+				{0, 6, 0, 6},
+				{1, 0, 1, 1},
+				{1, 2, 1, 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			l, err := NewLexer(NewLexSpec(clspec), strings.NewReader(src), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range tt.expected {
+				tok, err := l.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				actual := pos{tok.Row, tok.Col, tok.EndRow, tok.EndCol}
+				if actual != e {
+					t.Fatalf("unexpected position; want: %+v, got: %+v: %+v", e, actual, tok)
+				}
+			}
+		})
+	}
+}
+
+func TestLexer_Next_GraphemeColumns(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("newline", `\u{000A}`),
+			newLexEntryDefaultNOP("cr", `\u{000D}`),
+			newLexEntryDefaultNOP("space", `\u{0020}`),
+			newLexEntryDefaultNOP("acute", `\u{0301}`),
+			newLexEntryDefaultNOP("word", `[^\u{000A}\u{000D}\u{0020}\u{0301}]+`),
+		},
+	}
+
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	src := strings.Join([]string{
+		// `e` followed by U+0308 COMBINING DIAERESIS is one cluster.
+		"e\u0308x",
+		// Regional indicators form clusters in pairs.
+		"\U0001F1EF\U0001F1F5\U0001F1FA\U0001F1F8",
+		// ZWJ joins emojis into one cluster.
+		"\U0001F468\u200D\U0001F469",
+		// Hangul jamo L, V, and T form one cluster.
+		"\u1100\u1161\u11A8k",
+		// U+2E80 CJK RADICAL REPEAT is a symbol (So) but not Extended_Pictographic, so ZWJ doesn't join it with
+		// the next one. U+0D4E MALAYALAM LETTER DOT REPH is Prepend and begins a cluster with the next letter.
+		"\u2E80\u200D\u2E80\u0D4E\u0D15",
+		// U+0301 COMBINING ACUTE ACCENT continues the cluster of `e` even though it is another token. So does LF
+		// following CR.
+		"e\u0301\r\nz",
+	}, " ")
+
+	type pos struct {
+		row    int
+		col    int
+		endRow int
+		endCol int
+	}
+	expected := []pos{
+		{0, 0, 0, 1},
+		{0, 2, 0, 2},
+		{0, 3, 0, 4},
+		{0, 5, 0, 5},
+		{0, 6, 0, 6},
+		{0, 7, 0, 7},
+		{0, 8, 0, 9},
+		{0, 10, 0, 10},
+		{0, 11, 0, 13},
+		{0, 14, 0, 14},
+		{0, 15, 0, 15},
+		{0, 15, 0, 15},
+		{0, 16, 0, 16},
+		{0, 16, 0, 16},
+		{1, 0, 1, 0},
+	}
+
+	l, err := NewLexer(NewLexSpec(clspec), strings.NewReader(src), CountColumnsIn(ColumnUnitGrapheme))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range expected {
+		tok, err := l.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := pos{tok.Row, tok.Col, tok.EndRow, tok.EndCol}
+		if actual != e {
+			t.Fatalf("unexpected position; want: %+v, got: %+v: %q", e, actual, tok.Lexeme)
+		}
+	}
+	tok, err := l.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tok.EOF {
+		t.Fatalf("unexpected token: %q", tok.Lexeme)
+	}
+}
+
+func TestLexer_InvalidColumnOptions(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("any", `.`),
+		},
+	}
+
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, opt := range []LexerOption{CountColumnsIn(ColumnUnit(99)), TabWidth(0)} {
+		_, err := NewLexer(NewLexSpec(clspec), strings.NewReader(""), opt)
+		if err == nil {
+			t.Fatal("an expected error didn't occur")
+		}
+	}
+}
+
+func TestLexer_Next_InvalidTokens(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("white_space", `[\u{0009}\u{0020}]+`),
+			newLexEntryDefaultNOP("word", `[a-z]+`),
+		},
+	}
+
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		caption string
+		opts    []LexerOption
+		invalid []string
+	}{
+		{
+			caption: "the lexer merges consecutive invalid tokens by default",
+			invalid: []string{"12#", "!"},
+		},
+		{
+			caption: "the lexer returns invalid tokens separately when merging is disabled",
+			opts:    []LexerOption{DisableInvalidTokenMerging()},
+			invalid: []string{"1", "2", "#", "!"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			var handled []string
+			opts := append(tt.opts, OnInvalidToken(func(tok *Token) {
+				handled = append(handled, fmt.Sprintf("%v@%v", string(tok.Lexeme), tok.BytePos))
+			}))
+			l, err := NewLexer(NewLexSpec(clspec), strings.NewReader("ab 12# cd!"), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var invalid []string
+			var spans []string
+			words := 0
+			for {
+				tok, err := l.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tok.EOF {
+					break
+				}
+				if tok.Invalid {
+					invalid = append(invalid, string(tok.Lexeme))
+					spans = append(spans, fmt.Sprintf("%v@%v", string(tok.Lexeme), tok.BytePos))
+					continue
+				}
+				if string(tok.Lexeme) == "ab" || string(tok.Lexeme) == "cd" {
+					words++
+				}
+			}
+			if !reflect.DeepEqual(invalid, tt.invalid) {
+				t.Fatalf("unexpected invalid tokens: want: %v, got: %v", tt.invalid, invalid)
+			}
+			if !reflect.DeepEqual(handled, spans) {
+				t.Fatalf("the handler must be called with each invalid token: want: %v, got: %v", spans, handled)
+			}
+			if words != 2 {
+				t.Fatalf("the valid tokens following invalid ones must not be lost: %v words", words)
+			}
+		})
+	}
+
+	_, err = NewLexer(NewLexSpec(clspec), strings.NewReader(""), OnInvalidToken(nil))
+	if err == nil {
+		t.Fatal("an expected error didn't occur")
+	}
+}
+
+func TestLexer_Next_Region(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntry([]string{"default"}, "white_space", `[\u{0009}\u{000A}\u{0020}]+`, "", false),
+			newLexEntry([]string{"default"}, "string_open", `"`, "string", false),
+			newLexEntry([]string{"default"}, "word", `[a-z]+`, "", false),
+			newLexEntry([]string{"string"}, "char_seq", `[^"]+`, "", false),
+			newLexEntry([]string{"string"}, "string_close", `"`, "", true),
+		},
+	}
+
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewLexSpec(clspec)
+
+	var defaultMode, stringMode ModeID
+	for i, name := range clspec.ModeNames {
+		switch name {
+		case "default":
+			defaultMode = ModeID(i)
+		case "string":
+			stringMode = ModeID(i)
+		}
+	}
+
+	// The region `foo bar"` is in the middle of a string literal.
+	src := "ignored\n\"xx foo bar\" ignored"
+	start := strings.Index(src, "foo")
+	end := strings.LastIndex(src, " ")
+
+	l, err := NewLexer(s, strings.NewReader(src), Region(start, end), InitialModeStack(defaultMode, stringMode))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		kind   string
+		lexeme string
+		pos    int
+		row    int
+		col    int
+	}{
+		{"char_seq", "foo bar", 12, 1, 4},
+		{"string_close", `"`, 19, 1, 11},
+		{"", "", 20, 1, 12},
+	}
+	for _, e := range expected {
+		tok, err := l.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.kind == "" {
+			if !tok.EOF {
+				t.Fatalf("unexpected token; want: EOF, got: %+v", tok)
+			}
+		} else {
+			_, kind := s.KindIDAndName(tok.ModeID, tok.ModeKindID)
+			if kind != e.kind || string(tok.Lexeme) != e.lexeme {
+				t.Fatalf("unexpected token; want: %v (%v), got: %v (%v)", e.kind, e.lexeme, kind, string(tok.Lexeme))
+			}
+		}
+		if tok.BytePos != e.pos || tok.Row != e.row || tok.Col != e.col {
+			t.Fatalf("unexpected position; want: %v, %v:%v, got: %v, %v:%v", e.pos, e.row, e.col, tok.BytePos, tok.Row, tok.Col)
+		}
+	}
+
+	for _, opt := range []LexerOption{Region(-1, 3), Region(3, 1), Region(0, len(src)+1), InitialModeStack()} {
+		_, err := NewLexer(s, strings.NewReader(src), opt)
+		if err == nil {
+			t.Fatal("an expected error didn't occur")
+		}
+	}
+}
+
+func TestLexer_Next_Encoding(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("white_space", `[\u{0009}\u{000A}\u{0020}]+`),
+			newLexEntryDefaultNOP("word", `[a-z\u{00E9}]+`),
+			newLexEntryDefaultNOP("smile", `\u{01F600}`),
+		},
+	}
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewLexSpec(clspec)
+
+	utf16LE := func(src string) []byte {
+		var b []byte
+		for _, u := range utf16.Encode([]rune(src)) {
+			b = append(b, byte(u), byte(u>>8))
+		}
+		return b
+	}
+	utf16BE := func(src string) []byte {
+		var b []byte
+		for _, u := range utf16.Encode([]rune(src)) {
+			b = append(b, byte(u>>8), byte(u))
+		}
+		return b
+	}
+
+	type tokenAt struct {
+		kind   string
+		lexeme string
+		pos    int
+		len    int
+	}
+	utf16Tokens := func(offset int) []tokenAt {
+		return []tokenAt{
+			{"word", "é", offset, 2},
+			{"white_space", " ", offset + 2, 2},
+			{"word", "a", offset + 4, 2},
+			{"smile", "😀", offset + 6, 4},
+			{"", "", offset + 10, 0},
+		}
+	}
+	tests := []struct {
+		caption string
+		src     []byte
+		opts    []LexerOption
+		tokens  []tokenAt
+	}{
+		{
+			caption: "the lexer decodes UTF-16LE",
+			src:     utf16LE("é a😀"),
+			opts:    []LexerOption{WithEncoding(EncodingUTF16LE)},
+			tokens:  utf16Tokens(0),
+		},
+		{
+			caption: "the lexer decodes UTF-16BE",
+			src:     utf16BE("é a😀"),
+			opts:    []LexerOption{WithEncoding(EncodingUTF16BE)},
+			tokens:  utf16Tokens(0),
+		},
+		{
+			caption: "the lexer detects UTF-16LE by a BOM",
+			src:     append([]byte{0xFF, 0xFE}, utf16LE("é a😀")...),
+			opts:    []LexerOption{WithEncoding(EncodingAuto)},
+			tokens:  utf16Tokens(2),
+		},
+		{
+			caption: "the lexer detects UTF-16BE by a BOM",
+			src:     append([]byte{0xFE, 0xFF}, utf16BE("é a😀")...),
+			opts:    []LexerOption{WithEncoding(EncodingAuto)},
+			tokens:  utf16Tokens(2),
+		},
+		{
+			caption: "the lexer skips a BOM of UTF-8",
+			src:     append([]byte{0xEF, 0xBB, 0xBF}, "é a"...),
+			opts:    []LexerOption{WithEncoding(EncodingAuto)},
+			tokens: []tokenAt{
+				{"word", "é", 3, 2},
+				{"white_space", " ", 5, 1},
+				{"word", "a", 6, 1},
+				{"", "", 7, 0},
+			},
+		},
+		{
+			caption: "the lexer reads a source without a BOM as UTF-8",
+			src:     []byte("é a"),
+			opts:    []LexerOption{WithEncoding(EncodingAuto)},
+			tokens: []tokenAt{
+				{"word", "é", 0, 2},
+				{"white_space", " ", 2, 1},
+				{"word", "a", 3, 1},
+				{"", "", 4, 0},
+			},
+		},
+		{
+			caption: "the lexer decodes Latin-1",
+			src:     []byte("\xE9 a"),
+			opts:    []LexerOption{WithEncoding(EncodingLatin1)},
+			tokens: []tokenAt{
+				{"word", "é", 0, 1},
+				{"white_space", " ", 1, 1},
+				{"word", "a", 2, 1},
+				{"", "", 3, 0},
+			},
+		},
+		{
+			caption: "an unpaired surrogate becomes U+FFFD",
+			src:     []byte{0x00, 0xD8, 'a', 0x00},
+			opts:    []LexerOption{WithEncoding(EncodingUTF16LE)},
+			tokens: []tokenAt{
+				{"", "\uFFFD", 0, 2},
+				{"word", "a", 2, 2},
+				{"", "", 4, 0},
+			},
+		},
+		{
+			caption: "a region is in bytes of the original source",
+			src:     utf16LE("é a😀 b"),
+			opts:    []LexerOption{WithEncoding(EncodingUTF16LE), Region(4, 10)},
+			tokens: []tokenAt{
+				{"word", "a", 4, 2},
+				{"smile", "😀", 6, 4},
+				{"", "", 10, 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			// Reading a byte at a time checks that the decoder handles characters split across reads.
+			l, err := NewLexer(s, iotest.OneByteReader(bytes.NewReader(tt.src)), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range tt.tokens {
+				tok, err := l.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				var kind string
+				if !tok.EOF && !tok.Invalid {
+					_, k := s.KindIDAndName(tok.ModeID, tok.ModeKindID)
+					kind = k
+				}
+				if kind != e.kind || string(tok.Lexeme) != e.lexeme || tok.EOF != (e.kind == "" && e.lexeme == "") {
+					t.Fatalf("unexpected token; want: %v (%q), got: %v (%q)", e.kind, e.lexeme, kind, tok.Lexeme)
+				}
+				if tok.BytePos != e.pos || tok.ByteLen != e.len {
+					t.Fatalf("unexpected position of %q; want: %v+%v, got: %v+%v", tok.Lexeme, e.pos, e.len, tok.BytePos, tok.ByteLen)
+				}
+			}
+		})
+	}
+
+	t.Run("a UTF-16 source having an odd number of bytes is an error", func(t *testing.T) {
+		l, err := NewLexer(s, bytes.NewReader(append(utf16LE("a"), 'b')), WithEncoding(EncodingUTF16LE))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			tok, err := l.Next()
+			if err != nil {
+				break
+			}
+			if tok.EOF {
+				t.Fatal("an expected error didn't occur")
+			}
+		}
+	})
+
+	t.Run("the lexer detects the encoding of a new source on reset", func(t *testing.T) {
+		l, err := NewLexer(s, strings.NewReader("a"), WithEncoding(EncodingAuto))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = l.Reset(bytes.NewReader(append([]byte{0xFE, 0xFF}, utf16BE("é")...)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tok, err := l.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(tok.Lexeme) != "é" || tok.BytePos != 2 || tok.ByteLen != 2 {
+			t.Fatalf("unexpected token: %q at %v+%v", tok.Lexeme, tok.BytePos, tok.ByteLen)
+		}
+	})
+
+	for _, opts := range [][]LexerOption{
+		{WithEncoding(Encoding(-1))},
+		{WithEncoding(EncodingUTF16LE), Region(0, 12)},
+	} {
+		_, err := NewLexer(s, bytes.NewReader(utf16LE("é a😀")), opts...)
+		if err == nil {
+			t.Fatal("an expected error didn't occur")
+		}
+	}
+}
+
+func TestLexer_Next_Streaming(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("white_space", `[\u{0009}\u{000A}\u{0020}]+`),
+			newLexEntryDefaultNOP("word", `[a-z]+`),
+		},
+	}
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewLexSpec(clspec)
+
+	// The source is much larger than the chunk size the lexer reads at once.
+	var b strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&b, "foo bar\n")
+	}
+	src := b.String()
+
+	l, err := NewLexer(s, iotest.OneByteReader(strings.NewReader(src)), MaxTokenLength(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for {
+		tok, err := l.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.EOF {
+			if tok.BytePos != len(src) || tok.Row != 10000 || tok.Col != 0 {
+				t.Fatalf("unexpected EOF position: %v, %v:%v", tok.BytePos, tok.Row, tok.Col)
+			}
+			break
+		}
+		if tok.Invalid {
+			t.Fatalf("unexpected invalid token: %+v", tok)
+		}
+		wantPos := (count / 4) * 8
+		switch count % 4 {
+		case 1:
+			wantPos += 3
+		case 2:
+			wantPos += 4
+		case 3:
+			wantPos += 7
+		}
+		if tok.BytePos != wantPos {
+			t.Fatalf("unexpected position of token #%v: want: %v, got: %v", count, wantPos, tok.BytePos)
+		}
+		count++
+	}
+	if count != 40000 {
+		t.Fatalf("unexpected token count: want: %v, got: %v", 40000, count)
+	}
+	if cap(l.buf) > 4*readChunkSize {
+		t.Fatalf("the buffer grew too much: %v bytes", cap(l.buf))
+	}
+
+	l, err = NewLexer(s, strings.NewReader("foo barbazqux"), MaxTokenLength(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := l.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = l.Next()
+	if err == nil {
+		t.Fatal("an expected error didn't occur")
+	}
+
+	_, err = NewLexer(s, strings.NewReader(src), MaxTokenLength(0))
+	if err == nil {
+		t.Fatal("an expected error didn't occur")
+	}
+}
+
+func TestLexer_Next_ReadError(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("word", `[a-z]+`),
+		},
+	}
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	l, err := NewLexer(NewLexSpec(clspec), iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader("foo"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = l.Next()
+	if err != iotest.ErrTimeout {
+		t.Fatalf("unexpected error: want: %v, got: %v", iotest.ErrTimeout, err)
+	}
+}
+
+func TestLexer_NextInto(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("white_space", `[\u{0009}\u{000A}\u{0020}]+`),
+			newLexEntryDefaultNOP("word", `[a-z]+`),
+			newLexEntry([]string{"default"}, "string_open", `"`, "string", false),
+			newLexEntry([]string{"string"}, "char_seq", `[^"]+`, "", false),
+			newLexEntry([]string{"string"}, "string_close", `"`, "", true),
+		},
+	}
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewLexSpec(clspec)
+
+	t.Run("NextInto reads the same tokens as Next", func(t *testing.T) {
+		// The source contains invalid tokens that the lexer merges and tokens switching lex modes.
+		src := `foo "bar baz" 123!! qux "" 4`
+		l1, err := NewLexer(s, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		l2, err := NewLexer(s, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tok Token
+		for {
+			expected, err := l1.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = l2.NextInto(&tok)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testToken(t, expected, &tok)
+			if tok.EndRow != expected.EndRow || tok.EndCol != expected.EndCol {
+				t.Fatalf("unexpected end position; want: %v:%v, got: %v:%v", expected.EndRow, expected.EndCol, tok.EndRow, tok.EndCol)
+			}
+			if tok.EOF {
+				break
+			}
+		}
+	})
+
+	t.Run("NextInto doesn't allocate memory when it reuses a token", func(t *testing.T) {
+		var b strings.Builder
+		for i := 0; i < 10000; i++ {
+			fmt.Fprintf(&b, "foo \"bar baz\"\n")
+		}
+		l, err := NewLexer(s, strings.NewReader(b.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tok Token
+		allocs := testing.AllocsPerRun(1000, func() {
+			err := l.NextInto(&tok)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs != 0 {
+			t.Fatalf("NextInto allocated memory: %v allocations per call", allocs)
+		}
+	})
+}
+
+func TestLexer_Next_Transformations(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("white_space", `[\u{0009}\u{000A}\u{0020}]+`),
+			{
+				Kind:    spec.LexKindName("string"),
+				Pattern: `"([^"\\]|\\.)*"`,
+				Modes: []spec.LexModeName{
+					spec.LexModeNameDefault,
+				},
+				Transformations: []*spec.LexTransformation{
+					{
+						Name:      "unescape",
+						Delimiter: `"`,
+					},
+				},
+			},
+			{
+				Kind:    spec.LexKindName("comment"),
+				Pattern: `#[^\u{000A}]*`,
+				Modes: []spec.LexModeName{
+					spec.LexModeNameDefault,
+				},
+				Transformations: []*spec.LexTransformation{
+					{
+						Name: "trim",
+					},
+				},
+			},
+			newLexEntryDefaultNOP("word", `[a-z]+`),
+		},
+	}
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewLexSpec(clspec)
+
+	src := `"a\tb\"c\u{3042}" # x  ` + "\n" + `word`
+	expected := []struct {
+		lexeme string
+		value  string
+	}{
+		{lexeme: `"a\tb\"c\u{3042}"`, value: "a\tb\"cあ"},
+		{lexeme: " ", value: " "},
+		{lexeme: "# x  ", value: "# x"},
+		{lexeme: "\n", value: "\n"},
+		{lexeme: "word", value: "word"},
+	}
+	l, err := NewLexer(s, strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range expected {
+		tok, err := l.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(tok.Lexeme) != e.lexeme || string(tok.Value) != e.value {
+			t.Fatalf("unexpected token; want: %q (%q), got: %q (%q)", e.lexeme, e.value, tok.Lexeme, tok.Value)
+		}
+	}
+}
+
+// testNormalizer composes `e` and U+0301 COMBINING ACUTE ACCENT into U+00E9 LATIN SMALL LETTER E WITH ACUTE in NFC
+// and NFKC, which is enough for the tests. The normalizer package provides the normalizer covering all characters.
+type testNormalizer struct{}
+
+func (testNormalizer) Normalize(form string, v []byte) ([]byte, error) {
+	switch form {
+	case "nfc", "nfkc":
+		return bytes.ReplaceAll(v, []byte("e\u0301"), []byte("\u00e9")), nil
+	case "nfd", "nfkd":
+		return bytes.ReplaceAll(v, []byte("\u00e9"), []byte("e\u0301")), nil
+	}
+	return nil, fmt.Errorf("unknown normalization form: %v", form)
+}
+
+func TestLexer_Next_Normalize(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			{
+				Kind:    spec.LexKindName("id"),
+				Pattern: `[^\u{0020}]+`,
+				Modes: []spec.LexModeName{
+					spec.LexModeNameDefault,
+				},
+				Transformations: []*spec.LexTransformation{
+					{
+						Name: "normalize",
+						Form: "nfc",
+					},
+				},
+			},
+		},
+	}
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewLexSpec(clspec)
+
+	src := "cafe\u0301"
+	tests := []struct {
+		caption string
+		opts    []LexerOption
+		value   string
+		fails   bool
+	}{
+		{
+			caption: "a lexer without a normalizer fails on a token needing normalization",
+			fails:   true,
+		},
+		{
+			caption: "NormalizeWith option supplies a normalizer",
+			opts: []LexerOption{
+				NormalizeWith(testNormalizer{}),
+			},
+			value: "caf\u00e9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			l, err := NewLexer(s, strings.NewReader(src), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tok, err := l.Next()
+			if tt.fails {
+				if err == nil {
+					t.Fatal("an expected error didn't occur")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(tok.Lexeme) != src || string(tok.Value) != tt.value {
+				t.Fatalf("unexpected token; want: %q (%q), got: %q (%q)", src, tt.value, tok.Lexeme, tok.Value)
+			}
+		})
+	}
+}
+
+func TestLexer_Next_Captures(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("white_space", `[\u{0009}\u{000A}\u{0020}]+`),
+			newLexEntryDefaultNOP("int", `(?<int>[0-9]+)(?<suffix>[uU]?)`),
+			newLexEntryDefaultNOP("float", `(?<int>[0-9]+)\.(?<frac>[0-9]+)(?<exp>[eE](?<sign>[+\-]?)[0-9]+)?`),
+			newLexEntryDefaultNOP("digits", `d(?<digit>[0-9])+`),
+			{
+				Kind:    spec.LexKindName("word"),
+				Pattern: `(?<head>[a-z]*)(?<tail>[a-z]*)x`,
+				Modes: []spec.LexModeName{
+					spec.LexModeNameDefault,
+				},
+				CaseConfigurable: true,
+			},
+			newLexEntryDefaultNOP("other", `[A-Z]+`),
+		},
+	}
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewLexSpec(clspec)
+
+	type capture struct {
+		name string
+		text string
+		pos  int
+	}
+	tests := []struct {
+		caption string
+		src     string
+		opts    []LexerOption
+		caps    [][]capture
+	}{
+		{
+			caption: "capture groups matching some bytes or no bytes",
+			src:     "12u 12",
+			caps: [][]capture{
+				{{"int", "12", 0}, {"suffix", "u", 2}},
+				nil,
+				{{"int", "12", 0}, {"suffix", "", -1}},
+			},
+		},
+		{
+			caption: "nested capture groups and an optional capture group",
+			src:     "3.14 1.5e-10",
+			caps: [][]capture{
+				{{"int", "3", 0}, {"frac", "14", 2}, {"exp", "", -1}, {"sign", "", -1}},
+				nil,
+				{{"int", "1", 0}, {"frac", "5", 2}, {"exp", "e-10", 3}, {"sign", "-", 4}},
+			},
+		},
+		{
+			caption: "a capture group in a repetition spans from the first to the last repetition",
+			src:     "d123",
+			caps: [][]capture{
+				{{"digit", "123", 1}},
+			},
+		},
+		{
+			caption: "a capture group opening earlier takes precedence",
+			src:     "abcx",
+			caps: [][]capture{
+				{{"head", "abc", 0}, {"tail", "", -1}},
+			},
+		},
+		{
+			caption: "the lexer finds capture groups in a lexeme matched case-insensitively",
+			src:     "ABx",
+			opts: []LexerOption{
+				DisableCaseSensitivity(),
+			},
+			caps: [][]capture{
+				{{"head", "AB", 0}, {"tail", "", -1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			l, err := NewLexer(s, strings.NewReader(tt.src), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, caps := range tt.caps {
+				tok, err := l.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tok.Invalid || len(tok.Captures) != len(caps) {
+					t.Fatalf("unexpected token: %q: %+v", tok.Lexeme, tok.Captures)
+				}
+				for i, c := range caps {
+					text, ok := tok.Capture(c.name)
+					if !ok || tok.Captures[i].Name != c.name || tok.Captures[i].Pos != c.pos || string(text) != c.text {
+						t.Fatalf("unexpected capture; want: %v %q at %v, got: %+v %q", c.name, c.text, c.pos, tok.Captures[i], text)
+					}
+				}
+			}
+			tok, err := l.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tok.EOF {
+				t.Fatalf("unexpected token: %q", tok.Lexeme)
+			}
+		})
+	}
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		caption string
+		lexeme  string
+		ts      []Transformation
+		value   string
+	}{
+		{
+			caption: "no transformation returns the lexeme as it is",
+			lexeme:  " foo ",
+			value:   " foo ",
+		},
+		{
+			caption: "trim removes leading and trailing white spaces",
+			lexeme:  "\t foo bar \n",
+			ts:      []Transformation{{Name: "trim"}},
+			value:   "foo bar",
+		},
+		{
+			caption: "dedent removes the common indentation",
+			lexeme:  "    foo\n      bar\n  \n    baz",
+			ts:      []Transformation{{Name: "dedent"}},
+			value:   "foo\n  bar\n\nbaz",
+		},
+		{
+			caption: "dedent compares indentations character by character",
+			lexeme:  "\t foo\n\tbar",
+			ts:      []Transformation{{Name: "dedent"}},
+			value:   " foo\nbar",
+		},
+		{
+			caption: "unescape interprets escape sequences",
+			lexeme:  `a\nb\rc\td\\e\'f\"g\u{1F600}h`,
+			ts:      []Transformation{{Name: "unescape"}},
+			value:   "a\nb\rc\td\\e'f\"g\U0001F600h",
+		},
+		{
+			caption: "unescape leaves unknown escape sequences as they are",
+			lexeme:  `\x\u{}\u{D800}\u{110000}\`,
+			ts:      []Transformation{{Name: "unescape"}},
+			value:   `\x\u{}\u{D800}\u{110000}\`,
+		},
+		{
+			caption: "unescape removes a delimiter and interprets an escaped delimiter",
+			lexeme:  `|a\|b|`,
+			ts:      []Transformation{{Name: "unescape", Delimiter: "|"}},
+			value:   "a|b",
+		},
+		{
+			caption: "unescape doesn't remove a delimiter that appears only at one end",
+			lexeme:  `|a`,
+			ts:      []Transformation{{Name: "unescape", Delimiter: "|"}},
+			value:   "|a",
+		},
+		{
+			caption: "transformations are applied in order",
+			lexeme:  "\"\"\"\n    foo\n      bar\n    \"\"\"",
+			ts:      []Transformation{{Name: "unescape", Delimiter: `"""`}, {Name: "dedent"}, {Name: "trim"}},
+			value:   "foo\n  bar",
+		},
+		{
+			caption: "normalize composes a base character and a combining character in NFC",
+			lexeme:  "cafe\u0301",
+			ts:      []Transformation{{Name: "normalize", Form: "nfc"}},
+			value:   "caf\u00e9",
+		},
+		{
+			caption: "normalize decomposes a precomposed character in NFD",
+			lexeme:  "caf\u00e9",
+			ts:      []Transformation{{Name: "normalize", Form: "nfd"}},
+			value:   "cafe\u0301",
+		},
+		{
+			caption: "normalize can follow unescape",
+			lexeme:  `e\u{0301}`,
+			ts:      []Transformation{{Name: "unescape"}, {Name: "normalize", Form: "nfc"}},
+			value:   "\u00e9",
+		},
+	}
+	n := testNormalizer{}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			v, err := Transform([]byte(tt.lexeme), tt.ts, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(v) != tt.value {
+				t.Fatalf("unexpected value; want: %q, got: %q", tt.value, v)
+			}
+		})
+	}
+
+	_, err := Transform([]byte("e\u0301"), []Transformation{{Name: "normalize", Form: "nfc"}}, nil)
+	if err == nil {
+		t.Fatal("normalize without a normalizer must fail")
+	}
+	_, err = Transform([]byte("e\u0301"), []Transformation{{Name: "normalize", Form: "foo"}}, n)
+	if err == nil {
+		t.Fatal("an unknown normalization form must be an error")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			newLexEntryDefaultNOP("white_space", `[\u{0009}\u{0020}]+`),
+			{
+				Kind:    spec.LexKindName("id"),
+				Pattern: `[a-z_][0-9a-z_]*`,
+				Modes: []spec.LexModeName{
+					spec.LexModeNameDefault,
+				},
+				Keywords: []spec.LexKindName{
+					"kw_if",
+				},
+			},
+			{
+				Kind:    spec.LexKindName("kw_if"),
+				Pattern: `if`,
+				Modes: []spec.LexModeName{
+					spec.LexModeNameDefault,
+				},
+				Keyword: true,
+			},
+			newLexEntryDefaultNOP("int", `0|[1-9][0-9]*`),
+		},
+	}
+
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewLexSpec(clspec)
+
+	tests := []struct {
+		lexeme string
+		kind   spec.LexKindName
+		ok     bool
+	}{
+		{lexeme: "foo_1", kind: "id", ok: true},
+		{lexeme: "if", kind: "kw_if", ok: true},
+		{lexeme: "iff", kind: "id", ok: true},
+		{lexeme: "10", kind: "int", ok: true},
+		// A lexeme must match a pattern as a whole.
+		{lexeme: "01", ok: false},
+		{lexeme: "foo bar", ok: false},
+		{lexeme: "1a", ok: false},
+		{lexeme: "", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lexeme, func(t *testing.T) {
+			kind, ok := s.Classify([]byte(tt.lexeme))
+			if ok != tt.ok || kind != tt.kind {
+				t.Fatalf("unexpected classification; want: %v, %v, got: %v, %v", tt.kind, tt.ok, kind, ok)
+			}
+		})
+	}
+}
@@ -1,15 +1,118 @@
 package lexer
 
-import spec "github.com/nihei9/vartan/spec/grammar"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
 
 type lexSpec struct {
 	spec *spec.LexicalSpec
+
+	// lazyDFAs holds the DFAs of the modes having NFAs instead of DFAs.
+	lazyDFAs []*lazyDFA
+
+	// transformations holds the transformations of kinds indexed by kind IDs.
+	transformations [][]Transformation
+
+	// captureGroups holds the capture groups of kinds indexed by kind IDs.
+	captureGroups []*CaptureGroups
+
+	// dfas holds the tables of the DFAs compressed by the row displacement, indexed by mode IDs. The element of a mode
+	// is nil when the mode has an NFA or a table compressed at another level.
+	dfas []*dfaTables
+}
+
+// dfaTables holds the initial state and the tables that the lexer reads for each byte, so that it doesn't follow the
+// pointers of the specification every time.
+type dfaTables struct {
+	initial         StateID
+	rowNums         []int
+	rowDisplacement []int
+	bounds          []int
+	entries         []spec.StateID
+	accepting       []spec.LexModeKindID
 }
 
+// NewLexSpec returns a LexSpec backed by a lexical specification. The LexSpec only reads the lexical specification, so
+// multiple lexers running concurrently can share both of them. When the specification has NFAs instead of DFAs,
+// the LexSpec builds DFA states the first time a lexer reaches them and caches them safely for concurrent use.
 func NewLexSpec(spec *spec.LexicalSpec) *lexSpec {
-	return &lexSpec{
+	s := &lexSpec{
 		spec: spec,
 	}
+	for i, modeSpec := range spec.Specs {
+		if modeSpec == nil {
+			continue
+		}
+		if modeSpec.NFA == nil {
+			if spec.CompressionLevel == 2 {
+				if s.dfas == nil {
+					s.dfas = make([]*dfaTables, len(spec.Specs))
+				}
+				tran := modeSpec.DFA.Transition
+				s.dfas[i] = &dfaTables{
+					initial:         StateID(modeSpec.DFA.InitialStateID.Int()),
+					rowNums:         tran.RowNums,
+					rowDisplacement: tran.UniqueEntries.RowDisplacement,
+					bounds:          tran.UniqueEntries.Bounds,
+					entries:         tran.UniqueEntries.Entries,
+					accepting:       modeSpec.DFA.AcceptingStates,
+				}
+			}
+			continue
+		}
+		if s.lazyDFAs == nil {
+			s.lazyDFAs = make([]*lazyDFA, len(spec.Specs))
+		}
+		s.lazyDFAs[i] = newLazyDFA(modeSpec.NFA)
+	}
+	if spec.KindTransformations != nil {
+		s.transformations = make([][]Transformation, len(spec.KindTransformations))
+		for kind, ts := range spec.KindTransformations {
+			for _, t := range ts {
+				s.transformations[kind] = append(s.transformations[kind], Transformation{
+					Name:      t.Name,
+					Delimiter: t.Delimiter,
+					Form:      t.Form,
+				})
+			}
+		}
+	}
+	if spec.KindCaptures != nil {
+		s.captureGroups = make([]*CaptureGroups, len(spec.KindCaptures))
+		for kind, caps := range spec.KindCaptures {
+			if caps == nil {
+				continue
+			}
+			s.captureGroups[kind] = newCaptureGroups(caps)
+		}
+	}
+	return s
+}
+
+func newCaptureGroups(caps *spec.LexCaptures) *CaptureGroups {
+	nfa := caps.NFA
+	g := &CaptureGroups{
+		Names:   caps.Names,
+		Initial: nfa.InitialPositions,
+		From:    nfa.From,
+		To:      nfa.To,
+		Follow:  nfa.Follow,
+		Tags:    nfa.Tags,
+		Accept:  make([]bool, len(nfa.Accept)),
+		Folded:  make([]bool, len(nfa.Accept)),
+	}
+	for p, acc := range nfa.Accept {
+		g.Accept[p] = acc != spec.LexModeKindIDNil
+	}
+	for _, p := range nfa.Folded {
+		g.Folded[p] = true
+	}
+	return g
 }
 
 func (s *lexSpec) InitialMode() ModeID {
@@ -30,11 +133,43 @@ func (s *lexSpec) ModeName(mode ModeID) string {
 }
 
 func (s *lexSpec) InitialState(mode ModeID) StateID {
+	if s.dfas != nil {
+		if t := s.dfas[mode]; t != nil {
+			return t.initial
+		}
+	}
+	if d := s.lazyDFA(mode); d != nil {
+		return lazyDFAInitialState
+	}
 	return StateID(s.spec.Specs[mode].DFA.InitialStateID.Int())
 }
 
 func (s *lexSpec) NextState(mode ModeID, state StateID, v int) (StateID, bool) {
+	if s.dfas != nil {
+		if t := s.dfas[mode]; t != nil {
+			rowNum := t.rowNums[state]
+			i := t.rowDisplacement[rowNum] + v
+			if t.bounds[i] != rowNum {
+				return StateID(spec.StateIDNil.Int()), false
+			}
+			return StateID(t.entries[i].Int()), true
+		}
+	}
+	if d := s.lazyDFA(mode); d != nil {
+		return d.next(state, v)
+	}
+
 	switch s.spec.CompressionLevel {
+	case 3:
+		tran := s.spec.Specs[mode].DFA.BaseCheckTransition
+		for row := state.Int(); row != -1; row = tran.Default[row] {
+			i := tran.Base[row] + v
+			if tran.Check[i] == row {
+				next := tran.Next[i]
+				return StateID(next.Int()), next != spec.StateIDNil
+			}
+		}
+		return StateID(spec.StateIDNil.Int()), false
 	case 2:
 		tran := s.spec.Specs[mode].DFA.Transition
 		rowNum := tran.RowNums[state]
@@ -61,11 +196,220 @@ func (s *lexSpec) NextState(mode ModeID, state StateID, v int) (StateID, bool) {
 }
 
 func (s *lexSpec) Accept(mode ModeID, state StateID) (ModeKindID, bool) {
+	if s.dfas != nil {
+		if t := s.dfas[mode]; t != nil {
+			modeKindID := t.accepting[state]
+			return ModeKindID(modeKindID.Int()), modeKindID != spec.LexModeKindIDNil
+		}
+	}
+	if d := s.lazyDFA(mode); d != nil {
+		return d.accept(state, false)
+	}
 	modeKindID := s.spec.Specs[mode].DFA.AcceptingStates[state]
 	return ModeKindID(modeKindID.Int()), modeKindID != spec.LexModeKindIDNil
 }
 
+func (s *lexSpec) CaseInsensitiveAccept(mode ModeID, state StateID) (ModeKindID, bool) {
+	if d := s.lazyDFA(mode); d != nil {
+		return d.accept(state, true)
+	}
+	acc := s.spec.Specs[mode].DFA.CaseInsensitiveAcceptingStates
+	if len(acc) == 0 {
+		return s.Accept(mode, state)
+	}
+	modeKindID := acc[state]
+	return ModeKindID(modeKindID.Int()), modeKindID != spec.LexModeKindIDNil
+}
+
+func (s *lexSpec) Keyword(mode ModeID, modeKind ModeKindID, lexeme []byte) (ModeKindID, bool) {
+	kws := s.spec.Specs[mode].Keywords
+	if len(kws) == 0 || kws[modeKind] == nil {
+		return ModeKindID(spec.LexModeKindIDNil.Int()), false
+	}
+	kw, ok := kws[modeKind][string(lexeme)]
+	return ModeKindID(kw.Int()), ok
+}
+
+func (s *lexSpec) CaseInsensitiveKeyword(mode ModeID, modeKind ModeKindID, lexeme []byte) (ModeKindID, bool) {
+	if kw, ok := s.Keyword(mode, modeKind, lexeme); ok {
+		return kw, true
+	}
+	kws := s.spec.Specs[mode].CaseInsensitiveKeywords
+	if len(kws) == 0 || kws[modeKind] == nil {
+		return ModeKindID(spec.LexModeKindIDNil.Int()), false
+	}
+	kw, ok := kws[modeKind][spec.FoldKeyword(string(lexeme))]
+	return ModeKindID(kw.Int()), ok
+}
+
+// Classify returns a name of a kind of a lexeme `lexeme` when the whole lexeme matches a pattern of the kind in
+// the initial mode. Use the Classify function to classify a lexeme in other modes.
+func (s *lexSpec) Classify(lexeme []byte) (spec.LexKindName, bool) {
+	_, kindName, ok := Classify(s, s.InitialMode(), lexeme)
+	return spec.LexKindName(kindName), ok
+}
+
 func (s *lexSpec) KindIDAndName(mode ModeID, modeKind ModeKindID) (KindID, string) {
 	kindID := s.spec.KindIDs[mode][modeKind]
 	return KindID(kindID.Int()), s.spec.KindNames[kindID].String()
 }
+
+func (s *lexSpec) Transformations(kind KindID) []Transformation {
+	if kind.Int() >= len(s.transformations) {
+		return nil
+	}
+	return s.transformations[kind]
+}
+
+func (s *lexSpec) CaptureGroups(kind KindID) *CaptureGroups {
+	if kind.Int() >= len(s.captureGroups) {
+		return nil
+	}
+	return s.captureGroups[kind]
+}
+
+// KindClass returns the class that a `#class` directive assigns to a kind for syntax highlighting, such as `keyword`.
+// When the kind has no class, this method returns an empty string.
+func (s *lexSpec) KindClass(kind KindID) string {
+	if kind.Int() >= len(s.spec.KindClasses) {
+		return ""
+	}
+	return s.spec.KindClasses[kind]
+}
+
+func (s *lexSpec) lazyDFA(mode ModeID) *lazyDFA {
+	if s.lazyDFAs == nil {
+		return nil
+	}
+	return s.lazyDFAs[mode]
+}
+
+const lazyDFAInitialState = StateID(1)
+
+// lazyDFAState is a state of a lazyDFA. It is a set of positions of an NFA.
+type lazyDFAState struct {
+	poss []int
+
+	// next holds the transitions built so far. 0 means the transition isn't built yet, and -1 means no transition.
+	next [256]StateID
+
+	acc   ModeKindID
+	ciAcc ModeKindID
+}
+
+// lazyDFA builds DFA states from an NFA by subset construction when a lexer reaches them for the first time.
+type lazyDFA struct {
+	nfa    *spec.NFA
+	folded map[int]struct{}
+
+	mu sync.RWMutex
+
+	// states is indexed by state IDs. states[0] is nil because 0 is the nil state ID.
+	states []*lazyDFAState
+	ids    map[string]StateID
+}
+
+func newLazyDFA(nfa *spec.NFA) *lazyDFA {
+	d := &lazyDFA{
+		nfa:    nfa,
+		folded: map[int]struct{}{},
+		states: []*lazyDFAState{nil},
+		ids:    map[string]StateID{},
+	}
+	for _, p := range nfa.Folded {
+		d.folded[p] = struct{}{}
+	}
+	d.addState(nfa.InitialPositions)
+	return d
+}
+
+func (d *lazyDFA) next(state StateID, v int) (StateID, bool) {
+	d.mu.RLock()
+	next := d.states[state].next[v]
+	d.mu.RUnlock()
+	if next == 0 {
+		d.mu.Lock()
+		next = d.build(state, v)
+		d.mu.Unlock()
+	}
+	if next < 0 {
+		return StateID(spec.StateIDNil.Int()), false
+	}
+	return next, true
+}
+
+func (d *lazyDFA) accept(state StateID, caseInsensitive bool) (ModeKindID, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	acc := d.states[state].acc
+	if caseInsensitive {
+		acc = d.states[state].ciAcc
+	}
+	return acc, acc != ModeKindID(spec.LexModeKindIDNil.Int())
+}
+
+// build builds a transition of a state on a byte `v`. The caller must hold the write lock.
+func (d *lazyDFA) build(state StateID, v int) StateID {
+	s := d.states[state]
+	if s.next[v] != 0 {
+		return s.next[v]
+	}
+	var poss []int
+	for _, p := range s.poss {
+		if v >= d.nfa.From[p] && v <= d.nfa.To[p] {
+			poss = append(poss, d.nfa.Follow[p]...)
+		}
+	}
+	if len(poss) == 0 {
+		s.next[v] = -1
+		return -1
+	}
+	s.next[v] = d.addState(poss)
+	return s.next[v]
+}
+
+// addState returns the ID of a state consisting of positions `poss`. When the state doesn't exist, this method adds it.
+func (d *lazyDFA) addState(poss []int) StateID {
+	sort.Ints(poss)
+	var b strings.Builder
+	n := 0
+	for i, p := range poss {
+		if i > 0 && p == poss[i-1] {
+			continue
+		}
+		poss[n] = p
+		n++
+		fmt.Fprintf(&b, "%v,", p)
+	}
+	poss = poss[:n]
+	key := b.String()
+	if id, ok := d.ids[key]; ok {
+		return id
+	}
+
+	s := &lazyDFAState{
+		poss:  poss,
+		acc:   ModeKindID(spec.LexModeKindIDNil.Int()),
+		ciAcc: ModeKindID(spec.LexModeKindIDNil.Int()),
+	}
+	// When a state contains multiple end markers, the kind having the smallest ID takes precedence.
+	for _, p := range poss {
+		acc := ModeKindID(d.nfa.Accept[p].Int())
+		if acc == ModeKindID(spec.LexModeKindIDNil.Int()) {
+			continue
+		}
+		if s.ciAcc == ModeKindID(spec.LexModeKindIDNil.Int()) || acc < s.ciAcc {
+			s.ciAcc = acc
+		}
+		if _, ok := d.folded[p]; ok {
+			continue
+		}
+		if s.acc == ModeKindID(spec.LexModeKindIDNil.Int()) || acc < s.acc {
+			s.acc = acc
+		}
+	}
+	id := StateID(len(d.states))
+	d.states = append(d.states, s)
+	d.ids[key] = id
+	return id
+}
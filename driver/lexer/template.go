@@ -8,6 +8,8 @@ import (
 	"go/format"
 	"go/parser"
 	"go/token"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -18,7 +20,16 @@ import (
 //go:embed lexer.go
 var lexerCoreSrc string
 
+//go:embed grapheme.go
+var graphemeTableSrc string
+
 func GenLexer(lexSpec *spec.LexicalSpec, pkgName string) ([]byte, error) {
+	for _, s := range lexSpec.Specs {
+		if s != nil && s.NFA != nil {
+			return nil, fmt.Errorf("a lexical specification building DFAs at run time cannot generate a lexer; compile the grammar without lazy DFA construction")
+		}
+	}
+
 	var lexerSrc string
 	{
 		fset := token.NewFileSet()
@@ -36,6 +47,18 @@ func GenLexer(lexSpec *spec.LexicalSpec, pkgName string) ([]byte, error) {
 		lexerSrc = b.String()
 	}
 
+	var graphemeSrc string
+	{
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "grapheme.go", graphemeTableSrc, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		// The table follows the lexer core in the same file, so this drops the package clause.
+		graphemeSrc = graphemeTableSrc[fset.Position(f.Name.End()).Offset:]
+	}
+
 	var modeIDsSrc string
 	{
 		var b strings.Builder
@@ -180,6 +203,8 @@ func KindIDToName(id KindID) string {
 		tmpl := `// Code generated by vartan-go. DO NOT EDIT.
 {{ .lexerSrc }}
 
+{{ .graphemeSrc }}
+
 {{ .modeIDsSrc }}
 
 {{ .modeNamesSrc }}
@@ -203,6 +228,7 @@ func KindIDToName(id KindID) string {
 		var b strings.Builder
 		err = t.Execute(&b, map[string]string{
 			"lexerSrc":        lexerSrc,
+			"graphemeSrc":     graphemeSrc,
 			"modeIDsSrc":      modeIDsSrc,
 			"modeNamesSrc":    modeNamesSrc,
 			"modeIDToNameSrc": modeIDToNameSrc,
@@ -242,8 +268,13 @@ type lexSpec struct {
 	modeNames     []string
 	initialStates []StateID
 	acceptances   [][]ModeKindID
+	ciAcceptances [][]ModeKindID
+	keywords      [][]map[string]ModeKindID
+	ciKeywords    [][]map[string]ModeKindID
 	kindIDs       [][]KindID
 	kindNames     []string
+	transforms    [][]Transformation
+	captures      []*CaptureGroups
 	initialModeID ModeID
 	modeIDNil     ModeID
 	modeKindIDNil ModeKindID
@@ -254,6 +285,7 @@ type lexSpec struct {
 	bounds            [][]int
 	entries           [][]StateID
 	originalColCounts []int
+	defaults          [][]int
 }
 
 func NewLexSpec() *lexSpec {
@@ -263,8 +295,13 @@ func NewLexSpec() *lexSpec {
 		modeNames: {{ genModeNameTable }},
 		initialStates: {{ genInitialStateTable }},
 		acceptances: {{ genAcceptTable }},
+		ciAcceptances: {{ genCaseInsensitiveAcceptTable }},
+		keywords: {{ genKeywordTable }},
+		ciKeywords: {{ genCaseInsensitiveKeywordTable }},
 		kindIDs: {{ genKindIDTable }},
 		kindNames: {{ genKindNameTable }},
+		transforms: {{ genTransformationTable }},
+		captures: {{ genCaptureGroupTable }},
 		initialModeID: {{ .initialModeID }},
 		modeIDNil: {{ .modeIDNil }},
 		modeKindIDNil: {{ .modeKindIDNil }},
@@ -275,6 +312,7 @@ func NewLexSpec() *lexSpec {
 		bounds: {{ genBounds }},
 		entries: {{ genEntries }},
 		originalColCounts: {{ genOriginalColCounts }},
+		defaults: {{ genDefaults }},
 	}
 }
 
@@ -300,7 +338,16 @@ func (s *lexSpec) InitialState(mode ModeID) StateID {
 }
 
 func (s *lexSpec) NextState(mode ModeID, state StateID, v int) (StateID, bool) {
-{{ if eq .compressionLevel 2 -}}
+{{ if eq .compressionLevel 3 -}}
+	for row := int(state); row != -1; row = s.defaults[mode][row] {
+		i := s.rowDisplacements[mode][row] + v
+		if s.bounds[mode][i] == row {
+			next := s.entries[mode][i]
+			return next, next != s.stateIDNil
+		}
+	}
+	return s.stateIDNil, false
+{{ else if eq .compressionLevel 2 -}}
 	rowNum := s.rowNums[mode][state]
 	d := s.rowDisplacements[mode][rowNum]
 	if s.bounds[mode][d+v] != rowNum {
@@ -330,10 +377,58 @@ func (s *lexSpec) Accept(mode ModeID, state StateID) (ModeKindID, bool) {
 	return id, id != s.modeKindIDNil
 }
 
+func (s *lexSpec) CaseInsensitiveAccept(mode ModeID, state StateID) (ModeKindID, bool) {
+	if s.ciAcceptances[mode] == nil {
+		return s.Accept(mode, state)
+	}
+	id := s.ciAcceptances[mode][state]
+	return id, id != s.modeKindIDNil
+}
+
+func (s *lexSpec) Keyword(mode ModeID, modeKind ModeKindID, lexeme []byte) (ModeKindID, bool) {
+	if s.keywords[mode] == nil || s.keywords[mode][modeKind] == nil {
+		return s.modeKindIDNil, false
+	}
+	id, ok := s.keywords[mode][modeKind][string(lexeme)]
+	return id, ok
+}
+
+func (s *lexSpec) CaseInsensitiveKeyword(mode ModeID, modeKind ModeKindID, lexeme []byte) (ModeKindID, bool) {
+	if id, ok := s.Keyword(mode, modeKind, lexeme); ok {
+		return id, true
+	}
+	if s.ciKeywords[mode] == nil || s.ciKeywords[mode][modeKind] == nil {
+		return s.modeKindIDNil, false
+	}
+	folded := make([]byte, len(lexeme))
+	for i, c := range lexeme {
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		folded[i] = c
+	}
+	id, ok := s.ciKeywords[mode][modeKind][string(folded)]
+	return id, ok
+}
+
 func (s *lexSpec) KindIDAndName(mode ModeID, modeKind ModeKindID) (KindID, string) {
 	id := s.kindIDs[mode][modeKind]
 	return id, s.kindNames[id]
 }
+
+func (s *lexSpec) Transformations(kind KindID) []Transformation {
+	if int(kind) >= len(s.transforms) {
+		return nil
+	}
+	return s.transforms[kind]
+}
+
+func (s *lexSpec) CaptureGroups(kind KindID) *CaptureGroups {
+	if int(kind) >= len(s.captures) {
+		return nil
+	}
+	return s.captures[kind]
+}
 `
 
 func genTemplateFuncs(lexSpec *spec.LexicalSpec) template.FuncMap {
@@ -452,6 +547,111 @@ func genTemplateFuncs(lexSpec *spec.LexicalSpec) template.FuncMap {
 			fmt.Fprintf(&b, "}")
 			return b.String()
 		},
+		"genCaseInsensitiveAcceptTable": func() string {
+			var b strings.Builder
+			fmt.Fprintf(&b, "[][]ModeKindID{\n")
+			for i, s := range lexSpec.Specs {
+				if i == spec.LexModeIDNil.Int() || len(s.DFA.CaseInsensitiveAcceptingStates) == 0 {
+					fmt.Fprintf(&b, "nil,\n")
+					continue
+				}
+
+				c := 1
+				fmt.Fprintf(&b, "{\n")
+				for _, v := range s.DFA.CaseInsensitiveAcceptingStates {
+					fmt.Fprintf(&b, "%v,", v)
+
+					if c == 20 {
+						fmt.Fprintf(&b, "\n")
+						c = 1
+					} else {
+						c++
+					}
+				}
+				if c > 1 {
+					fmt.Fprintf(&b, "\n")
+				}
+				fmt.Fprintf(&b, "},\n")
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		},
+		"genKeywordTable": func() string {
+			return genKeywordTable(lexSpec, func(s *spec.CompiledLexModeSpec) []map[string]spec.LexModeKindID {
+				return s.Keywords
+			})
+		},
+		"genCaseInsensitiveKeywordTable": func() string {
+			return genKeywordTable(lexSpec, func(s *spec.CompiledLexModeSpec) []map[string]spec.LexModeKindID {
+				return s.CaseInsensitiveKeywords
+			})
+		},
+		"genTransformationTable": func() string {
+			if lexSpec.KindTransformations == nil {
+				return "nil"
+			}
+			var b strings.Builder
+			fmt.Fprintf(&b, "[][]Transformation{\n")
+			for _, ts := range lexSpec.KindTransformations {
+				if len(ts) == 0 {
+					fmt.Fprintf(&b, "nil,\n")
+					continue
+				}
+				fmt.Fprintf(&b, "{\n")
+				for _, t := range ts {
+					fmt.Fprintf(&b, "{Name: %v, Delimiter: %v, Form: %v},\n", strconv.Quote(t.Name), strconv.Quote(t.Delimiter), strconv.Quote(t.Form))
+				}
+				fmt.Fprintf(&b, "},\n")
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		},
+		"genCaptureGroupTable": func() string {
+			if lexSpec.KindCaptures == nil {
+				return "nil"
+			}
+			ints := func(vs []int) string {
+				var b strings.Builder
+				fmt.Fprintf(&b, "[]int{")
+				for i, v := range vs {
+					if i > 0 {
+						fmt.Fprintf(&b, ", ")
+					}
+					fmt.Fprintf(&b, "%v", v)
+				}
+				fmt.Fprintf(&b, "}")
+				return b.String()
+			}
+			var b strings.Builder
+			fmt.Fprintf(&b, "[]*CaptureGroups{\n")
+			for _, caps := range lexSpec.KindCaptures {
+				if caps == nil {
+					fmt.Fprintf(&b, "nil,\n")
+					continue
+				}
+				g := newCaptureGroups(caps)
+				fmt.Fprintf(&b, "{\n")
+				fmt.Fprintf(&b, "Names: %#v,\n", g.Names)
+				fmt.Fprintf(&b, "Initial: %v,\n", ints(g.Initial))
+				fmt.Fprintf(&b, "From: %v,\n", ints(g.From))
+				fmt.Fprintf(&b, "To: %v,\n", ints(g.To))
+				fmt.Fprintf(&b, "Follow: [][]int{\n")
+				for _, f := range g.Follow {
+					fmt.Fprintf(&b, "%v,\n", ints(f))
+				}
+				fmt.Fprintf(&b, "},\n")
+				fmt.Fprintf(&b, "Tags: [][]int{\n")
+				for _, t := range g.Tags {
+					fmt.Fprintf(&b, "%v,\n", ints(t))
+				}
+				fmt.Fprintf(&b, "},\n")
+				fmt.Fprintf(&b, "Accept: %#v,\n", g.Accept)
+				fmt.Fprintf(&b, "Folded: %#v,\n", g.Folded)
+				fmt.Fprintf(&b, "},\n")
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		},
 		"genKindIDTable": func() string {
 			var b strings.Builder
 			fmt.Fprintf(&b, "[][]KindID{\n")
@@ -489,7 +689,79 @@ func genTemplateFuncs(lexSpec *spec.LexicalSpec) template.FuncMap {
 		},
 	}
 
+	fns["genDefaults"] = func() string {
+		return "nil"
+	}
+
 	switch lexSpec.CompressionLevel {
+	case 3:
+		// The base, check, and next arrays of the two-level scheme are stored in rowDisplacements, bounds, and entries
+		// respectively because they play the same roles as the arrays of the row displacement.
+		genInts := func(typ string, ints func(tab *spec.BaseCheckTable) []int) string {
+			var b strings.Builder
+			fmt.Fprintf(&b, "[][]%v{\n", typ)
+			for i, s := range lexSpec.Specs {
+				if i == spec.LexModeIDNil.Int() {
+					fmt.Fprintf(&b, "nil,\n")
+					continue
+				}
+
+				c := 1
+				fmt.Fprintf(&b, "{\n")
+				for _, v := range ints(s.DFA.BaseCheckTransition) {
+					fmt.Fprintf(&b, "%v,", v)
+
+					if c == 20 {
+						fmt.Fprintf(&b, "\n")
+						c = 1
+					} else {
+						c++
+					}
+				}
+				if c > 1 {
+					fmt.Fprintf(&b, "\n")
+				}
+				fmt.Fprintf(&b, "},\n")
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		}
+
+		fns["genRowNums"] = func() string {
+			return "nil"
+		}
+
+		fns["genRowDisplacements"] = func() string {
+			return genInts("int", func(tab *spec.BaseCheckTable) []int {
+				return tab.Base
+			})
+		}
+
+		fns["genBounds"] = func() string {
+			return genInts("int", func(tab *spec.BaseCheckTable) []int {
+				return tab.Check
+			})
+		}
+
+		fns["genEntries"] = func() string {
+			return genInts("StateID", func(tab *spec.BaseCheckTable) []int {
+				next := make([]int, len(tab.Next))
+				for i, v := range tab.Next {
+					next[i] = v.Int()
+				}
+				return next
+			})
+		}
+
+		fns["genDefaults"] = func() string {
+			return genInts("int", func(tab *spec.BaseCheckTable) []int {
+				return tab.Default
+			})
+		}
+
+		fns["genOriginalColCounts"] = func() string {
+			return "nil"
+		}
 	case 2:
 		fns["genRowNums"] = func() string {
 			var b strings.Builder
@@ -758,3 +1030,38 @@ func genTemplateFuncs(lexSpec *spec.LexicalSpec) template.FuncMap {
 
 	return fns
 }
+
+// genKeywordTable generates keyword tables that `tables` returns from each lex mode.
+func genKeywordTable(lexSpec *spec.LexicalSpec, tables func(s *spec.CompiledLexModeSpec) []map[string]spec.LexModeKindID) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[][]map[string]ModeKindID{\n")
+	for i, s := range lexSpec.Specs {
+		if i == spec.LexModeIDNil.Int() || len(tables(s)) == 0 {
+			fmt.Fprintf(&b, "nil,\n")
+			continue
+		}
+
+		fmt.Fprintf(&b, "{\n")
+		for _, tab := range tables(s) {
+			if tab == nil {
+				fmt.Fprintf(&b, "nil,\n")
+				continue
+			}
+
+			lexemes := make([]string, 0, len(tab))
+			for lexeme := range tab {
+				lexemes = append(lexemes, lexeme)
+			}
+			sort.Strings(lexemes)
+
+			fmt.Fprintf(&b, "{\n")
+			for _, lexeme := range lexemes {
+				fmt.Fprintf(&b, "%v: %v,\n", strconv.Quote(lexeme), tab[lexeme])
+			}
+			fmt.Fprintf(&b, "},\n")
+		}
+		fmt.Fprintf(&b, "},\n")
+	}
+	fmt.Fprintf(&b, "}")
+	return b.String()
+}
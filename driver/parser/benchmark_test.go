@@ -0,0 +1,243 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+const benchJSONSpec = `
+#name json;
+
+json
+    : value
+    ;
+value
+    : object
+    | array
+    | string
+    | number
+    | true
+    | false
+    | null
+    ;
+object
+    : l_brace members r_brace #ast members...
+    | l_brace r_brace
+    ;
+members
+    : members comma member #ast members... member
+    | member
+    ;
+member
+    : string colon value #ast string value
+    ;
+array
+    : l_bracket elements r_bracket #ast elements...
+    | l_bracket r_bracket
+    ;
+elements
+    : elements comma value #ast elements... value
+    | value
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{000D}\u{0020}]+";
+l_brace
+    : '{';
+r_brace
+    : '}';
+l_bracket
+    : '[';
+r_bracket
+    : ']';
+colon
+    : ':';
+comma
+    : ',';
+true
+    : 'true';
+false
+    : 'false';
+null
+    : 'null';
+string
+    : "\"([^\"\\\\]|\\\\[\"\\\\/bfnrt])*\"";
+number
+    : "-?(0|[1-9][0-9]*)(\.[0-9]+)?([Ee][+\-]?[0-9]+)?";
+`
+
+const benchExprSpec = `
+#name expr;
+
+#prec (
+    #left mul div
+    #left add sub
+);
+
+stmts
+    : stmts stmt #ast stmts... stmt
+    | stmt
+    ;
+stmt
+    : id assign expr semi #ast id expr
+    ;
+expr
+    : expr add expr
+    | expr sub expr
+    | expr mul expr
+    | expr div expr
+    | l_paren expr r_paren #ast expr
+    | id
+    | num
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+assign
+    : '=';
+semi
+    : ';';
+add
+    : '+';
+sub
+    : '-';
+mul
+    : '*';
+div
+    : '/';
+l_paren
+    : '(';
+r_paren
+    : ')';
+id
+    : "[A-Za-z_][0-9A-Za-z_]*";
+num
+    : "0|[1-9][0-9]*";
+`
+
+func genBenchJSON() []byte {
+	var b bytes.Buffer
+	b.WriteString("[\n")
+	for i := 0; i < 2000; i++ {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, `  {"id": %v, "name": "item%v", "price": %v.5, "tags": ["a", "b", "c"], "active": true, "parent": null, "dims": {"w": 1.5e3, "h": -2}}`, i, i, i)
+	}
+	b.WriteString("\n]\n")
+	return b.Bytes()
+}
+
+func genBenchExpr() []byte {
+	var b bytes.Buffer
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, "x%v = (a + %v) * b - c / (d + (e * %v));\n", i, i, i)
+	}
+	return b.Bytes()
+}
+
+func buildBenchGrammar(b *testing.B, src string) *spec.CompiledGrammar {
+	b.Helper()
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		b.Fatal(err)
+	}
+	gb := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := gb.Build()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return cg
+}
+
+// BenchmarkParse measures the throughput of the parser, including the lexer, on representative grammars and inputs.
+// Compare results before and after changing the hot path of the driver, for instance:
+//
+//	go test -run '^$' -bench Parse -benchmem -count 10 ./driver/parser
+func BenchmarkParse(b *testing.B) {
+	inputs := []struct {
+		name string
+		spec string
+		src  []byte
+	}{
+		{
+			name: "json",
+			spec: benchJSONSpec,
+			src:  genBenchJSON(),
+		},
+		{
+			name: "expr",
+			spec: benchExprSpec,
+			src:  genBenchExpr(),
+		},
+	}
+	// The arena frees the tree of the previous iteration at once and reuses the memory, as a process parsing many
+	// sources would do.
+	arena := NewNodeArena()
+	actions := []struct {
+		name   string
+		semAct func(gram Grammar) SemanticActionSet
+	}{
+		{
+			name: "no-action",
+		},
+		{
+			name: "cst",
+			semAct: func(gram Grammar) SemanticActionSet {
+				return NewCSTActionSet(gram, NewDefaultSyntaxTreeBuilder())
+			},
+		},
+		{
+			name: "cst-arena",
+			semAct: func(gram Grammar) SemanticActionSet {
+				arena.Reset()
+				return NewCSTActionSet(gram, NewArenaSyntaxTreeBuilder(arena))
+			},
+		},
+		{
+			name: "ast",
+			semAct: func(gram Grammar) SemanticActionSet {
+				return NewASTActionSet(gram, NewDefaultSyntaxTreeBuilder())
+			},
+		},
+	}
+	for _, in := range inputs {
+		cg := buildBenchGrammar(b, in.spec)
+		gram := NewGrammar(cg)
+		for _, act := range actions {
+			b.Run(fmt.Sprintf("%v/%v", in.name, act.name), func(b *testing.B) {
+				b.SetBytes(int64(len(in.src)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					toks, err := NewTokenStream(cg, bytes.NewReader(in.src))
+					if err != nil {
+						b.Fatal(err)
+					}
+					var opts []ParserOption
+					if act.semAct != nil {
+						opts = append(opts, SemanticAction(act.semAct(gram)))
+					}
+					p, err := NewParser(toks, gram, opts...)
+					if err != nil {
+						b.Fatal(err)
+					}
+					err = p.Parse()
+					if err != nil {
+						b.Fatal(err)
+					}
+					if len(p.SyntaxErrors()) > 0 {
+						b.Fatalf("unexpected syntax error: %v", p.SyntaxErrors()[0])
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestSharedGrammar_NewParser_Concurrently(t *testing.T) {
+	specSrc := `
+#name test;
+
+#prec (
+    #left add
+);
+
+expr
+    : expr add expr
+    | l_paren expr r_paren
+    | num
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+add
+    : '+';
+l_paren
+    : '(';
+r_paren
+    : ')';
+num
+    : "[0-9]+";
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// All parsers share the compiled grammar and the Grammar.
+	shared, err := NewSharedGrammar(cg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gram := shared.Grammar()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 16)
+	for i := 0; i < len(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			src := fmt.Sprintf("%v + (%v + %v)", i, i+1, i+2)
+			tb := NewDefaultSyntaxTreeBuilder()
+			p, err := shared.NewParser(strings.NewReader(src), SemanticAction(NewCSTActionSet(gram, tb)))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			err = p.Parse()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			if len(p.SyntaxErrors()) > 0 {
+				errs[i] = fmt.Errorf("unexpected syntax errors: %v", p.SyntaxErrors())
+				return
+			}
+
+			var nums []string
+			var collect func(n *Node)
+			collect = func(n *Node) {
+				if n.KindName == "num" {
+					nums = append(nums, n.Text)
+				}
+				for _, c := range n.Children {
+					collect(c)
+				}
+			}
+			collect(tb.Tree())
+			expected := fmt.Sprintf("%v %v %v", i, i+1, i+2)
+			if strings.Join(nums, " ") != expected {
+				errs[i] = fmt.Errorf("unexpected numbers: want: %v, got: %v", expected, nums)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("parser #%v: %v", i, err)
+		}
+	}
+}
@@ -14,6 +14,7 @@ func TestParserWithConflicts(t *testing.T) {
 		specSrc string
 		src     string
 		cst     *Node
+		synErr  bool
 	}{
 		{
 			caption: "when a shift/reduce conflict occurred, we prioritize the shift action",
@@ -482,6 +483,95 @@ assign: '=';
 				),
 			),
 		},
+		{
+			caption: "the #prefer directive chooses the action regardless of the default rule",
+			specSrc: `
+#name test;
+
+expr
+    : expr assign expr #prefer reduce
+	| id
+	;
+
+id: "[A-Za-z0-9_]+";
+assign: '=';
+`,
+			src: `foo=bar=baz`,
+			cst: nonTermNode("expr",
+				nonTermNode("expr",
+					nonTermNode("expr",
+						termNode("id", "foo"),
+					),
+					termNode("assign", "="),
+					nonTermNode("expr",
+						termNode("id", "bar"),
+					),
+				),
+				termNode("assign", "="),
+				nonTermNode("expr",
+					termNode("id", "baz"),
+				),
+			),
+		},
+		{
+			caption: "non-associative operators can be used once without parentheses",
+			specSrc: `
+#name test;
+
+#prec (
+    #left add
+    #nonassoc lt
+);
+
+expr
+    : expr lt expr
+    | expr add expr
+    | id
+    ;
+
+id: "[A-Za-z0-9_]+";
+lt: '<';
+add: '+';
+`,
+			src: `a+b<c`,
+			cst: nonTermNode("expr",
+				nonTermNode("expr",
+					nonTermNode("expr",
+						termNode("id", "a"),
+					),
+					termNode("add", "+"),
+					nonTermNode("expr",
+						termNode("id", "b"),
+					),
+				),
+				termNode("lt", "<"),
+				nonTermNode("expr",
+					termNode("id", "c"),
+				),
+			),
+		},
+		{
+			caption: "non-associative operators of the same precedence cannot be chained",
+			specSrc: `
+#name test;
+
+#prec (
+    #nonassoc lt gt
+);
+
+expr
+    : expr lt expr
+    | expr gt expr
+    | id
+    ;
+
+id: "[A-Za-z0-9_]+";
+lt: '<';
+gt: '>';
+`,
+			src:    `a<b>c`,
+			synErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -516,9 +606,94 @@ assign: '=';
 				t.Fatal(err)
 			}
 
+			synErrs := p.SyntaxErrors()
+			if tt.synErr {
+				if len(synErrs) == 0 {
+					t.Fatal("a syntax error must occur")
+				}
+				return
+			}
+			if len(synErrs) > 0 {
+				t.Fatalf("unexpected syntax errors: %v", synErrs)
+			}
+
 			if tt.cst != nil {
 				testTree(t, tb.Tree(), tt.cst)
 			}
 		})
 	}
 }
+
+func TestParserWithConflicts_ReduceForever(t *testing.T) {
+	// `n2: n0 n2 n3` is left-recursive through the empty alternative of n0, and the resolution of the conflicts makes
+	// the parser reduce `n0: ;` forever on the lookahead `a`.
+	specSrc := `
+#name test;
+
+n0
+    :
+    | a n1
+    ;
+n1
+    : b a b
+    | a b b
+    |
+    | a n2
+    ;
+n2
+    :
+    | n0 n2 n3
+    ;
+n3
+    : a a
+    | n0 a
+    ;
+
+a
+    : 'a';
+b
+    : 'b';
+`
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gram := NewGrammar(cg)
+
+	for _, disableLAC := range []bool{false, true} {
+		toks, err := NewTokenStream(cg, strings.NewReader("aa"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var opts []ParserOption
+		if disableLAC {
+			opts = append(opts, DisableLAC())
+		}
+		p, err := NewParser(toks, gram, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.Parse()
+		if disableLAC {
+			// Without LAC, the parser reduces on the lookahead and detects that it cannot stop.
+			if err == nil {
+				t.Fatal("an error must occur")
+			}
+		} else {
+			// LAC gives up validating the lookahead, so the parser reports it as a syntax error.
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(p.SyntaxErrors()) == 0 {
+				t.Fatal("a syntax error must occur")
+			}
+		}
+	}
+}
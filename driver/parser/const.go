@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/nihei9/vartan/grammar/lexical"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+// GenConstants generates Go source code defining constants of mode IDs, kind IDs, terminal numbers, and production
+// numbers of a compiled grammar. The mode IDs and the kind IDs have the types of the driver/lexer package. A constant
+// of a production number is named after its LHS and its position among the alternatives of the LHS, such as
+// `ProductionExpr2` for the second alternative of `expr`.
+func GenConstants(cgram *spec.CompiledGrammar, pkgName string) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by vartan. DO NOT EDIT.\n")
+	fmt.Fprintf(&b, "package %v\n\n", pkgName)
+	fmt.Fprintf(&b, "import \"github.com/nihei9/vartan/driver/lexer\"\n\n")
+
+	lexSpec := cgram.Lexical
+	fmt.Fprintf(&b, "// Mode IDs\n")
+	fmt.Fprintf(&b, "const (\n")
+	for i, m := range lexSpec.ModeNames {
+		if i == spec.LexModeIDNil.Int() {
+			continue
+		}
+		fmt.Fprintf(&b, "ModeID%v lexer.ModeID = %v\n", lexical.SnakeCaseToUpperCamelCase(m.String()), i)
+	}
+	fmt.Fprintf(&b, ")\n\n")
+
+	fmt.Fprintf(&b, "// Kind IDs\n")
+	fmt.Fprintf(&b, "const (\n")
+	for i, k := range lexSpec.KindNames {
+		if i == spec.LexKindIDNil.Int() {
+			continue
+		}
+		fmt.Fprintf(&b, "KindID%v lexer.KindID = %v\n", lexical.SnakeCaseToUpperCamelCase(k.String()), i)
+	}
+	fmt.Fprintf(&b, ")\n")
+
+	if cgram.IsLexerOnly() {
+		return format.Source([]byte(b.String()))
+	}
+
+	syn := cgram.Syntactic
+	fmt.Fprintf(&b, "\n// Terminal numbers\n")
+	fmt.Fprintf(&b, "const (\n")
+	for i, t := range syn.Terminals {
+		switch {
+		case t == "":
+			continue
+		case i == syn.EOFSymbol:
+			fmt.Fprintf(&b, "TerminalEOF = %v\n", i)
+		default:
+			fmt.Fprintf(&b, "Terminal%v = %v\n", lexical.SnakeCaseToUpperCamelCase(t), i)
+		}
+	}
+	fmt.Fprintf(&b, ")\n\n")
+
+	fmt.Fprintf(&b, "// Production numbers\n")
+	fmt.Fprintf(&b, "const (\n")
+	for _, p := range grammarProductions(syn) {
+		fmt.Fprintf(&b, "Production%v%v = %v\n", lexical.SnakeCaseToUpperCamelCase(p.LHS), p.Alternative, p.Number)
+	}
+	fmt.Fprintf(&b, ")\n")
+
+	return format.Source([]byte(b.String()))
+}
+
+// Constants is the JSON form of the constants GenConstants generates. The names are the ones in a grammar, such as
+// `l_paren`, so that programs in other languages can look up the IDs by the names.
+type Constants struct {
+	Name        string                `json:"name"`
+	Hash        string                `json:"hash,omitempty"`
+	Modes       map[string]int        `json:"modes"`
+	Kinds       map[string]int        `json:"kinds"`
+	Terminals   map[string]int        `json:"terminals,omitempty"`
+	Productions []*ProductionConstant `json:"productions,omitempty"`
+}
+
+// ProductionConstant is the number of a production. Alternative is the position of the production among the
+// alternatives of its LHS, counting from 1.
+type ProductionConstant struct {
+	LHS         string `json:"lhs"`
+	Alternative int    `json:"alternative"`
+	Number      int    `json:"number"`
+}
+
+// GenConstantsJSON generates the same constants as GenConstants in JSON. The terminal numbers include `<eof>` and
+// `error`.
+func GenConstantsJSON(cgram *spec.CompiledGrammar) ([]byte, error) {
+	c := &Constants{
+		Name:  cgram.Name,
+		Hash:  cgram.Hash,
+		Modes: map[string]int{},
+		Kinds: map[string]int{},
+	}
+	lexSpec := cgram.Lexical
+	for i, m := range lexSpec.ModeNames {
+		if i == spec.LexModeIDNil.Int() {
+			continue
+		}
+		c.Modes[m.String()] = i
+	}
+	for i, k := range lexSpec.KindNames {
+		if i == spec.LexKindIDNil.Int() {
+			continue
+		}
+		c.Kinds[k.String()] = i
+	}
+	if !cgram.IsLexerOnly() {
+		syn := cgram.Syntactic
+		c.Terminals = map[string]int{}
+		for i, t := range syn.Terminals {
+			if t == "" {
+				continue
+			}
+			c.Terminals[t] = i
+		}
+		c.Productions = grammarProductions(syn)
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(c)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// grammarProductions returns the productions appearing in a grammar in order of their numbers.
+func grammarProductions(syn *spec.SyntacticSpec) []*ProductionConstant {
+	augStartProds := map[int]struct{}{
+		syn.StartProduction: {},
+	}
+	for _, e := range syn.EntryPoints {
+		augStartProds[e.StartProduction] = struct{}{}
+	}
+	var prods []*ProductionConstant
+	altNums := map[int]int{}
+	for prod, lhs := range syn.LHSSymbols {
+		// Skip the nil production and the augmented start productions because they don't appear in a grammar.
+		if _, ok := augStartProds[prod]; prod == 0 || ok {
+			continue
+		}
+		altNums[lhs]++
+		prods = append(prods, &ProductionConstant{
+			LHS:         syn.NonTerminals[lhs],
+			Alternative: altNums[lhs],
+			Number:      prod,
+		})
+	}
+	return prods
+}
@@ -0,0 +1,223 @@
+package parser
+
+import (
+	"encoding/json"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestGenConstants(t *testing.T) {
+	tests := []struct {
+		caption  string
+		specSrc  string
+		expected map[string]string
+	}{
+		{
+			caption: "constants of a grammar",
+			specSrc: `
+#name test;
+
+expr
+    : expr add term
+    | term
+    ;
+term
+    : l_paren expr r_paren
+    | id
+    ;
+
+add
+    : '+';
+l_paren
+    : '(';
+r_paren
+    : ')';
+id
+    : "[a-z]+";
+`,
+			expected: map[string]string{
+				"ModeIDDefault":   "1",
+				"KindIDAdd":       "1",
+				"KindIDLParen":    "2",
+				"KindIDRParen":    "3",
+				"KindIDId":        "4",
+				"TerminalEOF":     "1",
+				"TerminalError":   "2",
+				"TerminalAdd":     "3",
+				"TerminalLParen":  "4",
+				"TerminalRParen":  "5",
+				"TerminalId":      "6",
+				"ProductionExpr1": "2",
+				"ProductionExpr2": "3",
+				"ProductionTerm1": "4",
+				"ProductionTerm2": "5",
+			},
+		},
+		{
+			caption: "a lexer-only grammar has only mode IDs and kind IDs",
+			specSrc: `
+#name test;
+
+id
+    : "[a-z]+";
+str_open #push string
+    : '"';
+char #mode string
+    : "[^\"]";
+str_close #mode string #pop
+    : '"';
+`,
+			expected: map[string]string{
+				"ModeIDDefault":  "1",
+				"ModeIDString":   "2",
+				"KindIDId":       "1",
+				"KindIDStrOpen":  "2",
+				"KindIDChar":     "3",
+				"KindIDStrClose": "4",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(tt.specSrc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := grammar.GrammarBuilder{
+				AST: ast,
+			}
+			cg, _, err := b.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			src, err := GenConstants(cg, "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			consts, err := parseConstants(src)
+			if err != nil {
+				t.Fatalf("generated code is invalid: %v\n%v", err, string(src))
+			}
+			if len(consts) != len(tt.expected) {
+				t.Fatalf("unexpected constants: want: %v, got: %v", tt.expected, consts)
+			}
+			for name, value := range tt.expected {
+				if consts[name] != value {
+					t.Fatalf("unexpected value of %v: want: %v, got: %v", name, value, consts[name])
+				}
+			}
+		})
+	}
+}
+
+func parseConstants(src []byte) (map[string]string, error) {
+	f, err := goparser.ParseFile(token.NewFileSet(), "consts.go", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	consts := map[string]string{}
+	for _, decl := range f.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range d.Specs {
+			s := spec.(*ast.ValueSpec)
+			consts[s.Names[0].Name] = s.Values[0].(*ast.BasicLit).Value
+		}
+	}
+	return consts, nil
+}
+
+func TestGenConstantsJSON(t *testing.T) {
+	specSrc := `
+#name test;
+
+expr
+    : expr add term
+    | term
+    ;
+term
+    : l_paren expr r_paren
+    | id
+    ;
+
+add
+    : '+';
+l_paren
+    : '(';
+r_paren
+    : ')';
+id
+    : "[a-z]+";
+`
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := GenConstantsJSON(cg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var consts *Constants
+	err = json.Unmarshal(src, &consts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Constants{
+		Name: "test",
+		Hash: cg.Hash,
+		Modes: map[string]int{
+			"default": 1,
+		},
+		Kinds: map[string]int{
+			"add":     1,
+			"l_paren": 2,
+			"r_paren": 3,
+			"id":      4,
+		},
+		Terminals: map[string]int{
+			"<eof>":   1,
+			"error":   2,
+			"add":     3,
+			"l_paren": 4,
+			"r_paren": 5,
+			"id":      6,
+		},
+		Productions: []*ProductionConstant{
+			{LHS: "expr", Alternative: 1, Number: 2},
+			{LHS: "expr", Alternative: 2, Number: 3},
+			{LHS: "term", Alternative: 1, Number: 4},
+			{LHS: "term", Alternative: 2, Number: 5},
+		},
+	}
+	if !reflect.DeepEqual(consts, expected) {
+		t.Fatalf("unexpected constants;\nwant: %v\ngot: %v", string(mustMarshal(t, expected)), string(src))
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestParser_ParseContext(t *testing.T) {
+	specSrc := `
+#name test;
+
+s
+    : s foo
+    | foo
+    ;
+
+foo
+    : 'foo';
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("the parser stops parsing when the context is canceled", func(t *testing.T) {
+		toks, err := NewTokenStream(cg, strings.NewReader("foofoofoofoo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// The filter cancels the context after the parser reads two tokens.
+		count := 0
+		cancelAfterTwoTokens := func(next TokenStream) TokenStream {
+			return TokenStreamFunc(func() (VToken, error) {
+				count++
+				if count == 2 {
+					cancel()
+				}
+				return next.Next()
+			})
+		}
+		p, err := NewParser(toks, NewGrammar(cg), TokenFilters(cancelAfterTwoTokens))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = p.ParseContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count != 2 {
+			t.Fatalf("the parser must stop reading tokens; read: %v", count)
+		}
+	})
+
+	t.Run("the parser parses an input when the context isn't done", func(t *testing.T) {
+		toks, err := NewTokenStream(cg, strings.NewReader("foofoo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := NewParser(toks, NewGrammar(cg))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		err = p.ParseContext(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.SyntaxErrors()) > 0 {
+			t.Fatalf("unexpected syntax errors occurred: %v", p.SyntaxErrors()[0])
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestParserWithEntryPoint(t *testing.T) {
+	specSrc := `
+#name test;
+#start expr stmt;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq expr semi
+    ;
+expr
+    : expr add term
+    | term
+    ;
+term
+    : id
+    | num
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+eq
+    : '=';
+semi
+    : ';';
+add
+    : '+';
+id
+    : "[a-z]+";
+num
+    : "[0-9]+";
+`
+
+	tests := []struct {
+		caption string
+		entry   string
+		src     string
+		synErr  bool
+		cst     *Node
+	}{
+		{
+			caption: "the parser starts at the start symbol by default",
+			src:     `a = 1;`,
+			cst: nonTermNode("stmts",
+				nonTermNode("stmt",
+					termNode("id", "a"),
+					termNode("eq", "="),
+					nonTermNode("expr",
+						nonTermNode("term",
+							termNode("num", "1"),
+						),
+					),
+					termNode("semi", ";"),
+				),
+			),
+		},
+		{
+			caption: "the start symbol is also available as an entry point",
+			entry:   "stmts",
+			src:     `a = 1;`,
+			cst: nonTermNode("stmts",
+				nonTermNode("stmt",
+					termNode("id", "a"),
+					termNode("eq", "="),
+					nonTermNode("expr",
+						nonTermNode("term",
+							termNode("num", "1"),
+						),
+					),
+					termNode("semi", ";"),
+				),
+			),
+		},
+		{
+			caption: "the parser starts at an entry point",
+			entry:   "expr",
+			src:     `a + 1`,
+			cst: nonTermNode("expr",
+				nonTermNode("expr",
+					nonTermNode("term",
+						termNode("id", "a"),
+					),
+				),
+				termNode("add", "+"),
+				nonTermNode("term",
+					termNode("num", "1"),
+				),
+			),
+		},
+		{
+			caption: "an entry point accepts only its own fragment",
+			entry:   "stmt",
+			src:     `a = 1; b = 2;`,
+			synErr:  true,
+		},
+	}
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gram := NewGrammar(cg)
+			tb := NewDefaultSyntaxTreeBuilder()
+			opts := []ParserOption{
+				SemanticAction(NewCSTActionSet(gram, tb)),
+			}
+			if tt.entry != "" {
+				opts = append(opts, EntryPoint(tt.entry))
+			}
+			p, err := NewParser(toks, gram, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.synErr {
+				if len(p.SyntaxErrors()) == 0 {
+					t.Fatalf("a syntax error must occur")
+				}
+				return
+			}
+			if len(p.SyntaxErrors()) > 0 {
+				t.Fatalf("unexpected syntax errors occurred: %v", p.SyntaxErrors()[0])
+			}
+			testTree(t, tb.Tree(), tt.cst)
+		})
+	}
+
+	t.Run("an undeclared symbol is not an entry point", func(t *testing.T) {
+		toks, err := NewTokenStream(cg, strings.NewReader(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = NewParser(toks, NewGrammar(cg), EntryPoint("term"))
+		if err == nil {
+			t.Fatal("an error must occur")
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestParserWithKeptTerminals(t *testing.T) {
+	specSrc := `
+#name test;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : expr newline #keep newline
+    ;
+expr
+    : expr add term
+    | term
+    ;
+term
+    : l_paren expr r_paren
+    | id
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+newline #skip
+    : "\u{000A}";
+add
+    : '+';
+l_paren
+    : '(';
+r_paren
+    : ')';
+id
+    : "[a-z]+";
+`
+
+	tests := []struct {
+		caption string
+		src     string
+		cst     *Node
+		synErr  bool
+	}{
+		{
+			caption: "the parser doesn't skip a kept terminal symbol where it can accept the symbol",
+			src:     "a\nb + c\n",
+			cst: nonTermNode("stmts",
+				nonTermNode("stmts",
+					nonTermNode("stmt",
+						nonTermNode("expr",
+							nonTermNode("term",
+								termNode("id", "a"),
+							),
+						),
+						termNode("newline", "\n"),
+					),
+				),
+				nonTermNode("stmt",
+					nonTermNode("expr",
+						nonTermNode("expr",
+							nonTermNode("term",
+								termNode("id", "b"),
+							),
+						),
+						termNode("add", "+"),
+						nonTermNode("term",
+							termNode("id", "c"),
+						),
+					),
+					termNode("newline", "\n"),
+				),
+			),
+		},
+		{
+			caption: "the parser skips a kept terminal symbol where it cannot accept the symbol",
+			src:     "\n(a\n+\nb)\n\n",
+			cst: nonTermNode("stmts",
+				nonTermNode("stmt",
+					nonTermNode("expr",
+						nonTermNode("term",
+							termNode("l_paren", "("),
+							nonTermNode("expr",
+								nonTermNode("expr",
+									nonTermNode("term",
+										termNode("id", "a"),
+									),
+								),
+								termNode("add", "+"),
+								nonTermNode("term",
+									termNode("id", "b"),
+								),
+							),
+							termNode("r_paren", ")"),
+						),
+					),
+					termNode("newline", "\n"),
+				),
+			),
+		},
+		{
+			caption: "a kept terminal symbol ends a statement",
+			src:     "a\n+ b\n",
+			synErr:  true,
+		},
+	}
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gram := NewGrammar(cg)
+
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tb := NewDefaultSyntaxTreeBuilder()
+			p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.synErr {
+				if len(p.SyntaxErrors()) == 0 {
+					t.Fatal("a syntax error must occur")
+				}
+				return
+			}
+			if len(p.SyntaxErrors()) > 0 {
+				t.Fatalf("unexpected syntax error: %+v", p.SyntaxErrors()[0])
+			}
+			testTree(t, tb.Tree(), tt.cst)
+		})
+	}
+}
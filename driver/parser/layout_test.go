@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestParserWithLayout(t *testing.T) {
+	specSrc := `
+#name test;
+#layout indent dedent newline;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id colon newline indent stmts dedent
+    | id newline
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+comment #skip
+    : "#[^\u{000A}]*";
+colon
+    : ':';
+id
+    : "[a-z]+";
+`
+
+	simple := func(id string) *Node {
+		return nonTermNode("stmt",
+			termNode("id", id),
+			termNode("newline", ""),
+		)
+	}
+	block := func(id string, stmts *Node) *Node {
+		return nonTermNode("stmt",
+			termNode("id", id),
+			termNode("colon", ":"),
+			termNode("newline", ""),
+			termNode("indent", ""),
+			stmts,
+			termNode("dedent", ""),
+		)
+	}
+
+	tests := []struct {
+		caption  string
+		src      string
+		tabWidth int
+		synErr   bool
+		cst      *Node
+	}{
+		{
+			caption: "the parser synthesizes a newline token at the end of an input",
+			src:     `a`,
+			cst: nonTermNode("stmts",
+				simple("a"),
+			),
+		},
+		{
+			caption: "the parser synthesizes indent and dedent tokens from changes in indentation",
+			src: `a:
+  b:
+    c
+  d
+e
+`,
+			cst: nonTermNode("stmts",
+				nonTermNode("stmts",
+					block("a", nonTermNode("stmts",
+						nonTermNode("stmts",
+							block("b", nonTermNode("stmts",
+								simple("c"),
+							)),
+						),
+						simple("d"),
+					)),
+				),
+				simple("e"),
+			),
+		},
+		{
+			caption: "the parser closes all blocks at the end of an input",
+			src: `a:
+  b:
+    c`,
+			cst: nonTermNode("stmts",
+				block("a", nonTermNode("stmts",
+					block("b", nonTermNode("stmts",
+						simple("c"),
+					)),
+				)),
+			),
+		},
+		{
+			caption: "blank lines and lines consisting only of skipped tokens don't affect indentation",
+			src: `a:
+
+# comment
+  b
+      # comment
+  c
+`,
+			cst: nonTermNode("stmts",
+				block("a", nonTermNode("stmts",
+					nonTermNode("stmts",
+						simple("b"),
+					),
+					simple("c"),
+				)),
+			),
+		},
+		{
+			caption:  "the width of a tab follows the lexer",
+			src:      "a:\n    b\n\tc\n",
+			tabWidth: 4,
+			cst: nonTermNode("stmts",
+				block("a", nonTermNode("stmts",
+					nonTermNode("stmts",
+						simple("b"),
+					),
+					simple("c"),
+				)),
+			),
+		},
+		{
+			caption: "a tab occupies one column by default",
+			src:     "a:\n    b\n\tc\n",
+			synErr:  true,
+		},
+		{
+			caption: "indentation must match an outer level",
+			src: `a:
+    b
+  c
+`,
+			synErr: true,
+		},
+	}
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("#%v %v", i, tt.caption), func(t *testing.T) {
+			var lexOpts []lexer.LexerOption
+			if tt.tabWidth > 0 {
+				lexOpts = append(lexOpts, lexer.TabWidth(tt.tabWidth))
+			}
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src), lexOpts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gram := NewGrammar(cg)
+			tb := NewDefaultSyntaxTreeBuilder()
+			p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.synErr {
+				if len(p.SyntaxErrors()) == 0 {
+					t.Fatalf("a syntax error must occur")
+				}
+				return
+			}
+			if len(p.SyntaxErrors()) > 0 {
+				t.Fatalf("unexpected syntax errors occurred: %v", p.SyntaxErrors()[0])
+			}
+			testTree(t, tb.Tree(), tt.cst)
+		})
+	}
+}
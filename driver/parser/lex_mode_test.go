@@ -0,0 +1,293 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestParserSwitchesLexModes(t *testing.T) {
+	specSrc := `
+#name test;
+
+exprs
+    : exprs semi expr
+    | expr
+    ;
+expr
+    : expr div term
+    | term
+    ;
+term
+    : id
+    | regex
+    ;
+regex
+    : div regex_body regex_close #push regex div #pop regex_close
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+semi
+    : ';';
+div
+    : '/';
+id
+    : "[a-z]+";
+regex_body #mode regex
+    : "[^/]+";
+regex_close #mode regex
+    : '/';
+`
+
+	regex := func(body string) *Node {
+		return nonTermNode("term",
+			nonTermNode("regex",
+				termNode("div", "/"),
+				termNode("regex_body", body),
+				termNode("regex_close", "/"),
+			),
+		)
+	}
+	id := func(text string) *Node {
+		return nonTermNode("term",
+			termNode("id", text),
+		)
+	}
+
+	tests := []struct {
+		caption string
+		src     string
+		cst     *Node
+	}{
+		{
+			caption: "the parser doesn't switch lex modes when it shifts a division operator",
+			src:     `a / b`,
+			cst: nonTermNode("exprs",
+				nonTermNode("expr",
+					nonTermNode("expr",
+						id("a"),
+					),
+					termNode("div", "/"),
+					id("b"),
+				),
+			),
+		},
+		{
+			caption: "the parser pushes a lex mode when it shifts the beginning of a regular expression",
+			src:     `/a b/`,
+			cst: nonTermNode("exprs",
+				nonTermNode("expr",
+					regex("a b"),
+				),
+			),
+		},
+		{
+			caption: "the parser pops a lex mode when it shifts the end of a regular expression",
+			src:     `a / /b c/; /d/ / e`,
+			cst: nonTermNode("exprs",
+				nonTermNode("exprs",
+					nonTermNode("expr",
+						nonTermNode("expr",
+							id("a"),
+						),
+						termNode("div", "/"),
+						regex("b c"),
+					),
+				),
+				termNode("semi", ";"),
+				nonTermNode("expr",
+					nonTermNode("expr",
+						regex("d"),
+					),
+					termNode("div", "/"),
+					id("e"),
+				),
+			),
+		},
+	}
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gram := NewGrammar(cg)
+			tb := NewDefaultSyntaxTreeBuilder()
+			p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(p.SyntaxErrors()) > 0 {
+				t.Fatalf("unexpected syntax errors occurred: %v", p.SyntaxErrors()[0])
+			}
+			testTree(t, tb.Tree(), tt.cst)
+		})
+	}
+}
+
+func TestParserSwitchesLexModes_Conflict(t *testing.T) {
+	specSrc := `
+#name test;
+
+s
+    : a
+    | b
+    ;
+a
+    : foo bar #push m foo
+    ;
+b
+    : foo baz
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+baz
+    : 'baz';
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	_, _, err = b.Build()
+	if err == nil {
+		t.Fatal("an error must occur because the parser cannot decide whether to push a lex mode")
+	}
+}
+
+func TestParserWithExtendedLexModes(t *testing.T) {
+	specSrc := `
+#name test;
+#mode_extends default common;
+#mode_extends tag common;
+
+elems
+    : elems elem
+    | elem
+    ;
+elem
+    : id
+    | l_angle names r_angle
+    ;
+names
+    : names name
+    | name
+    ;
+
+ws #mode common #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+comment #mode common #skip
+    : "#[^\u{000A}]*";
+id
+    : "[a-z]+";
+l_angle #push tag
+    : '<';
+name #mode tag
+    : "[a-z]+";
+r_angle #mode tag #pop
+    : '>';
+`
+
+	tests := []struct {
+		caption string
+		src     string
+		cst     *Node
+	}{
+		{
+			caption: "the default mode and the tag mode recognize the terminal symbols of the common mode",
+			src:     "a # comment\n<\tb # comment\n c> d",
+			cst: nonTermNode("elems",
+				nonTermNode("elems",
+					nonTermNode("elems",
+						nonTermNode("elem",
+							termNode("id", "a"),
+						),
+					),
+					nonTermNode("elem",
+						termNode("l_angle", "<"),
+						nonTermNode("names",
+							nonTermNode("names",
+								termNode("name", "b"),
+							),
+							termNode("name", "c"),
+						),
+						termNode("r_angle", ">"),
+					),
+				),
+				nonTermNode("elem",
+					termNode("id", "d"),
+				),
+			),
+		},
+	}
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gram := NewGrammar(cg)
+			tb := NewDefaultSyntaxTreeBuilder()
+			p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(p.SyntaxErrors()) > 0 {
+				t.Fatalf("unexpected syntax errors occurred: %v", p.SyntaxErrors()[0])
+			}
+			testTree(t, tb.Tree(), tt.cst)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestParserWithLimits(t *testing.T) {
+	specSrc := `
+#name test;
+
+expr
+    : l_paren expr r_paren
+    | id
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+l_paren
+    : '(';
+r_paren
+    : ')';
+id
+    : "[a-z]+";
+`
+
+	tests := []struct {
+		caption  string
+		src      string
+		opts     []ParserOption
+		limitErr *LimitError
+	}{
+		{
+			caption: "the parser accepts an input within the maximum stack depth",
+			src:     `((a))`,
+			opts:    []ParserOption{MaxStackDepth(5)},
+		},
+		{
+			caption: "the parser stops parsing when the state stack exceeds the maximum depth",
+			src:     `(((a)))`,
+			opts:    []ParserOption{MaxStackDepth(5)},
+			limitErr: &LimitError{
+				Limit: LimitStackDepth,
+				Max:   5,
+				Row:   0,
+				Col:   4,
+			},
+		},
+		{
+			caption: "the parser accepts an input within the maximum token count",
+			src:     `( a )`,
+			opts:    []ParserOption{MaxTokens(5)},
+		},
+		{
+			caption: "the token count includes skipped tokens",
+			src:     `( a ) `,
+			opts:    []ParserOption{MaxTokens(5)},
+			limitErr: &LimitError{
+				Limit: LimitTokens,
+				Max:   5,
+				Row:   0,
+				Col:   5,
+			},
+		},
+	}
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gram := NewGrammar(cg)
+			tb := NewDefaultSyntaxTreeBuilder()
+			opts := append([]ParserOption{SemanticAction(NewCSTActionSet(gram, tb))}, tt.opts...)
+			p, err := NewParser(toks, gram, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.Parse()
+			if tt.limitErr == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(p.SyntaxErrors()) > 0 {
+					t.Fatalf("unexpected syntax errors occurred: %v", p.SyntaxErrors()[0])
+				}
+				if tb.Tree() == nil {
+					t.Fatal("the parser must generate a tree")
+				}
+				return
+			}
+
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("a limit error must occur: %v", err)
+			}
+			if limitErr.Limit != tt.limitErr.Limit || limitErr.Max != tt.limitErr.Max || limitErr.Row != tt.limitErr.Row || limitErr.Col != tt.limitErr.Col {
+				t.Fatalf("unexpected limit error; want: %+v, got: %+v", tt.limitErr, limitErr)
+			}
+		})
+	}
+
+	t.Run("a limit must be greater than 0", func(t *testing.T) {
+		for _, opt := range []ParserOption{MaxStackDepth(0), MaxTokens(-1)} {
+			toks, err := NewTokenStream(cg, strings.NewReader(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = NewParser(toks, NewGrammar(cg), opt)
+			if err == nil {
+				t.Fatal("an error must occur")
+			}
+		}
+	})
+}
+
+func TestParserWithMaxSyntaxErrors(t *testing.T) {
+	specSrc := `
+#name test;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq id semi_colon
+    | error semi_colon #recover
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+eq
+    : '=';
+semi_colon
+    : ';';
+id
+    : "[a-z]+";
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		caption  string
+		src      string
+		max      int
+		synErrs  int
+		limitErr *LimitError
+	}{
+		{
+			caption: "the parser reports syntax errors up to the limit",
+			src:     `a; b; c = d; e;`,
+			max:     3,
+			synErrs: 3,
+		},
+		{
+			caption: "the parser stops parsing when it finds a syntax error beyond the limit",
+			src:     `a; b; c = d; e;`,
+			max:     2,
+			synErrs: 2,
+			limitErr: &LimitError{
+				Limit: LimitSyntaxErrors,
+				Max:   2,
+				Row:   0,
+				Col:   14,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := NewParser(toks, NewGrammar(cg), MaxSyntaxErrors(tt.max))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.Parse()
+			if tt.limitErr == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				var limitErr *LimitError
+				if !errors.As(err, &limitErr) {
+					t.Fatalf("a limit error must occur: %v", err)
+				}
+				if limitErr.Limit != tt.limitErr.Limit || limitErr.Max != tt.limitErr.Max || limitErr.Row != tt.limitErr.Row || limitErr.Col != tt.limitErr.Col {
+					t.Fatalf("unexpected limit error; want: %+v, got: %+v", tt.limitErr, limitErr)
+				}
+			}
+			synErrs := p.SyntaxErrors()
+			if len(synErrs) != tt.synErrs {
+				t.Fatalf("unexpected syntax error count; want: %v, got: %v", tt.synErrs, len(synErrs))
+			}
+			for _, synErr := range synErrs {
+				if len(synErr.ExpectedTerminals) == 0 {
+					t.Fatalf("a syntax error must have expected terminals: %+v", synErr)
+				}
+			}
+		})
+	}
+
+	t.Run("the maximum syntax error count must be greater than 0", func(t *testing.T) {
+		toks, err := NewTokenStream(cg, strings.NewReader(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = NewParser(toks, NewGrammar(cg), MaxSyntaxErrors(0))
+		if err == nil {
+			t.Fatal("an error must occur")
+		}
+	})
+}
@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 )
 
 type Grammar interface {
@@ -11,6 +14,11 @@ type Grammar interface {
 	// StartProduction returns the start production of grammar.
 	StartProduction() int
 
+	// EntryPoint returns an initial state and a start production of an entry point starting at a non-terminal symbol
+	// `symbol`. The start symbol and symbols declared by `#start` directives are available as entry points. When
+	// the symbol is not an entry point, this method returns false.
+	EntryPoint(symbol string) (int, int, bool)
+
 	// Action returns an ACTION entry corresponding to a (state, terminal symbol) pair.
 	Action(state int, terminal int) int
 
@@ -38,6 +46,10 @@ type Grammar interface {
 	// SkipTerminal returns true when a terminal symbol must be skipped on syntax analysis.
 	SkipTerminal(terminal int) bool
 
+	// KeepTerminal returns true when a `#keep` directive allows alternatives to contain a terminal symbol having the
+	// skip directive. The parser doesn't skip tokens of such a terminal symbol wherever it can accept them.
+	KeepTerminal(terminal int) bool
+
 	// EOF returns the EOF symbol.
 	EOF() int
 
@@ -49,6 +61,32 @@ type Grammar interface {
 
 	// ASTAction returns an AST action entries.
 	ASTAction(prod int) []int
+
+	// ASTNodeName returns a name of an AST node a production generates. When the production doesn't rename its node,
+	// this method returns an empty string.
+	ASTNodeName(prod int) string
+
+	// ASTLift returns a position of an element that replaces an AST node a production generates. The position starts
+	// from 1. When the production doesn't replace its node, this method returns 0.
+	ASTLift(prod int) int
+
+	// Layout returns the terminal symbols of INDENT, DEDENT, and NEWLINE tokens that the parser synthesizes from
+	// leading white spaces of lines. When the grammar has no `#layout` directive, this method returns false.
+	Layout() (int, int, int, bool)
+
+	// LexModeAction returns an operation on the mode stack of the lexer that the parser performs when it shifts
+	// a terminal symbol in a state. The operation is the ID of a lex mode to push, -1 to pop a lex mode, or 0 to do
+	// nothing.
+	LexModeAction(state int, terminal int) int
+
+	// ErrorSyncTerminals returns the terminal symbols that `#recover until` directives declare for a state the parser
+	// enters by shifting the error symbol. The parser discards tokens until it reads one of them. When the state has
+	// no such terminal symbols, this method returns nil.
+	ErrorSyncTerminals(state int) []int
+
+	// AlternativeLabel returns the label that a `#label` directive gives a production. When the production has no
+	// label, this method returns an empty string.
+	AlternativeLabel(prod int) string
 }
 
 type VToken interface {
@@ -76,12 +114,207 @@ type TokenStream interface {
 	Next() (VToken, error)
 }
 
+// tokenReleaser is a TokenStream that reuses the memory of tokens the parser has released.
+type tokenReleaser interface {
+	releaseToken(tok VToken)
+}
+
+// tokenKeeper is a SemanticActionSet that tells whether it refers to tokens after its Shift method returns. The parser
+// releases the tokens it shifts only when the semantic action set is nil or doesn't keep them.
+type tokenKeeper interface {
+	keepsTokens() bool
+}
+
+// TokenStreamFunc is an adapter to allow the use of a function as a TokenStream.
+type TokenStreamFunc func() (VToken, error)
+
+func (f TokenStreamFunc) Next() (VToken, error) {
+	return f()
+}
+
+// TokenFilter is a pass inserted between a token stream and the parser. A filter receives the token stream `next` that
+// precedes it and returns a token stream the parser or the following filter reads. A filter can drop, insert, or
+// replace tokens, so it can implement passes such as keyword remapping and automatic semicolon insertion.
+type TokenFilter func(next TokenStream) TokenStream
+
+// LexModeController is a token stream that allows the parser to switch lex modes. When a grammar has `#push` or
+// `#pop` directives on alternatives, a token stream must implement this interface.
+type LexModeController interface {
+	TokenStream
+
+	// PushMode pushes a lex mode onto the mode stack of the lexer.
+	PushMode(mode int)
+
+	// PopMode pops a lex mode from the mode stack of the lexer.
+	PopMode() error
+}
+
+// SourceToken is a token that a TokenSource produces.
+type SourceToken struct {
+	// KindID is the ID of the lexical kind of the token, which is the same ID as the built-in lexer gives the kind.
+	// A token stream maps it to a terminal symbol. KindID of an EOF token doesn't matter.
+	KindID int
+
+	// Lexeme is the text of the token.
+	Lexeme []byte
+
+	// BytePos and ByteLen are the byte position and the length in bytes of the token.
+	BytePos int
+	ByteLen int
+
+	// Row and Col are the position of the token. They count from 0.
+	Row int
+	Col int
+
+	// EOF is true when the token represents the end of input. A source must return an EOF token at the end.
+	EOF bool
+
+	// Invalid is true when the token is an error token. The parser treats it as an unexpected token.
+	Invalid bool
+}
+
+// TokenSource is a minimal scanner interface that allows a scanner other than the built-in lexer, such as
+// a hand-written one, to feed tokens to a parser. Adapt a TokenSource to a TokenStream to pass it to NewParser. When
+// a grammar has `#push` or `#pop` directives on alternatives, a source must also implement LexModeSource.
+type TokenSource interface {
+	// Next returns a next token. After returning an EOF token, Next must keep returning EOF tokens.
+	Next() (*SourceToken, error)
+}
+
+// LexModeSource is a token source that allows the parser to switch lex modes.
+type LexModeSource interface {
+	TokenSource
+
+	// PushMode pushes a lex mode onto the mode stack of the scanner.
+	PushMode(mode int)
+
+	// PopMode pops a lex mode from the mode stack of the scanner.
+	PopMode() error
+}
+
+type sourceVToken struct {
+	terminalID int
+	tok        *SourceToken
+}
+
+func (t *sourceVToken) TerminalID() int {
+	return t.terminalID
+}
+
+func (t *sourceVToken) Lexeme() []byte {
+	return t.tok.Lexeme
+}
+
+func (t *sourceVToken) EOF() bool {
+	return t.tok.EOF
+}
+
+func (t *sourceVToken) Invalid() bool {
+	return t.tok.Invalid
+}
+
+func (t *sourceVToken) BytePosition() (int, int) {
+	return t.tok.BytePos, t.tok.ByteLen
+}
+
+func (t *sourceVToken) Position() (int, int) {
+	return t.tok.Row, t.tok.Col
+}
+
+// sourceTokenStream is a token stream reading tokens from a TokenSource.
+type sourceTokenStream struct {
+	src            TokenSource
+	kindToTerminal []int
+
+	// fallbackTerminal is the terminal symbol of invalid tokens. When it is 0, invalid tokens have no terminal symbol.
+	fallbackTerminal int
+}
+
+// newSourceTokenStream returns a token stream reading tokens from a source. `kindToTerminal` maps kind IDs to terminal
+// symbols. When the source implements LexModeSource, the token stream implements LexModeController.
+func newSourceTokenStream(src TokenSource, kindToTerminal []int, fallbackTerminal int) TokenStream {
+	s := &sourceTokenStream{
+		src:              src,
+		kindToTerminal:   kindToTerminal,
+		fallbackTerminal: fallbackTerminal,
+	}
+	if ctl, ok := src.(LexModeSource); ok {
+		return &modeSourceTokenStream{
+			sourceTokenStream: s,
+			ctl:               ctl,
+		}
+	}
+	return s
+}
+
+func (s *sourceTokenStream) Next() (VToken, error) {
+	tok, err := s.src.Next()
+	if err != nil {
+		return nil, err
+	}
+	vtok := &sourceVToken{
+		tok: tok,
+	}
+	switch {
+	case tok.EOF:
+	case tok.Invalid:
+		vtok.terminalID = s.fallbackTerminal
+	// Kind ID 0 is the nil kind, which has no terminal symbol.
+	case tok.KindID <= 0 || tok.KindID >= len(s.kindToTerminal):
+		return nil, fmt.Errorf("%v:%v: a token has an unknown kind ID: %v", tok.Row+1, tok.Col+1, tok.KindID)
+	default:
+		vtok.terminalID = s.kindToTerminal[tok.KindID]
+	}
+	return vtok, nil
+}
+
+type modeSourceTokenStream struct {
+	*sourceTokenStream
+	ctl LexModeSource
+}
+
+func (s *modeSourceTokenStream) PushMode(mode int) {
+	s.ctl.PushMode(mode)
+}
+
+func (s *modeSourceTokenStream) PopMode() error {
+	return s.ctl.PopMode()
+}
+
 type SyntaxError struct {
-	Row               int
-	Col               int
-	Message           string
-	Token             VToken
-	ExpectedTerminals []string
+	Row     int
+	Col     int
+	Message string
+	Token   VToken
+
+	// ExpectedTerminals and ExpectedTerminalIDs are the names and the IDs of the terminal symbols the parser can
+	// accept in the state where the error occurred. They are in the same order.
+	ExpectedTerminals   []string
+	ExpectedTerminalIDs []int
+}
+
+// Limit identifies a limit on the resources the parser uses.
+type Limit string
+
+const (
+	LimitStackDepth   Limit = "stack depth"
+	LimitTokens       Limit = "token count"
+	LimitSyntaxErrors Limit = "syntax error count"
+)
+
+// LimitError is an error the parser returns when an input exceeds a limit set by the MaxStackDepth, MaxTokens, or
+// MaxSyntaxErrors option. The parser stops parsing as soon as it exceeds the limit. Row and Col are the 0-based position of Token,
+// the token the parser was reading at that time.
+type LimitError struct {
+	Limit Limit
+	Max   int
+	Row   int
+	Col   int
+	Token VToken
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v:%v: the input exceeds the maximum %v: %v", e.Row+1, e.Col+1, e.Limit, e.Max)
 }
 
 type ParserOption func(p *Parser) error
@@ -94,6 +327,168 @@ func DisableLAC() ParserOption {
 	}
 }
 
+// KeepTrivia makes the parser keep tokens it skips, such as comments and white spaces, instead of discarding them.
+// You can retrieve the kept tokens using the Parser.Trivia and Parser.LeadingTrivia methods.
+func KeepTrivia() ParserOption {
+	return func(p *Parser) error {
+		p.keepTrivia = true
+		return nil
+	}
+}
+
+// Resilient makes the parser never give up parsing an input. When the parser cannot recover from a syntax error using
+// the error symbol, it skips tokens until it finds one of the terminal symbols `syncTerminals` and resumes parsing at
+// the sync token or the token following it, discarding states on the state stack as needed. When you pass no terminal
+// symbols, the parser tries to resume at every token. The parser always tries to resume at the end of an input, and it
+// discards as few states as possible to resume. When the parser reaches the end of an input without accepting it,
+// the parser finishes parsing anyway. The parser reports such errors via the SyntaxErrors method as usual.
+//
+// A semantic action set used with this option must implement ResilientSemanticActionSet.
+func Resilient(syncTerminals ...string) ParserOption {
+	return func(p *Parser) error {
+		p.resilient = true
+		p.syncTerms = map[int]struct{}{}
+		for _, name := range syncTerminals {
+			term, ok := p.lookupTerminal(name)
+			if !ok {
+				return fmt.Errorf("unknown sync terminal: %v", name)
+			}
+			p.syncTerms[term] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// EntryPoint makes the parser start parsing at a non-terminal symbol `symbol` instead of the start symbol. The symbol
+// must be the start symbol or a symbol declared by a `#start` directive.
+func EntryPoint(symbol string) ParserOption {
+	return func(p *Parser) error {
+		state, prod, ok := p.gram.EntryPoint(symbol)
+		if !ok {
+			return fmt.Errorf("%v is not an entry point", symbol)
+		}
+		p.initialState = state
+		p.startProd = prod
+		return nil
+	}
+}
+
+// TokenFilters inserts filters between a token stream and the parser. The first filter reads tokens from the token
+// stream passed to NewParser, and the parser reads tokens from the last filter. When the grammar has a `#layout`
+// directive, the parser synthesizes layout tokens from the tokens the last filter returns. Switching lex modes using
+// `#push` and `#pop` directives on alternatives always applies to the token stream passed to NewParser.
+func TokenFilters(filters ...TokenFilter) ParserOption {
+	return func(p *Parser) error {
+		for _, f := range filters {
+			toks := f(p.toks)
+			if toks == nil {
+				return fmt.Errorf("a token filter must return a token stream")
+			}
+			p.toks = toks
+		}
+		return nil
+	}
+}
+
+// MaxStackDepth limits the depth of the state stack to `depth`. Deeply nested inputs, such as a long run of opening
+// parentheses, make the state stack grow. When the stack exceeds the limit, Parser.Parse returns a *LimitError, so
+// services parsing untrusted inputs can reject such inputs before they exhaust memory. The depth must be greater than
+// 0.
+func MaxStackDepth(depth int) ParserOption {
+	return func(p *Parser) error {
+		if depth <= 0 {
+			return fmt.Errorf("the maximum stack depth must be greater than 0: %v", depth)
+		}
+		p.maxStackDepth = depth
+		return nil
+	}
+}
+
+// MaxTokens limits the number of tokens the parser reads to `count`. The count includes tokens the parser skips but
+// doesn't include the EOF token. When an input exceeds the limit, Parser.Parse returns a *LimitError. The count must be
+// greater than 0.
+func MaxTokens(count int) ParserOption {
+	return func(p *Parser) error {
+		if count <= 0 {
+			return fmt.Errorf("the maximum token count must be greater than 0: %v", count)
+		}
+		p.maxTokens = count
+		return nil
+	}
+}
+
+// MaxSyntaxErrors limits the number of syntax errors the parser reports to `count`. A parser recovering from errors
+// using `error` symbols or the resilient mode keeps parsing after a syntax error, so a badly broken input can produce
+// a flood of errors. When the parser finds a syntax error beyond the limit, it stops parsing, and Parser.Parse returns
+// a *LimitError pointing to the unexpected token. Parser.SyntaxErrors still returns the errors found up to the limit.
+// The count must be greater than 0.
+func MaxSyntaxErrors(count int) ParserOption {
+	return func(p *Parser) error {
+		if count <= 0 {
+			return fmt.Errorf("the maximum syntax error count must be greater than 0: %v", count)
+		}
+		p.maxSynErrs = count
+		return nil
+	}
+}
+
+// Trace makes the parser write every shift, reduce, goto, and accept action and every syntax error it finds to `w` as
+// JSON lines, one TraceEvent per line. When writing an event fails, Parser.Parse returns the error.
+func Trace(w io.Writer) ParserOption {
+	return func(p *Parser) error {
+		if w == nil {
+			return fmt.Errorf("a trace writer must not be nil")
+		}
+		p.trace = json.NewEncoder(w)
+		return nil
+	}
+}
+
+// Kinds of actions in TraceEvent.
+const (
+	TraceActionShift  = "shift"
+	TraceActionReduce = "reduce"
+	TraceActionGoTo   = "goto"
+	TraceActionAccept = "accept"
+	TraceActionError  = "error"
+)
+
+// TraceEvent is an action the parser performs, which the Trace option writes.
+type TraceEvent struct {
+	// Action is one of the TraceAction constants. An error event means the parser found a syntax error, and a shift
+	// event whose symbol is the error symbol follows it when the parser recovers from the error.
+	Action string `json:"action"`
+
+	// State is the state on the top of the state stack when the parser performs the action.
+	State int `json:"state"`
+
+	// NextState is the state that a shift or goto action pushes.
+	NextState int `json:"next_state,omitempty"`
+
+	// Production is the production that a reduce or accept action reduces.
+	Production int `json:"production,omitempty"`
+
+	// Symbol is the terminal symbol that a shift action shifts or an error event finds, or the LHS of the production
+	// that a reduce or goto action reduces.
+	Symbol string `json:"symbol,omitempty"`
+
+	// Token is the token the parser is reading. Goto and accept events have no token.
+	Token *TraceToken `json:"token,omitempty"`
+
+	// Depth is the depth of the state stack after the action.
+	Depth int `json:"depth"`
+}
+
+// TraceToken is a token in a TraceEvent. Row and Col are 0-based like VToken.Position.
+type TraceToken struct {
+	Lexeme  string `json:"lexeme"`
+	Row     int    `json:"row"`
+	Col     int    `json:"col"`
+	BytePos int    `json:"byte_pos"`
+	ByteLen int    `json:"byte_len"`
+	EOF     bool   `json:"eof,omitempty"`
+}
+
 func SemanticAction(semAct SemanticActionSet) ParserOption {
 	return func(p *Parser) error {
 		p.semAct = semAct
@@ -105,18 +500,73 @@ type Parser struct {
 	toks       TokenStream
 	gram       Grammar
 	stateStack *stateStack
+
+	// lexToks is the token stream passed to NewParser. toks differs from lexToks when token filters are inserted.
+	lexToks TokenStream
+
+	// layout synthesizes INDENT, DEDENT, and NEWLINE tokens when the grammar has a `#layout` directive.
+	layout *layoutStream
+
+	// initialState and startProd are the initial state and the start production of the entry point.
+	initialState int
+	startProd    int
+
 	semAct     SemanticActionSet
 	disableLAC bool
+
+	// releaser is the token stream that reuses tokens the parser releases, and it is nil when the parser cannot release
+	// tokens. releaseShifted is true when the parser releases the tokens it shifts.
+	releaser       tokenReleaser
+	releaseShifted bool
+
+	// lookaheadValidated is true when the parser has validated the current token using LAC since it shifted a token
+	// last.
+	lookaheadValidated bool
+
 	onError    bool
 	shiftCount int
 	synErrs    []*SyntaxError
+
+	keepTrivia    bool
+	trivia        []VToken
+	pendingTrivia []VToken
+	leadingTrivia map[int][]VToken
+
+	resilient bool
+	syncTerms map[int]struct{}
+	resyncPos int
+	resynced  bool
+
+	// maxStackDepth, maxTokens, and maxSynErrs are 0 when the parser has no limit.
+	maxStackDepth int
+	maxTokens     int
+	tokenCount    int
+	maxSynErrs    int
+
+	// ctx is the context passed to ParseContext.
+	ctx context.Context
+
+	// reduceBudget is the number of reductions the parser can perform until it shifts the next token.
+	reduceBudget int
+
+	// trace is non-nil when the Trace option is specified.
+	trace *json.Encoder
 }
 
+// maxReductionsPerState bounds the number of reductions per state on the stack that the parser performs between
+// shifts. Even a chain of unit productions and empty productions on a real grammar needs far fewer reductions, but
+// a grammar whose conflicts are resolved arbitrarily, such as `a: b a c | ; b: ;`, can make the parser reduce forever.
+const maxReductionsPerState = 1000
+
 func NewParser(toks TokenStream, gram Grammar, opts ...ParserOption) (*Parser, error) {
 	p := &Parser{
-		toks:       toks,
-		gram:       gram,
-		stateStack: &stateStack{},
+		toks:         toks,
+		lexToks:      toks,
+		gram:         gram,
+		stateStack:   &stateStack{},
+		initialState: gram.InitialState(),
+		startProd:    gram.StartProduction(),
+		resyncPos:    -1,
 	}
 
 	for _, opt := range opts {
@@ -126,11 +576,39 @@ func NewParser(toks TokenStream, gram Grammar, opts ...ParserOption) (*Parser, e
 		}
 	}
 
+	if indent, dedent, newline, ok := gram.Layout(); ok {
+		p.layout = &layoutStream{
+			toks:    p.toks,
+			gram:    gram,
+			indent:  indent,
+			dedent:  dedent,
+			newline: newline,
+			indents: []int{0},
+		}
+	}
+
+	if p.resilient && p.semAct != nil {
+		if _, ok := p.semAct.(ResilientSemanticActionSet); !ok {
+			return nil, fmt.Errorf("a semantic action set must implement ResilientSemanticActionSet to be used in the resilient mode")
+		}
+	}
+
 	return p, nil
 }
 
+// Parse parses an input. It is equivalent to ParseContext with context.Background().
 func (p *Parser) Parse() error {
-	p.stateStack.push(p.gram.InitialState())
+	return p.ParseContext(context.Background())
+}
+
+// ParseContext parses an input like Parse, but it stops parsing and returns ctx.Err() when ctx is canceled or its
+// deadline passes. The parser checks ctx each time it reads a token, so the semantic actions performed so far remain
+// applied, and the syntax tree built so far is incomplete.
+func (p *Parser) ParseContext(ctx context.Context) error {
+	p.ctx = ctx
+	p.setUpTokenRelease()
+	p.stateStack.push(p.initialState)
+	p.resetReduceBudget()
 	tok, err := p.nextToken()
 	if err != nil {
 		return err
@@ -156,11 +634,33 @@ ACTION_LOOP:
 				}
 			}
 
-			p.shift(nextState)
+			lexModeAct := p.gram.LexModeAction(p.stateStack.top(), p.tokenToTerminal(tok))
+
+			err = p.traceShift(nextState, p.tokenToTerminal(tok), tok)
+			if err != nil {
+				return err
+			}
+
+			err = p.shift(nextState, tok)
+			if err != nil {
+				return err
+			}
 
 			if p.semAct != nil {
 				p.semAct.Shift(tok, recovered)
 			}
+			if p.releaseShifted {
+				p.releaseToken(tok)
+			}
+
+			// Because the parser reads the next token only after shifting the current token, switching lex modes
+			// here affects the next token.
+			if lexModeAct != 0 {
+				err := p.switchLexMode(lexModeAct)
+				if err != nil {
+					return err
+				}
+			}
 
 			tok, err = p.nextToken()
 			if err != nil {
@@ -176,15 +676,32 @@ ACTION_LOOP:
 				recovered = true
 			}
 
-			accepted := p.reduce(prodNum)
+			p.reduceBudget--
+			if p.reduceBudget < 0 {
+				return fmt.Errorf("the parser keeps reducing without shifting a token; the grammar may have conflicts that make the parser reduce forever")
+			}
+
+			accepted, err := p.reduce(prodNum, tok)
+			if err != nil {
+				return err
+			}
 			if accepted {
 				if p.semAct != nil {
-					p.semAct.Accept()
+					if semAct, ok := p.semAct.(ResilientSemanticActionSet); ok && p.resynced {
+						semAct.AcceptPartially(p.startProd)
+					} else {
+						p.semAct.Accept()
+					}
 				}
 
 				return nil
 			}
 
+			err = p.checkStackDepth(tok)
+			if err != nil {
+				return err
+			}
+
 			if p.semAct != nil {
 				p.semAct.Reduce(prodNum, recovered)
 			}
@@ -195,6 +712,18 @@ ACTION_LOOP:
 					return err
 				}
 				if tok.EOF() {
+					if p.resilient {
+						tok, err = p.resync(tok)
+						if err != nil {
+							return err
+						}
+						if tok == nil {
+							return nil
+						}
+
+						continue ACTION_LOOP
+					}
+
 					if p.semAct != nil {
 						p.semAct.MissError(tok)
 					}
@@ -205,17 +734,52 @@ ACTION_LOOP:
 				continue ACTION_LOOP
 			}
 
+			if p.trace != nil {
+				err := p.writeTrace(&TraceEvent{
+					Action: TraceActionError,
+					State:  p.stateStack.top(),
+					Symbol: p.gram.Terminal(p.tokenToTerminal(tok)),
+					Token:  newTraceToken(tok),
+					Depth:  len(p.stateStack.items),
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			if p.maxSynErrs > 0 && len(p.synErrs) >= p.maxSynErrs {
+				return p.limitError(LimitSyntaxErrors, p.maxSynErrs, tok)
+			}
+
 			row, col := tok.Position()
+			expected := p.searchLookahead(p.stateStack.top())
+			expectedNames := make([]string, len(expected))
+			for i, term := range expected {
+				expectedNames[i] = p.gram.Terminal(term)
+			}
 			p.synErrs = append(p.synErrs, &SyntaxError{
-				Row:               row,
-				Col:               col,
-				Message:           "unexpected token",
-				Token:             tok,
-				ExpectedTerminals: p.searchLookahead(p.stateStack.top()),
+				Row:                 row,
+				Col:                 col,
+				Message:             "unexpected token",
+				Token:               tok,
+				ExpectedTerminals:   expectedNames,
+				ExpectedTerminalIDs: expected,
 			})
 
 			count, ok := p.trapError()
 			if !ok {
+				if p.resilient {
+					tok, err = p.resync(tok)
+					if err != nil {
+						return err
+					}
+					if tok == nil {
+						return nil
+					}
+
+					continue ACTION_LOOP
+				}
+
 				if p.semAct != nil {
 					p.semAct.MissError(tok)
 				}
@@ -231,10 +795,29 @@ ACTION_LOOP:
 				return err
 			}
 
-			p.shift(act * -1)
+			err = p.traceShift(act*-1, p.gram.Error(), tok)
+			if err != nil {
+				return err
+			}
+
+			err = p.shift(act*-1, tok)
+			if err != nil {
+				return err
+			}
 
 			if p.semAct != nil {
-				p.semAct.TrapAndShiftError(tok, count)
+				if semAct, ok := p.semAct.(ResilientSemanticActionSet); ok && p.resilient {
+					semAct.TrapAndShiftErrorKeepingNodes(tok, count)
+				} else {
+					p.semAct.TrapAndShiftError(tok, count)
+				}
+			}
+
+			if syncs := p.gram.ErrorSyncTerminals(act * -1); len(syncs) > 0 {
+				tok, err = p.discardUntil(tok, syncs)
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -246,6 +829,7 @@ func (p *Parser) validateLookahead(term int) bool {
 	p.stateStack.enableExploratoryMode()
 	defer p.stateStack.disableExploratoryMode()
 
+	budget := (p.stateStack.depthExploratorily() + 1) * maxReductionsPerState
 	for {
 		act := p.gram.Action(p.stateStack.topExploratorily(), term)
 
@@ -255,14 +839,25 @@ func (p *Parser) validateLookahead(term int) bool {
 		case act > 0: // Reduce
 			prodNum := act
 
+			budget--
+			if budget < 0 {
+				return false
+			}
+
 			lhs := p.gram.LHS(prodNum)
-			if lhs == p.gram.LHS(p.gram.StartProduction()) {
+			if lhs == p.gram.LHS(p.startProd) {
 				return true
 			}
 			n := p.gram.AlternativeSymbolCount(prodNum)
+			if n >= p.stateStack.depthExploratorily() {
+				return false
+			}
 			p.stateStack.popExploratorily(n)
 			state := p.gram.GoTo(p.stateStack.topExploratorily(), lhs)
 			p.stateStack.pushExploratorily(state)
+			if p.maxStackDepth > 0 && p.stateStack.depthExploratorily() > p.maxStackDepth {
+				return false
+			}
 		default: // Error
 			return false
 		}
@@ -270,23 +865,132 @@ func (p *Parser) validateLookahead(term int) bool {
 }
 
 func (p *Parser) nextToken() (VToken, error) {
+	p.lookaheadValidated = false
 	for {
+		select {
+		case <-p.ctx.Done():
+			return nil, p.ctx.Err()
+		default:
+		}
+
 		// We don't have to check whether the token is invalid because the kind ID of the invalid token is 0,
 		// and the parsing table doesn't have an entry corresponding to the kind ID 0. Thus we can detect
 		// a syntax error because the parser cannot find an entry corresponding to the invalid token.
-		tok, err := p.toks.Next()
+		var tok VToken
+		var err error
+		if p.layout != nil {
+			tok, err = p.layout.next()
+		} else {
+			tok, err = p.toks.Next()
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		if p.gram.SkipTerminal(tok.TerminalID()) {
+		if !tok.EOF() && p.maxTokens > 0 {
+			p.tokenCount++
+			if p.tokenCount > p.maxTokens {
+				return nil, p.limitError(LimitTokens, p.maxTokens, tok)
+			}
+		}
+
+		if p.skip(tok) {
+			if p.keepTrivia {
+				p.trivia = append(p.trivia, tok)
+				p.pendingTrivia = append(p.pendingTrivia, tok)
+			} else {
+				p.releaseToken(tok)
+			}
 			continue
 		}
 
+		if len(p.pendingTrivia) > 0 {
+			if p.leadingTrivia == nil {
+				p.leadingTrivia = map[int][]VToken{}
+			}
+			pos, _ := tok.BytePosition()
+			p.leadingTrivia[pos] = p.pendingTrivia
+			p.pendingTrivia = nil
+		}
+
 		return tok, nil
 	}
 }
 
+// releaseToken lets the token stream reuse the memory of a token `tok` that the parser no longer refers to. A token
+// that a syntax error holds or that the layout stream may still look at stays as it is.
+func (p *Parser) releaseToken(tok VToken) {
+	if p.releaser == nil {
+		return
+	}
+	if n := len(p.synErrs); n > 0 && p.synErrs[n-1].Token == tok {
+		return
+	}
+	p.releaser.releaseToken(tok)
+}
+
+// setUpTokenRelease decides whether the parser can release tokens.
+func (p *Parser) setUpTokenRelease() {
+	p.releaser = nil
+	if r, ok := p.toks.(tokenReleaser); ok && p.layout == nil {
+		p.releaser = r
+	}
+	p.releaseShifted = true
+	if p.semAct != nil {
+		k, ok := p.semAct.(tokenKeeper)
+		p.releaseShifted = ok && !k.keepsTokens()
+	}
+}
+
+// skip returns true when the parser must skip a token. The parser skips tokens of a terminal symbol that a `#keep`
+// directive names only when it cannot accept them in the current state.
+func (p *Parser) skip(tok VToken) bool {
+	if tok.EOF() {
+		return false
+	}
+	term := tok.TerminalID()
+	if !p.gram.SkipTerminal(term) {
+		return false
+	}
+	if p.gram.KeepTerminal(term) && !p.onError && p.validateLookahead(term) {
+		return false
+	}
+	return true
+}
+
+// Trivia returns tokens the parser skipped in order of appearance. The parser keeps these tokens only when the KeepTrivia
+// option is enabled.
+func (p *Parser) Trivia() []VToken {
+	return p.trivia
+}
+
+// LeadingTrivia returns tokens the parser skipped immediately before a token appearing at a byte position `bytePos`.
+// Trivia at the end of an input is attached to the EOF token. The parser keeps these tokens only when the KeepTrivia
+// option is enabled.
+func (p *Parser) LeadingTrivia(bytePos int) []VToken {
+	return p.leadingTrivia[bytePos]
+}
+
+// discardUntil discards tokens until it reads one of terminal symbols `terms` or EOF, and it returns the token it read
+// last.
+func (p *Parser) discardUntil(tok VToken, terms []int) (VToken, error) {
+	for !tok.EOF() {
+		term := p.tokenToTerminal(tok)
+		for _, t := range terms {
+			if t == term {
+				return tok, nil
+			}
+		}
+
+		var err error
+		tok, err = p.nextToken()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tok, nil
+}
+
 func (p *Parser) tokenToTerminal(tok VToken) int {
 	if tok.EOF() {
 		return p.gram.EOF()
@@ -296,14 +1000,19 @@ func (p *Parser) tokenToTerminal(tok VToken) int {
 }
 
 func (p *Parser) lookupAction(tok VToken) int {
-	if !p.disableLAC {
-		term := p.tokenToTerminal(tok)
-		if !p.validateLookahead(term) {
-			return 0
-		}
+	term := p.tokenToTerminal(tok)
+	act := p.gram.Action(p.stateStack.top(), term)
+	// Only a reduce action needs validation because LAC accepts a shift action and rejects an error entry at once.
+	// Moreover, once the parser validates a token, the reductions it performs on the token are the ones the validation
+	// has simulated, so the parser validates each token just once.
+	if act <= 0 || p.disableLAC || p.lookaheadValidated {
+		return act
 	}
-
-	return p.gram.Action(p.stateStack.top(), p.tokenToTerminal(tok))
+	if !p.validateLookahead(term) {
+		return 0
+	}
+	p.lookaheadValidated = true
+	return act
 }
 
 func (p *Parser) lookupActionOnError() (int, error) {
@@ -315,44 +1024,256 @@ func (p *Parser) lookupActionOnError() (int, error) {
 	return act, nil
 }
 
-func (p *Parser) shift(nextState int) {
+func (p *Parser) switchLexMode(act int) error {
+	ctl, ok := p.lexToks.(LexModeController)
+	if !ok {
+		return fmt.Errorf("a token stream must implement LexModeController to switch lex modes")
+	}
+	if act < 0 {
+		return ctl.PopMode()
+	}
+	ctl.PushMode(act)
+	return nil
+}
+
+func (p *Parser) shift(nextState int, tok VToken) error {
 	p.stateStack.push(nextState)
+	p.lookaheadValidated = false
+	p.resetReduceBudget()
+	return p.checkStackDepth(tok)
 }
 
-func (p *Parser) reduce(prodNum int) bool {
-	lhs := p.gram.LHS(prodNum)
-	if lhs == p.gram.LHS(p.gram.StartProduction()) {
-		return true
+func (p *Parser) resetReduceBudget() {
+	p.reduceBudget = (len(p.stateStack.items) + 1) * maxReductionsPerState
+}
+
+// checkStackDepth returns a *LimitError when the state stack exceeds the maximum depth. `tok` is the token the parser
+// is reading.
+func (p *Parser) checkStackDepth(tok VToken) error {
+	if p.maxStackDepth > 0 && len(p.stateStack.items) > p.maxStackDepth {
+		return p.limitError(LimitStackDepth, p.maxStackDepth, tok)
+	}
+	return nil
+}
+
+func (p *Parser) limitError(limit Limit, max int, tok VToken) *LimitError {
+	row, col := tok.Position()
+	return &LimitError{
+		Limit: limit,
+		Max:   max,
+		Row:   row,
+		Col:   col,
+		Token: tok,
 	}
+}
+
+// reduce reduces a production. `tok` is the lookahead token, which only trace events use.
+func (p *Parser) reduce(prodNum int, tok VToken) (bool, error) {
+	lhs := p.gram.LHS(prodNum)
 	n := p.gram.AlternativeSymbolCount(prodNum)
+	// A consistent grammar never pops the initial state and accepts an input only when the state stack consists of
+	// the initial state and the states of the start production. These checks keep a corrupted grammar from making
+	// the parser or semantic actions panic.
+	if lhs == p.gram.LHS(p.startProd) {
+		if n != len(p.stateStack.items)-1 {
+			return false, fmt.Errorf("the parser accepted an input in an inconsistent state; production: %v, states: %v", prodNum, len(p.stateStack.items))
+		}
+		if p.trace != nil {
+			err := p.writeTrace(&TraceEvent{
+				Action:     TraceActionAccept,
+				State:      p.stateStack.top(),
+				Production: prodNum,
+				Depth:      len(p.stateStack.items),
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	if n >= len(p.stateStack.items) {
+		return false, fmt.Errorf("a production pops more states than the state stack has; production: %v, states: %v", prodNum, len(p.stateStack.items))
+	}
+	state := p.stateStack.top()
 	p.stateStack.pop(n)
 	nextState := p.gram.GoTo(p.stateStack.top(), lhs)
+	if p.trace != nil {
+		err := p.writeTrace(&TraceEvent{
+			Action:     TraceActionReduce,
+			State:      state,
+			Production: prodNum,
+			Symbol:     p.gram.NonTerminal(lhs),
+			Token:      newTraceToken(tok),
+			Depth:      len(p.stateStack.items),
+		})
+		if err != nil {
+			return false, err
+		}
+		err = p.writeTrace(&TraceEvent{
+			Action:    TraceActionGoTo,
+			State:     p.stateStack.top(),
+			NextState: nextState,
+			Symbol:    p.gram.NonTerminal(lhs),
+			Depth:     len(p.stateStack.items) + 1,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
 	p.stateStack.push(nextState)
-	return false
+	return false, nil
 }
 
+// traceShift writes a trace event of shifting a terminal symbol `term` and going to a state `nextState`.
+func (p *Parser) traceShift(nextState int, term int, tok VToken) error {
+	if p.trace == nil {
+		return nil
+	}
+	return p.writeTrace(&TraceEvent{
+		Action:    TraceActionShift,
+		State:     p.stateStack.top(),
+		NextState: nextState,
+		Symbol:    p.gram.Terminal(term),
+		Token:     newTraceToken(tok),
+		Depth:     len(p.stateStack.items) + 1,
+	})
+}
+
+func (p *Parser) writeTrace(e *TraceEvent) error {
+	err := p.trace.Encode(e)
+	if err != nil {
+		return fmt.Errorf("cannot write a trace event: %w", err)
+	}
+	return nil
+}
+
+func newTraceToken(tok VToken) *TraceToken {
+	row, col := tok.Position()
+	pos, n := tok.BytePosition()
+	return &TraceToken{
+		Lexeme:  string(tok.Lexeme()),
+		Row:     row,
+		Col:     col,
+		BytePos: pos,
+		ByteLen: n,
+		EOF:     tok.EOF(),
+	}
+}
+
+// trapError pops states until a state that can shift the error symbol appears on the top of the state stack. When no
+// such state exists, this method leaves the state stack as it is.
 func (p *Parser) trapError() (int, bool) {
-	count := 0
-	for {
-		if p.gram.ErrorTrapperState(p.stateStack.top()) {
+	items := p.stateStack.items
+	for count := 0; count < len(items); count++ {
+		state := items[len(items)-1-count]
+		if p.gram.ErrorTrapperState(state) {
+			p.stateStack.pop(count)
 			return count, true
 		}
 
-		if p.stateStack.top() != p.gram.InitialState() {
-			p.stateStack.pop(1)
-			count++
-		} else {
-			return 0, false
+		if state == p.initialState {
+			break
+		}
+	}
+
+	return 0, false
+}
+
+// resync skips tokens and pops states until the parser can resume parsing in the resilient mode, and returns a token
+// the parser resumes at. When the parser reaches the end of an input without finding such a point, this method
+// finishes parsing and returns nil.
+func (p *Parser) resync(cause VToken) (VToken, error) {
+	p.onError = false
+	p.shiftCount = 0
+	p.resynced = true
+
+	semAct, _ := p.semAct.(ResilientSemanticActionSet)
+
+	var skipped []VToken
+	tok := cause
+	afterSync := false
+	for {
+		// When the parser has already resumed at the token, the parser skips it to avoid falling into an infinite loop.
+		pos, _ := tok.BytePosition()
+		if pos != p.resyncPos && (afterSync || p.isSyncToken(tok)) {
+			if n, ok := p.lookupResumePoint(tok); ok {
+				p.stateStack.pop(n)
+				p.resetReduceBudget()
+				p.resyncPos = pos
+				if semAct != nil {
+					semAct.Resync(cause, n, skipped)
+				}
+
+				return tok, nil
+			}
+		}
+
+		if tok.EOF() {
+			if semAct != nil {
+				semAct.Resync(cause, 0, skipped)
+				semAct.AcceptPartially(p.startProd)
+			}
+
+			return nil, nil
+		}
+
+		skipped = append(skipped, tok)
+		afterSync = p.isSyncToken(tok)
+
+		var err error
+		tok, err = p.nextToken()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *Parser) isSyncToken(tok VToken) bool {
+	if len(p.syncTerms) == 0 || tok.EOF() {
+		return true
+	}
+	_, ok := p.syncTerms[p.tokenToTerminal(tok)]
+	return ok
+}
+
+// lookupResumePoint returns the number of states the parser must pop to take a token `tok`.
+func (p *Parser) lookupResumePoint(tok VToken) (int, bool) {
+	items := p.stateStack.items
+	defer func() {
+		p.stateStack.items = items
+	}()
+
+	for n := 0; n < len(items); n++ {
+		p.stateStack.items = items[:len(items)-n]
+		p.lookaheadValidated = false
+		if p.lookupAction(tok) != 0 {
+			return n, true
 		}
 	}
+
+	return 0, false
+}
+
+func (p *Parser) lookupTerminal(name string) (int, bool) {
+	termCount := p.gram.TerminalCount()
+	for term := 0; term < termCount; term++ {
+		if term == p.gram.Error() {
+			continue
+		}
+		if p.gram.Terminal(term) == name {
+			return term, true
+		}
+	}
+
+	return 0, false
 }
 
 func (p *Parser) SyntaxErrors() []*SyntaxError {
 	return p.synErrs
 }
 
-func (p *Parser) searchLookahead(state int) []string {
-	kinds := []string{}
+func (p *Parser) searchLookahead(state int) []int {
+	terms := []int{}
 	termCount := p.gram.TerminalCount()
 	for term := 0; term < termCount; term++ {
 		if p.disableLAC {
@@ -371,24 +1292,29 @@ func (p *Parser) searchLookahead(state int) []string {
 			continue
 		}
 
-		kinds = append(kinds, p.gram.Terminal(term))
+		terms = append(terms, term)
 	}
 
-	return kinds
+	return terms
 }
 
 type stateStack struct {
-	items    []int
+	items []int
+
+	// In the exploratory mode, the stack consists of the bottom `expBase` items of `items` and `itemsExp` stacked on
+	// them, so the exploratory operations neither copy nor modify `items`.
+	expBase  int
 	itemsExp []int
 }
 
 func (s *stateStack) enableExploratoryMode() {
-	s.itemsExp = make([]int, len(s.items))
-	copy(s.itemsExp, s.items)
+	s.expBase = len(s.items)
+	s.itemsExp = s.itemsExp[:0]
 }
 
 func (s *stateStack) disableExploratoryMode() {
-	s.itemsExp = nil
+	s.expBase = 0
+	s.itemsExp = s.itemsExp[:0]
 }
 
 func (s *stateStack) top() int {
@@ -396,7 +1322,14 @@ func (s *stateStack) top() int {
 }
 
 func (s *stateStack) topExploratorily() int {
-	return s.itemsExp[len(s.itemsExp)-1]
+	if len(s.itemsExp) > 0 {
+		return s.itemsExp[len(s.itemsExp)-1]
+	}
+	return s.items[s.expBase-1]
+}
+
+func (s *stateStack) depthExploratorily() int {
+	return s.expBase + len(s.itemsExp)
 }
 
 func (s *stateStack) push(state int) {
@@ -412,5 +1345,144 @@ func (s *stateStack) pop(n int) {
 }
 
 func (s *stateStack) popExploratorily(n int) {
-	s.itemsExp = s.itemsExp[:len(s.itemsExp)-n]
+	if n <= len(s.itemsExp) {
+		s.itemsExp = s.itemsExp[:len(s.itemsExp)-n]
+		return
+	}
+	s.expBase -= n - len(s.itemsExp)
+	s.itemsExp = s.itemsExp[:0]
+}
+
+// layoutStream converts changes in indentation into INDENT and DEDENT tokens and the ends of lines into NEWLINE tokens.
+// It measures the indentation of a line by the column of the first token that the parser doesn't skip, so how
+// the lexer counts columns, including the width of a tab, determines the indentation. Lines consisting only of skipped
+// tokens, such as blank lines and comment lines, don't affect the indentation.
+type layoutStream struct {
+	toks    TokenStream
+	gram    Grammar
+	indent  int
+	dedent  int
+	newline int
+
+	// indents is a stack of the indentation levels. The bottom is always 0.
+	indents []int
+
+	// lastRow is the row where the last token the parser doesn't skip ends. started is true after such a token appears.
+	lastRow int
+	started bool
+
+	queue []VToken
+}
+
+func (s *layoutStream) next() (VToken, error) {
+	if len(s.queue) > 0 {
+		tok := s.queue[0]
+		s.queue = s.queue[1:]
+		return tok, nil
+	}
+
+	tok, err := s.toks.Next()
+	if err != nil {
+		return nil, err
+	}
+	if !tok.EOF() && s.gram.SkipTerminal(tok.TerminalID()) {
+		return tok, nil
+	}
+
+	pos, _ := tok.BytePosition()
+	row, col := tok.Position()
+	switch {
+	case tok.EOF():
+		if s.started {
+			s.enqueue(s.newline, pos, row, col)
+		}
+		for len(s.indents) > 1 {
+			s.indents = s.indents[:len(s.indents)-1]
+			s.enqueue(s.dedent, pos, row, col)
+		}
+	case !s.started || row != s.lastRow:
+		if s.started {
+			s.enqueue(s.newline, pos, row, col)
+		}
+		top := s.indents[len(s.indents)-1]
+		switch {
+		case col > top:
+			s.indents = append(s.indents, col)
+			s.enqueue(s.indent, pos, row, col)
+		case col < top:
+			for col < s.indents[len(s.indents)-1] {
+				s.indents = s.indents[:len(s.indents)-1]
+				s.enqueue(s.dedent, pos, row, col)
+			}
+			// When the indentation doesn't match any outer level, the parser detects a syntax error because of
+			// the invalid token. The stream adopts the indentation as a new level so that the following lines at
+			// the same level don't cause the error again.
+			if col != s.indents[len(s.indents)-1] {
+				s.queue = append(s.queue, &layoutToken{
+					invalid: true,
+					bytePos: pos,
+					row:     row,
+					col:     col,
+				})
+				s.indents = append(s.indents, col)
+			}
+		}
+	}
+	if !tok.EOF() {
+		s.started = true
+		// A token can span multiple lines, such as a multi-line string literal.
+		s.lastRow = row
+		for _, b := range tok.Lexeme() {
+			if b == '\n' {
+				s.lastRow++
+			}
+		}
+	}
+	s.queue = append(s.queue, tok)
+
+	tok = s.queue[0]
+	s.queue = s.queue[1:]
+	return tok, nil
+}
+
+func (s *layoutStream) enqueue(terminal int, bytePos, row, col int) {
+	s.queue = append(s.queue, &layoutToken{
+		terminalID: terminal,
+		bytePos:    bytePos,
+		row:        row,
+		col:        col,
+	})
+}
+
+// layoutToken is a token that layoutStream synthesizes. It has no lexeme and is located at the token following it.
+type layoutToken struct {
+	terminalID int
+	invalid    bool
+	bytePos    int
+	row        int
+	col        int
+}
+
+func (t *layoutToken) TerminalID() int {
+	return t.terminalID
+}
+
+func (t *layoutToken) Lexeme() []byte {
+	return nil
+}
+
+func (t *layoutToken) EOF() bool {
+	return false
+}
+
+func (t *layoutToken) Invalid() bool {
+	return t.invalid
+}
+
+func (t *layoutToken) BytePosition() (int, int) {
+	return t.bytePos, 0
+}
+
+func (t *layoutToken) Position() (int, int) {
+	return t.row, t.col
 }
@@ -33,6 +33,11 @@ func nonTermNode(kind string, children ...*Node) *Node {
 	}
 }
 
+func labeledNode(label string, n *Node) *Node {
+	n.Label = label
+	return n
+}
+
 func TestParser_Parse(t *testing.T) {
 	tests := []struct {
 		specSrc string
@@ -124,6 +129,42 @@ id
 				),
 			),
 		},
+		// A lexical production can have alternatives consisting of string literals or patterns. It defines one
+		// terminal symbol matching any of them.
+		{
+			specSrc: `
+#name test;
+
+s
+    : s op bool
+    | bool
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+op
+    : '&&'
+    | '||'
+    ;
+bool
+    : 'true'
+    | 'false'
+    | "[01]"
+    ;
+`,
+			src: `true && 0 || false`,
+			cst: nonTermNode("s",
+				nonTermNode("s",
+					nonTermNode("s",
+						termNode("bool", "true"),
+					),
+					termNode("op", "&&"),
+					termNode("bool", "0"),
+				),
+				termNode("op", "||"),
+				termNode("bool", "false"),
+			),
+		},
 		// Fragments (\f{}), code point expressions (\u{}), and character property expressions (\p{}) are
 		// not allowed in string literals.
 		{
@@ -536,6 +577,155 @@ num
 				),
 			),
 		},
+		// The #rename directive renames a node, and the #lift directive replaces a node with one of its children.
+		{
+			specSrc: `
+#name test;
+
+#prec (
+    #left add sub
+);
+
+expr
+    : expr@lhs add expr@rhs #ast lhs rhs #rename add_expr
+    | expr@lhs sub expr@rhs #ast lhs rhs #rename sub_expr
+    | l_paren expr r_paren #lift expr
+    | num #lift num
+    ;
+
+l_paren
+    : '(';
+r_paren
+    : ')';
+add
+    : '+';
+sub
+    : '-';
+num
+    : "0|[1-9][0-9]*";
+`,
+			src: `1+(2-3)`,
+			ast: nonTermNode("add_expr",
+				termNode("num", "1"),
+				nonTermNode("sub_expr",
+					termNode("num", "2"),
+					termNode("num", "3"),
+				),
+			),
+		},
+		// The #rename and #lift directives don't affect a CST.
+		{
+			specSrc: `
+#name test;
+
+s
+    : l_paren foo r_paren #lift foo
+    | foo #rename bar
+    ;
+
+l_paren
+    : '(';
+r_paren
+    : ')';
+foo
+    : 'foo';
+`,
+			src: `(foo)`,
+			cst: nonTermNode("s",
+				termNode("l_paren", "("),
+				termNode("foo", "foo"),
+				termNode("r_paren", ")"),
+			),
+		},
+		// The #omit_punctuation directive omits punctuation terminals from AST nodes unless an alternative has #ast or #lift.
+		{
+			specSrc: `
+#name test;
+#omit_punctuation;
+
+s
+    : l_paren elems r_paren
+    | l_paren r_paren
+    ;
+elems
+    : elems comma id
+    | id
+    | elems semi_colon #ast elems semi_colon
+    ;
+
+l_paren
+    : '(';
+r_paren
+    : ')';
+comma
+    : ',';
+semi_colon
+    : ';';
+id
+    : "[a-z]+";
+`,
+			src: `(a,b;)`,
+			ast: nonTermNode("s",
+				nonTermNode("elems",
+					nonTermNode("elems",
+						nonTermNode("elems",
+							termNode("id", "a"),
+						),
+						termNode("id", "b"),
+					),
+					termNode("semi_colon", ";"),
+				),
+			),
+		},
+		// An AST node consisting only of punctuation terminals has no child.
+		{
+			specSrc: `
+#name test;
+#omit_punctuation;
+
+s
+    : l_paren r_paren
+    ;
+
+l_paren
+    : '(';
+r_paren
+    : ')';
+`,
+			src: `()`,
+			ast: nonTermNode("s"),
+		},
+		// The #dot_excludes_newline directive makes `.` match any character except a newline.
+		{
+			specSrc: `
+#name test;
+#dot_excludes_newline;
+
+s
+    : ids
+    ;
+ids
+    : ids id
+    | id
+    ;
+
+id
+    : "[a-z]+";
+comment #skip
+    : "//.*";
+ws #skip
+    : "[ \t\n]+";
+`,
+			src: "a // b\nc",
+			ast: nonTermNode("s",
+				nonTermNode("ids",
+					nonTermNode("ids",
+						termNode("id", "a"),
+					),
+					termNode("id", "c"),
+				),
+			),
+		},
 		// An AST can contain a symbol name, even if the symbol has a label. That is, unused labels are allowed.
 		{
 			specSrc: `
@@ -761,6 +951,91 @@ bar: 'bar';
 `,
 			src: `foo`,
 		},
+		// Invalid tokens become the terminal symbol a `#fallback` directive declares.
+		{
+			specSrc: `
+#name test;
+
+#fallback unknown;
+
+words
+    : words word
+    | word
+    ;
+word
+    : id
+    | unknown
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+id
+    : "[a-z]+";
+`,
+			src: `ab ?! cd`,
+			cst: nonTermNode("words",
+				nonTermNode("words",
+					nonTermNode("words",
+						nonTermNode("word",
+							termNode("id", "ab"),
+						),
+					),
+					nonTermNode("word",
+						termNode("unknown", "?!"),
+					),
+				),
+				nonTermNode("word",
+					termNode("id", "cd"),
+				),
+			),
+		},
+		// Nodes have the labels of the alternatives they come from in both CSTs and ASTs.
+		{
+			specSrc: `
+#name test;
+
+#prec (
+    #left mul
+    #left add
+);
+
+expr
+    : expr add expr #label add
+    | expr mul expr #label mul
+    | l_paren expr r_paren #ast expr #label group
+    | id
+    ;
+
+id
+    : "[a-z]+";
+add
+    : '+';
+mul
+    : '*';
+l_paren
+    : '(';
+r_paren
+    : ')';
+`,
+			src: `(a+b)*c`,
+			ast: labeledNode("mul", nonTermNode("expr",
+				labeledNode("group", nonTermNode("expr",
+					labeledNode("add", nonTermNode("expr",
+						nonTermNode("expr",
+							termNode("id", "a"),
+						),
+						termNode("add", "+"),
+						nonTermNode("expr",
+							termNode("id", "b"),
+						),
+					)),
+				)),
+				termNode("mul", "*"),
+				nonTermNode("expr",
+					termNode("id", "c"),
+				),
+			)),
+		},
 	}
 
 	for i, tt := range tests {
@@ -821,7 +1096,7 @@ bar: 'bar';
 func testTree(t *testing.T, node, expected *Node) {
 	t.Helper()
 
-	if node.Type != expected.Type || node.KindName != expected.KindName || node.Text != expected.Text {
+	if node.Type != expected.Type || node.KindName != expected.KindName || node.Text != expected.Text || node.Label != expected.Label {
 		t.Fatalf("unexpected node; want: %+v, got: %+v", expected, node)
 	}
 	if len(node.Children) != len(expected.Children) {
@@ -831,3 +1106,94 @@ func testTree(t *testing.T, node, expected *Node) {
 		testTree(t, c, expected.Children[i])
 	}
 }
+
+func FuzzParseWithGrammar(f *testing.F) {
+	f.Add(`
+#name test;
+
+#prec (
+    #left mul
+    #left add
+);
+
+expr
+    : expr add expr
+    | expr mul expr
+    | l_paren expr r_paren #ast expr
+    | id
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+add
+    : '+';
+mul
+    : '*';
+l_paren
+    : '(';
+r_paren
+    : ')';
+id
+    : "[a-z]+";
+`, "(a + b) * c")
+	f.Add(`
+#name test;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq id semi
+    | error semi #recover
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+eq
+    : '=';
+semi
+    : ';';
+id
+    : "[a-z]+";
+`, "a = b; c = ; d = e;")
+	f.Add(`
+#name test;
+
+s
+    : str_open str_body str_close
+    ;
+
+str_open #push str
+    : '"';
+str_body #mode str
+    : "[^\"]*";
+str_close #mode str #pop
+    : '"';
+`, `"abc"`)
+
+	f.Fuzz(func(t *testing.T, specSrc string, src string) {
+		ast, err := parser.Parse(strings.NewReader(specSrc))
+		if err != nil {
+			return
+		}
+		b := grammar.GrammarBuilder{
+			AST: ast,
+		}
+		cg, _, err := b.Build()
+		if err != nil || cg.IsLexerOnly() {
+			return
+		}
+		gram := NewGrammar(cg)
+		toks, err := NewTokenStream(cg, strings.NewReader(src))
+		if err != nil {
+			return
+		}
+		// The limits keep grammars whose conflicts make the parser reduce forever from hanging the fuzzer.
+		p, err := NewParser(toks, gram, SemanticAction(NewASTActionSet(gram, NewDefaultSyntaxTreeBuilder())), MaxStackDepth(1000), MaxTokens(1000))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = p.Parse()
+	})
+}
@@ -0,0 +1,182 @@
+// Package pretty prints syntax trees that the parser builds in the same format as `vartan parse`.
+//
+// By default, Fprint renders a tree exactly as parser.PrintTree does:
+//
+//	expr
+//	├─ expr
+//	│  └─ id "a"
+//	├─ add "+"
+//	└─ id "b"
+//
+// Options limit the depth of a tree, elide the lexemes of tokens, color the output with ANSI escape sequences, and
+// replace the text of nodes with the one a custom renderer returns.
+package pretty
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nihei9/vartan/driver/parser"
+)
+
+// ANSI escape sequences Fprint uses when the Color option is enabled.
+const (
+	colorReset   = "\x1b[0m"
+	colorError   = "\x1b[31m"
+	colorText    = "\x1b[32m"
+	colorTerm    = "\x1b[36m"
+	colorNonTerm = "\x1b[1m"
+	colorLabel   = "\x1b[35m"
+	colorLine    = "\x1b[2m"
+)
+
+// Renderer returns the text of a node, which Fprint prints after the ruled lines. The text must not contain new lines.
+type Renderer func(n *parser.Node) string
+
+type printer struct {
+	maxDepth  int
+	elideText bool
+	color     bool
+	render    Renderer
+}
+
+// Option is an option of Fprint and Sprint.
+type Option func(p *printer) error
+
+// MaxDepth limits the depth of nodes Fprint prints to `depth`. The root has depth 1. In place of the children of a node
+// at the maximum depth, Fprint prints `...` and the number of the elided nodes. The depth must be greater than 0.
+func MaxDepth(depth int) Option {
+	return func(p *printer) error {
+		if depth <= 0 {
+			return fmt.Errorf("the maximum depth must be greater than 0: %v", depth)
+		}
+		p.maxDepth = depth
+		return nil
+	}
+}
+
+// ElideText makes Fprint print only the kind names of terminal nodes without their lexemes.
+func ElideText() Option {
+	return func(p *printer) error {
+		p.elideText = true
+		return nil
+	}
+}
+
+// Color makes Fprint color kind names, lexemes, labels, and ruled lines with ANSI escape sequences. The colors apply
+// only to the text Fprint renders by default, not to the text a custom renderer returns.
+func Color() Option {
+	return func(p *printer) error {
+		p.color = true
+		return nil
+	}
+}
+
+// Render makes Fprint print the text `render` returns for each node instead of the default text. The ruled lines and
+// the MaxDepth option still apply.
+func Render(render Renderer) Option {
+	return func(p *printer) error {
+		if render == nil {
+			return fmt.Errorf("a renderer must not be nil")
+		}
+		p.render = render
+		return nil
+	}
+}
+
+// Fprint prints a syntax tree whose root is `root` to `w`. When `root` is nil, Fprint prints nothing.
+func Fprint(w io.Writer, root *parser.Node, opts ...Option) error {
+	p := &printer{}
+	for _, opt := range opts {
+		err := opt(p)
+		if err != nil {
+			return err
+		}
+	}
+	if root == nil {
+		return nil
+	}
+	return p.print(w, root, 1, "", "")
+}
+
+// Sprint returns a syntax tree whose root is `root` in the format of Fprint.
+func Sprint(root *parser.Node, opts ...Option) (string, error) {
+	var b strings.Builder
+	err := Fprint(&b, root, opts...)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (p *printer) print(w io.Writer, n *parser.Node, depth int, ruledLine string, childRuledLinePrefix string) error {
+	_, err := fmt.Fprintf(w, "%v%v\n", p.paint(colorLine, ruledLine), p.text(n))
+	if err != nil {
+		return err
+	}
+	if n.Type != parser.NodeTypeNonTerminal || len(n.Children) == 0 {
+		return nil
+	}
+
+	if p.maxDepth > 0 && depth >= p.maxDepth {
+		elided := "... (1 node)"
+		if count := countDescendants(n); count > 1 {
+			elided = fmt.Sprintf("... (%v nodes)", count)
+		}
+		_, err := fmt.Fprintf(w, "%v\n", p.paint(colorLine, childRuledLinePrefix+"└─ "+elided))
+		return err
+	}
+
+	num := len(n.Children)
+	for i, c := range n.Children {
+		line := "└─ "
+		prefix := "   "
+		if i < num-1 {
+			line = "├─ "
+			prefix = "│  "
+		}
+		err := p.print(w, c, depth+1, childRuledLinePrefix+line, childRuledLinePrefix+prefix)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *printer) text(n *parser.Node) string {
+	if p.render != nil {
+		return p.render(n)
+	}
+
+	switch n.Type {
+	case parser.NodeTypeError:
+		return p.paint(colorError, n.KindName)
+	case parser.NodeTypeTerminal:
+		if p.elideText {
+			return p.paint(colorTerm, n.KindName)
+		}
+		return fmt.Sprintf("%v %v", p.paint(colorTerm, n.KindName), p.paint(colorText, strconv.Quote(n.Text)))
+	default:
+		if n.Label != "" {
+			return fmt.Sprintf("%v %v", p.paint(colorNonTerm, n.KindName), p.paint(colorLabel, "@"+n.Label))
+		}
+		return p.paint(colorNonTerm, n.KindName)
+	}
+}
+
+func (p *printer) paint(color string, s string) string {
+	if !p.color || s == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func countDescendants(n *parser.Node) int {
+	count := 0
+	for _, c := range n.Children {
+		count += 1 + countDescendants(c)
+	}
+	return count
+}
@@ -0,0 +1,128 @@
+package pretty
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/driver/parser"
+)
+
+func termNode(kind string, text string) *parser.Node {
+	return &parser.Node{
+		Type:     parser.NodeTypeTerminal,
+		KindName: kind,
+		Text:     text,
+	}
+}
+
+func nonTermNode(kind string, children ...*parser.Node) *parser.Node {
+	return &parser.Node{
+		Type:     parser.NodeTypeNonTerminal,
+		KindName: kind,
+		Children: children,
+	}
+}
+
+// genTree returns a tree of `a + (b * 1`.
+func genTree() *parser.Node {
+	mul := nonTermNode("term",
+		termNode("id", "b"),
+		termNode("mul", "*"),
+		termNode("int", "1"),
+		&parser.Node{
+			Type:     parser.NodeTypeError,
+			KindName: "error",
+		},
+	)
+	mul.Label = "mul"
+	return nonTermNode("expr",
+		nonTermNode("expr",
+			termNode("id", "a"),
+		),
+		termNode("add", "+"),
+		mul,
+	)
+}
+
+func TestFprint(t *testing.T) {
+	tests := []struct {
+		caption  string
+		opts     []Option
+		expected string
+	}{
+		{
+			caption: "Fprint prints a tree in the same format as parser.PrintTree by default",
+			expected: func() string {
+				var b strings.Builder
+				parser.PrintTree(&b, genTree())
+				return b.String()
+			}(),
+		},
+		{
+			caption: "MaxDepth elides nodes deeper than the maximum depth",
+			opts:    []Option{MaxDepth(2)},
+			expected: `expr
+├─ expr
+│  └─ ... (1 node)
+├─ add "+"
+└─ term @mul
+   └─ ... (4 nodes)
+`,
+		},
+		{
+			caption: "ElideText omits lexemes",
+			opts:    []Option{ElideText(), MaxDepth(2)},
+			expected: `expr
+├─ expr
+│  └─ ... (1 node)
+├─ add
+└─ term @mul
+   └─ ... (4 nodes)
+`,
+		},
+		{
+			caption: "Render replaces the text of nodes",
+			opts: []Option{
+				Render(func(n *parser.Node) string {
+					return strings.ToUpper(n.KindName)
+				}),
+			},
+			expected: `EXPR
+├─ EXPR
+│  └─ ID
+├─ ADD
+└─ TERM
+   ├─ ID
+   ├─ MUL
+   ├─ INT
+   └─ ERROR
+`,
+		},
+		{
+			caption: "Color colors the output",
+			opts:    []Option{Color(), MaxDepth(1)},
+			expected: "\x1b[1mexpr\x1b[0m\n" +
+				"\x1b[2m└─ ... (8 nodes)\x1b[0m\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			s, err := Sprint(genTree(), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != tt.expected {
+				t.Fatalf("unexpected output;\nwant:\n%q\ngot:\n%q", tt.expected, s)
+			}
+		})
+	}
+
+	t.Run("invalid options cause an error", func(t *testing.T) {
+		for _, opt := range []Option{MaxDepth(0), Render(nil)} {
+			_, err := Sprint(genTree(), opt)
+			if err == nil {
+				t.Fatal("an error must occur")
+			}
+		}
+	})
+}
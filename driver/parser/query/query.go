@@ -0,0 +1,360 @@
+// Package query evaluates XPath-like path expressions against syntax trees.
+//
+// A path consists of steps separated by `/` or `//`. `/` selects children of the current nodes, and `//` selects
+// descendants of them. A step is a kind name or `*` matching any kind, optionally followed by predicates in
+// brackets. A path beginning with `/` starts at the root of a tree, so `/expr` selects the root only when its kind
+// is `expr`. Any other path searches the whole tree as if it began with `//`.
+//
+// A predicate is one of the following forms:
+//
+//   - [N] selects the N-th (1-origin) node among the nodes a step selects from one node.
+//   - [path] selects nodes having at least one node the relative path selects.
+//   - [path='text'] selects nodes having at least one node the relative path selects and whose text equals the string.
+//
+// A relative path in a predicate can begin with an axis, `child::` (the default) or `descendant::`, and can end with
+// `text()` that refers to the node itself. The text of a node is the concatenation of the texts of its terminal
+// descendants. For instance, `//assignment[child::id='x']` selects `assignment` nodes having an `id` child whose
+// text is `x`, and `//id[text()='x']` selects `id` nodes whose text is `x`.
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nihei9/vartan/driver/parser"
+)
+
+type axis int
+
+const (
+	axisChild axis = iota
+	axisDescendant
+	axisSelf
+)
+
+type step struct {
+	axis  axis
+	kind  string
+	preds []*predicate
+}
+
+type predicate struct {
+	// When pos is greater than 0, the predicate is a positional one.
+	pos   int
+	path  []*step
+	value *string
+}
+
+// Query is a compiled path expression.
+type Query struct {
+	absolute bool
+	steps    []*step
+}
+
+// Compile compiles a path expression into a query.
+func Compile(expr string) (*Query, error) {
+	p := &exprParser{
+		src: expr,
+	}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %v: %w", expr, err)
+	}
+	return q, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid.
+func MustCompile(expr string) *Query {
+	q, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// Eval returns nodes the query selects from a tree in pre-order.
+func (q *Query) Eval(root *parser.Node) []*parser.Node {
+	if root == nil {
+		return nil
+	}
+
+	// The virtual root makes the actual root selectable by the first step.
+	vRoot := &parser.Node{
+		Type:     parser.NodeTypeNonTerminal,
+		Children: []*parser.Node{root},
+	}
+	steps := q.steps
+	if !q.absolute && steps[0].axis == axisChild {
+		s := *steps[0]
+		s.axis = axisDescendant
+		steps = append([]*step{&s}, steps[1:]...)
+	}
+	nodes := evalSteps([]*parser.Node{vRoot}, steps)
+
+	order := map[*parser.Node]int{}
+	var index func(n *parser.Node)
+	index = func(n *parser.Node) {
+		order[n] = len(order)
+		for _, c := range n.Children {
+			index(c)
+		}
+	}
+	index(root)
+	sort.Slice(nodes, func(i, j int) bool {
+		return order[nodes[i]] < order[nodes[j]]
+	})
+	return nodes
+}
+
+// Text returns the concatenation of the texts of terminal nodes in a tree.
+func Text(n *parser.Node) string {
+	var b strings.Builder
+	writeText(&b, n)
+	return b.String()
+}
+
+func writeText(b *strings.Builder, n *parser.Node) {
+	if n.Type == parser.NodeTypeTerminal {
+		b.WriteString(n.Text)
+		return
+	}
+	for _, c := range n.Children {
+		writeText(b, c)
+	}
+}
+
+func evalSteps(ctx []*parser.Node, steps []*step) []*parser.Node {
+	for _, s := range steps {
+		var next []*parser.Node
+		selected := map[*parser.Node]struct{}{}
+		for _, n := range ctx {
+			for _, m := range evalStep(n, s) {
+				if _, ok := selected[m]; ok {
+					continue
+				}
+				selected[m] = struct{}{}
+				next = append(next, m)
+			}
+		}
+		ctx = next
+	}
+	return ctx
+}
+
+func evalStep(n *parser.Node, s *step) []*parser.Node {
+	var candidates []*parser.Node
+	switch s.axis {
+	case axisSelf:
+		candidates = []*parser.Node{n}
+	case axisChild:
+		for _, c := range n.Children {
+			if matchKind(c, s.kind) {
+				candidates = append(candidates, c)
+			}
+		}
+	case axisDescendant:
+		var collect func(n *parser.Node)
+		collect = func(n *parser.Node) {
+			for _, c := range n.Children {
+				if matchKind(c, s.kind) {
+					candidates = append(candidates, c)
+				}
+				collect(c)
+			}
+		}
+		collect(n)
+	}
+
+	for _, pred := range s.preds {
+		var filtered []*parser.Node
+		for i, c := range candidates {
+			if pred.pos > 0 {
+				if i+1 == pred.pos {
+					filtered = append(filtered, c)
+				}
+				continue
+			}
+			for _, m := range evalSteps([]*parser.Node{c}, pred.path) {
+				if pred.value == nil || Text(m) == *pred.value {
+					filtered = append(filtered, c)
+					break
+				}
+			}
+		}
+		candidates = filtered
+	}
+	return candidates
+}
+
+func matchKind(n *parser.Node, kind string) bool {
+	return kind == "*" || n.KindName == kind
+}
+
+type exprParser struct {
+	src string
+	pos int
+}
+
+func (p *exprParser) parseQuery() (*Query, error) {
+	q := &Query{}
+	ax := axisChild
+	switch {
+	case p.consume("//"):
+		q.absolute = true
+		ax = axisDescendant
+	case p.consume("/"):
+		q.absolute = true
+	}
+	steps, err := p.parsePath(ax, false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected character at %v: %q", p.pos+1, p.src[p.pos])
+	}
+	q.steps = steps
+	return q, nil
+}
+
+// parsePath parses steps. When inPred is true, the path can begin with an axis and end with `text()`.
+func (p *exprParser) parsePath(ax axis, inPred bool) ([]*step, error) {
+	var steps []*step
+	for {
+		if inPred && len(steps) == 0 {
+			switch {
+			case p.consume("child::"):
+				ax = axisChild
+			case p.consume("descendant::"):
+				ax = axisDescendant
+			}
+		}
+		if inPred && p.consume("text()") {
+			steps = append(steps, &step{
+				axis: axisSelf,
+			})
+			return steps, nil
+		}
+
+		s, err := p.parseStep(ax)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, s)
+
+		switch {
+		case p.consume("//"):
+			ax = axisDescendant
+		case p.consume("/"):
+			ax = axisChild
+		default:
+			return steps, nil
+		}
+	}
+}
+
+func (p *exprParser) parseStep(ax axis) (*step, error) {
+	var kind string
+	if p.consume("*") {
+		kind = "*"
+	} else {
+		start := p.pos
+		for p.pos < len(p.src) && isIDChar(p.src[p.pos]) {
+			p.pos++
+		}
+		if p.pos == start {
+			return nil, p.errorf("a kind name or `*` is expected")
+		}
+		kind = p.src[start:p.pos]
+	}
+
+	s := &step{
+		axis: ax,
+		kind: kind,
+	}
+	for p.consume("[") {
+		pred, err := p.parsePredicate()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume("]") {
+			return nil, p.errorf("`]` is expected")
+		}
+		s.preds = append(s.preds, pred)
+	}
+	return s, nil
+}
+
+func (p *exprParser) parsePredicate() (*predicate, error) {
+	p.skipSpaces()
+	if p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		pos, err := strconv.Atoi(p.src[start:p.pos])
+		if err != nil || pos == 0 {
+			return nil, fmt.Errorf("a position must be a positive integer: %v", p.src[start:p.pos])
+		}
+		p.skipSpaces()
+		return &predicate{
+			pos: pos,
+		}, nil
+	}
+
+	path, err := p.parsePath(axisChild, true)
+	if err != nil {
+		return nil, err
+	}
+	pred := &predicate{
+		path: path,
+	}
+	p.skipSpaces()
+	if p.consume("=") {
+		p.skipSpaces()
+		v, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		pred.value = &v
+		p.skipSpaces()
+	}
+	return pred, nil
+}
+
+func (p *exprParser) parseString() (string, error) {
+	if p.pos >= len(p.src) || (p.src[p.pos] != '\'' && p.src[p.pos] != '"') {
+		return "", p.errorf("a string literal is expected")
+	}
+	quote := p.src[p.pos]
+	end := strings.IndexByte(p.src[p.pos+1:], quote)
+	if end < 0 {
+		return "", p.errorf("unclosed string literal")
+	}
+	v := p.src[p.pos+1 : p.pos+1+end]
+	p.pos += end + 2
+	return v, nil
+}
+
+func (p *exprParser) consume(s string) bool {
+	if !strings.HasPrefix(p.src[p.pos:], s) {
+		return false
+	}
+	p.pos += len(s)
+	return true
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *exprParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("%v at %v", fmt.Sprintf(format, a...), p.pos+1)
+}
+
+func isIDChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
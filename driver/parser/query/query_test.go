@@ -0,0 +1,148 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nihei9/vartan/driver/parser"
+)
+
+func termNode(kind string, text string) *parser.Node {
+	return &parser.Node{
+		Type:     parser.NodeTypeTerminal,
+		KindName: kind,
+		Text:     text,
+	}
+}
+
+func nonTermNode(kind string, children ...*parser.Node) *parser.Node {
+	return &parser.Node{
+		Type:     parser.NodeTypeNonTerminal,
+		KindName: kind,
+		Children: children,
+	}
+}
+
+// genTree returns a tree of `x = 1; y = x + 2;`.
+func genTree() *parser.Node {
+	return nonTermNode("program",
+		nonTermNode("assignment",
+			termNode("id", "x"),
+			termNode("eq", "="),
+			nonTermNode("expr",
+				termNode("int", "1"),
+			),
+		),
+		nonTermNode("assignment",
+			termNode("id", "y"),
+			termNode("eq", "="),
+			nonTermNode("expr",
+				nonTermNode("expr",
+					termNode("id", "x"),
+				),
+				termNode("add", "+"),
+				nonTermNode("expr",
+					termNode("int", "2"),
+				),
+			),
+		),
+	)
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{
+			expr:     "//id",
+			expected: []string{"x", "y", "x"},
+		},
+		{
+			expr:     "id",
+			expected: []string{"x", "y", "x"},
+		},
+		{
+			expr:     "/program/assignment/id",
+			expected: []string{"x", "y"},
+		},
+		{
+			expr: "/assignment",
+		},
+		{
+			expr:     "/program//int",
+			expected: []string{"1", "2"},
+		},
+		{
+			expr:     "//assignment[child::id='x']",
+			expected: []string{"x=1"},
+		},
+		{
+			expr:     "//assignment[id = \"y\"]/expr",
+			expected: []string{"x+2"},
+		},
+		{
+			expr:     "//assignment[descendant::id='x']/id",
+			expected: []string{"x", "y"},
+		},
+		{
+			expr:     "//id[text()='x']",
+			expected: []string{"x", "x"},
+		},
+		{
+			expr:     "//expr[expr/text()='x']",
+			expected: []string{"x+2"},
+		},
+		{
+			expr:     "//assignment[2]/id",
+			expected: []string{"y"},
+		},
+		{
+			expr:     "//expr[expr][add]",
+			expected: []string{"x+2"},
+		},
+		{
+			expr:     "assignment/*[3]",
+			expected: []string{"1", "x+2"},
+		},
+		{
+			expr:     "//expr//expr",
+			expected: []string{"x", "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			q, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var texts []string
+			for _, n := range q.Eval(genTree()) {
+				texts = append(texts, Text(n))
+			}
+			if !reflect.DeepEqual(texts, tt.expected) {
+				t.Fatalf("unexpected nodes: want: %v, got: %v", tt.expected, texts)
+			}
+		})
+	}
+}
+
+func TestCompile_Error(t *testing.T) {
+	exprs := []string{
+		"",
+		"/",
+		"//id[",
+		"//id[0]",
+		"//id[text()='x]",
+		"//id]",
+		"//id/",
+	}
+	for _, expr := range exprs {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Compile(expr)
+			if err == nil {
+				t.Fatalf("an error must occur")
+			}
+		})
+	}
+}
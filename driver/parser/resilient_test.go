@@ -0,0 +1,371 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestParserInResilientMode(t *testing.T) {
+	specSrc := `
+#name test;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq expr semi
+    | l_brace stmts r_brace
+    ;
+expr
+    : expr add term
+    | term
+    ;
+term
+    : id
+    | num
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+eq
+    : '=';
+semi
+    : ';';
+add
+    : '+';
+l_brace
+    : '{';
+r_brace
+    : '}';
+id
+    : "[a-z]+";
+num
+    : "[0-9]+";
+`
+
+	assign := func(id string, expr *Node, extra ...*Node) *Node {
+		children := []*Node{
+			termNode("id", id),
+			termNode("eq", "="),
+			expr,
+		}
+		children = append(children, extra...)
+		children = append(children, termNode("semi", ";"))
+		return nonTermNode("stmt", children...)
+	}
+	num := func(text string, extra ...*Node) *Node {
+		children := append(extra, termNode("num", text))
+		return nonTermNode("expr",
+			nonTermNode("term", children...),
+		)
+	}
+	errNode := func(children ...*Node) *Node {
+		return nonTermNode("error", children...)
+	}
+
+	tests := []struct {
+		caption string
+		src     string
+		sync    []string
+		synErrs int
+		cst     *Node
+	}{
+		{
+			caption: "the parser resumes at the token following the skipped token",
+			src:     `a = 1; b = + 2;`,
+			synErrs: 1,
+			cst: nonTermNode("stmts",
+				nonTermNode("stmts",
+					assign("a", num("1")),
+				),
+				assign("b", num("2", errNode(termNode("add", "+")))),
+			),
+		},
+		{
+			caption: "the parser resumes at a sync token discarding states",
+			src:     `a = 1 + ; b = 2;`,
+			sync:    []string{"semi"},
+			synErrs: 1,
+			cst: nonTermNode("stmts",
+				nonTermNode("stmts",
+					assign("a", num("1"), errNode(termNode("add", "+"))),
+				),
+				assign("b", num("2")),
+			),
+		},
+		{
+			caption: "the parser skips tokens until a sync token",
+			src:     `+ a = 1; b = 2;`,
+			sync:    []string{"semi"},
+			synErrs: 1,
+			cst: nonTermNode("stmts",
+				nonTermNode("stmt",
+					errNode(
+						termNode("add", "+"),
+						termNode("id", "a"),
+						termNode("eq", "="),
+						termNode("num", "1"),
+						termNode("semi", ";"),
+					),
+					termNode("id", "b"),
+					termNode("eq", "="),
+					num("2"),
+					termNode("semi", ";"),
+				),
+			),
+		},
+		{
+			caption: "the parser finishes parsing at the end of an input",
+			src:     `a = 1; b = 2`,
+			sync:    []string{"semi"},
+			synErrs: 1,
+			cst: nonTermNode("stmts",
+				nonTermNode("stmts",
+					assign("a", num("1")),
+				),
+				errNode(
+					termNode("id", "b"),
+					termNode("eq", "="),
+					termNode("num", "2"),
+				),
+			),
+		},
+		{
+			caption: "the parser generates an empty error node when an input ends unexpectedly",
+			src:     `a = 1 +`,
+			synErrs: 1,
+			cst: nonTermNode("stmts",
+				termNode("id", "a"),
+				termNode("eq", "="),
+				num("1"),
+				termNode("add", "+"),
+				errorNode(),
+			),
+		},
+		{
+			caption: "the parser accepts a valid input as usual",
+			src:     `a = 1; { b = 2; }`,
+			sync:    []string{"semi", "r_brace"},
+			cst: nonTermNode("stmts",
+				nonTermNode("stmts",
+					assign("a", num("1")),
+				),
+				nonTermNode("stmt",
+					termNode("l_brace", "{"),
+					nonTermNode("stmts",
+						assign("b", num("2")),
+					),
+					termNode("r_brace", "}"),
+				),
+			),
+		},
+	}
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("#%v %v", i, tt.caption), func(t *testing.T) {
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gram := NewGrammar(cg)
+			tb := NewDefaultSyntaxTreeBuilder()
+			p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)), Resilient(tt.sync...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(p.SyntaxErrors()) != tt.synErrs {
+				t.Fatalf("unexpected syntax error count; want: %v, got: %v", tt.synErrs, len(p.SyntaxErrors()))
+			}
+			testTree(t, tb.Tree(), tt.cst)
+		})
+	}
+
+	t.Run("a sync terminal must be defined in a grammar", func(t *testing.T) {
+		toks, err := NewTokenStream(cg, strings.NewReader(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = NewParser(toks, NewGrammar(cg), Resilient("foo"))
+		if err == nil {
+			t.Fatal("an error must occur")
+		}
+	})
+
+	t.Run("a semantic action set must implement ResilientSemanticActionSet", func(t *testing.T) {
+		toks, err := NewTokenStream(cg, strings.NewReader(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = NewParser(toks, NewGrammar(cg), SemanticAction(&testSemAct{}), Resilient())
+		if err == nil {
+			t.Fatal("an error must occur")
+		}
+	})
+}
+
+func TestParserInResilientModeWithErrorSymbol(t *testing.T) {
+	specSrc := `
+#name test;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq num semi
+    | error semi #recover
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+eq
+    : '=';
+semi
+    : ';';
+id
+    : "[a-z]+";
+num
+    : "[0-9]+";
+`
+
+	assign := func(id, num string) *Node {
+		return nonTermNode("stmt",
+			termNode("id", id),
+			termNode("eq", "="),
+			termNode("num", num),
+			termNode("semi", ";"),
+		)
+	}
+	errNode := func(children ...*Node) *Node {
+		return nonTermNode("error", children...)
+	}
+
+	tests := []struct {
+		caption string
+		src     string
+		sync    []string
+		synErrs int
+		cst     *Node
+	}{
+		{
+			caption: "the error symbol keeps the nodes that the parser discards to trap a syntax error",
+			src:     `a; b = 2;`,
+			sync:    []string{"semi"},
+			synErrs: 1,
+			cst: nonTermNode("stmts",
+				nonTermNode("stmts",
+					nonTermNode("stmt",
+						errNode(
+							termNode("id", "a"),
+						),
+						termNode("semi", ";"),
+					),
+				),
+				assign("b", "2"),
+			),
+		},
+		{
+			caption: "an input ending after a partial statement doesn't lose the tokens of the statement",
+			src:     `a = 1; b = 2`,
+			sync:    []string{"semi"},
+			synErrs: 1,
+			cst: nonTermNode("stmts",
+				nonTermNode("stmts",
+					assign("a", "1"),
+				),
+				errNode(
+					termNode("id", "b"),
+					termNode("eq", "="),
+					termNode("num", "2"),
+				),
+			),
+		},
+		{
+			caption: "an input ending right after a trapped syntax error has a single empty error node",
+			src:     `=`,
+			sync:    []string{"semi"},
+			synErrs: 1,
+			cst: nonTermNode("stmts",
+				errorNode(),
+			),
+		},
+		{
+			caption: "the error symbol trapping a syntax error at the end of an input keeps the tokens of a statement",
+			src:     `a = 1; =`,
+			sync:    []string{"semi"},
+			synErrs: 1,
+			cst: nonTermNode("stmts",
+				errNode(
+					termNode("id", "a"),
+					termNode("eq", "="),
+					termNode("num", "1"),
+					termNode("semi", ";"),
+				),
+			),
+		},
+	}
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("#%v %v", i, tt.caption), func(t *testing.T) {
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gram := NewGrammar(cg)
+			tb := NewDefaultSyntaxTreeBuilder()
+			p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)), Resilient(tt.sync...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(p.SyntaxErrors()) != tt.synErrs {
+				t.Fatalf("unexpected syntax error count; want: %v, got: %v", tt.synErrs, len(p.SyntaxErrors()))
+			}
+			testTree(t, tb.Tree(), tt.cst)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 )
 
 // SemanticActionSet is a set of semantic actions a parser calls.
@@ -31,7 +32,26 @@ type SemanticActionSet interface {
 	MissError(cause VToken)
 }
 
-var _ SemanticActionSet = &SyntaxTreeActionSet{}
+// ResilientSemanticActionSet is a SemanticActionSet that a parser in the resilient mode can use.
+type ResilientSemanticActionSet interface {
+	SemanticActionSet
+
+	// Resync runs when the parser in the resilient mode resumes parsing after a syntax error that the error symbol
+	// cannot trap. `cause` is a token that caused a syntax error. `popped` is the number of frames that the parser
+	// discards from the state stack, and `skipped` is tokens that the parser skips.
+	Resync(cause VToken, popped int, skipped []VToken)
+
+	// TrapAndShiftErrorKeepingNodes runs instead of TrapAndShiftError in the resilient mode. Unlike
+	// TrapAndShiftError, the error symbol keeps the nodes of the frames that the parser discards.
+	TrapAndShiftErrorKeepingNodes(cause VToken, popped int)
+
+	// AcceptPartially runs instead of Accept when the parser in the resilient mode finishes parsing an input after
+	// resynchronizing, whether the parser reaches the end of the input without accepting it or not. `startProd` is
+	// the start production of the entry point the parser started at.
+	AcceptPartially(startProd int)
+}
+
+var _ ResilientSemanticActionSet = &SyntaxTreeActionSet{}
 
 // SyntaxTreeNode is a node of a syntax tree. A node type used in SyntaxTreeActionSet must implement SyntaxTreeNode interface.
 type SyntaxTreeNode interface {
@@ -55,11 +75,33 @@ type SyntaxTreeBuilder interface {
 	Accept(f SyntaxTreeNode)
 }
 
-var _ SyntaxTreeBuilder = &DefaultSyntaxTreeBuilder{}
+// LabeledSyntaxTreeBuilder is a SyntaxTreeBuilder that receives the labels of alternatives. When a builder implements
+// this interface, SyntaxTreeActionSet calls ReduceLabeled instead of Reduce to construct the nodes of non-terminal
+// symbols. `label` is the label that a `#label` directive gives the reduced alternative, or an empty string.
+type LabeledSyntaxTreeBuilder interface {
+	SyntaxTreeBuilder
+	ReduceLabeled(kindName string, label string, children []SyntaxTreeNode) SyntaxTreeNode
+}
+
+// AppendingSyntaxTreeBuilder is a LabeledSyntaxTreeBuilder that appends children to an existing node. When an `#ast`
+// directive expands the first symbol of an alternative and no other element refers to the symbol, as a left-recursive
+// list does with `#ast elems... elem`, SyntaxTreeActionSet calls ReduceAppending instead of ReduceLabeled, so building
+// a list takes time linear in its length instead of copying the elements collected so far on every reduction.
+type AppendingSyntaxTreeBuilder interface {
+	LabeledSyntaxTreeBuilder
+
+	// ReduceAppending returns a node having the children of `node` followed by `children` like ReduceLabeled does.
+	// `node` is a node the builder has constructed, and nothing but the returned node refers to it afterwards, so the
+	// builder can reuse it.
+	ReduceAppending(node SyntaxTreeNode, kindName string, label string, children []SyntaxTreeNode) SyntaxTreeNode
+}
+
+var _ AppendingSyntaxTreeBuilder = &DefaultSyntaxTreeBuilder{}
 
 // DefaultSyntaxTreeBuilder is a implementation of SyntaxTreeBuilder.
 type DefaultSyntaxTreeBuilder struct {
-	tree *Node
+	tree  *Node
+	arena *NodeArena
 }
 
 // NewDefaultSyntaxTreeBuilder returns a new DefaultSyntaxTreeBuilder.
@@ -67,45 +109,119 @@ func NewDefaultSyntaxTreeBuilder() *DefaultSyntaxTreeBuilder {
 	return &DefaultSyntaxTreeBuilder{}
 }
 
+// NewArenaSyntaxTreeBuilder returns a new DefaultSyntaxTreeBuilder that allocates nodes from an arena `arena`. Builders
+// sharing an arena must not run concurrently.
+func NewArenaSyntaxTreeBuilder(arena *NodeArena) *DefaultSyntaxTreeBuilder {
+	return &DefaultSyntaxTreeBuilder{
+		arena: arena,
+	}
+}
+
 // Shift is a implementation of SyntaxTreeBuilder.Shift.
 func (b *DefaultSyntaxTreeBuilder) Shift(kindName string, tok VToken) SyntaxTreeNode {
 	bytePos, byteLen := tok.BytePosition()
 	row, col := tok.Position()
-	return &Node{
-		Type:     NodeTypeTerminal,
-		KindName: kindName,
-		Text:     string(tok.Lexeme()),
-		BytePos:  bytePos,
-		ByteLen:  byteLen,
-		Row:      row,
-		Col:      col,
-	}
+	n := b.nodeArena().node()
+	n.Type = NodeTypeTerminal
+	n.KindName = kindName
+	n.Text = b.nodeArena().text(tok.Lexeme())
+	n.BytePos = bytePos
+	n.ByteLen = byteLen
+	n.Row = row
+	n.Col = col
+	return n
 }
 
 // ShiftError is a implementation of SyntaxTreeBuilder.ShiftError.
 func (b *DefaultSyntaxTreeBuilder) ShiftError(kindName string) SyntaxTreeNode {
-	return &Node{
+	n := b.nodeArena().node()
+	*n = Node{
 		Type:     NodeTypeError,
 		KindName: kindName,
 	}
+	return n
 }
 
 // Reduce is a implementation of SyntaxTreeBuilder.Reduce.
 func (b *DefaultSyntaxTreeBuilder) Reduce(kindName string, children []SyntaxTreeNode) SyntaxTreeNode {
-	cNodes := make([]*Node, len(children))
+	return b.ReduceLabeled(kindName, "", children)
+}
+
+// ReduceLabeled is a implementation of LabeledSyntaxTreeBuilder.ReduceLabeled.
+func (b *DefaultSyntaxTreeBuilder) ReduceLabeled(kindName string, label string, children []SyntaxTreeNode) SyntaxTreeNode {
+	cNodes := b.nodeArena().children(len(children))
 	for i, c := range children {
 		cNodes[i] = c.(*Node)
 	}
-	return &Node{
+	n := b.nodeArena().node()
+	n.Type = NodeTypeNonTerminal
+	n.KindName = kindName
+	n.Label = label
+	n.Children = cNodes
+	return n
+}
+
+// ReduceAppending is a implementation of AppendingSyntaxTreeBuilder.ReduceAppending. This method turns `node` into
+// the new node, and the capacity of the children grows geometrically.
+func (b *DefaultSyntaxTreeBuilder) ReduceAppending(node SyntaxTreeNode, kindName string, label string, children []SyntaxTreeNode) SyntaxTreeNode {
+	n := node.(*Node)
+	cNodes := n.Children
+	for _, c := range children {
+		cNodes = append(cNodes, c.(*Node))
+	}
+	*n = Node{
 		Type:     NodeTypeNonTerminal,
 		KindName: kindName,
+		Label:    label,
 		Children: cNodes,
 	}
+	return n
 }
 
-// Accept is a implementation of SyntaxTreeBuilder.Accept.
+// Accept is a implementation of SyntaxTreeBuilder.Accept. This method numbers the nodes of the tree and computes the
+// byte ranges of the non-terminal nodes and the error nodes.
 func (b *DefaultSyntaxTreeBuilder) Accept(f SyntaxTreeNode) {
 	b.tree = f.(*Node)
+	index := 0
+	end := 0
+	numberNodes(b.tree, &index, &end)
+}
+
+// numberNodes gives `n` and its descendants their preorder indexes starting from `index` and computes the byte ranges
+// of the nodes. `end` is the end of the last token the traversal visited. When `n` covers at least one token, this
+// function returns true.
+func numberNodes(n *Node, index *int, end *int) bool {
+	n.Index = *index
+	*index++
+	if n.Type == NodeTypeTerminal {
+		*end = n.BytePos + n.ByteLen
+		return true
+	}
+
+	// An AST can reorder children, so the range spans from the first token to the last one in the source.
+	var first *Node
+	last := 0
+	for _, c := range n.Children {
+		if !numberNodes(c, index, end) {
+			continue
+		}
+		if first == nil || c.BytePos < first.BytePos {
+			first = c
+		}
+		if e := c.BytePos + c.ByteLen; e > last {
+			last = e
+		}
+	}
+	if first == nil {
+		n.BytePos = *end
+		n.ByteLen = 0
+		return false
+	}
+	n.BytePos = first.BytePos
+	n.ByteLen = last - first.BytePos
+	n.Row = first.Row
+	n.Col = first.Col
+	return true
 }
 
 // Tree returns a syntax tree when the parser has accepted an input. If a syntax error occurs, the return value is nil.
@@ -113,33 +229,163 @@ func (b *DefaultSyntaxTreeBuilder) Tree() *Node {
 	return b.tree
 }
 
+func (b *DefaultSyntaxTreeBuilder) nodeArena() *NodeArena {
+	if b.arena == nil {
+		b.arena = NewNodeArena()
+	}
+	return b.arena
+}
+
+const (
+	// nodeChunkLen is the number of nodes in a chunk NodeArena allocates nodes from.
+	nodeChunkLen = 128
+
+	// childrenChunkLen is the length of a chunk NodeArena allocates slices of children from. A node having more children
+	// than a quarter of the chunk has its own slice.
+	childrenChunkLen = 512
+
+	// textChunkSize is the size of a chunk NodeArena allocates the texts of terminal nodes from. A text longer than
+	// a quarter of the chunk has its own memory.
+	textChunkSize = 4096
+)
+
+// NodeArena allocates nodes and slices of their children in chunks, which cuts allocations and GC pressure. When you
+// parse many sources in one process, Reset lets the arena reuse all the memory at once for the next tree instead of
+// allocating new memory. An arena isn't safe for concurrent use; to share arenas among goroutines, keep them in
+// a sync.Pool, for instance.
+type NodeArena struct {
+	nodeChunks     [][]Node
+	nodeChunk      int
+	nodePos        int
+	childrenChunks [][]*Node
+	childrenChunk  int
+	childrenPos    int
+
+	// texts is a chunk holding the texts of terminal nodes. A strings.Builder never modifies the bytes it has written,
+	// so the texts can share the memory of the chunk. Unlike the other chunks, Reset cannot reuse the chunks of texts
+	// because strings are immutable.
+	texts strings.Builder
+}
+
+// NewNodeArena returns a new NodeArena.
+func NewNodeArena() *NodeArena {
+	return &NodeArena{}
+}
+
+// Reset frees all the nodes the arena has allocated at once so that the arena reuses the memory. Because the nodes are
+// overwritten, you must not use trees built from the arena after calling Reset.
+func (a *NodeArena) Reset() {
+	a.nodeChunk = 0
+	a.nodePos = 0
+	a.childrenChunk = 0
+	a.childrenPos = 0
+}
+
+// node returns a node whose fields are zero values. The builder sets the fields of a node one by one because it is
+// cheaper than assigning a composite literal, which makes the runtime process all the pointer fields at once while the
+// GC is running.
+func (a *NodeArena) node() *Node {
+	if a.nodeChunk == len(a.nodeChunks) {
+		a.nodeChunks = append(a.nodeChunks, make([]Node, nodeChunkLen))
+	} else if a.nodePos == 0 {
+		// The chunk holds the nodes of a tree built before Reset.
+		chunk := a.nodeChunks[a.nodeChunk]
+		for i := range chunk {
+			chunk[i] = Node{}
+		}
+	}
+	n := &a.nodeChunks[a.nodeChunk][a.nodePos]
+	a.nodePos++
+	if a.nodePos == nodeChunkLen {
+		a.nodeChunk++
+		a.nodePos = 0
+	}
+	return n
+}
+
+func (a *NodeArena) children(l int) []*Node {
+	if l > childrenChunkLen/4 {
+		return make([]*Node, l)
+	}
+	if a.childrenPos+l > childrenChunkLen {
+		a.childrenChunk++
+		a.childrenPos = 0
+	}
+	if a.childrenChunk == len(a.childrenChunks) {
+		a.childrenChunks = append(a.childrenChunks, make([]*Node, childrenChunkLen))
+	}
+	// Limiting the capacity prevents appending to the children from overwriting the children of other nodes.
+	cs := a.childrenChunks[a.childrenChunk][a.childrenPos : a.childrenPos+l : a.childrenPos+l]
+	a.childrenPos += l
+	return cs
+}
+
+func (a *NodeArena) text(b []byte) string {
+	if len(b) > textChunkSize/4 {
+		return string(b)
+	}
+	if a.texts.Cap()-a.texts.Len() < len(b) {
+		a.texts = strings.Builder{}
+		a.texts.Grow(textChunkSize)
+	}
+	start := a.texts.Len()
+	a.texts.Write(b)
+	return a.texts.String()[start:]
+}
+
 // SyntaxTreeActionSet is a implementation of SemanticActionSet interface and constructs a syntax tree.
 type SyntaxTreeActionSet struct {
 	gram             Grammar
 	builder          SyntaxTreeBuilder
 	semStack         *semanticStack
 	disableASTAction bool
+
+	// labeledBuilder and appendingBuilder are the builder when it implements LabeledSyntaxTreeBuilder and
+	// AppendingSyntaxTreeBuilder respectively, and nil otherwise.
+	labeledBuilder   LabeledSyntaxTreeBuilder
+	appendingBuilder AppendingSyntaxTreeBuilder
+
+	// pending is error nodes the parser generated in the resilient mode and that no node contains yet. The nodes are
+	// sorted by their positions.
+	pending []*pendingNode
+
+	// errPos is the position of the error node that TrapAndShiftErrorKeepingNodes pushed onto the semantic stack.
+	// When the node is no longer on the stack, errPos is -1.
+	errPos int
+}
+
+// pendingNode is a node placed immediately before the frame at `pos` on the semantic stack.
+type pendingNode struct {
+	pos  int
+	node SyntaxTreeNode
 }
 
 // NewASTActionSet returns a new SyntaxTreeActionSet that constructs an AST (Abstract Syntax Tree).
 // When grammar `gram` contains `#ast` directives, the new SyntaxTreeActionSet this function returns interprets them.
 func NewASTActionSet(gram Grammar, builder SyntaxTreeBuilder) *SyntaxTreeActionSet {
-	return &SyntaxTreeActionSet{
+	a := &SyntaxTreeActionSet{
 		gram:     gram,
 		builder:  builder,
 		semStack: newSemanticStack(),
+		errPos:   -1,
 	}
+	a.labeledBuilder, _ = builder.(LabeledSyntaxTreeBuilder)
+	a.appendingBuilder, _ = builder.(AppendingSyntaxTreeBuilder)
+	return a
 }
 
 // NewCSTTActionSet returns a new SyntaxTreeActionSet that constructs a CST (Concrete Syntax Tree).
 // Even if grammar `gram` contains `#ast` directives, the new SyntaxTreeActionSet this function returns ignores them.
 func NewCSTActionSet(gram Grammar, builder SyntaxTreeBuilder) *SyntaxTreeActionSet {
-	return &SyntaxTreeActionSet{
+	a := &SyntaxTreeActionSet{
 		gram:             gram,
 		builder:          builder,
 		semStack:         newSemanticStack(),
 		disableASTAction: true,
+		errPos:           -1,
 	}
+	a.labeledBuilder, _ = builder.(LabeledSyntaxTreeBuilder)
+	return a
 }
 
 // Shift is a implementation of SemanticActionSet.Shift method.
@@ -155,49 +401,112 @@ func (a *SyntaxTreeActionSet) Reduce(prodNum int, recovered bool) {
 	// When an alternative is empty, `n` will be 0, and `handle` will be empty slice.
 	n := a.gram.AlternativeSymbolCount(prodNum)
 	handle := a.semStack.pop(n)
+	if a.errPos >= len(a.semStack.frames) {
+		a.errPos = -1
+	}
 
+	// The new node contains error nodes placed among the handle.
+	var errNodes []*pendingNode
+	if n > 0 {
+		errNodes = a.takePending(len(a.semStack.frames))
+	}
+
+	kindName := a.gram.NonTerminal(lhs)
 	var astAct []int
 	if !a.disableASTAction {
+		// When a production has a `#lift` directive, the lifted node replaces the whole node of the production.
+		if pos := a.gram.ASTLift(prodNum); pos > 0 {
+			// The lifted node cannot contain the error nodes, so they precede the lifted node.
+			for _, e := range errNodes {
+				e.pos = len(a.semStack.frames)
+			}
+			a.pending = append(a.pending, errNodes...)
+			a.semStack.push(handle[pos-1])
+			return
+		}
+
 		astAct = a.gram.ASTAction(prodNum)
+		if name := a.gram.ASTNodeName(prodNum); name != "" {
+			kindName = name
+		}
 	}
 	var children []SyntaxTreeNode
-	if astAct != nil {
-		// Count the number of children in advance to avoid frequent growth in a slice for children.
-		{
-			l := 0
-			for _, e := range astAct {
-				if e > 0 {
-					l++
-				} else {
-					offset := e*-1 - 1
-					l += handle[offset].ChildCount()
-				}
-			}
-
-			children = make([]SyntaxTreeNode, l)
+	if b := a.appendingBuilder; b != nil && appendsToHead(astAct) {
+		children = a.expandASTChildren(astAct[1:], handle)
+		for _, e := range errNodes {
+			children = append(children, e.node)
 		}
+		a.semStack.push(b.ReduceAppending(handle[0], kindName, a.gram.AlternativeLabel(prodNum), children))
+		return
+	}
+	if astAct != nil {
+		children = a.expandASTChildren(astAct, handle)
 
-		p := 0
-		for _, e := range astAct {
-			if e > 0 {
-				offset := e - 1
-				children[p] = handle[offset]
-				p++
-			} else {
-				offset := e*-1 - 1
-				for _, c := range handle[offset].ExpandChildren() {
-					children[p] = c
-					p++
-				}
-			}
+		// Because an AST doesn't keep the order of the handle, the error nodes follow the other children.
+		for _, e := range errNodes {
+			children = append(children, e.node)
 		}
 	} else {
 		// If an alternative has no AST action, a driver generates
 		// a node with the same structure as a CST.
 		children = handle
+		if len(errNodes) > 0 {
+			children = interleave(handle, len(a.semStack.frames), errNodes)
+		}
+	}
+
+	if b := a.labeledBuilder; b != nil {
+		a.semStack.push(b.ReduceLabeled(kindName, a.gram.AlternativeLabel(prodNum), children))
+		return
 	}
+	a.semStack.push(a.builder.Reduce(kindName, children))
+}
 
-	a.semStack.push(a.builder.Reduce(a.gram.NonTerminal(lhs), children))
+// expandASTChildren returns the children that the elements `astAct` of an `#ast` directive select from `handle`.
+func (a *SyntaxTreeActionSet) expandASTChildren(astAct []int, handle []SyntaxTreeNode) []SyntaxTreeNode {
+	// Count the number of children in advance to avoid frequent growth in a slice for children.
+	l := 0
+	for _, e := range astAct {
+		if e > 0 {
+			l++
+		} else {
+			offset := e*-1 - 1
+			l += handle[offset].ChildCount()
+		}
+	}
+
+	children := make([]SyntaxTreeNode, 0, l)
+	for _, e := range astAct {
+		if e > 0 {
+			offset := e - 1
+			children = append(children, handle[offset])
+		} else {
+			offset := e*-1 - 1
+			children = append(children, handle[offset].ExpandChildren()...)
+		}
+	}
+	return children
+}
+
+// appendsToHead returns true when the elements `astAct` of an `#ast` directive expand the first symbol of a handle and
+// refer to the symbol nowhere else, so the children of the symbol can grow in place.
+func appendsToHead(astAct []int) bool {
+	if len(astAct) == 0 || astAct[0] != -1 {
+		return false
+	}
+	for _, e := range astAct[1:] {
+		if e == 1 || e == -1 {
+			return false
+		}
+	}
+	return true
+}
+
+// keepsTokens returns false when the builder is known to copy what it needs from tokens, as DefaultSyntaxTreeBuilder
+// does.
+func (a *SyntaxTreeActionSet) keepsTokens() bool {
+	_, ok := a.builder.(*DefaultSyntaxTreeBuilder)
+	return !ok
 }
 
 // Accept is a implementation of SemanticActionSet.Accept method.
@@ -212,10 +521,107 @@ func (a *SyntaxTreeActionSet) TrapAndShiftError(cause VToken, popped int) {
 	a.semStack.push(a.builder.ShiftError(a.gram.Terminal(a.gram.Error())))
 }
 
+// TrapAndShiftErrorKeepingNodes is a implementation of ResilientSemanticActionSet.TrapAndShiftErrorKeepingNodes method.
+// This method makes an error node containing the discarded nodes.
+func (a *SyntaxTreeActionSet) TrapAndShiftErrorKeepingNodes(cause VToken, popped int) {
+	handle := a.semStack.pop(popped)
+	pos := len(a.semStack.frames)
+	a.semStack.push(a.errorNode(interleave(handle, pos, a.takePending(pos))))
+	a.errPos = pos
+}
+
 // MissError is a implementation of SemanticActionSet.MissError method.
 func (a *SyntaxTreeActionSet) MissError(cause VToken) {
 }
 
+// Resync is a implementation of ResilientSemanticActionSet.Resync method. This method makes an error node containing
+// the discarded nodes and the skipped tokens. When the discarded nodes begin with the error node that the parser
+// shifted, or when the parser discards no nodes and the error node is on the top of the stack, this method puts
+// the others into the error node instead of making another one. The innermost node that the parser generates around
+// the error node later contains it.
+func (a *SyntaxTreeActionSet) Resync(cause VToken, popped int, skipped []VToken) {
+	if popped == 0 && a.errPos >= 0 && a.errPos == len(a.semStack.frames)-1 {
+		children := a.semStack.pop(1)[0].ExpandChildren()
+		for _, tok := range skipped {
+			children = append(children, a.builder.Shift(a.gram.Terminal(a.tokenToTerminal(tok)), tok))
+		}
+		a.semStack.push(a.errorNode(children))
+		return
+	}
+
+	handle := a.semStack.pop(popped)
+	pos := len(a.semStack.frames)
+	var children []SyntaxTreeNode
+	if a.errPos == pos && len(handle) > 0 {
+		children = append(children, handle[0].ExpandChildren()...)
+		children = append(children, interleave(handle[1:], pos+1, a.takePending(pos))...)
+	} else {
+		children = interleave(handle, pos, a.takePending(pos))
+	}
+	a.errPos = -1
+	for _, tok := range skipped {
+		children = append(children, a.builder.Shift(a.gram.Terminal(a.tokenToTerminal(tok)), tok))
+	}
+
+	a.pending = append(a.pending, &pendingNode{
+		pos:  pos,
+		node: a.errorNode(children),
+	})
+}
+
+// errorNode makes an error node containing `children`.
+func (a *SyntaxTreeActionSet) errorNode(children []SyntaxTreeNode) SyntaxTreeNode {
+	errKindName := a.gram.Terminal(a.gram.Error())
+	if len(children) == 0 {
+		return a.builder.ShiftError(errKindName)
+	}
+	return a.builder.Reduce(errKindName, children)
+}
+
+// AcceptPartially is a implementation of ResilientSemanticActionSet.AcceptPartially method. When the parser has
+// reduced an input to a single node containing all error nodes, this method accepts the node. Otherwise, this method
+// makes a node of the start symbol containing all the nodes remaining on the semantic stack and accepts it.
+func (a *SyntaxTreeActionSet) AcceptPartially(startProd int) {
+	if len(a.semStack.frames) == 1 && len(a.pending) == 0 && a.errPos != 0 {
+		a.Accept()
+		return
+	}
+
+	frames := a.semStack.pop(len(a.semStack.frames))
+	children := interleave(frames, 0, a.takePending(0))
+
+	// The start production has the augmented start symbol, whose name is the start symbol followed by `'`.
+	kindName := strings.TrimSuffix(a.gram.NonTerminal(a.gram.LHS(startProd)), "'")
+	a.builder.Accept(a.builder.Reduce(kindName, children))
+}
+
+// takePending removes pending nodes whose positions are `pos` or later and returns them.
+func (a *SyntaxTreeActionSet) takePending(pos int) []*pendingNode {
+	i := len(a.pending)
+	for i > 0 && a.pending[i-1].pos >= pos {
+		i--
+	}
+	ns := a.pending[i:]
+	a.pending = a.pending[:i]
+	return ns
+}
+
+// interleave places pending nodes among `frames` located from `base` on the semantic stack.
+func interleave(frames []SyntaxTreeNode, base int, pending []*pendingNode) []SyntaxTreeNode {
+	nodes := make([]SyntaxTreeNode, 0, len(frames)+len(pending))
+	for i, f := range frames {
+		for len(pending) > 0 && pending[0].pos <= base+i {
+			nodes = append(nodes, pending[0].node)
+			pending = pending[1:]
+		}
+		nodes = append(nodes, f)
+	}
+	for _, e := range pending {
+		nodes = append(nodes, e.node)
+	}
+	return nodes
+}
+
 func (a *SyntaxTreeActionSet) tokenToTerminal(tok VToken) int {
 	if tok.EOF() {
 		return a.gram.EOF()
@@ -258,11 +664,24 @@ type Node struct {
 	Type     NodeType
 	KindName string
 	Text     string
-	BytePos  int
-	ByteLen  int
-	Row      int
-	Col      int
+
+	// BytePos and ByteLen are the byte range of the source a node covers, and Row and Col are the position where the
+	// range starts. A non-terminal node and an error node cover the tokens of their descendants. A node covering no
+	// token, such as a node of an empty alternative, has an empty range at the end of the token preceding it in
+	// the preorder traversal of the tree, and its Row and Col are 0.
+	BytePos int
+	ByteLen int
+	Row     int
+	Col     int
+
 	Children []*Node
+
+	// Label is the label that a `#label` directive gives the alternative a non-terminal node comes from.
+	Label string
+
+	// Index is the position of a node in the preorder traversal of the tree, and the root is 0. The same input and
+	// the same grammar always give a node the same index, so tools can use it to identify nodes.
+	Index int
 }
 
 func (n *Node) MarshalJSON() ([]byte, error) {
@@ -271,22 +690,30 @@ func (n *Node) MarshalJSON() ([]byte, error) {
 		return json.Marshal(struct {
 			Type     NodeType `json:"type"`
 			KindName string   `json:"kind_name"`
+			BytePos  int      `json:"byte_pos"`
+			ByteLen  int      `json:"byte_len"`
 		}{
 			Type:     n.Type,
 			KindName: n.KindName,
+			BytePos:  n.BytePos,
+			ByteLen:  n.ByteLen,
 		})
 	case NodeTypeTerminal:
 		if n.KindName == "" {
 			return json.Marshal(struct {
-				Type NodeType `json:"type"`
-				Text string   `json:"text"`
-				Row  int      `json:"row"`
-				Col  int      `json:"col"`
+				Type    NodeType `json:"type"`
+				Text    string   `json:"text"`
+				Row     int      `json:"row"`
+				Col     int      `json:"col"`
+				BytePos int      `json:"byte_pos"`
+				ByteLen int      `json:"byte_len"`
 			}{
-				Type: n.Type,
-				Text: n.Text,
-				Row:  n.Row,
-				Col:  n.Col,
+				Type:    n.Type,
+				Text:    n.Text,
+				Row:     n.Row,
+				Col:     n.Col,
+				BytePos: n.BytePos,
+				ByteLen: n.ByteLen,
 			})
 		}
 		return json.Marshal(struct {
@@ -295,21 +722,35 @@ func (n *Node) MarshalJSON() ([]byte, error) {
 			Text     string   `json:"text"`
 			Row      int      `json:"row"`
 			Col      int      `json:"col"`
+			BytePos  int      `json:"byte_pos"`
+			ByteLen  int      `json:"byte_len"`
 		}{
 			Type:     n.Type,
 			KindName: n.KindName,
 			Text:     n.Text,
 			Row:      n.Row,
 			Col:      n.Col,
+			BytePos:  n.BytePos,
+			ByteLen:  n.ByteLen,
 		})
 	case NodeTypeNonTerminal:
 		return json.Marshal(struct {
 			Type     NodeType `json:"type"`
 			KindName string   `json:"kind_name"`
+			Label    string   `json:"label,omitempty"`
+			Row      int      `json:"row"`
+			Col      int      `json:"col"`
+			BytePos  int      `json:"byte_pos"`
+			ByteLen  int      `json:"byte_len"`
 			Children []*Node  `json:"children"`
 		}{
 			Type:     n.Type,
 			KindName: n.KindName,
+			Label:    n.Label,
+			Row:      n.Row,
+			Col:      n.Col,
+			BytePos:  n.BytePos,
+			ByteLen:  n.ByteLen,
 			Children: n.Children,
 		})
 	default:
@@ -317,6 +758,46 @@ func (n *Node) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// UnmarshalJSON reads a node in the format MarshalJSON writes, so tools can read trees that `vartan parse --format json`
+// prints.
+func (n *Node) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Type     *NodeType `json:"type"`
+		KindName string    `json:"kind_name"`
+		Label    string    `json:"label"`
+		Text     string    `json:"text"`
+		Row      int       `json:"row"`
+		Col      int       `json:"col"`
+		BytePos  int       `json:"byte_pos"`
+		ByteLen  int       `json:"byte_len"`
+		Children []*Node   `json:"children"`
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	if v.Type == nil {
+		return fmt.Errorf("a node must have a type")
+	}
+	switch *v.Type {
+	case NodeTypeError, NodeTypeTerminal, NodeTypeNonTerminal:
+	default:
+		return fmt.Errorf("invalid node type: %v", *v.Type)
+	}
+	*n = Node{
+		Type:     *v.Type,
+		KindName: v.KindName,
+		Text:     v.Text,
+		BytePos:  v.BytePos,
+		ByteLen:  v.ByteLen,
+		Row:      v.Row,
+		Col:      v.Col,
+		Children: v.Children,
+		Label:    v.Label,
+	}
+	return nil
+}
+
 // ChildCount is a implementation of SyntaxTreeNode.ChildCount.
 func (n *Node) ChildCount() int {
 	return len(n.Children)
@@ -347,7 +828,11 @@ func printTree(w io.Writer, node *Node, ruledLine string, childRuledLinePrefix s
 	case NodeTypeTerminal:
 		fmt.Fprintf(w, "%v%v %v\n", ruledLine, node.KindName, strconv.Quote(node.Text))
 	case NodeTypeNonTerminal:
-		fmt.Fprintf(w, "%v%v\n", ruledLine, node.KindName)
+		if node.Label != "" {
+			fmt.Fprintf(w, "%v%v @%v\n", ruledLine, node.KindName, node.Label)
+		} else {
+			fmt.Fprintf(w, "%v%v\n", ruledLine, node.KindName)
+		}
 
 		num := len(node.Children)
 		for i, child := range node.Children {
@@ -225,3 +225,266 @@ char
 		})
 	}
 }
+
+func TestNodeArena(t *testing.T) {
+	ast, err := parser.Parse(strings.NewReader(benchExprSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gram := NewGrammar(cg)
+
+	parse := func(t *testing.T, src string, tb *DefaultSyntaxTreeBuilder) *Node {
+		t.Helper()
+		toks, err := NewTokenStream(cg, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.SyntaxErrors()) > 0 {
+			t.Fatalf("unexpected syntax error: %v", p.SyntaxErrors()[0])
+		}
+		return tb.Tree()
+	}
+
+	// The sources make more nodes than a chunk holds.
+	var src1, src2 strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&src1, "a%v = (b + %v) * c;\n", i, i)
+		fmt.Fprintf(&src2, "x%v = y / %v - z;\n", i, i)
+	}
+
+	arena := NewNodeArena()
+	for _, src := range []string{src1.String(), src2.String(), src1.String()} {
+		arena.Reset()
+		tree := parse(t, src, NewArenaSyntaxTreeBuilder(arena))
+		testTree(t, tree, parse(t, src, NewDefaultSyntaxTreeBuilder()))
+	}
+	// Parsing the same source again after Reset reuses the chunks the arena already has.
+	nodeChunks := len(arena.nodeChunks)
+	childrenChunks := len(arena.childrenChunks)
+	arena.Reset()
+	parse(t, src1.String(), NewArenaSyntaxTreeBuilder(arena))
+	if len(arena.nodeChunks) != nodeChunks || len(arena.childrenChunks) != childrenChunks {
+		t.Fatalf("the arena allocated new chunks after Reset; nodes: %v -> %v, children: %v -> %v", nodeChunks, len(arena.nodeChunks), childrenChunks, len(arena.childrenChunks))
+	}
+}
+
+func TestDefaultSyntaxTreeBuilder_NodeRanges(t *testing.T) {
+	specSrc := `
+#name test;
+
+decl
+    : mods id eq expr semi_colon #ast id expr mods
+    ;
+mods
+    : mods pub
+    |
+    ;
+expr
+    : expr add id
+    | id
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+pub
+    : 'pub';
+eq
+    : '=';
+add
+    : '+';
+semi_colon
+    : ';';
+id
+    : "[a-z]+";
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gram := NewGrammar(cg)
+
+	tests := []struct {
+		src string
+		// ranges lists the kind name and the covered text of each node in preorder.
+		ranges []string
+	}{
+		{
+			src: "pub pub x =\n a + b ;",
+			ranges: []string{
+				`decl:"pub pub x =\n a + b"`,
+				`id:"x"`,
+				`expr:"a + b"`,
+				`expr:"a"`,
+				`id:"a"`,
+				`add:"+"`,
+				`id:"b"`,
+				`mods:"pub pub"`,
+				`mods:"pub"`,
+				`mods:""`,
+				`pub:"pub"`,
+				`pub:"pub"`,
+			},
+		},
+		{
+			src: "x = a;",
+			ranges: []string{
+				`decl:"x = a"`,
+				`id:"x"`,
+				`expr:"a"`,
+				`id:"a"`,
+				`mods:""`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tb := NewDefaultSyntaxTreeBuilder()
+			p, err := NewParser(toks, gram, SemanticAction(NewASTActionSet(gram, tb)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ranges []string
+			var visit func(n *Node)
+			visit = func(n *Node) {
+				if n.Index != len(ranges) {
+					t.Fatalf("unexpected index of %v; want: %v, got: %v", n.KindName, len(ranges), n.Index)
+				}
+				ranges = append(ranges, fmt.Sprintf("%v:%q", n.KindName, tt.src[n.BytePos:n.BytePos+n.ByteLen]))
+				for _, c := range n.Children {
+					visit(c)
+				}
+			}
+			visit(tb.Tree())
+			if strings.Join(ranges, " ") != strings.Join(tt.ranges, " ") {
+				t.Fatalf("unexpected ranges;\nwant: %v\ngot:  %v", tt.ranges, ranges)
+			}
+		})
+	}
+}
+
+// forwardingBuilder implements only SyntaxTreeBuilder and LabeledSyntaxTreeBuilder by forwarding calls to
+// a DefaultSyntaxTreeBuilder. It keeps the tokens it shifts and copies of their lexemes at that time.
+type forwardingBuilder struct {
+	b       *DefaultSyntaxTreeBuilder
+	toks    []VToken
+	lexemes []string
+}
+
+func (b *forwardingBuilder) Shift(kindName string, tok VToken) SyntaxTreeNode {
+	b.toks = append(b.toks, tok)
+	b.lexemes = append(b.lexemes, string(tok.Lexeme()))
+	return b.b.Shift(kindName, tok)
+}
+
+func (b *forwardingBuilder) ShiftError(kindName string) SyntaxTreeNode {
+	return b.b.ShiftError(kindName)
+}
+
+func (b *forwardingBuilder) Reduce(kindName string, children []SyntaxTreeNode) SyntaxTreeNode {
+	return b.b.Reduce(kindName, children)
+}
+
+func (b *forwardingBuilder) ReduceLabeled(kindName string, label string, children []SyntaxTreeNode) SyntaxTreeNode {
+	return b.b.ReduceLabeled(kindName, label, children)
+}
+
+func (b *forwardingBuilder) Accept(f SyntaxTreeNode) {
+	b.b.Accept(f)
+}
+
+func TestDefaultSyntaxTreeBuilder_ReduceAppending(t *testing.T) {
+	ast, err := parser.Parse(strings.NewReader(benchJSONSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gram := NewGrammar(cg)
+
+	parse := func(t *testing.T, src string, tb SyntaxTreeBuilder) {
+		t.Helper()
+		toks, err := NewTokenStream(cg, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := NewParser(toks, gram, SemanticAction(NewASTActionSet(gram, tb)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.SyntaxErrors()) > 0 {
+			t.Fatalf("unexpected syntax error: %v", p.SyntaxErrors()[0])
+		}
+	}
+
+	// The lists are longer than the children a chunk of an arena holds.
+	var src strings.Builder
+	src.WriteString("[")
+	for i := 0; i < 300; i++ {
+		if i > 0 {
+			src.WriteString(", ")
+		}
+		fmt.Fprintf(&src, `{"id": %v, "tags": ["a", "b"], "nested": [[%v], {}]}`, i, i)
+	}
+	src.WriteString("]")
+
+	// Appending elements to a list in place builds the same tree as building a new list for each element does.
+	arena := NewNodeArena()
+	for i := 0; i < 2; i++ {
+		arena.Reset()
+		tb := NewArenaSyntaxTreeBuilder(arena)
+		parse(t, src.String(), tb)
+		fb := &forwardingBuilder{
+			b: NewDefaultSyntaxTreeBuilder(),
+		}
+		parse(t, src.String(), fb)
+		testTree(t, tb.Tree(), fb.b.Tree())
+
+		// The parser doesn't reuse the tokens that a builder other than DefaultSyntaxTreeBuilder may keep.
+		for j, tok := range fb.toks {
+			if string(tok.Lexeme()) != fb.lexemes[j] {
+				t.Fatalf("a token the builder keeps was overwritten; want: %q, got: %q", fb.lexemes[j], tok.Lexeme())
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+// SharedGrammar holds the immutable parts of a compiled grammar that parsers need: the lexical specification, the
+// parsing table, and the AST actions. SharedGrammar never modifies them after construction, so it is safe for
+// concurrent use by multiple goroutines. Create it once and make a parser per input using NewParser.
+//
+// Per-parse mutable state, such as the state stack, syntax errors, and a syntax tree under construction, belongs to
+// a Parser, a TokenStream, and a SemanticActionSet. Don't share them among goroutines.
+type SharedGrammar struct {
+	cg      *spec.CompiledGrammar
+	gram    *grammarImpl
+	lexSpec lexer.LexSpec
+}
+
+// NewSharedGrammar returns a SharedGrammar backed by a compiled grammar. The compiled grammar must not be modified
+// after calling this function. NewSharedGrammar validates the compiled grammar once, so parsers made from it can trust
+// the tables.
+func NewSharedGrammar(cg *spec.CompiledGrammar) (*SharedGrammar, error) {
+	if cg.IsLexerOnly() {
+		return nil, fmt.Errorf("a lexer-only grammar cannot be used to parse: %v", cg.Name)
+	}
+	err := cg.CheckCompatibility()
+	if err != nil {
+		return nil, err
+	}
+	err = cg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SharedGrammar{
+		cg:      cg,
+		gram:    NewGrammar(cg),
+		lexSpec: lexer.NewLexSpec(cg.Lexical),
+	}, nil
+}
+
+// Grammar returns the Grammar shared among parsers. Use it to construct semantic action sets, such as
+// NewASTActionSet(g.Grammar(), builder).
+func (g *SharedGrammar) Grammar() Grammar {
+	return g.gram
+}
+
+// Hash returns the content hash of the compiled grammar. Use it as a key to cache results of parsing. A grammar
+// compiled before vartan recorded hashes has an empty hash.
+func (g *SharedGrammar) Hash() string {
+	return g.cg.Hash
+}
+
+// NewTokenStream returns a token stream reading a source. Each call allocates only per-parse state.
+func (g *SharedGrammar) NewTokenStream(src io.Reader, opts ...lexer.LexerOption) (TokenStream, error) {
+	lex, err := lexer.NewLexer(g.lexSpec, src, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenStream{
+		lex:            lex,
+		kindToTerminal: g.cg.Syntactic.KindToTerminal,
+	}, nil
+}
+
+// NewSourceTokenStream returns a token stream reading tokens from a TokenSource instead of the built-in lexer.
+func (g *SharedGrammar) NewSourceTokenStream(src TokenSource) TokenStream {
+	return newSourceTokenStream(src, g.cg.Syntactic.KindToTerminal, g.cg.Syntactic.FallbackTerminal)
+}
+
+// NewParser returns a parser reading a source. The parser shares the immutable parts of the grammar with other
+// parsers created from the same SharedGrammar.
+func (g *SharedGrammar) NewParser(src io.Reader, opts ...ParserOption) (*Parser, error) {
+	toks, err := g.NewTokenStream(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewParser(toks, g.gram, opts...)
+}
@@ -0,0 +1,307 @@
+package parser
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestNewSharedGrammar_FormatVersion(t *testing.T) {
+	specSrc := `
+#name test;
+
+s
+    : foo
+    ;
+
+foo
+    : 'foo';
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		formatVersion int
+		ok            bool
+	}{
+		{
+			formatVersion: spec.FormatVersion,
+			ok:            true,
+		},
+		// A grammar compiled before vartan recorded format versions has version 0.
+		{
+			formatVersion: 0,
+			ok:            false,
+		},
+		{
+			formatVersion: spec.MinFormatVersion - 1,
+			ok:            false,
+		},
+		{
+			formatVersion: spec.FormatVersion + 1,
+			ok:            false,
+		},
+	}
+	for _, tt := range tests {
+		c := *cg
+		c.FormatVersion = tt.formatVersion
+		shared, err := NewSharedGrammar(&c)
+		if tt.ok {
+			if err != nil {
+				t.Fatalf("format version %v: unexpected error: %v", tt.formatVersion, err)
+			}
+			if shared.Hash() != cg.Hash {
+				t.Fatalf("unexpected hash: want: %v, got: %v", cg.Hash, shared.Hash())
+			}
+		} else if err == nil {
+			t.Fatalf("format version %v: an error must occur", tt.formatVersion)
+		}
+		_, err = NewTokenStream(&c, strings.NewReader("foo"))
+		if tt.ok && err != nil {
+			t.Fatalf("format version %v: unexpected error: %v", tt.formatVersion, err)
+		}
+		if !tt.ok && err == nil {
+			t.Fatalf("format version %v: an error must occur", tt.formatVersion)
+		}
+	}
+}
+
+func TestNewSharedGrammar_Validate(t *testing.T) {
+	specSrc := `
+#name test;
+
+s
+    : foo bar #ast foo bar
+    ;
+
+foo
+    : 'foo';
+bar
+    : 'bar';
+`
+
+	tests := []struct {
+		caption string
+		corrupt func(cg *spec.CompiledGrammar)
+		ok      bool
+	}{
+		{
+			caption: "a compiled grammar is valid",
+			corrupt: func(cg *spec.CompiledGrammar) {},
+			ok:      true,
+		},
+		{
+			caption: "the action table lacks entries",
+			corrupt: func(cg *spec.CompiledGrammar) {
+				cg.Syntactic.Action = cg.Syntactic.Action[:len(cg.Syntactic.Action)-1]
+			},
+		},
+		{
+			caption: "an action shifts to a state out of range",
+			corrupt: func(cg *spec.CompiledGrammar) {
+				cg.Syntactic.Action[0] = -cg.Syntactic.StateCount
+			},
+		},
+		{
+			caption: "a goto entry refers to a state out of range",
+			corrupt: func(cg *spec.CompiledGrammar) {
+				cg.Syntactic.GoTo[0] = cg.Syntactic.StateCount
+			},
+		},
+		{
+			caption: "two kinds map to the same terminal symbol",
+			corrupt: func(cg *spec.CompiledGrammar) {
+				cg.Syntactic.KindToTerminal[2] = cg.Syntactic.KindToTerminal[1]
+			},
+		},
+		{
+			caption: "an AST action refers to an element out of the RHS",
+			corrupt: func(cg *spec.CompiledGrammar) {
+				for prod, act := range cg.ASTAction.Entries {
+					if len(act) > 0 {
+						cg.ASTAction.Entries[prod] = []int{1, 3}
+					}
+				}
+			},
+		},
+		{
+			caption: "the accepting state table of the lexer lacks entries",
+			corrupt: func(cg *spec.CompiledGrammar) {
+				dfa := cg.Lexical.Specs[spec.LexModeIDDefault].DFA
+				dfa.AcceptingStates = dfa.AcceptingStates[:1]
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(specSrc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := grammar.GrammarBuilder{
+				AST: ast,
+			}
+			cg, _, err := b.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.corrupt(cg)
+			_, err = NewSharedGrammar(cg)
+			if tt.ok && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("an error must occur")
+			}
+		})
+	}
+}
+
+// TestNewSharedGrammar_CorruptedGrammars corrupts a number in a compiled grammar at random and checks that a parser
+// either refuses the grammar or parses inputs without panicking.
+func TestNewSharedGrammar_CorruptedGrammars(t *testing.T) {
+	specSrc := `
+#name test;
+
+stmts
+    : stmts stmt #ast stmts... stmt
+    | stmt
+    ;
+stmt
+    : name eq value semi #ast name value
+    | error semi #recover
+    ;
+value
+    : str_open str_body str_close
+    | num
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+eq
+    : '=';
+semi
+    : ';';
+name
+    : "[a-z]+";
+num
+    : "[0-9]+";
+str_open #push str
+    : '"';
+str_body #mode str
+    : "[^\"]+";
+str_close #mode str #pop
+    : '"';
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(cg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcs := []string{
+		`a = 1; b = "x y"; c = ;`,
+		`= = "`,
+		`a = 1`,
+	}
+	values := []int{-2, -1, 0, 1, 2, 3, 255, 256, 1000}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var nums []func(float64)
+		collectNumbers(v, func(set func(float64)) {
+			nums = append(nums, set)
+		})
+		nums[r.Intn(len(nums))](float64(values[r.Intn(len(values))]))
+		corrupted, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					t.Fatalf("a parser panicked with a corrupted grammar: %v\n%s", err, corrupted)
+				}
+			}()
+
+			c := &spec.CompiledGrammar{}
+			err := json.Unmarshal(corrupted, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			shared, err := NewSharedGrammar(c)
+			if err != nil {
+				return
+			}
+			for _, src := range srcs {
+				p, err := shared.NewParser(strings.NewReader(src), SemanticAction(NewASTActionSet(shared.Grammar(), NewDefaultSyntaxTreeBuilder())))
+				if err != nil {
+					continue
+				}
+				_ = p.Parse()
+			}
+		}()
+	}
+}
+
+// collectNumbers calls `f` with a setter of each number in a JSON value.
+func collectNumbers(v interface{}, f func(set func(float64))) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			e := v[k]
+			if _, ok := e.(float64); ok {
+				k := k
+				f(func(n float64) { v[k] = n })
+				continue
+			}
+			collectNumbers(e, f)
+		}
+	case []interface{}:
+		for i, e := range v {
+			if _, ok := e.(float64); ok {
+				i := i
+				f(func(n float64) { v[i] = n })
+				continue
+			}
+			collectNumbers(e, f)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestParserWithSnippetLibraries(t *testing.T) {
+	tests := []struct {
+		caption string
+		specSrc string
+		src     string
+		cst     *Node
+		synErr  bool
+	}{
+		{
+			caption: "list accepts items separated by commas",
+			specSrc: `
+#name test;
+
+#use list;
+
+s
+    : list
+    ;
+list_item
+    : id
+    ;
+
+id
+    : "[a-z]+";
+`,
+			src: "a,b,c",
+			cst: nonTermNode("s",
+				nonTermNode("list",
+					nonTermNode("list",
+						nonTermNode("list",
+							nonTermNode("list_item",
+								termNode("id", "a"),
+							),
+						),
+						termNode("comma", ","),
+						nonTermNode("list_item",
+							termNode("id", "b"),
+						),
+					),
+					termNode("comma", ","),
+					nonTermNode("list_item",
+						termNode("id", "c"),
+					),
+				),
+			),
+		},
+		{
+			caption: "list doesn't accept a trailing comma",
+			specSrc: `
+#name test;
+
+#use list;
+
+s
+    : list
+    ;
+list_item
+    : id
+    ;
+
+id
+    : "[a-z]+";
+`,
+			src:    "a,",
+			synErr: true,
+		},
+		{
+			caption: "trailing_comma_list accepts a trailing comma",
+			specSrc: `
+#name test;
+
+#use list;
+
+s
+    : trailing_comma_list
+    ;
+list_item
+    : id
+    ;
+
+id
+    : "[a-z]+";
+`,
+			src: "a,b,",
+			cst: nonTermNode("s",
+				nonTermNode("trailing_comma_list",
+					nonTermNode("list",
+						nonTermNode("list",
+							nonTermNode("list_item",
+								termNode("id", "a"),
+							),
+						),
+						termNode("comma", ","),
+						nonTermNode("list_item",
+							termNode("id", "b"),
+						),
+					),
+					termNode("comma", ","),
+				),
+			),
+		},
+		{
+			caption: "optional_list accepts nested lists and empty lists",
+			specSrc: `
+#name test;
+
+#use list;
+
+call
+    : id l_paren optional_list r_paren
+    ;
+list_item
+    : id
+    | call
+    ;
+
+id
+    : "[a-z]+";
+l_paren
+    : '(';
+r_paren
+    : ')';
+`,
+			src: "f(g(),a,)",
+			cst: nonTermNode("call",
+				termNode("id", "f"),
+				termNode("l_paren", "("),
+				nonTermNode("optional_list",
+					nonTermNode("trailing_comma_list",
+						nonTermNode("list",
+							nonTermNode("list",
+								nonTermNode("list_item",
+									nonTermNode("call",
+										termNode("id", "g"),
+										termNode("l_paren", "("),
+										nonTermNode("optional_list"),
+										termNode("r_paren", ")"),
+									),
+								),
+							),
+							termNode("comma", ","),
+							nonTermNode("list_item",
+								termNode("id", "a"),
+							),
+						),
+						termNode("comma", ","),
+					),
+				),
+				termNode("r_paren", ")"),
+			),
+		},
+		{
+			caption: "parens accepts balanced parentheses enclosing items",
+			specSrc: `
+#name test;
+
+#use parens;
+
+s
+    : parens
+    ;
+parens_item
+    : id
+    ;
+
+id
+    : "[a-z]+";
+`,
+			src: "(a()(b))",
+			cst: nonTermNode("s",
+				nonTermNode("parens",
+					termNode("l_paren", "("),
+					nonTermNode("parens_content",
+						nonTermNode("parens_content",
+							nonTermNode("parens_content",
+								nonTermNode("parens_content"),
+								nonTermNode("parens_element",
+									nonTermNode("parens_item",
+										termNode("id", "a"),
+									),
+								),
+							),
+							nonTermNode("parens_element",
+								nonTermNode("parens",
+									termNode("l_paren", "("),
+									nonTermNode("parens_content"),
+									termNode("r_paren", ")"),
+								),
+							),
+						),
+						nonTermNode("parens_element",
+							nonTermNode("parens",
+								termNode("l_paren", "("),
+								nonTermNode("parens_content",
+									nonTermNode("parens_content"),
+									nonTermNode("parens_element",
+										nonTermNode("parens_item",
+											termNode("id", "b"),
+										),
+									),
+								),
+								termNode("r_paren", ")"),
+							),
+						),
+					),
+					termNode("r_paren", ")"),
+				),
+			),
+		},
+		{
+			caption: "parens doesn't accept unbalanced parentheses",
+			specSrc: `
+#name test;
+
+#use parens;
+
+s
+    : parens
+    ;
+parens_item
+    : id
+    ;
+
+id
+    : "[a-z]+";
+`,
+			src:    "(a))",
+			synErr: true,
+		},
+		{
+			caption: "a prefix lets a grammar use a library more than once",
+			specSrc: `
+#name test;
+
+#use list;
+#use list pair;
+
+s
+    : l_bracket list r_bracket l_brace pair_list r_brace
+    ;
+list_item
+    : id
+    ;
+pair_list_item
+    : id colon id
+    ;
+
+id
+    : "[a-z]+";
+colon
+    : ':';
+l_bracket
+    : '[';
+r_bracket
+    : ']';
+l_brace
+    : '{';
+r_brace
+    : '}';
+`,
+			src: "[a]{b:c}",
+			cst: nonTermNode("s",
+				termNode("l_bracket", "["),
+				nonTermNode("list",
+					nonTermNode("list_item",
+						termNode("id", "a"),
+					),
+				),
+				termNode("r_bracket", "]"),
+				termNode("l_brace", "{"),
+				nonTermNode("pair_list",
+					nonTermNode("pair_list_item",
+						termNode("id", "b"),
+						termNode("colon", ":"),
+						termNode("id", "c"),
+					),
+				),
+				termNode("r_brace", "}"),
+			),
+		},
+		{
+			caption: "a production of a grammar takes precedence over a library production of the same name",
+			specSrc: `
+#name test;
+
+#use list;
+
+s
+    : trailing_comma_list
+    ;
+list
+    : list_item
+    ;
+list_item
+    : id
+    ;
+
+id
+    : "[a-z]+";
+`,
+			src:    "a,b",
+			synErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(tt.specSrc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := grammar.GrammarBuilder{
+				AST: ast,
+			}
+			cg, _, err := b.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			gram := NewGrammar(cg)
+			toks, err := NewTokenStream(cg, strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tb := NewDefaultSyntaxTreeBuilder()
+			p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.synErr {
+				if len(p.SyntaxErrors()) == 0 {
+					t.Fatal("a syntax error must occur")
+				}
+				return
+			}
+			if len(p.SyntaxErrors()) > 0 {
+				t.Fatalf("unexpected syntax error: %+v", p.SyntaxErrors()[0])
+			}
+			testTree(t, tb.Tree(), tt.cst)
+		})
+	}
+}
@@ -6,6 +6,9 @@ type grammarImpl struct {
 	g *spec.CompiledGrammar
 }
 
+// NewGrammar returns a Grammar backed by a compiled grammar. The Grammar only reads the compiled grammar, so multiple
+// parsers running concurrently can share both of them. NewGrammar trusts the compiled grammar, so call
+// CompiledGrammar.Validate beforehand or use NewSharedGrammar when the grammar comes from an untrusted source.
 func NewGrammar(g *spec.CompiledGrammar) *grammarImpl {
 	return &grammarImpl{
 		g: g,
@@ -20,6 +23,20 @@ func (g *grammarImpl) StartProduction() int {
 	return g.g.Syntactic.StartProduction
 }
 
+func (g *grammarImpl) EntryPoint(symbol string) (int, int, bool) {
+	syn := g.g.Syntactic
+	// The start production has the augmented start symbol, whose name is the start symbol followed by `'`.
+	if symbol+"'" == syn.NonTerminals[syn.LHSSymbols[syn.StartProduction]] {
+		return syn.InitialState, syn.StartProduction, true
+	}
+	for _, e := range syn.EntryPoints {
+		if e.Symbol == symbol {
+			return e.InitialState, e.StartProduction, true
+		}
+	}
+	return 0, 0, false
+}
+
 func (g *grammarImpl) RecoverProduction(prod int) bool {
 	return g.g.Syntactic.RecoverProductions[prod] != 0
 }
@@ -44,6 +61,13 @@ func (g *grammarImpl) SkipTerminal(terminal int) bool {
 	return g.g.Syntactic.TerminalSkip[terminal] == 1
 }
 
+func (g *grammarImpl) KeepTerminal(terminal int) bool {
+	if len(g.g.Syntactic.TerminalKeep) == 0 {
+		return false
+	}
+	return g.g.Syntactic.TerminalKeep[terminal] == 1
+}
+
 func (g *grammarImpl) ErrorTrapperState(state int) bool {
 	return g.g.Syntactic.ErrorTrapperStates[state] != 0
 }
@@ -71,3 +95,46 @@ func (g *grammarImpl) Terminal(terminal int) string {
 func (g *grammarImpl) ASTAction(prod int) []int {
 	return g.g.ASTAction.Entries[prod]
 }
+
+func (g *grammarImpl) ASTNodeName(prod int) string {
+	if len(g.g.ASTAction.NodeNames) == 0 {
+		return ""
+	}
+	return g.g.ASTAction.NodeNames[prod]
+}
+
+func (g *grammarImpl) ASTLift(prod int) int {
+	if len(g.g.ASTAction.Lifts) == 0 {
+		return 0
+	}
+	return g.g.ASTAction.Lifts[prod]
+}
+
+func (g *grammarImpl) LexModeAction(state int, terminal int) int {
+	if len(g.g.Syntactic.LexModeActions) == 0 {
+		return 0
+	}
+	return g.g.Syntactic.LexModeActions[state*g.g.Syntactic.TerminalCount+terminal]
+}
+
+func (g *grammarImpl) ErrorSyncTerminals(state int) []int {
+	if len(g.g.Syntactic.ErrorSyncTerminals) == 0 {
+		return nil
+	}
+	return g.g.Syntactic.ErrorSyncTerminals[state]
+}
+
+func (g *grammarImpl) AlternativeLabel(prod int) string {
+	if len(g.g.Syntactic.AlternativeLabels) == 0 {
+		return ""
+	}
+	return g.g.Syntactic.AlternativeLabels[prod]
+}
+
+func (g *grammarImpl) Layout() (int, int, int, bool) {
+	lay := g.g.Syntactic.Layout
+	if lay == nil {
+		return 0, 0, 0, false
+	}
+	return lay.Indent, lay.Dedent, lay.Newline, true
+}
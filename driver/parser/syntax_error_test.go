@@ -120,6 +120,109 @@ c
 			src:         `!**; a!**; ab!**; abc!`,
 			synErrCount: 4,
 		},
+		{
+			caption: "without a synchronization set, the parser resumes as soon as it can perform an action",
+			specSrc: `
+#name test;
+
+block
+    : l_brace stmts r_brace
+    ;
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq id semi_colon
+    | block
+    | error #recover
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+l_brace
+    : '{';
+r_brace
+    : '}';
+eq
+    : '=';
+semi_colon
+    : ';';
+id
+    : "[a-z]+";
+`,
+			// The parser resumes at `b`, and then `;` causes another error.
+			src:         `{ a b; c = d; }`,
+			synErrCount: 2,
+		},
+		{
+			caption: "the parser discards tokens until a terminal symbol in the synchronization set appears",
+			specSrc: `
+#name test;
+
+block
+    : l_brace stmts r_brace
+    ;
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq id semi_colon
+    | block
+    | error #recover until semi_colon r_brace
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+l_brace
+    : '{';
+r_brace
+    : '}';
+eq
+    : '=';
+semi_colon
+    : ';';
+id
+    : "[a-z]+";
+`,
+			src:         `{ a b; c = d; { e f g } h = i; }`,
+			synErrCount: 2,
+		},
+		{
+			caption: "the synchronization set can contain string literals",
+			specSrc: `
+#name test;
+
+block
+    : l_brace stmts r_brace
+    ;
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq id semi_colon
+    | block
+    | error #recover until ';' '}'
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+l_brace
+    : '{';
+r_brace
+    : '}';
+eq
+    : '=';
+semi_colon
+    : ';';
+id
+    : "[a-z]+";
+`,
+			src:         `{ a b; c = d; }`,
+			synErrCount: 1,
+		},
 	}
 	for i, tt := range tests {
 		t.Run(fmt.Sprintf("#%v", i), func(t *testing.T) {
@@ -245,6 +348,37 @@ foo
 				"<eof>",
 			},
 		},
+		{
+			caption: "the token causing a syntax error stays intact after the parser shifts it and reads the following tokens",
+			specSrc: `
+#name test;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq num semi_colon
+    | error semi_colon #recover
+    ;
+
+ws #skip
+    : "[\u{0020}]+";
+eq
+    : '=';
+semi_colon
+    : ';';
+id
+    : "[a-z]+";
+num
+    : "[0-9]+";
+`,
+			src:   `a = 1; b = ; c = 2; d = 3;`,
+			cause: `;`,
+			expected: []string{
+				"num",
+			},
+		},
 	}
 	for i, tt := range tests {
 		t.Run(fmt.Sprintf("#%v", i), func(t *testing.T) {
@@ -290,6 +424,14 @@ foo
 			if len(synErr.ExpectedTerminals) != len(tt.expected) {
 				t.Fatalf("unexpected lookahead symbols: want: %v, got: %v", tt.expected, synErr.ExpectedTerminals)
 			}
+			if len(synErr.ExpectedTerminalIDs) != len(synErr.ExpectedTerminals) {
+				t.Fatalf("the IDs of lookahead symbols don't correspond to the names: IDs: %v, names: %v", synErr.ExpectedTerminalIDs, synErr.ExpectedTerminals)
+			}
+			for i, id := range synErr.ExpectedTerminalIDs {
+				if gram.Syntactic.Terminals[id] != synErr.ExpectedTerminals[i] {
+					t.Errorf("the ID of a lookahead symbol doesn't correspond to the name: ID: %v, name: %v", id, synErr.ExpectedTerminals[i])
+				}
+			}
 			sort.Slice(tt.expected, func(i, j int) bool {
 				return tt.expected[i] < tt.expected[j]
 			})
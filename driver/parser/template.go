@@ -23,6 +23,10 @@ var parserCoreSrc string
 var semActSrc string
 
 func GenParser(cgram *spec.CompiledGrammar, pkgName string) ([]byte, error) {
+	if cgram.IsLexerOnly() {
+		return nil, fmt.Errorf("a lexer-only grammar cannot generate a parser: %v", cgram.Name)
+	}
+
 	var parserSrc string
 	{
 		fset := goToken.NewFileSet()
@@ -55,6 +59,7 @@ func GenParser(cgram *spec.CompiledGrammar, pkgName string) ([]byte, error) {
 			"nonTerminalCount": cgram.Syntactic.NonTerminalCount,
 			"eofSymbol":        cgram.Syntactic.EOFSymbol,
 			"errorSymbol":      cgram.Syntactic.ErrorSymbol,
+			"hash":             cgram.Hash,
 		})
 		if err != nil {
 			return nil, err
@@ -71,7 +76,9 @@ func GenParser(cgram *spec.CompiledGrammar, pkgName string) ([]byte, error) {
 		}
 
 		var b strings.Builder
-		err = t.Execute(&b, nil)
+		err = t.Execute(&b, map[string]interface{}{
+			"fallbackTerminal": cgram.Syntactic.FallbackTerminal,
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -114,17 +121,26 @@ func GenParser(cgram *spec.CompiledGrammar, pkgName string) ([]byte, error) {
 
 	f.Name = ast.NewIdent(pkgName)
 
-	// Complete an import statement.
+	// Complete an import statement. The lexer part needs the io package, which the parser part may already import.
 	for _, d := range f.Decls {
 		gd, ok := d.(*ast.GenDecl)
 		if !ok || gd.Tok != token.IMPORT {
 			continue
 		}
-		gd.Specs = append(gd.Specs, &ast.ImportSpec{
-			Path: &ast.BasicLit{
-				Value: `"io"`,
-			},
-		})
+		imported := false
+		for _, s := range gd.Specs {
+			if is, ok := s.(*ast.ImportSpec); ok && is.Path.Value == `"io"` {
+				imported = true
+				break
+			}
+		}
+		if !imported {
+			gd.Specs = append(gd.Specs, &ast.ImportSpec{
+				Path: &ast.BasicLit{
+					Value: `"io"`,
+				},
+			})
+		}
 		break
 	}
 
@@ -138,6 +154,9 @@ func GenParser(cgram *spec.CompiledGrammar, pkgName string) ([]byte, error) {
 }
 
 const grammarSrcTmplate = `
+// GrammarHash is the content hash of the compiled grammar that the parser was generated from.
+const GrammarHash = {{ printf "%q" .hash }}
+
 type grammarImpl struct {
 	recoverProductions      []int
 	action                  []int
@@ -148,7 +167,15 @@ type grammarImpl struct {
 	lhsSymbols              []int
 	terminals               []string
 	terminalSkip            []int
+	terminalKeep            []int
 	astActions              [][]int
+	astNodeNames            []string
+	astLifts                []int
+	entryPoints             map[string][2]int
+	lexModeActions          []int
+	layout                  []int
+	errorSyncTerminals      [][]int
+	alternativeLabels       []string
 }
 
 func NewGrammar() *grammarImpl {
@@ -162,7 +189,15 @@ func NewGrammar() *grammarImpl {
 		lhsSymbols:              {{ genLHSSymbols }},
 		terminals:               {{ genTerminals }},
 		terminalSkip:            {{ genTerminalSkip }},
+		terminalKeep:            {{ genTerminalKeep }},
 		astActions:              {{ genASTActions }},
+		astNodeNames:            {{ genASTNodeNames }},
+		astLifts:                {{ genASTLifts }},
+		entryPoints:             {{ genEntryPoints }},
+		lexModeActions:          {{ genLexModeActions }},
+		layout:                  {{ genLayout }},
+		errorSyncTerminals:      {{ genErrorSyncTerminals }},
+		alternativeLabels:       {{ genAlternativeLabels }},
 	}
 }
 
@@ -174,6 +209,14 @@ func (g *grammarImpl) StartProduction() int {
 	return {{ .startProduction }}
 }
 
+func (g *grammarImpl) EntryPoint(symbol string) (int, int, bool) {
+	e, ok := g.entryPoints[symbol]
+	if !ok {
+		return 0, 0, false
+	}
+	return e[0], e[1], true
+}
+
 func (g *grammarImpl) RecoverProduction(prod int) bool {
 	return g.recoverProductions[prod] != 0
 }
@@ -198,6 +241,13 @@ func (g *grammarImpl) SkipTerminal(terminal int) bool {
 	return g.terminalSkip[terminal] == 1
 }
 
+func (g *grammarImpl) KeepTerminal(terminal int) bool {
+	if len(g.terminalKeep) == 0 {
+		return false
+	}
+	return g.terminalKeep[terminal] == 1
+}
+
 func (g *grammarImpl) ErrorTrapperState(state int) bool {
 	return g.errorTrapperStates[state] != 0
 }
@@ -225,6 +275,48 @@ func (g *grammarImpl) Terminal(terminal int) string {
 func (g *grammarImpl) ASTAction(prod int) []int {
 	return g.astActions[prod]
 }
+
+func (g *grammarImpl) ASTNodeName(prod int) string {
+	if len(g.astNodeNames) == 0 {
+		return ""
+	}
+	return g.astNodeNames[prod]
+}
+
+func (g *grammarImpl) ASTLift(prod int) int {
+	if len(g.astLifts) == 0 {
+		return 0
+	}
+	return g.astLifts[prod]
+}
+
+func (g *grammarImpl) Layout() (int, int, int, bool) {
+	if len(g.layout) == 0 {
+		return 0, 0, 0, false
+	}
+	return g.layout[0], g.layout[1], g.layout[2], true
+}
+
+func (g *grammarImpl) LexModeAction(state int, terminal int) int {
+	if len(g.lexModeActions) == 0 {
+		return 0
+	}
+	return g.lexModeActions[state*{{ .terminalCount }}+terminal]
+}
+
+func (g *grammarImpl) ErrorSyncTerminals(state int) []int {
+	if len(g.errorSyncTerminals) == 0 {
+		return nil
+	}
+	return g.errorSyncTerminals[state]
+}
+
+func (g *grammarImpl) AlternativeLabel(prod int) string {
+	if len(g.alternativeLabels) == 0 {
+		return ""
+	}
+	return g.alternativeLabels[prod]
+}
 `
 
 func genGrammarTemplateFuncs(cgram *spec.CompiledGrammar) template.FuncMap {
@@ -248,6 +340,18 @@ func genGrammarTemplateFuncs(cgram *spec.CompiledGrammar) template.FuncMap {
 			fmt.Fprintf(&b, "}")
 			return b.String()
 		},
+		"genEntryPoints": func() string {
+			syn := cgram.Syntactic
+			var b strings.Builder
+			fmt.Fprintf(&b, "map[string][2]int{\n")
+			start := strings.TrimSuffix(syn.NonTerminals[syn.LHSSymbols[syn.StartProduction]], "'")
+			fmt.Fprintf(&b, "%v: {%v, %v},\n", strconv.Quote(start), syn.InitialState, syn.StartProduction)
+			for _, e := range syn.EntryPoints {
+				fmt.Fprintf(&b, "%v: {%v, %v},\n", strconv.Quote(e.Symbol), e.InitialState, e.StartProduction)
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		},
 		"genAction": func() string {
 			var b strings.Builder
 			fmt.Fprintf(&b, "[]int{\n")
@@ -380,11 +484,35 @@ func genGrammarTemplateFuncs(cgram *spec.CompiledGrammar) template.FuncMap {
 			fmt.Fprintf(&b, "}")
 			return b.String()
 		},
+		"genTerminalKeep": func() string {
+			if len(cgram.Syntactic.TerminalKeep) == 0 {
+				return "nil"
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "[]int{\n")
+			c := 1
+			for _, v := range cgram.Syntactic.TerminalKeep {
+				fmt.Fprintf(&b, "%v, ", v)
+				if c == 20 {
+					fmt.Fprintf(&b, "\n")
+					c = 1
+				} else {
+					c++
+				}
+			}
+			if c > 1 {
+				fmt.Fprintf(&b, "\n")
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		},
 		"genASTActions": func() string {
 			var b strings.Builder
 			fmt.Fprintf(&b, "[][]int{\n")
 			for _, entries := range cgram.ASTAction.Entries {
-				if len(entries) == 0 {
+				// An empty non-nil entry means an AST node has no child, so we must distinguish it from nil.
+				if entries == nil {
 					fmt.Fprintf(&b, "nil,\n")
 					continue
 				}
@@ -408,6 +536,109 @@ func genGrammarTemplateFuncs(cgram *spec.CompiledGrammar) template.FuncMap {
 			fmt.Fprintf(&b, "}")
 			return b.String()
 		},
+		"genErrorSyncTerminals": func() string {
+			if len(cgram.Syntactic.ErrorSyncTerminals) == 0 {
+				return "nil"
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "[][]int{\n")
+			for _, terms := range cgram.Syntactic.ErrorSyncTerminals {
+				if len(terms) == 0 {
+					fmt.Fprintf(&b, "nil,\n")
+					continue
+				}
+				fmt.Fprintf(&b, "{")
+				for i, v := range terms {
+					if i > 0 {
+						fmt.Fprintf(&b, ", ")
+					}
+					fmt.Fprintf(&b, "%v", v)
+				}
+				fmt.Fprintf(&b, "},\n")
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		},
+		"genASTNodeNames": func() string {
+			if len(cgram.ASTAction.NodeNames) == 0 {
+				return "nil"
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "[]string{\n")
+			for _, v := range cgram.ASTAction.NodeNames {
+				fmt.Fprintf(&b, "%#v,\n", v)
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		},
+		"genAlternativeLabels": func() string {
+			if len(cgram.Syntactic.AlternativeLabels) == 0 {
+				return "nil"
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "[]string{\n")
+			for _, v := range cgram.Syntactic.AlternativeLabels {
+				fmt.Fprintf(&b, "%#v,\n", v)
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		},
+		"genLayout": func() string {
+			lay := cgram.Syntactic.Layout
+			if lay == nil {
+				return "nil"
+			}
+			return fmt.Sprintf("[]int{%v, %v, %v}", lay.Indent, lay.Dedent, lay.Newline)
+		},
+		"genLexModeActions": func() string {
+			if len(cgram.Syntactic.LexModeActions) == 0 {
+				return "nil"
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "[]int{\n")
+			c := 1
+			for _, v := range cgram.Syntactic.LexModeActions {
+				fmt.Fprintf(&b, "%v, ", v)
+				if c == 20 {
+					fmt.Fprintf(&b, "\n")
+					c = 1
+				} else {
+					c++
+				}
+			}
+			if c > 1 {
+				fmt.Fprintf(&b, "\n")
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		},
+		"genASTLifts": func() string {
+			if len(cgram.ASTAction.Lifts) == 0 {
+				return "nil"
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "[]int{\n")
+			c := 1
+			for _, v := range cgram.ASTAction.Lifts {
+				fmt.Fprintf(&b, "%v, ", v)
+				if c == 20 {
+					fmt.Fprintf(&b, "\n")
+					c = 1
+				} else {
+					c++
+				}
+			}
+			if c > 1 {
+				fmt.Fprintf(&b, "\n")
+			}
+			fmt.Fprintf(&b, "}")
+			return b.String()
+		},
 	}
 }
 
@@ -443,13 +674,25 @@ func (t *vToken) Position() (int, int) {
 
 var kindToTerminal = {{ genKindToTerminal }}
 
+// fallbackTerminal is the terminal symbol of invalid tokens. When it is 0, invalid tokens have no terminal symbol.
+const fallbackTerminal = {{ .fallbackTerminal }}
+
+// vTokenChunkLen is the number of tokens in a chunk tokenStream allocates tokens from.
+const vTokenChunkLen = 128
+
 type tokenStream struct {
 	lex            *Lexer
 	kindToTerminal []int
+
+	// chunk is a chunk of memory that the stream allocates tokens from to reduce allocations per token.
+	chunk []vToken
+
+	// spare is a token the parser has released. The stream reads the next token into it instead of allocating one.
+	spare *vToken
 }
 
-func NewTokenStream(src io.Reader) (*tokenStream, error) {
-	lex, err := NewLexer(NewLexSpec(), src)
+func NewTokenStream(src io.Reader, opts ...LexerOption) (*tokenStream, error) {
+	lex, err := NewLexer(NewLexSpec(), src, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -460,15 +703,88 @@ func NewTokenStream(src io.Reader) (*tokenStream, error) {
 }
 
 func (t *tokenStream) Next() (VToken, error) {
-	tok, err := t.lex.Next()
+	vtok := t.spare
+	if vtok != nil {
+		t.spare = nil
+		err := t.lex.NextInto(vtok.tok)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		tok, err := t.lex.Next()
+		if err != nil {
+			return nil, err
+		}
+		if len(t.chunk) == 0 {
+			t.chunk = make([]vToken, vTokenChunkLen)
+		}
+		vtok = &t.chunk[0]
+		t.chunk = t.chunk[1:]
+		vtok.tok = tok
+	}
+	vtok.terminalID = kindToTerminal[vtok.tok.KindID]
+	if vtok.tok.Invalid {
+		vtok.terminalID = fallbackTerminal
+	}
+	return vtok, nil
+}
+
+func (t *tokenStream) releaseToken(tok VToken) {
+	if vtok, ok := tok.(*vToken); ok {
+		t.spare = vtok
+	}
+}
+
+func (t *tokenStream) PushMode(mode int) {
+	t.lex.PushMode(ModeID(mode))
+}
+
+func (t *tokenStream) PopMode() error {
+	return t.lex.PopMode()
+}
+
+// NewSourceTokenStream returns a token stream reading tokens from a source, such as a hand-written scanner, instead of
+// the lexer. The kind IDs of the tokens are the KindID constants.
+func NewSourceTokenStream(src TokenSource) TokenStream {
+	return newSourceTokenStream(src, kindToTerminal, fallbackTerminal)
+}
+
+// lexerTokenSource is a TokenSource adapting the lexer.
+type lexerTokenSource struct {
+	lex *Lexer
+}
+
+// NewLexerTokenSource returns a TokenSource reading tokens from the lexer. The source implements LexModeSource.
+func NewLexerTokenSource(lex *Lexer) LexModeSource {
+	return &lexerTokenSource{
+		lex: lex,
+	}
+}
+
+func (s *lexerTokenSource) Next() (*SourceToken, error) {
+	tok, err := s.lex.Next()
 	if err != nil {
 		return nil, err
 	}
-	return &vToken{
-		terminalID: kindToTerminal[tok.KindID],
-		tok:        tok,
+	return &SourceToken{
+		KindID:  tok.KindID.Int(),
+		Lexeme:  tok.Lexeme,
+		BytePos: tok.BytePos,
+		ByteLen: tok.ByteLen,
+		Row:     tok.Row,
+		Col:     tok.Col,
+		EOF:     tok.EOF,
+		Invalid: tok.Invalid,
 	}, nil
 }
+
+func (s *lexerTokenSource) PushMode(mode int) {
+	s.lex.PushMode(ModeID(mode))
+}
+
+func (s *lexerTokenSource) PopMode() error {
+	return s.lex.PopMode()
+}
 `
 
 func genLexerTemplateFuncs(cgram *spec.CompiledGrammar) template.FuncMap {
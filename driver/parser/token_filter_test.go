@@ -0,0 +1,213 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+type insertedToken struct {
+	VToken
+	terminalID int
+	lexeme     string
+}
+
+func (t *insertedToken) TerminalID() int {
+	return t.terminalID
+}
+
+func (t *insertedToken) Lexeme() []byte {
+	return []byte(t.lexeme)
+}
+
+func (t *insertedToken) EOF() bool {
+	return false
+}
+
+// newSemicolonInserter returns a filter that inserts a semicolon at the end of a line or an input not ending with
+// a semicolon.
+func newSemicolonInserter(semi int, skip func(term int) bool) TokenFilter {
+	return func(next TokenStream) TokenStream {
+		var pending VToken
+		var last VToken
+		return TokenStreamFunc(func() (VToken, error) {
+			if pending != nil {
+				tok := pending
+				pending = nil
+				last = tok
+				return tok, nil
+			}
+			tok, err := next.Next()
+			if err != nil {
+				return nil, err
+			}
+			if !tok.EOF() && skip(tok.TerminalID()) {
+				return tok, nil
+			}
+			if last != nil && last.TerminalID() != semi {
+				lastRow, _ := last.Position()
+				row, _ := tok.Position()
+				if tok.EOF() || row != lastRow {
+					pending = tok
+					last = &insertedToken{
+						VToken:     tok,
+						terminalID: semi,
+						lexeme:     ";",
+					}
+					return last, nil
+				}
+			}
+			last = tok
+			return tok, nil
+		})
+	}
+}
+
+func TestParserWithTokenFilters(t *testing.T) {
+	specSrc := `
+#name test;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq expr semi
+    ;
+expr
+    : expr add term
+    | term
+    ;
+term
+    : id
+    | num
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+eq
+    : '=';
+semi
+    : ';';
+add
+    : '+';
+id
+    : "[a-z]+";
+num
+    : "[0-9]+";
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	semi := -1
+	for term, name := range cg.Syntactic.Terminals {
+		if name == "semi" {
+			semi = term
+			break
+		}
+	}
+	gram := NewGrammar(cg)
+
+	assign := func(id string, num string) *Node {
+		return nonTermNode("stmt",
+			termNode("id", id),
+			termNode("eq", "="),
+			nonTermNode("expr",
+				nonTermNode("term",
+					termNode("num", num),
+				),
+			),
+			termNode("semi", ";"),
+		)
+	}
+
+	t.Run("a filter can insert tokens", func(t *testing.T) {
+		src := `a = 1
+b = 2; c = 3
+`
+		toks, err := NewTokenStream(cg, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tb := NewDefaultSyntaxTreeBuilder()
+		p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)), TokenFilters(newSemicolonInserter(semi, gram.SkipTerminal)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.SyntaxErrors()) > 0 {
+			t.Fatalf("unexpected syntax errors occurred: %v", p.SyntaxErrors()[0])
+		}
+		testTree(t, tb.Tree(), nonTermNode("stmts",
+			nonTermNode("stmts",
+				nonTermNode("stmts",
+					assign("a", "1"),
+				),
+				assign("b", "2"),
+			),
+			assign("c", "3"),
+		))
+	})
+
+	t.Run("filters are applied in order", func(t *testing.T) {
+		toks, err := NewTokenStream(cg, strings.NewReader("a = 1\nb = 2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		semiCount := 0
+		counter := func(next TokenStream) TokenStream {
+			return TokenStreamFunc(func() (VToken, error) {
+				tok, err := next.Next()
+				if err == nil && tok.TerminalID() == semi {
+					semiCount++
+				}
+				return tok, err
+			})
+		}
+		p, err := NewParser(toks, gram, TokenFilters(newSemicolonInserter(semi, gram.SkipTerminal), counter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.SyntaxErrors()) > 0 {
+			t.Fatalf("unexpected syntax errors occurred: %v", p.SyntaxErrors()[0])
+		}
+		if semiCount != 2 {
+			t.Fatalf("the second filter must read the tokens the first filter inserts; want: %v, got: %v", 2, semiCount)
+		}
+	})
+
+	t.Run("a filter must return a token stream", func(t *testing.T) {
+		toks, err := NewTokenStream(cg, strings.NewReader(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = NewParser(toks, gram, TokenFilters(func(next TokenStream) TokenStream {
+			return nil
+		}))
+		if err == nil {
+			t.Fatal("an error must occur")
+		}
+	})
+}
@@ -0,0 +1,241 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	"github.com/nihei9/vartan/grammar"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+// wordScanner is a hand-written scanner splitting a source into words and commas. It skips spaces itself.
+type wordScanner struct {
+	src   string
+	pos   int
+	kinds map[string]int
+}
+
+func (s *wordScanner) Next() (*SourceToken, error) {
+	for s.pos < len(s.src) && s.src[s.pos] == ' ' {
+		s.pos++
+	}
+	if s.pos >= len(s.src) {
+		return &SourceToken{
+			BytePos: s.pos,
+			Col:     s.pos,
+			EOF:     true,
+		}, nil
+	}
+	start := s.pos
+	kind := "comma"
+	if s.src[s.pos] == ',' {
+		s.pos++
+	} else {
+		kind = "word"
+		for s.pos < len(s.src) && s.src[s.pos] != ' ' && s.src[s.pos] != ',' {
+			s.pos++
+		}
+	}
+	return &SourceToken{
+		KindID:  s.kinds[kind],
+		Lexeme:  []byte(s.src[start:s.pos]),
+		BytePos: start,
+		ByteLen: s.pos - start,
+		Col:     start,
+	}, nil
+}
+
+func TestParserWithTokenSource(t *testing.T) {
+	specSrc := `
+#name test;
+
+list
+    : list comma word
+    | word
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+comma
+    : ',';
+word
+    : "[a-z]+";
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]int{}
+	for id, name := range cg.Lexical.KindNames {
+		kinds[name.String()] = id
+	}
+
+	expected := nonTermNode("list",
+		nonTermNode("list",
+			termNode("word", "foo"),
+		),
+		termNode("comma", ","),
+		termNode("word", "bar"),
+	)
+
+	parse := func(t *testing.T, toks TokenStream) *Node {
+		t.Helper()
+		gram := NewGrammar(cg)
+		tb := NewDefaultSyntaxTreeBuilder()
+		p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.SyntaxErrors()) > 0 {
+			t.Fatalf("unexpected syntax errors occurred: %v", p.SyntaxErrors()[0])
+		}
+		return tb.Tree()
+	}
+
+	t.Run("a hand-written scanner feeds tokens to the parser", func(t *testing.T) {
+		toks, err := NewSourceTokenStream(cg, &wordScanner{
+			src:   "foo , bar",
+			kinds: kinds,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		testTree(t, parse(t, toks), expected)
+	})
+
+	t.Run("the built-in lexer can be a token source", func(t *testing.T) {
+		lex, err := lexer.NewLexer(lexer.NewLexSpec(cg.Lexical), strings.NewReader("foo, bar"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		shared, err := NewSharedGrammar(cg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testTree(t, parse(t, shared.NewSourceTokenStream(NewLexerTokenSource(lex))), expected)
+	})
+
+	t.Run("a token having an unknown kind ID is an error", func(t *testing.T) {
+		toks, err := NewSourceTokenStream(cg, &wordScanner{
+			src: "foo",
+			kinds: map[string]int{
+				"word": len(cg.Lexical.KindNames),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = toks.Next()
+		if err == nil {
+			t.Fatal("an expected error didn't occur")
+		}
+	})
+
+	t.Run("a lexer-only grammar cannot make a token stream", func(t *testing.T) {
+		_, err := NewSourceTokenStream(&spec.CompiledGrammar{
+			Name:    "test",
+			Lexical: cg.Lexical,
+		}, &wordScanner{})
+		if err == nil {
+			t.Fatal("an expected error didn't occur")
+		}
+	})
+}
+
+func TestParserWithTokenSource_LexModes(t *testing.T) {
+	specSrc := `
+#name test;
+
+exprs
+    : exprs semi expr
+    | expr
+    ;
+expr
+    : id
+    | regex
+    ;
+regex
+    : div regex_body regex_close #push regex div #pop regex_close
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+semi
+    : ';';
+div
+    : '/';
+id
+    : "[a-z]+";
+regex_body #mode regex
+    : "[^/]+";
+regex_close #mode regex
+    : '/';
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lex, err := lexer.NewLexer(lexer.NewLexSpec(cg.Lexical), strings.NewReader("a; /b c/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	toks, err := NewSourceTokenStream(cg, NewLexerTokenSource(lex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := toks.(LexModeController); !ok {
+		t.Fatal("a token stream reading a LexModeSource must implement LexModeController")
+	}
+
+	gram := NewGrammar(cg)
+	tb := NewDefaultSyntaxTreeBuilder()
+	p, err := NewParser(toks, gram, SemanticAction(NewCSTActionSet(gram, tb)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.SyntaxErrors()) > 0 {
+		t.Fatalf("unexpected syntax errors occurred: %v", p.SyntaxErrors()[0])
+	}
+	testTree(t, tb.Tree(), nonTermNode("exprs",
+		nonTermNode("exprs",
+			nonTermNode("expr",
+				termNode("id", "a"),
+			),
+		),
+		termNode("semi", ";"),
+		nonTermNode("expr",
+			nonTermNode("regex",
+				termNode("div", "/"),
+				termNode("regex_body", "b c"),
+				termNode("regex_close", "/"),
+			),
+		),
+	))
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/nihei9/vartan/driver/lexer"
@@ -36,30 +37,134 @@ func (t *vToken) Position() (int, int) {
 	return t.tok.Row, t.tok.Col
 }
 
+// vTokenChunkLen is the number of tokens in a chunk tokenStream allocates tokens from.
+const vTokenChunkLen = 128
+
 type tokenStream struct {
 	lex            *lexer.Lexer
 	kindToTerminal []int
+
+	// fallbackTerminal is the terminal symbol of invalid tokens. When it is 0, invalid tokens have no terminal symbol.
+	fallbackTerminal int
+
+	// chunk is a chunk of memory that the stream allocates tokens from to reduce allocations per token.
+	chunk []vToken
+
+	// spare is a token the parser has released. The stream reads the next token into it instead of allocating one.
+	spare *vToken
 }
 
-func NewTokenStream(g *spec.CompiledGrammar, src io.Reader) (TokenStream, error) {
-	lex, err := lexer.NewLexer(lexer.NewLexSpec(g.Lexical), src)
+func NewTokenStream(g *spec.CompiledGrammar, src io.Reader, opts ...lexer.LexerOption) (TokenStream, error) {
+	if g.IsLexerOnly() {
+		return nil, fmt.Errorf("a lexer-only grammar cannot make a token stream for a parser: %v", g.Name)
+	}
+	err := g.CheckCompatibility()
+	if err != nil {
+		return nil, err
+	}
+
+	lex, err := lexer.NewLexer(lexer.NewLexSpec(g.Lexical), src, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	return &tokenStream{
-		lex:            lex,
-		kindToTerminal: g.Syntactic.KindToTerminal,
+		lex:              lex,
+		kindToTerminal:   g.Syntactic.KindToTerminal,
+		fallbackTerminal: g.Syntactic.FallbackTerminal,
 	}, nil
 }
 
 func (l *tokenStream) Next() (VToken, error) {
-	tok, err := l.lex.Next()
+	vtok := l.spare
+	if vtok != nil {
+		l.spare = nil
+		err := l.lex.NextInto(vtok.tok)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		tok, err := l.lex.Next()
+		if err != nil {
+			return nil, err
+		}
+		if len(l.chunk) == 0 {
+			l.chunk = make([]vToken, vTokenChunkLen)
+		}
+		vtok = &l.chunk[0]
+		l.chunk = l.chunk[1:]
+		vtok.tok = tok
+	}
+	vtok.terminalID = l.kindToTerminal[vtok.tok.KindID]
+	if vtok.tok.Invalid {
+		vtok.terminalID = l.fallbackTerminal
+	}
+	return vtok, nil
+}
+
+func (l *tokenStream) releaseToken(tok VToken) {
+	if vtok, ok := tok.(*vToken); ok {
+		l.spare = vtok
+	}
+}
+
+func (l *tokenStream) PushMode(mode int) {
+	l.lex.PushMode(lexer.ModeID(mode))
+}
+
+func (l *tokenStream) PopMode() error {
+	return l.lex.PopMode()
+}
+
+// NewSourceTokenStream returns a token stream reading tokens from a source, such as a hand-written scanner, instead of
+// the built-in lexer. The kind IDs of the tokens are the indices of the kind names in the lexical specification of
+// the grammar.
+func NewSourceTokenStream(g *spec.CompiledGrammar, src TokenSource) (TokenStream, error) {
+	if g.IsLexerOnly() {
+		return nil, fmt.Errorf("a lexer-only grammar cannot make a token stream for a parser: %v", g.Name)
+	}
+	err := g.CheckCompatibility()
+	if err != nil {
+		return nil, err
+	}
+
+	return newSourceTokenStream(src, g.Syntactic.KindToTerminal, g.Syntactic.FallbackTerminal), nil
+}
+
+// lexerTokenSource is a TokenSource adapting the built-in lexer.
+type lexerTokenSource struct {
+	lex *lexer.Lexer
+}
+
+// NewLexerTokenSource returns a TokenSource reading tokens from the built-in lexer. This is useful to wrap the lexer
+// with a scanner that produces some of the tokens itself. The source implements LexModeSource.
+func NewLexerTokenSource(lex *lexer.Lexer) LexModeSource {
+	return &lexerTokenSource{
+		lex: lex,
+	}
+}
+
+func (s *lexerTokenSource) Next() (*SourceToken, error) {
+	tok, err := s.lex.Next()
 	if err != nil {
 		return nil, err
 	}
-	return &vToken{
-		terminalID: l.kindToTerminal[tok.KindID],
-		tok:        tok,
+	return &SourceToken{
+		KindID:  tok.KindID.Int(),
+		Lexeme:  tok.Lexeme,
+		BytePos: tok.BytePos,
+		ByteLen: tok.ByteLen,
+		Row:     tok.Row,
+		Col:     tok.Col,
+		EOF:     tok.EOF,
+		Invalid: tok.Invalid,
 	}, nil
 }
+
+func (s *lexerTokenSource) PushMode(mode int) {
+	s.lex.PushMode(lexer.ModeID(mode))
+}
+
+func (s *lexerTokenSource) PopMode() error {
+	return s.lex.PopMode()
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestParserWithTrace(t *testing.T) {
+	specSrc := `
+#name test;
+
+list
+    : list comma id
+    | id
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+comma
+    : ',';
+id
+    : "[a-z]+";
+`
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func(t *testing.T, src string) []*TraceEvent {
+		t.Helper()
+		toks, err := NewTokenStream(cg, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var w bytes.Buffer
+		p, err := NewParser(toks, NewGrammar(cg), Trace(&w))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []*TraceEvent
+		for _, line := range strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n") {
+			e := &TraceEvent{}
+			err := json.Unmarshal([]byte(line), e)
+			if err != nil {
+				t.Fatalf("a trace line must be a JSON object: %v: %v", line, err)
+			}
+			events = append(events, e)
+		}
+		return events
+	}
+
+	actions := func(events []*TraceEvent) []string {
+		var as []string
+		for _, e := range events {
+			as = append(as, e.Action)
+		}
+		return as
+	}
+
+	t.Run("the parser traces every action", func(t *testing.T) {
+		events := parse(t, "a, b")
+		expected := []string{
+			TraceActionShift, TraceActionReduce, TraceActionGoTo,
+			TraceActionShift, TraceActionShift, TraceActionReduce, TraceActionGoTo,
+			TraceActionAccept,
+		}
+		if !reflect.DeepEqual(actions(events), expected) {
+			t.Fatalf("unexpected actions; want: %v, got: %v", expected, actions(events))
+		}
+
+		shift := events[0]
+		if shift.Symbol != "id" || shift.Depth != 2 {
+			t.Fatalf("unexpected shift event: %+v", shift)
+		}
+		if shift.Token == nil || *shift.Token != (TraceToken{Lexeme: "a", ByteLen: 1}) {
+			t.Fatalf("unexpected token of a shift event: %+v", shift.Token)
+		}
+		reduce := events[1]
+		if reduce.Symbol != "list" || reduce.State != shift.NextState || reduce.Depth != 1 {
+			t.Fatalf("unexpected reduce event: %+v", reduce)
+		}
+		if reduce.Token == nil || reduce.Token.Lexeme != "," || reduce.Token.Col != 1 {
+			t.Fatalf("a reduce event must have the lookahead token: %+v", reduce.Token)
+		}
+		goTo := events[2]
+		if goTo.Symbol != "list" || goTo.State != shift.State || goTo.Token != nil || goTo.Depth != 2 {
+			t.Fatalf("unexpected goto event: %+v", goTo)
+		}
+		shiftB := events[4]
+		if shiftB.Token == nil || shiftB.Token.Lexeme != "b" || shiftB.Token.BytePos != 3 || shiftB.Depth != 4 {
+			t.Fatalf("unexpected shift event: %+v", shiftB)
+		}
+		accept := events[len(events)-1]
+		if accept.Production != NewGrammar(cg).StartProduction() || accept.Token != nil {
+			t.Fatalf("unexpected accept event: %+v", accept)
+		}
+	})
+
+	t.Run("the parser traces syntax errors", func(t *testing.T) {
+		events := parse(t, "a b")
+		last := events[len(events)-1]
+		if last.Action != TraceActionError || last.Symbol != "id" || last.Token == nil || last.Token.Lexeme != "b" {
+			t.Fatalf("unexpected error event: %+v", last)
+		}
+	})
+
+	t.Run("a nil trace writer is an error", func(t *testing.T) {
+		toks, err := NewTokenStream(cg, strings.NewReader("a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = NewParser(toks, NewGrammar(cg), Trace(nil))
+		if err == nil {
+			t.Fatal("an expected error didn't occur")
+		}
+	})
+}
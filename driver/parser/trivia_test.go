@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestParserWithTrivia(t *testing.T) {
+	specSrc := `
+#name test;
+
+s
+    : s foo
+    | foo
+	;
+
+foo: 'foo';
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+comment #skip
+    : "#[^\u{000A}]*\u{000A}";
+`
+
+	src := "#c1\n foo  foo\t#c2\n"
+
+	ast, err := parser.Parse(strings.NewReader(specSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	gram, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("the parser discards trivia by default", func(t *testing.T) {
+		toks, err := NewTokenStream(gram, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := NewParser(toks, NewGrammar(gram))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(p.Trivia()) > 0 {
+			t.Fatalf("unexpected trivia: %+v", p.Trivia())
+		}
+	})
+
+	t.Run("the parser keeps trivia", func(t *testing.T) {
+		toks, err := NewTokenStream(gram, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := NewParser(toks, NewGrammar(gram), KeepTrivia())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lexemes := func(toks []VToken) []string {
+			var ls []string
+			for _, tok := range toks {
+				ls = append(ls, string(tok.Lexeme()))
+			}
+			return ls
+		}
+		testLexemes := func(t *testing.T, expected []string, actual []VToken) {
+			t.Helper()
+
+			ls := lexemes(actual)
+			if len(ls) != len(expected) {
+				t.Fatalf("unexpected trivia; want: %q, got: %q", expected, ls)
+			}
+			for i, e := range expected {
+				if ls[i] != e {
+					t.Fatalf("unexpected trivia; want: %q, got: %q", expected, ls)
+				}
+			}
+		}
+
+		testLexemes(t, []string{"#c1\n", " ", "  ", "\t", "#c2\n"}, p.Trivia())
+		// The first `foo` appears at the byte position 5.
+		testLexemes(t, []string{"#c1\n", " "}, p.LeadingTrivia(5))
+		// The second `foo` appears at the byte position 10.
+		testLexemes(t, []string{"  "}, p.LeadingTrivia(10))
+		// The trailing trivia is attached to the EOF token.
+		testLexemes(t, []string{"\t", "#c2\n"}, p.LeadingTrivia(len(src)))
+	})
+}
@@ -0,0 +1,98 @@
+// Package walk provides utilities to traverse, query, and rewrite syntax trees that the parser builds.
+package walk
+
+import (
+	"strings"
+
+	"github.com/nihei9/vartan/driver/parser"
+)
+
+// Visitor visits nodes of a syntax tree. Walk calls Enter before visiting the children of a node and Leave after
+// visiting them. When Enter returns false, Walk skips the children of the node, but it still calls Leave.
+type Visitor interface {
+	Enter(n *parser.Node) bool
+	Leave(n *parser.Node)
+}
+
+// Walk traverses a syntax tree in depth-first order calling the methods of a visitor.
+func Walk(root *parser.Node, v Visitor) {
+	if root == nil {
+		return
+	}
+	if v.Enter(root) {
+		for _, c := range root.Children {
+			Walk(c, v)
+		}
+	}
+	v.Leave(root)
+}
+
+// PreOrder traverses a syntax tree in pre-order. When f returns false, PreOrder skips the children of the node.
+func PreOrder(root *parser.Node, f func(n *parser.Node) bool) {
+	if root == nil {
+		return
+	}
+	if !f(root) {
+		return
+	}
+	for _, c := range root.Children {
+		PreOrder(c, f)
+	}
+}
+
+// PostOrder traverses a syntax tree in post-order.
+func PostOrder(root *parser.Node, f func(n *parser.Node)) {
+	if root == nil {
+		return
+	}
+	for _, c := range root.Children {
+		PostOrder(c, f)
+	}
+	f(root)
+}
+
+// Find returns nodes matching a kind path in pre-order. A kind path is a sequence of kind names separated by `/`,
+// such as `expr/term/id`, and each kind name must match a child of a node matching the preceding one. The first kind
+// name can match any node in the tree. `*` matches any kind name.
+func Find(root *parser.Node, path string) []*parser.Node {
+	kinds := strings.Split(path, "/")
+	var nodes []*parser.Node
+	PreOrder(root, func(n *parser.Node) bool {
+		nodes = append(nodes, match(n, kinds)...)
+		return true
+	})
+	return nodes
+}
+
+func match(n *parser.Node, kinds []string) []*parser.Node {
+	if n == nil || (kinds[0] != "*" && n.KindName != kinds[0]) {
+		return nil
+	}
+	if len(kinds) == 1 {
+		return []*parser.Node{n}
+	}
+	var nodes []*parser.Node
+	for _, c := range n.Children {
+		nodes = append(nodes, match(c, kinds[1:])...)
+	}
+	return nodes
+}
+
+// Rewrite returns a tree that f rewrites in post-order. Rewrite passes f a copy of each node whose children have
+// already been rewritten, and f returns a node replacing it. When f returns nil, Rewrite removes the node from its
+// parent. Rewrite doesn't modify the original tree.
+func Rewrite(root *parser.Node, f func(n *parser.Node) *parser.Node) *parser.Node {
+	if root == nil {
+		return nil
+	}
+	n := *root
+	if root.Children != nil {
+		n.Children = make([]*parser.Node, 0, len(root.Children))
+		for _, c := range root.Children {
+			if rc := Rewrite(c, f); rc != nil {
+				n.Children = append(n.Children, rc)
+			}
+		}
+	}
+	return f(&n)
+}
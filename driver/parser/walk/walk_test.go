@@ -0,0 +1,176 @@
+package walk
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/driver/parser"
+)
+
+func termNode(kind string, text string) *parser.Node {
+	return &parser.Node{
+		Type:     parser.NodeTypeTerminal,
+		KindName: kind,
+		Text:     text,
+	}
+}
+
+func nonTermNode(kind string, children ...*parser.Node) *parser.Node {
+	return &parser.Node{
+		Type:     parser.NodeTypeNonTerminal,
+		KindName: kind,
+		Children: children,
+	}
+}
+
+// genTree returns a tree of `a + b * 1`.
+func genTree() *parser.Node {
+	return nonTermNode("expr",
+		nonTermNode("expr",
+			nonTermNode("term",
+				termNode("id", "a"),
+			),
+		),
+		termNode("add", "+"),
+		nonTermNode("term",
+			nonTermNode("term",
+				termNode("id", "b"),
+			),
+			termNode("mul", "*"),
+			termNode("int", "1"),
+		),
+	)
+}
+
+type recorder struct {
+	events []string
+	skip   string
+}
+
+func (r *recorder) Enter(n *parser.Node) bool {
+	r.events = append(r.events, "enter:"+n.KindName)
+	return n.KindName != r.skip
+}
+
+func (r *recorder) Leave(n *parser.Node) {
+	r.events = append(r.events, "leave:"+n.KindName)
+}
+
+func TestWalk(t *testing.T) {
+	r := &recorder{
+		skip: "term",
+	}
+	Walk(genTree(), r)
+	expected := []string{
+		"enter:expr",
+		"enter:expr",
+		"enter:term",
+		"leave:term",
+		"leave:expr",
+		"enter:add",
+		"leave:add",
+		"enter:term",
+		"leave:term",
+		"leave:expr",
+	}
+	if !reflect.DeepEqual(r.events, expected) {
+		t.Fatalf("unexpected events: want: %v, got: %v", expected, r.events)
+	}
+}
+
+func TestPreOrderAndPostOrder(t *testing.T) {
+	var pre []string
+	PreOrder(genTree(), func(n *parser.Node) bool {
+		pre = append(pre, n.KindName)
+		return true
+	})
+	expectedPre := []string{"expr", "expr", "term", "id", "add", "term", "term", "id", "mul", "int"}
+	if !reflect.DeepEqual(pre, expectedPre) {
+		t.Fatalf("unexpected pre-order: want: %v, got: %v", expectedPre, pre)
+	}
+
+	var post []string
+	PostOrder(genTree(), func(n *parser.Node) {
+		post = append(post, n.KindName)
+	})
+	expectedPost := []string{"id", "term", "expr", "add", "id", "term", "mul", "int", "term", "expr"}
+	if !reflect.DeepEqual(post, expectedPost) {
+		t.Fatalf("unexpected post-order: want: %v, got: %v", expectedPost, post)
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{
+			path:     "id",
+			expected: []string{"a", "b"},
+		},
+		{
+			path:     "expr/term/id",
+			expected: []string{"a"},
+		},
+		{
+			path:     "term/term/id",
+			expected: []string{"b"},
+		},
+		{
+			path:     "expr/*/id",
+			expected: []string{"a"},
+		},
+		{
+			path:     "term/*",
+			expected: []string{"a", "", "*", "1", "b"},
+		},
+		{
+			path: "expr/id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var texts []string
+			for _, n := range Find(genTree(), tt.path) {
+				texts = append(texts, n.Text)
+			}
+			if !reflect.DeepEqual(texts, tt.expected) {
+				t.Fatalf("unexpected nodes: want: %v, got: %v", tt.expected, texts)
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	orig := genTree()
+	tree := Rewrite(orig, func(n *parser.Node) *parser.Node {
+		switch n.KindName {
+		case "add", "mul":
+			return nil
+		case "id":
+			n.Text = strings.ToUpper(n.Text)
+		}
+		return n
+	})
+
+	expected := nonTermNode("expr",
+		nonTermNode("expr",
+			nonTermNode("term",
+				termNode("id", "A"),
+			),
+		),
+		nonTermNode("term",
+			nonTermNode("term",
+				termNode("id", "B"),
+			),
+			termNode("int", "1"),
+		),
+	)
+	if !reflect.DeepEqual(tree, expected) {
+		t.Fatalf("unexpected tree")
+	}
+	if !reflect.DeepEqual(orig, genTree()) {
+		t.Fatalf("Rewrite must not modify the original tree")
+	}
+}
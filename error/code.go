@@ -0,0 +1,42 @@
+package error
+
+import "errors"
+
+// CodedError is an error having a stable code. A code never changes once it is assigned, so tools can use it to
+// suppress an error or to link to its explanation.
+//
+// Codes are grouped by the phase detecting the errors:
+//
+//   - V1xxx: syntax errors in a grammar
+//   - V2xxx: semantic errors in a grammar
+//   - V3xxx: syntax errors in a pattern of a terminal symbol
+type CodedError struct {
+	code    string
+	message string
+}
+
+func NewCodedError(code string, message string) *CodedError {
+	return &CodedError{
+		code:    code,
+		message: message,
+	}
+}
+
+func (e *CodedError) Error() string {
+	return e.message
+}
+
+func (e *CodedError) Code() string {
+	return e.code
+}
+
+// CodeOf returns a code of an error. When the error doesn't have a code, it returns an empty string.
+func CodeOf(err error) string {
+	var coded interface {
+		Code() string
+	}
+	if errors.As(err, &coded) {
+		return coded.Code()
+	}
+	return ""
+}
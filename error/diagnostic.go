@@ -0,0 +1,97 @@
+package error
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type Severity string
+
+const (
+	SeverityError   = Severity("error")
+	SeverityWarning = Severity("warning")
+)
+
+// Diagnostic is a machine-readable form of an error or a warning. Editors and CI tools can consume diagnostics
+// encoded as JSON. Rows and columns are 1-based, and they are 0 when a diagnostic has no position. An end position is
+// inclusive and equals the start position when a diagnostic covers only one character.
+type Diagnostic struct {
+	Severity Severity             `json:"severity"`
+	Code     string               `json:"code,omitempty"`
+	Message  string               `json:"message"`
+	File     string               `json:"file,omitempty"`
+	Row      int                  `json:"row"`
+	Col      int                  `json:"col"`
+	EndRow   int                  `json:"end_row"`
+	EndCol   int                  `json:"end_col"`
+	Related  []*DiagnosticRelated `json:"related,omitempty"`
+}
+
+// DiagnosticRelated is a location relevant to a diagnostic.
+type DiagnosticRelated struct {
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Row     int    `json:"row"`
+	Col     int    `json:"col"`
+}
+
+// Diagnostic converts the error or the warning into a diagnostic.
+func (e *SpecError) Diagnostic() *Diagnostic {
+	msg := e.Cause.Error()
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	file := e.SourceName
+	if file == "" {
+		file = e.FilePath
+	}
+	d := &Diagnostic{
+		Severity: e.severity(),
+		Code:     e.ErrorCode(),
+		Message:  msg,
+		File:     file,
+		Row:      e.Row,
+		Col:      e.Col,
+		EndRow:   e.EndRow,
+		EndCol:   e.EndCol,
+	}
+	if d.EndRow == 0 && d.EndCol == 0 {
+		d.EndRow = d.Row
+		d.EndCol = d.Col
+	}
+	for _, r := range e.Related {
+		d.Related = append(d.Related, &DiagnosticRelated{
+			Message: r.Message,
+			File:    file,
+			Row:     r.Row,
+			Col:     r.Col,
+		})
+	}
+	return d
+}
+
+// Diagnostics converts the errors into diagnostics.
+func (e SpecErrors) Diagnostics() []*Diagnostic {
+	diags := make([]*Diagnostic, 0, len(e))
+	for _, err := range e {
+		diags = append(diags, err.Diagnostic())
+	}
+	return diags
+}
+
+// WriteDiagnostics writes diagnostics as a JSON object having a `diagnostics` field.
+func WriteDiagnostics(w io.Writer, diags []*Diagnostic) error {
+	if diags == nil {
+		diags = []*Diagnostic{}
+	}
+	b, err := json.Marshal(struct {
+		Diagnostics []*Diagnostic `json:"diagnostics"`
+	}{
+		Diagnostics: diags,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -40,6 +40,27 @@ type SpecError struct {
 	SourceName string
 	Row        int
 	Col        int
+
+	// Code is a stable identifier of the kind of the error. When it is empty, the error has the code of its cause.
+	Code string
+
+	// EndRow and EndCol are the position of the last character the error covers. They are 0 when the error covers
+	// only the character at Row and Col.
+	EndRow int
+	EndCol int
+
+	// Related holds other locations relevant to the error, such as a previous definition of a duplicate symbol.
+	Related []*RelatedInfo
+
+	// Severity is SeverityError or SeverityWarning. An empty severity means SeverityError.
+	Severity Severity
+}
+
+// RelatedInfo is a location relevant to an error.
+type RelatedInfo struct {
+	Message string
+	Row     int
+	Col     int
 }
 
 func (e *SpecError) Error() string {
@@ -50,7 +71,11 @@ func (e *SpecError) Error() string {
 	if e.Row != 0 && e.Col != 0 {
 		fmt.Fprintf(&b, "%v:%v: ", e.Row, e.Col)
 	}
-	fmt.Fprintf(&b, "error: %v", e.Cause)
+	if code := e.ErrorCode(); code != "" {
+		fmt.Fprintf(&b, "%v[%v]: %v", e.severity(), code, e.Cause)
+	} else {
+		fmt.Fprintf(&b, "%v: %v", e.severity(), e.Cause)
+	}
 	if e.Detail != "" {
 		fmt.Fprintf(&b, ": %v", e.Detail)
 	}
@@ -63,6 +88,21 @@ func (e *SpecError) Error() string {
 	return b.String()
 }
 
+func (e *SpecError) severity() Severity {
+	if e.Severity == "" {
+		return SeverityError
+	}
+	return e.Severity
+}
+
+// ErrorCode returns the code of the error. When the Code field is empty, it returns the code of the cause.
+func (e *SpecError) ErrorCode() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return CodeOf(e.Cause)
+}
+
 func readLine(filePath string, row int) string {
 	if filePath == "" || row <= 0 {
 		return ""
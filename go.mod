@@ -2,7 +2,10 @@ module github.com/nihei9/vartan
 
 go 1.19
 
-require github.com/spf13/cobra v1.4.0
+require (
+	github.com/spf13/cobra v1.4.0
+	golang.org/x/text v0.14.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
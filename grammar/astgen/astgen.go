@@ -0,0 +1,480 @@
+// Package astgen generates typed AST definitions from a grammar.
+package astgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/grammar/lexical"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+// GoOption configures Go code generation.
+type GoOption func(config *goConfig)
+
+type goConfig struct {
+	standalone bool
+}
+
+// Standalone makes the generated code refer to the Node type in the same package instead of the Node type in the
+// driver package. Use this option when you put the generated code in the same package as a parser vartan-go generates.
+func Standalone() GoOption {
+	return func(config *goConfig) {
+		config.standalone = true
+	}
+}
+
+// child is an element of an alternative that appears as a child of an AST node.
+type child struct {
+	sym       string
+	label     string
+	expansion bool
+}
+
+// alternative is an alternative from the point of view of an AST node it generates.
+type alternative struct {
+	kindName string
+	children []*child
+
+	// When lift isn't nil, the alternative doesn't generate its own node.
+	lift *child
+}
+
+// field is a field of a struct representing an AST node.
+type field struct {
+	name   string
+	label  string
+	goType string
+}
+
+type astSchema struct {
+	terminals map[string]struct{}
+	alts      map[string][]*alternative
+
+	// kindNames is a list of kind names of AST nodes for non-terminal symbols in order of appearance.
+	kindNames []string
+
+	possibleKinds map[string][]string
+}
+
+// GenGo generates Go source code containing one struct type per kind of AST node and a function unmarshalling
+// a syntax tree into the structs. Fields of the structs correspond to labeled elements of alternatives.
+func GenGo(root *parser.RootNode, pkgName string, opts ...GoOption) ([]byte, error) {
+	config := &goConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	s, err := genSchema(root)
+	if err != nil {
+		return nil, err
+	}
+
+	nodePkg := "vartan."
+	if config.standalone {
+		nodePkg = ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by vartan astgen. DO NOT EDIT.\n")
+	fmt.Fprintf(&b, "package %v\n\n", pkgName)
+	fmt.Fprintf(&b, "import (\n")
+	fmt.Fprintf(&b, "\"fmt\"\n")
+	if !config.standalone {
+		fmt.Fprintf(&b, "\n")
+		fmt.Fprintf(&b, "vartan \"github.com/nihei9/vartan/driver/parser\"\n")
+	}
+	fmt.Fprintf(&b, ")\n\n")
+
+	fmt.Fprintf(&b, `// ASTNode is a node of a typed AST.
+type ASTNode interface {
+	astNode()
+}
+
+// Terminal is a node representing a terminal symbol.
+type Terminal struct {
+	KindName string
+	Text     string
+	Row      int
+	Col      int
+}
+
+func (n *Terminal) astNode() {}
+
+`)
+
+	structs := map[string][]*field{}
+	for _, kind := range s.kindNames {
+		fields, err := s.genFields(kind)
+		if err != nil {
+			return nil, err
+		}
+		structs[kind] = fields
+
+		fmt.Fprintf(&b, "// %v represents a node of kind `%v`.\n", structName(kind), kind)
+		fmt.Fprintf(&b, "type %v struct {\n", structName(kind))
+		for _, f := range fields {
+			fmt.Fprintf(&b, "%v %v\n", f.name, f.goType)
+		}
+		if len(fields) > 0 {
+			fmt.Fprintf(&b, "\n")
+		}
+		fmt.Fprintf(&b, "// Children holds all children of the node, whether or not they have labels.\n")
+		fmt.Fprintf(&b, "Children []ASTNode\n")
+		fmt.Fprintf(&b, "}\n\n")
+		fmt.Fprintf(&b, "func (n *%v) astNode() {}\n\n", structName(kind))
+	}
+
+	fmt.Fprintf(&b, `// UnmarshalAST converts a syntax tree into a typed AST.
+func UnmarshalAST(n *%vNode) (ASTNode, error) {
+	switch n.Type {
+	case %vNodeTypeError:
+		return &Terminal{
+			KindName: n.KindName,
+		}, nil
+	case %vNodeTypeTerminal:
+		return &Terminal{
+			KindName: n.KindName,
+			Text:     n.Text,
+			Row:      n.Row,
+			Col:      n.Col,
+		}, nil
+	}
+
+	children := make([]ASTNode, len(n.Children))
+	for i, c := range n.Children {
+		child, err := UnmarshalAST(c)
+		if err != nil {
+			return nil, err
+		}
+		children[i] = child
+	}
+
+	switch n.KindName {
+`, nodePkg, nodePkg, nodePkg)
+	for _, kind := range s.kindNames {
+		fmt.Fprintf(&b, "case %#v:\n", kind)
+		fmt.Fprintf(&b, "return unmarshal%v(n, children), nil\n", structName(kind))
+	}
+	fmt.Fprintf(&b, `	}
+	return nil, fmt.Errorf("unknown kind: %%v", n.KindName)
+}
+
+`)
+
+	for _, kind := range s.kindNames {
+		fmt.Fprintf(&b, "func unmarshal%v(n *%vNode, children []ASTNode) *%v {\n", structName(kind), nodePkg, structName(kind))
+		fmt.Fprintf(&b, "node := &%v{\n", structName(kind))
+		fmt.Fprintf(&b, "Children: children,\n")
+		fmt.Fprintf(&b, "}\n")
+		fields := map[string]*field{}
+		for _, f := range structs[kind] {
+			fields[f.label] = f
+		}
+		fmt.Fprintf(&b, "switch {\n")
+		for _, alt := range s.alts[kind] {
+			if !alt.hasLabel() || alt.hasExpansion() {
+				continue
+			}
+			fmt.Fprintf(&b, "case matchKinds(n, %v):\n", s.genKindsLiteral(alt))
+			for i, c := range alt.children {
+				if c.label == "" {
+					continue
+				}
+				f := fields[c.label]
+				if f.goType == "ASTNode" {
+					fmt.Fprintf(&b, "node.%v = children[%v]\n", f.name, i)
+				} else {
+					fmt.Fprintf(&b, "node.%v, _ = children[%v].(%v)\n", f.name, i, f.goType)
+				}
+			}
+		}
+		fmt.Fprintf(&b, "}\n")
+		fmt.Fprintf(&b, "return node\n")
+		fmt.Fprintf(&b, "}\n\n")
+	}
+
+	fmt.Fprintf(&b, `// matchKinds returns true when the children of a node have one of the kinds in each position.
+func matchKinds(n *%vNode, kinds [][]string) bool {
+	if len(n.Children) != len(kinds) {
+		return false
+	}
+	for i, c := range n.Children {
+		found := false
+		for _, k := range kinds[i] {
+			if c.KindName == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+`, nodePkg)
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+func genSchema(root *parser.RootNode) (*astSchema, error) {
+	// Generate the schema from the productions the parser has. `#expr` directives and snippet libraries add
+	// productions, and inline string literals become implicit terminal symbols, which syntax trees refer to by their
+	// generated names.
+	root, err := grammar.ExpandAST(root)
+	if err != nil {
+		return nil, err
+	}
+
+	omitPunct := false
+	for _, dir := range root.Directives {
+		if dir.Name == "omit_punctuation" {
+			omitPunct = true
+		}
+	}
+
+	s := &astSchema{
+		terminals: map[string]struct{}{
+			"error": {},
+		},
+		alts:          map[string][]*alternative{},
+		possibleKinds: map[string][]string{},
+	}
+	puncts := map[string]struct{}{}
+	for _, prod := range root.LexProductions {
+		s.terminals[prod.LHS] = struct{}{}
+		if lit, ok := prod.Literal(); ok && grammar.IsPunctuation(lit) {
+			puncts[prod.LHS] = struct{}{}
+		}
+	}
+
+	nonTermAlts := map[string][]*alternative{}
+	var nonTerms []string
+	for _, prod := range root.Productions {
+		if _, ok := nonTermAlts[prod.LHS]; !ok {
+			nonTerms = append(nonTerms, prod.LHS)
+		}
+		for _, a := range prod.RHS {
+			alt, err := genAlternative(prod.LHS, a, puncts, omitPunct)
+			if err != nil {
+				return nil, err
+			}
+			nonTermAlts[prod.LHS] = append(nonTermAlts[prod.LHS], alt)
+			if alt.lift != nil {
+				continue
+			}
+			if _, ok := s.alts[alt.kindName]; !ok {
+				s.kindNames = append(s.kindNames, alt.kindName)
+			}
+			s.alts[alt.kindName] = append(s.alts[alt.kindName], alt)
+		}
+	}
+
+	for _, nonTerm := range nonTerms {
+		s.possibleKinds[nonTerm] = s.findPossibleKinds(nonTerm, nonTermAlts, map[string]struct{}{})
+	}
+
+	return s, nil
+}
+
+func genAlternative(lhs string, a *parser.AlternativeNode, puncts map[string]struct{}, omitPunct bool) (*alternative, error) {
+	alt := &alternative{
+		kindName: lhs,
+	}
+
+	elems := map[string]*parser.ElementNode{}
+	for _, elem := range a.Elements {
+		if elem.Label != nil {
+			elems[elem.Label.Name] = elem
+		}
+		elems[elem.ID] = elem
+	}
+	lookup := func(param *parser.ParameterNode) (*child, error) {
+		elem, ok := elems[param.ID]
+		if !ok {
+			return nil, fmt.Errorf("%v:%v: a symbol was not found in an alternative: %v", param.Pos.Row, param.Pos.Col, param.ID)
+		}
+		c := &child{
+			sym:       elem.ID,
+			expansion: param.Expansion,
+		}
+		if elem.Label != nil {
+			c.label = elem.Label.Name
+		}
+		return c, nil
+	}
+
+	hasAST := false
+	for _, dir := range a.Directives {
+		switch dir.Name {
+		case "ast":
+			hasAST = true
+			for _, param := range dir.Parameters {
+				c, err := lookup(param)
+				if err != nil {
+					return nil, err
+				}
+				alt.children = append(alt.children, c)
+			}
+		case "rename":
+			alt.kindName = dir.Parameters[0].ID
+		case "lift":
+			c, err := lookup(dir.Parameters[0])
+			if err != nil {
+				return nil, err
+			}
+			alt.lift = c
+		}
+	}
+	if hasAST || alt.lift != nil {
+		return alt, nil
+	}
+
+	for _, elem := range a.Elements {
+		if _, ok := puncts[elem.ID]; ok && omitPunct {
+			continue
+		}
+		c := &child{
+			sym: elem.ID,
+		}
+		if elem.Label != nil {
+			c.label = elem.Label.Name
+		}
+		alt.children = append(alt.children, c)
+	}
+	return alt, nil
+}
+
+// findPossibleKinds returns kind names of nodes a symbol can generate. Because of the `#lift` and `#rename` directives,
+// a node of a non-terminal symbol can have a kind name different from the symbol.
+func (s *astSchema) findPossibleKinds(sym string, nonTermAlts map[string][]*alternative, visited map[string]struct{}) []string {
+	if _, ok := s.terminals[sym]; ok {
+		return []string{sym}
+	}
+	if _, ok := visited[sym]; ok {
+		return nil
+	}
+	visited[sym] = struct{}{}
+
+	kinds := map[string]struct{}{}
+	for _, alt := range nonTermAlts[sym] {
+		if alt.lift == nil {
+			kinds[alt.kindName] = struct{}{}
+			continue
+		}
+		for _, k := range s.findPossibleKinds(alt.lift.sym, nonTermAlts, visited) {
+			kinds[k] = struct{}{}
+		}
+	}
+	var ks []string
+	for k := range kinds {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+func (s *astSchema) kindsOf(sym string) []string {
+	if _, ok := s.terminals[sym]; ok {
+		return []string{sym}
+	}
+	return s.possibleKinds[sym]
+}
+
+func (s *astSchema) genFields(kind string) ([]*field, error) {
+	var fields []*field
+	types := map[string]string{}
+	for _, alt := range s.alts[kind] {
+		for _, c := range alt.children {
+			if c.label == "" || c.expansion {
+				continue
+			}
+			ty := s.goTypeOf(c.sym)
+			if prev, ok := types[c.label]; ok {
+				if prev != ty {
+					types[c.label] = "ASTNode"
+				}
+				continue
+			}
+			types[c.label] = ty
+			fields = append(fields, &field{
+				name:  lexical.SnakeCaseToUpperCamelCase(c.label),
+				label: c.label,
+			})
+		}
+	}
+	names := map[string]string{}
+	for _, f := range fields {
+		if f.name == "Children" {
+			return nil, fmt.Errorf("a label conflicts with the field `Children` of the struct `%v`: %v", structName(kind), f.label)
+		}
+		if l, ok := names[f.name]; ok {
+			return nil, fmt.Errorf("labels %v and %v conflict in the struct `%v`", l, f.label, structName(kind))
+		}
+		names[f.name] = f.label
+		f.goType = types[f.label]
+	}
+	return fields, nil
+}
+
+func (s *astSchema) goTypeOf(sym string) string {
+	if _, ok := s.terminals[sym]; ok {
+		return "*Terminal"
+	}
+	kinds := s.possibleKinds[sym]
+	if len(kinds) == 1 {
+		if _, ok := s.terminals[kinds[0]]; ok {
+			return "*Terminal"
+		}
+		return "*" + structName(kinds[0])
+	}
+	return "ASTNode"
+}
+
+func (s *astSchema) genKindsLiteral(alt *alternative) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "[][]string{")
+	for _, c := range alt.children {
+		fmt.Fprintf(&b, "{")
+		for i, k := range s.kindsOf(c.sym) {
+			if i > 0 {
+				fmt.Fprintf(&b, ", ")
+			}
+			fmt.Fprintf(&b, "%#v", k)
+		}
+		fmt.Fprintf(&b, "}, ")
+	}
+	fmt.Fprintf(&b, "}")
+	return b.String()
+}
+
+func (a *alternative) hasLabel() bool {
+	for _, c := range a.children {
+		if c.label != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *alternative) hasExpansion() bool {
+	for _, c := range a.children {
+		if c.expansion {
+			return true
+		}
+	}
+	return false
+}
+
+func structName(kind string) string {
+	return lexical.SnakeCaseToUpperCamelCase(kind) + "Node"
+}
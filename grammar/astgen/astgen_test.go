@@ -0,0 +1,207 @@
+package astgen
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestGenGo(t *testing.T) {
+	src := `
+#name test;
+#omit_punctuation;
+
+#prec (
+    #left add
+);
+
+expr
+    : expr@lhs add expr@rhs #ast lhs rhs #rename add_expr
+    | l_paren expr r_paren #lift expr
+    | id@name
+    ;
+
+l_paren
+    : '(';
+r_paren
+    : ')';
+add
+    : '+';
+id
+    : "[a-z]+";
+`
+	root, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opts       []GoOption
+		importPath bool
+	}{
+		{
+			importPath: true,
+		},
+		{
+			opts:       []GoOption{Standalone()},
+			importPath: false,
+		},
+	}
+	for _, tt := range tests {
+		code, err := GenGo(root, "test", tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fset := token.NewFileSet()
+		f, err := goparser.ParseFile(fset, "ast.go", code, 0)
+		if err != nil {
+			t.Fatalf("generated code is invalid: %v\n%v", err, string(code))
+		}
+
+		imported := false
+		for _, imp := range f.Imports {
+			if imp.Path.Value == `"github.com/nihei9/vartan/driver/parser"` {
+				imported = true
+			}
+		}
+		if imported != tt.importPath {
+			t.Fatalf("unexpected import; want: %v, got: %v", tt.importPath, imported)
+		}
+
+		expectedFields := map[string]map[string]string{
+			"AddExprNode": {
+				"Lhs":      "ASTNode",
+				"Rhs":      "ASTNode",
+				"Children": "[]ASTNode",
+			},
+			"ExprNode": {
+				"Name":     "*Terminal",
+				"Children": "[]ASTNode",
+			},
+		}
+		structs := map[string]map[string]string{}
+		ast.Inspect(f, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			fields := map[string]string{}
+			for _, field := range st.Fields.List {
+				for _, name := range field.Names {
+					fields[name.Name] = string(code[fset.Position(field.Type.Pos()).Offset:fset.Position(field.Type.End()).Offset])
+				}
+			}
+			structs[spec.Name.Name] = fields
+			return false
+		})
+		for name, eFields := range expectedFields {
+			fields, ok := structs[name]
+			if !ok {
+				t.Fatalf("struct %v was not found", name)
+			}
+			if len(fields) != len(eFields) {
+				t.Fatalf("unexpected fields of %v; want: %v, got: %v", name, eFields, fields)
+			}
+			for fName, fType := range eFields {
+				if fields[fName] != fType {
+					t.Fatalf("unexpected type of %v.%v; want: %v, got: %v", name, fName, fType, fields[fName])
+				}
+			}
+		}
+	}
+}
+
+func TestGenGo_InlineLiterals(t *testing.T) {
+	src := `
+#name test;
+
+#prec (
+    #left '+'
+);
+
+expr
+    : expr@lhs '+'@op expr@rhs #rename add_expr
+    | '(' expr ')' #lift expr
+    | id@name
+    ;
+
+id
+    : "[a-z]+";
+`
+	root, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := GenGo(root, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The string literal `'+'` defines the implicit terminal symbol `plus`, and syntax trees refer to it by the name.
+	kinds := `matchKinds(n, [][]string{{"add_expr", "expr"}, {"plus"}, {"add_expr", "expr"}})`
+	if !strings.Contains(string(code), kinds) {
+		t.Fatalf("generated code doesn't contain %v\n%v", kinds, string(code))
+	}
+	if strings.Contains(string(code), "{}, ") || strings.Contains(string(code), `{""}`) {
+		t.Fatalf("generated code contains an empty kind\n%v", string(code))
+	}
+	if !strings.Contains(string(code), "Op  *Terminal") {
+		t.Fatalf("a label of a string literal doesn't make a field of type *Terminal\n%v", string(code))
+	}
+}
+
+func TestGenGo_ExpandedProductions(t *testing.T) {
+	src := `
+#name test;
+#use list;
+
+#expr expr (
+    #operand int id
+    #group '(' ')'
+    #prefix '-'
+    #left '*'
+    #left '+' '-'
+);
+
+stmt
+    : list@values ';'
+    ;
+list_item
+    : expr@value
+    ;
+
+int
+    : "0|[1-9][0-9]*";
+id
+    : "[a-z]+";
+`
+	root, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := GenGo(root, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The `#expr` directive defines the production of `expr`, and the `#use` directive adds the production of `list`.
+	for _, s := range []string{
+		"type ExprNode struct",
+		"type ListNode struct",
+		"Values *ListNode",
+		"Value *ExprNode",
+	} {
+		if !strings.Contains(string(code), s) {
+			t.Fatalf("generated code doesn't contain %v\n%v", s, string(code))
+		}
+	}
+}
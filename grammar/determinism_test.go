@@ -0,0 +1,135 @@
+package grammar
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+// TestGrammarBuilderIsDeterministic builds a grammar using many features repeatedly and checks that the compiled
+// grammar and the report are byte-for-byte identical every time. Because the iteration order of maps varies from run
+// to run, building several times catches output depending on it.
+func TestGrammarBuilderIsDeterministic(t *testing.T) {
+	src := `
+#name test;
+#start expr;
+#prec (
+    #assign eq
+    #left add sub
+    #left mul div
+    #right $uminus
+);
+
+program
+    : program stmt
+    | stmt
+    ;
+stmt
+    : kw_let id@name eq expr semi #ast name expr
+    | kw_if expr block kw_else block
+    | kw_if expr block
+    | tag_open name_in_tag tag_close
+    | sel id semi
+    | error semi #recover
+    ;
+block
+    : l_brace program r_brace #ast program...
+    | l_brace r_brace
+    ;
+expr
+    : expr add expr
+    | expr sub expr
+    | expr mul expr
+    | expr div expr
+    | sub expr #prec $uminus
+    | expr eq expr
+    | l_paren expr r_paren #lift expr
+    | id
+    | num
+    | str
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+comment #skip
+    : "//[^\u{000A}]*";
+id #keywords kw_let kw_if kw_else
+    : "[a-z_][0-9a-z_]*";
+kw_let
+    : 'let';
+kw_if
+    : 'if';
+kw_else
+    : 'else';
+num
+    : "\f{digit}+";
+fragment digit
+    : "[0-9]";
+str
+    : "\"([^\"\\\\]|\\\\.)*\"";
+sel #case_configurable
+    : 'select';
+eq
+    : '=';
+add
+    : '+';
+sub
+    : '-';
+mul
+    : '*';
+div
+    : '/';
+semi
+    : ';';
+l_paren
+    : '(';
+r_paren
+    : ')';
+l_brace
+    : '{';
+r_brace
+    : '}';
+tag_open #push tag
+    : '<';
+tag_close #mode tag #pop
+    : '>';
+name_in_tag #mode tag
+    : "[a-z]+";
+`
+
+	var cgWant, reportWant []byte
+	for i := 0; i < 20; i++ {
+		ast, err := parser.Parse(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := GrammarBuilder{
+			AST: ast,
+		}
+		cg, report, err := b.Build(EnableReporting())
+		if err != nil {
+			t.Fatal(err)
+		}
+		cgJSON, err := json.Marshal(cg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reportJSON, err := json.Marshal(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			cgWant, reportWant = cgJSON, reportJSON
+			continue
+		}
+		if !bytes.Equal(cgJSON, cgWant) {
+			t.Fatalf("the compiled grammar differs between builds")
+		}
+		if !bytes.Equal(reportJSON, reportWant) {
+			t.Fatalf("the report differs between builds")
+		}
+	}
+}
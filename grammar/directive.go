@@ -0,0 +1,735 @@
+package grammar
+
+// DirectiveContext represents a place where a directive can appear.
+type DirectiveContext string
+
+const (
+	// DirectiveContextGrammar represents the top level of a grammar.
+	DirectiveContextGrammar = DirectiveContext("grammar")
+
+	// DirectiveContextPrecedence represents the inside of a directive group of a `#prec` directive.
+	DirectiveContextPrecedence = DirectiveContext("precedence")
+
+	// DirectiveContextExpression represents the inside of a directive group of an `#expr` directive.
+	DirectiveContextExpression = DirectiveContext("expression")
+
+	// DirectiveContextLexicalProduction represents a production defining a terminal symbol.
+	DirectiveContextLexicalProduction = DirectiveContext("lexical_production")
+
+	// DirectiveContextAlternative represents an alternative of a production defining a non-terminal symbol.
+	DirectiveContextAlternative = DirectiveContext("alternative")
+)
+
+// DirectiveParameterType represents a type of a directive parameter.
+type DirectiveParameterType string
+
+const (
+	DirectiveParameterTypeID             = DirectiveParameterType("id")
+	DirectiveParameterTypeNumber         = DirectiveParameterType("number")
+	DirectiveParameterTypePattern        = DirectiveParameterType("pattern")
+	DirectiveParameterTypeString         = DirectiveParameterType("string")
+	DirectiveParameterTypeOrderedSymbol  = DirectiveParameterType("ordered_symbol")
+	DirectiveParameterTypeLevelName      = DirectiveParameterType("level_name")
+	DirectiveParameterTypeExpansion      = DirectiveParameterType("expansion")
+	DirectiveParameterTypeDirectiveGroup = DirectiveParameterType("directive_group")
+)
+
+// DirectiveParameter describes a parameter a directive takes.
+type DirectiveParameter struct {
+	// Name is a name of the parameter used only for the description.
+	Name string `json:"name"`
+
+	// Types is a list of the types the parameter accepts.
+	Types []DirectiveParameterType `json:"types"`
+
+	// When Repeatable is true, the parameter can appear one or more times.
+	Repeatable bool `json:"repeatable"`
+
+	// When Optional is true, the parameter can be omitted.
+	Optional bool `json:"optional"`
+}
+
+// Directive describes a directive GrammarBuilder accepts.
+type Directive struct {
+	Name        string                `json:"name"`
+	Contexts    []DirectiveContext    `json:"contexts"`
+	Parameters  []*DirectiveParameter `json:"parameters"`
+	Description string                `json:"description"`
+}
+
+// directives is the catalog of the directives. GrammarBuilder refers to this catalog to know which directives are valid
+// in each context.
+var directives = []*Directive{
+	{
+		Name: "name",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "grammar_name",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Specifies the name of the grammar.",
+	},
+	{
+		Name: "meta",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "key",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+			{
+				Name: "value",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeString,
+				},
+			},
+		},
+		Description: "Attaches metadata to the grammar. Available keys are author, license, and version.",
+	},
+	{
+		Name: "prec",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "precedence_group",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeDirectiveGroup,
+				},
+			},
+		},
+		Description: "Defines precedence and associativity of symbols. Directives listed earlier in the group have higher precedence.",
+	},
+	{
+		Name: "expr",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+			{
+				Name: "operator_group",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeDirectiveGroup,
+				},
+			},
+		},
+		Description: "Defines the productions of an expression from its operands and operators, with their precedence and associativity. Directives listed earlier in the group have higher precedence.",
+	},
+	{
+		Name: "start",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Declares non-terminal symbols available as additional entry points of a parser besides the start symbol.",
+	},
+	{
+		Name: "layout",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "indent",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+			{
+				Name: "dedent",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+			{
+				Name: "newline",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Declares terminal symbols a parser synthesizes from leading white spaces of lines: an increase in indentation, a decrease in indentation, and the end of a line.",
+	},
+	{
+		Name: "fallback",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "terminal",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Declares a terminal symbol that invalid tokens become, so that syntactic rules and error recovery can handle them.",
+	},
+	{
+		Name: "omit_punctuation",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Description: "Makes alternatives having neither #ast nor #lift omit terminal symbols defined by string literals consisting only of punctuation characters from their AST nodes.",
+	},
+	{
+		Name: "dot_excludes_newline",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Description: "Makes `.` in patterns match any character except a newline (U+000A).",
+	},
+	{
+		Name: "mode_extends",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "mode",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+			{
+				Name: "base_mode",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Makes a lex mode inherit the terminal symbols of base modes, so that the mode recognizes them as well as its own terminal symbols.",
+	},
+	{
+		Name: "use",
+		Contexts: []DirectiveContext{
+			DirectiveContextGrammar,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "library",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+			{
+				Name: "prefix",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+				Optional: true,
+			},
+		},
+		Description: "Makes the fragments of a fragment library, such as `std`, available in patterns by their plain names. Patterns can also refer to them as `\\f{std.decimal}` without this directive. For a snippet library, such as `list` or `parens`, adds the productions of the library that the grammar refers to, and a prefix, only allowed for a snippet library, makes the names of the symbols of the library `<prefix>_<name>`.",
+	},
+	{
+		Name: "left",
+		Contexts: []DirectiveContext{
+			DirectiveContextPrecedence,
+			DirectiveContextExpression,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeOrderedSymbol,
+					DirectiveParameterTypeLevelName,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Assigns the left associativity and a precedence to symbols. A level name given as the first parameter names the precedence level.",
+	},
+	{
+		Name: "right",
+		Contexts: []DirectiveContext{
+			DirectiveContextPrecedence,
+			DirectiveContextExpression,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeOrderedSymbol,
+					DirectiveParameterTypeLevelName,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Assigns the right associativity and a precedence to symbols. A level name given as the first parameter names the precedence level.",
+	},
+	{
+		Name: "nonassoc",
+		Contexts: []DirectiveContext{
+			DirectiveContextPrecedence,
+			DirectiveContextExpression,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeOrderedSymbol,
+					DirectiveParameterTypeLevelName,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Assigns the non-associativity and a precedence to symbols. Symbols of the same precedence can't be chained without parentheses. A level name given as the first parameter names the precedence level.",
+	},
+	{
+		Name: "assign",
+		Contexts: []DirectiveContext{
+			DirectiveContextPrecedence,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeOrderedSymbol,
+					DirectiveParameterTypeLevelName,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Assigns only a precedence to symbols. A level name given as the first parameter names the precedence level.",
+	},
+	{
+		Name: "operand",
+		Contexts: []DirectiveContext{
+			DirectiveContextExpression,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeString,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Adds alternatives consisting of a single operand to an expression.",
+	},
+	{
+		Name: "group",
+		Contexts: []DirectiveContext{
+			DirectiveContextExpression,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "open",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeString,
+				},
+			},
+			{
+				Name: "close",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeString,
+				},
+			},
+		},
+		Description: "Adds an alternative enclosing an expression with two symbols, such as parentheses.",
+	},
+	{
+		Name: "prefix",
+		Contexts: []DirectiveContext{
+			DirectiveContextExpression,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "operator",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeString,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Adds alternatives applying prefix operators to an expression and assigns them a precedence.",
+	},
+	{
+		Name: "postfix",
+		Contexts: []DirectiveContext{
+			DirectiveContextExpression,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "operator",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeString,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Adds alternatives applying postfix operators to an expression and assigns them a precedence.",
+	},
+	{
+		Name: "mode",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "mode_name",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Specifies lex modes in which a terminal symbol is available.",
+	},
+	{
+		Name: "push",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "mode_name",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Pushes a lex mode onto the mode stack when the lexer recognizes a terminal symbol.",
+	},
+	{
+		Name: "pop",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Description: "Pops a lex mode from the mode stack when the lexer recognizes a terminal symbol.",
+	},
+	{
+		Name: "skip",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Description: "Makes the parser ignore a terminal symbol.",
+	},
+	{
+		Name: "case_configurable",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Description: "Allows the lexer to match a terminal symbol case-insensitively at run time.",
+	},
+	{
+		Name: "class",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "class",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Classifies a terminal symbol for syntax highlighting: keyword, operator, literal, or comment.",
+	},
+	{
+		Name: "priority",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "priority",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeNumber,
+				},
+			},
+		},
+		Description: "Gives a terminal symbol a priority, a non-negative integer. When the patterns of terminal symbols match a lexeme of the same length, the terminal symbol having the highest priority wins instead of the one defined first.",
+	},
+	{
+		Name: "keywords",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "keyword",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Assigns keywords to a terminal symbol. The lexer remaps a token to a keyword when the lexeme equals the keyword.",
+	},
+	{
+		Name: "trim",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Description: "Makes the lexer remove leading and trailing white spaces from the value of a token.",
+	},
+	{
+		Name: "dedent",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Description: "Makes the lexer remove the longest common indentation of lines from the value of a token.",
+	},
+	{
+		Name: "unescape",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "delimiter",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeString,
+				},
+			},
+		},
+		Description: "Makes the lexer interpret escape sequences in the value of a token. The optional `delimiter` parameter makes the lexer remove the delimiter from both ends of the value beforehand.",
+	},
+	{
+		Name: "normalize",
+		Contexts: []DirectiveContext{
+			DirectiveContextLexicalProduction,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "form",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Makes the lexer convert the value of a token into a Unicode normalization form: nfc, nfd, nfkc, or nfkd.",
+	},
+	{
+		Name: "ast",
+		Contexts: []DirectiveContext{
+			DirectiveContextAlternative,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol_or_label",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeExpansion,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Specifies the structure of an AST node.",
+	},
+	{
+		Name: "prec",
+		Contexts: []DirectiveContext{
+			DirectiveContextAlternative,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeOrderedSymbol,
+					DirectiveParameterTypeLevelName,
+				},
+			},
+		},
+		Description: "Makes an alternative inherit the precedence of a terminal symbol, an ordered symbol, or a named precedence level.",
+	},
+	{
+		Name: "prefer",
+		Contexts: []DirectiveContext{
+			DirectiveContextAlternative,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "action",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Resolves shift/reduce conflicts on reducing an alternative in favor of an action, shift or reduce, regardless of precedence.",
+	},
+	{
+		Name: "recover",
+		Contexts: []DirectiveContext{
+			DirectiveContextAlternative,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "until",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+				Optional: true,
+			},
+			{
+				Name: "terminal",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+					DirectiveParameterTypeString,
+				},
+				Repeatable: true,
+				Optional:   true,
+			},
+		},
+		Description: "Makes the parser recover from an error state when it reduces an alternative. The optional `until` parameters give the error symbol of the alternative a synchronization set: the parser discards tokens until one of the terminal symbols.",
+	},
+	{
+		Name: "label",
+		Contexts: []DirectiveContext{
+			DirectiveContextAlternative,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "label",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Gives an alternative a label that identifies it among the alternatives of its LHS in syntax trees and reports.",
+	},
+	{
+		Name: "rename",
+		Contexts: []DirectiveContext{
+			DirectiveContextAlternative,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "node_name",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Specifies the name of an AST node an alternative generates instead of the name of its LHS.",
+	},
+	{
+		Name: "lift",
+		Contexts: []DirectiveContext{
+			DirectiveContextAlternative,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol_or_label",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Replaces an AST node an alternative generates with the node of one of its elements.",
+	},
+	{
+		Name: "keep",
+		Contexts: []DirectiveContext{
+			DirectiveContextAlternative,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+				Repeatable: true,
+			},
+		},
+		Description: "Allows an alternative to contain terminal symbols having the skip directive. The parser doesn't skip tokens of the symbols wherever it can accept them.",
+	},
+	{
+		Name: "push",
+		Contexts: []DirectiveContext{
+			DirectiveContextAlternative,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "mode_name",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+			{
+				Name: "symbol_or_label",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Makes the parser push a lex mode onto the mode stack of the lexer when it shifts a terminal symbol of an alternative.",
+	},
+	{
+		Name: "pop",
+		Contexts: []DirectiveContext{
+			DirectiveContextAlternative,
+		},
+		Parameters: []*DirectiveParameter{
+			{
+				Name: "symbol_or_label",
+				Types: []DirectiveParameterType{
+					DirectiveParameterTypeID,
+				},
+			},
+		},
+		Description: "Makes the parser pop a lex mode from the mode stack of the lexer when it shifts a terminal symbol of an alternative.",
+	},
+}
+
+// Directives returns the catalog of the directives GrammarBuilder accepts.
+func Directives() []*Directive {
+	ds := make([]*Directive, len(directives))
+	copy(ds, directives)
+	return ds
+}
+
+// lookupDirective returns a directive that has a name `name` and can appear in a context `ctx`.
+func lookupDirective(ctx DirectiveContext, name string) (*Directive, bool) {
+	for _, d := range directives {
+		if d.Name != name {
+			continue
+		}
+		for _, c := range d.Contexts {
+			if c == ctx {
+				return d, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,43 @@
+package grammar
+
+import "testing"
+
+func TestDirectives(t *testing.T) {
+	type dirKey struct {
+		ctx  DirectiveContext
+		name string
+	}
+	defined := map[dirKey]struct{}{}
+	for _, d := range Directives() {
+		if d.Name == "" {
+			t.Fatalf("a directive must have a name")
+		}
+		if len(d.Contexts) == 0 {
+			t.Fatalf("directive '%v' must have at least one context", d.Name)
+		}
+		for _, ctx := range d.Contexts {
+			k := dirKey{
+				ctx:  ctx,
+				name: d.Name,
+			}
+			if _, ok := defined[k]; ok {
+				t.Fatalf("directive '%v' is defined twice in the context '%v'", d.Name, ctx)
+			}
+			defined[k] = struct{}{}
+
+			found, ok := lookupDirective(ctx, d.Name)
+			if !ok || found != d {
+				t.Fatalf("failed to look up directive '%v' in the context '%v'", d.Name, ctx)
+			}
+		}
+		for _, p := range d.Parameters {
+			if len(p.Types) == 0 {
+				t.Fatalf("parameter '%v' of directive '%v' must have at least one type", p.Name, d.Name)
+			}
+		}
+	}
+
+	if _, ok := lookupDirective(DirectiveContextGrammar, "mode"); ok {
+		t.Fatalf("'mode' directive must not be available at the top level")
+	}
+}
@@ -0,0 +1,317 @@
+package grammar
+
+import (
+	"fmt"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+// expandExprDirectives replaces `#expr` directives with the productions and the precedences they describe. An `#expr`
+// directive takes a non-terminal symbol and a directive group listing its operands and operators:
+//
+//	#expr expr (
+//	    #operand int id
+//	    #group l_paren r_paren
+//	    #prefix sub
+//	    #left mul div
+//	    #left add sub
+//	);
+//
+// `#operand` and `#group` don't have precedence. The other directives form precedence levels, and directives listed
+// earlier have higher precedence, as in a `#prec` directive. The levels of `#expr` directives come before the ones of
+// the `#prec` directive in the order of the `#expr` directives. The alternatives are added to the production of the
+// symbol when the grammar defines it. Otherwise, a new production is added at the end of the productions.
+//
+// This function doesn't modify `root`. When `root` has no `#expr` directive, this function returns `root` itself.
+func expandExprDirectives(root *parser.RootNode) (*parser.RootNode, verr.SpecErrors) {
+	var exprDirs []*parser.DirectiveNode
+	var dirs []*parser.DirectiveNode
+	var precDir *parser.DirectiveNode
+	for _, dir := range root.Directives {
+		switch dir.Name {
+		case "expr":
+			exprDirs = append(exprDirs, dir)
+			continue
+		case "prec":
+			if precDir == nil {
+				precDir = dir
+			}
+		}
+		dirs = append(dirs, dir)
+	}
+	if len(exprDirs) == 0 {
+		return root, nil
+	}
+
+	// Operators defined by lexical productions must be referred to by their names in precedence groups.
+	lit2Name := map[string]string{}
+	for _, prod := range root.LexProductions {
+		if lit, ok := prod.Literal(); ok {
+			if _, ok := lit2Name[lit]; !ok {
+				lit2Name[lit] = prod.LHS
+			}
+		}
+	}
+
+	prods := append([]*parser.ProductionNode{}, root.Productions...)
+	lhs2Index := map[string]int{}
+	for i, prod := range prods {
+		lhs2Index[prod.LHS] = i
+	}
+
+	var errs verr.SpecErrors
+	var precGroup []*parser.DirectiveNode
+	expanded := map[string]struct{}{}
+	for _, dir := range exprDirs {
+		e := &exprExpander{
+			dir:      dir,
+			lit2Name: lit2Name,
+		}
+		e.expand()
+		if len(e.errs) > 0 {
+			errs = append(errs, e.errs...)
+			continue
+		}
+		if _, ok := expanded[e.lhs]; ok {
+			errs = append(errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: fmt.Sprintf("'expr' directive is already applied to %v", e.lhs),
+				Row:    dir.Parameters[0].Pos.Row,
+				Col:    dir.Parameters[0].Pos.Col,
+			})
+			continue
+		}
+		expanded[e.lhs] = struct{}{}
+
+		if i, ok := lhs2Index[e.lhs]; ok {
+			p := *prods[i]
+			p.RHS = append(append([]*parser.AlternativeNode{}, prods[i].RHS...), e.alts...)
+			prods[i] = &p
+		} else {
+			if !e.hasOperand {
+				errs = append(errs, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: fmt.Sprintf("'expr' directive needs operands because no production defines %v", e.lhs),
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				})
+				continue
+			}
+			lhs2Index[e.lhs] = len(prods)
+			prods = append(prods, &parser.ProductionNode{
+				LHS: e.lhs,
+				RHS: e.alts,
+				Pos: dir.Pos,
+				End: dir.End,
+			})
+		}
+		precGroup = append(precGroup, e.precGroup...)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if len(precGroup) > 0 {
+		if precDir != nil && len(precDir.Parameters) == 1 && precDir.Parameters[0].Group != nil {
+			param := *precDir.Parameters[0]
+			param.Group = append(precGroup, precDir.Parameters[0].Group...)
+			d := *precDir
+			d.Parameters = []*parser.ParameterNode{&param}
+			for i, dir := range dirs {
+				if dir == precDir {
+					dirs[i] = &d
+				}
+			}
+		} else if precDir == nil {
+			dirs = append(dirs, &parser.DirectiveNode{
+				Name: "prec",
+				Parameters: []*parser.ParameterNode{
+					{
+						Group: precGroup,
+						Pos:   exprDirs[0].Pos,
+					},
+				},
+				Pos: exprDirs[0].Pos,
+			})
+		}
+	}
+
+	r := *root
+	r.Directives = dirs
+	r.Productions = prods
+	return &r, nil
+}
+
+type exprExpander struct {
+	dir      *parser.DirectiveNode
+	lit2Name map[string]string
+
+	lhs        string
+	alts       []*parser.AlternativeNode
+	precGroup  []*parser.DirectiveNode
+	hasOperand bool
+	errs       verr.SpecErrors
+}
+
+func (e *exprExpander) errorf(pos parser.Position, format string, a ...interface{}) {
+	e.errs = append(e.errs, &verr.SpecError{
+		Cause:  semErrDirInvalidParam,
+		Detail: fmt.Sprintf(format, a...),
+		Row:    pos.Row,
+		Col:    pos.Col,
+	})
+}
+
+func (e *exprExpander) expand() {
+	dir := e.dir
+	if len(dir.Parameters) != 2 || dir.Parameters[0].ID == "" || dir.Parameters[1].Group == nil {
+		e.errorf(dir.Pos, "'expr' directive needs an ID parameter and a directive group")
+		return
+	}
+	e.lhs = dir.Parameters[0].ID
+
+	level := 0
+	for _, d := range dir.Parameters[1].Group {
+		if _, ok := lookupDirective(DirectiveContextExpression, d.Name); !ok {
+			e.errs = append(e.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidName,
+				Detail: d.Name,
+				Row:    d.Pos.Row,
+				Col:    d.Pos.Col,
+			})
+			continue
+		}
+		if len(d.Parameters) == 0 {
+			e.errorf(d.Pos, "'%v' directive needs at least one symbol", d.Name)
+			continue
+		}
+		for _, p := range d.Parameters {
+			if p.ID == "" && p.String == "" {
+				e.errorf(p.Pos, "'%v' directive can take only IDs and string literals", d.Name)
+			}
+		}
+		if len(e.errs) > 0 {
+			continue
+		}
+
+		switch d.Name {
+		case "operand":
+			e.hasOperand = true
+			for _, p := range d.Parameters {
+				e.addAlt(d, nil, e.elem(p))
+			}
+		case "group":
+			if len(d.Parameters) != 2 {
+				e.errorf(d.Pos, "'group' directive needs an opening symbol and a closing symbol")
+				continue
+			}
+			e.hasOperand = true
+			e.addAlt(d, []*parser.DirectiveNode{
+				{
+					Name: "ast",
+					Parameters: []*parser.ParameterNode{
+						{
+							ID:  e.lhs,
+							Pos: d.Pos,
+						},
+					},
+					Pos: d.Pos,
+				},
+			}, e.elem(d.Parameters[0]), e.self(d.Pos), e.elem(d.Parameters[1]))
+		case "prefix":
+			level++
+			// A prefix operator can also be a binary operator having another precedence, so the alternatives refer to
+			// their precedence by an ordered symbol instead of the operator.
+			ordSym := fmt.Sprintf("%v_prefix_%v", e.lhs, level)
+			for _, p := range d.Parameters {
+				e.addAlt(d, []*parser.DirectiveNode{
+					{
+						Name: "prec",
+						Parameters: []*parser.ParameterNode{
+							{
+								OrderedSymbol: ordSym,
+								Pos:           p.Pos,
+							},
+						},
+						Pos: d.Pos,
+					},
+				}, e.elem(p), e.self(p.Pos))
+			}
+			e.addPrec("assign", d.Pos, []*parser.ParameterNode{
+				{
+					OrderedSymbol: ordSym,
+					Pos:           d.Pos,
+				},
+			})
+		case "postfix":
+			level++
+			for _, p := range d.Parameters {
+				e.addAlt(d, nil, e.self(p.Pos), e.elem(p))
+			}
+			e.addPrec("left", d.Pos, e.precParams(d.Parameters))
+		case "left", "right", "nonassoc":
+			level++
+			for _, p := range d.Parameters {
+				e.addAlt(d, nil, e.self(p.Pos), e.elem(p), e.self(p.Pos))
+			}
+			e.addPrec(d.Name, d.Pos, e.precParams(d.Parameters))
+		}
+	}
+}
+
+func (e *exprExpander) addAlt(d *parser.DirectiveNode, dirs []*parser.DirectiveNode, elems ...*parser.ElementNode) {
+	e.alts = append(e.alts, &parser.AlternativeNode{
+		Elements:   elems,
+		Directives: dirs,
+		Pos:        d.Pos,
+	})
+}
+
+func (e *exprExpander) addPrec(name string, pos parser.Position, params []*parser.ParameterNode) {
+	e.precGroup = append(e.precGroup, &parser.DirectiveNode{
+		Name:       name,
+		Parameters: params,
+		Pos:        pos,
+	})
+}
+
+func (e *exprExpander) self(pos parser.Position) *parser.ElementNode {
+	return &parser.ElementNode{
+		ID:  e.lhs,
+		Pos: pos,
+	}
+}
+
+func (e *exprExpander) elem(p *parser.ParameterNode) *parser.ElementNode {
+	if p.ID != "" {
+		return &parser.ElementNode{
+			ID:  p.ID,
+			Pos: p.Pos,
+		}
+	}
+	return &parser.ElementNode{
+		Pattern:   p.String,
+		Literally: true,
+		Pos:       p.Pos,
+	}
+}
+
+func (e *exprExpander) precParams(params []*parser.ParameterNode) []*parser.ParameterNode {
+	ps := make([]*parser.ParameterNode, len(params))
+	for i, p := range params {
+		if name, ok := e.lit2Name[p.String]; p.ID == "" && ok {
+			ps[i] = &parser.ParameterNode{
+				ID:  name,
+				Pos: p.Pos,
+			}
+			continue
+		}
+		ps[i] = &parser.ParameterNode{
+			ID:     p.ID,
+			String: p.String,
+			Pos:    p.Pos,
+		}
+	}
+	return ps
+}
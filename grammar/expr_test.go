@@ -0,0 +1,170 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestExpandExprDirectives(t *testing.T) {
+	tests := []struct {
+		caption  string
+		src      string
+		expected string
+	}{
+		{
+			caption: "an expr directive defines a production and precedences",
+			src: `
+#name test;
+
+#expr expr (
+    #operand int id
+    #group l_paren r_paren
+    #postfix '!'
+    #prefix sub
+    #right pow
+    #left mul div
+    #left add sub
+);
+
+int
+    : "[0-9]+";
+id
+    : "[a-z]+";
+add
+    : '+';
+sub
+    : '-';
+mul
+    : '*';
+div
+    : '/';
+pow
+    : '^';
+l_paren
+    : '(';
+r_paren
+    : ')';
+`,
+			expected: `#name test;
+
+#prec (
+	#left '!'
+	#assign $expr_prefix_2
+	#right pow
+	#left mul div
+	#left add sub
+);
+
+expr
+	: int
+	| id
+	| l_paren expr r_paren #ast expr
+	| expr '!'
+	| sub expr             #prec $expr_prefix_2
+	| expr pow expr
+	| expr mul expr
+	| expr div expr
+	| expr add expr
+	| expr sub expr
+	;
+`,
+		},
+		{
+			caption: "an expr directive adds alternatives to the existing production and precedences before the prec directive",
+			src: `
+#name test;
+
+#prec (
+    #left comma
+);
+
+#expr expr (
+    #left '*'
+    #left '+'
+);
+
+list
+    : list comma expr
+    | expr
+    ;
+expr
+    : id
+    ;
+
+id
+    : "[a-z]+";
+plus
+    : '+';
+comma
+    : ',';
+`,
+			expected: `#name test;
+
+#prec (
+	#left '*'
+	#left plus
+	#left comma
+);
+
+list
+	: list comma expr
+	| expr
+	;
+expr
+	: id
+	| expr '*' expr
+	| expr '+' expr
+	;
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, errs := expandExprDirectives(ast)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			var b strings.Builder
+			err = parser.Format(&b, &parser.RootNode{
+				Directives:  root.Directives,
+				Productions: root.Productions,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.expected {
+				t.Fatalf("unexpected result;\nwant:\n%v\ngot:\n%v", tt.expected, b.String())
+			}
+
+			// The precedences must resolve all conflicts of the expanded grammar.
+			gb := GrammarBuilder{
+				AST: ast,
+			}
+			_, report, err := gb.Build(EnableReporting())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range report.States {
+				for _, c := range s.SRConflict {
+					if c.ResolvedBy != ResolvedByPrec.Int() && c.ResolvedBy != ResolvedByAssoc.Int() {
+						t.Fatalf("a conflict in state %v isn't resolved by precedence: %+v", s.Number, c)
+					}
+				}
+				if len(s.RRConflict) > 0 {
+					t.Fatalf("the grammar has reduce/reduce conflicts in state %v", s.Number)
+				}
+			}
+
+			// The expansion must not modify the AST.
+			if len(ast.Productions) > 0 && len(ast.Productions[len(ast.Productions)-1].RHS) != 1 {
+				t.Fatalf("the AST was modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package grammar
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/grammar/lexical"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+//go:embed fragments/std.vartan
+var stdFragmentLibrary string
+
+// fragmentLibraries are the fragment libraries that vartan ships, keyed by their names. A library is a grammar source
+// consisting only of fragments, which refer to each other by their plain names.
+var fragmentLibraries = map[string]string{
+	"std": stdFragmentLibrary,
+}
+
+// fragmentRefRE matches a reference to a fragment in a pattern, such as `\f{digit}` or `\f{std.digit}`.
+var fragmentRefRE = regexp.MustCompile(`\\f\{\s*([0-9a-z_]+)(\.[0-9a-z_]+)?\s*\}`)
+
+// genLibraryFragments returns the fragments of the libraries that a grammar uses. A pattern refers to a fragment `f`
+// of a library `lib` as `\f{lib.f}`, and a `#use lib;` directive lets patterns refer to it as `\f{f}` unless
+// the grammar defines a fragment of the same name. `defined` holds the names of the fragments the grammar defines.
+func (b *GrammarBuilder) genLibraryFragments(root *parser.RootNode, defined map[string]struct{}) ([]*lexical.LexEntry, error) {
+	used := map[string]struct{}{}
+	var imports []string
+	for _, dir := range root.Directives {
+		if dir.Name != "use" {
+			continue
+		}
+		if len(dir.Parameters) > 0 {
+			// useSnippetLibraries handles the directives using snippet libraries.
+			if _, ok := snippetLibraries[dir.Parameters[0].ID]; ok {
+				continue
+			}
+		}
+		if len(dir.Parameters) != 1 || dir.Parameters[0].ID == "" {
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: "'use' directive needs just one ID parameter",
+				Row:    dir.Pos.Row,
+				Col:    dir.Pos.Col,
+			})
+			continue
+		}
+		name := dir.Parameters[0].ID
+		if _, ok := fragmentLibraries[name]; !ok {
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: fmt.Sprintf("unknown library: %v", name),
+				Row:    dir.Parameters[0].Pos.Row,
+				Col:    dir.Parameters[0].Pos.Col,
+			})
+			continue
+		}
+		if _, ok := used[name]; !ok {
+			imports = append(imports, name)
+		}
+		used[name] = struct{}{}
+	}
+
+	var patterns []string
+	for _, prod := range root.LexProductions {
+		for _, alt := range prod.RHS {
+			for _, elem := range alt.Elements {
+				if elem.Pattern != "" && !elem.Literally {
+					patterns = append(patterns, elem.Pattern)
+				}
+			}
+		}
+	}
+	for _, frag := range root.Fragments {
+		if frag.Literal == "" {
+			patterns = append(patterns, frag.RHS)
+		}
+	}
+	for _, pat := range patterns {
+		for _, m := range fragmentRefRE.FindAllStringSubmatch(pat, -1) {
+			if m[2] == "" {
+				continue
+			}
+			if _, ok := fragmentLibraries[m[1]]; ok {
+				used[m[1]] = struct{}{}
+			}
+		}
+	}
+	if len(used) == 0 {
+		return nil, nil
+	}
+
+	var names []string
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	libFrags := map[string][]*parser.FragmentNode{}
+	var entries []*lexical.LexEntry
+	for _, name := range names {
+		frags, err := parseFragmentLibrary(name)
+		if err != nil {
+			return nil, err
+		}
+		libFrags[name] = frags
+		for _, frag := range frags {
+			entries = append(entries, &lexical.LexEntry{
+				Fragment: true,
+				Kind:     spec.LexKindName(name + "." + frag.LHS),
+				Pattern:  qualifyFragmentRefs(frag.RHS, name),
+			})
+		}
+	}
+
+	// A library imported earlier takes precedence over the later ones for the same plain name.
+	for _, name := range imports {
+		for _, frag := range libFrags[name] {
+			if _, ok := defined[frag.LHS]; ok {
+				continue
+			}
+			defined[frag.LHS] = struct{}{}
+			entries = append(entries, &lexical.LexEntry{
+				Fragment: true,
+				Kind:     spec.LexKindName(frag.LHS),
+				Pattern:  fmt.Sprintf(`\f{%v.%v}`, name, frag.LHS),
+			})
+		}
+	}
+	return entries, nil
+}
+
+func parseFragmentLibrary(name string) ([]*parser.FragmentNode, error) {
+	lib, err := parser.Parse(strings.NewReader(fragmentLibraries[name]))
+	if err != nil {
+		return nil, fmt.Errorf("the fragment library %v is broken: %w", name, err)
+	}
+	return lib.Fragments, nil
+}
+
+// qualifyFragmentRefs makes the plain references to fragments in a pattern of a library refer to the fragments of
+// the library.
+func qualifyFragmentRefs(pattern, lib string) string {
+	return fragmentRefRE.ReplaceAllStringFunc(pattern, func(ref string) string {
+		m := fragmentRefRE.FindStringSubmatch(ref)
+		if m[2] != "" {
+			return ref
+		}
+		return fmt.Sprintf(`\f{%v.%v}`, lib, m[1])
+	})
+}
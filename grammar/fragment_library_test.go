@@ -0,0 +1,251 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestGrammarBuilderResolvesLibraryFragments(t *testing.T) {
+	type token struct {
+		kind   string
+		lexeme string
+	}
+
+	tests := []struct {
+		caption  string
+		specSrc  string
+		src      string
+		expected []token
+	}{
+		{
+			caption: "a pattern can refer to a library fragment by its qualified name",
+			specSrc: `
+#name test;
+
+ws #skip
+    : "\f{std.whitespace}";
+num
+    : "\f{std.hex}|\f{std.decimal}";
+`,
+			src: "0 10 0x1F",
+			expected: []token{
+				{kind: "num", lexeme: "0"},
+				{kind: "num", lexeme: "10"},
+				{kind: "num", lexeme: "0x1F"},
+			},
+		},
+		{
+			caption: "the #use directive makes library fragments available by their plain names",
+			specSrc: `
+#name test;
+
+#use std;
+
+ws #skip
+    : "\f{whitespace}|\f{line_comment}|\f{block_comment}";
+id
+    : "\f{identifier}";
+str
+    : "\f{string}";
+`,
+			src: `foo_1 /* a ** comment */ "a\"\x41" // comment`,
+			expected: []token{
+				{kind: "id", lexeme: "foo_1"},
+				{kind: "str", lexeme: `"a\"\x41"`},
+			},
+		},
+		{
+			caption: "a fragment of a grammar takes precedence over a library fragment of the same name",
+			specSrc: `
+#name test;
+
+#use std;
+
+ws #skip
+    : "\f{whitespace}";
+id
+    : "\f{identifier}";
+
+fragment identifier
+    : "[a-z]+";
+`,
+			src: "foo Bar",
+			expected: []token{
+				{kind: "id", lexeme: "foo"},
+				{kind: "<invalid>", lexeme: "B"},
+				{kind: "id", lexeme: "ar"},
+			},
+		},
+		{
+			caption: "json_string and json_number match the literals of JSON",
+			specSrc: `
+#name test;
+
+#use std;
+
+ws #skip
+    : "\f{whitespace}";
+str
+    : "\f{json_string}";
+num
+    : "\f{json_number}";
+`,
+			src: `"a\"\u00e9\/" -0 1.5e-3 10 01`,
+			expected: []token{
+				{kind: "str", lexeme: `"a\"\u00e9\/"`},
+				{kind: "num", lexeme: "-0"},
+				{kind: "num", lexeme: "1.5e-3"},
+				{kind: "num", lexeme: "10"},
+				{kind: "num", lexeme: "0"},
+				{kind: "num", lexeme: "1"},
+			},
+		},
+		{
+			caption: "single_quoted_string matches a string literal enclosed in single quotes",
+			specSrc: `
+#name test;
+
+#use std;
+
+ws #skip
+    : "\f{whitespace}";
+str
+    : "\f{single_quoted_string}";
+`,
+			src: `'a\'b' 'c\n'`,
+			expected: []token{
+				{kind: "str", lexeme: `'a\'b'`},
+				{kind: "str", lexeme: `'c\n'`},
+			},
+		},
+		{
+			caption: "raw_string matches a string literal enclosed in backquotes without escape sequences",
+			specSrc: `
+#name test;
+
+#use std;
+
+ws #skip
+    : "\f{whitespace}";
+str
+    : "\f{raw_string}";
+`,
+			src: "`a\\` `b\nc`",
+			expected: []token{
+				{kind: "str", lexeme: "`a\\`"},
+				{kind: "str", lexeme: "`b\nc`"},
+			},
+		},
+		{
+			caption: "sql_string matches a string literal of SQL that doubles single quotes",
+			specSrc: `
+#name test;
+
+#use std;
+
+ws #skip
+    : "\f{whitespace}";
+str
+    : "\f{sql_string}";
+`,
+			src: `'it''s' '' 'a\'`,
+			expected: []token{
+				{kind: "str", lexeme: `'it''s'`},
+				{kind: "str", lexeme: `''`},
+				{kind: "str", lexeme: `'a\'`},
+			},
+		},
+		{
+			caption: "library fragments don't see the fragments of a grammar",
+			specSrc: `
+#name test;
+
+ws #skip
+    : "\f{std.whitespace}";
+num
+    : "\f{std.decimal}";
+
+fragment digit
+    : "[0-1]";
+`,
+			src: "109",
+			expected: []token{
+				{kind: "num", lexeme: "109"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(tt.specSrc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := GrammarBuilder{
+				AST: ast,
+			}
+			cg, _, err := b.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			lex, err := lexer.NewLexer(lexer.NewLexSpec(cg.Lexical), strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual []token
+			for {
+				tok, err := lex.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tok.EOF {
+					break
+				}
+				kind := "<invalid>"
+				if !tok.Invalid {
+					kind = cg.Lexical.KindNames[tok.KindID].String()
+				}
+				if kind == "ws" {
+					continue
+				}
+				actual = append(actual, token{kind: kind, lexeme: string(tok.Lexeme)})
+			}
+			if len(actual) != len(tt.expected) {
+				t.Fatalf("unexpected tokens; want: %+v, got: %+v", tt.expected, actual)
+			}
+			for i, e := range tt.expected {
+				if actual[i] != e {
+					t.Fatalf("unexpected token; want: %+v, got: %+v", e, actual[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFragmentLibrariesAreWellFormed(t *testing.T) {
+	for name := range fragmentLibraries {
+		frags, err := parseFragmentLibrary(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(frags) == 0 {
+			t.Fatalf("the fragment library %v has no fragments", name)
+		}
+
+		// The shipped libraries are in the canonical layout that `vartan fmt` prints.
+		ast, err := parser.Parse(strings.NewReader(fragmentLibraries[name]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		err = parser.Format(&b, ast)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != fragmentLibraries[name] {
+			t.Fatalf("the fragment library %v isn't in the canonical layout; format it using vartan fmt", name)
+		}
+	}
+}
@@ -3,7 +3,10 @@ package grammar
 import (
 	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
 	verr "github.com/nihei9/vartan/error"
 	"github.com/nihei9/vartan/grammar/lexical"
@@ -20,9 +23,10 @@ type astActionEntry struct {
 type assocType string
 
 const (
-	assocTypeNil   = assocType("")
-	assocTypeLeft  = assocType("left")
-	assocTypeRight = assocType("right")
+	assocTypeNil      = assocType("")
+	assocTypeLeft     = assocType("left")
+	assocTypeRight    = assocType("right")
+	assocTypeNonAssoc = assocType("nonassoc")
 )
 
 const (
@@ -41,6 +45,10 @@ type precAndAssoc struct {
 	// These values are inherited from the right-most terminal symbols in the RHS of the productions.
 	prodPrec  map[productionNum]int
 	prodAssoc map[productionNum]assocType
+
+	// prodPrefer holds the actions that `#prefer` directives choose in shift/reduce conflicts on reducing
+	// the productions.
+	prodPrefer map[productionNum]ActionType
 }
 
 func (pa *precAndAssoc) terminalPrecedence(sym symbol.SymbolNum) int {
@@ -79,25 +87,94 @@ func (pa *precAndAssoc) productionAssociativity(prod productionNum) assocType {
 	return assoc
 }
 
+func (pa *precAndAssoc) productionPreference(prod productionNum) (ActionType, bool) {
+	act, ok := pa.prodPrefer[prod]
+	return act, ok
+}
+
 const reservedSymbolNameError = "error"
 
 type Grammar struct {
 	name                 string
+	metadata             *spec.Metadata
 	lexSpec              *lexical.LexSpec
 	skipSymbols          []symbol.Symbol
 	productionSet        *productionSet
 	augmentedStartSymbol symbol.Symbol
+	entryPoints          []*entryPoint
+	layout               *layout
+	fallback             symbol.Symbol
 	errorSymbol          symbol.Symbol
 	symbolTable          *symbol.SymbolTableReader
 	astActions           map[productionID][]*astActionEntry
+	astNodeNames         map[productionID]string
+	astLifts             map[productionID]int
 	precAndAssoc         *precAndAssoc
 
+	// keptSymbols is the terminal symbols having the skip directive that `#keep` directives allow alternatives to
+	// contain. The parser doesn't skip their tokens wherever it can accept them.
+	keptSymbols []symbol.Symbol
+
+	// altLabels holds the labels that `#label` directives give alternatives.
+	altLabels map[productionID]string
+
 	// recoverProductions is a set of productions having the recover directive.
 	recoverProductions map[productionID]struct{}
+
+	// recoverSyncTerminals holds the synchronization sets that `#recover until` directives declare.
+	recoverSyncTerminals map[productionID][]symbol.Symbol
+
+	// lexModeOps holds operations on the mode stack of the lexer for each production. The keys of the inner maps are
+	// the offsets of the elements the parser performs the operations when it shifts.
+	lexModeOps map[productionID]map[int]*lexModeOp
+
+	// altNodes holds the alternatives in the grammar source that the productions come from, and altIndexes holds their
+	// positions in their rules, counting from 1.
+	altNodes   map[productionID]*parser.AlternativeNode
+	altIndexes map[productionID]int
+
+	// prodPositions and rulePositions hold the positions of alternatives and of whole rules in the grammar source.
+	// The keys of rulePositions are the LHSs of both syntactic and lexical rules.
+	prodPositions map[productionID]parser.Position
+	rulePositions map[string]*spec.SourceRange
+
+	// implicitTerminals maps the names of terminal symbols that string literals in syntactic rules define implicitly
+	// to the literals.
+	implicitTerminals map[string]string
+
+	// terminalLiterals maps the names of terminal symbols defined by string literals to the literals.
+	terminalLiterals map[string]string
+}
+
+// entryPoint is an additional entry point declared by a `#start` directive. Like the start symbol, each entry point
+// has its own augmented start symbol `symbol'`.
+type entryPoint struct {
+	symbol   symbol.Symbol
+	augStart symbol.Symbol
+}
+
+// layout holds the terminal symbols a `#layout` directive declares.
+type layout struct {
+	indent  symbol.Symbol
+	dedent  symbol.Symbol
+	newline symbol.Symbol
+}
+
+// isLexerOnly returns true when the grammar has only lexical productions.
+func (g *Grammar) isLexerOnly() bool {
+	return g.productionSet == nil
 }
 
 type buildConfig struct {
 	isReportingEnabled bool
+	dupAltPolicy       DuplicateAlternativePolicy
+	unusedSymbols      Severity
+	unusedAnnotations  Severity
+	rightRecursion     Severity
+	unreachableTerms   Severity
+	sourceName         string
+	lazyLexer          bool
+	lexerTable         LexerTable
 }
 
 type BuildOption func(config *buildConfig)
@@ -108,14 +185,139 @@ func EnableReporting() BuildOption {
 	}
 }
 
+// DuplicateAlternativePolicy represents how GrammarBuilder detects duplicate alternatives.
+type DuplicateAlternativePolicy string
+
+const (
+	// DuplicateAlternativePolicySymbols treats alternatives having the same symbol sequence as duplicates, even if
+	// they differ in labels or directives. This is the default policy.
+	DuplicateAlternativePolicySymbols = DuplicateAlternativePolicy("symbols")
+
+	// DuplicateAlternativePolicyExact ignores an alternative that is identical to a preceding one, including labels and
+	// directives. Alternatives having the same symbol sequence but different labels or directives are still duplicates
+	// because the parser cannot tell which one to reduce.
+	DuplicateAlternativePolicyExact = DuplicateAlternativePolicy("exact")
+)
+
+// Severity represents how GrammarBuilder treats a kind of problem in a grammar.
+type Severity string
+
+const (
+	// SeverityError makes a problem an error, which fails the build.
+	SeverityError = Severity("error")
+
+	// SeverityWarn makes a problem a warning. GrammarBuilder reports warnings via its Warnings method and still
+	// builds the grammar.
+	SeverityWarn = Severity("warn")
+
+	// SeverityIgnore makes GrammarBuilder ignore a problem.
+	SeverityIgnore = Severity("ignore")
+)
+
+// TreatUnusedSymbolsAs sets a severity of unused terminal symbols and unused productions. They are errors by default.
+// An unused terminal symbol that a grammar doesn't treat as an error remains in the lexer, so the parser reports
+// a syntax error when it reads the terminal symbol.
+func TreatUnusedSymbolsAs(severity Severity) BuildOption {
+	return func(config *buildConfig) {
+		config.unusedSymbols = severity
+	}
+}
+
+// TreatUnusedAnnotationsAs sets a severity of labels that neither directives nor AST nodes use and ordered symbols that
+// no `#prec` directive of an alternative uses. They are warnings by default. Such annotations don't affect a compiled
+// grammar, but they often remain after the directives referring to them are removed. The label of an element an AST
+// node contains isn't unused because astgen makes a field of it.
+func TreatUnusedAnnotationsAs(severity Severity) BuildOption {
+	return func(config *buildConfig) {
+		config.unusedAnnotations = severity
+	}
+}
+
+// TreatRightRecursiveListsAs sets a severity of productions defining lists by right recursion, such as
+// `list: elem comma list | elem`. They are warnings by default. The parser stack grows with the length of such
+// a list, and the left-recursive form `list: list comma elem | elem` avoids it. See FindRightRecursiveLists.
+func TreatRightRecursiveListsAs(severity Severity) BuildOption {
+	return func(config *buildConfig) {
+		config.rightRecursion = severity
+	}
+}
+
+// TreatUnreachableTerminalsAs sets a severity of terminal symbols that the lexer never recognizes because no `#push`
+// directive enters any of their lex modes. They are warnings by default. Such a terminal symbol often results from
+// a misspelled mode name. When a program switches lex modes by itself, ignore them.
+func TreatUnreachableTerminalsAs(severity Severity) BuildOption {
+	return func(config *buildConfig) {
+		config.unreachableTerms = severity
+	}
+}
+
+// SourceName makes GrammarBuilder record a name of a grammar source, such as a file path, in the source map of
+// a compiled grammar.
+func SourceName(name string) BuildOption {
+	return func(config *buildConfig) {
+		config.sourceName = name
+	}
+}
+
+// BuildLexerLazily makes a compiled grammar contain the NFAs of lex modes instead of their DFAs. A lexer builds DFA
+// states from the NFAs at run time the first time it reaches them. This option keeps a compiled grammar small when the
+// full DFAs are huge, such as when patterns contain many Unicode character properties. Code generation doesn't support
+// such a compiled grammar.
+func BuildLexerLazily() BuildOption {
+	return func(config *buildConfig) {
+		config.lazyLexer = true
+	}
+}
+
+// LexerTable represents how GrammarBuilder compresses the transition tables of a lexer.
+type LexerTable string
+
+const (
+	// LexerTableRowDisplacement removes duplicate rows from a transition table and displaces the remaining rows into
+	// a single array. This is the default.
+	LexerTableRowDisplacement = LexerTable("row-displacement")
+
+	// LexerTableBaseCheck compresses a transition table by the two-level (base/check) scheme, in which each row keeps
+	// only the entries differing from a similar row. It often makes a table smaller when patterns contain Unicode
+	// character properties, but a lexer may follow a chain of rows to look up a transition.
+	LexerTableBaseCheck = LexerTable("base-check")
+)
+
+// CompressLexerTablesBy makes GrammarBuilder compress the transition tables of a lexer by a scheme `table`.
+func CompressLexerTablesBy(table LexerTable) BuildOption {
+	return func(config *buildConfig) {
+		config.lexerTable = table
+	}
+}
+
+// DetectDuplicateAlternativesBy makes GrammarBuilder detect duplicate alternatives according to a policy `policy`.
+func DetectDuplicateAlternativesBy(policy DuplicateAlternativePolicy) BuildOption {
+	return func(config *buildConfig) {
+		config.dupAltPolicy = policy
+	}
+}
+
 type GrammarBuilder struct {
 	AST *parser.RootNode
 
-	errs verr.SpecErrors
+	errs  verr.SpecErrors
+	warns verr.SpecErrors
+}
+
+// Warnings returns warnings the last build found in the order of their positions. Unlike errors, warnings don't fail
+// a build.
+func (b *GrammarBuilder) Warnings() verr.SpecErrors {
+	sort.SliceStable(b.warns, func(i, j int) bool {
+		if b.warns[i].Row != b.warns[j].Row {
+			return b.warns[i].Row < b.warns[j].Row
+		}
+		return b.warns[i].Col < b.warns[j].Col
+	})
+	return b.warns
 }
 
 func (b *GrammarBuilder) Build(opts ...BuildOption) (*spec.CompiledGrammar, *spec.Report, error) {
-	gram, err := b.build()
+	gram, err := b.build(opts...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -123,7 +325,69 @@ func (b *GrammarBuilder) Build(opts ...BuildOption) (*spec.CompiledGrammar, *spe
 	return compile(gram, opts...)
 }
 
-func (b *GrammarBuilder) build() (*Grammar, error) {
+// Validate checks a grammar without generating a lexer and a parsing table, so it returns faster than Build. It finds
+// the same errors as Build except for the ones only the DFA of the lexer reveals, such as a keyword that the pattern of
+// its owner doesn't match. Because Validate doesn't make a parsing table, it doesn't find conflicts either.
+func (b *GrammarBuilder) Validate(opts ...BuildOption) error {
+	gram, err := b.build(opts...)
+	if err != nil {
+		return err
+	}
+
+	err, cErrs := lexical.Validate(gram.lexSpec)
+	if err != nil {
+		if len(cErrs) > 0 {
+			return compileErrorsToError(cErrs)
+		}
+		return err
+	}
+	return nil
+}
+
+func (b *GrammarBuilder) build(opts ...BuildOption) (*Grammar, error) {
+	config := &buildConfig{
+		dupAltPolicy:      DuplicateAlternativePolicySymbols,
+		unusedSymbols:     SeverityError,
+		unusedAnnotations: SeverityWarn,
+		rightRecursion:    SeverityWarn,
+		unreachableTerms:  SeverityWarn,
+		lexerTable:        LexerTableRowDisplacement,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	switch config.dupAltPolicy {
+	case DuplicateAlternativePolicySymbols, DuplicateAlternativePolicyExact:
+	default:
+		return nil, fmt.Errorf("invalid duplicate alternative policy: %v", config.dupAltPolicy)
+	}
+	switch config.unusedSymbols {
+	case SeverityError, SeverityWarn, SeverityIgnore:
+	default:
+		return nil, fmt.Errorf("invalid severity of unused symbols: %v", config.unusedSymbols)
+	}
+	switch config.unusedAnnotations {
+	case SeverityError, SeverityWarn, SeverityIgnore:
+	default:
+		return nil, fmt.Errorf("invalid severity of unused annotations: %v", config.unusedAnnotations)
+	}
+	switch config.rightRecursion {
+	case SeverityError, SeverityWarn, SeverityIgnore:
+	default:
+		return nil, fmt.Errorf("invalid severity of right-recursive lists: %v", config.rightRecursion)
+	}
+	switch config.unreachableTerms {
+	case SeverityError, SeverityWarn, SeverityIgnore:
+	default:
+		return nil, fmt.Errorf("invalid severity of unreachable terminals: %v", config.unreachableTerms)
+	}
+	switch config.lexerTable {
+	case LexerTableRowDisplacement, LexerTableBaseCheck:
+	default:
+		return nil, fmt.Errorf("invalid lexer table: %v", config.lexerTable)
+	}
+	b.warns = nil
+
 	var specName string
 	{
 		errOccurred := false
@@ -155,22 +419,50 @@ func (b *GrammarBuilder) build() (*Grammar, error) {
 		}
 	}
 
+	metadata := b.genMetadata(b.AST)
+
 	b.checkSpellingInconsistenciesOfUserDefinedIDs(b.AST)
 	if len(b.errs) > 0 {
 		return nil, b.errs
 	}
 
-	symTab, ss, err := b.genSymbolTable(b.AST)
+	// The following steps use the AST where `#expr` directives are expanded into productions and string literals in
+	// syntactic rules refer to terminal symbols.
+	root, implicitTerms, err := b.expandAST(b.AST)
 	if err != nil {
 		return nil, err
 	}
 
-	lexSpec, skip, err := b.genLexSpecAndSkipSymbols(symTab.Reader(), b.AST)
+	symTab, ss, err := b.genSymbolTable(root)
 	if err != nil {
 		return nil, err
 	}
 
-	prodsAndActs, err := b.genProductionsAndActions(b.AST, symTab.Reader(), ss.errSym, ss.augStartSym, ss.startSym)
+	lexSpec, skip, err := b.genLexSpecAndSkipSymbols(symTab.Reader(), root)
+	if err != nil {
+		return nil, err
+	}
+
+	// A grammar having only lexical productions is a lexer-only grammar. It has no syntactic part, so every terminal
+	// symbol is available regardless of whether productions refer to it.
+	if len(root.Productions) == 0 && len(root.LexProductions) > 0 {
+		b.checkLexModes(root, lexSpec, nil, config.unreachableTerms)
+		if len(b.errs) > 0 {
+			return nil, b.errs
+		}
+
+		return &Grammar{
+			name:          specName,
+			metadata:      metadata,
+			lexSpec:       lexSpec,
+			skipSymbols:   skip,
+			errorSymbol:   ss.errSym,
+			symbolTable:   symTab.Reader(),
+			rulePositions: genRulePositions(root),
+		}, nil
+	}
+
+	prodsAndActs, err := b.genProductionsAndActions(root, symTab.Reader(), ss.errSym, ss.augStartSym, ss.startSym, ss.entryPoints, config.dupAltPolicy)
 	if err != nil {
 		return nil, err
 	}
@@ -178,7 +470,7 @@ func (b *GrammarBuilder) build() (*Grammar, error) {
 		return nil, b.errs
 	}
 
-	pa, err := b.genPrecAndAssoc(symTab.Reader(), ss.errSym, prodsAndActs)
+	pa, err := b.genPrecAndAssoc(root, symTab.Reader(), ss.errSym, prodsAndActs)
 	if err != nil {
 		return nil, err
 	}
@@ -186,11 +478,65 @@ func (b *GrammarBuilder) build() (*Grammar, error) {
 		return nil, b.errs
 	}
 
-	syms := findUsedAndUnusedSymbols(b.AST)
+	b.omitPunctuation(root, symTab.Reader(), prodsAndActs)
+
+	// A lex mode that `#push` directive on an alternative refers to must be defined by lexical productions.
+	{
+		modes := map[spec.LexModeName]struct{}{
+			spec.LexModeNameDefault: {},
+		}
+		for _, e := range lexSpec.Entries {
+			for _, m := range e.Modes {
+				modes[m] = struct{}{}
+			}
+		}
+		var altPushes []spec.LexModeName
+		for _, p := range prodsAndActs.prods.getAllProductions() {
+			ops := prodsAndActs.lexModeOps[p.id]
+			for i := 0; i < p.rhsLen; i++ {
+				op, ok := ops[i]
+				if !ok || op.push == "" {
+					continue
+				}
+				if _, ok := modes[op.push]; !ok {
+					b.errs = append(b.errs, &verr.SpecError{
+						Cause:  semErrDirInvalidParam,
+						Detail: fmt.Sprintf("unknown lex mode: %v", op.push),
+						Row:    op.pos.Row,
+						Col:    op.pos.Col,
+					})
+					continue
+				}
+				altPushes = append(altPushes, op.push)
+			}
+		}
+		b.checkLexModes(root, lexSpec, altPushes, config.unreachableTerms)
+	}
+
+	syms := findUsedAndUnusedSymbols(root)
 	if syms == nil && len(b.errs) > 0 {
 		return nil, b.errs
 	}
 
+	// `#keep` directives allow alternatives to contain terminal symbols having the skip directive.
+	var kept []symbol.Symbol
+	{
+		r := symTab.Reader()
+		skipNames := map[string]struct{}{}
+		for _, sym := range skip {
+			s, _ := r.ToText(sym)
+			skipNames[s] = struct{}{}
+		}
+		keptNames, errs := checkKeepDirectives(root, skipNames)
+		b.errs = append(b.errs, errs...)
+		for _, sym := range skip {
+			s, _ := r.ToText(sym)
+			if _, ok := keptNames[s]; ok {
+				kept = append(kept, sym)
+			}
+		}
+	}
+
 	// When a terminal symbol that cannot be reached from the start symbol has the skip directive,
 	// the compiler treats its terminal as a used symbol, not unused.
 	{
@@ -198,6 +544,9 @@ func (b *GrammarBuilder) build() (*Grammar, error) {
 		for _, sym := range skip {
 			s, _ := r.ToText(sym)
 			if _, ok := syms.unusedTerminals[s]; !ok {
+				if symbolIn(sym, kept) {
+					continue
+				}
 				prod := syms.usedTerminals[s]
 				b.errs = append(b.errs, &verr.SpecError{
 					Cause:  semErrTermCannotBeSkipped,
@@ -213,7 +562,7 @@ func (b *GrammarBuilder) build() (*Grammar, error) {
 	}
 
 	for sym, prod := range syms.unusedProductions {
-		b.errs = append(b.errs, &verr.SpecError{
+		b.report(config.unusedSymbols, &verr.SpecError{
 			Cause:  semErrUnusedProduction,
 			Detail: sym,
 			Row:    prod.Pos.Row,
@@ -222,7 +571,7 @@ func (b *GrammarBuilder) build() (*Grammar, error) {
 	}
 
 	for sym, prod := range syms.unusedTerminals {
-		b.errs = append(b.errs, &verr.SpecError{
+		b.report(config.unusedSymbols, &verr.SpecError{
 			Cause:  semErrUnusedTerminal,
 			Detail: sym,
 			Row:    prod.Pos.Row,
@@ -230,115 +579,620 @@ func (b *GrammarBuilder) build() (*Grammar, error) {
 		})
 	}
 
+	if config.unusedAnnotations != SeverityIgnore {
+		for _, e := range findUnusedAnnotations(root) {
+			b.report(config.unusedAnnotations, e)
+		}
+	}
+
+	if config.rightRecursion != SeverityIgnore {
+		for _, list := range FindRightRecursiveLists(root) {
+			b.report(config.rightRecursion, &verr.SpecError{
+				Cause:  semErrRightRecursiveList,
+				Detail: list.Production.LHS,
+				Row:    list.Production.Pos.Row,
+				Col:    list.Production.Pos.Col,
+			})
+		}
+	}
+
 	if len(b.errs) > 0 {
 		return nil, b.errs
 	}
 
 	return &Grammar{
 		name:                 specName,
+		metadata:             metadata,
 		lexSpec:              lexSpec,
 		skipSymbols:          skip,
+		keptSymbols:          kept,
 		productionSet:        prodsAndActs.prods,
 		augmentedStartSymbol: prodsAndActs.augStartSym,
+		entryPoints:          ss.entryPoints,
+		layout:               ss.layout,
+		fallback:             ss.fallback,
 		errorSymbol:          ss.errSym,
 		symbolTable:          symTab.Reader(),
 		astActions:           prodsAndActs.astActs,
+		astNodeNames:         prodsAndActs.astNodeNames,
+		astLifts:             prodsAndActs.astLifts,
+		altLabels:            prodsAndActs.altLabels,
 		recoverProductions:   prodsAndActs.recoverProds,
+		recoverSyncTerminals: prodsAndActs.recoverSyncs,
+		lexModeOps:           prodsAndActs.lexModeOps,
 		precAndAssoc:         pa,
+		prodPositions:        prodsAndActs.prodPoss,
+		altNodes:             prodsAndActs.altNodes,
+		altIndexes:           prodsAndActs.altIndexes,
+		rulePositions:        genRulePositions(root),
+		implicitTerminals:    implicitTerms,
+		terminalLiterals:     genTerminalLiterals(root),
 	}, nil
 }
 
-type usedAndUnusedSymbols struct {
-	unusedProductions map[string]*parser.ProductionNode
-	unusedTerminals   map[string]*parser.ProductionNode
-	usedTerminals     map[string]*parser.ProductionNode
-}
-
-func findUsedAndUnusedSymbols(root *parser.RootNode) *usedAndUnusedSymbols {
-	prods := map[string]*parser.ProductionNode{}
-	lexProds := map[string]*parser.ProductionNode{}
-	mark := map[string]bool{}
-	{
-		for _, p := range root.Productions {
-			prods[p.LHS] = p
-			mark[p.LHS] = false
-			for _, alt := range p.RHS {
-				for _, e := range alt.Elements {
-					if e.ID == "" {
+// checkKeepDirectives validates `#keep` directives and returns the names of the terminal symbols they name. `skip` is
+// the names of the terminal symbols having the skip directive. An alternative can contain such a terminal symbol only
+// when a `#keep` directive of the alternative names it.
+func checkKeepDirectives(root *parser.RootNode, skip map[string]struct{}) (map[string]struct{}, verr.SpecErrors) {
+	var errs verr.SpecErrors
+	kept := map[string]struct{}{}
+	altKept := map[*parser.AlternativeNode]map[string]struct{}{}
+	for _, prod := range root.Productions {
+		for _, alt := range prod.RHS {
+			for _, dir := range alt.Directives {
+				if dir.Name != "keep" {
+					continue
+				}
+				if len(dir.Parameters) == 0 {
+					errs = append(errs, &verr.SpecError{
+						Cause:  semErrDirInvalidParam,
+						Detail: "'keep' directive needs at least one ID parameter",
+						Row:    dir.Pos.Row,
+						Col:    dir.Pos.Col,
+					})
+					continue
+				}
+				for _, param := range dir.Parameters {
+					_, isSkip := skip[param.ID]
+					var detail string
+					switch {
+					case param.ID == "" || param.Expansion:
+						detail = "'keep' directive can take only IDs"
+					case !isSkip:
+						detail = fmt.Sprintf("'%v' is not a terminal symbol having the skip directive", param.ID)
+					case !alternativeContains(alt, param.ID):
+						detail = fmt.Sprintf("the alternative doesn't contain '%v'", param.ID)
+					}
+					if detail != "" {
+						errs = append(errs, &verr.SpecError{
+							Cause:  semErrDirInvalidParam,
+							Detail: detail,
+							Row:    param.Pos.Row,
+							Col:    param.Pos.Col,
+						})
 						continue
 					}
-					mark[e.ID] = false
+					if altKept[alt] == nil {
+						altKept[alt] = map[string]struct{}{}
+					}
+					altKept[alt][param.ID] = struct{}{}
+					kept[param.ID] = struct{}{}
 				}
 			}
 		}
+	}
 
-		for _, p := range root.LexProductions {
-			lexProds[p.LHS] = p
-			mark[p.LHS] = false
+	// Terminal symbols that no `#keep` directive names are reported as unused or as used ones that cannot be skipped
+	// later, so only the other alternatives containing kept symbols need to be checked here.
+	for _, prod := range root.Productions {
+		for _, alt := range prod.RHS {
+			for _, elem := range alt.Elements {
+				if _, ok := kept[elem.ID]; !ok {
+					continue
+				}
+				if _, ok := altKept[alt][elem.ID]; ok {
+					continue
+				}
+				errs = append(errs, &verr.SpecError{
+					Cause:  semErrTermCannotBeSkipped,
+					Detail: fmt.Sprintf("%v (the alternative needs '#keep %v')", elem.ID, elem.ID),
+					Row:    elem.Pos.Row,
+					Col:    elem.Pos.Col,
+				})
+			}
 		}
+	}
 
-		start := root.Productions[0]
-		mark[start.LHS] = true
-		markUsedSymbols(mark, map[string]bool{}, prods, start)
+	return kept, errs
+}
 
-		// We don't have to check the error symbol because the error symbol doesn't have a production.
-		delete(mark, reservedSymbolNameError)
+func alternativeContains(alt *parser.AlternativeNode, id string) bool {
+	for _, elem := range alt.Elements {
+		if elem.ID == id {
+			return true
+		}
 	}
+	return false
+}
 
-	usedTerms := make(map[string]*parser.ProductionNode, len(lexProds))
-	unusedProds := map[string]*parser.ProductionNode{}
-	unusedTerms := map[string]*parser.ProductionNode{}
-	for sym, used := range mark {
-		if p, ok := prods[sym]; ok {
-			if used {
-				continue
-			}
-			unusedProds[sym] = p
+func symbolIn(sym symbol.Symbol, syms []symbol.Symbol) bool {
+	for _, s := range syms {
+		if s == sym {
+			return true
+		}
+	}
+	return false
+}
+
+// genTerminalLiterals returns the string literals defining terminal symbols.
+func genTerminalLiterals(root *parser.RootNode) map[string]string {
+	lits := map[string]string{}
+	for _, prod := range root.LexProductions {
+		lit, ok := prod.Literal()
+		if !ok {
 			continue
 		}
-		if p, ok := lexProds[sym]; ok {
-			if used {
-				usedTerms[sym] = p
-			} else {
-				unusedTerms[sym] = p
-			}
+		if _, ok := lits[prod.LHS]; ok {
 			continue
 		}
-
-		// May be reached here when a fragment name appears on the right-hand side of a production rule. However, an error
-		// to the effect that a production rule cannot contain a fragment will be detected in a subsequent process. So we can
-		// ignore it here.
+		lits[prod.LHS] = lit
 	}
+	return lits
+}
 
-	return &usedAndUnusedSymbols{
-		usedTerminals:     usedTerms,
-		unusedProductions: unusedProds,
-		unusedTerminals:   unusedTerms,
+// genRulePositions returns the ranges of all rules in a grammar source.
+func genRulePositions(root *parser.RootNode) map[string]*spec.SourceRange {
+	poss := map[string]*spec.SourceRange{}
+	for _, prods := range [][]*parser.ProductionNode{root.Productions, root.LexProductions} {
+		for _, prod := range prods {
+			if _, ok := poss[prod.LHS]; ok {
+				continue
+			}
+			poss[prod.LHS] = &spec.SourceRange{
+				Row:    prod.Pos.Row,
+				Col:    prod.Pos.Col,
+				EndRow: prod.End.Row,
+				EndCol: prod.End.Col,
+			}
+		}
 	}
+	return poss
 }
 
-func markUsedSymbols(mark map[string]bool, marked map[string]bool, prods map[string]*parser.ProductionNode, prod *parser.ProductionNode) {
-	if marked[prod.LHS] {
-		return
+// report adds a problem to the errors or the warnings according to a severity.
+func (b *GrammarBuilder) report(severity Severity, err *verr.SpecError) {
+	switch severity {
+	case SeverityWarn:
+		err.Severity = verr.SeverityWarning
+		b.warns = append(b.warns, err)
+	case SeverityIgnore:
+	default:
+		b.errs = append(b.errs, err)
 	}
+}
 
-	for _, alt := range prod.RHS {
-		for _, e := range alt.Elements {
-			if e.ID == "" {
-				continue
-			}
-
-			mark[e.ID] = true
+// genMetadata collects key/value pairs specified by `#meta` directives. When the grammar has no `#meta` directive,
+// this method returns nil.
+func (b *GrammarBuilder) genMetadata(root *parser.RootNode) *spec.Metadata {
+	var meta *spec.Metadata
+	defined := map[string]struct{}{}
+	for _, dir := range root.Directives {
+		if dir.Name != "meta" {
+			continue
+		}
 
-			p, ok := prods[e.ID]
-			if !ok {
-				continue
-			}
+		if len(dir.Parameters) != 2 || dir.Parameters[0].ID == "" || dir.Parameters[1].String == "" {
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: "'meta' takes just two parameters: a key (ID) and a value (string literal)",
+				Row:    dir.Pos.Row,
+				Col:    dir.Pos.Col,
+			})
+			continue
+		}
 
-			// Remove a production to avoid inifinite recursion.
-			marked[prod.LHS] = true
+		key := dir.Parameters[0]
+		value := dir.Parameters[1].String
+		if _, ok := defined[key.ID]; ok {
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDuplicateMetadata,
+				Detail: key.ID,
+				Row:    key.Pos.Row,
+				Col:    key.Pos.Col,
+			})
+			continue
+		}
+		defined[key.ID] = struct{}{}
 
-			markUsedSymbols(mark, marked, prods, p)
+		if meta == nil {
+			meta = &spec.Metadata{}
+		}
+		switch key.ID {
+		case "author":
+			meta.Author = value
+		case "license":
+			meta.License = value
+		case "version":
+			meta.Version = value
+		default:
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: fmt.Sprintf("'meta' doesn't support the key '%v'; available keys are author, license, and version", key.ID),
+				Row:    key.Pos.Row,
+				Col:    key.Pos.Col,
+			})
+		}
+	}
+	return meta
+}
+
+// omitPunctuation makes alternatives having neither `#ast` nor `#lift` directive omit punctuation terminals from their
+// AST nodes when the grammar has the `#omit_punctuation` directive. A punctuation terminal is a terminal defined by
+// a string literal consisting only of punctuation and symbol characters, such as '(' and ','.
+func (b *GrammarBuilder) omitPunctuation(root *parser.RootNode, symTab *symbol.SymbolTableReader, prodsAndActs *productionsAndActions) {
+	enabled := false
+	for _, dir := range root.Directives {
+		if dir.Name != "omit_punctuation" {
+			continue
+		}
+		if len(dir.Parameters) > 0 {
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: "'omit_punctuation' directive needs no parameter",
+				Row:    dir.Pos.Row,
+				Col:    dir.Pos.Col,
+			})
+			return
+		}
+		enabled = true
+	}
+	if !enabled {
+		return
+	}
+
+	puncts := map[symbol.Symbol]struct{}{}
+	for _, prod := range root.LexProductions {
+		if lit, ok := prod.Literal(); !ok || !IsPunctuation(lit) {
+			continue
+		}
+		sym, ok := symTab.ToSymbol(prod.LHS)
+		if !ok {
+			continue
+		}
+		puncts[sym] = struct{}{}
+	}
+
+	for _, p := range prodsAndActs.prods.getAllProductions() {
+		if p.lhs == prodsAndActs.augStartSym {
+			continue
+		}
+		if _, ok := prodsAndActs.astActs[p.id]; ok {
+			continue
+		}
+		if _, ok := prodsAndActs.astLifts[p.id]; ok {
+			continue
+		}
+
+		omitted := false
+		astAct := []*astActionEntry{}
+		for i, sym := range p.rhs {
+			if _, ok := puncts[sym]; ok {
+				omitted = true
+				continue
+			}
+			astAct = append(astAct, &astActionEntry{
+				position: i + 1,
+			})
+		}
+		if omitted {
+			prodsAndActs.astActs[p.id] = astAct
+		}
+	}
+}
+
+// IsPunctuation returns true when a string consists only of punctuation and symbol characters. The `#omit_punctuation`
+// directive omits terminals defined by such string literals from AST nodes.
+func IsPunctuation(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPunct(r) && !unicode.IsSymbol(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpandAST returns the AST that a grammar is built from. In the AST, `#expr` directives are expanded into
+// productions, the productions of the snippet libraries that `#use` directives name are added, and string literals in
+// syntactic rules refer to terminal symbols named as in the compiled grammar. Tools generating code from a grammar,
+// such as astgen, use it to see the same symbols as the parser. The function doesn't modify `root`.
+func ExpandAST(root *parser.RootNode) (*parser.RootNode, error) {
+	b := &GrammarBuilder{
+		AST: root,
+	}
+	r, _, err := b.expandAST(root)
+	return r, err
+}
+
+// expandAST returns the AST that a grammar is built from and a map from the names of the implicit terminal symbols to
+// their literals.
+func (b *GrammarBuilder) expandAST(root *parser.RootNode) (*parser.RootNode, map[string]string, error) {
+	root, errs := expandExprDirectives(root)
+	if len(errs) > 0 {
+		b.errs = append(b.errs, errs...)
+		return nil, nil, b.errs
+	}
+	root, err := b.useSnippetLibraries(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(b.errs) > 0 {
+		return nil, nil, b.errs
+	}
+	root, implicitTerms := defineImplicitTerminals(root)
+	return root, implicitTerms, nil
+}
+
+// equalAlternatives returns true when two alternatives have the same elements, labels, and directives.
+func equalAlternatives(a1, a2 *parser.AlternativeNode) bool {
+	if a1 == nil || a2 == nil {
+		return false
+	}
+	if len(a1.Elements) != len(a2.Elements) {
+		return false
+	}
+	for i, e1 := range a1.Elements {
+		e2 := a2.Elements[i]
+		if e1.ID != e2.ID || e1.Pattern != e2.Pattern || e1.Literally != e2.Literally {
+			return false
+		}
+		if (e1.Label == nil) != (e2.Label == nil) {
+			return false
+		}
+		if e1.Label != nil && e1.Label.Name != e2.Label.Name {
+			return false
+		}
+	}
+	return equalDirectives(a1.Directives, a2.Directives)
+}
+
+func equalDirectives(ds1, ds2 []*parser.DirectiveNode) bool {
+	if len(ds1) != len(ds2) {
+		return false
+	}
+	for i, d1 := range ds1 {
+		d2 := ds2[i]
+		if d1.Name != d2.Name || len(d1.Parameters) != len(d2.Parameters) {
+			return false
+		}
+		for j, p1 := range d1.Parameters {
+			p2 := d2.Parameters[j]
+			if p1.ID != p2.ID ||
+				p1.Number != p2.Number ||
+				p1.Pattern != p2.Pattern ||
+				p1.String != p2.String ||
+				p1.OrderedSymbol != p2.OrderedSymbol ||
+				p1.LevelName != p2.LevelName ||
+				p1.Expansion != p2.Expansion {
+				return false
+			}
+			if !equalDirectives(p1.Group, p2.Group) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// findUnusedAnnotations returns the labels of elements that neither directives of their alternatives refer to nor AST
+// nodes contain, and the ordered symbols of a `#prec` directive that no `#prec` directive of an alternative uses. The
+// label of an element an AST node contains names a field of a struct astgen generates, so the label is in use.
+func findUnusedAnnotations(root *parser.RootNode) verr.SpecErrors {
+	omitPunct := false
+	for _, dir := range root.Directives {
+		if dir.Name == "omit_punctuation" {
+			omitPunct = true
+		}
+	}
+	puncts := map[string]struct{}{}
+	for _, prod := range root.LexProductions {
+		if lit, ok := prod.Literal(); ok && IsPunctuation(lit) {
+			puncts[prod.LHS] = struct{}{}
+		}
+	}
+
+	var errs verr.SpecErrors
+	usedOrdSyms := map[string]struct{}{}
+	for _, prod := range root.Productions {
+		for _, alt := range prod.RHS {
+			refs := map[string]struct{}{}
+			// astRefs is the symbols and labels that a `#ast` directive refers to. It is nil when the alternative has
+			// no `#ast` directive.
+			var astRefs map[string]struct{}
+			lifted := false
+			for _, dir := range alt.Directives {
+				switch dir.Name {
+				case "ast":
+					astRefs = map[string]struct{}{}
+				case "lift":
+					lifted = true
+				}
+				for _, param := range dir.Parameters {
+					if param.ID != "" {
+						refs[param.ID] = struct{}{}
+						if dir.Name == "ast" && !param.Expansion {
+							astRefs[param.ID] = struct{}{}
+						}
+					}
+					if dir.Name == "prec" && param.OrderedSymbol != "" {
+						usedOrdSyms[param.OrderedSymbol] = struct{}{}
+					}
+				}
+			}
+			inASTNode := func(elem *parser.ElementNode) bool {
+				switch {
+				case lifted:
+					return false
+				case astRefs != nil:
+					_, ok := astRefs[elem.ID]
+					return ok
+				}
+				_, ok := puncts[elem.ID]
+				return !ok || !omitPunct
+			}
+			for _, elem := range alt.Elements {
+				if elem.Label == nil {
+					continue
+				}
+				if _, ok := refs[elem.Label.Name]; ok {
+					continue
+				}
+				if inASTNode(elem) {
+					continue
+				}
+				errs = append(errs, &verr.SpecError{
+					Cause:  semErrUnusedLabel,
+					Detail: elem.Label.Name,
+					Row:    elem.Label.Pos.Row,
+					Col:    elem.Label.Pos.Col,
+				})
+			}
+		}
+	}
+
+	for _, dir := range root.Directives {
+		if dir.Name != "prec" || len(dir.Parameters) != 1 {
+			continue
+		}
+		for _, d := range dir.Parameters[0].Group {
+			for _, param := range d.Parameters {
+				if param.OrderedSymbol == "" {
+					continue
+				}
+				if _, ok := usedOrdSyms[param.OrderedSymbol]; ok {
+					continue
+				}
+				errs = append(errs, &verr.SpecError{
+					Cause:  semErrUnusedOrdSym,
+					Detail: "$" + param.OrderedSymbol,
+					Row:    param.Pos.Row,
+					Col:    param.Pos.Col,
+				})
+			}
+		}
+	}
+	return errs
+}
+
+type usedAndUnusedSymbols struct {
+	unusedProductions map[string]*parser.ProductionNode
+	unusedTerminals   map[string]*parser.ProductionNode
+	usedTerminals     map[string]*parser.ProductionNode
+}
+
+func findUsedAndUnusedSymbols(root *parser.RootNode) *usedAndUnusedSymbols {
+	prods := map[string]*parser.ProductionNode{}
+	lexProds := map[string]*parser.ProductionNode{}
+	mark := map[string]bool{}
+	{
+		for _, p := range root.Productions {
+			prods[p.LHS] = p
+			mark[p.LHS] = false
+			for _, alt := range p.RHS {
+				for _, e := range alt.Elements {
+					if e.ID == "" {
+						continue
+					}
+					mark[e.ID] = false
+				}
+			}
+		}
+
+		for _, p := range root.LexProductions {
+			lexProds[p.LHS] = p
+			mark[p.LHS] = false
+		}
+
+		start := root.Productions[0]
+		mark[start.LHS] = true
+		marked := map[string]bool{}
+		markUsedSymbols(mark, marked, prods, start)
+
+		// Symbols reachable from additional entry points are also used.
+		for _, dir := range root.Directives {
+			if dir.Name != "start" {
+				continue
+			}
+			for _, param := range dir.Parameters {
+				p, ok := prods[param.ID]
+				if !ok {
+					continue
+				}
+				mark[p.LHS] = true
+				markUsedSymbols(mark, marked, prods, p)
+			}
+		}
+
+		// We don't have to check the error symbol because the error symbol doesn't have a production.
+		delete(mark, reservedSymbolNameError)
+	}
+
+	usedTerms := make(map[string]*parser.ProductionNode, len(lexProds))
+	unusedProds := map[string]*parser.ProductionNode{}
+	unusedTerms := map[string]*parser.ProductionNode{}
+	for sym, used := range mark {
+		if p, ok := prods[sym]; ok {
+			if used {
+				continue
+			}
+			unusedProds[sym] = p
+			continue
+		}
+		if p, ok := lexProds[sym]; ok {
+			if used {
+				usedTerms[sym] = p
+			} else {
+				unusedTerms[sym] = p
+			}
+			continue
+		}
+
+		// May be reached here when a fragment name appears on the right-hand side of a production rule. However, an error
+		// to the effect that a production rule cannot contain a fragment will be detected in a subsequent process. So we can
+		// ignore it here.
+	}
+
+	return &usedAndUnusedSymbols{
+		usedTerminals:     usedTerms,
+		unusedProductions: unusedProds,
+		unusedTerminals:   unusedTerms,
+	}
+}
+
+func markUsedSymbols(mark map[string]bool, marked map[string]bool, prods map[string]*parser.ProductionNode, prod *parser.ProductionNode) {
+	if marked[prod.LHS] {
+		return
+	}
+
+	for _, alt := range prod.RHS {
+		for _, e := range alt.Elements {
+			if e.ID == "" {
+				continue
+			}
+
+			mark[e.ID] = true
+
+			p, ok := prods[e.ID]
+			if !ok {
+				continue
+			}
+
+			// Remove a production to avoid inifinite recursion.
+			marked[prod.LHS] = true
+
+			markUsedSymbols(mark, marked, prods, p)
 		}
 	}
 }
@@ -412,6 +1266,9 @@ type symbols struct {
 	errSym      symbol.Symbol
 	augStartSym symbol.Symbol
 	startSym    symbol.Symbol
+	entryPoints []*entryPoint
+	layout      *layout
+	fallback    symbol.Symbol
 }
 
 func (b *GrammarBuilder) genSymbolTable(root *parser.RootNode) (*symbol.SymbolTable, *symbols, error) {
@@ -443,6 +1300,8 @@ func (b *GrammarBuilder) genSymbolTable(root *parser.RootNode) (*symbol.SymbolTa
 					Detail: prod.LHS,
 					Row:    prod.Pos.Row,
 					Col:    prod.Pos.Col,
+					EndRow: prod.Pos.Row,
+					EndCol: prod.Pos.Col + len(prod.LHS) - 1,
 				})
 			}
 
@@ -455,6 +1314,136 @@ func (b *GrammarBuilder) genSymbolTable(root *parser.RootNode) (*symbol.SymbolTa
 		}
 	}
 
+	// The terminal symbols a `#layout` directive declares have no lexical productions because the parser synthesizes
+	// them instead of the lexer.
+	var lay *layout
+	{
+		consumed := false
+		for _, dir := range root.Directives {
+			if dir.Name != "layout" {
+				continue
+			}
+
+			if consumed {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDuplicateDir,
+					Detail: dir.Name,
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				})
+				continue
+			}
+			consumed = true
+
+			if len(dir.Parameters) != 3 {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: "'layout' needs just three ID parameters: indent, dedent, and newline",
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				})
+				continue
+			}
+
+			var syms [3]symbol.Symbol
+			ok := true
+			for i, param := range dir.Parameters {
+				if param.ID == "" {
+					b.errs = append(b.errs, &verr.SpecError{
+						Cause:  semErrDirInvalidParam,
+						Detail: "'layout' takes only ID parameters",
+						Row:    param.Pos.Row,
+						Col:    param.Pos.Col,
+					})
+					ok = false
+					continue
+				}
+				if _, exist := r.ToSymbol(param.ID); exist {
+					b.errs = append(b.errs, &verr.SpecError{
+						Cause:  semErrDuplicateTerminal,
+						Detail: param.ID,
+						Row:    param.Pos.Row,
+						Col:    param.Pos.Col,
+					})
+					ok = false
+					continue
+				}
+
+				sym, err := w.RegisterTerminalSymbol(param.ID)
+				if err != nil {
+					return nil, nil, err
+				}
+				syms[i] = sym
+			}
+			if !ok {
+				continue
+			}
+
+			lay = &layout{
+				indent:  syms[0],
+				dedent:  syms[1],
+				newline: syms[2],
+			}
+		}
+	}
+
+	// The terminal symbol a `#fallback` directive declares has no lexical production either because the token stream
+	// maps invalid tokens to it.
+	fallback := symbol.SymbolNil
+	{
+		consumed := false
+		for _, dir := range root.Directives {
+			if dir.Name != "fallback" {
+				continue
+			}
+
+			if consumed {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDuplicateDir,
+					Detail: dir.Name,
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				})
+				continue
+			}
+			consumed = true
+
+			if len(dir.Parameters) != 1 || dir.Parameters[0].ID == "" {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: "'fallback' needs just one ID parameter",
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				})
+				continue
+			}
+			param := dir.Parameters[0]
+			if _, exist := r.ToSymbol(param.ID); exist {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDuplicateTerminal,
+					Detail: param.ID,
+					Row:    param.Pos.Row,
+					Col:    param.Pos.Col,
+				})
+				continue
+			}
+
+			sym, err := w.RegisterTerminalSymbol(param.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			fallback = sym
+		}
+	}
+
+	if len(root.Productions) == 0 {
+		return symTab, &symbols{
+			errSym:   errSym,
+			layout:   lay,
+			fallback: fallback,
+		}, nil
+	}
+
 	startProd := root.Productions[0]
 	augStartText := fmt.Sprintf("%s'", startProd.LHS)
 	var err error
@@ -504,16 +1493,82 @@ func (b *GrammarBuilder) genSymbolTable(root *parser.RootNode) (*symbol.SymbolTa
 		}
 	}
 
+	// We register the augmented start symbols of the entry points after all the other non-terminal symbols so that
+	// the entry points don't change the numbers of the other symbols.
+	var entryPoints []*entryPoint
+	{
+		defined := map[symbol.Symbol]struct{}{
+			startSym: {},
+		}
+		for _, dir := range root.Directives {
+			if dir.Name != "start" {
+				continue
+			}
+
+			if len(dir.Parameters) == 0 {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: "'start' needs at least one ID parameter",
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				})
+				continue
+			}
+
+			for _, param := range dir.Parameters {
+				if param.ID == "" {
+					b.errs = append(b.errs, &verr.SpecError{
+						Cause:  semErrDirInvalidParam,
+						Detail: "'start' takes only ID parameters",
+						Row:    param.Pos.Row,
+						Col:    param.Pos.Col,
+					})
+					continue
+				}
+
+				sym, ok := r.ToSymbol(param.ID)
+				if !ok || sym.IsTerminal() {
+					b.errs = append(b.errs, &verr.SpecError{
+						Cause:  semErrDirInvalidParam,
+						Detail: fmt.Sprintf("'start' takes only non-terminal symbols: %v", param.ID),
+						Row:    param.Pos.Row,
+						Col:    param.Pos.Col,
+					})
+					continue
+				}
+
+				// The start symbol is always an entry point, and a symbol can appear multiple times.
+				if _, ok := defined[sym]; ok {
+					continue
+				}
+				defined[sym] = struct{}{}
+
+				augSym, err := w.RegisterNonTerminalSymbol(fmt.Sprintf("%s'", param.ID))
+				if err != nil {
+					return nil, nil, err
+				}
+				entryPoints = append(entryPoints, &entryPoint{
+					symbol:   sym,
+					augStart: augSym,
+				})
+			}
+		}
+	}
+
 	return symTab, &symbols{
 		errSym:      errSym,
 		augStartSym: augStartSym,
 		startSym:    startSym,
+		entryPoints: entryPoints,
+		layout:      lay,
+		fallback:    fallback,
 	}, nil
 }
 
 func (b *GrammarBuilder) genLexSpecAndSkipSymbols(symTab *symbol.SymbolTableReader, root *parser.RootNode) (*lexical.LexSpec, []symbol.Symbol, error) {
 	entries := []*lexical.LexEntry{}
 	skipSyms := []symbol.Symbol{}
+	kind2Entry := map[string]*lexical.LexEntry{}
 	for _, prod := range root.LexProductions {
 		entry, skip, specErr, err := genLexEntry(prod)
 		if err != nil {
@@ -528,8 +1583,12 @@ func (b *GrammarBuilder) genLexSpecAndSkipSymbols(symTab *symbol.SymbolTableRead
 			skipSyms = append(skipSyms, sym)
 		}
 		entries = append(entries, entry)
+		kind2Entry[prod.LHS] = entry
 	}
 
+	b.resolveKeywords(root, kind2Entry)
+	b.extendLexModes(root, entries)
+
 	checkedFragments := map[string]struct{}{}
 	for _, fragment := range root.Fragments {
 		if _, exist := checkedFragments[fragment.LHS]; exist {
@@ -543,33 +1602,405 @@ func (b *GrammarBuilder) genLexSpecAndSkipSymbols(symTab *symbol.SymbolTableRead
 		}
 		checkedFragments[fragment.LHS] = struct{}{}
 
-		entries = append(entries, &lexical.LexEntry{
-			Fragment: true,
-			Kind:     spec.LexKindName(fragment.LHS),
-			Pattern:  fragment.RHS,
-		})
+		entries = append(entries, &lexical.LexEntry{
+			Fragment: true,
+			Kind:     spec.LexKindName(fragment.LHS),
+			Pattern:  fragment.RHS,
+		})
+	}
+
+	libEntries, err := b.genLibraryFragments(root, checkedFragments)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries = append(entries, libEntries...)
+
+	return &lexical.LexSpec{
+		Entries:            entries,
+		DotExcludesNewline: b.dotExcludesNewline(root),
+	}, skipSyms, nil
+}
+
+// dotExcludesNewline returns true when a grammar has the `#dot_excludes_newline` directive.
+func (b *GrammarBuilder) dotExcludesNewline(root *parser.RootNode) bool {
+	enabled := false
+	for _, dir := range root.Directives {
+		if dir.Name != "dot_excludes_newline" {
+			continue
+		}
+		if len(dir.Parameters) > 0 {
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: "'dot_excludes_newline' directive needs no parameter",
+				Row:    dir.Pos.Row,
+				Col:    dir.Pos.Col,
+			})
+			return false
+		}
+		enabled = true
+	}
+	return enabled
+}
+
+// checkLexModes reports `#push` directives of lexical productions referring to undefined lex modes, and terminal
+// symbols that the lexer never recognizes because it never enters their lex modes. `altPushes` is the lex modes that
+// `#push` directives of alternatives push. The lexer starts in the default mode, so a lex mode is reachable when it is
+// the default mode or when an alternative or a terminal symbol belonging to a reachable mode pushes it. This method
+// must run after extendLexModes because a terminal symbol also belongs to the modes extending its modes.
+func (b *GrammarBuilder) checkLexModes(root *parser.RootNode, lexSpec *lexical.LexSpec, altPushes []spec.LexModeName, severity Severity) {
+	kind2Entry := map[spec.LexKindName]*lexical.LexEntry{}
+	defined := map[spec.LexModeName]struct{}{
+		spec.LexModeNameDefault: {},
+	}
+	for _, e := range lexSpec.Entries {
+		if e.Fragment {
+			continue
+		}
+		kind2Entry[e.Kind] = e
+		for _, m := range e.Modes {
+			defined[m] = struct{}{}
+		}
+	}
+	modesOf := func(e *lexical.LexEntry) []spec.LexModeName {
+		if len(e.Modes) == 0 {
+			return []spec.LexModeName{spec.LexModeNameDefault}
+		}
+		return e.Modes
+	}
+
+	// Pushing a lex mode having no terminal symbols would leave the lexer unable to recognize any token.
+	pushErr := false
+	for _, prod := range root.LexProductions {
+		for _, dir := range prod.Directives {
+			if dir.Name != "push" || len(dir.Parameters) != 1 {
+				continue
+			}
+			param := dir.Parameters[0]
+			if _, ok := defined[spec.LexModeName(param.ID)]; !ok {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: fmt.Sprintf("unknown lex mode: %v", param.ID),
+					Row:    param.Pos.Row,
+					Col:    param.Pos.Col,
+				})
+				pushErr = true
+			}
+		}
+	}
+	if pushErr || severity == SeverityIgnore {
+		return
+	}
+
+	reachable := map[spec.LexModeName]struct{}{
+		spec.LexModeNameDefault: {},
+	}
+	for _, m := range altPushes {
+		reachable[m] = struct{}{}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, e := range kind2Entry {
+			if e.Push == "" {
+				continue
+			}
+			if _, ok := reachable[e.Push]; ok {
+				continue
+			}
+			for _, m := range modesOf(e) {
+				if _, ok := reachable[m]; ok {
+					reachable[e.Push] = struct{}{}
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	for _, prod := range root.LexProductions {
+		e, ok := kind2Entry[spec.LexKindName(prod.LHS)]
+		if !ok {
+			continue
+		}
+		modes := modesOf(e)
+		found := false
+		for _, m := range modes {
+			if _, ok := reachable[m]; ok {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		names := make([]string, len(modes))
+		for i, m := range modes {
+			names[i] = m.String()
+		}
+		b.report(severity, &verr.SpecError{
+			Cause:  semErrUnreachableTerminal,
+			Detail: fmt.Sprintf("%v; no #push directive enters lex mode %v", prod.LHS, strings.Join(names, ", ")),
+			Row:    prod.Pos.Row,
+			Col:    prod.Pos.Col,
+		})
+	}
+}
+
+// extendLexModes applies `#mode_extends` directives. When a mode extends a base mode, every terminal symbol belonging
+// to the base mode also belongs to the extending mode. The inheritance is transitive, and a mode can extend multiple
+// base modes. Because the lexer prefers a terminal symbol defined earlier among ones matching the same length of
+// text, the inherited terminal symbols keep their order of definition relative to the mode's own ones.
+func (b *GrammarBuilder) extendLexModes(root *parser.RootNode, entries []*lexical.LexEntry) {
+	defined := map[spec.LexModeName]struct{}{
+		spec.LexModeNameDefault: {},
+	}
+	for _, e := range entries {
+		for _, m := range e.Modes {
+			defined[m] = struct{}{}
+		}
+	}
+
+	var dirs []*parser.DirectiveNode
+	for _, dir := range root.Directives {
+		if dir.Name != "mode_extends" {
+			continue
+		}
+		valid := len(dir.Parameters) >= 2
+		for _, param := range dir.Parameters {
+			if param.ID == "" {
+				valid = false
+			}
+		}
+		if !valid {
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: "'mode_extends' directive needs a mode and one or more base modes as ID parameters",
+				Row:    dir.Pos.Row,
+				Col:    dir.Pos.Col,
+			})
+			continue
+		}
+		dirs = append(dirs, dir)
+		defined[spec.LexModeName(dir.Parameters[0].ID)] = struct{}{}
+	}
+	if len(dirs) == 0 {
+		return
+	}
+
+	// bases maps a mode to the modes it extends directly.
+	bases := map[spec.LexModeName][]spec.LexModeName{}
+	for _, dir := range dirs {
+		mode := dir.Parameters[0]
+		if _, ok := bases[spec.LexModeName(mode.ID)]; ok {
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: fmt.Sprintf("a mode can appear in only one 'mode_extends' directive: %v", mode.ID),
+				Row:    mode.Pos.Row,
+				Col:    mode.Pos.Col,
+			})
+			continue
+		}
+		var bs []spec.LexModeName
+		for _, param := range dir.Parameters[1:] {
+			if _, ok := defined[spec.LexModeName(param.ID)]; !ok {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: fmt.Sprintf("unknown lex mode: %v", param.ID),
+					Row:    param.Pos.Row,
+					Col:    param.Pos.Col,
+				})
+				continue
+			}
+			bs = append(bs, spec.LexModeName(param.ID))
+		}
+		bases[spec.LexModeName(mode.ID)] = bs
+	}
+
+	// ancestors returns all the modes a mode extends directly or indirectly. When the inheritance is cyclic, the
+	// result contains the mode itself.
+	ancestors := func(mode spec.LexModeName) map[spec.LexModeName]struct{} {
+		visited := map[spec.LexModeName]struct{}{}
+		stack := append([]spec.LexModeName{}, bases[mode]...)
+		for len(stack) > 0 {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if _, ok := visited[m]; ok {
+				continue
+			}
+			visited[m] = struct{}{}
+			stack = append(stack, bases[m]...)
+		}
+		return visited
+	}
+
+	// descendants maps a base mode to the modes extending it, in the order of the directives.
+	descendants := map[spec.LexModeName][]spec.LexModeName{}
+	for _, dir := range dirs {
+		mode := spec.LexModeName(dir.Parameters[0].ID)
+		if _, ok := bases[mode]; !ok {
+			continue
+		}
+		as := ancestors(mode)
+		if _, ok := as[mode]; ok {
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: fmt.Sprintf("a mode cannot extend itself directly or indirectly: %v", mode),
+				Row:    dir.Parameters[0].Pos.Row,
+				Col:    dir.Parameters[0].Pos.Col,
+			})
+			// Prevent the other modes in the cycle from reporting the same error.
+			delete(bases, mode)
+			continue
+		}
+		for a := range as {
+			descendants[a] = append(descendants[a], mode)
+		}
+	}
+
+	for _, e := range entries {
+		if e.Fragment {
+			continue
+		}
+		modes := e.Modes
+		if len(modes) == 0 {
+			modes = []spec.LexModeName{spec.LexModeNameDefault}
+		}
+		belongs := map[spec.LexModeName]struct{}{}
+		for _, m := range modes {
+			belongs[m] = struct{}{}
+		}
+		var extended []spec.LexModeName
+		for _, m := range modes {
+			for _, d := range descendants[m] {
+				if _, ok := belongs[d]; ok {
+					continue
+				}
+				belongs[d] = struct{}{}
+				extended = append(extended, d)
+			}
+		}
+		if len(extended) == 0 {
+			continue
+		}
+		// Keywords share the slice of modes with their owners, so we must not modify the slice in place.
+		e.Modes = append(append([]spec.LexModeName{}, modes...), extended...)
+	}
+}
+
+// resolveKeywords marks terminal symbols referred to by `#keywords` directives as keywords. A keyword must be a terminal
+// symbol defined by a string literal, and it belongs to the same modes as its owner.
+func (b *GrammarBuilder) resolveKeywords(root *parser.RootNode, kind2Entry map[string]*lexical.LexEntry) {
+	lexProds := map[string]*parser.ProductionNode{}
+	for _, prod := range root.LexProductions {
+		lexProds[prod.LHS] = prod
+	}
+
+	owners := map[string]string{}
+	for _, prod := range root.LexProductions {
+		owner, ok := kind2Entry[prod.LHS]
+		if !ok || len(owner.Keywords) == 0 {
+			continue
+		}
+		var dir *parser.DirectiveNode
+		for _, d := range prod.Directives {
+			if d.Name == "keywords" {
+				dir = d
+				break
+			}
+		}
+		for i, kind := range owner.Keywords {
+			param := dir.Parameters[i]
+			kwProd, ok := lexProds[kind.String()]
+			if !ok {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: fmt.Sprintf("'keywords' directive needs terminal symbols: %v is not a terminal symbol", kind),
+					Row:    param.Pos.Row,
+					Col:    param.Pos.Col,
+				})
+				continue
+			}
+			lit, ok := kwProd.Literal()
+			if !ok {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: fmt.Sprintf("a keyword must be defined by a string literal: %v", kind),
+					Row:    param.Pos.Row,
+					Col:    param.Pos.Col,
+				})
+				continue
+			}
+			if o, ok := owners[kind.String()]; ok {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: fmt.Sprintf("%v is already a keyword of %v", kind, o),
+					Row:    param.Pos.Row,
+					Col:    param.Pos.Col,
+				})
+				continue
+			}
+			owners[kind.String()] = prod.LHS
+
+			kw, ok := kind2Entry[kind.String()]
+			if !ok {
+				// genLexEntry has already reported an error about the keyword.
+				continue
+			}
+			if len(kw.Modes) > 0 || len(kw.Keywords) > 0 {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: fmt.Sprintf("a keyword cannot have 'mode' and 'keywords' directives: %v", kind),
+					Row:    param.Pos.Row,
+					Col:    param.Pos.Col,
+				})
+				continue
+			}
+			kw.Keyword = true
+			kw.Pattern = lit
+			kw.Modes = owner.Modes
+			// A keyword of `#keywords` directive is a keyword for syntax highlighting unless it has its own class.
+			if kw.Class == "" {
+				kw.Class = "keyword"
+			}
+		}
 	}
+}
 
-	return &lexical.LexSpec{
-		Entries: entries,
-	}, skipSyms, nil
+// isNormalizationForm reports whether a parameter of a `#normalize` directive is a Unicode normalization form.
+func isNormalizationForm(form string) bool {
+	switch form {
+	case "nfc", "nfd", "nfkc", "nfkd":
+		return true
+	}
+	return false
 }
 
 func genLexEntry(prod *parser.ProductionNode) (*lexical.LexEntry, bool, *verr.SpecError, error) {
-	alt := prod.RHS[0]
-	elem := alt.Elements[0]
-
+	// A lexical production having multiple alternatives defines one kind matching the union of the alternatives.
 	var pattern string
-	if elem.Literally {
-		pattern = spec.EscapePattern(elem.Pattern)
-	} else {
-		pattern = elem.Pattern
+	for i, alt := range prod.RHS {
+		elem := alt.Elements[0]
+		p := elem.Pattern
+		if elem.Literally {
+			p = spec.EscapePattern(elem.Pattern)
+		}
+		if len(prod.RHS) > 1 {
+			p = "(" + p + ")"
+		}
+		if i > 0 {
+			pattern += "|"
+		}
+		pattern += p
 	}
 
 	var modes []spec.LexModeName
 	var skip bool
 	var push spec.LexModeName
 	var pop bool
+	var caseConfigurable bool
+	var class string
+	var priority int
+	var keywords []spec.LexKindName
+	var trans []*spec.LexTransformation
 	dirConsumed := map[string]struct{}{}
 	for _, dir := range prod.Directives {
 		if _, consumed := dirConsumed[dir.Name]; consumed {
@@ -582,6 +2013,15 @@ func genLexEntry(prod *parser.ProductionNode) (*lexical.LexEntry, bool, *verr.Sp
 		}
 		dirConsumed[dir.Name] = struct{}{}
 
+		if _, ok := lookupDirective(DirectiveContextLexicalProduction, dir.Name); !ok {
+			return nil, false, &verr.SpecError{
+				Cause:  semErrDirInvalidName,
+				Detail: dir.Name,
+				Row:    dir.Pos.Row,
+				Col:    dir.Pos.Col,
+			}, nil
+		}
+
 		switch dir.Name {
 		case "mode":
 			if len(dir.Parameters) == 0 {
@@ -633,45 +2073,239 @@ func genLexEntry(prod *parser.ProductionNode) (*lexical.LexEntry, bool, *verr.Sp
 				}, nil
 			}
 			pop = true
-		default:
-			return nil, false, &verr.SpecError{
-				Cause:  semErrDirInvalidName,
-				Detail: dir.Name,
-				Row:    dir.Pos.Row,
-				Col:    dir.Pos.Col,
-			}, nil
+		case "case_configurable":
+			if len(dir.Parameters) > 0 {
+				return nil, false, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: "'case_configurable' directive needs no parameter",
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				}, nil
+			}
+			caseConfigurable = true
+		case "priority":
+			var err error
+			if len(dir.Parameters) == 1 && dir.Parameters[0].Number != "" {
+				priority, err = strconv.Atoi(dir.Parameters[0].Number)
+			}
+			if len(dir.Parameters) != 1 || dir.Parameters[0].Number == "" || err != nil {
+				return nil, false, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: "'priority' directive needs a non-negative integer parameter",
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				}, nil
+			}
+		case "class":
+			if len(dir.Parameters) != 1 || dir.Parameters[0].ID == "" {
+				return nil, false, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: "'class' directive needs an ID parameter",
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				}, nil
+			}
+			param := dir.Parameters[0]
+			if !isTokenClass(param.ID) {
+				return nil, false, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: fmt.Sprintf("'class' directive needs one of %v: %v", strings.Join(tokenClasses, ", "), param.ID),
+					Row:    param.Pos.Row,
+					Col:    param.Pos.Col,
+				}, nil
+			}
+			class = param.ID
+		case "keywords":
+			if len(dir.Parameters) == 0 {
+				return nil, false, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: "'keywords' directive needs ID parameters",
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				}, nil
+			}
+			for _, param := range dir.Parameters {
+				if param.ID == "" {
+					return nil, false, &verr.SpecError{
+						Cause:  semErrDirInvalidParam,
+						Detail: "'keywords' directive needs ID parameters",
+						Row:    param.Pos.Row,
+						Col:    param.Pos.Col,
+					}, nil
+				}
+				keywords = append(keywords, spec.LexKindName(param.ID))
+			}
+		case "trim", "dedent":
+			if len(dir.Parameters) > 0 {
+				return nil, false, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: fmt.Sprintf("'%v' directive needs no parameter", dir.Name),
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				}, nil
+			}
+			trans = append(trans, &spec.LexTransformation{
+				Name: dir.Name,
+			})
+		case "unescape":
+			if len(dir.Parameters) > 1 || len(dir.Parameters) == 1 && dir.Parameters[0].String == "" {
+				return nil, false, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: "'unescape' directive takes just one optional parameter: a delimiter (string literal)",
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				}, nil
+			}
+			t := &spec.LexTransformation{
+				Name: dir.Name,
+			}
+			if len(dir.Parameters) == 1 {
+				t.Delimiter = dir.Parameters[0].String
+			}
+			trans = append(trans, t)
+		case "normalize":
+			if len(dir.Parameters) != 1 || !isNormalizationForm(dir.Parameters[0].ID) {
+				return nil, false, &verr.SpecError{
+					Cause:  semErrDirInvalidParam,
+					Detail: "'normalize' directive needs just one ID parameter: nfc, nfd, nfkc, or nfkd",
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				}, nil
+			}
+			trans = append(trans, &spec.LexTransformation{
+				Name: dir.Name,
+				Form: dir.Parameters[0].ID,
+			})
 		}
 	}
 
-	if len(alt.Directives) > 0 {
-		return nil, false, &verr.SpecError{
-			Cause:  semErrInvalidAltDir,
-			Detail: "a lexical production cannot have alternative directives",
-			Row:    alt.Directives[0].Pos.Row,
-			Col:    alt.Directives[0].Pos.Col,
-		}, nil
+	for _, alt := range prod.RHS {
+		if len(alt.Directives) > 0 {
+			return nil, false, &verr.SpecError{
+				Cause:  semErrInvalidAltDir,
+				Detail: "a lexical production cannot have alternative directives",
+				Row:    alt.Directives[0].Pos.Row,
+				Col:    alt.Directives[0].Pos.Col,
+			}, nil
+		}
 	}
 
 	return &lexical.LexEntry{
-		Modes:   modes,
-		Kind:    spec.LexKindName(prod.LHS),
-		Pattern: pattern,
-		Push:    push,
-		Pop:     pop,
+		Modes:            modes,
+		Kind:             spec.LexKindName(prod.LHS),
+		Pattern:          pattern,
+		Push:             push,
+		Pop:              pop,
+		CaseConfigurable: caseConfigurable,
+		Class:            class,
+		Priority:         priority,
+		Keywords:         keywords,
+		Transformations:  trans,
 	}, skip, nil, nil
 }
 
+// tokenClasses is the list of classes that `#class` directive accepts.
+var tokenClasses = []string{
+	"keyword",
+	"operator",
+	"literal",
+	"comment",
+}
+
+func isTokenClass(class string) bool {
+	for _, c := range tokenClasses {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
 type productionsAndActions struct {
 	prods           *productionSet
 	augStartSym     symbol.Symbol
 	astActs         map[productionID][]*astActionEntry
+	astNodeNames    map[productionID]string
+	astLifts        map[productionID]int
+	altLabels       map[productionID]string
 	prodPrecsTerm   map[productionID]symbol.Symbol
 	prodPrecsOrdSym map[productionID]string
+	prodPrecsLevel  map[productionID]string
 	prodPrecPoss    map[productionID]*parser.Position
+	prodPrefers     map[productionID]ActionType
 	recoverProds    map[productionID]struct{}
+	recoverSyncs    map[productionID][]symbol.Symbol
+	lexModeOps      map[productionID]map[int]*lexModeOp
+	prodPoss        map[productionID]parser.Position
+	altNodes        map[productionID]*parser.AlternativeNode
+	altIndexes      map[productionID]int
+}
+
+// genRecoverSyncTerminals returns the terminal symbols of a `#recover until {<terminal>}` directive. The terminal
+// symbols are the synchronization set of the error symbol in the alternative. After the parser shifts the error
+// symbol, it discards tokens until it reads one of them.
+func (b *GrammarBuilder) genRecoverSyncTerminals(dir *parser.DirectiveNode, altSyms []symbol.Symbol, symTab *symbol.SymbolTableReader, errSym symbol.Symbol, lit2Term map[string]string) ([]symbol.Symbol, bool) {
+	if len(dir.Parameters) < 2 || dir.Parameters[0].ID != "until" {
+		b.errs = append(b.errs, &verr.SpecError{
+			Cause:  semErrDirInvalidParam,
+			Detail: "'recover' directive needs no parameter or 'until' followed by terminal symbols",
+			Row:    dir.Pos.Row,
+			Col:    dir.Pos.Col,
+		})
+		return nil, false
+	}
+	hasErrSym := false
+	for _, sym := range altSyms {
+		if sym == errSym {
+			hasErrSym = true
+			break
+		}
+	}
+	if !hasErrSym {
+		b.errs = append(b.errs, &verr.SpecError{
+			Cause:  semErrDirInvalidParam,
+			Detail: "'recover until' directive can be applied only to an alternative containing the error symbol",
+			Row:    dir.Pos.Row,
+			Col:    dir.Pos.Col,
+		})
+		return nil, false
+	}
+
+	var syncs []symbol.Symbol
+	added := map[symbol.Symbol]struct{}{}
+	for _, param := range dir.Parameters[1:] {
+		name := param.ID
+		if param.String != "" {
+			name = lit2Term[param.String]
+		}
+		sym, ok := symTab.ToSymbol(name)
+		if name == "" || !ok || !sym.IsTerminal() || sym == errSym {
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: "'recover until' directive takes only terminal symbols except the error symbol",
+				Row:    param.Pos.Row,
+				Col:    param.Pos.Col,
+			})
+			return nil, false
+		}
+		if _, ok := added[sym]; ok {
+			continue
+		}
+		added[sym] = struct{}{}
+		syncs = append(syncs, sym)
+	}
+	return syncs, true
 }
 
-func (b *GrammarBuilder) genProductionsAndActions(root *parser.RootNode, symTab *symbol.SymbolTableReader, errSym symbol.Symbol, augStartSym symbol.Symbol, startSym symbol.Symbol) (*productionsAndActions, error) {
+// lexModeOp is an operation on the mode stack of the lexer that `#push` or `#pop` directive on an alternative specifies.
+// The parser performs the operation when it shifts the element the directive refers to.
+type lexModeOp struct {
+	push spec.LexModeName
+	pop  bool
+	pos  parser.Position
+}
+
+func (b *GrammarBuilder) genProductionsAndActions(root *parser.RootNode, symTab *symbol.SymbolTableReader, errSym symbol.Symbol, augStartSym symbol.Symbol, startSym symbol.Symbol, entryPoints []*entryPoint, dupAltPolicy DuplicateAlternativePolicy) (*productionsAndActions, error) {
 	if len(root.Productions) == 0 {
 		b.errs = append(b.errs, &verr.SpecError{
 			Cause: semErrNoProduction,
@@ -681,10 +2315,32 @@ func (b *GrammarBuilder) genProductionsAndActions(root *parser.RootNode, symTab
 
 	prods := newProductionSet()
 	astActs := map[productionID][]*astActionEntry{}
+	astNodeNames := map[productionID]string{}
+	astLifts := map[productionID]int{}
+	altLabels := map[productionID]string{}
 	prodPrecsTerm := map[productionID]symbol.Symbol{}
 	prodPrecsOrdSym := map[productionID]string{}
+	prodPrecsLevel := map[productionID]string{}
 	prodPrecPoss := map[productionID]*parser.Position{}
+	prodPrefers := map[productionID]ActionType{}
 	recoverProds := map[productionID]struct{}{}
+	recoverSyncs := map[productionID][]symbol.Symbol{}
+	lexModeOps := map[productionID]map[int]*lexModeOp{}
+
+	// `#recover until` directive can refer to terminal symbols by the string literals defining them.
+	lit2Term := map[string]string{}
+	for _, prod := range root.LexProductions {
+		if lit, ok := prod.Literal(); ok {
+			if _, ok := lit2Term[lit]; !ok {
+				lit2Term[lit] = prod.LHS
+			}
+		}
+	}
+
+	// altNodes and altPoss hold alternatives and their positions to detect and report duplicate alternatives.
+	altNodes := map[productionID]*parser.AlternativeNode{}
+	altPoss := map[productionID]parser.Position{}
+	altIndexes := map[productionID]int{}
 
 	p, err := newProduction(augStartSym, []symbol.Symbol{
 		startSym,
@@ -712,8 +2368,11 @@ func (b *GrammarBuilder) genProductionsAndActions(root *parser.RootNode, symTab
 			continue
 		}
 
+		// labels holds the labels of the alternatives of the production because a label must identify an alternative.
+		labels := map[string]struct{}{}
+
 	LOOP_RHS:
-		for _, alt := range prod.RHS {
+		for altIndex, alt := range prod.RHS {
 			altSyms := make([]symbol.Symbol, len(alt.Elements))
 			offsets := map[string]int{}
 			ambiguousIDOffsets := map[string]struct{}{}
@@ -725,6 +2384,8 @@ func (b *GrammarBuilder) genProductionsAndActions(root *parser.RootNode, symTab
 						Detail: elem.ID,
 						Row:    elem.Pos.Row,
 						Col:    elem.Pos.Col,
+						EndRow: elem.Pos.Row,
+						EndCol: elem.Pos.Col + len(elem.ID) - 1,
 					})
 					continue LOOP_RHS
 				}
@@ -770,17 +2431,20 @@ func (b *GrammarBuilder) genProductionsAndActions(root *parser.RootNode, symTab
 			if err != nil {
 				return nil, err
 			}
+			// Report the line number of a duplicate alternative.
+			// When the alternative is empty, we report the position of its LHS.
+			var row int
+			var col int
+			if len(alt.Elements) > 0 {
+				row = alt.Elements[0].Pos.Row
+				col = alt.Elements[0].Pos.Col
+			} else {
+				row = prod.Pos.Row
+				col = prod.Pos.Col
+			}
 			if _, exist := prods.findByID(p.id); exist {
-				// Report the line number of a duplicate alternative.
-				// When the alternative is empty, we report the position of its LHS.
-				var row int
-				var col int
-				if len(alt.Elements) > 0 {
-					row = alt.Elements[0].Pos.Row
-					col = alt.Elements[0].Pos.Col
-				} else {
-					row = prod.Pos.Row
-					col = prod.Pos.Col
+				if dupAltPolicy == DuplicateAlternativePolicyExact && equalAlternatives(alt, altNodes[p.id]) {
+					continue LOOP_RHS
 				}
 
 				var detail string
@@ -798,19 +2462,35 @@ func (b *GrammarBuilder) genProductionsAndActions(root *parser.RootNode, symTab
 					if len(alt.Elements) == 0 {
 						fmt.Fprintf(&b, " ε")
 					}
+					prev := altPoss[p.id]
+					fmt.Fprintf(&b, " (previously defined at %v:%v)", prev.Row, prev.Col)
 
 					detail = b.String()
 				}
 
+				prev := altPoss[p.id]
 				b.errs = append(b.errs, &verr.SpecError{
 					Cause:  semErrDuplicateProduction,
 					Detail: detail,
 					Row:    row,
 					Col:    col,
+					Related: []*verr.RelatedInfo{
+						{
+							Message: "previous definition",
+							Row:     prev.Row,
+							Col:     prev.Col,
+						},
+					},
 				})
 				continue LOOP_RHS
 			}
 			prods.append(p)
+			altNodes[p.id] = alt
+			altPoss[p.id] = parser.Position{
+				Row: row,
+				Col: col,
+			}
+			altIndexes[p.id] = altIndex + 1
 
 			dirConsumed := map[string]struct{}{}
 			for _, dir := range alt.Directives {
@@ -824,6 +2504,16 @@ func (b *GrammarBuilder) genProductionsAndActions(root *parser.RootNode, symTab
 				}
 				dirConsumed[dir.Name] = struct{}{}
 
+				if _, ok := lookupDirective(DirectiveContextAlternative, dir.Name); !ok {
+					b.errs = append(b.errs, &verr.SpecError{
+						Cause:  semErrDirInvalidName,
+						Detail: fmt.Sprintf("invalid directive name '%v'", dir.Name),
+						Row:    dir.Pos.Row,
+						Col:    dir.Pos.Col,
+					})
+					continue LOOP_RHS
+				}
+
 				switch dir.Name {
 				case "ast":
 					if len(dir.Parameters) == 0 {
@@ -914,70 +2604,240 @@ func (b *GrammarBuilder) genProductionsAndActions(root *parser.RootNode, symTab
 							expansion: param.Expansion,
 						}
 					}
-					astActs[p.id] = astAct
-				case "prec":
-					if len(dir.Parameters) != 1 || (dir.Parameters[0].ID == "" && dir.Parameters[0].OrderedSymbol == "") {
+					astActs[p.id] = astAct
+				case "prec":
+					if len(dir.Parameters) != 1 || (dir.Parameters[0].ID == "" && dir.Parameters[0].OrderedSymbol == "" && dir.Parameters[0].LevelName == "") {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrDirInvalidParam,
+							Detail: "'prec' directive needs just one ID parameter, ordered symbol, or level name",
+							Row:    dir.Pos.Row,
+							Col:    dir.Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					param := dir.Parameters[0]
+					switch {
+					case param.ID != "":
+						sym, ok := symTab.ToSymbol(param.ID)
+						if !ok {
+							b.errs = append(b.errs, &verr.SpecError{
+								Cause:  semErrDirInvalidParam,
+								Detail: fmt.Sprintf("unknown terminal symbol: %v", param.ID),
+								Row:    param.Pos.Row,
+								Col:    param.Pos.Col,
+							})
+							continue LOOP_RHS
+						}
+						if sym == errSym {
+							b.errs = append(b.errs, &verr.SpecError{
+								Cause:  semErrDirInvalidParam,
+								Detail: fmt.Sprintf("'%v' directive cannot be applied to an error symbol", dir.Name),
+								Row:    param.Pos.Row,
+								Col:    param.Pos.Col,
+							})
+						}
+						if !sym.IsTerminal() {
+							b.errs = append(b.errs, &verr.SpecError{
+								Cause:  semErrDirInvalidParam,
+								Detail: fmt.Sprintf("the symbol must be a terminal: %v", param.ID),
+								Row:    param.Pos.Row,
+								Col:    param.Pos.Col,
+							})
+							continue LOOP_RHS
+						}
+						prodPrecsTerm[p.id] = sym
+						prodPrecPoss[p.id] = &param.Pos
+					case param.OrderedSymbol != "":
+						prodPrecsOrdSym[p.id] = param.OrderedSymbol
+						prodPrecPoss[p.id] = &param.Pos
+					case param.LevelName != "":
+						prodPrecsLevel[p.id] = param.LevelName
+						prodPrecPoss[p.id] = &param.Pos
+					}
+				case "prefer":
+					if len(dir.Parameters) != 1 || (dir.Parameters[0].ID != "shift" && dir.Parameters[0].ID != "reduce") {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrDirInvalidParam,
+							Detail: "'prefer' directive needs just one parameter: shift or reduce",
+							Row:    dir.Pos.Row,
+							Col:    dir.Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					if dir.Parameters[0].ID == "shift" {
+						prodPrefers[p.id] = ActionTypeShift
+					} else {
+						prodPrefers[p.id] = ActionTypeReduce
+					}
+				case "recover":
+					if len(dir.Parameters) > 0 {
+						syncs, ok := b.genRecoverSyncTerminals(dir, altSyms, symTab, errSym, lit2Term)
+						if !ok {
+							continue LOOP_RHS
+						}
+						recoverSyncs[p.id] = syncs
+					}
+					recoverProds[p.id] = struct{}{}
+				case "rename":
+					if len(dir.Parameters) != 1 || dir.Parameters[0].ID == "" {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrDirInvalidParam,
+							Detail: "'rename' directive needs just one ID parameter",
+							Row:    dir.Pos.Row,
+							Col:    dir.Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					astNodeNames[p.id] = dir.Parameters[0].ID
+				case "label":
+					if len(dir.Parameters) != 1 || dir.Parameters[0].ID == "" || dir.Parameters[0].Expansion {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrDirInvalidParam,
+							Detail: "'label' directive needs just one ID parameter",
+							Row:    dir.Pos.Row,
+							Col:    dir.Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					label := dir.Parameters[0].ID
+					if _, ok := labels[label]; ok {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrDuplicateLabel,
+							Detail: fmt.Sprintf("'%v' labels another alternative of %v", label, prod.LHS),
+							Row:    dir.Parameters[0].Pos.Row,
+							Col:    dir.Parameters[0].Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					labels[label] = struct{}{}
+					altLabels[p.id] = label
+				case "lift":
+					if len(dir.Parameters) != 1 || dir.Parameters[0].ID == "" || dir.Parameters[0].Expansion {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrDirInvalidParam,
+							Detail: "'lift' directive needs just one ID parameter",
+							Row:    dir.Pos.Row,
+							Col:    dir.Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					param := dir.Parameters[0]
+					if _, ambiguous := ambiguousIDOffsets[param.ID]; ambiguous {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrAmbiguousElem,
+							Detail: fmt.Sprintf("'%v' is ambiguous", param.ID),
+							Row:    param.Pos.Row,
+							Col:    param.Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					offset, ok := offsets[param.ID]
+					if !ok {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrDirInvalidParam,
+							Detail: fmt.Sprintf("a symbol was not found in an alternative: %v", param.ID),
+							Row:    param.Pos.Row,
+							Col:    param.Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					astLifts[p.id] = offset + 1
+				case "push", "pop":
+					paramCount := 1
+					detail := "'pop' directive needs just one ID parameter"
+					if dir.Name == "push" {
+						paramCount = 2
+						detail = "'push' directive needs a mode name and a symbol"
+					}
+					if len(dir.Parameters) != paramCount {
 						b.errs = append(b.errs, &verr.SpecError{
 							Cause:  semErrDirInvalidParam,
-							Detail: "'prec' directive needs just one ID parameter or ordered symbol",
+							Detail: detail,
 							Row:    dir.Pos.Row,
 							Col:    dir.Pos.Col,
 						})
 						continue LOOP_RHS
 					}
-					param := dir.Parameters[0]
-					switch {
-					case param.ID != "":
-						sym, ok := symTab.ToSymbol(param.ID)
-						if !ok {
-							b.errs = append(b.errs, &verr.SpecError{
-								Cause:  semErrDirInvalidParam,
-								Detail: fmt.Sprintf("unknown terminal symbol: %v", param.ID),
-								Row:    param.Pos.Row,
-								Col:    param.Pos.Col,
-							})
-							continue LOOP_RHS
-						}
-						if sym == errSym {
-							b.errs = append(b.errs, &verr.SpecError{
-								Cause:  semErrDirInvalidParam,
-								Detail: fmt.Sprintf("'%v' directive cannot be applied to an error symbol", dir.Name),
-								Row:    param.Pos.Row,
-								Col:    param.Pos.Col,
-							})
-						}
-						if !sym.IsTerminal() {
+					for _, param := range dir.Parameters {
+						if param.ID == "" || param.Expansion {
 							b.errs = append(b.errs, &verr.SpecError{
 								Cause:  semErrDirInvalidParam,
-								Detail: fmt.Sprintf("the symbol must be a terminal: %v", param.ID),
+								Detail: fmt.Sprintf("'%v' directive can take only ID parameters", dir.Name),
 								Row:    param.Pos.Row,
 								Col:    param.Pos.Col,
 							})
 							continue LOOP_RHS
 						}
-						prodPrecsTerm[p.id] = sym
-						prodPrecPoss[p.id] = &param.Pos
-					case param.OrderedSymbol != "":
-						prodPrecsOrdSym[p.id] = param.OrderedSymbol
-						prodPrecPoss[p.id] = &param.Pos
 					}
-				case "recover":
-					if len(dir.Parameters) > 0 {
+					param := dir.Parameters[paramCount-1]
+					if _, ambiguous := ambiguousIDOffsets[param.ID]; ambiguous {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrAmbiguousElem,
+							Detail: fmt.Sprintf("'%v' is ambiguous", param.ID),
+							Row:    param.Pos.Row,
+							Col:    param.Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					offset, ok := offsets[param.ID]
+					if !ok {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrDirInvalidParam,
+							Detail: fmt.Sprintf("a symbol was not found in an alternative: %v", param.ID),
+							Row:    param.Pos.Row,
+							Col:    param.Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					// The parser switches lex modes only when it shifts a token, so the element must be a terminal symbol
+					// the lexer generates.
+					if elemSym := altSyms[offset]; !elemSym.IsTerminal() || elemSym == errSym {
 						b.errs = append(b.errs, &verr.SpecError{
 							Cause:  semErrDirInvalidParam,
-							Detail: "'recover' directive needs no parameter",
+							Detail: fmt.Sprintf("the symbol must be a terminal: %v", param.ID),
+							Row:    param.Pos.Row,
+							Col:    param.Pos.Col,
+						})
+						continue LOOP_RHS
+					}
+					ops, ok := lexModeOps[p.id]
+					if !ok {
+						ops = map[int]*lexModeOp{}
+						lexModeOps[p.id] = ops
+					}
+					if _, ok := ops[offset]; ok {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrInvalidAltDir,
+							Detail: fmt.Sprintf("'push' directive and 'pop' directive cannot be applied to the same symbol: %v", param.ID),
 							Row:    dir.Pos.Row,
 							Col:    dir.Pos.Col,
 						})
 						continue LOOP_RHS
 					}
-					recoverProds[p.id] = struct{}{}
-				default:
+					op := &lexModeOp{
+						pos: dir.Pos,
+					}
+					if dir.Name == "push" {
+						op.push = spec.LexModeName(dir.Parameters[0].ID)
+					} else {
+						op.pop = true
+					}
+					ops[offset] = op
+				}
+			}
+
+			// Because a node lifted by the `#lift` directive replaces a whole node of the alternative, the node cannot
+			// have its own structure or name.
+			if _, lifted := dirConsumed["lift"]; lifted {
+				for _, name := range []string{"ast", "rename", "label"} {
+					if _, ok := dirConsumed[name]; !ok {
+						continue
+					}
 					b.errs = append(b.errs, &verr.SpecError{
-						Cause:  semErrDirInvalidName,
-						Detail: fmt.Sprintf("invalid directive name '%v'", dir.Name),
-						Row:    dir.Pos.Row,
-						Col:    dir.Pos.Col,
+						Cause:  semErrInvalidAltDir,
+						Detail: fmt.Sprintf("'lift' directive cannot be used with '%v' directive", name),
+						Row:    alt.Pos.Row,
+						Col:    alt.Pos.Col,
 					})
 					continue LOOP_RHS
 				}
@@ -985,24 +2845,46 @@ func (b *GrammarBuilder) genProductionsAndActions(root *parser.RootNode, symTab
 		}
 	}
 
+	for _, e := range entryPoints {
+		p, err := newProduction(e.augStart, []symbol.Symbol{
+			e.symbol,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		prods.append(p)
+	}
+
 	return &productionsAndActions{
 		prods:           prods,
 		augStartSym:     augStartSym,
 		astActs:         astActs,
+		astNodeNames:    astNodeNames,
+		astLifts:        astLifts,
+		altLabels:       altLabels,
 		prodPrecsTerm:   prodPrecsTerm,
 		prodPrecsOrdSym: prodPrecsOrdSym,
+		prodPrecsLevel:  prodPrecsLevel,
 		prodPrecPoss:    prodPrecPoss,
+		prodPrefers:     prodPrefers,
 		recoverProds:    recoverProds,
+		recoverSyncs:    recoverSyncs,
+		lexModeOps:      lexModeOps,
+		prodPoss:        altPoss,
+		altNodes:        altNodes,
+		altIndexes:      altIndexes,
 	}, nil
 }
 
-func (b *GrammarBuilder) genPrecAndAssoc(symTab *symbol.SymbolTableReader, errSym symbol.Symbol, prodsAndActs *productionsAndActions) (*precAndAssoc, error) {
+func (b *GrammarBuilder) genPrecAndAssoc(root *parser.RootNode, symTab *symbol.SymbolTableReader, errSym symbol.Symbol, prodsAndActs *productionsAndActions) (*precAndAssoc, error) {
 	termPrec := map[symbol.SymbolNum]int{}
 	termAssoc := map[symbol.SymbolNum]assocType{}
 	ordSymPrec := map[string]int{}
+	levelPrec := map[string]int{}
 	{
 		var precGroup []*parser.DirectiveNode
-		for _, dir := range b.AST.Directives {
+		for _, dir := range root.Directives {
 			if dir.Name == "prec" {
 				if dir.Parameters == nil || len(dir.Parameters) != 1 || dir.Parameters[0].Group == nil {
 					b.errs = append(b.errs, &verr.SpecError{
@@ -1017,7 +2899,7 @@ func (b *GrammarBuilder) genPrecAndAssoc(symTab *symbol.SymbolTableReader, errSy
 				continue
 			}
 
-			if dir.Name != "name" && dir.Name != "prec" {
+			if _, ok := lookupDirective(DirectiveContextGrammar, dir.Name); !ok {
 				b.errs = append(b.errs, &verr.SpecError{
 					Cause:  semErrDirInvalidName,
 					Detail: dir.Name,
@@ -1030,22 +2912,26 @@ func (b *GrammarBuilder) genPrecAndAssoc(symTab *symbol.SymbolTableReader, errSy
 
 		precN := precMin
 		for _, dir := range precGroup {
+			if _, ok := lookupDirective(DirectiveContextPrecedence, dir.Name); !ok {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrDirInvalidName,
+					Detail: dir.Name,
+					Row:    dir.Pos.Row,
+					Col:    dir.Pos.Col,
+				})
+				return nil, nil
+			}
+
 			var assocTy assocType
 			switch dir.Name {
 			case "left":
 				assocTy = assocTypeLeft
 			case "right":
 				assocTy = assocTypeRight
+			case "nonassoc":
+				assocTy = assocTypeNonAssoc
 			case "assign":
 				assocTy = assocTypeNil
-			default:
-				b.errs = append(b.errs, &verr.SpecError{
-					Cause:  semErrDirInvalidName,
-					Detail: dir.Name,
-					Row:    dir.Pos.Row,
-					Col:    dir.Pos.Col,
-				})
-				return nil, nil
 			}
 
 			if len(dir.Parameters) == 0 {
@@ -1058,7 +2944,7 @@ func (b *GrammarBuilder) genPrecAndAssoc(symTab *symbol.SymbolTableReader, errSy
 				return nil, nil
 			}
 		ASSOC_PARAM_LOOP:
-			for _, p := range dir.Parameters {
+			for i, p := range dir.Parameters {
 				switch {
 				case p.ID != "":
 					sym, ok := symTab.ToSymbol(p.ID)
@@ -1138,10 +3024,31 @@ func (b *GrammarBuilder) genPrecAndAssoc(symTab *symbol.SymbolTableReader, errSy
 					}
 
 					ordSymPrec[p.OrderedSymbol] = precN
+				case p.LevelName != "":
+					if i != 0 {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrDirInvalidParam,
+							Detail: fmt.Sprintf("a level name must be the first parameter: ':%v'", p.LevelName),
+							Row:    p.Pos.Row,
+							Col:    p.Pos.Col,
+						})
+						return nil, nil
+					}
+					if _, alreadySet := levelPrec[p.LevelName]; alreadySet {
+						b.errs = append(b.errs, &verr.SpecError{
+							Cause:  semErrDirInvalidParam,
+							Detail: fmt.Sprintf("level ':%v' is already defined", p.LevelName),
+							Row:    p.Pos.Row,
+							Col:    p.Pos.Col,
+						})
+						break ASSOC_PARAM_LOOP
+					}
+
+					levelPrec[p.LevelName] = precN
 				default:
 					b.errs = append(b.errs, &verr.SpecError{
 						Cause:  semErrDirInvalidParam,
-						Detail: "a parameter must be an ID or an ordered symbol",
+						Detail: "a parameter must be an ID, an ordered symbol, or a level name",
 						Row:    p.Pos.Row,
 						Col:    p.Pos.Col,
 					})
@@ -1158,7 +3065,12 @@ func (b *GrammarBuilder) genPrecAndAssoc(symTab *symbol.SymbolTableReader, errSy
 
 	prodPrec := map[productionNum]int{}
 	prodAssoc := map[productionNum]assocType{}
+	prodPrefer := map[productionNum]ActionType{}
 	for _, prod := range prodsAndActs.prods.getAllProductions() {
+		if act, ok := prodsAndActs.prodPrefers[prod.id]; ok {
+			prodPrefer[prod.num] = act
+		}
+
 		// A #prec directive changes only precedence, not associativity.
 		if term, ok := prodsAndActs.prodPrecsTerm[prod.id]; ok {
 			if prec, ok := termPrec[term.Num()]; ok {
@@ -1173,6 +3085,18 @@ func (b *GrammarBuilder) genPrecAndAssoc(symTab *symbol.SymbolTableReader, errSy
 					Col:    prodsAndActs.prodPrecPoss[prod.id].Col,
 				})
 			}
+		} else if level, ok := prodsAndActs.prodPrecsLevel[prod.id]; ok {
+			if prec, ok := levelPrec[level]; ok {
+				prodPrec[prod.num] = prec
+				prodAssoc[prod.num] = assocTypeNil
+			} else {
+				b.errs = append(b.errs, &verr.SpecError{
+					Cause:  semErrUndefinedPrecLevel,
+					Detail: ":" + level,
+					Row:    prodsAndActs.prodPrecPoss[prod.id].Row,
+					Col:    prodsAndActs.prodPrecPoss[prod.id].Col,
+				})
+			}
 		} else if ordSym, ok := prodsAndActs.prodPrecsOrdSym[prod.id]; ok {
 			if prec, ok := ordSymPrec[ordSym]; ok {
 				prodPrec[prod.num] = prec
@@ -1205,10 +3129,11 @@ func (b *GrammarBuilder) genPrecAndAssoc(symTab *symbol.SymbolTableReader, errSy
 	}
 
 	return &precAndAssoc{
-		termPrec:  termPrec,
-		termAssoc: termAssoc,
-		prodPrec:  prodPrec,
-		prodAssoc: prodAssoc,
+		termPrec:   termPrec,
+		termAssoc:  termAssoc,
+		prodPrec:   prodPrec,
+		prodAssoc:  prodAssoc,
+		prodPrefer: prodPrefer,
 	}, nil
 }
 
@@ -1218,16 +3143,15 @@ func compile(gram *Grammar, opts ...BuildOption) (*spec.CompiledGrammar, *spec.R
 		opt(config)
 	}
 
-	lexSpec, err, cErrs := lexical.Compile(gram.lexSpec, lexical.CompressionLevelMax)
+	compLv := lexical.CompressionLevelRowDisplacement
+	if config.lexerTable == LexerTableBaseCheck {
+		compLv = lexical.CompressionLevelBaseCheck
+	}
+	gram.lexSpec.LazyDFA = config.lazyLexer
+	lexSpec, lexReport, err, cErrs := lexical.CompileAndReport(gram.lexSpec, compLv)
 	if err != nil {
 		if len(cErrs) > 0 {
-			var b strings.Builder
-			writeCompileError(&b, cErrs[0])
-			for _, cerr := range cErrs[1:] {
-				fmt.Fprintf(&b, "\n")
-				writeCompileError(&b, cerr)
-			}
-			return nil, nil, fmt.Errorf(b.String())
+			return nil, nil, compileErrorsToError(cErrs)
 		}
 		return nil, nil, err
 	}
@@ -1246,6 +3170,33 @@ func compile(gram *Grammar, opts ...BuildOption) (*spec.CompiledGrammar, *spec.R
 		kind2Term[i] = sym.Num().Int()
 	}
 
+	if gram.isLexerOnly() {
+		var report *spec.Report
+		if config.isReportingEnabled {
+			report, err = genLexerOnlyReport(gram)
+			if err != nil {
+				return nil, nil, err
+			}
+			report.Lexical = lexReport
+		}
+
+		cg := &spec.CompiledGrammar{
+			Name:      gram.name,
+			Metadata:  gram.metadata,
+			Lexical:   lexSpec,
+			SourceMap: genSourceMap(gram, config.sourceName, lexSpec.KindNames, nil),
+		}
+		if report != nil {
+			report.SourceMap = cg.SourceMap
+		}
+		err := stamp(cg)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return cg, report, nil
+	}
+
 	termTexts, err := gram.symbolTable.TerminalTexts()
 	if err != nil {
 		return nil, nil, err
@@ -1271,6 +3222,14 @@ func compile(gram *Grammar, opts ...BuildOption) (*spec.CompiledGrammar, *spec.R
 		}
 	}
 
+	var termKeep []int
+	if len(gram.keptSymbols) > 0 {
+		termKeep = make([]int, len(termTexts))
+		for _, sym := range gram.keptSymbols {
+			termKeep[sym.Num()] = 1
+		}
+	}
+
 	nonTerms, err := gram.symbolTable.NonTerminalTexts()
 	if err != nil {
 		return nil, nil, err
@@ -1281,7 +3240,11 @@ func compile(gram *Grammar, opts ...BuildOption) (*spec.CompiledGrammar, *spec.R
 		return nil, nil, err
 	}
 
-	lr0, err := genLR0Automaton(gram.productionSet, gram.augmentedStartSymbol, gram.errorSymbol)
+	entryStartSyms := make([]symbol.Symbol, len(gram.entryPoints))
+	for i, e := range gram.entryPoints {
+		entryStartSyms[i] = e.augStart
+	}
+	lr0, err := genLR0Automaton(gram.productionSet, gram.augmentedStartSymbol, gram.errorSymbol, entryStartSyms...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1312,6 +3275,55 @@ func compile(gram *Grammar, opts ...BuildOption) (*spec.CompiledGrammar, *spec.R
 			if err != nil {
 				return nil, nil, err
 			}
+			report.Lexical = lexReport
+		}
+	}
+
+	var entryPoints []*spec.EntryPoint
+	for _, e := range gram.entryPoints {
+		name, ok := gram.symbolTable.ToText(e.symbol)
+		if !ok {
+			return nil, nil, fmt.Errorf("symbol not found: %v", e.symbol)
+		}
+		prods, ok := gram.productionSet.findByLHS(e.augStart)
+		if !ok {
+			return nil, nil, fmt.Errorf("the production of an entry point was not found: %v", name)
+		}
+		entryPoints = append(entryPoints, &spec.EntryPoint{
+			Symbol:          name,
+			InitialState:    lr0.states[lr0.entryStates[e.augStart]].num.Int(),
+			StartProduction: prods[0].num.Int(),
+		})
+	}
+
+	var termLits []string
+	for i, t := range termTexts {
+		lit, ok := gram.terminalLiterals[t]
+		if !ok {
+			continue
+		}
+		if termLits == nil {
+			termLits = make([]string, len(termTexts))
+		}
+		termLits[i] = lit
+	}
+
+	lexModeActs, err := genLexModeActions(gram, lr0, lexSpec.ModeNames, len(termTexts))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	errSyncs, err := genErrorSyncTerminals(gram, lr0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lay *spec.Layout
+	if gram.layout != nil {
+		lay = &spec.Layout{
+			Indent:  gram.layout.indent.Num().Int(),
+			Dedent:  gram.layout.dedent.Num().Int(),
+			Newline: gram.layout.newline.Num().Int(),
 		}
 	}
 
@@ -1328,6 +3340,9 @@ func compile(gram *Grammar, opts ...BuildOption) (*spec.CompiledGrammar, *spec.R
 	altSymCounts := make([]int, len(gram.productionSet.getAllProductions())+1)
 	recoverProds := make([]int, len(gram.productionSet.getAllProductions())+1)
 	astActEnties := make([][]int, len(gram.productionSet.getAllProductions())+1)
+	var astNodeNames []string
+	var astLifts []int
+	var altLabels []string
 	for _, p := range gram.productionSet.getAllProductions() {
 		lhsSyms[p.num] = p.lhs.Num().Int()
 		altSymCounts[p.num] = p.rhsLen
@@ -1336,6 +3351,25 @@ func compile(gram *Grammar, opts ...BuildOption) (*spec.CompiledGrammar, *spec.R
 			recoverProds[p.num] = 1
 		}
 
+		if name, ok := gram.astNodeNames[p.id]; ok {
+			if astNodeNames == nil {
+				astNodeNames = make([]string, len(gram.productionSet.getAllProductions())+1)
+			}
+			astNodeNames[p.num] = name
+		}
+		if label, ok := gram.altLabels[p.id]; ok {
+			if altLabels == nil {
+				altLabels = make([]string, len(gram.productionSet.getAllProductions())+1)
+			}
+			altLabels[p.num] = label
+		}
+		if pos, ok := gram.astLifts[p.id]; ok {
+			if astLifts == nil {
+				astLifts = make([]int, len(gram.productionSet.getAllProductions())+1)
+			}
+			astLifts[p.num] = pos
+		}
+
 		astAct, ok := gram.astActions[p.id]
 		if !ok {
 			continue
@@ -1351,20 +3385,23 @@ func compile(gram *Grammar, opts ...BuildOption) (*spec.CompiledGrammar, *spec.R
 		astActEnties[p.num] = astActEntry
 	}
 
-	return &spec.CompiledGrammar{
-		Name:    gram.name,
-		Lexical: lexSpec,
+	cg := &spec.CompiledGrammar{
+		Name:     gram.name,
+		Metadata: gram.metadata,
+		Lexical:  lexSpec,
 		Syntactic: &spec.SyntacticSpec{
 			Action:                  action,
 			GoTo:                    goTo,
 			StateCount:              tab.stateCount,
 			InitialState:            tab.InitialState.Int(),
 			StartProduction:         productionNumStart.Int(),
+			EntryPoints:             entryPoints,
 			LHSSymbols:              lhsSyms,
 			AlternativeSymbolCounts: altSymCounts,
 			Terminals:               termTexts,
 			TerminalCount:           tab.terminalCount,
 			TerminalSkip:            termSkip,
+			TerminalKeep:            termKeep,
 			KindToTerminal:          kind2Term,
 			NonTerminals:            nonTerms,
 			NonTerminalCount:        tab.nonTerminalCount,
@@ -1372,14 +3409,237 @@ func compile(gram *Grammar, opts ...BuildOption) (*spec.CompiledGrammar, *spec.R
 			ErrorSymbol:             gram.errorSymbol.Num().Int(),
 			ErrorTrapperStates:      tab.errorTrapperStates,
 			RecoverProductions:      recoverProds,
+			LexModeActions:          lexModeActs,
+			Layout:                  lay,
+			ErrorSyncTerminals:      errSyncs,
+			FallbackTerminal:        gram.fallback.Num().Int(),
+			TerminalLiterals:        termLits,
+			AlternativeLabels:       altLabels,
 		},
 		ASTAction: &spec.ASTAction{
-			Entries: astActEnties,
+			Entries:   astActEnties,
+			NodeNames: astNodeNames,
+			Lifts:     astLifts,
 		},
-	}, report, nil
+		SourceMap:    genSourceMap(gram, config.sourceName, lexSpec.KindNames, nonTerms),
+		Alternatives: genAlternatives(gram),
+	}
+	if report != nil {
+		report.SourceMap = cg.SourceMap
+	}
+	err = stamp(cg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cg, report, nil
+}
+
+// genSourceMap generates a source map of a grammar. `nonTerms` is indexed by non-terminal symbol numbers, and it is nil
+// for a lexer-only grammar.
+func genSourceMap(gram *Grammar, sourceName string, kindNames []spec.LexKindName, nonTerms []string) *spec.SourceMap {
+	sm := &spec.SourceMap{
+		File:  sourceName,
+		Kinds: make([]*spec.SourceRange, len(kindNames)),
+	}
+	for i, k := range kindNames {
+		sm.Kinds[i] = gram.rulePositions[k.String()]
+	}
+	if nonTerms == nil {
+		return sm
+	}
+
+	sm.NonTerminals = make([]*spec.SourceRange, len(nonTerms))
+	for i, n := range nonTerms {
+		sm.NonTerminals[i] = gram.rulePositions[n]
+	}
+	sm.Productions = make([]*spec.SourceRange, len(gram.productionSet.getAllProductions())+1)
+	for _, p := range gram.productionSet.getAllProductions() {
+		pos, ok := gram.prodPositions[p.id]
+		if !ok {
+			continue
+		}
+		sm.Productions[p.num] = &spec.SourceRange{
+			Row: pos.Row,
+			Col: pos.Col,
+		}
+	}
+	return sm
+}
+
+// genAlternatives generates the descriptions of the alternatives that the productions come from. It is indexed by
+// production numbers.
+func genAlternatives(gram *Grammar) []*spec.Alternative {
+	alts := make([]*spec.Alternative, len(gram.productionSet.getAllProductions())+1)
+	for _, p := range gram.productionSet.getAllProductions() {
+		node, ok := gram.altNodes[p.id]
+		if !ok {
+			continue
+		}
+		lhs, _ := gram.symbolTable.ToText(p.lhs)
+		alt := &spec.Alternative{
+			LHS:   lhs,
+			Index: gram.altIndexes[p.id],
+			Label: gram.altLabels[p.id],
+		}
+		for _, dir := range node.Directives {
+			d := &spec.AlternativeDirective{
+				Name: dir.Name,
+			}
+			for _, param := range dir.Parameters {
+				d.Parameters = append(d.Parameters, parser.FormatParameter(param))
+			}
+			alt.Directives = append(alt.Directives, d)
+		}
+		if pos, ok := gram.prodPositions[p.id]; ok {
+			alt.Position = &spec.SourceRange{
+				Row: pos.Row,
+				Col: pos.Col,
+			}
+		}
+		alts[p.num] = alt
+	}
+	return alts
+}
+
+// stamp records the format version and the content hash in a compiled grammar. The hash covers the format version, so
+// this function must set the version first.
+func stamp(cg *spec.CompiledGrammar) error {
+	cg.FormatVersion = spec.FormatVersion
+	hash, err := cg.ComputeHash()
+	if err != nil {
+		return err
+	}
+	cg.Hash = hash
+	return nil
+}
+
+// genLexModeActions generates a table of operations on the mode stack of the lexer that the parser performs when it
+// shifts a terminal symbol. An entry corresponding to a (state, terminal symbol) pair is the ID of a lex mode to push,
+// -1 to pop a lex mode, or 0 to do nothing. Because the parser doesn't know which alternative it is in until it
+// reduces, all items shifting the same terminal symbol in a state must agree on the operation. When the grammar has
+// no `#push` or `#pop` directives on alternatives, this function returns nil.
+func genLexModeActions(gram *Grammar, automaton *lr0Automaton, modeNames []spec.LexModeName, termCount int) ([]int, error) {
+	if len(gram.lexModeOps) == 0 {
+		return nil, nil
+	}
+
+	modeName2ID := map[spec.LexModeName]int{}
+	for id, name := range modeNames {
+		modeName2ID[name] = id
+	}
+
+	acts := make([]int, len(automaton.states)*termCount)
+	for _, state := range automaton.states {
+		items, err := genLR0Closure(state.kernel, gram.productionSet)
+		if err != nil {
+			return nil, err
+		}
+		decided := map[symbol.Symbol]struct{}{}
+		for _, item := range items {
+			if !item.dottedSymbol.IsTerminal() || item.dottedSymbol == gram.errorSymbol {
+				continue
+			}
+
+			act := 0
+			if op, ok := gram.lexModeOps[item.prod][item.dot]; ok {
+				if op.pop {
+					act = -1
+				} else {
+					act = modeName2ID[op.push]
+				}
+			}
+
+			i := state.num.Int()*termCount + item.dottedSymbol.Num().Int()
+			if _, ok := decided[item.dottedSymbol]; ok {
+				if acts[i] != act {
+					term, _ := gram.symbolTable.ToText(item.dottedSymbol)
+					return nil, fmt.Errorf("lex mode operations conflict when the parser shifts %v in state %v", term, state.num)
+				}
+				continue
+			}
+			acts[i] = act
+			decided[item.dottedSymbol] = struct{}{}
+		}
+	}
+
+	return acts, nil
+}
+
+// genErrorSyncTerminals returns the synchronization sets of the states the parser enters by shifting the error symbol.
+// The set of a state is the union of the sets of the `#recover until` directives applied to the kernel items that have
+// just passed the error symbol.
+func genErrorSyncTerminals(gram *Grammar, automaton *lr0Automaton) ([][]int, error) {
+	if len(gram.recoverSyncTerminals) == 0 {
+		return nil, nil
+	}
+
+	syncs := make([][]int, len(automaton.states))
+	for _, state := range automaton.states {
+		added := map[int]struct{}{}
+		for _, item := range state.items {
+			terms, ok := gram.recoverSyncTerminals[item.prod]
+			if !ok || item.dot == 0 {
+				continue
+			}
+			prod, ok := gram.productionSet.findByID(item.prod)
+			if !ok {
+				return nil, fmt.Errorf("production not found: %v", item.prod)
+			}
+			if prod.rhs[item.dot-1] != gram.errorSymbol {
+				continue
+			}
+			for _, t := range terms {
+				if _, ok := added[t.Num().Int()]; ok {
+					continue
+				}
+				added[t.Num().Int()] = struct{}{}
+				syncs[state.num] = append(syncs[state.num], t.Num().Int())
+			}
+		}
+		sort.Ints(syncs[state.num])
+	}
+
+	return syncs, nil
+}
+
+// genLexerOnlyReport generates a report of a lexer-only grammar. The report contains only terminal symbols because
+// the grammar has no syntactic part.
+func genLexerOnlyReport(gram *Grammar) (*spec.Report, error) {
+	termSyms := gram.symbolTable.TerminalSymbols()
+	terms := make([]*spec.Terminal, len(termSyms)+1)
+	for _, sym := range termSyms {
+		name, ok := gram.symbolTable.ToText(sym)
+		if !ok {
+			return nil, fmt.Errorf("failed to generate terminals: symbol not found: %v", sym)
+		}
+
+		terms[sym.Num()] = &spec.Terminal{
+			Number: sym.Num().Int(),
+			Name:   name,
+		}
+	}
+
+	return &spec.Report{
+		Metadata:  gram.metadata,
+		Terminals: terms,
+	}, nil
+}
+
+func compileErrorsToError(cErrs []*lexical.CompileError) error {
+	var b strings.Builder
+	writeCompileError(&b, cErrs[0])
+	for _, cerr := range cErrs[1:] {
+		fmt.Fprintf(&b, "\n")
+		writeCompileError(&b, cerr)
+	}
+	return fmt.Errorf(b.String())
 }
 
 func writeCompileError(w io.Writer, cErr *lexical.CompileError) {
+	if code := verr.CodeOf(cErr.Cause); code != "" {
+		fmt.Fprintf(w, "error[%v]: ", code)
+	}
 	if cErr.Fragment {
 		fmt.Fprintf(w, "fragment ")
 	}
@@ -1,10 +1,13 @@
 package grammar
 
 import (
+	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
 
 	verr "github.com/nihei9/vartan/error"
+	spec "github.com/nihei9/vartan/spec/grammar"
 	"github.com/nihei9/vartan/spec/grammar/parser"
 )
 
@@ -97,6 +100,53 @@ fragment f
 		},
 	}
 
+	metaTests := []*okTest{
+		{
+			caption: "`#meta` directives attach metadata to a grammar",
+			specSrc: `
+#name test;
+#meta author 'John Doe';
+#meta license 'MIT';
+#meta version '1.2.3';
+
+s
+    : foo
+    ;
+
+foo
+    : 'foo';
+`,
+			validate: func(t *testing.T, g *Grammar) {
+				expected := &spec.Metadata{
+					Author:  "John Doe",
+					License: "MIT",
+					Version: "1.2.3",
+				}
+				if g.metadata == nil || *g.metadata != *expected {
+					t.Fatalf("unexpected metadata: want: %+v, got: %+v", expected, g.metadata)
+				}
+			},
+		},
+		{
+			caption: "a grammar without `#meta` directives has no metadata",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo
+    : 'foo';
+`,
+			validate: func(t *testing.T, g *Grammar) {
+				if g.metadata != nil {
+					t.Fatalf("unexpected metadata: want: nil, got: %+v", g.metadata)
+				}
+			},
+		},
+	}
+
 	modeTests := []*okTest{
 		{
 			caption: "a `#mode` can be the same identifier as a non-terminal symbol",
@@ -928,6 +978,57 @@ bar
 				}
 			},
 		},
+		{
+			caption: "an alternative can refer to a named precedence level",
+			specSrc: `
+#name test;
+
+#prec (
+    #assign :unary
+    #left :multiplicative mul
+    #left :additive add sub
+);
+
+expr
+    : expr add expr
+    | expr sub expr
+    | expr mul expr
+    | sub expr #prec :unary
+    | expr expr #prec :additive
+    | id
+    ;
+
+add
+    : '+';
+sub
+    : '-';
+mul
+    : '*';
+id
+    : "[a-z]+";
+`,
+			validate: func(t *testing.T, g *Grammar) {
+				s, _ := g.symbolTable.ToSymbol("expr")
+				ps, _ := g.productionSet.findByLHS(s)
+				expected := []struct {
+					prec  int
+					assoc assocType
+				}{
+					{prec: 3, assoc: assocTypeLeft},
+					{prec: 3, assoc: assocTypeLeft},
+					{prec: 2, assoc: assocTypeLeft},
+					{prec: 1, assoc: assocTypeNil},
+					{prec: 3, assoc: assocTypeNil},
+				}
+				for i, e := range expected {
+					prec := g.precAndAssoc.productionPredence(ps[i].num)
+					assoc := g.precAndAssoc.productionAssociativity(ps[i].num)
+					if prec != e.prec || assoc != e.assoc {
+						t.Fatalf("unexpected precedence and associativity of alternative #%v: want: (prec: %v, assoc: %v), got: (prec: %v, assoc: %v)", i+1, e.prec, e.assoc, prec, assoc)
+					}
+				}
+			},
+		},
 		{
 			caption: "names of an ordered symbol and a terminal symbol can duplicate",
 			specSrc: `
@@ -1045,8 +1146,176 @@ bar
 		},
 	}
 
+	lexerOnlyTests := []*okTest{
+		{
+			caption: "a grammar having only lexical productions is a lexer-only grammar",
+			specSrc: `
+#name test;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+id
+    : "[a-z]+";
+num
+    : "[0-9]+";
+`,
+			validate: func(t *testing.T, g *Grammar) {
+				if !g.isLexerOnly() {
+					t.Fatalf("the grammar must be lexer-only")
+				}
+				if len(g.lexSpec.Entries) != 3 {
+					t.Fatalf("unexpected lexical entries: want: %v entries, got: %v entries", 3, len(g.lexSpec.Entries))
+				}
+				if len(g.skipSymbols) != 1 {
+					t.Fatalf("unexpected skip symbols: want: %v symbols, got: %v symbols", 1, len(g.skipSymbols))
+				}
+
+				cg, _, err := compile(g, EnableReporting())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !cg.IsLexerOnly() {
+					t.Fatalf("a compiled grammar must be lexer-only")
+				}
+				if cg.Lexical == nil {
+					t.Fatalf("a compiled grammar must have a lexical specification")
+				}
+				if cg.ASTAction != nil {
+					t.Fatalf("a lexer-only grammar must not have AST actions")
+				}
+			},
+		},
+	}
+
+	startTests := []*okTest{
+		{
+			caption: "the `#start` directive declares additional entry points",
+			specSrc: `
+#name test;
+#start expr stmt pair;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id eq expr semi
+    ;
+expr
+    : expr add id
+    | id
+    ;
+// A production reachable only from an entry point is not an unused production.
+pair
+    : id id
+    ;
+
+eq
+    : '=';
+semi
+    : ';';
+add
+    : '+';
+id
+    : "[a-z]+";
+`,
+			validate: func(t *testing.T, g *Grammar) {
+				if len(g.entryPoints) != 3 {
+					t.Fatalf("unexpected entry points: want: %v entries, got: %v entries", 3, len(g.entryPoints))
+				}
+				for i, name := range []string{"expr", "stmt", "pair"} {
+					sym, _ := g.symbolTable.ToSymbol(name)
+					if g.entryPoints[i].symbol != sym {
+						t.Fatalf("unexpected entry point: want: %v, got: %v", sym, g.entryPoints[i].symbol)
+					}
+					augSym, _ := g.symbolTable.ToSymbol(name + "'")
+					if g.entryPoints[i].augStart != augSym {
+						t.Fatalf("unexpected augmented start symbol: want: %v, got: %v", augSym, g.entryPoints[i].augStart)
+					}
+				}
+
+				cg, _, err := compile(g)
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries := cg.Syntactic.EntryPoints
+				if len(entries) != 3 {
+					t.Fatalf("unexpected entry points: want: %v entries, got: %v entries", 3, len(entries))
+				}
+				states := map[int]struct{}{
+					cg.Syntactic.InitialState: {},
+				}
+				for _, e := range entries {
+					if _, ok := states[e.InitialState]; ok {
+						t.Fatalf("each entry point must have its own initial state: %v", e.InitialState)
+					}
+					states[e.InitialState] = struct{}{}
+					lhs := cg.Syntactic.NonTerminals[cg.Syntactic.LHSSymbols[e.StartProduction]]
+					if lhs != e.Symbol+"'" {
+						t.Fatalf("unexpected LHS of a start production: want: %v, got: %v", e.Symbol+"'", lhs)
+					}
+				}
+			},
+		},
+	}
+
+	layoutTests := []*okTest{
+		{
+			caption: "the `#layout` directive declares terminal symbols without lexical productions",
+			specSrc: `
+#name test;
+#layout indent dedent newline;
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : id colon newline indent stmts dedent
+    | id newline
+    ;
+
+ws #skip
+    : "[\u{000A}\u{0020}]+";
+colon
+    : ':';
+id
+    : "[a-z]+";
+`,
+			validate: func(t *testing.T, g *Grammar) {
+				if g.layout == nil {
+					t.Fatal("layout symbols must be declared")
+				}
+				for _, e := range g.lexSpec.Entries {
+					switch e.Kind {
+					case "indent", "dedent", "newline":
+						t.Fatalf("a layout symbol must not have a lexical production: %v", e.Kind)
+					}
+				}
+
+				cg, _, err := compile(g)
+				if err != nil {
+					t.Fatal(err)
+				}
+				lay := cg.Syntactic.Layout
+				if lay == nil {
+					t.Fatal("a compiled grammar must have layout symbols")
+				}
+				for name, term := range map[string]int{"indent": lay.Indent, "dedent": lay.Dedent, "newline": lay.Newline} {
+					if cg.Syntactic.Terminals[term] != name {
+						t.Fatalf("unexpected terminal symbol: want: %v, got: %v", name, cg.Syntactic.Terminals[term])
+					}
+				}
+			},
+		},
+	}
+
 	var tests []*okTest
 	tests = append(tests, nameTests...)
+	tests = append(tests, metaTests...)
+	tests = append(tests, startTests...)
+	tests = append(tests, layoutTests...)
+	tests = append(tests, lexerOnlyTests...)
 	tests = append(tests, modeTests...)
 	tests = append(tests, precTests...)
 
@@ -1071,6 +1340,116 @@ bar
 	}
 }
 
+func TestGrammarBuilderDuplicateAlternativePolicy(t *testing.T) {
+	tests := []struct {
+		caption string
+		policy  DuplicateAlternativePolicy
+		specSrc string
+		errs    []error
+		detail  string
+	}{
+		{
+			caption: "the symbols policy reports identical alternatives with the position of the preceding one",
+			policy:  DuplicateAlternativePolicySymbols,
+			specSrc: `
+#name test;
+
+s
+    : foo #ast foo
+    | foo #ast foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs:   []error{semErrDuplicateProduction},
+			detail: "s → foo (previously defined at 5:7)",
+		},
+		{
+			caption: "the exact policy ignores identical alternatives",
+			policy:  DuplicateAlternativePolicyExact,
+			specSrc: `
+#name test;
+
+s
+    : foo@x bar #ast x
+    | foo@x bar #ast x
+    ;
+
+foo
+    : 'foo';
+bar
+    : 'bar';
+`,
+		},
+		{
+			caption: "the exact policy reports alternatives differing in labels",
+			policy:  DuplicateAlternativePolicyExact,
+			specSrc: `
+#name test;
+
+s
+    : foo@x bar #ast x
+    | foo@y bar #ast y
+    ;
+
+foo
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDuplicateProduction},
+		},
+		{
+			caption: "the exact policy reports alternatives differing in directives",
+			policy:  DuplicateAlternativePolicyExact,
+			specSrc: `
+#name test;
+
+s
+    : foo bar #ast foo
+    | foo bar #ast bar
+    ;
+
+foo
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDuplicateProduction},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.caption, func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(test.specSrc))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := GrammarBuilder{
+				AST: ast,
+			}
+			_, err = b.build(DetectDuplicateAlternativesBy(test.policy))
+			if len(test.errs) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			specErrs, ok := err.(verr.SpecErrors)
+			if !ok {
+				t.Fatalf("unexpected error type: want: %T, got: %T: %v", verr.SpecErrors{}, err, err)
+			}
+			if len(specErrs) != len(test.errs) || specErrs[0].Cause != test.errs[0] {
+				t.Fatalf("unexpected spec errors: want: %+v, got: %+v", test.errs, specErrs)
+			}
+			if test.detail != "" && specErrs[0].Detail != test.detail {
+				t.Fatalf("unexpected detail: want: %v, got: %v", test.detail, specErrs[0].Detail)
+			}
+		})
+	}
+}
+
 func TestGrammarBuilderSpecError(t *testing.T) {
 	type specErrTest struct {
 		caption string
@@ -1222,6 +1601,13 @@ bar
 	}
 
 	prodTests := []*specErrTest{
+		{
+			caption: "a grammar needs at least one production",
+			specSrc: `
+#name test;
+`,
+			errs: []error{semErrNoProduction},
+		},
 		{
 			caption: "a production `b` is unused",
 			specSrc: `
@@ -1595,13 +1981,12 @@ foo
 		},
 	}
 
-	precDirTests := []*specErrTest{
+	metaDirTests := []*specErrTest{
 		{
-			caption: "the `#prec` directive needs a directive group parameter",
+			caption: "the `#meta` directive needs a key and a value",
 			specSrc: `
 #name test;
-
-#prec;
+#meta author;
 
 s
     : foo
@@ -1613,11 +1998,10 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive cannot take an ID parameter",
+			caption: "the `#meta` directive cannot take a pattern as a value",
 			specSrc: `
 #name test;
-
-#prec foo;
+#meta author "John Doe";
 
 s
     : foo
@@ -1629,11 +2013,10 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive cannot take an ordered symbol parameter",
+			caption: "the `#meta` directive cannot take an unknown key",
 			specSrc: `
 #name test;
-
-#prec $x;
+#meta homepage 'https://example.com';
 
 s
     : foo
@@ -1645,11 +2028,11 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive cannot take a pattern parameter",
+			caption: "a key of the `#meta` directive cannot be duplicated",
 			specSrc: `
 #name test;
-
-#prec "foo";
+#meta author 'John Doe';
+#meta author 'Jane Doe';
 
 s
     : foo
@@ -1658,14 +2041,16 @@ s
 foo
     : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrDuplicateMetadata},
 		},
+	}
+
+	startDirTests := []*specErrTest{
 		{
-			caption: "the `#prec` directive cannot take a string parameter",
+			caption: "the `#start` directive needs at least one parameter",
 			specSrc: `
 #name test;
-
-#prec 'foo';
+#start;
 
 s
     : foo
@@ -1677,11 +2062,10 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive takes just one directive group parameter",
+			caption: "the `#start` directive cannot take an undefined symbol",
 			specSrc: `
 #name test;
-
-#prec () ();
+#start bar;
 
 s
     : foo
@@ -1692,17 +2076,11 @@ foo
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
-	}
-
-	leftDirTests := []*specErrTest{
 		{
-			caption: "the `#left` directive needs ID parameters",
+			caption: "the `#start` directive cannot take a terminal symbol",
 			specSrc: `
 #name test;
-
-#prec (
-    #left
-);
+#start foo;
 
 s
     : foo
@@ -1714,34 +2092,28 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#left` directive cannot be applied to an error symbol",
+			caption: "the `#start` directive cannot take a string",
 			specSrc: `
 #name test;
-
-#prec (
-    #left error
-);
+#start 's';
 
 s
-    : foo semi_colon
-    | error semi_colon
+    : foo
     ;
 
 foo
     : 'foo';
-semi_colon
-    : ';';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
+	}
+
+	fallbackDirTests := []*specErrTest{
 		{
-			caption: "the `#left` directive cannot take an undefined symbol",
+			caption: "the `#fallback` directive needs an ID parameter",
 			specSrc: `
 #name test;
-
-#prec (
-    #left x
-);
+#fallback;
 
 s
     : foo
@@ -1753,13 +2125,10 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#left` directive cannot take a non-terminal symbol",
+			caption: "the `#fallback` directive cannot take a string parameter",
 			specSrc: `
 #name test;
-
-#prec (
-    #left s
-);
+#fallback 'unknown';
 
 s
     : foo
@@ -1771,13 +2140,10 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#left` directive cannot take a pattern parameter",
+			caption: "the `#fallback` directive cannot take a terminal symbol defined by a lexical production",
 			specSrc: `
 #name test;
-
-#prec (
-    #left "foo"
-);
+#fallback foo;
 
 s
     : foo
@@ -1786,16 +2152,33 @@ s
 foo
     : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrDuplicateTerminal},
 		},
 		{
-			caption: "the `#left` directive cannot take a string parameter",
+			caption: "the `#fallback` directive cannot be duplicated",
 			specSrc: `
 #name test;
+#fallback unknown;
+#fallback unknown2;
 
-#prec (
-    #left 'foo'
-);
+s
+    : foo
+    | unknown
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDuplicateDir},
+		},
+	}
+
+	layoutDirTests := []*specErrTest{
+		{
+			caption: "the `#layout` directive needs three parameters",
+			specSrc: `
+#name test;
+#layout indent dedent;
 
 s
     : foo
@@ -1807,31 +2190,63 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#left` directive cannot take a directive parameter",
+			caption: "the `#layout` directive cannot take a terminal symbol defined by a lexical production",
 			specSrc: `
 #name test;
+#layout indent dedent foo;
 
-#prec (
-    #left ()
-);
+s
+    : foo indent dedent
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDuplicateTerminal},
+		},
+		{
+			caption: "the `#layout` directive cannot be duplicated",
+			specSrc: `
+#name test;
+#layout indent dedent newline;
+#layout indent2 dedent2 newline2;
+
+s
+    : foo indent dedent newline
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDuplicateDir},
+		},
+		{
+			caption: "a layout symbol and a non-terminal symbol cannot have the same name",
+			specSrc: `
+#name test;
+#layout indent dedent newline;
 
 s
+    : foo indent dedent newline
+    ;
+newline
     : foo
     ;
 
 foo
     : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrDuplicateName},
 		},
+	}
+
+	precDirTests := []*specErrTest{
 		{
-			caption: "the `#left` dirctive cannot be specified multiple times for a terminal symbol",
+			caption: "the `#prec` directive needs a directive group parameter",
 			specSrc: `
 #name test;
 
-#prec (
-    #left foo foo
-);
+#prec;
 
 s
     : foo
@@ -1840,35 +2255,30 @@ s
 foo
     : 'foo';
 `,
-			errs: []error{semErrDuplicateAssoc},
+			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#left` dirctive cannot be specified multiple times for an ordered symbol",
+			caption: "the `#prec` directive cannot take an ID parameter",
 			specSrc: `
 #name test;
 
-#prec (
-    #left $x $x
-);
+#prec foo;
 
 s
-    : foo #prec $x
+    : foo
     ;
 
 foo
     : 'foo';
 `,
-			errs: []error{semErrDuplicateAssoc},
+			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "a terminal symbol cannot have different precedence",
+			caption: "the `#prec` directive cannot take an ordered symbol parameter",
 			specSrc: `
 #name test;
 
-#prec (
-    #left foo
-    #left foo
-);
+#prec $x;
 
 s
     : foo
@@ -1877,36 +2287,30 @@ s
 foo
     : 'foo';
 `,
-			errs: []error{semErrDuplicateAssoc},
+			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "an ordered symbol cannot have different precedence",
+			caption: "the `#prec` directive cannot take a pattern parameter",
 			specSrc: `
 #name test;
 
-#prec (
-    #left $x
-    #left $x
-);
+#prec "foo";
 
 s
-    : foo #prec $x
+    : foo
     ;
 
 foo
     : 'foo';
 `,
-			errs: []error{semErrDuplicateAssoc},
+			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "a terminal symbol cannot have different associativity",
+			caption: "the `#prec` directive cannot take a string parameter",
 			specSrc: `
 #name test;
 
-#prec (
-    #right foo
-    #left foo
-);
+#prec 'foo';
 
 s
     : foo
@@ -1915,37 +2319,34 @@ s
 foo
     : 'foo';
 `,
-			errs: []error{semErrDuplicateAssoc},
+			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "an ordered symbol cannot have different associativity",
+			caption: "the `#prec` directive takes just one directive group parameter",
 			specSrc: `
 #name test;
 
-#prec (
-    #right $x
-    #left $x
-);
+#prec () ();
 
 s
-    : foo #prec $x
+    : foo
     ;
 
 foo
     : 'foo';
 `,
-			errs: []error{semErrDuplicateAssoc},
+			errs: []error{semErrDirInvalidParam},
 		},
 	}
 
-	rightDirTests := []*specErrTest{
+	leftDirTests := []*specErrTest{
 		{
-			caption: "the `#right` directive needs ID parameters",
+			caption: "the `#left` directive needs ID parameters",
 			specSrc: `
 #name test;
 
 #prec (
-    #right
+    #left
 );
 
 s
@@ -1958,12 +2359,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#right` directive cannot be applied to an error symbol",
+			caption: "the `#left` directive cannot be applied to an error symbol",
 			specSrc: `
 #name test;
 
 #prec (
-    #right error
+    #left error
 );
 
 s
@@ -1979,12 +2380,12 @@ semi_colon
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#right` directive cannot take an undefined symbol",
+			caption: "the `#left` directive cannot take an undefined symbol",
 			specSrc: `
 #name test;
 
 #prec (
-    #right x
+    #left x
 );
 
 s
@@ -1997,12 +2398,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#right` directive cannot take a non-terminal symbol",
+			caption: "the `#left` directive cannot take a non-terminal symbol",
 			specSrc: `
 #name test;
 
 #prec (
-    #right s
+    #left s
 );
 
 s
@@ -2015,12 +2416,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#right` directive cannot take a pattern parameter",
+			caption: "the `#left` directive cannot take a pattern parameter",
 			specSrc: `
 #name test;
 
 #prec (
-    #right "foo"
+    #left "foo"
 );
 
 s
@@ -2033,12 +2434,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#right` directive cannot take a string parameter",
+			caption: "the `#left` directive cannot take a string parameter",
 			specSrc: `
 #name test;
 
 #prec (
-    #right 'foo'
+    #left 'foo'
 );
 
 s
@@ -2051,12 +2452,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#right` directive cannot take a directive group parameter",
+			caption: "the `#left` directive cannot take a directive parameter",
 			specSrc: `
 #name test;
 
 #prec (
-    #right ()
+    #left ()
 );
 
 s
@@ -2069,12 +2470,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#right` directive cannot be specified multiple times for a terminal symbol",
+			caption: "the `#left` dirctive cannot be specified multiple times for a terminal symbol",
 			specSrc: `
 #name test;
 
 #prec (
-    #right foo foo
+    #left foo foo
 );
 
 s
@@ -2087,12 +2488,12 @@ foo
 			errs: []error{semErrDuplicateAssoc},
 		},
 		{
-			caption: "the `#right` directive cannot be specified multiple times for an ordered symbol",
+			caption: "the `#left` dirctive cannot be specified multiple times for an ordered symbol",
 			specSrc: `
 #name test;
 
 #prec (
-    #right $x $x
+    #left $x $x
 );
 
 s
@@ -2110,8 +2511,8 @@ foo
 #name test;
 
 #prec (
-    #right foo
-    #right foo
+    #left foo
+    #left foo
 );
 
 s
@@ -2129,8 +2530,8 @@ foo
 #name test;
 
 #prec (
-    #right $x
-    #right $x
+    #left $x
+    #left $x
 );
 
 s
@@ -2148,8 +2549,8 @@ foo
 #name test;
 
 #prec (
-    #left foo
     #right foo
+    #left foo
 );
 
 s
@@ -2167,8 +2568,8 @@ foo
 #name test;
 
 #prec (
-    #left $x
     #right $x
+    #left $x
 );
 
 s
@@ -2182,14 +2583,14 @@ foo
 		},
 	}
 
-	assignDirTests := []*specErrTest{
+	rightDirTests := []*specErrTest{
 		{
-			caption: "the `#assign` directive needs ID parameters",
+			caption: "the `#right` directive needs ID parameters",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign
+    #right
 );
 
 s
@@ -2202,12 +2603,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#assign` directive cannot be applied to an error symbol",
+			caption: "the `#right` directive cannot be applied to an error symbol",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign error
+    #right error
 );
 
 s
@@ -2223,12 +2624,12 @@ semi_colon
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#assign` directive cannot take an undefined symbol",
+			caption: "the `#right` directive cannot take an undefined symbol",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign x
+    #right x
 );
 
 s
@@ -2241,12 +2642,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#assign` directive cannot take a non-terminal symbol",
+			caption: "the `#right` directive cannot take a non-terminal symbol",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign s
+    #right s
 );
 
 s
@@ -2259,12 +2660,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#assign` directive cannot take a pattern parameter",
+			caption: "the `#right` directive cannot take a pattern parameter",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign "foo"
+    #right "foo"
 );
 
 s
@@ -2277,12 +2678,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#assign` directive cannot take a string parameter",
+			caption: "the `#right` directive cannot take a string parameter",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign 'foo'
+    #right 'foo'
 );
 
 s
@@ -2295,12 +2696,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#assign` directive cannot take a directive parameter",
+			caption: "the `#right` directive cannot take a directive group parameter",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign ()
+    #right ()
 );
 
 s
@@ -2313,12 +2714,12 @@ foo
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#assign` dirctive cannot be specified multiple times for a terminal symbol",
+			caption: "the `#right` directive cannot be specified multiple times for a terminal symbol",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign foo foo
+    #right foo foo
 );
 
 s
@@ -2331,12 +2732,12 @@ foo
 			errs: []error{semErrDuplicateAssoc},
 		},
 		{
-			caption: "the `#assign` dirctive cannot be specified multiple times for an ordered symbol",
+			caption: "the `#right` directive cannot be specified multiple times for an ordered symbol",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign $x $x
+    #right $x $x
 );
 
 s
@@ -2354,8 +2755,8 @@ foo
 #name test;
 
 #prec (
-    #assign foo
-    #assign foo
+    #right foo
+    #right foo
 );
 
 s
@@ -2373,8 +2774,8 @@ foo
 #name test;
 
 #prec (
-    #assign $x
-    #assign $x
+    #right $x
+    #right $x
 );
 
 s
@@ -2392,8 +2793,8 @@ foo
 #name test;
 
 #prec (
-    #assign foo
     #left foo
+    #right foo
 );
 
 s
@@ -2411,8 +2812,8 @@ foo
 #name test;
 
 #prec (
-    #assign $x
     #left $x
+    #right $x
 );
 
 s
@@ -2426,259 +2827,309 @@ foo
 		},
 	}
 
-	errorSymTests := []*specErrTest{
+	assignDirTests := []*specErrTest{
 		{
-			caption: "cannot use the error symbol as a non-terminal symbol",
+			caption: "the `#assign` directive needs ID parameters",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign
+);
+
 s
-    : error
-    ;
-error
     : foo
     ;
 
-foo: 'foo';
+foo
+    : 'foo';
 `,
-			errs: []error{
-				semErrErrSymIsReserved,
-				semErrDuplicateName,
-			},
+			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "cannot use the error symbol as a terminal symbol",
+			caption: "the `#assign` directive cannot be applied to an error symbol",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign error
+);
+
 s
-    : error
+    : foo semi_colon
+    | error semi_colon
     ;
 
-error: 'error';
+foo
+    : 'foo';
+semi_colon
+    : ';';
 `,
-			errs: []error{semErrErrSymIsReserved},
+			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "cannot use the error symbol as a terminal symbol, even if given the skip directive",
+			caption: "the `#assign` directive cannot take an undefined symbol",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign x
+);
+
 s
     : foo
     ;
 
 foo
     : 'foo';
-error #skip
-    : 'error';
 `,
-			errs: []error{semErrErrSymIsReserved},
+			errs: []error{semErrDirInvalidParam},
 		},
-	}
-
-	astDirTests := []*specErrTest{
 		{
-			caption: "the `#ast` directive needs ID or label prameters",
+			caption: "the `#assign` directive cannot take a non-terminal symbol",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign s
+);
+
 s
-    : foo #ast
+    : foo
     ;
 
 foo
-    : "foo";
+    : 'foo';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#ast` directive cannot take an ordered symbol parameter",
+			caption: "the `#assign` directive cannot take a pattern parameter",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign $x
+    #assign "foo"
 );
 
 s
-    : foo #ast $x
+    : foo
     ;
 
 foo
-    : "foo";
+    : 'foo';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#ast` directive cannot take a pattern parameter",
+			caption: "the `#assign` directive cannot take a string parameter",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign 'foo'
+);
+
 s
-    : foo #ast "foo"
+    : foo
     ;
 
 foo
-    : "foo";
+    : 'foo';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#ast` directive cannot take a string parameter",
+			caption: "the `#assign` directive cannot take a directive parameter",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign ()
+);
+
 s
-    : foo #ast 'foo'
+    : foo
     ;
 
 foo
-    : "foo";
+    : 'foo';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#ast` directive cannot take a directive group parameter",
+			caption: "the `#assign` dirctive cannot be specified multiple times for a terminal symbol",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign foo foo
+);
+
 s
-    : foo #ast ()
+    : foo
     ;
 
 foo
-    : "foo";
+    : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrDuplicateAssoc},
 		},
 		{
-			caption: "a parameter of the `#ast` directive must be either a symbol or a label in an alternative",
+			caption: "the `#assign` dirctive cannot be specified multiple times for an ordered symbol",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign $x $x
+);
+
 s
-    : foo bar #ast foo x
+    : foo #prec $x
     ;
 
 foo
-    : "foo";
-bar
-    : "bar";
+    : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrDuplicateAssoc},
 		},
 		{
-			caption: "a symbol in a different alternative cannot be a parameter of the `#ast` directive",
+			caption: "a terminal symbol cannot have different precedence",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign foo
+    #assign foo
+);
+
 s
-    : foo #ast bar
-    | bar
+    : foo
     ;
 
 foo
-    : "foo";
-bar
-    : "bar";
+    : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrDuplicateAssoc},
 		},
 		{
-			caption: "a label in a different alternative cannot be a parameter of the `#ast` directive",
+			caption: "an ordered symbol cannot have different precedence",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign $x
+    #assign $x
+);
+
 s
-    : foo #ast b
-    | bar@b
+    : foo #prec $x
     ;
 
 foo
-    : "foo";
-bar
-    : "bar";
+    : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrDuplicateAssoc},
 		},
 		{
-			caption: "a symbol can appear in the `#ast` directive only once",
+			caption: "a terminal symbol cannot have different associativity",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign foo
+    #left foo
+);
+
 s
-    : foo #ast foo foo
+    : foo
     ;
 
 foo
     : 'foo';
 `,
-			errs: []error{semErrDuplicateElem},
+			errs: []error{semErrDuplicateAssoc},
 		},
 		{
-			caption: "a label can appear in the `#ast` directive only once",
+			caption: "an ordered symbol cannot have different associativity",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign $x
+    #left $x
+);
+
 s
-    : foo@x #ast x x
+    : foo #prec $x
     ;
 
 foo
     : 'foo';
 `,
-			errs: []error{semErrDuplicateElem},
+			errs: []error{semErrDuplicateAssoc},
 		},
+	}
+
+	errorSymTests := []*specErrTest{
 		{
-			caption: "a symbol can appear in the `#ast` directive only once, even if the symbol has a label",
+			caption: "cannot use the error symbol as a non-terminal symbol",
 			specSrc: `
 #name test;
 
 s
-    : foo@x #ast foo x
+    : error
+    ;
+error
+    : foo
     ;
 
-foo
-    : 'foo';
+foo: 'foo';
 `,
-			errs: []error{semErrDuplicateElem},
+			errs: []error{
+				semErrErrSymIsReserved,
+				semErrDuplicateName,
+			},
 		},
 		{
-			caption: "symbol `foo` is ambiguous because it appears in an alternative twice",
+			caption: "cannot use the error symbol as a terminal symbol",
 			specSrc: `
 #name test;
 
 s
-    : foo foo #ast foo
+    : error
     ;
 
-foo
-    : 'foo';
+error: 'error';
 `,
-			errs: []error{semErrAmbiguousElem},
+			errs: []error{semErrErrSymIsReserved},
 		},
 		{
-			caption: "symbol `foo` is ambiguous because it appears in an alternative twice, even if one of them has a label",
+			caption: "cannot use the error symbol as a terminal symbol, even if given the skip directive",
 			specSrc: `
 #name test;
 
 s
-    : foo@x foo #ast foo
+    : foo
     ;
 
 foo
     : 'foo';
+error #skip
+    : 'error';
 `,
-			errs: []error{semErrAmbiguousElem},
+			errs: []error{semErrErrSymIsReserved},
 		},
+	}
+
+	astDirTests := []*specErrTest{
 		{
-			caption: "the expansion operator cannot be applied to a terminal symbol",
+			caption: "the `#ast` directive needs ID or label prameters",
 			specSrc: `
 #name test;
 
 s
-    : foo #ast foo...
+    : foo #ast
     ;
 
 foo
@@ -2686,216 +3137,210 @@ foo
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
-	}
-
-	altPrecDirTests := []*specErrTest{
 		{
-			caption: "the `#prec` directive needs an ID parameter or an ordered symbol parameter",
+			caption: "the `#ast` directive cannot take an ordered symbol parameter",
 			specSrc: `
 #name test;
 
+#prec (
+    #assign $x
+);
+
 s
-    : foo #prec
+    : foo #ast $x
     ;
 
 foo
-    : 'foo';
+    : "foo";
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive cannot be applied to an error symbol",
+			caption: "the `#ast` directive cannot take a pattern parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo #prec error
+    : foo #ast "foo"
     ;
 
 foo
-    : 'foo';
+    : "foo";
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive cannot take an undefined symbol",
+			caption: "the `#ast` directive cannot take a string parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo #prec x
+    : foo #ast 'foo'
     ;
 
 foo
-    : 'foo';
+    : "foo";
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive cannot take a non-terminal symbol",
+			caption: "the `#ast` directive cannot take a directive group parameter",
 			specSrc: `
 #name test;
 
 s
-    : a #prec b
-    | b
-    ;
-a
-    : foo
-    ;
-b
-    : bar
+    : foo #ast ()
     ;
 
 foo
-    : 'foo';
-bar
-    : 'bar';
+    : "foo";
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive cannot take an undefined ordered symbol parameter",
+			caption: "a parameter of the `#ast` directive must be either a symbol or a label in an alternative",
 			specSrc: `
 #name test;
 
 s
-    : foo #prec $x
+    : foo bar #ast foo x
     ;
 
 foo
-    : 'foo';
+    : "foo";
+bar
+    : "bar";
 `,
-			errs: []error{semErrUndefinedOrdSym},
+			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive cannot take a pattern parameter",
+			caption: "a symbol in a different alternative cannot be a parameter of the `#ast` directive",
 			specSrc: `
 #name test;
 
 s
-    : foo #prec "foo"
+    : foo #ast bar
+    | bar
     ;
 
 foo
-    : 'foo';
+    : "foo";
+bar
+    : "bar";
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive cannot take a string parameter",
+			caption: "a label in a different alternative cannot be a parameter of the `#ast` directive",
 			specSrc: `
 #name test;
 
 s
-    : foo #prec 'foo'
+    : foo #ast b
+    | bar@b
     ;
 
 foo
-    : 'foo';
+    : "foo";
+bar
+    : "bar";
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#prec` directive cannot take a directive parameter",
+			caption: "a symbol can appear in the `#ast` directive only once",
 			specSrc: `
 #name test;
 
 s
-    : foo #prec ()
+    : foo #ast foo foo
     ;
 
 foo
     : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrDuplicateElem},
 		},
 		{
-			caption: "a symbol the `#prec` directive takes must be given precedence explicitly",
+			caption: "a label can appear in the `#ast` directive only once",
 			specSrc: `
 #name test;
 
 s
-    : foo bar #prec foo
+    : foo@x #ast x x
     ;
 
 foo
     : 'foo';
-bar
-    : 'bar';
 `,
-			errs: []error{semErrUndefinedPrec},
+			errs: []error{semErrDuplicateElem},
 		},
-	}
-
-	recoverDirTests := []*specErrTest{
 		{
-			caption: "the `#recover` directive cannot take an ID parameter",
+			caption: "a symbol can appear in the `#ast` directive only once, even if the symbol has a label",
 			specSrc: `
 #name test;
 
 s
-    : foo #recover foo
+    : foo@x #ast foo x
     ;
 
 foo
     : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrDuplicateElem},
 		},
 		{
-			caption: "the `#recover` directive cannot take an ordered symbol parameter",
+			caption: "symbol `foo` is ambiguous because it appears in an alternative twice",
 			specSrc: `
 #name test;
 
-#prec (
-    #assign $x
-);
-
 s
-    : foo #recover $x
+    : foo foo #ast foo
     ;
 
 foo
     : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrAmbiguousElem},
 		},
 		{
-			caption: "the `#recover` directive cannot take a pattern parameter",
+			caption: "symbol `foo` is ambiguous because it appears in an alternative twice, even if one of them has a label",
 			specSrc: `
 #name test;
 
 s
-    : foo #recover "foo"
+    : foo@x foo #ast foo
     ;
 
 foo
     : 'foo';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrAmbiguousElem},
 		},
 		{
-			caption: "the `#recover` directive cannot take a string parameter",
+			caption: "the expansion operator cannot be applied to a terminal symbol",
 			specSrc: `
 #name test;
 
 s
-    : foo #recover 'foo'
+    : foo #ast foo...
     ;
 
 foo
-    : 'foo';
+    : "foo";
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
+	}
+
+	altPreferDirTests := []*specErrTest{
 		{
-			caption: "the `#recover` directive cannot take a directive group parameter",
+			caption: "the `#prefer` directive needs a parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo #recover ()
+    : foo #prefer
     ;
 
 foo
@@ -2903,177 +3348,167 @@ foo
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
-	}
-
-	fragmentTests := []*specErrTest{
 		{
-			caption: "a production cannot contain a fragment",
+			caption: "the `#prefer` directive takes only shift or reduce",
 			specSrc: `
 #name test;
 
 s
-    : f
+    : foo #prefer error
     ;
 
-fragment f
-    : 'fragment';
+foo
+    : 'foo';
 `,
-			errs: []error{semErrUndefinedSym},
+			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "fragments cannot be duplicated",
+			caption: "the `#prefer` directive takes just one parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo
+    : foo #prefer shift reduce
     ;
 
 foo
-    : "\f{f}";
-fragment f
-    : 'fragment 1';
-fragment f
-    : 'fragment 2';
+    : 'foo';
 `,
-			errs: []error{semErrDuplicateFragment},
+			errs: []error{semErrDirInvalidParam},
 		},
 	}
 
-	modeDirTests := []*specErrTest{
+	altPrecDirTests := []*specErrTest{
 		{
-			caption: "the `#mode` directive needs an ID parameter",
+			caption: "the `#prec` directive needs an ID parameter or an ordered symbol parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : foo #prec
     ;
 
-foo #push mode_1
+foo
     : 'foo';
-bar #mode
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#mode` directive cannot take an ordered symbol parameter",
+			caption: "the `#prec` directive cannot be applied to an error symbol",
 			specSrc: `
 #name test;
 
-#prec (
-    #assign $x
-);
-
 s
-    : foo bar
+    : foo #prec error
     ;
 
 foo
     : 'foo';
-bar #mode $x
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#mode` directive cannot take a pattern parameter",
+			caption: "the `#prec` directive cannot take an undefined symbol",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : foo #prec x
     ;
 
-foo #push mode_1
+foo
     : 'foo';
-bar #mode "mode_1"
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#mode` directive cannot take a string parameter",
+			caption: "the `#prec` directive cannot take a non-terminal symbol",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : a #prec b
+    | b
+    ;
+a
+    : foo
+    ;
+b
+    : bar
     ;
 
-foo #push mode_1
+foo
     : 'foo';
-bar #mode 'mode_1'
+bar
     : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#mode` directive cannot take a directive group parameter",
+			caption: "the `#prec` directive cannot take an undefined ordered symbol parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : foo #prec $x
     ;
 
-foo #push mode_1
+foo
     : 'foo';
-bar #mode ()
-    : 'bar';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrUndefinedOrdSym},
 		},
-	}
-
-	pushDirTests := []*specErrTest{
 		{
-			caption: "the `#push` directive needs an ID parameter",
+			caption: "the `#prec` directive cannot take an undefined level name",
 			specSrc: `
 #name test;
 
+#prec (
+    #left :additive foo
+);
+
 s
-    : foo bar
+    : foo #prec :multiplicative
     ;
 
-foo #push
+foo
     : 'foo';
-bar #mode mode_1
-    : 'bar';
 `,
-			errs: []error{semErrDirInvalidParam},
+			errs: []error{semErrUndefinedPrecLevel},
 		},
 		{
-			caption: "the `#push` directive takes just one ID parameter",
+			caption: "a level name must be the first parameter of a directive in a precedence group",
 			specSrc: `
 #name test;
 
+#prec (
+    #left foo :additive
+);
+
 s
-    : foo bar
+    : foo #prec :additive
     ;
 
-foo #push mode_1 mode_2
+foo
     : 'foo';
-bar #mode mode_1
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#push` directive cannot take an ordered symbol parameter",
+			caption: "a level name cannot be defined multiple times",
 			specSrc: `
 #name test;
 
 #prec (
-    #assign $x
+    #left :additive foo
+    #left :additive bar
 );
 
 s
-    : foo bar
+    : foo bar #prec :additive
     ;
 
-foo #push $x
+foo
     : 'foo';
 bar
     : 'bar';
@@ -3081,76 +3516,82 @@ bar
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#push` directive cannot take a pattern parameter",
+			caption: "the `#prec` directive cannot take a pattern parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : foo #prec "foo"
     ;
 
-foo #push "mode_1"
+foo
     : 'foo';
-bar #mode mode_1
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#push` directive cannot take a string parameter",
+			caption: "the `#prec` directive cannot take a string parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : foo #prec 'foo'
     ;
 
-foo #push 'mode_1'
+foo
     : 'foo';
-bar #mode mode_1
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#push` directive cannot take a directive group parameter",
+			caption: "the `#prec` directive cannot take a directive parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : foo #prec ()
     ;
 
-foo #push ()
+foo
     : 'foo';
-bar #mode mode_1
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
+		{
+			caption: "a symbol the `#prec` directive takes must be given precedence explicitly",
+			specSrc: `
+#name test;
+
+s
+    : foo bar #prec foo
+    ;
+
+foo
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrUndefinedPrec},
+		},
 	}
 
-	popDirTests := []*specErrTest{
+	recoverDirTests := []*specErrTest{
 		{
-			caption: "the `#pop` directive cannot take an ID parameter",
+			caption: "the `#recover` directive cannot take an ID parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo bar baz
+    : foo #recover foo
     ;
 
-foo #push mode_1
+foo
     : 'foo';
-bar #mode mode_1
-    : 'bar';
-baz #pop mode_1
-    : 'baz';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#pop` directive cannot take an ordered symbol parameter",
+			caption: "the `#recover` directive cannot take an ordered symbol parameter",
 			specSrc: `
 #name test;
 
@@ -3159,223 +3600,2800 @@ baz #pop mode_1
 );
 
 s
-    : foo bar baz
+    : foo #recover $x
     ;
 
-foo #push mode_1
+foo
     : 'foo';
-bar #mode mode_1
-    : 'bar';
-baz #pop $x
-    : 'baz';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#pop` directive cannot take a pattern parameter",
+			caption: "the `#recover` directive cannot take a pattern parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo bar baz
+    : foo #recover "foo"
     ;
 
-foo #push mode_1
+foo
     : 'foo';
-bar #mode mode_1
-    : 'bar';
-baz #pop "mode_1"
-    : 'baz';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#pop` directive cannot take a string parameter",
+			caption: "the `#recover` directive cannot take a string parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo bar baz
+    : foo #recover 'foo'
     ;
 
-foo #push mode_1
+foo
     : 'foo';
-bar #mode mode_1
-    : 'bar';
-baz #pop 'mode_1'
-    : 'baz';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#pop` directive cannot take a directive parameter",
+			caption: "the `#recover` directive cannot take a directive group parameter",
 			specSrc: `
 #name test;
 
 s
-    : foo bar baz
+    : foo #recover ()
     ;
 
-foo #push mode_1
+foo
     : 'foo';
-bar #mode mode_1
-    : 'bar';
-baz #pop ()
-    : 'baz';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
-	}
-
-	skipDirTests := []*specErrTest{
 		{
-			caption: "the `#skip` directive cannot take an ID parameter",
+			caption: "the `#recover until` directive needs at least one terminal symbol",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : foo
+    | error #recover until
     ;
 
-foo #skip bar
+foo
     : 'foo';
-bar
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#skip` directive cannot take an ordered symbol parameter",
+			caption: "the `#recover until` directive can be applied only to an alternative containing the error symbol",
 			specSrc: `
 #name test;
 
-#prec (
-    #assign $x
-);
-
 s
-    : foo bar
+    : foo #recover until foo
     ;
 
-foo #skip $x
+foo
     : 'foo';
-bar
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#skip` directive cannot take a pattern parameter",
+			caption: "the `#recover until` directive cannot take a non-terminal symbol",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : foo
+    | error #recover until s
     ;
 
-foo #skip "bar"
+foo
     : 'foo';
-bar
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#skip` directive cannot take a string parameter",
+			caption: "the `#recover until` directive cannot take the error symbol",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : foo
+    | error #recover until error
     ;
 
-foo #skip 'bar'
+foo
     : 'foo';
-bar
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
 		{
-			caption: "the `#skip` directive cannot take a directive group parameter",
+			caption: "the `#recover until` directive cannot take a string literal no terminal symbol is defined by",
 			specSrc: `
 #name test;
 
 s
-    : foo bar
+    : foo
+    | error #recover until ';'
     ;
 
-foo #skip ()
+foo
     : 'foo';
-bar
-    : 'bar';
 `,
 			errs: []error{semErrDirInvalidParam},
 		},
+	}
+
+	fragmentTests := []*specErrTest{
+		{
+			caption: "a production cannot contain a fragment",
+			specSrc: `
+#name test;
+
+s
+    : f
+    ;
+
+fragment f
+    : 'fragment';
+`,
+			errs: []error{semErrUndefinedSym},
+		},
+		{
+			caption: "fragments cannot be duplicated",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo
+    : "\f{f}";
+fragment f
+    : 'fragment 1';
+fragment f
+    : 'fragment 2';
+`,
+			errs: []error{semErrDuplicateFragment},
+		},
+	}
+
+	useDirTests := []*specErrTest{
+		{
+			caption: "the `#use` directive needs a library name",
+			specSrc: `
+#name test;
+
+#use;
+
+s
+    : foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#use` directive cannot take an unknown library",
+			specSrc: `
+#name test;
+
+#use unknown;
+
+s
+    : foo
+    ;
+
+foo
+    : "\f{digit}";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#use` directive cannot take a prefix for a fragment library",
+			specSrc: `
+#name test;
+
+#use std num;
+
+s
+    : foo
+    ;
+
+foo
+    : "\f{digit}";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#use` directive cannot take a non-ID prefix for a snippet library",
+			specSrc: `
+#name test;
+
+#use list 'arg';
+
+s
+    : list
+    ;
+list_item
+    : foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#use` directive cannot take more than one prefix for a snippet library",
+			specSrc: `
+#name test;
+
+#use list arg param;
+
+s
+    : list
+    ;
+list_item
+    : foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	renameDirTests := []*specErrTest{
+		{
+			caption: "the `#rename` directive needs an ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo #rename
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#rename` directive cannot take multiple parameters",
+			specSrc: `
+#name test;
+
+s
+    : foo #rename bar baz
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#rename` directive cannot take a string parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo #rename 'bar'
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	labelDirTests := []*specErrTest{
+		{
+			caption: "the `#label` directive needs an ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo #label
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#label` directive cannot take multiple parameters",
+			specSrc: `
+#name test;
+
+s
+    : foo #label bar baz
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#label` directive cannot take a string parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo #label 'bar'
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "alternatives of the same LHS cannot have the same label",
+			specSrc: `
+#name test;
+
+s
+    : foo #label x
+    | bar #label x
+    ;
+
+foo
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDuplicateLabel},
+		},
+		{
+			caption: "the `#label` directive cannot be used with the `#lift` directive",
+			specSrc: `
+#name test;
+
+s
+    : foo #lift foo #label x
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrInvalidAltDir},
+		},
+	}
+
+	liftDirTests := []*specErrTest{
+		{
+			caption: "the `#lift` directive needs an ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo #lift
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#lift` directive cannot take multiple parameters",
+			specSrc: `
+#name test;
+
+s
+    : foo bar #lift foo bar
+    ;
+
+foo
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#lift` directive cannot take an expansion parameter",
+			specSrc: `
+#name test;
+
+s
+    : a #lift a...
+    ;
+a
+    : foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#lift` directive cannot take a symbol that doesn't appear in an alternative",
+			specSrc: `
+#name test;
+
+s
+    : foo #lift bar
+    ;
+
+foo
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#lift` directive cannot take an ambiguous symbol",
+			specSrc: `
+#name test;
+
+s
+    : foo foo #lift foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrAmbiguousElem},
+		},
+		{
+			caption: "the `#lift` directive cannot be used with the `#ast` directive",
+			specSrc: `
+#name test;
+
+s
+    : foo #lift foo #ast foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrInvalidAltDir},
+		},
+		{
+			caption: "the `#lift` directive cannot be used with the `#rename` directive",
+			specSrc: `
+#name test;
+
+s
+    : foo #lift foo #rename bar
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrInvalidAltDir},
+		},
+	}
+
+	altPushDirTests := []*specErrTest{
+		{
+			caption: "the `#push` directive needs a mode name and a symbol",
+			specSrc: `
+#name test;
+
+s
+    : foo #push m
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` directive cannot take an undefined lex mode",
+			specSrc: `
+#name test;
+
+s
+    : foo #push m foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` directive cannot take a symbol that doesn't appear in an alternative",
+			specSrc: `
+#name test;
+
+s
+    : foo #push m bar
+    | bar
+    ;
+
+foo
+    : 'foo';
+bar
+    : 'bar';
+baz #mode m
+    : 'baz';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` directive cannot take a non-terminal symbol",
+			specSrc: `
+#name test;
+
+s
+    : a #push m a
+    ;
+a
+    : foo
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` directive cannot take an ambiguous symbol",
+			specSrc: `
+#name test;
+
+s
+    : foo foo #push m foo
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+`,
+			errs: []error{semErrAmbiguousElem},
+		},
+	}
+
+	altPopDirTests := []*specErrTest{
+		{
+			caption: "the `#pop` directive needs a symbol",
+			specSrc: `
+#name test;
+
+s
+    : foo #pop
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#pop` directive cannot take an error symbol",
+			specSrc: `
+#name test;
+
+s
+    : foo error #pop error
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` and `#pop` directives cannot be applied to the same symbol",
+			specSrc: `
+#name test;
+
+s
+    : foo #push m foo #pop foo
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+`,
+			errs: []error{semErrInvalidAltDir},
+		},
+	}
+
+	omitPunctuationDirTests := []*specErrTest{
+		{
+			caption: "the `#omit_punctuation` directive cannot take a parameter",
+			specSrc: `
+#name test;
+#omit_punctuation foo;
+
+s
+    : foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	dotExcludesNewlineDirTests := []*specErrTest{
+		{
+			caption: "the `#dot_excludes_newline` directive cannot take a parameter",
+			specSrc: `
+#name test;
+#dot_excludes_newline foo;
+
+s
+    : foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	modeExtendsDirTests := []*specErrTest{
+		{
+			caption: "the `#mode_extends` directive needs a base mode",
+			specSrc: `
+#name test;
+#mode_extends m;
+
+s
+    : foo bar
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#mode_extends` directive needs ID parameters",
+			specSrc: `
+#name test;
+#mode_extends m 'default';
+
+s
+    : foo bar
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "a base mode must be defined",
+			specSrc: `
+#name test;
+#mode_extends m undefined_mode;
+
+s
+    : foo bar
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "a mode cannot extend itself",
+			specSrc: `
+#name test;
+#mode_extends m m;
+
+s
+    : foo bar
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "a mode cannot extend itself indirectly",
+			specSrc: `
+#name test;
+#mode_extends m n;
+#mode_extends n m;
+
+s
+    : foo bar
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "a mode can appear in only one `#mode_extends` directive",
+			specSrc: `
+#name test;
+#mode_extends m default;
+#mode_extends m default;
+
+s
+    : foo bar
+    ;
+
+foo
+    : 'foo';
+bar #mode m
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	modeDirTests := []*specErrTest{
+		{
+			caption: "the `#mode` directive needs an ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #push mode_1
+    : 'foo';
+bar #mode
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#mode` directive cannot take an ordered symbol parameter",
+			specSrc: `
+#name test;
+
+#prec (
+    #assign $x
+);
+
+s
+    : foo bar
+    ;
+
+foo
+    : 'foo';
+bar #mode $x
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#mode` directive cannot take a pattern parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #push mode_1
+    : 'foo';
+bar #mode "mode_1"
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#mode` directive cannot take a string parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #push mode_1
+    : 'foo';
+bar #mode 'mode_1'
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#mode` directive cannot take a directive group parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #push mode_1
+    : 'foo';
+bar #mode ()
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	pushDirTests := []*specErrTest{
+		{
+			caption: "the `#push` directive needs an ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #push
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` directive takes just one ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #push mode_1 mode_2
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` directive cannot take an ordered symbol parameter",
+			specSrc: `
+#name test;
+
+#prec (
+    #assign $x
+);
+
+s
+    : foo bar
+    ;
+
+foo #push $x
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` directive cannot take a pattern parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #push "mode_1"
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` directive cannot take a string parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #push 'mode_1'
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` directive cannot take a directive group parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #push ()
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#push` directive cannot take an undefined lex mode",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #push mode_2
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	popDirTests := []*specErrTest{
+		{
+			caption: "the `#pop` directive cannot take an ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar baz
+    ;
+
+foo #push mode_1
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+baz #pop mode_1
+    : 'baz';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#pop` directive cannot take an ordered symbol parameter",
+			specSrc: `
+#name test;
+
+#prec (
+    #assign $x
+);
+
+s
+    : foo bar baz
+    ;
+
+foo #push mode_1
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+baz #pop $x
+    : 'baz';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#pop` directive cannot take a pattern parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar baz
+    ;
+
+foo #push mode_1
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+baz #pop "mode_1"
+    : 'baz';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#pop` directive cannot take a string parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar baz
+    ;
+
+foo #push mode_1
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+baz #pop 'mode_1'
+    : 'baz';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#pop` directive cannot take a directive parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar baz
+    ;
+
+foo #push mode_1
+    : 'foo';
+bar #mode mode_1
+    : 'bar';
+baz #pop ()
+    : 'baz';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	skipDirTests := []*specErrTest{
+		{
+			caption: "the `#skip` directive cannot take an ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #skip bar
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#skip` directive cannot take an ordered symbol parameter",
+			specSrc: `
+#name test;
+
+#prec (
+    #assign $x
+);
+
+s
+    : foo bar
+    ;
+
+foo #skip $x
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#skip` directive cannot take a pattern parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #skip "bar"
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#skip` directive cannot take a string parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #skip 'bar'
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#skip` directive cannot take a directive group parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #skip ()
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "a terminal symbol used in productions cannot have the skip directive",
+			specSrc: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo #skip
+    : 'foo';
+bar
+    : 'bar';
+`,
+			errs: []error{semErrTermCannotBeSkipped},
+		},
+	}
+
+	keepDirTests := []*specErrTest{
+		{
+			caption: "the `#keep` directive needs a parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo #keep
+    ;
+
+foo
+    : 'foo';
+nl #skip
+    : "\u{000A}";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#keep` directive cannot take a terminal symbol without the skip directive",
+			specSrc: `
+#name test;
+
+s
+    : foo #keep foo
+    ;
+
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#keep` directive cannot take a symbol the alternative doesn't contain",
+			specSrc: `
+#name test;
+
+s
+    : foo nl #keep nl
+    | foo #keep nl
+    ;
+
+foo
+    : 'foo';
+nl #skip
+    : "\u{000A}";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "an alternative containing a kept terminal symbol needs the `#keep` directive",
+			specSrc: `
+#name test;
+
+s
+    : foo nl #keep nl
+    | nl foo
+    ;
+
+foo
+    : 'foo';
+nl #skip
+    : "\u{000A}";
+`,
+			errs: []error{semErrTermCannotBeSkipped},
+		},
+	}
+
+	caseConfigurableDirTests := []*specErrTest{
+		{
+			caption: "the `#case_configurable` directive cannot take an ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #case_configurable bar
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#case_configurable` directive cannot take a string parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #case_configurable 'bar'
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	exprDirTests := []*specErrTest{
+		{
+			caption: "the `#expr` directive needs a directive group",
+			specSrc: `
+#name test;
+
+#expr expr;
+
+id
+    : "[a-z]+";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#expr` directive cannot take an unknown directive",
+			specSrc: `
+#name test;
+
+#expr expr (
+    #operand id
+    #assign id
+);
+
+id
+    : "[a-z]+";
+`,
+			errs: []error{semErrDirInvalidName},
+		},
+		{
+			caption: "the `#group` directive needs two symbols",
+			specSrc: `
+#name test;
+
+#expr expr (
+    #operand id
+    #group l_paren
+);
+
+id
+    : "[a-z]+";
+l_paren
+    : '(';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#expr` directive needs operands when no production defines the symbol",
+			specSrc: `
+#name test;
+
+#expr expr (
+    #left add
+);
+
+add
+    : '+';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#expr` directive cannot be applied to a symbol twice",
+			specSrc: `
+#name test;
+
+#expr expr (
+    #operand id
+);
+#expr expr (
+    #left add
+);
+
+id
+    : "[a-z]+";
+add
+    : '+';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "an operator of the `#expr` directive cannot have two precedences",
+			specSrc: `
+#name test;
+
+#expr expr (
+    #operand id
+    #postfix add
+    #left add
+);
+
+id
+    : "[a-z]+";
+add
+    : '+';
+`,
+			errs: []error{semErrDuplicateAssoc},
+		},
+	}
+
+	classDirTests := []*specErrTest{
+		{
+			caption: "the `#class` directive needs an ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #class
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#class` directive cannot take multiple parameters",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #class keyword operator
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#class` directive cannot take an unknown class",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #class identifier
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	priorityDirTests := []*specErrTest{
+		{
+			caption: "the `#priority` directive needs a number parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #priority
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#priority` directive cannot take an ID parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #priority high
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#priority` directive cannot take multiple parameters",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #priority 1 2
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	transformationDirTests := []*specErrTest{
+		{
+			caption: "the `#trim` directive cannot take a parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #trim foo
+    : "[a-z]+";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#dedent` directive cannot take a parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #dedent foo
+    : "[a-z]+";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#unescape` directive needs a string literal as a parameter",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #unescape foo
+    : "[a-z]+";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#unescape` directive cannot take multiple parameters",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #unescape '"' '|'
+    : "[a-z]+";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#normalize` directive needs a normalization form",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #normalize
+    : "[a-z]+";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#normalize` directive cannot take an unknown normalization form",
+			specSrc: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo #normalize nfx
+    : "[a-z]+";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	keywordsDirTests := []*specErrTest{
+		{
+			caption: "the `#keywords` directive needs ID parameters",
+			specSrc: `
+#name test;
+
+s
+    : id
+    ;
+
+id #keywords
+    : "[a-z]+";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "the `#keywords` directive cannot take a string parameter",
+			specSrc: `
+#name test;
+
+s
+    : id
+    ;
+
+id #keywords 'if'
+    : "[a-z]+";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "a keyword must be a terminal symbol",
+			specSrc: `
+#name test;
+
+s
+    : id
+    | kw_if
+    ;
+
+kw_if
+    : foo
+    ;
+
+id #keywords kw_if
+    : "[a-z]+";
+foo
+    : 'foo';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "a keyword must be defined by a string literal",
+			specSrc: `
+#name test;
+
+s
+    : id
+    | kw_if
+    ;
+
+id #keywords kw_if
+    : "[a-z]+";
+kw_if
+    : "if";
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "a keyword cannot belong to multiple terminal symbols",
+			specSrc: `
+#name test;
+
+s
+    : id
+    | name
+    | kw_if
+    ;
+
+id #keywords kw_if
+    : "[a-z]+";
+name #keywords kw_if
+    : "[a-z]+";
+kw_if
+    : 'if';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+		{
+			caption: "a keyword cannot have the `#mode` directive",
+			specSrc: `
+#name test;
+
+s
+    : id
+    | kw_if
+    ;
+
+id #keywords kw_if
+    : "[a-z]+";
+kw_if #mode default
+    : 'if';
+`,
+			errs: []error{semErrDirInvalidParam},
+		},
+	}
+
+	var tests []*specErrTest
+	tests = append(tests, spellingInconsistenciesTests...)
+	tests = append(tests, prodTests...)
+	tests = append(tests, nameDirTests...)
+	tests = append(tests, metaDirTests...)
+	tests = append(tests, startDirTests...)
+	tests = append(tests, layoutDirTests...)
+	tests = append(tests, fallbackDirTests...)
+	tests = append(tests, precDirTests...)
+	tests = append(tests, leftDirTests...)
+	tests = append(tests, rightDirTests...)
+	tests = append(tests, assignDirTests...)
+	tests = append(tests, errorSymTests...)
+	tests = append(tests, astDirTests...)
+	tests = append(tests, altPrecDirTests...)
+	tests = append(tests, altPreferDirTests...)
+	tests = append(tests, recoverDirTests...)
+	tests = append(tests, renameDirTests...)
+	tests = append(tests, labelDirTests...)
+	tests = append(tests, liftDirTests...)
+	tests = append(tests, altPushDirTests...)
+	tests = append(tests, altPopDirTests...)
+	tests = append(tests, omitPunctuationDirTests...)
+	tests = append(tests, dotExcludesNewlineDirTests...)
+	tests = append(tests, fragmentTests...)
+	tests = append(tests, useDirTests...)
+	tests = append(tests, modeDirTests...)
+	tests = append(tests, modeExtendsDirTests...)
+	tests = append(tests, pushDirTests...)
+	tests = append(tests, popDirTests...)
+	tests = append(tests, skipDirTests...)
+	tests = append(tests, keepDirTests...)
+	tests = append(tests, caseConfigurableDirTests...)
+	tests = append(tests, classDirTests...)
+	tests = append(tests, priorityDirTests...)
+	tests = append(tests, exprDirTests...)
+	tests = append(tests, keywordsDirTests...)
+	tests = append(tests, transformationDirTests...)
+	for _, test := range tests {
+		t.Run(test.caption, func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(test.specSrc))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := GrammarBuilder{
+				AST: ast,
+			}
+			_, err = b.build()
+			if err == nil {
+				t.Fatal("an expected error didn't occur")
+			}
+			specErrs, ok := err.(verr.SpecErrors)
+			if !ok {
+				t.Fatalf("unexpected error type: want: %T, got: %T: %v", verr.SpecErrors{}, err, err)
+			}
+			if len(specErrs) != len(test.errs) {
+				t.Fatalf("unexpected spec error count: want: %+v, got: %+v", test.errs, specErrs)
+			}
+			for _, expected := range test.errs {
+				for _, actual := range specErrs {
+					if actual.Cause == expected {
+						return
+					}
+				}
+			}
+			t.Fatalf("an expected spec error didn't occur: want: %v, got: %+v", test.errs, specErrs)
+		})
+	}
+}
+
+func TestGrammarBuilderStampsFormatVersionAndHash(t *testing.T) {
+	build := func(t *testing.T, src string) *spec.CompiledGrammar {
+		t.Helper()
+		ast, err := parser.Parse(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := GrammarBuilder{
+			AST: ast,
+		}
+		cg, _, err := b.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cg
+	}
+
+	src1 := `
+#name test;
+
+s
+    : foo
+    ;
+
+foo
+    : 'foo';
+`
+	src2 := strings.Replace(src1, "'foo'", "'bar'", 1)
+	lexerOnlySrc := `
+#name test;
+
+foo
+    : 'foo';
+`
+
+	cg1 := build(t, src1)
+	for _, cg := range []*spec.CompiledGrammar{cg1, build(t, lexerOnlySrc)} {
+		if cg.FormatVersion != spec.FormatVersion {
+			t.Fatalf("unexpected format version: want: %v, got: %v", spec.FormatVersion, cg.FormatVersion)
+		}
+		hash, err := cg.ComputeHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cg.Hash == "" || cg.Hash != hash {
+			t.Fatalf("the hash must match the content; want: %v, got: %v", hash, cg.Hash)
+		}
+	}
+	if h := build(t, src1).Hash; h != cg1.Hash {
+		t.Fatalf("the same grammar must have the same hash: %v, %v", cg1.Hash, h)
+	}
+	if h := build(t, src2).Hash; h == cg1.Hash {
+		t.Fatalf("different grammars must have different hashes: %v", h)
+	}
+	cg1.BuildInfo = &spec.BuildInfo{
+		Source: src1,
+	}
+	if h, err := cg1.ComputeHash(); err != nil || h != cg1.Hash {
+		t.Fatalf("build info must not affect the hash: %v, %v", cg1.Hash, h)
+	}
+}
+
+func TestGrammarBuilderReportsPrecedencesOfConflicts(t *testing.T) {
+	src := `
+#name test;
+
+#prec (
+    #left mul
+    #right add
+);
+
+expr
+    : expr add expr
+    | expr mul expr
+    | expr sub expr
+    | id
+    ;
+
+id
+    : 'id';
+add
+    : '+';
+mul
+    : '*';
+sub
+    : '-';
+`
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := GrammarBuilder{
+		AST: ast,
+	}
+	_, report, err := b.Build(EnableReporting())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type conflictKey struct {
+		prod string
+		sym  string
+	}
+	termName := func(sym int) string {
+		return report.Terminals[sym].Name
+	}
+	prodName := func(prod int) string {
+		return termName(report.Productions[prod].RHS[1])
+	}
+	conflicts := map[conflictKey]*spec.SRConflict{}
+	for _, s := range report.States {
+		for _, c := range s.SRConflict {
+			conflicts[conflictKey{prod: prodName(c.Production), sym: termName(c.Symbol)}] = c
+		}
+	}
+
+	tests := []struct {
+		key        conflictKey
+		resolvedBy conflictResolutionMethod
+		symPrec    int
+		symAssoc   string
+		prodPrec   int
+		prodAssoc  string
+	}{
+		{key: conflictKey{prod: "add", sym: "mul"}, resolvedBy: ResolvedByPrec, symPrec: 1, symAssoc: "l", prodPrec: 2, prodAssoc: "r"},
+		{key: conflictKey{prod: "add", sym: "add"}, resolvedBy: ResolvedByAssoc, symPrec: 2, symAssoc: "r", prodPrec: 2, prodAssoc: "r"},
+		{key: conflictKey{prod: "mul", sym: "mul"}, resolvedBy: ResolvedByAssoc, symPrec: 1, symAssoc: "l", prodPrec: 1, prodAssoc: "l"},
+		{key: conflictKey{prod: "sub", sym: "add"}, resolvedBy: ResolvedByShift, symPrec: 2, symAssoc: "r"},
+		{key: conflictKey{prod: "add", sym: "sub"}, resolvedBy: ResolvedByShift, prodPrec: 2, prodAssoc: "r"},
+	}
+	for _, tt := range tests {
+		c, ok := conflicts[tt.key]
+		if !ok {
+			t.Fatalf("a conflict was not found: %+v", tt.key)
+		}
+		if c.ResolvedBy != tt.resolvedBy.Int() {
+			t.Errorf("%+v: unexpected resolution method: want: %v, got: %v", tt.key, tt.resolvedBy, c.ResolvedBy)
+		}
+		if c.SymbolPrecedence != tt.symPrec || c.SymbolAssociativity != tt.symAssoc {
+			t.Errorf("%+v: unexpected symbol precedence: want: %v %v, got: %v %v", tt.key, tt.symPrec, tt.symAssoc, c.SymbolPrecedence, c.SymbolAssociativity)
+		}
+		if c.ProductionPrecedence != tt.prodPrec || c.ProductionAssociativity != tt.prodAssoc {
+			t.Errorf("%+v: unexpected production precedence: want: %v %v, got: %v %v", tt.key, tt.prodPrec, tt.prodAssoc, c.ProductionPrecedence, c.ProductionAssociativity)
+		}
+	}
+}
+
+func TestGrammarBuilderResolvesConflictsByNonAssocAndPreference(t *testing.T) {
+	src := `
+#name test;
+
+#prec (
+    #nonassoc lt
+);
+
+stmt
+    : if expr then stmt #prefer shift
+    | if expr then stmt else stmt
+    | expr
+    ;
+expr
+    : expr lt expr
+    | id
+    ;
+
+if
+    : 'if';
+then
+    : 'then';
+else
+    : 'else';
+lt
+    : '<';
+id
+    : "[a-z]+";
+`
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := GrammarBuilder{
+		AST: ast,
+	}
+	_, report, err := b.Build(EnableReporting())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nonAssoc, preferred *spec.SRConflict
+	for _, s := range report.States {
+		for _, c := range s.SRConflict {
+			switch report.Terminals[c.Symbol].Name {
+			case "lt":
+				nonAssoc = c
+			case "else":
+				preferred = c
+			}
+		}
+	}
+	if nonAssoc == nil || preferred == nil {
+		t.Fatalf("conflicts were not found; lt: %v, else: %v", nonAssoc, preferred)
+	}
+	if nonAssoc.ResolvedBy != ResolvedByAssoc.Int() || nonAssoc.ProductionAssociativity != "n" {
+		t.Errorf("unexpected resolution of the conflict on lt: %+v", nonAssoc)
+	}
+	if nonAssoc.AdoptedState != nil || nonAssoc.AdoptedProduction != nil {
+		t.Errorf("the conflict on lt must be resolved to an error action: %+v", nonAssoc)
+	}
+	if preferred.ResolvedBy != ResolvedByPreference.Int() || preferred.AdoptedState == nil {
+		t.Errorf("the conflict on else must be resolved to the shift action by the #prefer directive: %+v", preferred)
+	}
+}
+
+func TestGrammarBuilderGeneratesSourceMap(t *testing.T) {
+	src := `#name test;
+
+s
+    : foo s
+    |
+    ;
+
+foo #mode default
+    : 'foo';
+`
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := GrammarBuilder{
+		AST: ast,
+	}
+	cg, report, err := b.Build(EnableReporting(), SourceName("test.vartan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := cg.SourceMap
+	if sm == nil {
+		t.Fatal("a compiled grammar must have a source map")
+	}
+	if report.SourceMap != sm {
+		t.Fatal("a report must have the same source map as the compiled grammar")
+	}
+	if sm.File != "test.vartan" {
+		t.Fatalf("unexpected file name: %v", sm.File)
+	}
+
+	testRange := func(t *testing.T, caption string, r, expected *spec.SourceRange) {
+		t.Helper()
+		if r == nil {
+			t.Fatalf("%v: a range was not found", caption)
+		}
+		if *r != *expected {
+			t.Fatalf("%v: unexpected range; want: %+v, got: %+v", caption, expected, r)
+		}
+	}
+	for num, lhs := range cg.Syntactic.LHSSymbols {
+		if cg.Syntactic.NonTerminals[lhs] != "s" {
+			continue
+		}
+		if cg.Syntactic.AlternativeSymbolCounts[num] == 2 {
+			testRange(t, "s → foo s", sm.Productions[num], &spec.SourceRange{Row: 4, Col: 7})
+		} else {
+			// An empty alternative has the position of its LHS.
+			testRange(t, "s → ε", sm.Productions[num], &spec.SourceRange{Row: 3, Col: 1})
+		}
+	}
+	if sm.Productions[cg.Syntactic.StartProduction] != nil {
+		t.Fatalf("the augmented start production must not have a range: %+v", sm.Productions[cg.Syntactic.StartProduction])
+	}
+	for num, name := range cg.Syntactic.NonTerminals {
+		if name == "s" {
+			testRange(t, "s", sm.NonTerminals[num], &spec.SourceRange{Row: 3, Col: 1, EndRow: 6, EndCol: 5})
+		}
+	}
+	for id, name := range cg.Lexical.KindNames {
+		if name == "foo" {
+			testRange(t, "foo", sm.Kinds[id], &spec.SourceRange{Row: 8, Col: 1, EndRow: 9, EndCol: 12})
+		}
+	}
+}
+
+func TestGrammarBuilderDescribesAlternatives(t *testing.T) {
+	src := `
+#name test;
+
+#prec (
+    #left add
+);
+
+s
+    : s add t #label add #ast s t...
+    | t #prec add
+    ;
+t
+    : id #recover
+    ;
+
+add
+    : '+';
+id
+    : "[a-z]+";
+`
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*spec.Alternative{
+		nil,
+		nil,
+		{
+			LHS:   "s",
+			Index: 1,
+			Label: "add",
+			Directives: []*spec.AlternativeDirective{
+				{Name: "label", Parameters: []string{"add"}},
+				{Name: "ast", Parameters: []string{"s", "t..."}},
+			},
+			Position: &spec.SourceRange{Row: 9, Col: 7},
+		},
+		{
+			LHS:   "s",
+			Index: 2,
+			Directives: []*spec.AlternativeDirective{
+				{Name: "prec", Parameters: []string{"add"}},
+			},
+			Position: &spec.SourceRange{Row: 10, Col: 7},
+		},
+		{
+			LHS:   "t",
+			Index: 1,
+			Directives: []*spec.AlternativeDirective{
+				{Name: "recover"},
+			},
+			Position: &spec.SourceRange{Row: 13, Col: 7},
+		},
+	}
+	if !reflect.DeepEqual(cg.Alternatives, expected) {
+		want, _ := json.Marshal(expected)
+		got, _ := json.Marshal(cg.Alternatives)
+		t.Fatalf("unexpected alternatives; want: %s, got: %s", want, got)
+	}
+
+	// The descriptions of alternatives don't affect the hash because they don't change how the grammar parses an input.
+	hash := cg.Hash
+	cg.Alternatives = nil
+	h, err := cg.ComputeHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h != hash {
+		t.Fatal("the hash must not depend on the descriptions of alternatives")
+	}
+}
+
+func TestGrammarBuilderLabelsAlternatives(t *testing.T) {
+	src := `
+#name test;
+
+s
+    : s add t #label add
+    | t
+    ;
+t
+    : t add id #label add
+    | id #label id
+    ;
+
+add
+    : '+';
+id
+    : "[a-z]+";
+`
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := GrammarBuilder{
+		AST: ast,
+	}
+	cg, report, err := b.Build(EnableReporting())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Alternatives of different LHSs can have the same label.
+	expected := []string{"", "", "add", "", "add", "id"}
+	if !reflect.DeepEqual(cg.Syntactic.AlternativeLabels, expected) {
+		t.Fatalf("unexpected labels; want: %#v, got: %#v", expected, cg.Syntactic.AlternativeLabels)
+	}
+	for _, prod := range report.Productions[1:] {
+		if prod.Label != expected[prod.Number] {
+			t.Errorf("production %v: unexpected label; want: %#v, got: %#v", prod.Number, expected[prod.Number], prod.Label)
+		}
+	}
+}
+
+func TestCompiledGrammarSymbolTable(t *testing.T) {
+	build := func(t *testing.T, src string) *spec.CompiledGrammar {
+		t.Helper()
+		ast, err := parser.Parse(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := GrammarBuilder{
+			AST: ast,
+		}
+		cg, _, err := b.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cg
+	}
+
+	t.Run("a grammar having syntactic productions", func(t *testing.T) {
+		cg := build(t, `
+#name test;
+
+s
+    : s add id
+    | id
+    ;
+
+ws #skip
+    : "[\u{0020}]+";
+add #class operator
+    : '+';
+id
+    : "[a-z]+";
+`)
+		tab := cg.SymbolTable()
+		var names []string
+		for _, sym := range tab.Terminals() {
+			names = append(names, sym.Name)
+		}
+		if strings.Join(names, " ") != "<eof> error ws add id" {
+			t.Fatalf("unexpected terminals: %v", names)
+		}
+		names = nil
+		for _, sym := range tab.NonTerminals() {
+			names = append(names, sym.Name)
+		}
+		if strings.Join(names, " ") != "s' s" {
+			t.Fatalf("unexpected non-terminals: %v", names)
+		}
+
+		add, ok := tab.TerminalByName("add")
+		if !ok {
+			t.Fatal("add was not found")
+		}
+		if !add.Terminal || add.Skip || add.Literal != "+" || add.Class != "operator" || add.Kind == spec.LexKindIDNil {
+			t.Fatalf("unexpected terminal: %+v", add)
+		}
+		if sym, ok := tab.Terminal(add.Num); !ok || sym != add {
+			t.Fatalf("a terminal must be found by its number: %+v", sym)
+		}
+		if sym, ok := tab.TerminalByKind(add.Kind); !ok || sym != add {
+			t.Fatalf("a terminal must be found by its kind: %+v", sym)
+		}
+		if cg.Syntactic.Terminals[add.Num] != "add" {
+			t.Fatalf("the number of a terminal must be the one of the parser: %v", add.Num)
+		}
+		if cg.Lexical.KindNames[add.Kind] != "add" {
+			t.Fatalf("the kind of a terminal must be the one of the lexer: %v", add.Kind)
+		}
+
+		ws, ok := tab.TerminalByName("ws")
+		if !ok || !ws.Skip {
+			t.Fatalf("unexpected terminal: %+v", ws)
+		}
+		eof, ok := tab.TerminalByName("<eof>")
+		if !ok || eof.Kind != spec.LexKindIDNil {
+			t.Fatalf("unexpected terminal: %+v", eof)
+		}
+
+		s, ok := tab.NonTerminalByName("s")
+		if !ok || s.Terminal || cg.Syntactic.NonTerminals[s.Num] != "s" {
+			t.Fatalf("unexpected non-terminal: %+v", s)
+		}
+		if sym, ok := tab.NonTerminal(s.Num); !ok || sym != s {
+			t.Fatalf("a non-terminal must be found by its number: %+v", sym)
+		}
+		if _, ok := tab.TerminalByName("s"); ok {
+			t.Fatal("a non-terminal must not be found as a terminal")
+		}
+		if _, ok := tab.Terminal(0); ok {
+			t.Fatal("the nil symbol must not be found")
+		}
+	})
+
+	t.Run("a lexer-only grammar", func(t *testing.T) {
+		cg := build(t, `
+#name test;
+
+ws #skip
+    : "[\u{0020}]+";
+id
+    : "[a-z]+";
+`)
+		tab := cg.SymbolTable()
+		if len(tab.NonTerminals()) != 0 {
+			t.Fatalf("a lexer-only grammar must not have non-terminals: %v", tab.NonTerminals())
+		}
+		id, ok := tab.TerminalByName("id")
+		if !ok || id.Num != id.Kind.Int() || cg.Lexical.KindNames[id.Kind] != "id" {
+			t.Fatalf("unexpected terminal: %+v", id)
+		}
+	})
+}
+
+func TestGrammarBuilderDefinesImplicitTerminals(t *testing.T) {
+	src := `
+#name test;
+
+#prec (
+    #left '*'
+    #left '+'
+);
+
+s
+    : s '+' s
+    | s '*' s
+    | '(' s ')'
+    | 'If' s
+    | s '-' s
+    | plus
+    ;
+plus
+    : id
+    ;
+
+id
+    : "[a-z]+";
+minus
+    : '-';
+`
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := GrammarBuilder{
+		AST: ast,
+	}
+	cg, report, err := b.Build(EnableReporting())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTerms := map[string]struct {
+		literal string
+		prec    int
+	}{
+		// `plus` is already the name of a non-terminal symbol.
+		"plus_2":  {literal: "+", prec: 2},
+		"star":    {literal: "*", prec: 1},
+		"l_paren": {literal: "("},
+		"r_paren": {literal: ")"},
+		"if":      {literal: "If"},
+		// A lexical production already defines '-'.
+		"minus": {},
+	}
+	for name, expected := range expectedTerms {
+		var term *spec.Terminal
+		for _, t := range report.Terminals[1:] {
+			if t.Name == name {
+				term = t
+				break
+			}
+		}
+		if term == nil {
+			t.Fatalf("terminal %v was not found", name)
+		}
+		if term.Literal != expected.literal {
+			t.Errorf("%v: unexpected literal; want: %#v, got: %#v", name, expected.literal, term.Literal)
+		}
+		if term.Precedence != expected.prec {
+			t.Errorf("%v: unexpected precedence; want: %v, got: %v", name, expected.prec, term.Precedence)
+		}
+	}
+	// The compiled grammar holds the literals of both implicit and explicit terminals.
+	for i, name := range cg.Syntactic.Terminals {
+		var expected string
+		switch name {
+		case "minus":
+			expected = "-"
+		case "id", "<eof>", "error", "":
+		default:
+			expected = expectedTerms[name].literal
+		}
+		if cg.Syntactic.TerminalLiterals[i] != expected {
+			t.Errorf("%v: unexpected literal in the compiled grammar; want: %#v, got: %#v", name, expected, cg.Syntactic.TerminalLiterals[i])
+		}
+	}
+	// The terminals identical literals define are merged, and implicit ones precede explicit ones.
+	if len(cg.Lexical.KindNames) != len(expectedTerms)+2 {
+		t.Fatalf("unexpected kinds: %v", cg.Lexical.KindNames)
+	}
+	if cg.Lexical.KindNames[len(cg.Lexical.KindNames)-2] != "id" {
+		t.Fatalf("the implicit terminals must precede the explicit ones: %v", cg.Lexical.KindNames)
+	}
+
+	// The builder must not modify the AST.
+	if elem := ast.Productions[0].RHS[0].Elements[1]; elem.ID != "" || elem.Pattern != "+" {
+		t.Fatalf("the AST was modified: %+v", elem)
+	}
+	if len(ast.LexProductions) != 2 {
+		t.Fatalf("the AST was modified: %v lexical productions", len(ast.LexProductions))
+	}
+}
+
+func TestGrammarBuilderClassifiesKinds(t *testing.T) {
+	src := `
+#name test;
+
+s
+    : kw_if id add num
+    | id
+    ;
+
+comment #class comment #skip
+    : "//[^\u{000A}]*";
+id #keywords kw_if kw_else
+    : "[a-z]+";
+kw_if
+    : 'if';
+kw_else #class operator
+    : 'else';
+add #class operator
+    : '+';
+num #class literal
+    : "[0-9]+";
+`
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build(TreatUnusedSymbolsAs(SeverityIgnore))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"comment": "comment",
+		"id":      "",
+		// A keyword is a keyword for syntax highlighting unless it has its own class.
+		"kw_if":   "keyword",
+		"kw_else": "operator",
+		"add":     "operator",
+		"num":     "literal",
+	}
+	if len(cg.Lexical.KindClasses) != len(cg.Lexical.KindNames) {
+		t.Fatalf("unexpected classes: %v", cg.Lexical.KindClasses)
+	}
+	for id, name := range cg.Lexical.KindNames[1:] {
+		class := cg.Lexical.KindClasses[id+1]
+		if class != expected[name.String()] {
+			t.Errorf("%v: unexpected class; want: %#v, got: %#v", name, expected[name.String()], class)
+		}
+	}
+}
+
+func TestGrammarBuilderCompilesTransformations(t *testing.T) {
+	src := `
+#name test;
+
+s
+    : str doc id
+    ;
+
+str #unescape '"'
+    : "\"([^\"\\]|\\.)*\"";
+doc #dedent #trim
+    : "<<[^>]*>>";
+id #normalize nfc
+    : "[a-z]+";
+`
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]*spec.LexTransformation{
+		"str": {
+			{Name: "unescape", Delimiter: `"`},
+		},
+		"doc": {
+			{Name: "dedent"},
+			{Name: "trim"},
+		},
+		"id": {
+			{Name: "normalize", Form: "nfc"},
+		},
+	}
+	if len(cg.Lexical.KindTransformations) != len(cg.Lexical.KindNames) {
+		t.Fatalf("unexpected transformations: %v", cg.Lexical.KindTransformations)
+	}
+	for id, name := range cg.Lexical.KindNames[1:] {
+		ts := cg.Lexical.KindTransformations[id+1]
+		if !reflect.DeepEqual(ts, expected[name.String()]) {
+			t.Errorf("%v: unexpected transformations; want: %#v, got: %#v", name, expected[name.String()], ts)
+		}
+	}
+}
+
+func TestGrammarBuilderSpecErrorLocations(t *testing.T) {
+	src := `
+#name test;
+
+s
+    : foo undefined_sym
+    | foo
+    | foo
+    ;
+
+foo
+    : 'foo';
+`
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := GrammarBuilder{
+		AST: ast,
+	}
+	_, _, err = b.Build()
+	specErrs, ok := err.(verr.SpecErrors)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var undefErr, dupErr *verr.SpecError
+	for _, e := range specErrs {
+		switch e.Cause {
+		case semErrUndefinedSym:
+			undefErr = e
+		case semErrDuplicateProduction:
+			dupErr = e
+		}
+	}
+	if undefErr == nil || dupErr == nil {
+		t.Fatalf("expected errors didn't occur: %v", specErrs)
+	}
+	if undefErr.Row != 5 || undefErr.Col != 11 || undefErr.EndRow != 5 || undefErr.EndCol != 23 {
+		t.Fatalf("unexpected range of an undefined symbol: %v:%v-%v:%v", undefErr.Row, undefErr.Col, undefErr.EndRow, undefErr.EndCol)
+	}
+	if code := undefErr.ErrorCode(); code != "V2010" {
+		t.Fatalf("unexpected error code: %v", code)
+	}
+	if len(dupErr.Related) != 1 || dupErr.Related[0].Row != 6 || dupErr.Related[0].Col != 7 {
+		t.Fatalf("a duplicate production must refer to the previous definition: %+v", dupErr.Related)
+	}
+}
+
+func TestGrammarBuilderUnusedSymbolSeverity(t *testing.T) {
+	src := `
+#name test;
+
+s
+    : a
+    ;
+t
+    : b
+    ;
+
+a
+    : 'a';
+b
+    : 'b';
+c
+    : 'c';
+`
+	tests := []struct {
+		severity  Severity
+		errCount  int
+		warnCount int
+	}{
 		{
-			caption: "a terminal symbol used in productions cannot have the skip directive",
-			specSrc: `
+			severity: SeverityError,
+			errCount: 3,
+		},
+		{
+			severity:  SeverityWarn,
+			warnCount: 3,
+		},
+		{
+			severity: SeverityIgnore,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.severity), func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := GrammarBuilder{
+				AST: ast,
+			}
+			cg, _, err := b.Build(TreatUnusedSymbolsAs(tt.severity))
+			if tt.errCount > 0 {
+				specErrs, ok := err.(verr.SpecErrors)
+				if !ok || len(specErrs) != tt.errCount {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cg == nil {
+				t.Fatal("a compiled grammar must be returned")
+			}
+			warns := b.Warnings()
+			if len(warns) != tt.warnCount {
+				t.Fatalf("unexpected warnings: %v", warns)
+			}
+			for i, w := range warns {
+				if w.Severity != verr.SeverityWarning {
+					t.Fatalf("unexpected severity: %v", w.Severity)
+				}
+				if i > 0 && w.Row < warns[i-1].Row {
+					t.Fatalf("warnings must be sorted by their positions: %v", warns)
+				}
+			}
+		})
+	}
+}
+
+func TestGrammarBuilderUnusedAnnotationSeverity(t *testing.T) {
+	src := `
 #name test;
+#omit_punctuation;
+
+#prec (
+    #left $high
+    #left $low
+    #left $unused
+);
 
 s
-    : foo bar
+    : foo@x bar@y #ast x
+    | foo@z baz #lift z #prec $high
+    | s@s2 s@s3 #ast s2 s3 #prec $low
+    | bar@v foo@w #ast foo
+    | baz@t ';'@u
     ;
 
-foo #skip
+foo
     : 'foo';
 bar
     : 'bar';
-`,
-			errs: []error{semErrTermCannotBeSkipped},
+baz
+    : 'baz';
+`
+	tests := []struct {
+		severity Severity
+		errCount int
+		warns    []string
+	}{
+		{
+			severity: SeverityError,
+			errCount: 4,
+		},
+		{
+			// The AST nodes contain the elements labeled `w` and `t`, so astgen uses the labels. The `#ast` directive
+			// omits the element labeled `v`, and the `#omit_punctuation` directive omits the one labeled `u`.
+			severity: SeverityWarn,
+			warns:    []string{"$unused", "y", "v", "u"},
+		},
+		{
+			severity: SeverityIgnore,
 		},
 	}
-
-	var tests []*specErrTest
-	tests = append(tests, spellingInconsistenciesTests...)
-	tests = append(tests, prodTests...)
-	tests = append(tests, nameDirTests...)
-	tests = append(tests, precDirTests...)
-	tests = append(tests, leftDirTests...)
-	tests = append(tests, rightDirTests...)
-	tests = append(tests, assignDirTests...)
-	tests = append(tests, errorSymTests...)
-	tests = append(tests, astDirTests...)
-	tests = append(tests, altPrecDirTests...)
-	tests = append(tests, recoverDirTests...)
-	tests = append(tests, fragmentTests...)
-	tests = append(tests, modeDirTests...)
-	tests = append(tests, pushDirTests...)
-	tests = append(tests, popDirTests...)
-	tests = append(tests, skipDirTests...)
-	for _, test := range tests {
-		t.Run(test.caption, func(t *testing.T) {
-			ast, err := parser.Parse(strings.NewReader(test.specSrc))
+	for _, tt := range tests {
+		t.Run(string(tt.severity), func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := GrammarBuilder{
+				AST: ast,
+			}
+			_, _, err = b.Build(TreatUnusedAnnotationsAs(tt.severity))
+			if tt.errCount > 0 {
+				specErrs, ok := err.(verr.SpecErrors)
+				if !ok || len(specErrs) != tt.errCount {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
 			if err != nil {
 				t.Fatal(err)
 			}
+			warns := b.Warnings()
+			if len(warns) != len(tt.warns) {
+				t.Fatalf("unexpected warnings: %v", warns)
+			}
+			for i, w := range warns {
+				if w.Detail != tt.warns[i] {
+					t.Fatalf("unexpected warning; want: %v, got: %v", tt.warns[i], w)
+				}
+			}
+		})
+	}
+}
+
+func TestGrammarBuilderUnreachableTerminalSeverity(t *testing.T) {
+	src := `
+#name test;
+
+#mode_extends str_ext str;
+
+s
+    : foo bar baz quote #push str quote
+    | id
+    ;
 
+foo #push str_ext
+    : 'foo';
+bar #mode str_ext
+    : 'bar';
+baz #mode str
+    : 'baz';
+quote #mode default str
+    : '"';
+id #mode strng
+    : "[a-z]+";
+`
+	tests := []struct {
+		severity Severity
+		errCount int
+		warns    []string
+	}{
+		{
+			severity: SeverityError,
+			errCount: 1,
+		},
+		{
+			severity: SeverityWarn,
+			warns:    []string{"id; no #push directive enters lex mode strng"},
+		},
+		{
+			severity: SeverityIgnore,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.severity), func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(src))
+			if err != nil {
+				t.Fatal(err)
+			}
 			b := GrammarBuilder{
 				AST: ast,
 			}
-			_, err = b.build()
-			if err == nil {
-				t.Fatal("an expected error didn't occur")
+			_, _, err = b.Build(TreatUnreachableTerminalsAs(tt.severity))
+			if tt.errCount > 0 {
+				specErrs, ok := err.(verr.SpecErrors)
+				if !ok || len(specErrs) != tt.errCount || specErrs[0].Cause != semErrUnreachableTerminal {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
 			}
-			specErrs, ok := err.(verr.SpecErrors)
-			if !ok {
-				t.Fatalf("unexpected error type: want: %T, got: %T: %v", verr.SpecErrors{}, err, err)
+			if err != nil {
+				t.Fatal(err)
 			}
-			if len(specErrs) != len(test.errs) {
-				t.Fatalf("unexpected spec error count: want: %+v, got: %+v", test.errs, specErrs)
+			warns := b.Warnings()
+			if len(warns) != len(tt.warns) {
+				t.Fatalf("unexpected warnings: %v", warns)
 			}
-			for _, expected := range test.errs {
-				for _, actual := range specErrs {
-					if actual.Cause == expected {
-						return
-					}
+			for i, w := range warns {
+				if w.Detail != tt.warns[i] {
+					t.Fatalf("unexpected warning; want: %v, got: %v", tt.warns[i], w)
+				}
+			}
+		})
+	}
+}
+
+func TestGrammarBuilderValidate(t *testing.T) {
+	tests := []struct {
+		caption string
+		src     string
+		specErr error
+		err     bool
+	}{
+		{
+			caption: "a valid grammar passes",
+			src: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo
+    : "[a-z]+";
+`,
+		},
+		{
+			caption: "a semantic error is reported",
+			src: `
+#name test;
+
+s
+    : foo bar
+    ;
+
+foo
+    : "[a-z]+";
+`,
+			specErr: semErrUndefinedSym,
+		},
+		{
+			caption: "an error in a pattern is reported",
+			src: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo
+    : "[z-a]+";
+`,
+			err: true,
+		},
+		{
+			caption: "an undefined fragment is reported",
+			src: `
+#name test;
+
+s
+    : foo
+    ;
+
+foo
+    : "\f{bar}";
+`,
+			err: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := GrammarBuilder{
+				AST: ast,
+			}
+			err = b.Validate()
+			switch {
+			case tt.specErr != nil:
+				specErrs, ok := err.(verr.SpecErrors)
+				if !ok || specErrs[0].Cause != tt.specErr {
+					t.Fatalf("unexpected error: want: %v, got: %v", tt.specErr, err)
+				}
+			case tt.err:
+				if err == nil {
+					t.Fatal("an error must occur")
+				}
+			default:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
 				}
 			}
-			t.Fatalf("an expected spec error didn't occur: want: %v, got: %+v", test.errs, specErrs)
 		})
 	}
 }
@@ -0,0 +1,262 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+// punctuationNames maps punctuation and symbol characters to the words that names of implicit terminals consist of.
+var punctuationNames = map[rune]string{
+	'!':  "bang",
+	'"':  "dquote",
+	'#':  "hash",
+	'$':  "dollar",
+	'%':  "percent",
+	'&':  "amp",
+	'\'': "quote",
+	'(':  "l_paren",
+	')':  "r_paren",
+	'*':  "star",
+	'+':  "plus",
+	',':  "comma",
+	'-':  "minus",
+	'.':  "dot",
+	'/':  "slash",
+	':':  "colon",
+	';':  "semicolon",
+	'<':  "lt",
+	'=':  "equals",
+	'>':  "gt",
+	'?':  "question",
+	'@':  "at",
+	'[':  "l_bracket",
+	'\\': "backslash",
+	']':  "r_bracket",
+	'^':  "caret",
+	'_':  "underscore",
+	'`':  "backquote",
+	'{':  "l_brace",
+	'|':  "pipe",
+	'}':  "r_brace",
+	'~':  "tilde",
+	' ':  "space",
+}
+
+// defineImplicitTerminals returns an AST in which the string literals in syntactic rules refer to terminal symbols.
+// When a lexical production defines a terminal symbol by the same string literal, a literal refers to the symbol.
+// Otherwise, the function defines an anonymous terminal symbol with a generated name, and identical literals share it.
+// The implicit lexical productions precede the others so that a literal wins over a pattern matching the same lexeme,
+// such as a pattern of identifiers. `#prec` directive can also refer to the implicit terminal symbols by the literals.
+//
+// The function doesn't modify `root`. It also returns a map from the names of the implicit terminal symbols to their
+// literals.
+func defineImplicitTerminals(root *parser.RootNode) (*parser.RootNode, map[string]string) {
+	lit2Name := map[string]string{}
+	for _, prod := range root.LexProductions {
+		lit, ok := prod.Literal()
+		if !ok {
+			continue
+		}
+		if _, ok := lit2Name[lit]; !ok {
+			lit2Name[lit] = prod.LHS
+		}
+	}
+
+	var used map[string]struct{}
+	rewritten := false
+	var implicitProds []*parser.ProductionNode
+	name2Lit := map[string]string{}
+	prods := make([]*parser.ProductionNode, len(root.Productions))
+	for i, prod := range root.Productions {
+		prods[i] = prod
+		for j, alt := range prod.RHS {
+			for k, elem := range alt.Elements {
+				if !elem.Literally {
+					continue
+				}
+
+				name, ok := lit2Name[elem.Pattern]
+				if !ok {
+					if used == nil {
+						used = collectNames(root)
+					}
+					name = genImplicitTerminalName(elem.Pattern, used)
+					used[name] = struct{}{}
+					lit2Name[elem.Pattern] = name
+					name2Lit[name] = elem.Pattern
+					implicitProds = append(implicitProds, &parser.ProductionNode{
+						LHS: name,
+						RHS: []*parser.AlternativeNode{
+							{
+								Elements: []*parser.ElementNode{
+									{
+										Pattern:   elem.Pattern,
+										Literally: true,
+										Pos:       elem.Pos,
+									},
+								},
+								Pos: elem.Pos,
+							},
+						},
+						Pos: elem.Pos,
+						End: elem.Pos,
+					})
+				}
+
+				rewritten = true
+
+				// Copy the nodes on the path to the element so as not to modify the original AST.
+				if prods[i] == prod {
+					p := *prod
+					p.RHS = append([]*parser.AlternativeNode{}, prod.RHS...)
+					prods[i] = &p
+				}
+				if prods[i].RHS[j] == alt {
+					a := *alt
+					a.Elements = append([]*parser.ElementNode{}, alt.Elements...)
+					prods[i].RHS[j] = &a
+				}
+				prods[i].RHS[j].Elements[k] = &parser.ElementNode{
+					ID:    name,
+					Label: elem.Label,
+					Pos:   elem.Pos,
+				}
+			}
+		}
+	}
+	// Precedence groups can also refer to implicit terminal symbols by string literals. A terminal symbol a lexical
+	// production defines must be referred to by its name.
+	dirs := root.Directives
+	for i, dir := range root.Directives {
+		if dir.Name != "prec" || len(dir.Parameters) != 1 {
+			continue
+		}
+		var group []*parser.DirectiveNode
+		for j, assocDir := range dir.Parameters[0].Group {
+			var params []*parser.ParameterNode
+			for k, param := range assocDir.Parameters {
+				name := lit2Name[param.String]
+				if _, ok := name2Lit[name]; !ok {
+					continue
+				}
+				if params == nil {
+					params = append([]*parser.ParameterNode{}, assocDir.Parameters...)
+				}
+				params[k] = &parser.ParameterNode{
+					ID:  name,
+					Pos: param.Pos,
+				}
+			}
+			if params == nil {
+				continue
+			}
+			if group == nil {
+				group = append([]*parser.DirectiveNode{}, dir.Parameters[0].Group...)
+			}
+			d := *assocDir
+			d.Parameters = params
+			group[j] = &d
+		}
+		if group == nil {
+			continue
+		}
+		rewritten = true
+		dirs = append([]*parser.DirectiveNode{}, root.Directives...)
+		param := *dir.Parameters[0]
+		param.Group = group
+		d := *dir
+		d.Parameters = []*parser.ParameterNode{&param}
+		dirs[i] = &d
+	}
+
+	if !rewritten {
+		return root, nil
+	}
+
+	r := *root
+	r.Directives = dirs
+	r.Productions = prods
+	r.LexProductions = append(implicitProds, root.LexProductions...)
+	return &r, name2Lit
+}
+
+// genImplicitTerminalName generates a name of a terminal symbol defined by a string literal. The name consists of
+// the letters and digits of the literal and the names of the other characters, and it doesn't conflict with `used`.
+func genImplicitTerminalName(lit string, used map[string]struct{}) string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range lit {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			word.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			word.WriteRune(r - 'A' + 'a')
+		default:
+			flush()
+			if w, ok := punctuationNames[r]; ok {
+				words = append(words, w)
+			} else {
+				words = append(words, fmt.Sprintf("u%x", r))
+			}
+		}
+	}
+	flush()
+
+	base := strings.Join(words, "_")
+	if base == "" || base[0] >= '0' && base[0] <= '9' {
+		base = "lit_" + base
+	}
+	name := base
+	for n := 2; ; n++ {
+		if _, ok := used[name]; !ok {
+			return name
+		}
+		name = fmt.Sprintf("%v_%v", base, n)
+	}
+}
+
+// collectNames returns all names that a grammar defines or refers to.
+func collectNames(root *parser.RootNode) map[string]struct{} {
+	names := map[string]struct{}{
+		reservedSymbolNameError: {},
+	}
+	var collectDirs func(dirs []*parser.DirectiveNode)
+	collectDirs = func(dirs []*parser.DirectiveNode) {
+		for _, dir := range dirs {
+			for _, param := range dir.Parameters {
+				if param.ID != "" {
+					names[param.ID] = struct{}{}
+				}
+				collectDirs(param.Group)
+			}
+		}
+	}
+
+	collectDirs(root.Directives)
+	for _, prods := range [][]*parser.ProductionNode{root.Productions, root.LexProductions} {
+		for _, prod := range prods {
+			names[prod.LHS] = struct{}{}
+			collectDirs(prod.Directives)
+			for _, alt := range prod.RHS {
+				collectDirs(alt.Directives)
+				for _, elem := range alt.Elements {
+					if elem.ID != "" {
+						names[elem.ID] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	for _, frag := range root.Fragments {
+		names[frag.LHS] = struct{}{}
+	}
+	return names
+}
@@ -26,6 +26,12 @@ func genLALR1Automaton(lr0 *lr0Automaton, prods *productionSet, first *firstSet)
 	iniState.items[0].lookAhead.symbols = map[symbol.Symbol]struct{}{
 		symbol.SymbolEOF: {},
 	}
+	// Also the initial items of the additional entry points.
+	for _, kID := range lr0.entryStates {
+		lr0.states[kID].items[0].lookAhead.symbols = map[symbol.Symbol]struct{}{
+			symbol.SymbolEOF: {},
+		}
+	}
 
 	var props []*propagation
 	for _, state := range lr0.states {
@@ -226,7 +232,9 @@ func genLALR1Closure(srcItem *lrItem, prods *productionSet, first *firstSet) ([]
 					nextUncheckedItems = append(nextUncheckedItems, newItem)
 				}
 
-				if isFstNullable {
+				// Only the source item and the items inheriting its look-ahead symbols propagate them. An item having
+				// its own look-ahead symbol passes just the symbol to the items it derives.
+				if isFstNullable && (item == srcItem || item.lookAhead.propagation) {
 					newItem, err := newLR0Item(prod, 0)
 					if err != nil {
 						return nil, err
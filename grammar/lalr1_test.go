@@ -185,3 +185,41 @@ id: "[A-Za-z0-9_]+";
 
 	testLRAutomaton(t, expectedStates, automaton.lr0Automaton)
 }
+
+func TestGenLALR1AutomatonPropagatesOnlyInheritedLookAheads(t *testing.T) {
+	// In the state after `l_paren`, the item `args → ・elems` has `r_paren` as its own look-ahead symbol. Although
+	// the rest of `args → elems` is empty, the items derived from it must not take over the look-ahead symbols of
+	// the kernel item `call → id l_paren ・args r_paren`, or `elems → list ・` conflicts with
+	// `elems → list ・comma` on `comma`, which can follow a call in the enclosing list.
+	src := `
+#name test;
+
+s: list;
+call: id l_paren args r_paren;
+args: elems;
+elems: list comma | list;
+list: list comma item | item;
+item: id | call;
+id: "[a-z]+";
+l_paren: '(';
+r_paren: ')';
+comma: ',';
+`
+
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := GrammarBuilder{
+		AST: ast,
+	}
+	_, report, err := b.Build(EnableReporting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range report.States {
+		if len(s.SRConflict) > 0 || len(s.RRConflict) > 0 {
+			t.Fatalf("state %v has conflicts: shift/reduce: %v, reduce/reduce: %v", s.Number, len(s.SRConflict), len(s.RRConflict))
+		}
+	}
+}
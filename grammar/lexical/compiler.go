@@ -3,6 +3,8 @@ package lexical
 import (
 	"bytes"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/nihei9/vartan/compressor"
 	"github.com/nihei9/vartan/grammar/lexical/dfa"
@@ -18,9 +20,16 @@ type CompileError struct {
 }
 
 func Compile(lexspec *LexSpec, compLv int) (*spec.LexicalSpec, error, []*CompileError) {
+	lexSpec, _, err, cerrs := CompileAndReport(lexspec, compLv)
+	return lexSpec, err, cerrs
+}
+
+// CompileAndReport compiles a lexical specification like Compile, and it also reports how the DFA of each lex mode
+// recognizes kinds.
+func CompileAndReport(lexspec *LexSpec, compLv int) (*spec.LexicalSpec, *spec.LexicalReport, error, []*CompileError) {
 	err := lexspec.Validate()
 	if err != nil {
-		return nil, fmt.Errorf("invalid lexical specification:\n%w", err), nil
+		return nil, nil, fmt.Errorf("invalid lexical specification:\n%w", err), nil
 	}
 
 	modeEntries, modeNames, modeName2ID, fragmetns := groupEntriesByLexMode(lexspec.Entries)
@@ -28,26 +37,29 @@ func Compile(lexspec *LexSpec, compLv int) (*spec.LexicalSpec, error, []*Compile
 	modeSpecs := []*spec.CompiledLexModeSpec{
 		nil,
 	}
+	report := &spec.LexicalReport{}
 	for i, es := range modeEntries[1:] {
 		modeName := modeNames[i+1]
-		modeSpec, err, cerrs := compile(es, modeName2ID, fragmetns, compLv)
+		modeSpec, modeReport, err, cerrs := compile(modeName, es, modeName2ID, fragmetns, lexspec.DotExcludesNewline, lexspec.LazyDFA, compLv)
 		if err != nil {
-			return nil, fmt.Errorf("failed to compile in %v mode: %w", modeName, err), cerrs
+			return nil, nil, fmt.Errorf("failed to compile in %v mode: %w", modeName, err), cerrs
 		}
 		modeSpecs = append(modeSpecs, modeSpec)
+		report.Modes = append(report.Modes, modeReport)
 	}
 
+	// Kind IDs follow the order of definitions regardless of priorities, which affect only the IDs of kinds in each mode.
 	var kindNames []spec.LexKindName
 	var name2ID map[spec.LexKindName]spec.LexKindID
 	{
 		name2ID = map[spec.LexKindName]spec.LexKindID{}
 		id := spec.LexKindIDMin
-		for _, modeSpec := range modeSpecs[1:] {
-			for _, name := range modeSpec.KindNames[1:] {
-				if _, ok := name2ID[name]; ok {
+		for _, es := range modeEntries[1:] {
+			for _, e := range es {
+				if _, ok := name2ID[e.Kind]; ok {
 					continue
 				}
-				name2ID[name] = id
+				name2ID[e.Kind] = id
 				id++
 			}
 		}
@@ -73,14 +85,89 @@ func Compile(lexspec *LexSpec, compLv int) (*spec.LexicalSpec, error, []*Compile
 		}
 	}
 
+	var kindClasses []string
+	for _, e := range lexspec.Entries {
+		id, ok := name2ID[e.Kind]
+		if e.Fragment || e.Class == "" || !ok {
+			continue
+		}
+		if kindClasses == nil {
+			kindClasses = make([]string, len(kindNames))
+		}
+		kindClasses[id] = e.Class
+	}
+
+	var kindTrans [][]*spec.LexTransformation
+	for _, e := range lexspec.Entries {
+		id, ok := name2ID[e.Kind]
+		if e.Fragment || len(e.Transformations) == 0 || !ok {
+			continue
+		}
+		if kindTrans == nil {
+			kindTrans = make([][]*spec.LexTransformation, len(kindNames))
+		}
+		kindTrans[id] = e.Transformations
+	}
+
+	kindCaptures, err := compileCaptures(lexspec, fragmetns, name2ID, len(kindNames))
+	if err != nil {
+		return nil, nil, err, nil
+	}
+
 	return &spec.LexicalSpec{
-		InitialModeID:    spec.LexModeIDDefault,
-		ModeNames:        modeNames,
-		KindNames:        kindNames,
-		KindIDs:          kindIDs,
-		CompressionLevel: compLv,
-		Specs:            modeSpecs,
-	}, nil, nil
+		InitialModeID:       spec.LexModeIDDefault,
+		ModeNames:           modeNames,
+		KindNames:           kindNames,
+		KindIDs:             kindIDs,
+		CompressionLevel:    compLv,
+		Specs:               modeSpecs,
+		KindClasses:         kindClasses,
+		KindTransformations: kindTrans,
+		KindCaptures:        kindCaptures,
+	}, report, nil, nil
+}
+
+// compileCaptures generates the automata that find the sub-spans of lexemes the capture groups of patterns match.
+// The patterns must be valid because the lex modes containing them have already been compiled.
+func compileCaptures(lexspec *LexSpec, fragments map[spec.LexKindName]*LexEntry, name2ID map[spec.LexKindName]spec.LexKindID, kindCount int) ([]*spec.LexCaptures, error) {
+	var fragmentCPTrees map[spec.LexKindName]psr.CPTree
+	var kindCaptures []*spec.LexCaptures
+	for _, e := range lexspec.Entries {
+		id, ok := name2ID[e.Kind]
+		if e.Fragment || e.Keyword || !ok || !strings.Contains(e.Pattern, "(?<") {
+			continue
+		}
+		if fragmentCPTrees == nil {
+			var err error
+			fragmentCPTrees, err, _ = parseFragments(fragments, lexspec.DotExcludesNewline)
+			if err != nil {
+				return nil, err
+			}
+		}
+		t, err, cerr := parsePattern(e.Kind, []byte(e.Pattern), fragmentCPTrees, lexspec.DotExcludesNewline)
+		if err != nil {
+			return nil, err
+		}
+		if cerr != nil {
+			return nil, fmt.Errorf("%v: %v", e.Kind, cerr.Cause)
+		}
+		names := psr.CaptureNames(t)
+		if len(names) == 0 {
+			continue
+		}
+		nfa, err := dfa.GenCaptureNFA(t, e.CaseConfigurable)
+		if err != nil {
+			return nil, err
+		}
+		if kindCaptures == nil {
+			kindCaptures = make([]*spec.LexCaptures, kindCount)
+		}
+		kindCaptures[id] = &spec.LexCaptures{
+			Names: names,
+			NFA:   nfa,
+		}
+	}
+	return kindCaptures, nil
 }
 
 func groupEntriesByLexMode(entries []*LexEntry) ([][]*LexEntry, []spec.LexModeName, map[spec.LexModeName]spec.LexModeID, map[spec.LexKindName]*LexEntry) {
@@ -125,14 +212,23 @@ func groupEntriesByLexMode(entries []*LexEntry) ([][]*LexEntry, []spec.LexModeNa
 }
 
 func compile(
+	modeName spec.LexModeName,
 	entries []*LexEntry,
 	modeName2ID map[spec.LexModeName]spec.LexModeID,
 	fragments map[spec.LexKindName]*LexEntry,
+	dotExcludesNewline bool,
+	lazyDFA bool,
 	compLv int,
-) (*spec.CompiledLexModeSpec, error, []*CompileError) {
+) (*spec.CompiledLexModeSpec, *spec.LexModeReport, error, []*CompileError) {
+	// A DFA accepts the kind having the smallest ID among kinds matching a lexeme, so kinds having higher priorities
+	// precede the others.
+	entries, priorities := sortEntriesByPriority(entries)
+
 	var kindNames []spec.LexKindName
 	kindIDToName := map[spec.LexModeKindID]spec.LexKindName{}
+	kindNameToID := map[spec.LexKindName]spec.LexModeKindID{}
 	var patterns map[spec.LexModeKindID][]byte
+	foldCaseIDs := map[spec.LexModeKindID]struct{}{}
 	{
 		kindNames = append(kindNames, spec.LexKindNameNil)
 		patterns = map[spec.LexModeKindID][]byte{}
@@ -141,7 +237,58 @@ func compile(
 
 			kindNames = append(kindNames, e.Kind)
 			kindIDToName[kindID] = e.Kind
+			kindNameToID[e.Kind] = kindID
+			// Since a lexer recognizes keywords by looking up keyword tables, keywords don't appear in the DFA.
+			if e.Keyword {
+				continue
+			}
 			patterns[kindID] = []byte(e.Pattern)
+			if e.CaseConfigurable {
+				foldCaseIDs[kindID] = struct{}{}
+			}
+		}
+	}
+
+	var keywords []map[string]spec.LexModeKindID
+	var ciKeywords []map[string]spec.LexModeKindID
+	{
+		var cerrs []*CompileError
+		for i, e := range entries {
+			if len(e.Keywords) == 0 {
+				continue
+			}
+			if keywords == nil {
+				keywords = make([]map[string]spec.LexModeKindID, len(entries)+1)
+			}
+			tab := map[string]spec.LexModeKindID{}
+			var ciTab map[string]spec.LexModeKindID
+			for _, kw := range e.Keywords {
+				kwID, ok := kindNameToID[kw]
+				if !ok {
+					cerrs = append(cerrs, &CompileError{
+						Kind:  kw,
+						Cause: fmt.Errorf("a keyword must belong to the same modes as its owner `%v`", e.Kind),
+					})
+					continue
+				}
+				tab[entries[kwID-1].Pattern] = kwID
+				if entries[kwID-1].CaseConfigurable {
+					if ciTab == nil {
+						ciTab = map[string]spec.LexModeKindID{}
+					}
+					ciTab[spec.FoldKeyword(entries[kwID-1].Pattern)] = kwID
+				}
+			}
+			keywords[i+1] = tab
+			if ciTab != nil {
+				if ciKeywords == nil {
+					ciKeywords = make([]map[string]spec.LexModeKindID, len(entries)+1)
+				}
+				ciKeywords[i+1] = ciTab
+			}
+		}
+		if len(cerrs) > 0 {
+			return nil, nil, fmt.Errorf("compile error"), cerrs
 		}
 	}
 
@@ -164,170 +311,270 @@ func compile(
 		pop = append(pop, popV)
 	}
 
-	fragmentPatterns := map[spec.LexKindName][]byte{}
-	for k, e := range fragments {
-		fragmentPatterns[k] = []byte(e.Pattern)
+	fragmentCPTrees, err, cerrs := parseFragments(fragments, dotExcludesNewline)
+	if err != nil {
+		return nil, nil, err, cerrs
 	}
 
-	fragmentCPTrees := make(map[spec.LexKindName]psr.CPTree, len(fragmentPatterns))
+	cpTrees := map[spec.LexModeKindID]psr.CPTree{}
 	{
 		var cerrs []*CompileError
-		for kind, pat := range fragmentPatterns {
-			p := psr.NewParser(kind, bytes.NewReader(pat))
-			t, err := p.Parse()
+		for id := spec.LexModeKindIDMin; int(id) < len(kindNames); id++ {
+			pattern, ok := patterns[id]
+			// Keywords don't have patterns.
+			if !ok {
+				continue
+			}
+
+			t, err, cerr := parsePattern(kindIDToName[id], pattern, fragmentCPTrees, dotExcludesNewline)
 			if err != nil {
-				if err == psr.ParseErr {
-					detail, cause := p.Error()
-					cerrs = append(cerrs, &CompileError{
-						Kind:     kind,
-						Fragment: true,
-						Cause:    cause,
-						Detail:   detail,
-					})
-				} else {
-					cerrs = append(cerrs, &CompileError{
-						Kind:     kind,
-						Fragment: true,
-						Cause:    err,
-					})
-				}
+				return nil, nil, err, nil
+			}
+			if cerr != nil {
+				cerrs = append(cerrs, cerr)
 				continue
 			}
-			fragmentCPTrees[kind] = t
+			cpTrees[id] = t
 		}
 		if len(cerrs) > 0 {
-			return nil, fmt.Errorf("compile error"), cerrs
+			return nil, nil, fmt.Errorf("compile error"), cerrs
 		}
+	}
 
-		err := psr.CompleteFragments(fragmentCPTrees)
+	if lazyDFA {
+		root, symTab, err := dfa.ConvertCPTreeToByteTree(cpTrees, foldCaseIDs)
 		if err != nil {
-			if err == psr.ParseErr {
-				for _, frag := range fragmentCPTrees {
-					kind, frags, err := frag.Describe()
-					if err != nil {
-						return nil, err, nil
-					}
-
-					cerrs = append(cerrs, &CompileError{
-						Kind:     kind,
-						Fragment: true,
-						Cause:    fmt.Errorf("fragment contains undefined fragments or cycles"),
-						Detail:   fmt.Sprintf("%v", frags),
-					})
-				}
-
-				return nil, fmt.Errorf("compile error"), cerrs
-			}
-
-			return nil, err, nil
+			return nil, nil, err, nil
+		}
+		nfa := dfa.GenNFA(root, symTab)
+		cerrs := checkKeywords(keywords, kindIDToName, func(lexeme []byte) (spec.LexModeKindID, bool) {
+			return matchNFA(nfa, lexeme)
+		})
+		if len(cerrs) > 0 {
+			return nil, nil, fmt.Errorf("compile error"), cerrs
 		}
+		return &spec.CompiledLexModeSpec{
+			KindNames: kindNames,
+			Push:      push,
+			Pop:       pop,
+			NFA:       nfa,
+			Keywords:  keywords,
+
+			CaseInsensitiveKeywords: ciKeywords,
+		}, &spec.LexModeReport{
+			Name:       modeName.String(),
+			Lazy:       true,
+			Priorities: priorities,
+		}, nil, nil
 	}
 
-	cpTrees := map[spec.LexModeKindID]psr.CPTree{}
+	var tranTab *spec.TransitionTable
+	var report *spec.LexModeReport
 	{
-		pats := make([]*psr.PatternEntry, len(patterns)+1)
-		pats[spec.LexModeKindIDNil] = &psr.PatternEntry{
-			ID: spec.LexModeKindIDNil,
-		}
-		for id, pattern := range patterns {
-			pats[id] = &psr.PatternEntry{
-				ID:      id,
-				Pattern: pattern,
-			}
+		root, symTab, err := dfa.ConvertCPTreeToByteTree(cpTrees, foldCaseIDs)
+		if err != nil {
+			return nil, nil, err, nil
 		}
+		d := dfa.GenDFA(root, symTab)
+		report = genModeReport(modeName, d, entries)
+		report.Priorities = priorities
+		tranTab, err = dfa.GenTransitionTable(d)
+		if err != nil {
+			return nil, nil, err, nil
+		}
+	}
 
-		var cerrs []*CompileError
-		for _, pat := range pats {
-			if pat.ID == spec.LexModeKindIDNil {
-				continue
-			}
+	cerrs = checkKeywords(keywords, kindIDToName, func(lexeme []byte) (spec.LexModeKindID, bool) {
+		return match(tranTab, lexeme)
+	})
+	if len(cerrs) > 0 {
+		return nil, nil, fmt.Errorf("compile error"), cerrs
+	}
 
-			p := psr.NewParser(kindIDToName[pat.ID], bytes.NewReader(pat.Pattern))
-			t, err := p.Parse()
-			if err != nil {
-				if err == psr.ParseErr {
-					detail, cause := p.Error()
-					cerrs = append(cerrs, &CompileError{
-						Kind:     kindIDToName[pat.ID],
-						Fragment: false,
-						Cause:    cause,
-						Detail:   detail,
-					})
-				} else {
-					cerrs = append(cerrs, &CompileError{
-						Kind:     kindIDToName[pat.ID],
-						Fragment: false,
-						Cause:    err,
-					})
-				}
-				continue
-			}
+	tranTab, report.TableSizes, err = compressTransitionTable(tranTab, compLv)
+	if err != nil {
+		return nil, nil, err, nil
+	}
 
-			complete, err := psr.ApplyFragments(t, fragmentCPTrees)
-			if err != nil {
-				return nil, err, nil
-			}
-			if !complete {
-				_, frags, err := t.Describe()
-				if err != nil {
-					return nil, err, nil
-				}
+	return &spec.CompiledLexModeSpec{
+		KindNames: kindNames,
+		Push:      push,
+		Pop:       pop,
+		DFA:       tranTab,
+		Keywords:  keywords,
+
+		CaseInsensitiveKeywords: ciKeywords,
+	}, report, nil, nil
+}
+
+// sortEntriesByPriority sorts the entries of a lex mode by their priorities in descending order, keeping the order of
+// definitions among the entries having the same priority. It also reports the order when any entry has a priority.
+func sortEntriesByPriority(entries []*LexEntry) ([]*LexEntry, []*spec.LexPriorityReport) {
+	prioritized := false
+	for _, e := range entries {
+		if e.Priority != 0 {
+			prioritized = true
+			break
+		}
+	}
+	if !prioritized {
+		return entries, nil
+	}
+
+	sorted := make([]*LexEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	var report []*spec.LexPriorityReport
+	for _, e := range sorted {
+		// Keywords don't compete with the other kinds because a lexer recognizes them by looking up keyword tables.
+		if e.Keyword {
+			continue
+		}
+		report = append(report, &spec.LexPriorityReport{
+			Kind:     e.Kind.String(),
+			Priority: e.Priority,
+		})
+	}
+	return sorted, report
+}
 
+// checkKeywords checks that the pattern of the owner of each keyword matches the keyword. `match` returns the kind
+// accepting a whole input.
+func checkKeywords(keywords []map[string]spec.LexModeKindID, kindIDToName map[spec.LexModeKindID]spec.LexKindName, match func(input []byte) (spec.LexModeKindID, bool)) []*CompileError {
+	var cerrs []*CompileError
+	for ownerID, tab := range keywords {
+		for lexeme, kwID := range tab {
+			if id, ok := match([]byte(lexeme)); !ok || id != spec.LexModeKindID(ownerID) {
 				cerrs = append(cerrs, &CompileError{
-					Kind:     kindIDToName[pat.ID],
-					Fragment: false,
-					Cause:    fmt.Errorf("pattern contains undefined fragments"),
-					Detail:   fmt.Sprintf("%v", frags),
+					Kind:   kindIDToName[kwID],
+					Cause:  fmt.Errorf("a keyword must be matched by the pattern of its owner `%v`", kindIDToName[spec.LexModeKindID(ownerID)]),
+					Detail: lexeme,
 				})
-				continue
 			}
+		}
+	}
+	return cerrs
+}
 
-			cpTrees[pat.ID] = t
+// matchNFA runs an NFA over a whole input and returns the kind accepting the input like match.
+func matchNFA(nfa *spec.NFA, input []byte) (spec.LexModeKindID, bool) {
+	poss := nfa.InitialPositions
+	for _, b := range input {
+		var next []int
+		for _, p := range poss {
+			if int(b) >= nfa.From[p] && int(b) <= nfa.To[p] {
+				next = append(next, nfa.Follow[p]...)
+			}
 		}
-		if len(cerrs) > 0 {
-			return nil, fmt.Errorf("compile error"), cerrs
+		if len(next) == 0 {
+			return spec.LexModeKindIDNil, false
+		}
+		poss = next
+	}
+	folded := map[int]struct{}{}
+	for _, p := range nfa.Folded {
+		folded[p] = struct{}{}
+	}
+	id := spec.LexModeKindIDNil
+	for _, p := range poss {
+		if _, ok := folded[p]; ok {
+			continue
+		}
+		if acc := nfa.Accept[p]; acc != spec.LexModeKindIDNil && (id == spec.LexModeKindIDNil || acc < id) {
+			id = acc
 		}
 	}
+	return id, id != spec.LexModeKindIDNil
+}
 
-	var tranTab *spec.TransitionTable
-	{
-		root, symTab, err := dfa.ConvertCPTreeToByteTree(cpTrees)
-		if err != nil {
-			return nil, err, nil
+// match runs an uncompressed transition table over a whole input and returns the kind accepting the input.
+func match(tranTab *spec.TransitionTable, input []byte) (spec.LexModeKindID, bool) {
+	state := tranTab.InitialStateID
+	for _, b := range input {
+		state = tranTab.UncompressedTransition[state.Int()*tranTab.ColCount+int(b)]
+		if state == spec.StateIDNil {
+			return spec.LexModeKindIDNil, false
 		}
-		d := dfa.GenDFA(root, symTab)
-		tranTab, err = dfa.GenTransitionTable(d)
+	}
+	id := tranTab.AcceptingStates[state]
+	return id, id != spec.LexModeKindIDNil
+}
+
+const (
+	CompressionLevelMin = 0
+	CompressionLevelMax = 3
+
+	// CompressionLevelRowDisplacement compresses a transition table by removing duplicate rows and then displacing
+	// the remaining rows into a single array.
+	CompressionLevelRowDisplacement = 2
+
+	// CompressionLevelBaseCheck compresses a transition table by the two-level (base/check) scheme. Each row keeps only
+	// the entries differing from a similar row, which suits DFAs of Unicode character properties having many states
+	// that differ in a few transitions.
+	CompressionLevelBaseCheck = 3
+)
+
+// compressTransitionTable compresses a transition table at every compression level to measure the sizes and returns
+// the table compressed at `compLv`.
+func compressTransitionTable(tranTab *spec.TransitionTable, compLv int) (*spec.TransitionTable, *spec.LexTableSizeReport, error) {
+	if compLv < CompressionLevelMin || compLv > CompressionLevelMax {
+		return nil, nil, fmt.Errorf("invalid compression level: %v", compLv)
+	}
+
+	// Each compression function replaces the uncompressed transition of a table with the compressed one, so it takes
+	// a shallow copy of the table.
+	tabs := []*spec.TransitionTable{
+		tranTab,
+	}
+	for _, compress := range []func(*spec.TransitionTable) (*spec.TransitionTable, error){
+		compressTransitionTableLv1,
+		compressTransitionTableLv2,
+		compressTransitionTableLv3,
+	} {
+		t := *tranTab
+		tab, err := compress(&t)
 		if err != nil {
-			return nil, err, nil
+			return nil, nil, err
 		}
+		tabs = append(tabs, tab)
 	}
 
-	var err error
-	switch compLv {
-	case 2:
-		tranTab, err = compressTransitionTableLv2(tranTab)
+	lv1 := tabs[1].Transition
+	lv2 := tabs[2].Transition
+	lv3 := tabs[3].BaseCheckTransition
+	return tabs[compLv], &spec.LexTableSizeReport{
+		Uncompressed:    len(tranTab.UncompressedTransition),
+		UniqueEntries:   len(lv1.RowNums) + len(lv1.UncompressedUniqueEntries),
+		RowDisplacement: len(lv2.RowNums) + len(lv2.UniqueEntries.RowDisplacement) + len(lv2.UniqueEntries.Entries) + len(lv2.UniqueEntries.Bounds),
+		BaseCheck:       len(lv3.Base) + len(lv3.Default) + len(lv3.Next) + len(lv3.Check),
+	}, nil
+}
+
+func compressTransitionTableLv3(tranTab *spec.TransitionTable) (*spec.TransitionTable, error) {
+	bcTab := compressor.NewBaseCheckTable(spec.StateIDNil.Int())
+	{
+		orig, err := compressor.NewOriginalTable(convertStateIDSliceToIntSlice(tranTab.UncompressedTransition), tranTab.ColCount)
 		if err != nil {
-			return nil, err, nil
+			return nil, err
 		}
-	case 1:
-		tranTab, err = compressTransitionTableLv1(tranTab)
+		err = bcTab.Compress(orig)
 		if err != nil {
-			return nil, err, nil
+			return nil, err
 		}
 	}
 
-	return &spec.CompiledLexModeSpec{
-		KindNames: kindNames,
-		Push:      push,
-		Pop:       pop,
-		DFA:       tranTab,
-	}, nil, nil
-}
+	tranTab.BaseCheckTransition = &spec.BaseCheckTable{
+		Base:    bcTab.Base,
+		Default: bcTab.Default,
+		Next:    convertIntSliceToStateIDSlice(bcTab.Next),
+		Check:   bcTab.Check,
+	}
+	tranTab.UncompressedTransition = nil
 
-const (
-	CompressionLevelMin = 0
-	CompressionLevelMax = 2
-)
+	return tranTab, nil
+}
 
 func compressTransitionTableLv2(tranTab *spec.TransitionTable) (*spec.TransitionTable, error) {
 	ueTab := compressor.NewUniqueEntriesTable()
@@ -411,3 +658,150 @@ func convertIntSliceToStateIDSlice(s []int) []spec.StateID {
 	}
 	return ss
 }
+
+// parseFragments parses the patterns of fragments and completes fragments referring to other fragments.
+func parseFragments(fragments map[spec.LexKindName]*LexEntry, dotExcludesNewline bool) (map[spec.LexKindName]psr.CPTree, error, []*CompileError) {
+	fragmentCPTrees := make(map[spec.LexKindName]psr.CPTree, len(fragments))
+	var cerrs []*CompileError
+	for kind, e := range fragments {
+		p := psr.NewParser(kind, bytes.NewReader([]byte(e.Pattern)))
+		if dotExcludesNewline {
+			p.ExcludeNewlineFromDot()
+		}
+		t, err := p.Parse()
+		if err != nil {
+			if err == psr.ParseErr {
+				detail, cause := p.Error()
+				cerrs = append(cerrs, &CompileError{
+					Kind:     kind,
+					Fragment: true,
+					Cause:    cause,
+					Detail:   detail,
+				})
+			} else {
+				cerrs = append(cerrs, &CompileError{
+					Kind:     kind,
+					Fragment: true,
+					Cause:    err,
+				})
+			}
+			continue
+		}
+		if len(psr.CaptureNames(t)) > 0 {
+			cerrs = append(cerrs, &CompileError{
+				Kind:     kind,
+				Fragment: true,
+				Cause:    fmt.Errorf("a fragment cannot contain capture groups"),
+			})
+			continue
+		}
+		fragmentCPTrees[kind] = t
+	}
+	if len(cerrs) > 0 {
+		return nil, fmt.Errorf("compile error"), cerrs
+	}
+
+	err := psr.CompleteFragments(fragmentCPTrees)
+	if err != nil {
+		if err == psr.ParseErr {
+			for _, frag := range fragmentCPTrees {
+				kind, frags, err := frag.Describe()
+				if err != nil {
+					return nil, err, nil
+				}
+
+				cerrs = append(cerrs, &CompileError{
+					Kind:     kind,
+					Fragment: true,
+					Cause:    fmt.Errorf("fragment contains undefined fragments or cycles"),
+					Detail:   fmt.Sprintf("%v", frags),
+				})
+			}
+
+			return nil, fmt.Errorf("compile error"), cerrs
+		}
+
+		return nil, err, nil
+	}
+
+	return fragmentCPTrees, nil, nil
+}
+
+// parsePattern parses a pattern of a kind and applies fragments to it. When the pattern is invalid, this function
+// returns a compile error.
+func parsePattern(kind spec.LexKindName, pattern []byte, fragmentCPTrees map[spec.LexKindName]psr.CPTree, dotExcludesNewline bool) (psr.CPTree, error, *CompileError) {
+	p := psr.NewParser(kind, bytes.NewReader(pattern))
+	if dotExcludesNewline {
+		p.ExcludeNewlineFromDot()
+	}
+	t, err := p.Parse()
+	if err != nil {
+		if err == psr.ParseErr {
+			detail, cause := p.Error()
+			return nil, nil, &CompileError{
+				Kind:     kind,
+				Fragment: false,
+				Cause:    cause,
+				Detail:   detail,
+			}
+		}
+		return nil, nil, &CompileError{
+			Kind:     kind,
+			Fragment: false,
+			Cause:    err,
+		}
+	}
+
+	complete, err := psr.ApplyFragments(t, fragmentCPTrees)
+	if err != nil {
+		return nil, err, nil
+	}
+	if !complete {
+		_, frags, err := t.Describe()
+		if err != nil {
+			return nil, err, nil
+		}
+
+		return nil, nil, &CompileError{
+			Kind:     kind,
+			Fragment: false,
+			Cause:    fmt.Errorf("pattern contains undefined fragments"),
+			Detail:   fmt.Sprintf("%v", frags),
+		}
+	}
+
+	return t, nil, nil
+}
+
+// Validate checks a lexical specification and the patterns of its entries without generating DFAs. It finds the same
+// errors as Compile except for the ones only DFAs reveal, such as a keyword that the pattern of its owner doesn't match.
+func Validate(lexspec *LexSpec) (error, []*CompileError) {
+	err := lexspec.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid lexical specification:\n%w", err), nil
+	}
+
+	_, _, _, fragments := groupEntriesByLexMode(lexspec.Entries)
+	fragmentCPTrees, err, cerrs := parseFragments(fragments, lexspec.DotExcludesNewline)
+	if err != nil {
+		return err, cerrs
+	}
+
+	for _, e := range lexspec.Entries {
+		if e.Fragment || e.Keyword {
+			continue
+		}
+		_, err, cerr := parsePattern(e.Kind, []byte(e.Pattern), fragmentCPTrees, lexspec.DotExcludesNewline)
+		if err != nil {
+			return err, nil
+		}
+		if cerr != nil {
+			cerrs = append(cerrs, cerr)
+		}
+	}
+	if len(cerrs) > 0 {
+		return fmt.Errorf("compile error"), cerrs
+	}
+
+	return nil, nil
+}
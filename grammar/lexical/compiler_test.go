@@ -3,6 +3,7 @@ package lexical
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"testing"
 
 	spec "github.com/nihei9/vartan/spec/grammar"
@@ -194,6 +195,26 @@ func TestCompile(t *testing.T) {
         }
     ]
 }
+`,
+			Err: true,
+		},
+		{
+			Caption: "don't allow fragments to contain capture groups",
+			Spec: `
+{
+    "name": "test",
+    "entries": [
+        {
+            "kind": "int",
+            "pattern": "\\f{digits}"
+        },
+        {
+            "fragment": true,
+            "kind": "digits",
+            "pattern": "(?<digits>[0-9]+)"
+        }
+    ]
+}
 `,
 			Err: true,
 		},
@@ -309,6 +330,92 @@ func TestCompile(t *testing.T) {
 }
 `,
 		},
+		{
+			Caption: "allow a kind to have keywords",
+			Spec: `
+{
+    "name": "test",
+    "entries": [
+        {
+            "kind": "id",
+            "pattern": "[a-z]+",
+            "keywords": ["kw_if", "kw_while"]
+        },
+        {
+            "kind": "kw_if",
+            "pattern": "if",
+            "keyword": true
+        },
+        {
+            "kind": "kw_while",
+            "pattern": "while",
+            "keyword": true
+        }
+    ]
+}
+`,
+		},
+		{
+			Caption: "don't allow a keyword that the pattern of its owner cannot match",
+			Spec: `
+{
+    "name": "test",
+    "entries": [
+        {
+            "kind": "id",
+            "pattern": "[a-z]+",
+            "keywords": ["kw_if"]
+        },
+        {
+            "kind": "kw_if",
+            "pattern": "IF",
+            "keyword": true
+        }
+    ]
+}
+`,
+			Err: true,
+		},
+		{
+			Caption: "don't allow a keyword that doesn't belong to any kind",
+			Spec: `
+{
+    "name": "test",
+    "entries": [
+        {
+            "kind": "id",
+            "pattern": "[a-z]+"
+        },
+        {
+            "kind": "kw_if",
+            "pattern": "if",
+            "keyword": true
+        }
+    ]
+}
+`,
+			Err: true,
+		},
+		{
+			Caption: "don't allow a kind that isn't a keyword to be a keyword of another kind",
+			Spec: `
+{
+    "name": "test",
+    "entries": [
+        {
+            "kind": "id",
+            "pattern": "[a-z]+",
+            "keywords": ["kw_if"]
+        },
+        {
+            "kind": "kw_if",
+            "pattern": "if"
+        }
+    ]
+}
+`,
+			Err: true,
+		},
 	}
 	for i, tt := range tests {
 		t.Run(fmt.Sprintf("#%v %s", i, tt.Caption), func(t *testing.T) {
@@ -336,3 +443,201 @@ func TestCompile(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileAndReport(t *testing.T) {
+	lspec := &LexSpec{
+		Entries: []*LexEntry{
+			{
+				Kind:    "id",
+				Pattern: "[a-z]+",
+			},
+			{
+				Kind:    "kw_if",
+				Pattern: "if",
+			},
+			{
+				Kind:    "kw_for",
+				Pattern: "for",
+			},
+			{
+				Kind:    "num",
+				Pattern: "[0-9]+",
+			},
+		},
+	}
+	_, report, err, _ := CompileAndReport(lspec, CompressionLevelMin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Modes) != 1 {
+		t.Fatalf("unexpected mode count: %v", len(report.Modes))
+	}
+	mode := report.Modes[0]
+	if mode.Name != spec.LexModeNameDefault.String() || mode.StateCount == 0 {
+		t.Fatalf("unexpected mode report: %+v", mode)
+	}
+	kinds := map[string]*spec.LexKindReport{}
+	for _, k := range mode.Kinds {
+		kinds[k.Name] = k
+	}
+	for _, name := range []string{"kw_if", "kw_for"} {
+		k := kinds[name]
+		if k == nil || k.AcceptingStateCount != 0 || k.ReachingStateCount != 0 || len(k.ShadowedBy) != 1 || k.ShadowedBy[0] != "id" {
+			t.Fatalf("%v must be shadowed by id: %+v", name, k)
+		}
+	}
+	for _, name := range []string{"id", "num"} {
+		k := kinds[name]
+		if k == nil || k.AcceptingStateCount == 0 || k.ReachingStateCount <= k.AcceptingStateCount || len(k.ShadowedBy) != 0 {
+			t.Fatalf("unexpected report of %v: %+v", name, k)
+		}
+	}
+}
+
+func TestCompileAndReport_Priorities(t *testing.T) {
+	lspec := &LexSpec{
+		Entries: []*LexEntry{
+			{
+				Kind:    "id",
+				Pattern: "[a-z0-9]+",
+			},
+			{
+				Kind:     "kw_if",
+				Pattern:  "if",
+				Priority: 1,
+			},
+			{
+				Kind:     "num",
+				Pattern:  "[0-9]+",
+				Priority: 2,
+			},
+			{
+				Kind:    "ws",
+				Pattern: "[\\u{0020}]+",
+				Modes:   []spec.LexModeName{"default", "other"},
+			},
+		},
+	}
+	clspec, report, err, _ := CompileAndReport(lspec, CompressionLevelMin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Priorities affect only the IDs of kinds in each mode.
+	expectedKindNames := []spec.LexKindName{spec.LexKindNameNil, "id", "kw_if", "num", "ws"}
+	if !reflect.DeepEqual(clspec.KindNames, expectedKindNames) {
+		t.Fatalf("unexpected kind names: want: %v, got: %v", expectedKindNames, clspec.KindNames)
+	}
+	modeSpec := clspec.Specs[spec.LexModeIDDefault]
+	expectedModeKindNames := []spec.LexKindName{spec.LexKindNameNil, "num", "kw_if", "id", "ws"}
+	if !reflect.DeepEqual(modeSpec.KindNames, expectedModeKindNames) {
+		t.Fatalf("unexpected kind names of the default mode: want: %v, got: %v", expectedModeKindNames, modeSpec.KindNames)
+	}
+
+	for input, expected := range map[string]spec.LexKindName{
+		"if":  "kw_if",
+		"12":  "num",
+		"ifa": "id",
+		"1a":  "id",
+	} {
+		id, ok := match(modeSpec.DFA, []byte(input))
+		if !ok || modeSpec.KindNames[id] != expected {
+			t.Errorf("%v: unexpected kind: want: %v, got: %v", input, expected, modeSpec.KindNames[id])
+		}
+	}
+
+	expectedPriorities := []*spec.LexPriorityReport{
+		{Kind: "num", Priority: 2},
+		{Kind: "kw_if", Priority: 1},
+		{Kind: "id", Priority: 0},
+		{Kind: "ws", Priority: 0},
+	}
+	if !reflect.DeepEqual(report.Modes[0].Priorities, expectedPriorities) {
+		t.Fatalf("unexpected priorities of the default mode: %v", report.Modes[0].Priorities)
+	}
+	if len(report.Modes[1].Priorities) != 0 {
+		t.Fatalf("a mode without priorities must not report priorities: %v", report.Modes[1].Priorities)
+	}
+}
+
+func TestCompileAndReport_Backtracks(t *testing.T) {
+	tests := []struct {
+		caption    string
+		entries    []*LexEntry
+		backtracks []*spec.LexBacktrackReport
+	}{
+		{
+			caption: "the lexer never backtracks when every state following an accepting state is accepting",
+			entries: []*LexEntry{
+				{Kind: "id", Pattern: "[a-z]+"},
+				{Kind: "num", Pattern: "[0-9]+"},
+			},
+		},
+		{
+			caption: "the lexer rescans a bounded number of bytes",
+			entries: []*LexEntry{
+				{Kind: "dot", Pattern: "\\."},
+				{Kind: "ellipsis", Pattern: "\\.\\.\\."},
+			},
+			backtracks: []*spec.LexBacktrackReport{
+				{Kind: "dot", Lexeme: `"."`, Beyond: `"."`, MaxRescan: 1},
+			},
+		},
+		{
+			caption: "the lexer rescans an unbounded number of bytes when a comment isn't closed",
+			entries: []*LexEntry{
+				{Kind: "div", Pattern: "/"},
+				{Kind: "comment", Pattern: "/\\*([^*]|\\*+[^*/])*\\*+/"},
+			},
+			backtracks: []*spec.LexBacktrackReport{
+				{Kind: "div", Lexeme: `"/"`, Beyond: `"*"`, MaxRescan: -1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			_, report, err, _ := CompileAndReport(&LexSpec{Entries: tt.entries}, CompressionLevelMin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			backtracks := report.Modes[0].Backtracks
+			if len(backtracks) != len(tt.backtracks) {
+				t.Fatalf("unexpected backtracks: want: %v entries, got: %v entries", len(tt.backtracks), len(backtracks))
+			}
+			for i, b := range backtracks {
+				if *b != *tt.backtracks[i] {
+					t.Fatalf("unexpected backtrack: want: %+v, got: %+v", tt.backtracks[i], b)
+				}
+			}
+		})
+	}
+}
+
+func TestCompile_LazyDFA(t *testing.T) {
+	newSpec := func(kw string) *LexSpec {
+		return &LexSpec{
+			Entries: []*LexEntry{
+				{Kind: "id", Pattern: "[a-z]+", Keywords: []spec.LexKindName{"kw"}},
+				{Kind: "kw", Pattern: kw, Keyword: true},
+			},
+			LazyDFA: true,
+		}
+	}
+
+	clspec, err, _ := Compile(newSpec("if"), CompressionLevelMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modeSpec := clspec.Specs[spec.LexModeIDDefault]
+	if modeSpec.DFA != nil || modeSpec.NFA == nil {
+		t.Fatalf("a lexical specification must have an NFA instead of a DFA: %+v", modeSpec)
+	}
+	if id, ok := matchNFA(modeSpec.NFA, []byte("if")); !ok || id != 1 {
+		t.Fatalf("id must match the keyword: %v, %v", id, ok)
+	}
+
+	_, err, cerrs := Compile(newSpec("IF"), CompressionLevelMax)
+	if err == nil || len(cerrs) != 1 || cerrs[0].Kind != "kw" {
+		t.Fatalf("a keyword that its owner doesn't match must be an error: %v, %v", err, cerrs)
+	}
+}
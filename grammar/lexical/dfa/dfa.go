@@ -7,14 +7,21 @@ import (
 )
 
 type symbolTable struct {
-	symPos2Byte map[symbolPosition]byteRange
-	endPos2ID   map[symbolPosition]spec.LexModeKindID
+	symPos2Byte   map[symbolPosition]byteRange
+	endPos2ID     map[symbolPosition]spec.LexModeKindID
+	foldedEndPoss map[symbolPosition]struct{}
+
+	// symPos2Captures holds the indexes of the capture groups containing each symbol.
+	symPos2Captures map[symbolPosition][]int
 }
 
 func genSymbolTable(root byteTree) *symbolTable {
 	symTab := &symbolTable{
-		symPos2Byte: map[symbolPosition]byteRange{},
-		endPos2ID:   map[symbolPosition]spec.LexModeKindID{},
+		symPos2Byte:   map[symbolPosition]byteRange{},
+		endPos2ID:     map[symbolPosition]spec.LexModeKindID{},
+		foldedEndPoss: map[symbolPosition]struct{}{},
+
+		symPos2Captures: map[symbolPosition][]int{},
 	}
 	return genSymTab(symTab, root)
 }
@@ -30,8 +37,14 @@ func genSymTab(symTab *symbolTable, node byteTree) *symbolTable {
 			from: n.from,
 			to:   n.to,
 		}
+		if len(n.captures) > 0 {
+			symTab.symPos2Captures[n.pos] = n.captures
+		}
 	case *endMarkerNode:
 		symTab.endPos2ID[n.pos] = n.id
+		if n.folded {
+			symTab.foldedEndPoss[n.pos] = struct{}{}
+		}
 	default:
 		left, right := node.children()
 		genSymTab(symTab, left)
@@ -45,6 +58,14 @@ type DFA struct {
 	InitialState         string
 	AcceptingStatesTable map[string]spec.LexModeKindID
 	TransitionTable      map[string][256]string
+
+	// CaseInsensitiveAcceptingStatesTable is the accepting states used when the DFA runs case-insensitively.
+	// This field is nil when the DFA has no case-folded patterns.
+	CaseInsensitiveAcceptingStatesTable map[string]spec.LexModeKindID
+
+	// MatchingKindsTable holds all kinds whose patterns match at each state in ascending order, including the kinds
+	// losing to a kind having a smaller ID. Like AcceptingStatesTable, it doesn't consider folded end markers.
+	MatchingKindsTable map[string][]spec.LexModeKindID
 }
 
 func GenDFA(root byteTree, symTab *symbolTable) *DFA {
@@ -99,24 +120,10 @@ func GenDFA(root byteTree, symTab *symbolTable) *DFA {
 		}
 	}
 
-	accTab := map[string]spec.LexModeKindID{}
-	{
-		for h, s := range stateMap {
-			for _, pos := range s.set() {
-				if !pos.isEndMark() {
-					continue
-				}
-				priorID, ok := accTab[h]
-				if !ok {
-					accTab[h] = symTab.endPos2ID[pos]
-				} else {
-					id := symTab.endPos2ID[pos]
-					if id < priorID {
-						accTab[h] = id
-					}
-				}
-			}
-		}
+	accTab := genAcceptingStatesTable(stateMap, symTab, false)
+	var ciAccTab map[string]spec.LexModeKindID
+	if len(symTab.foldedEndPoss) > 0 {
+		ciAccTab = genAcceptingStatesTable(stateMap, symTab, true)
 	}
 
 	var states []string
@@ -134,9 +141,62 @@ func GenDFA(root byteTree, symTab *symbolTable) *DFA {
 		InitialState:         initialStateHash,
 		AcceptingStatesTable: accTab,
 		TransitionTable:      tranTab,
+
+		CaseInsensitiveAcceptingStatesTable: ciAccTab,
+		MatchingKindsTable:                  genMatchingKindsTable(stateMap, symTab),
 	}
 }
 
+func genMatchingKindsTable(stateMap map[string]*symbolPositionSet, symTab *symbolTable) map[string][]spec.LexModeKindID {
+	tab := map[string][]spec.LexModeKindID{}
+	for h, s := range stateMap {
+		var ids []spec.LexModeKindID
+		for _, pos := range s.set() {
+			if !pos.isEndMark() {
+				continue
+			}
+			if _, folded := symTab.foldedEndPoss[pos]; folded {
+				continue
+			}
+			ids = append(ids, symTab.endPos2ID[pos])
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+		tab[h] = ids
+	}
+	return tab
+}
+
+// genAcceptingStatesTable decides a kind each state accepts. When a state contains multiple end markers, the kind having
+// the smallest ID takes precedence. Folded end markers are considered only when `caseInsensitive` is true.
+func genAcceptingStatesTable(stateMap map[string]*symbolPositionSet, symTab *symbolTable, caseInsensitive bool) map[string]spec.LexModeKindID {
+	accTab := map[string]spec.LexModeKindID{}
+	for h, s := range stateMap {
+		for _, pos := range s.set() {
+			if !pos.isEndMark() {
+				continue
+			}
+			if _, folded := symTab.foldedEndPoss[pos]; folded && !caseInsensitive {
+				continue
+			}
+			priorID, ok := accTab[h]
+			if !ok {
+				accTab[h] = symTab.endPos2ID[pos]
+			} else {
+				id := symTab.endPos2ID[pos]
+				if id < priorID {
+					accTab[h] = id
+				}
+			}
+		}
+	}
+	return accTab
+}
+
 func GenTransitionTable(dfa *DFA) (*spec.TransitionTable, error) {
 	stateHash2ID := map[string]spec.StateID{}
 	for i, s := range dfa.States {
@@ -154,6 +214,18 @@ func GenTransitionTable(dfa *DFA) (*spec.TransitionTable, error) {
 		acc[stateHash2ID[s]] = id
 	}
 
+	var ciAcc []spec.LexModeKindID
+	if dfa.CaseInsensitiveAcceptingStatesTable != nil {
+		ciAcc = make([]spec.LexModeKindID, len(dfa.States)+1)
+		for _, s := range dfa.States {
+			id, ok := dfa.CaseInsensitiveAcceptingStatesTable[s]
+			if !ok {
+				continue
+			}
+			ciAcc[stateHash2ID[s]] = id
+		}
+	}
+
 	rowCount := len(dfa.States) + 1
 	colCount := 256
 	tran := make([]spec.StateID, rowCount*colCount)
@@ -169,5 +241,7 @@ func GenTransitionTable(dfa *DFA) (*spec.TransitionTable, error) {
 		UncompressedTransition: tran,
 		RowCount:               rowCount,
 		ColCount:               colCount,
+
+		CaseInsensitiveAcceptingStates: ciAcc,
 	}, nil
 }
@@ -16,7 +16,7 @@ func TestGenDFA(t *testing.T) {
 	}
 	bt, symTab, err := ConvertCPTreeToByteTree(map[spec.LexModeKindID]parser.CPTree{
 		spec.LexModeKindIDMin: cpt,
-	})
+	}, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,90 @@
+package dfa
+
+import (
+	"sort"
+
+	"github.com/nihei9/vartan/grammar/lexical/parser"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+// GenNFA generates the position automaton underlying the DFA GenDFA generates. A set of its positions corresponds to
+// a DFA state, so a lexer can build the same DFA from the automaton lazily.
+func GenNFA(root byteTree, symTab *symbolTable) *spec.NFA {
+	return genNFA(root, symTab, false)
+}
+
+// GenCaptureNFA generates the position automaton of a single pattern containing capture groups. Unlike GenNFA, the
+// automaton holds the capture groups containing each position, so a lexer can find the sub-spans of a lexeme that
+// the groups match. When `foldCase` is true, the automaton also has a case-folded variant of the pattern.
+func GenCaptureNFA(cpTree parser.CPTree, foldCase bool) (*spec.NFA, error) {
+	foldCaseIDs := map[spec.LexModeKindID]struct{}{}
+	if foldCase {
+		foldCaseIDs[spec.LexModeKindIDMin] = struct{}{}
+	}
+	root, symTab, err := ConvertCPTreeToByteTree(map[spec.LexModeKindID]parser.CPTree{
+		spec.LexModeKindIDMin: cpTree,
+	}, foldCaseIDs)
+	if err != nil {
+		return nil, err
+	}
+	return genNFA(root, symTab, true), nil
+}
+
+func genNFA(root byteTree, symTab *symbolTable, withTags bool) *spec.NFA {
+	var poss []symbolPosition
+	for pos := range symTab.symPos2Byte {
+		poss = append(poss, pos)
+	}
+	for pos := range symTab.endPos2ID {
+		poss = append(poss, pos)
+	}
+	sort.Slice(poss, func(i, j int) bool {
+		return poss[i] < poss[j]
+	})
+	pos2Num := make(map[symbolPosition]int, len(poss))
+	for i, pos := range poss {
+		pos2Num[pos] = i
+	}
+	toNums := func(s *symbolPositionSet) []int {
+		if s == nil {
+			return nil
+		}
+		var nums []int
+		for _, pos := range s.set() {
+			nums = append(nums, pos2Num[pos])
+		}
+		return nums
+	}
+
+	follow := genFollowTable(root)
+	nfa := &spec.NFA{
+		InitialPositions: toNums(root.first()),
+		From:             make([]int, len(poss)),
+		To:               make([]int, len(poss)),
+		Follow:           make([][]int, len(poss)),
+		Accept:           make([]spec.LexModeKindID, len(poss)),
+	}
+	if withTags {
+		nfa.Tags = make([][]int, len(poss))
+	}
+	for i, pos := range poss {
+		if pos.isEndMark() {
+			// An empty range
+			nfa.From[i] = 1
+			nfa.To[i] = 0
+			nfa.Accept[i] = symTab.endPos2ID[pos]
+			if _, folded := symTab.foldedEndPoss[pos]; folded {
+				nfa.Folded = append(nfa.Folded, i)
+			}
+			continue
+		}
+		r := symTab.symPos2Byte[pos]
+		nfa.From[i] = int(r.from)
+		nfa.To[i] = int(r.to)
+		nfa.Follow[i] = toNums(follow[pos])
+		if withTags {
+			nfa.Tags[i] = symTab.symPos2Captures[pos]
+		}
+	}
+	return nfa
+}
@@ -38,6 +38,9 @@ type symbolNode struct {
 	pos       symbolPosition
 	firstMemo *symbolPositionSet
 	lastMemo  *symbolPositionSet
+
+	// captures holds the indexes of the capture groups containing the symbol.
+	captures []int
 }
 
 func newSymbolNode(value byte) *symbolNode {
@@ -89,11 +92,18 @@ func (n *symbolNode) last() *symbolPositionSet {
 }
 
 func (n *symbolNode) clone() byteTree {
-	return newRangeSymbolNode(n.from, n.to)
+	c := newRangeSymbolNode(n.from, n.to)
+	c.captures = append([]int(nil), n.captures...)
+	return c
 }
 
 type endMarkerNode struct {
-	id        spec.LexModeKindID
+	id spec.LexModeKindID
+
+	// When folded is true, the end marker terminates a case-folded variant of a pattern. The DFA accepts such an
+	// end marker only when it runs case-insensitively.
+	folded bool
+
 	pos       symbolPosition
 	firstMemo *symbolPositionSet
 	lastMemo  *symbolPositionSet
@@ -106,6 +116,14 @@ func newEndMarkerNode(id spec.LexModeKindID) *endMarkerNode {
 	}
 }
 
+func newFoldedEndMarkerNode(id spec.LexModeKindID) *endMarkerNode {
+	return &endMarkerNode{
+		id:     id,
+		folded: true,
+		pos:    symbolPositionNil,
+	}
+}
+
 func (n *endMarkerNode) String() string {
 	return fmt.Sprintf("end: pos: %v", n.pos)
 }
@@ -135,6 +153,9 @@ func (n *endMarkerNode) last() *symbolPositionSet {
 }
 
 func (n *endMarkerNode) clone() byteTree {
+	if n.folded {
+		return newFoldedEndMarkerNode(n.id)
+	}
 	return newEndMarkerNode(n.id)
 }
 
@@ -480,7 +501,9 @@ func printByteTree(w io.Writer, t byteTree, ruledLine string, childRuledLinePref
 	}
 }
 
-func ConvertCPTreeToByteTree(cpTrees map[spec.LexModeKindID]parser.CPTree) (byteTree, *symbolTable, error) {
+// ConvertCPTreeToByteTree converts code point trees into a byte tree. For each ID contained in `foldCaseIDs`, the byte tree
+// additionally has a case-folded variant of the pattern, which is terminated by a folded end marker.
+func ConvertCPTreeToByteTree(cpTrees map[spec.LexModeKindID]parser.CPTree, foldCaseIDs map[spec.LexModeKindID]struct{}) (byteTree, *symbolTable, error) {
 	var ids []spec.LexModeKindID
 	for id := range cpTrees {
 		ids = append(ids, id)
@@ -492,12 +515,22 @@ func ConvertCPTreeToByteTree(cpTrees map[spec.LexModeKindID]parser.CPTree) (byte
 	var bt byteTree
 	for _, id := range ids {
 		cpTree := cpTrees[id]
-		t, err := convCPTreeToByteTree(cpTree)
+		t, err := convCPTreeToByteTree(cpTree, false)
 		if err != nil {
 			return nil, nil, err
 		}
 		bt = oneOf(bt, concat(t, newEndMarkerNode(id)))
 	}
+	for _, id := range ids {
+		if _, ok := foldCaseIDs[id]; !ok {
+			continue
+		}
+		t, err := convCPTreeToByteTree(cpTrees[id], true)
+		if err != nil {
+			return nil, nil, err
+		}
+		bt = oneOf(bt, concat(t, newFoldedEndMarkerNode(id)))
+	}
 	_, err := positionSymbols(bt, symbolPositionMin)
 	if err != nil {
 		return nil, nil, err
@@ -506,25 +539,36 @@ func ConvertCPTreeToByteTree(cpTrees map[spec.LexModeKindID]parser.CPTree) (byte
 	return bt, genSymbolTable(bt), nil
 }
 
-func convCPTreeToByteTree(cpTree parser.CPTree) (byteTree, error) {
+func convCPTreeToByteTree(cpTree parser.CPTree, foldCase bool) (byteTree, error) {
 	if from, to, ok := cpTree.Range(); ok {
-		bs, err := utf8.GenCharBlocks(from, to)
-		if err != nil {
-			return nil, err
+		rs := []parser.CPRange{
+			{
+				From: from,
+				To:   to,
+			},
+		}
+		if foldCase {
+			rs = append(rs, foldCaseOfRange(from, to)...)
 		}
 		var a byteTree
-		for _, b := range bs {
-			var c byteTree
-			for i := 0; i < len(b.From); i++ {
-				c = concat(c, newRangeSymbolNode(b.From[i], b.To[i]))
+		for _, r := range rs {
+			bs, err := utf8.GenCharBlocks(r.From, r.To)
+			if err != nil {
+				return nil, err
+			}
+			for _, b := range bs {
+				var c byteTree
+				for i := 0; i < len(b.From); i++ {
+					c = concat(c, newRangeSymbolNode(b.From[i], b.To[i]))
+				}
+				a = oneOf(a, c)
 			}
-			a = oneOf(a, c)
 		}
 		return a, nil
 	}
 
 	if tree, ok := cpTree.Repeatable(); ok {
-		t, err := convCPTreeToByteTree(tree)
+		t, err := convCPTreeToByteTree(tree, foldCase)
 		if err != nil {
 			return nil, err
 		}
@@ -532,7 +576,7 @@ func convCPTreeToByteTree(cpTree parser.CPTree) (byteTree, error) {
 	}
 
 	if tree, ok := cpTree.Optional(); ok {
-		t, err := convCPTreeToByteTree(tree)
+		t, err := convCPTreeToByteTree(tree, foldCase)
 		if err != nil {
 			return nil, err
 		}
@@ -540,11 +584,11 @@ func convCPTreeToByteTree(cpTree parser.CPTree) (byteTree, error) {
 	}
 
 	if left, right, ok := cpTree.Concatenation(); ok {
-		l, err := convCPTreeToByteTree(left)
+		l, err := convCPTreeToByteTree(left, foldCase)
 		if err != nil {
 			return nil, err
 		}
-		r, err := convCPTreeToByteTree(right)
+		r, err := convCPTreeToByteTree(right, foldCase)
 		if err != nil {
 			return nil, err
 		}
@@ -552,16 +596,73 @@ func convCPTreeToByteTree(cpTree parser.CPTree) (byteTree, error) {
 	}
 
 	if left, right, ok := cpTree.Alternatives(); ok {
-		l, err := convCPTreeToByteTree(left)
+		l, err := convCPTreeToByteTree(left, foldCase)
 		if err != nil {
 			return nil, err
 		}
-		r, err := convCPTreeToByteTree(right)
+		r, err := convCPTreeToByteTree(right, foldCase)
 		if err != nil {
 			return nil, err
 		}
 		return newAltNode(l, r), nil
 	}
 
+	if index, tree, ok := cpTree.Capture(); ok {
+		t, err := convCPTreeToByteTree(tree, foldCase)
+		if err != nil {
+			return nil, err
+		}
+		tagCapture(t, index)
+		return t, nil
+	}
+
 	return nil, fmt.Errorf("invalid tree type: %T", cpTree)
 }
+
+// tagCapture marks the symbols in a tree as belonging to a capture group `index`.
+func tagCapture(t byteTree, index int) {
+	if t == nil {
+		return
+	}
+	if n, ok := t.(*symbolNode); ok {
+		n.captures = append(n.captures, index)
+		return
+	}
+	left, right := t.children()
+	tagCapture(left, index)
+	tagCapture(right, index)
+}
+
+// foldCaseOfRange returns the ranges of the letters whose cases are the opposite of the letters in a range [from, to].
+// Case folding covers only ASCII letters.
+func foldCaseOfRange(from, to rune) []parser.CPRange {
+	var rs []parser.CPRange
+	if f, t, ok := intersectRange(from, to, 'A', 'Z'); ok {
+		rs = append(rs, parser.CPRange{
+			From: f + ('a' - 'A'),
+			To:   t + ('a' - 'A'),
+		})
+	}
+	if f, t, ok := intersectRange(from, to, 'a', 'z'); ok {
+		rs = append(rs, parser.CPRange{
+			From: f - ('a' - 'A'),
+			To:   t - ('a' - 'A'),
+		})
+	}
+	return rs
+}
+
+func intersectRange(from1, to1, from2, to2 rune) (rune, rune, bool) {
+	from := from1
+	if from2 > from {
+		from = from2
+	}
+	to := to1
+	if to2 < to {
+		to = to2
+	}
+	if from > to {
+		return 0, 0, false
+	}
+	return from, to, true
+}
@@ -165,7 +165,7 @@ func TestFollowAndSymbolTable(t *testing.T) {
 
 	bt, symTab, err := ConvertCPTreeToByteTree(map[spec.LexModeKindID]parser.CPTree{
 		spec.LexModeKindIDMin: cpt,
-	})
+	}, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -15,10 +15,40 @@ type LexEntry struct {
 	Push     spec.LexModeName
 	Pop      bool
 	Fragment bool
+
+	// When CaseConfigurable is true, a lexer can match the pattern case-insensitively at run time.
+	CaseConfigurable bool
+
+	// Class is a class of the kind for syntax highlighting, such as `keyword`. An empty string means the kind has no
+	// class.
+	Class string
+
+	// Priority decides which kind a lexer gives a lexeme that the patterns of multiple kinds match at the same length.
+	// A kind having a higher priority wins, and among kinds having the same priority, a kind defined earlier wins.
+	// Priorities don't override the longest match.
+	Priority int
+
+	// Keywords is a list of kinds that are keywords of this kind. When a lexeme matched the pattern of this kind equals
+	// the lexeme of a keyword, a lexer remaps the kind of the token to the keyword.
+	Keywords []spec.LexKindName
+
+	// When Keyword is true, the entry is a keyword of another entry, and Pattern is a literal string, not a regular
+	// expression. The entry must belong to the same modes as the entry owning it.
+	Keyword bool
+
+	// Transformations is a list of transformations a lexer applies to lexemes of the kind in order.
+	Transformations []*spec.LexTransformation
 }
 
 type LexSpec struct {
 	Entries []*LexEntry
+
+	// When DotExcludesNewline is true, `.` in patterns matches any character except a newline (U+000A).
+	DotExcludesNewline bool
+
+	// When LazyDFA is true, the compiler stores the NFA of each lex mode instead of its DFA, and a lexer builds DFA states
+	// at run time. The compiled specification is smaller, but a lexer takes time to build DFA states.
+	LazyDFA bool
 }
 
 func (s *LexSpec) Validate() error {
@@ -43,6 +73,42 @@ func (s *LexSpec) Validate() error {
 			}
 		}
 	}
+	{
+		entries := map[spec.LexKindName]*LexEntry{}
+		for _, e := range s.Entries {
+			if e.Fragment {
+				continue
+			}
+			entries[e.Kind] = e
+		}
+		owners := map[spec.LexKindName]spec.LexKindName{}
+		for _, e := range s.Entries {
+			if e.Fragment {
+				continue
+			}
+			for _, kw := range e.Keywords {
+				kwEntry, ok := entries[kw]
+				if !ok || !kwEntry.Keyword {
+					return fmt.Errorf("kind `%v` is not a keyword; owner: %v", kw, e.Kind)
+				}
+				if owner, ok := owners[kw]; ok {
+					return fmt.Errorf("keyword `%v` belongs to multiple kinds: %v, %v", kw, owner, e.Kind)
+				}
+				owners[kw] = e.Kind
+			}
+		}
+		for _, e := range s.Entries {
+			if e.Fragment || !e.Keyword {
+				continue
+			}
+			if _, ok := owners[e.Kind]; !ok {
+				return fmt.Errorf("keyword `%v` doesn't belong to any kind", e.Kind)
+			}
+			if len(e.Keywords) > 0 {
+				return fmt.Errorf("keyword `%v` cannot have keywords", e.Kind)
+			}
+		}
+	}
 	{
 		kinds := []string{}
 		modes := []string{
@@ -1,36 +1,45 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
+
+	verr "github.com/nihei9/vartan/error"
+)
 
 var (
 	ParseErr = fmt.Errorf("parse error")
 
 	// lexical errors
-	synErrIncompletedEscSeq     = fmt.Errorf("incompleted escape sequence; unexpected EOF following \\")
-	synErrInvalidEscSeq         = fmt.Errorf("invalid escape sequence")
-	synErrInvalidCodePoint      = fmt.Errorf("code points must consist of just 4 or 6 hex digits")
-	synErrCharPropInvalidSymbol = fmt.Errorf("invalid character property symbol")
-	SynErrFragmentInvalidSymbol = fmt.Errorf("invalid fragment symbol")
+	synErrIncompletedEscSeq     = verr.NewCodedError("V3001", "incompleted escape sequence; unexpected EOF following \\")
+	synErrInvalidEscSeq         = verr.NewCodedError("V3002", "invalid escape sequence")
+	synErrInvalidCodePoint      = verr.NewCodedError("V3003", "code points must consist of just 4 or 6 hex digits")
+	synErrCharPropInvalidSymbol = verr.NewCodedError("V3004", "invalid character property symbol")
+	SynErrFragmentInvalidSymbol = verr.NewCodedError("V3005", "invalid fragment symbol")
 
 	// syntax errors
-	synErrUnexpectedToken        = fmt.Errorf("unexpected token")
-	synErrNullPattern            = fmt.Errorf("a pattern must be a non-empty byte sequence")
-	synErrUnmatchablePattern     = fmt.Errorf("a pattern cannot match any characters")
-	synErrAltLackOfOperand       = fmt.Errorf("an alternation expression must have operands")
-	synErrRepNoTarget            = fmt.Errorf("a repeat expression must have an operand")
-	synErrGroupNoElem            = fmt.Errorf("a grouping expression must include at least one character")
-	synErrGroupUnclosed          = fmt.Errorf("unclosed grouping expression")
-	synErrGroupNoInitiator       = fmt.Errorf(") needs preceding (")
-	synErrGroupInvalidForm       = fmt.Errorf("invalid grouping expression")
-	synErrBExpNoElem             = fmt.Errorf("a bracket expression must include at least one character")
-	synErrBExpUnclosed           = fmt.Errorf("unclosed bracket expression")
-	synErrBExpInvalidForm        = fmt.Errorf("invalid bracket expression")
-	synErrRangeInvalidOrder      = fmt.Errorf("a range expression with invalid order")
-	synErrRangePropIsUnavailable = fmt.Errorf("a property expression is unavailable in a range expression")
-	synErrRangeInvalidForm       = fmt.Errorf("invalid range expression")
-	synErrCPExpInvalidForm       = fmt.Errorf("invalid code point expression")
-	synErrCPExpOutOfRange        = fmt.Errorf("a code point must be between U+0000 to U+10FFFF")
-	synErrCharPropExpInvalidForm = fmt.Errorf("invalid character property expression")
-	synErrCharPropUnsupported    = fmt.Errorf("unsupported character property")
-	synErrFragmentExpInvalidForm = fmt.Errorf("invalid fragment expression")
+	synErrUnexpectedToken        = verr.NewCodedError("V3006", "unexpected token")
+	synErrNullPattern            = verr.NewCodedError("V3007", "a pattern must be a non-empty byte sequence")
+	synErrUnmatchablePattern     = verr.NewCodedError("V3008", "a pattern cannot match any characters")
+	synErrAltLackOfOperand       = verr.NewCodedError("V3009", "an alternation expression must have operands")
+	synErrRepNoTarget            = verr.NewCodedError("V3010", "a repeat expression must have an operand")
+	synErrGroupNoElem            = verr.NewCodedError("V3011", "a grouping expression must include at least one character")
+	synErrGroupUnclosed          = verr.NewCodedError("V3012", "unclosed grouping expression")
+	synErrGroupNoInitiator       = verr.NewCodedError("V3013", ") needs preceding (")
+	synErrGroupInvalidForm       = verr.NewCodedError("V3014", "invalid grouping expression")
+	synErrBExpNoElem             = verr.NewCodedError("V3015", "a bracket expression must include at least one character")
+	synErrBExpUnclosed           = verr.NewCodedError("V3016", "unclosed bracket expression")
+	synErrBExpInvalidForm        = verr.NewCodedError("V3017", "invalid bracket expression")
+	synErrRangeInvalidOrder      = verr.NewCodedError("V3018", "a range expression with invalid order")
+	synErrRangePropIsUnavailable = verr.NewCodedError("V3019", "a property expression is unavailable in a range expression")
+	synErrRangeInvalidForm       = verr.NewCodedError("V3020", "invalid range expression")
+	synErrCPExpInvalidForm       = verr.NewCodedError("V3021", "invalid code point expression")
+	synErrCPExpOutOfRange        = verr.NewCodedError("V3022", "a code point must be between U+0000 to U+10FFFF")
+	synErrCharPropExpInvalidForm = verr.NewCodedError("V3023", "invalid character property expression")
+	synErrCharPropUnsupported    = verr.NewCodedError("V3024", "unsupported character property")
+	synErrFragmentExpInvalidForm = verr.NewCodedError("V3025", "invalid fragment expression")
+	synErrSetOpInvalidOperand    = verr.NewCodedError("V3026", "a set operator needs a bracket expression, a character property expression, or a code point expression as its right operand")
+	synErrPOSIXClassInvalidForm  = verr.NewCodedError("V3027", "invalid POSIX character class")
+	synErrPOSIXClassUnsupported  = verr.NewCodedError("V3028", "unsupported POSIX character class")
+	synErrCaptureInvalidName     = verr.NewCodedError("V3029", "invalid capture group name")
+	synErrCaptureDuplicateName   = verr.NewCodedError("V3030", "duplicate capture group name")
 )
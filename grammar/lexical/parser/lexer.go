@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 )
 
@@ -17,11 +18,15 @@ const (
 	tokenKindOption          tokenKind = "?"
 	tokenKindAlt             tokenKind = "|"
 	tokenKindGroupOpen       tokenKind = "("
+	tokenKindCaptureOpen     tokenKind = "(?<"
 	tokenKindGroupClose      tokenKind = ")"
 	tokenKindBExpOpen        tokenKind = "["
 	tokenKindInverseBExpOpen tokenKind = "[^"
 	tokenKindBExpClose       tokenKind = "]"
 	tokenKindCharRange       tokenKind = "-"
+	tokenKindIntersection    tokenKind = "&&"
+	tokenKindSubtraction     tokenKind = "--"
+	tokenKindPOSIXClass      tokenKind = "POSIX character class"
 	tokenKindCodePointLeader tokenKind = "\\u"
 	tokenKindCharPropLeader  tokenKind = "\\p"
 	tokenKindFragmentLeader  tokenKind = "\\f"
@@ -40,6 +45,8 @@ type token struct {
 	propSymbol     string
 	codePoint      string
 	fragmentSymbol string
+	posixClass     string
+	captureName    string
 }
 
 const nullChar = '\u0000'
@@ -72,6 +79,20 @@ func newFragmentSymbolToken(fragmentSymbol string) *token {
 	}
 }
 
+func newPOSIXClassToken(posixClass string) *token {
+	return &token{
+		kind:       tokenKindPOSIXClass,
+		posixClass: posixClass,
+	}
+}
+
+func newCaptureOpenToken(name string) *token {
+	return &token{
+		kind:        tokenKindCaptureOpen,
+		captureName: name,
+	}
+}
+
 type lexerMode string
 
 const (
@@ -135,6 +156,10 @@ type lexer struct {
 	modeStack  *lexerModeStack
 	rangeState rangeState
 
+	// afterSetOp is true when the last token is a set operator. A `[` following a set operator opens a nested bracket
+	// expression.
+	afterSetOp bool
+
 	errCause  error
 	errDetail string
 }
@@ -176,7 +201,7 @@ func (l *lexer) next() (*token, error) {
 		if err != nil {
 			return nil, err
 		}
-		if tok.kind == tokenKindChar || tok.kind == tokenKindCodePointLeader || tok.kind == tokenKindCharPropLeader {
+		if tok.kind == tokenKindChar || tok.kind == tokenKindCodePointLeader || tok.kind == tokenKindCharPropLeader || tok.kind == tokenKindPOSIXClass {
 			switch l.rangeState {
 			case rangeStateReady:
 				l.rangeState = rangeStateReadRangeInitiator
@@ -184,9 +209,17 @@ func (l *lexer) next() (*token, error) {
 				l.rangeState = rangeStateReady
 			}
 		}
+		l.afterSetOp = tok.kind == tokenKindIntersection || tok.kind == tokenKindSubtraction
 		switch tok.kind {
+		case tokenKindBExpOpen, tokenKindInverseBExpOpen:
+			l.modeStack.push(lexerModeBExp)
+			l.rangeState = rangeStateReady
+		case tokenKindIntersection, tokenKindSubtraction:
+			l.rangeState = rangeStateReady
 		case tokenKindBExpClose:
 			l.modeStack.pop()
+			// A nested bracket expression is an operand of a set operator, so it cannot be the beginning of a range.
+			l.rangeState = rangeStateReady
 		case tokenKindCharRange:
 			l.rangeState = rangeStateExpectRangeTerminator
 		case tokenKindCodePointLeader:
@@ -261,55 +294,11 @@ func (l *lexer) nextInDefault(c rune) (*token, error) {
 	case '|':
 		return newToken(tokenKindAlt, nullChar), nil
 	case '(':
-		return newToken(tokenKindGroupOpen, nullChar), nil
+		return l.nextGroupOpen()
 	case ')':
 		return newToken(tokenKindGroupClose, nullChar), nil
 	case '[':
-		c1, eof, err := l.read()
-		if err != nil {
-			return nil, err
-		}
-		if eof {
-			err := l.restore()
-			if err != nil {
-				return nil, err
-			}
-			return newToken(tokenKindBExpOpen, nullChar), nil
-		}
-		if c1 != '^' {
-			err := l.restore()
-			if err != nil {
-				return nil, err
-			}
-			return newToken(tokenKindBExpOpen, nullChar), nil
-		}
-		c2, eof, err := l.read()
-		if err != nil {
-			return nil, err
-		}
-		if eof {
-			err := l.restore()
-			if err != nil {
-				return nil, err
-			}
-			return newToken(tokenKindInverseBExpOpen, nullChar), nil
-		}
-		if c2 != ']' {
-			err := l.restore()
-			if err != nil {
-				return nil, err
-			}
-			return newToken(tokenKindInverseBExpOpen, nullChar), nil
-		}
-		err = l.restore()
-		if err != nil {
-			return nil, err
-		}
-		err = l.restore()
-		if err != nil {
-			return nil, err
-		}
-		return newToken(tokenKindBExpOpen, nullChar), nil
+		return l.nextBExpOpen()
 	case '\\':
 		c, eof, err := l.read()
 		if err != nil {
@@ -328,6 +317,9 @@ func (l *lexer) nextInDefault(c rune) (*token, error) {
 		if c == 'f' {
 			return newToken(tokenKindFragmentLeader, nullChar), nil
 		}
+		if tok, ok, err := l.nextControlCharEscSeq(c); ok || err != nil {
+			return tok, err
+		}
 		if c == '\\' || c == '.' || c == '*' || c == '+' || c == '?' || c == '|' || c == '(' || c == ')' || c == '[' || c == ']' {
 			return newToken(tokenKindChar, c), nil
 		}
@@ -341,7 +333,21 @@ func (l *lexer) nextInDefault(c rune) (*token, error) {
 
 func (l *lexer) nextInBExp(c rune) (*token, error) {
 	switch c {
-	case '-':
+	case '&', '-':
+		ok, err := l.readSetOperator(c)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if c == '&' {
+				return newToken(tokenKindIntersection, nullChar), nil
+			}
+			return newToken(tokenKindSubtraction, nullChar), nil
+		}
+		if c == '&' {
+			return newToken(tokenKindChar, c), nil
+		}
+
 		if l.rangeState != rangeStateReadRangeInitiator {
 			return newToken(tokenKindChar, c), nil
 		}
@@ -368,6 +374,18 @@ func (l *lexer) nextInBExp(c rune) (*token, error) {
 			return nil, err
 		}
 		return newToken(tokenKindChar, c), nil
+	case '[':
+		tok, err := l.readPOSIXClass()
+		if err != nil {
+			return nil, err
+		}
+		if tok != nil {
+			return tok, nil
+		}
+		if l.afterSetOp {
+			return l.nextBExpOpen()
+		}
+		return newToken(tokenKindChar, c), nil
 	case ']':
 		return newToken(tokenKindBExpClose, nullChar), nil
 	case '\\':
@@ -385,6 +403,9 @@ func (l *lexer) nextInBExp(c rune) (*token, error) {
 		if c == 'p' {
 			return newToken(tokenKindCharPropLeader, nullChar), nil
 		}
+		if tok, ok, err := l.nextControlCharEscSeq(c); ok || err != nil {
+			return tok, err
+		}
 		if c == '\\' || c == '^' || c == '-' || c == ']' {
 			return newToken(tokenKindChar, c), nil
 		}
@@ -396,6 +417,244 @@ func (l *lexer) nextInBExp(c rune) (*token, error) {
 	}
 }
 
+// nextControlCharEscSeq returns a character token of an escape sequence `\n`, `\t`, `\r`, `\0`, or `\xNN` when `c`
+// following `\` begins one of them. `\xNN` represents a character whose code point is the two hex digits NN.
+func (l *lexer) nextControlCharEscSeq(c rune) (*token, bool, error) {
+	switch c {
+	case 'n':
+		return newToken(tokenKindChar, '\n'), true, nil
+	case 't':
+		return newToken(tokenKindChar, '\t'), true, nil
+	case 'r':
+		return newToken(tokenKindChar, '\r'), true, nil
+	case '0':
+		return newToken(tokenKindChar, 0x00), true, nil
+	case 'x':
+		var b strings.Builder
+		for i := 0; i < 2; i++ {
+			c, eof, err := l.read()
+			if err != nil {
+				return nil, false, err
+			}
+			if eof || !strings.ContainsRune("0123456789ABCDEFabcdef", c) {
+				l.errCause = synErrInvalidEscSeq
+				l.errDetail = "\\x needs just 2 hex digits"
+				return nil, false, ParseErr
+			}
+			fmt.Fprint(&b, string(c))
+		}
+		n, err := strconv.ParseUint(b.String(), 16, 8)
+		if err != nil {
+			return nil, false, err
+		}
+		return newToken(tokenKindChar, rune(n)), true, nil
+	}
+	return nil, false, nil
+}
+
+// nextGroupOpen returns a token opening a grouping expression or a capture group `(?<name>`. The lexer has already
+// read `(`.
+func (l *lexer) nextGroupOpen() (*token, error) {
+	c1, eof, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if eof || c1 != '?' {
+		err := l.restore()
+		if err != nil {
+			return nil, err
+		}
+		return newToken(tokenKindGroupOpen, nullChar), nil
+	}
+	c2, eof, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if eof || c2 != '<' {
+		err := l.restore()
+		if err != nil {
+			return nil, err
+		}
+		err = l.restore()
+		if err != nil {
+			return nil, err
+		}
+		return newToken(tokenKindGroupOpen, nullChar), nil
+	}
+	var b strings.Builder
+	for {
+		c, eof, err := l.read()
+		if err != nil {
+			return nil, err
+		}
+		if eof {
+			l.errCause = synErrCaptureInvalidName
+			l.errDetail = "a capture group name must be terminated by >"
+			return nil, ParseErr
+		}
+		if c == '>' {
+			break
+		}
+		b.WriteRune(c)
+	}
+	name := b.String()
+	if !isCaptureName(name) {
+		l.errCause = synErrCaptureInvalidName
+		l.errDetail = fmt.Sprintf("a capture group name must consist of ASCII letters, digits, and underscores and must not start with a digit: '%v'", name)
+		return nil, ParseErr
+	}
+	return newCaptureOpenToken(name), nil
+}
+
+func isCaptureName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// nextBExpOpen returns a token opening a bracket expression. The lexer has already read `[`.
+func (l *lexer) nextBExpOpen() (*token, error) {
+	c1, eof, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if eof {
+		err := l.restore()
+		if err != nil {
+			return nil, err
+		}
+		return newToken(tokenKindBExpOpen, nullChar), nil
+	}
+	if c1 != '^' {
+		err := l.restore()
+		if err != nil {
+			return nil, err
+		}
+		return newToken(tokenKindBExpOpen, nullChar), nil
+	}
+	c2, eof, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if eof {
+		err := l.restore()
+		if err != nil {
+			return nil, err
+		}
+		return newToken(tokenKindInverseBExpOpen, nullChar), nil
+	}
+	if c2 != ']' {
+		err := l.restore()
+		if err != nil {
+			return nil, err
+		}
+		return newToken(tokenKindInverseBExpOpen, nullChar), nil
+	}
+	err = l.restore()
+	if err != nil {
+		return nil, err
+	}
+	err = l.restore()
+	if err != nil {
+		return nil, err
+	}
+	return newToken(tokenKindBExpOpen, nullChar), nil
+}
+
+// readSetOperator reads a set operator `&&` or `--` beginning with a character `c` the lexer has already read. The lexer
+// regards the characters as a set operator only when `[` or `\` follows them, so that bracket expressions like `[--]`
+// keep their meaning. When the characters aren't a set operator, this method reads nothing more.
+func (l *lexer) readSetOperator(c rune) (bool, error) {
+	c1, eof, err := l.read()
+	if err != nil {
+		return false, err
+	}
+	if eof || c1 != c {
+		return false, l.restore()
+	}
+	c2, eof, err := l.read()
+	if err != nil {
+		return false, err
+	}
+	err = l.restore()
+	if err != nil {
+		return false, err
+	}
+	if !eof && (c2 == '[' || c2 == '\\') {
+		return true, nil
+	}
+	return false, l.restore()
+}
+
+// readPOSIXClass reads a POSIX character class like `[:alpha:]`. The lexer has already read `[`. Only when `[:` and
+// a letter follow, the lexer regards the characters as a POSIX character class; otherwise, this method reads nothing
+// more and returns nil.
+func (l *lexer) readPOSIXClass() (*token, error) {
+	c1, eof, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if eof || c1 != ':' {
+		return nil, l.restore()
+	}
+	c2, eof, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if eof || !isLetter(c2) {
+		err := l.restore()
+		if err != nil {
+			return nil, err
+		}
+		return nil, l.restore()
+	}
+	var b strings.Builder
+	fmt.Fprint(&b, string(c2))
+	for {
+		c, eof, err := l.read()
+		if err != nil {
+			return nil, err
+		}
+		if eof {
+			l.errCause = synErrPOSIXClassInvalidForm
+			return nil, ParseErr
+		}
+		if isLetter(c) {
+			fmt.Fprint(&b, string(c))
+			continue
+		}
+		if c != ':' {
+			l.errCause = synErrPOSIXClassInvalidForm
+			l.errDetail = fmt.Sprintf("[:%v%v", b.String(), string(c))
+			return nil, ParseErr
+		}
+		break
+	}
+	c, eof, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if eof || c != ']' {
+		l.errCause = synErrPOSIXClassInvalidForm
+		l.errDetail = fmt.Sprintf("[:%v:", b.String())
+		return nil, ParseErr
+	}
+	return newPOSIXClassToken(b.String()), nil
+}
+
+func isLetter(c rune) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
 func (l *lexer) nextInCodePoint(c rune) (*token, error) {
 	switch c {
 	case '{':
@@ -194,6 +194,84 @@ func TestLexer(t *testing.T) {
 				newToken(tokenKindEOF, nullChar),
 			},
 		},
+		{
+			caption: "[: and a letter begin a POSIX character class in a bracket expression",
+			src:     "[[:alpha:][:Digit:]a][a--[:upper:]][[:][[:-]",
+			tokens: []*token{
+				newToken(tokenKindBExpOpen, nullChar),
+				newPOSIXClassToken("alpha"),
+				newPOSIXClassToken("Digit"),
+				newToken(tokenKindChar, 'a'),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindBExpOpen, nullChar),
+				newToken(tokenKindChar, 'a'),
+				newToken(tokenKindSubtraction, nullChar),
+				newPOSIXClassToken("upper"),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindBExpOpen, nullChar),
+				newToken(tokenKindChar, '['),
+				newToken(tokenKindChar, ':'),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindBExpOpen, nullChar),
+				newToken(tokenKindChar, '['),
+				newToken(tokenKindChar, ':'),
+				newToken(tokenKindChar, '-'),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindEOF, nullChar),
+			},
+		},
+		{
+			caption: "a POSIX character class must be closed with :]",
+			src:     "[[:alpha]",
+			tokens: []*token{
+				newToken(tokenKindBExpOpen, nullChar),
+			},
+			err: synErrPOSIXClassInvalidForm,
+		},
+		{
+			caption: "&& and -- are set operators when [ or \\ follows them",
+			src:     "[a-z--[^aeiou]][a&&\\p{Lu}][&&][a&&b][a--b]",
+			tokens: []*token{
+				newToken(tokenKindBExpOpen, nullChar),
+				newToken(tokenKindChar, 'a'),
+				newToken(tokenKindCharRange, nullChar),
+				newToken(tokenKindChar, 'z'),
+				newToken(tokenKindSubtraction, nullChar),
+				newToken(tokenKindInverseBExpOpen, nullChar),
+				newToken(tokenKindChar, 'a'),
+				newToken(tokenKindChar, 'e'),
+				newToken(tokenKindChar, 'i'),
+				newToken(tokenKindChar, 'o'),
+				newToken(tokenKindChar, 'u'),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindBExpOpen, nullChar),
+				newToken(tokenKindChar, 'a'),
+				newToken(tokenKindIntersection, nullChar),
+				newToken(tokenKindCharPropLeader, nullChar),
+				newToken(tokenKindLBrace, nullChar),
+				newCharPropSymbolToken("Lu"),
+				newToken(tokenKindRBrace, nullChar),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindBExpOpen, nullChar),
+				newToken(tokenKindChar, '&'),
+				newToken(tokenKindChar, '&'),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindBExpOpen, nullChar),
+				newToken(tokenKindChar, 'a'),
+				newToken(tokenKindChar, '&'),
+				newToken(tokenKindChar, '&'),
+				newToken(tokenKindChar, 'b'),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindBExpOpen, nullChar),
+				newToken(tokenKindChar, 'a'),
+				newToken(tokenKindCharRange, nullChar),
+				newToken(tokenKindChar, '-'),
+				newToken(tokenKindChar, 'b'),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindEOF, nullChar),
+			},
+		},
 		{
 			caption: "caret symbols that appear in bracket expressions are handled as the logical inverse symbol or ordinary characters",
 			// [^...^...][^]
@@ -218,6 +296,29 @@ func TestLexer(t *testing.T) {
 				newToken(tokenKindEOF, nullChar),
 			},
 		},
+		{
+			caption: "lexer can recognize the escape sequences of control characters",
+			src:     "\\n\\t\\r\\0\\x41[\\n\\x00-\\x1f]",
+			tokens: []*token{
+				newToken(tokenKindChar, '\n'),
+				newToken(tokenKindChar, '\t'),
+				newToken(tokenKindChar, '\r'),
+				newToken(tokenKindChar, 0x00),
+				newToken(tokenKindChar, 'A'),
+				newToken(tokenKindBExpOpen, nullChar),
+				newToken(tokenKindChar, '\n'),
+				newToken(tokenKindChar, 0x00),
+				newToken(tokenKindCharRange, nullChar),
+				newToken(tokenKindChar, 0x1f),
+				newToken(tokenKindBExpClose, nullChar),
+				newToken(tokenKindEOF, nullChar),
+			},
+		},
+		{
+			caption: "\\x needs just 2 hex digits",
+			src:     "\\x4g",
+			err:     synErrInvalidEscSeq,
+		},
 		{
 			caption: "lexer raises an error when an invalid escape sequence appears",
 			src:     "\\@",
@@ -40,6 +40,12 @@ type parser struct {
 	// https://unicode.org/reports/tr44/#Property_APIs
 	isContributoryPropertyExposed bool
 
+	// When dotExcludesNewline is true, `.` matches any character except a newline (U+000A).
+	dotExcludesNewline bool
+
+	// captureNames holds the names of capture groups in the order their opening parentheses appear.
+	captureNames []string
+
 	errCause  error
 	errDetail string
 }
@@ -56,6 +62,11 @@ func (p *parser) exposeContributoryProperty() {
 	p.isContributoryPropertyExposed = true
 }
 
+// ExcludeNewlineFromDot makes `.` match any character except a newline (U+000A).
+func (p *parser) ExcludeNewlineFromDot() {
+	p.dotExcludesNewline = true
+}
+
 func (p *parser) Error() (string, error) {
 	return p.errDetail, p.errCause
 }
@@ -73,7 +84,9 @@ func (p *parser) Parse() (root CPTree, retErr error) {
 		}
 	}()
 
-	return newRootNode(p.kind, p.parseRegexp()), nil
+	r := newRootNode(p.kind, p.parseRegexp())
+	r.captures = p.captureNames
+	return r, nil
 }
 
 func (p *parser) parseRegexp() CPTree {
@@ -151,7 +164,26 @@ func (p *parser) parseRepeat() CPTree {
 }
 
 func (p *parser) parseGroup() CPTree {
+	if p.consume(tokenKindCaptureOpen) {
+		name := p.lastTok.captureName
+		for _, n := range p.captureNames {
+			if n == name {
+				p.raiseParseError(synErrCaptureDuplicateName, name)
+			}
+		}
+		index := len(p.captureNames)
+		p.captureNames = append(p.captureNames, name)
+		return newCaptureNode(index, name, p.parseGroupBody())
+	}
 	if p.consume(tokenKindGroupOpen) {
+		return p.parseGroupBody()
+	}
+	return p.parseSingleChar()
+}
+
+// parseGroupBody parses the rest of a grouping expression following its opening parenthesis.
+func (p *parser) parseGroupBody() CPTree {
+	{
 		alt := p.parseAlt()
 		if alt == nil {
 			if p.consume(tokenKindEOF) {
@@ -167,61 +199,20 @@ func (p *parser) parseGroup() CPTree {
 		}
 		return alt
 	}
-	return p.parseSingleChar()
 }
 
 func (p *parser) parseSingleChar() CPTree {
 	if p.consume(tokenKindAnyChar) {
+		if p.dotExcludesNewline {
+			return exclude(newSymbolNode('\n'), genAnyCharAST())
+		}
 		return genAnyCharAST()
 	}
 	if p.consume(tokenKindBExpOpen) {
-		left := p.parseBExpElem()
-		if left == nil {
-			if p.consume(tokenKindEOF) {
-				p.raiseParseError(synErrBExpUnclosed, "")
-			}
-			p.raiseParseError(synErrBExpNoElem, "")
-		}
-		for {
-			right := p.parseBExpElem()
-			if right == nil {
-				break
-			}
-			left = newAltNode(left, right)
-		}
-		if p.consume(tokenKindEOF) {
-			p.raiseParseError(synErrBExpUnclosed, "")
-		}
-		p.expect(tokenKindBExpClose)
-		return left
+		return p.parseBExp(false)
 	}
 	if p.consume(tokenKindInverseBExpOpen) {
-		elem := p.parseBExpElem()
-		if elem == nil {
-			if p.consume(tokenKindEOF) {
-				p.raiseParseError(synErrBExpUnclosed, "")
-			}
-			p.raiseParseError(synErrBExpNoElem, "")
-		}
-		inverse := exclude(elem, genAnyCharAST())
-		if inverse == nil {
-			p.raiseParseError(synErrUnmatchablePattern, "")
-		}
-		for {
-			elem := p.parseBExpElem()
-			if elem == nil {
-				break
-			}
-			inverse = exclude(elem, inverse)
-			if inverse == nil {
-				p.raiseParseError(synErrUnmatchablePattern, "")
-			}
-		}
-		if p.consume(tokenKindEOF) {
-			p.raiseParseError(synErrBExpUnclosed, "")
-		}
-		p.expect(tokenKindBExpClose)
-		return inverse
+		return p.parseBExp(true)
 	}
 	if p.consume(tokenKindCodePointLeader) {
 		return p.parseCodePoint()
@@ -242,6 +233,74 @@ func (p *parser) parseSingleChar() CPTree {
 	return c
 }
 
+// parseBExp parses the rest of a bracket expression following `[` or `[^`. The elements of a bracket expression form
+// a union, and set operators `&&` (intersection) and `--` (subtraction) combine it with their right operands from left
+// to right. An inverse bracket expression matches the characters that the whole expression doesn't match.
+func (p *parser) parseBExp(inverse bool) CPTree {
+	set := p.parseBExpElem()
+	if set == nil {
+		if p.consume(tokenKindEOF) {
+			p.raiseParseError(synErrBExpUnclosed, "")
+		}
+		p.raiseParseError(synErrBExpNoElem, "")
+	}
+	for {
+		elem := p.parseBExpElem()
+		if elem == nil {
+			break
+		}
+		set = newAltNode(set, elem)
+	}
+	for {
+		switch {
+		case p.consume(tokenKindIntersection):
+			operand := p.parseSetOperand()
+			// A ∩ B = A - (A - B)
+			diff := exclude(operand, set)
+			if diff != nil {
+				set = exclude(diff, set)
+			}
+		case p.consume(tokenKindSubtraction):
+			operand := p.parseSetOperand()
+			set = exclude(operand, set)
+		default:
+			if p.consume(tokenKindEOF) {
+				p.raiseParseError(synErrBExpUnclosed, "")
+			}
+			p.expect(tokenKindBExpClose)
+			if inverse {
+				set = exclude(set, genAnyCharAST())
+				if set == nil {
+					p.raiseParseError(synErrUnmatchablePattern, "")
+				}
+			}
+			return set
+		}
+		if set == nil {
+			p.raiseParseError(synErrUnmatchablePattern, "")
+		}
+	}
+}
+
+// parseSetOperand parses a right operand of a set operator. It must be a bracket expression, a character property
+// expression, or a code point expression.
+func (p *parser) parseSetOperand() CPTree {
+	switch {
+	case p.consume(tokenKindBExpOpen):
+		return p.parseBExp(false)
+	case p.consume(tokenKindInverseBExpOpen):
+		return p.parseBExp(true)
+	case p.consume(tokenKindCharPropLeader):
+		return p.parseCharProp()
+	case p.consume(tokenKindCodePointLeader):
+		return p.parseCodePoint()
+	case p.consume(tokenKindPOSIXClass):
+		return p.parsePOSIXClass()
+	}
+	p.raiseParseError(synErrSetOpInvalidOperand, "")
+	return nil
+}
+
 func (p *parser) parseBExpElem() CPTree {
 	var left CPTree
 	switch {
@@ -252,6 +311,11 @@ func (p *parser) parseBExpElem() CPTree {
 		if p.consume(tokenKindCharRange) {
 			p.raiseParseError(synErrRangePropIsUnavailable, "")
 		}
+	case p.consume(tokenKindPOSIXClass):
+		left = p.parsePOSIXClass()
+		if p.consume(tokenKindCharRange) {
+			p.raiseParseError(synErrRangePropIsUnavailable, "")
+		}
 	default:
 		left = p.parseNormalChar()
 	}
@@ -265,7 +329,7 @@ func (p *parser) parseBExpElem() CPTree {
 	switch {
 	case p.consume(tokenKindCodePointLeader):
 		right = p.parseCodePoint()
-	case p.consume(tokenKindCharPropLeader):
+	case p.consume(tokenKindCharPropLeader), p.consume(tokenKindPOSIXClass):
 		p.raiseParseError(synErrRangePropIsUnavailable, "")
 	default:
 		right = p.parseNormalChar()
@@ -380,6 +444,20 @@ func (p *parser) parseCharProp() CPTree {
 	return alt
 }
 
+// parsePOSIXClass converts a POSIX character class into the tree of the equivalent pattern.
+func (p *parser) parsePOSIXClass() CPTree {
+	name := p.lastTok.posixClass
+	pat, ok := posixClassPatterns[name]
+	if !ok {
+		p.raiseParseError(synErrPOSIXClassUnsupported, name)
+	}
+	ast, err := NewParser(p.kind, bytes.NewReader([]byte(pat))).Parse()
+	if err != nil {
+		panic(err)
+	}
+	return ast.(*rootNode).tree
+}
+
 func (p *parser) parseFragment() CPTree {
 	if !p.consume(tokenKindLBrace) {
 		p.raiseParseError(synErrFragmentExpInvalidForm, "")
@@ -481,6 +481,86 @@ func TestParse(t *testing.T) {
 			pattern:     "[^\\u{0000}-\\u{FFFF}\\u{010000}-\\u{10FFFF}]",
 			syntaxError: synErrUnmatchablePattern,
 		},
+		{
+			pattern: "[a-e--[b-d]]",
+			ast: genAltNode(
+				newRangeSymbolNode('a', 'a'),
+				newRangeSymbolNode('e', 'e'),
+			),
+		},
+		{
+			pattern: "[a-e&&[c-z]]",
+			ast:     newRangeSymbolNode('c', 'e'),
+		},
+		{
+			pattern: "[a-z--[b-y]--\\u{007A}]",
+			ast:     newRangeSymbolNode('a', 'a'),
+		},
+		{
+			pattern: "[^\\u{0001}-\\u{10FFFF}--[a-z]]",
+			ast: genAltNode(
+				newRangeSymbolNode(0x00, 0x00),
+				newRangeSymbolNode('a', 'z'),
+			),
+		},
+		{
+			pattern:     "[a-z--\\p{Lu}]",
+			skipTestAST: true,
+		},
+		{
+			pattern: "[[:xdigit:]]",
+			ast: genAltNode(
+				newRangeSymbolNode('0', '9'),
+				newRangeSymbolNode('A', 'F'),
+				newRangeSymbolNode('a', 'f'),
+			),
+		},
+		{
+			pattern: "[_[:xdigit:]--[a-z]]",
+			ast: genAltNode(
+				newSymbolNode('_'),
+				genAltNode(
+					newRangeSymbolNode('0', '9'),
+					newRangeSymbolNode('A', 'F'),
+				),
+			),
+		},
+		{
+			pattern:     "[[:alpha:]&&[^aeiou]]",
+			skipTestAST: true,
+		},
+		{
+			pattern:     "[[:foo:]]",
+			syntaxError: synErrPOSIXClassUnsupported,
+		},
+		{
+			pattern:     "[[:digit:]-z]",
+			syntaxError: synErrRangePropIsUnavailable,
+		},
+		{
+			pattern:     "[a-[:digit:]]",
+			syntaxError: synErrRangePropIsUnavailable,
+		},
+		{
+			pattern:     "[a--[a]]",
+			syntaxError: synErrUnmatchablePattern,
+		},
+		{
+			pattern:     "[a&&[b]]",
+			syntaxError: synErrUnmatchablePattern,
+		},
+		{
+			pattern:     "[a--\\]]",
+			syntaxError: synErrSetOpInvalidOperand,
+		},
+		{
+			pattern:     "[a-z--[b]",
+			syntaxError: synErrBExpUnclosed,
+		},
+		{
+			pattern:     "[a-z--[b]c]",
+			syntaxError: synErrUnexpectedToken,
+		},
 		{
 			pattern: "[^]",
 			ast:     newSymbolNode('^'),
@@ -779,6 +859,52 @@ func TestParse(t *testing.T) {
 			pattern: "(((a)))",
 			ast:     newSymbolNode('a'),
 		},
+		{
+			pattern: "(?<int>[0-9])(?<suffix>u?)",
+			ast: genConcatNode(
+				newCaptureNode(0, "int", newRangeSymbolNode('0', '9')),
+				newCaptureNode(1, "suffix", newOptionNode(newSymbolNode('u'))),
+			),
+		},
+		{
+			pattern: "(?<a>x(?<b_1>y))",
+			ast: newCaptureNode(0, "a", genConcatNode(
+				newSymbolNode('x'),
+				newCaptureNode(1, "b_1", newSymbolNode('y')),
+			)),
+		},
+		{
+			pattern:     "(?x)",
+			syntaxError: synErrRepNoTarget,
+		},
+		{
+			pattern:     "(?<a>)",
+			syntaxError: synErrGroupNoElem,
+		},
+		{
+			pattern:     "(?<a>x",
+			syntaxError: synErrGroupUnclosed,
+		},
+		{
+			pattern:     "(?<>x)",
+			syntaxError: synErrCaptureInvalidName,
+		},
+		{
+			pattern:     "(?<1a>x)",
+			syntaxError: synErrCaptureInvalidName,
+		},
+		{
+			pattern:     "(?<a-b>x)",
+			syntaxError: synErrCaptureInvalidName,
+		},
+		{
+			pattern:     "(?<a",
+			syntaxError: synErrCaptureInvalidName,
+		},
+		{
+			pattern:     "(?<a>x)(?<a>y)",
+			syntaxError: synErrCaptureDuplicateName,
+		},
 		{
 			pattern:     "a()",
 			syntaxError: synErrGroupNoElem,
@@ -1381,9 +1507,34 @@ func testAST(t *testing.T, expected, actual CPTree) {
 		if a.From != e.From || a.To != e.To {
 			t.Fatalf("unexpected node: want: %+v, got: %+v", e, a)
 		}
+	case *captureNode:
+		a := actual.(*captureNode)
+		if a.index != e.index || a.name != e.name {
+			t.Fatalf("unexpected node: want: %+v, got: %+v", e, a)
+		}
 	}
 	eLeft, eRight := expected.children()
 	aLeft, aRight := actual.children()
 	testAST(t, eLeft, aLeft)
 	testAST(t, eRight, aRight)
 }
+
+func FuzzPatternParse(f *testing.F) {
+	for _, pattern := range []string{
+		`a|b*c+d?`,
+		`(ab){2,3}[^a-z\u{3042}]`,
+		`\p{Letter}\P{Nd}\p{gc=Lu}\f{frag}`,
+		`[[:alpha:]-_.]+\.[0-9]{0,}`,
+		`(?<name>[a-z]+)=(?<value>"[^"]*")`,
+		`\u{10FFFF}|\x{7F}|\\\*\u{0}`,
+		`\p{Script=Hiragana}[\u{0020}-\u{007E}]`,
+	} {
+		f.Add(pattern)
+	}
+
+	f.Fuzz(func(t *testing.T, pattern string) {
+		// The parser must return an error instead of panicking on any input.
+		p := NewParser(spec.LexKindName("test"), strings.NewReader(pattern))
+		_, _ = p.Parse()
+	})
+}
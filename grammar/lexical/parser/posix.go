@@ -0,0 +1,22 @@
+package parser
+
+// posixClassPatterns maps the names of POSIX character classes to patterns consisting of character property
+// expressions. The mapping follows the recommendation for Unicode in [UTS #18 Annex C Compatibility Properties].
+// [:xdigit:] matches only ASCII hex digits because vartan doesn't support `Hex_Digit` property.
+//
+// [UTS #18 Annex C Compatibility Properties]: https://unicode.org/reports/tr18/#Compatibility_Properties
+var posixClassPatterns = map[string]string{
+	"alpha":  `[\p{Alphabetic=yes}]`,
+	"lower":  `[\p{Lowercase=yes}]`,
+	"upper":  `[\p{Uppercase=yes}]`,
+	"digit":  `[\p{gc=Nd}]`,
+	"xdigit": `[0-9A-Fa-f]`,
+	"alnum":  `[\p{Alphabetic=yes}\p{gc=Nd}]`,
+	"punct":  `[\p{gc=P}]`,
+	"space":  `[\p{White_Space=yes}]`,
+	"blank":  `[\p{gc=Zs}\u{0009}]`,
+	"cntrl":  `[\p{gc=Cc}]`,
+	"graph":  `[^\p{White_Space=yes}\p{gc=Cc}\p{gc=Cs}\p{gc=Cn}]`,
+	"print":  `[^\p{gc=Zl}\p{gc=Zp}\p{gc=Cc}\p{gc=Cs}\p{gc=Cn}]`,
+	"word":   `[\p{Alphabetic=yes}\p{gc=Mn}\p{gc=Mc}\p{gc=Me}\p{gc=Nd}\p{gc=Pc}]`,
+}
@@ -20,6 +20,7 @@ type CPTree interface {
 	Repeatable() (CPTree, bool)
 	Concatenation() (CPTree, CPTree, bool)
 	Alternatives() (CPTree, CPTree, bool)
+	Capture() (int, CPTree, bool)
 	Describe() (spec.LexKindName, []spec.LexKindName, error)
 
 	children() (CPTree, CPTree)
@@ -33,12 +34,16 @@ var (
 	_ CPTree = &altNode{}
 	_ CPTree = &quantifierNode{}
 	_ CPTree = &fragmentNode{}
+	_ CPTree = &captureNode{}
 )
 
 type rootNode struct {
 	kind      spec.LexKindName
 	tree      CPTree
 	fragments map[spec.LexKindName][]*fragmentNode
+
+	// captures holds the names of the capture groups in the tree indexed by their indexes.
+	captures []string
 }
 
 func newRootNode(kind spec.LexKindName, t CPTree) *rootNode {
@@ -91,6 +96,10 @@ func (n *rootNode) Alternatives() (CPTree, CPTree, bool) {
 	return n.tree.Alternatives()
 }
 
+func (n *rootNode) Capture() (int, CPTree, bool) {
+	return n.tree.Capture()
+}
+
 func (n *rootNode) Describe() (spec.LexKindName, []spec.LexKindName, error) {
 	var frags []spec.LexKindName
 	for f := range n.fragments {
@@ -182,6 +191,10 @@ func (n *symbolNode) Alternatives() (CPTree, CPTree, bool) {
 	return nil, nil, false
 }
 
+func (n *symbolNode) Capture() (int, CPTree, bool) {
+	return 0, nil, false
+}
+
 func (n *symbolNode) Describe() (spec.LexKindName, []spec.LexKindName, error) {
 	return spec.LexKindNameNil, nil, fmt.Errorf("%T cannot describe", n)
 }
@@ -230,6 +243,10 @@ func (n *concatNode) Alternatives() (CPTree, CPTree, bool) {
 	return nil, nil, false
 }
 
+func (n *concatNode) Capture() (int, CPTree, bool) {
+	return 0, nil, false
+}
+
 func (n *concatNode) Describe() (spec.LexKindName, []spec.LexKindName, error) {
 	return spec.LexKindNameNil, nil, fmt.Errorf("%T cannot describe", n)
 }
@@ -281,6 +298,10 @@ func (n *altNode) Alternatives() (CPTree, CPTree, bool) {
 	return n.left, n.right, true
 }
 
+func (n *altNode) Capture() (int, CPTree, bool) {
+	return 0, nil, false
+}
+
 func (n *altNode) Describe() (spec.LexKindName, []spec.LexKindName, error) {
 	return spec.LexKindNameNil, nil, fmt.Errorf("%T cannot describe", n)
 }
@@ -353,6 +374,10 @@ func (n *quantifierNode) Alternatives() (CPTree, CPTree, bool) {
 	return nil, nil, false
 }
 
+func (n *quantifierNode) Capture() (int, CPTree, bool) {
+	return 0, nil, false
+}
+
 func (n *quantifierNode) Describe() (spec.LexKindName, []spec.LexKindName, error) {
 	return spec.LexKindNameNil, nil, fmt.Errorf("%T cannot describe", n)
 }
@@ -404,6 +429,10 @@ func (n *fragmentNode) Alternatives() (CPTree, CPTree, bool) {
 	return n.tree.Alternatives()
 }
 
+func (n *fragmentNode) Capture() (int, CPTree, bool) {
+	return n.tree.Capture()
+}
+
 func (n *fragmentNode) Describe() (spec.LexKindName, []spec.LexKindName, error) {
 	return spec.LexKindNameNil, nil, fmt.Errorf("%T cannot describe", n)
 }
@@ -419,6 +448,72 @@ func (n *fragmentNode) clone() CPTree {
 	return newFragmentNode(n.kind, n.tree.clone())
 }
 
+// captureNode is a capture group `(?<name>...)`. Its index is the order in which its opening parenthesis appears in
+// a pattern.
+type captureNode struct {
+	index int
+	name  string
+	tree  CPTree
+}
+
+func newCaptureNode(index int, name string, t CPTree) *captureNode {
+	return &captureNode{
+		index: index,
+		name:  name,
+		tree:  t,
+	}
+}
+
+func (n *captureNode) String() string {
+	return fmt.Sprintf("capture: %v (%v)", n.name, n.index)
+}
+
+func (n *captureNode) Range() (rune, rune, bool) {
+	return 0, 0, false
+}
+
+func (n *captureNode) Optional() (CPTree, bool) {
+	return nil, false
+}
+
+func (n *captureNode) Repeatable() (CPTree, bool) {
+	return nil, false
+}
+
+func (n *captureNode) Concatenation() (CPTree, CPTree, bool) {
+	return nil, nil, false
+}
+
+func (n *captureNode) Alternatives() (CPTree, CPTree, bool) {
+	return nil, nil, false
+}
+
+func (n *captureNode) Capture() (int, CPTree, bool) {
+	return n.index, n.tree, true
+}
+
+func (n *captureNode) Describe() (spec.LexKindName, []spec.LexKindName, error) {
+	return spec.LexKindNameNil, nil, fmt.Errorf("%T cannot describe", n)
+}
+
+func (n *captureNode) children() (CPTree, CPTree) {
+	return n.tree, nil
+}
+
+func (n *captureNode) clone() CPTree {
+	return newCaptureNode(n.index, n.name, n.tree.clone())
+}
+
+// CaptureNames returns the names of the capture groups in a tree that Parse returns. The index of each name is the
+// index of the capture group.
+func CaptureNames(t CPTree) []string {
+	root, ok := t.(*rootNode)
+	if !ok {
+		return nil
+	}
+	return root.captures
+}
+
 //nolint:unused
 func printCPTree(w io.Writer, t CPTree, ruledLine string, childRuledLinePrefix string) {
 	if t == nil {
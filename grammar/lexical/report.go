@@ -0,0 +1,214 @@
+package lexical
+
+import (
+	"strconv"
+
+	"github.com/nihei9/vartan/grammar/lexical/dfa"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+// genModeReport reports the number of states of the DFA of a lex mode and how the DFA recognizes each kind.
+func genModeReport(modeName spec.LexModeName, d *dfa.DFA, entries []*LexEntry) *spec.LexModeReport {
+	kindNames := make([]spec.LexKindName, len(entries)+1)
+	for i, e := range entries {
+		kindNames[i+1] = e.Kind
+	}
+
+	// rev holds the transitions of the DFA in reverse to find the states reaching accepting states.
+	rev := map[string][]string{}
+	for from, tab := range d.TransitionTable {
+		for _, to := range tab {
+			if to == "" {
+				continue
+			}
+			rev[to] = append(rev[to], from)
+		}
+	}
+
+	r := &spec.LexModeReport{
+		Name:       modeName.String(),
+		StateCount: len(d.States),
+	}
+	for i, e := range entries {
+		if e.Keyword {
+			continue
+		}
+		id := spec.LexModeKindID(i + 1)
+
+		var accepting []string
+		shadowedBy := map[spec.LexModeKindID]struct{}{}
+		for _, s := range d.States {
+			ids, ok := d.MatchingKindsTable[s]
+			if !ok || !containsKind(ids, id) {
+				continue
+			}
+			if winner := d.AcceptingStatesTable[s]; winner != id {
+				shadowedBy[winner] = struct{}{}
+				continue
+			}
+			accepting = append(accepting, s)
+		}
+
+		kr := &spec.LexKindReport{
+			Name:                e.Kind.String(),
+			AcceptingStateCount: len(accepting),
+			ReachingStateCount:  countReachingStates(accepting, rev),
+		}
+		for winner := spec.LexModeKindIDMin; int(winner) < len(kindNames); winner++ {
+			if _, ok := shadowedBy[winner]; ok {
+				kr.ShadowedBy = append(kr.ShadowedBy, kindNames[winner].String())
+			}
+		}
+		r.Kinds = append(r.Kinds, kr)
+	}
+	r.Backtracks = genBacktrackReports(d, kindNames)
+	return r
+}
+
+// byteOrder is the order in which the analysis tries bytes. It tries printable ASCII characters first to make
+// examples readable.
+var byteOrder = func() []int {
+	order := make([]int, 0, 256)
+	for b := 0x20; b <= 0x7e; b++ {
+		order = append(order, b)
+	}
+	for b := 0; b < 256; b++ {
+		if b < 0x20 || b > 0x7e {
+			order = append(order, b)
+		}
+	}
+	return order
+}()
+
+// genBacktrackReports finds the accepting states of a DFA that have transitions to non-accepting states. After reaching
+// such a state, the lexer may read bytes beyond an acceptable lexeme, fail to reach another accepting state, and go
+// back to the end of the lexeme. The number of bytes the lexer reads beyond a lexeme is unbounded when the non-accepting
+// states following an accepting state form a cycle.
+func genBacktrackReports(d *dfa.DFA, kindNames []spec.LexKindName) []*spec.LexBacktrackReport {
+	isAccepting := func(s string) bool {
+		_, ok := d.AcceptingStatesTable[s]
+		return ok
+	}
+
+	// Find the shortest input reaching each state.
+	inputs := map[string][]byte{
+		d.InitialState: {},
+	}
+	var order []string
+	queue := []string{d.InitialState}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		order = append(order, s)
+		tab := d.TransitionTable[s]
+		for _, b := range byteOrder {
+			next := tab[b]
+			if next == "" {
+				continue
+			}
+			if _, ok := inputs[next]; ok {
+				continue
+			}
+			inputs[next] = append(append([]byte{}, inputs[s]...), byte(b))
+			queue = append(queue, next)
+		}
+	}
+
+	// longest holds the number of bytes the lexer can read from a non-accepting state without reaching accepting states.
+	// -1 means the number is unbounded.
+	const visiting = -2
+	longest := map[string]int{}
+	var walk func(s string) int
+	walk = func(s string) int {
+		if n, ok := longest[s]; ok {
+			if n == visiting {
+				return -1
+			}
+			return n
+		}
+		longest[s] = visiting
+		n := 1
+		for _, next := range d.TransitionTable[s] {
+			if next == "" || isAccepting(next) {
+				continue
+			}
+			m := walk(next)
+			if m < 0 {
+				n = -1
+				break
+			}
+			if m+1 > n {
+				n = m + 1
+			}
+		}
+		longest[s] = n
+		return n
+	}
+
+	reports := map[spec.LexModeKindID]*spec.LexBacktrackReport{}
+	for _, s := range order {
+		id, ok := d.AcceptingStatesTable[s]
+		if !ok {
+			continue
+		}
+		tab := d.TransitionTable[s]
+		for _, b := range byteOrder {
+			next := tab[b]
+			if next == "" || isAccepting(next) {
+				continue
+			}
+			n := walk(next)
+			r, ok := reports[id]
+			if !ok {
+				reports[id] = &spec.LexBacktrackReport{
+					Kind:      kindNames[id].String(),
+					Lexeme:    strconv.Quote(string(inputs[s])),
+					Beyond:    strconv.Quote(string([]byte{byte(b)})),
+					MaxRescan: n,
+				}
+				continue
+			}
+			if r.MaxRescan >= 0 && (n < 0 || n > r.MaxRescan) {
+				r.MaxRescan = n
+			}
+		}
+	}
+
+	var rs []*spec.LexBacktrackReport
+	for id := spec.LexModeKindIDMin; int(id) < len(kindNames); id++ {
+		if r, ok := reports[id]; ok {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+func containsKind(ids []spec.LexModeKindID, id spec.LexModeKindID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// countReachingStates counts the states from which a DFA can reach any of `targets`, including the targets themselves.
+func countReachingStates(targets []string, rev map[string][]string) int {
+	visited := map[string]struct{}{}
+	queue := append([]string{}, targets...)
+	for _, s := range targets {
+		visited[s] = struct{}{}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, prev := range rev[s] {
+			if _, ok := visited[prev]; ok {
+				continue
+			}
+			visited[prev] = struct{}{}
+			queue = append(queue, prev)
+		}
+	}
+	return len(visited)
+}
@@ -10,15 +10,21 @@ import (
 type lr0Automaton struct {
 	initialState kernelID
 	states       map[kernelID]*lrState
+
+	// entryStates maps the augmented start symbols of additional entry points to their initial states.
+	entryStates map[symbol.Symbol]kernelID
 }
 
-func genLR0Automaton(prods *productionSet, startSym symbol.Symbol, errSym symbol.Symbol) (*lr0Automaton, error) {
+// genLR0Automaton generates an LR(0) automaton. In addition to the initial state corresponding to the start symbol,
+// the automaton has an initial state for each augmented start symbol of an additional entry point `entryStartSyms`.
+func genLR0Automaton(prods *productionSet, startSym symbol.Symbol, errSym symbol.Symbol, entryStartSyms ...symbol.Symbol) (*lr0Automaton, error) {
 	if !startSym.IsStart() {
 		return nil, fmt.Errorf("passed symbold is not a start symbol")
 	}
 
 	automaton := &lr0Automaton{
-		states: map[kernelID]*lrState{},
+		states:      map[kernelID]*lrState{},
+		entryStates: map[symbol.Symbol]kernelID{},
 	}
 
 	currentState := stateNumInitial
@@ -42,6 +48,29 @@ func genLR0Automaton(prods *productionSet, startSym symbol.Symbol, errSym symbol
 		knownKernels[k.id] = struct{}{}
 		uncheckedKernels = append(uncheckedKernels, k)
 	}
+	for _, sym := range entryStartSyms {
+		prods, ok := prods.findByLHS(sym)
+		if !ok {
+			return nil, fmt.Errorf("the production of an entry point was not found: %v", sym)
+		}
+		item, err := newLR0Item(prods[0], 0)
+		if err != nil {
+			return nil, err
+		}
+		// Like the initial item of the start symbol, the initial item of an entry point is a kernel item even though
+		// its dot is at the beginning.
+		item.initial = true
+		item.kernel = true
+
+		k, err := newKernel([]*lrItem{item})
+		if err != nil {
+			return nil, err
+		}
+
+		automaton.entryStates[sym] = k.id
+		knownKernels[k.id] = struct{}{}
+		uncheckedKernels = append(uncheckedKernels, k)
+	}
 
 	for len(uncheckedKernels) > 0 {
 		nextUncheckedKernels := []*kernel{}
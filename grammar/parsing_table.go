@@ -75,6 +75,9 @@ const (
 	ResolvedByAssoc     conflictResolutionMethod = 2
 	ResolvedByShift     conflictResolutionMethod = 3
 	ResolvedByProdOrder conflictResolutionMethod = 4
+
+	// ResolvedByPreference means that a `#prefer` directive of an alternative chose the action.
+	ResolvedByPreference conflictResolutionMethod = 5
 )
 
 type conflict interface {
@@ -156,6 +159,10 @@ type lrTableBuilder struct {
 	precAndAssoc *precAndAssoc
 
 	conflicts []conflict
+
+	// errorEntries holds the entries that non-associative operators make error actions. Other actions can't
+	// overwrite the entries.
+	errorEntries map[[2]int]struct{}
 }
 
 func (b *lrTableBuilder) build() (*ParsingTable, error) {
@@ -228,6 +235,9 @@ func (b *lrTableBuilder) build() (*ParsingTable, error) {
 // writeShiftAction writes a shift action to the parsing table. When a shift/reduce conflict occurred,
 // we prioritize the shift action.
 func (b *lrTableBuilder) writeShiftAction(tab *ParsingTable, state stateNum, sym symbol.Symbol, nextState stateNum) {
+	if b.isErrorEntry(state, sym) {
+		return
+	}
 	act := tab.readAction(state.Int(), sym.Num().Int())
 	if !act.isEmpty() {
 		ty, _, p := act.describe()
@@ -240,8 +250,11 @@ func (b *lrTableBuilder) writeShiftAction(tab *ParsingTable, state stateNum, sym
 				prodNum:    p,
 				resolvedBy: method,
 			})
-			if act == ActionTypeShift {
+			switch act {
+			case ActionTypeShift:
 				tab.writeAction(state.Int(), sym.Num().Int(), newShiftActionEntry(nextState))
+			case ActionTypeError:
+				b.writeErrorAction(tab, state, sym)
 			}
 			return
 		}
@@ -253,6 +266,9 @@ func (b *lrTableBuilder) writeShiftAction(tab *ParsingTable, state stateNum, sym
 // we prioritize the shift action, and when a reduce/reduce conflict we prioritize the action that reduces
 // the production with higher priority. Productions defined earlier in the grammar file have a higher priority.
 func (b *lrTableBuilder) writeReduceAction(tab *ParsingTable, state stateNum, sym symbol.Symbol, prod productionNum) {
+	if b.isErrorEntry(state, sym) {
+		return
+	}
 	act := tab.readAction(state.Int(), sym.Num().Int())
 	if !act.isEmpty() {
 		ty, s, p := act.describe()
@@ -283,8 +299,11 @@ func (b *lrTableBuilder) writeReduceAction(tab *ParsingTable, state stateNum, sy
 				prodNum:    prod,
 				resolvedBy: method,
 			})
-			if act == ActionTypeReduce {
+			switch act {
+			case ActionTypeReduce:
 				tab.writeAction(state.Int(), sym.Num().Int(), newReduceActionEntry(prod))
+			case ActionTypeError:
+				b.writeErrorAction(tab, state, sym)
 			}
 		}
 		return
@@ -292,18 +311,39 @@ func (b *lrTableBuilder) writeReduceAction(tab *ParsingTable, state stateNum, sy
 	tab.writeAction(state.Int(), sym.Num().Int(), newReduceActionEntry(prod))
 }
 
+// writeErrorAction makes an entry of the parsing table an error action. The parser reports a syntax error when it
+// reads the symbol in the state.
+func (b *lrTableBuilder) writeErrorAction(tab *ParsingTable, state stateNum, sym symbol.Symbol) {
+	tab.writeAction(state.Int(), sym.Num().Int(), actionEntryEmpty)
+	if b.errorEntries == nil {
+		b.errorEntries = map[[2]int]struct{}{}
+	}
+	b.errorEntries[[2]int{state.Int(), sym.Num().Int()}] = struct{}{}
+}
+
+func (b *lrTableBuilder) isErrorEntry(state stateNum, sym symbol.Symbol) bool {
+	_, ok := b.errorEntries[[2]int{state.Int(), sym.Num().Int()}]
+	return ok
+}
+
 func (b *lrTableBuilder) resolveSRConflict(sym symbol.SymbolNum, prod productionNum) (ActionType, conflictResolutionMethod) {
+	if act, ok := b.precAndAssoc.productionPreference(prod); ok {
+		return act, ResolvedByPreference
+	}
+
 	symPrec := b.precAndAssoc.terminalPrecedence(sym)
 	prodPrec := b.precAndAssoc.productionPredence(prod)
 	if symPrec == 0 || prodPrec == 0 {
 		return ActionTypeShift, ResolvedByShift
 	}
 	if symPrec == prodPrec {
-		assoc := b.precAndAssoc.productionAssociativity(prod)
-		if assoc != assocTypeLeft {
-			return ActionTypeShift, ResolvedByAssoc
+		switch b.precAndAssoc.productionAssociativity(prod) {
+		case assocTypeLeft:
+			return ActionTypeReduce, ResolvedByAssoc
+		case assocTypeNonAssoc:
+			return ActionTypeError, ResolvedByAssoc
 		}
-		return ActionTypeReduce, ResolvedByAssoc
+		return ActionTypeShift, ResolvedByAssoc
 	}
 	if symPrec < prodPrec {
 		return ActionTypeShift, ResolvedByPrec
@@ -324,8 +364,9 @@ func (b *lrTableBuilder) genReport(tab *ParsingTable, gram *Grammar) (*spec.Repo
 			}
 
 			term := &spec.Terminal{
-				Number: sym.Num().Int(),
-				Name:   name,
+				Number:  sym.Num().Int(),
+				Name:    name,
+				Literal: gram.implicitTerminals[name],
 			}
 
 			prec := b.precAndAssoc.terminalPrecedence(sym.Num())
@@ -339,6 +380,8 @@ func (b *lrTableBuilder) genReport(tab *ParsingTable, gram *Grammar) (*spec.Repo
 				term.Associativity = "l"
 			case assocTypeRight:
 				term.Associativity = "r"
+			case assocTypeNonAssoc:
+				term.Associativity = "n"
 			}
 
 			terms[sym.Num()] = term
@@ -380,6 +423,7 @@ func (b *lrTableBuilder) genReport(tab *ParsingTable, gram *Grammar) (*spec.Repo
 				Number: p.num.Int(),
 				LHS:    p.lhs.Num().Int(),
 				RHS:    rhs,
+				Label:  gram.altLabels[p.id],
 			}
 
 			prec := b.precAndAssoc.productionPredence(p.num)
@@ -393,6 +437,8 @@ func (b *lrTableBuilder) genReport(tab *ParsingTable, gram *Grammar) (*spec.Repo
 				prod.Associativity = "l"
 			case assocTypeRight:
 				prod.Associativity = "r"
+			case assocTypeNonAssoc:
+				prod.Associativity = "n"
 			}
 
 			prods[p.num.Int()] = prod
@@ -494,6 +540,11 @@ func (b *lrTableBuilder) genReport(tab *ParsingTable, gram *Grammar) (*spec.Repo
 						State:      c.nextState.Int(),
 						Production: c.prodNum.Int(),
 						ResolvedBy: c.resolvedBy.Int(),
+
+						SymbolPrecedence:        b.precAndAssoc.terminalPrecedence(c.sym.Num()),
+						SymbolAssociativity:     reportedAssociativity(b.precAndAssoc.terminalAssociativity(c.sym.Num())),
+						ProductionPrecedence:    b.precAndAssoc.productionPredence(c.prodNum),
+						ProductionAssociativity: reportedAssociativity(b.precAndAssoc.productionAssociativity(c.prodNum)),
 					}
 
 					ty, s, p := tab.getAction(s.num, c.sym.Num())
@@ -509,8 +560,16 @@ func (b *lrTableBuilder) genReport(tab *ParsingTable, gram *Grammar) (*spec.Repo
 					sr = append(sr, conflict)
 				}
 
+				// The conflicts are sorted by all keys identifying them so that their order doesn't depend on
+				// the sorting algorithm.
 				sort.Slice(sr, func(i, j int) bool {
-					return sr[i].Symbol < sr[j].Symbol
+					if sr[i].Symbol != sr[j].Symbol {
+						return sr[i].Symbol < sr[j].Symbol
+					}
+					if sr[i].State != sr[j].State {
+						return sr[i].State < sr[j].State
+					}
+					return sr[i].Production < sr[j].Production
 				})
 
 				for _, c := range rrConflicts[s.num] {
@@ -528,7 +587,13 @@ func (b *lrTableBuilder) genReport(tab *ParsingTable, gram *Grammar) (*spec.Repo
 				}
 
 				sort.Slice(rr, func(i, j int) bool {
-					return rr[i].Symbol < rr[j].Symbol
+					if rr[i].Symbol != rr[j].Symbol {
+						return rr[i].Symbol < rr[j].Symbol
+					}
+					if rr[i].Production1 != rr[j].Production1 {
+						return rr[i].Production1 < rr[j].Production1
+					}
+					return rr[i].Production2 < rr[j].Production2
 				})
 			}
 
@@ -545,9 +610,23 @@ func (b *lrTableBuilder) genReport(tab *ParsingTable, gram *Grammar) (*spec.Repo
 	}
 
 	return &spec.Report{
+		Metadata:     gram.metadata,
 		Terminals:    terms,
 		NonTerminals: nonTerms,
 		Productions:  prods,
 		States:       states,
 	}, nil
 }
+
+// reportedAssociativity converts an associativity into the notation reports use.
+func reportedAssociativity(assoc assocType) string {
+	switch assoc {
+	case assocTypeLeft:
+		return "l"
+	case assocTypeRight:
+		return "r"
+	case assocTypeNonAssoc:
+		return "n"
+	}
+	return ""
+}
@@ -0,0 +1,195 @@
+package grammar
+
+import (
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+// RightRecursiveList is a production defining a list by right recursion, such as `list: elem comma list | elem`. The
+// parser shifts all elements of such a list before reducing any of them, so the parser stack grows with the length of
+// the list. The left-recursive form `list: list comma elem | elem` accepts the same language with a shallow stack.
+type RightRecursiveList struct {
+	Production *parser.ProductionNode
+
+	// Recursive is the alternative ending with the LHS, and Base is the other one. The elements of Base are
+	// a prefix of the elements of Recursive.
+	Recursive *parser.AlternativeNode
+	Base      *parser.AlternativeNode
+
+	// Rewritable is false when the recursive alternative has directives other than `#ast` because the rewrite would
+	// change what they mean.
+	Rewritable bool
+
+	// KeepsTree is true when the rewrite keeps syntax trees, that is, the `#ast` directive of the recursive alternative
+	// flattens list nodes, like `#ast elem list...`. Otherwise, the nesting of list nodes reverses.
+	KeepsTree bool
+
+	// astParams is the parameters of the `#ast` directive of the recursive alternative after the rewrite.
+	astParams []*parser.ParameterNode
+}
+
+// FindRightRecursiveLists returns the productions defining lists by right recursion. A production is such a list when
+// it has exactly two alternatives, `y z P` and `y`, where P is the LHS, y and z are sequences of elements not
+// containing P, and y may be empty. The language of the production is `(y z)* y`, which equals `y (z y)*`.
+func FindRightRecursiveLists(root *parser.RootNode) []*RightRecursiveList {
+	omitPunct := false
+	for _, dir := range root.Directives {
+		if dir.Name == "omit_punctuation" {
+			omitPunct = true
+		}
+	}
+	var lists []*RightRecursiveList
+	for _, prod := range root.Productions {
+		if len(prod.RHS) != 2 {
+			continue
+		}
+		for _, pair := range [][2]*parser.AlternativeNode{{prod.RHS[0], prod.RHS[1]}, {prod.RHS[1], prod.RHS[0]}} {
+			rec, base := pair[0], pair[1]
+			if !isRightRecursiveList(prod.LHS, rec, base) {
+				continue
+			}
+			list := &RightRecursiveList{
+				Production: prod,
+				Recursive:  rec,
+				Base:       base,
+				Rewritable: true,
+			}
+			for _, dir := range rec.Directives {
+				if dir.Name != "ast" {
+					list.Rewritable = false
+				}
+			}
+			if list.Rewritable {
+				list.astParams, list.KeepsTree = leftRecursiveASTParams(list, omitPunct)
+			}
+			lists = append(lists, list)
+			break
+		}
+	}
+	return lists
+}
+
+func isRightRecursiveList(lhs string, rec, base *parser.AlternativeNode) bool {
+	n := len(rec.Elements)
+	if n < 2 || rec.Elements[n-1].ID != lhs || len(base.Elements) > n-1 {
+		return false
+	}
+	for _, elem := range rec.Elements[:n-1] {
+		if elem.ID == lhs {
+			return false
+		}
+	}
+	for i, e := range base.Elements {
+		r := rec.Elements[i]
+		if e.ID != r.ID || e.Pattern != r.Pattern || e.Literally != r.Literally {
+			return false
+		}
+	}
+	return true
+}
+
+// RewriteRightRecursiveList rewrites the recursive alternative `y z P` of a list into `P z y`. When KeepsTree of the
+// list is true, the rewrite also reorders the parameters of the `#ast` directive of the alternative so that list nodes
+// keep their children.
+func RewriteRightRecursiveList(list *RightRecursiveList) {
+	rec := list.Recursive
+	params := list.astParams
+
+	n := len(rec.Elements)
+	y := len(list.Base.Elements)
+	var elems []*parser.ElementNode
+	elems = append(elems, rec.Elements[n-1])
+	elems = append(elems, rec.Elements[y:n-1]...)
+	elems = append(elems, rec.Elements[:y]...)
+	rec.Elements = elems
+
+	if list.KeepsTree {
+		for _, dir := range rec.Directives {
+			if dir.Name == "ast" {
+				dir.Parameters = params
+			}
+		}
+	}
+}
+
+// leftRecursiveASTParams returns the parameters of the `#ast` directive of the recursive alternative `y z P` that keep
+// the children of list nodes after the rewrite into `P z y`. A right-recursive list node with `#ast Y Z P...` has the
+// children `Y Z Y Z ... B`, where Y and Z are the parameters referring to y and z, and B is the children of the base
+// alternative. When B equals Y, the left-recursive form with `#ast P... Z Y` has the same children. The second return
+// value is false when the parameters don't take that form.
+func leftRecursiveASTParams(list *RightRecursiveList, omitPunct bool) ([]*parser.ParameterNode, bool) {
+	rec, base := list.Recursive, list.Base
+	var ast *parser.DirectiveNode
+	for _, dir := range rec.Directives {
+		if dir.Name == "ast" {
+			ast = dir
+		}
+	}
+	if ast == nil || len(ast.Parameters) == 0 {
+		return nil, false
+	}
+
+	n := len(rec.Elements)
+	y := len(base.Elements)
+	// An element having a label can be specified by both the label and the symbol name.
+	indexOf := func(alt *parser.AlternativeNode, param *parser.ParameterNode) int {
+		for i, elem := range alt.Elements {
+			if elem.ID == param.ID || elem.Label != nil && elem.Label.Name == param.ID {
+				return i
+			}
+		}
+		return -1
+	}
+	params := ast.Parameters
+	if last := params[len(params)-1]; !last.Expansion || indexOf(rec, last) != n-1 {
+		return nil, false
+	}
+	var ys, zs []*parser.ParameterNode
+	var yIndices []int
+	for _, param := range params[:len(params)-1] {
+		i := indexOf(rec, param)
+		switch {
+		case i >= 0 && i < y && len(zs) == 0:
+			ys = append(ys, param)
+			yIndices = append(yIndices, i)
+		case i >= y && i < n-1:
+			zs = append(zs, param)
+		default:
+			return nil, false
+		}
+	}
+
+	// The children of the base alternative must equal Y.
+	var baseAST *parser.DirectiveNode
+	for _, dir := range base.Directives {
+		if dir.Name == "ast" {
+			baseAST = dir
+		}
+	}
+	switch {
+	case baseAST != nil:
+		if len(baseAST.Parameters) != len(ys) {
+			return nil, false
+		}
+		for k, param := range baseAST.Parameters {
+			if indexOf(base, param) != yIndices[k] || param.Expansion != ys[k].Expansion {
+				return nil, false
+			}
+		}
+	case len(base.Directives) == 0 && !omitPunct:
+		if len(ys) != y {
+			return nil, false
+		}
+		for k, param := range ys {
+			if yIndices[k] != k || param.Expansion {
+				return nil, false
+			}
+		}
+	default:
+		return nil, false
+	}
+
+	newParams := []*parser.ParameterNode{params[len(params)-1]}
+	newParams = append(newParams, zs...)
+	newParams = append(newParams, ys...)
+	return newParams, true
+}
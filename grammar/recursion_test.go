@@ -0,0 +1,206 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestRewriteRightRecursiveList(t *testing.T) {
+	tests := []struct {
+		caption    string
+		src        string
+		rewritable bool
+		keepsTree  bool
+		expected   string
+	}{
+		{
+			caption: "a list with a separator flattened by #ast keeps its tree",
+			src: `#name test;
+args
+    : arg comma args #ast arg args...
+    | arg
+    ;
+arg: "[a-z]+";
+comma: ',';
+`,
+			rewritable: true,
+			keepsTree:  true,
+			expected: `args
+	: args comma arg #ast args... arg
+	| arg
+	;
+`,
+		},
+		{
+			caption: "parameters referring to the separator come before the ones referring to the elements",
+			src: `#name test;
+pairs
+    : key@k colon val@v #ast k v
+    | key@k colon val@v semi pairs@rest #ast k v semi rest...
+    ;
+key: "[a-z]+";
+val: "[0-9]+";
+colon: ':';
+semi: ';';
+`,
+			rewritable: true,
+			keepsTree:  true,
+			expected: `pairs
+	: key@k colon val@v                 #ast k v
+	| pairs@rest semi key@k colon val@v #ast rest... semi k v
+	;
+`,
+		},
+		{
+			caption: "an empty base alternative is a prefix of any alternative",
+			src: `#name test;
+stmts
+    : stmt stmts #ast stmt stmts...
+    |
+    ;
+stmt: "[a-z]+";
+`,
+			rewritable: true,
+			keepsTree:  true,
+			expected: `stmts
+	: stmts stmt #ast stmts... stmt
+	|
+	;
+`,
+		},
+		{
+			caption: "a list without #ast reverses the nesting of its nodes",
+			src: `#name test;
+list
+    : elem
+    | elem list
+    ;
+elem: "[a-z]+";
+`,
+			rewritable: true,
+			keepsTree:  false,
+			expected: `list
+	: elem
+	| list elem
+	;
+`,
+		},
+		{
+			caption: "a list having directives other than #ast isn't rewritable",
+			src: `#name test;
+list
+    : elem list #recover
+    | elem
+    ;
+elem: "[a-z]+";
+`,
+			rewritable: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			root, err := parser.Parse(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lists := FindRightRecursiveLists(root)
+			if len(lists) != 1 {
+				t.Fatalf("unexpected list count; want: 1, got: %v", len(lists))
+			}
+			list := lists[0]
+			if list.Rewritable != tt.rewritable {
+				t.Fatalf("unexpected rewritability; want: %v, got: %v", tt.rewritable, list.Rewritable)
+			}
+			if !list.Rewritable {
+				return
+			}
+			if list.KeepsTree != tt.keepsTree {
+				t.Fatalf("unexpected KeepsTree; want: %v, got: %v", tt.keepsTree, list.KeepsTree)
+			}
+			RewriteRightRecursiveList(list)
+			var b strings.Builder
+			err = parser.Format(&b, &parser.RootNode{
+				Productions: []*parser.ProductionNode{list.Production},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.expected {
+				t.Fatalf("unexpected result;\nwant:\n%v\ngot:\n%v", tt.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestGrammarBuilderRightRecursiveListSeverity(t *testing.T) {
+	src := `
+#name test;
+
+args
+    : arg comma args #ast arg args...
+    | arg
+    ;
+
+arg
+    : "[a-z]+";
+comma
+    : ',';
+`
+	tests := []struct {
+		severity  Severity
+		errCount  int
+		warnCount int
+	}{
+		{
+			severity: SeverityError,
+			errCount: 1,
+		},
+		{
+			severity:  SeverityWarn,
+			warnCount: 1,
+		},
+		{
+			severity: SeverityIgnore,
+		},
+		// A plain compile reports right-recursive lists as warnings.
+		{
+			warnCount: 1,
+		},
+	}
+	for _, tt := range tests {
+		name := string(tt.severity)
+		if name == "" {
+			name = "default"
+		}
+		t.Run(name, func(t *testing.T) {
+			ast, err := parser.Parse(strings.NewReader(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := GrammarBuilder{
+				AST: ast,
+			}
+			if tt.severity == "" {
+				_, _, err = b.Build()
+			} else {
+				err = b.Validate(TreatRightRecursiveListsAs(tt.severity))
+			}
+			if tt.errCount > 0 {
+				specErrs, ok := err.(verr.SpecErrors)
+				if !ok || len(specErrs) != tt.errCount || specErrs[0].Cause != semErrRightRecursiveList || specErrs[0].Row != 4 {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if warns := b.Warnings(); len(warns) != tt.warnCount {
+				t.Fatalf("unexpected warnings: %v", warns)
+			}
+		})
+	}
+}
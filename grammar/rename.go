@@ -0,0 +1,347 @@
+package grammar
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nihei9/vartan/grammar/lexical"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+type idKind string
+
+const (
+	idKindSymbol        = idKind("symbol")
+	idKindLabel         = idKind("label")
+	idKindOrderedSymbol = idKind("ordered symbol")
+	idKindMode          = idKind("mode")
+)
+
+// idOccurrence is an identifier in a grammar referring to a symbol, a label, an ordered symbol, or a lex mode.
+type idOccurrence struct {
+	kind idKind
+	name string
+	pos  parser.Position
+
+	// alt is the alternative containing a label or a directive parameter referring to a label.
+	alt *parser.AlternativeNode
+
+	// param is true when the identifier is a parameter of a directive.
+	param bool
+}
+
+var renameIDRE = regexp.MustCompile(`^[0-9A-Za-z_]+$`)
+
+// RenameSymbol renames a symbol, a label, or an ordered symbol in a grammar source and returns the renamed source.
+// An ordered symbol is specified with the leading `$`. RenameSymbol renames the LHSs of productions, the elements of
+// alternatives, and the parameters of directives referring to the symbol, and keeps the other parts of the source as
+// they are, such as labels and lex modes having the same name.
+//
+// RenameSymbol refuses a new name that conflicts with another identifier: an existing symbol or label, an existing
+// label of the same alternative when renaming a label, or an identifier that differs only in spelling, such as
+// `foo_bar` and `fooBar`.
+func RenameSymbol(src []byte, oldName, newName string) ([]byte, error) {
+	kind := idKindSymbol
+	if strings.HasPrefix(oldName, "$") || strings.HasPrefix(newName, "$") {
+		if !strings.HasPrefix(oldName, "$") || !strings.HasPrefix(newName, "$") {
+			return nil, fmt.Errorf("an ordered symbol can be renamed only to an ordered symbol: %v -> %v", oldName, newName)
+		}
+		kind = idKindOrderedSymbol
+		oldName = strings.TrimPrefix(oldName, "$")
+		newName = strings.TrimPrefix(newName, "$")
+	}
+	if oldName == reservedSymbolNameError || newName == reservedSymbolNameError {
+		return nil, fmt.Errorf("symbol '%v' is reserved", reservedSymbolNameError)
+	}
+	return rename(src, kind, oldName, newName)
+}
+
+// RenameMode renames a lex mode in a grammar source and returns the renamed source. The predefined `default` mode
+// cannot be renamed.
+func RenameMode(src []byte, oldName, newName string) ([]byte, error) {
+	if oldName == spec.LexModeNameDefault.String() || newName == spec.LexModeNameDefault.String() {
+		return nil, fmt.Errorf("mode '%v' is predefined", spec.LexModeNameDefault)
+	}
+	return rename(src, idKindMode, oldName, newName)
+}
+
+func rename(src []byte, kind idKind, oldName, newName string) ([]byte, error) {
+	if !renameIDRE.MatchString(newName) || newName == "fragment" {
+		return nil, fmt.Errorf("invalid identifier: %v", newName)
+	}
+	if newName == oldName {
+		return nil, fmt.Errorf("the new name is the same as the old one: %v", oldName)
+	}
+
+	root, err := parser.Parse(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	occs := collectIDOccurrences(root)
+
+	// A name that isn't a symbol may be a label. A label must differ from symbols, so a name can't be both.
+	if kind == idKindSymbol && !hasIDOccurrence(occs, idKindSymbol, oldName) && hasIDOccurrence(occs, idKindLabel, oldName) {
+		kind = idKindLabel
+	}
+	var targets []*idOccurrence
+	for _, occ := range occs {
+		if occ.kind == kind && occ.name == oldName {
+			targets = append(targets, occ)
+		}
+	}
+	if len(targets) == 0 {
+		if kind == idKindOrderedSymbol {
+			return nil, fmt.Errorf("ordered symbol '$%v' was not found", oldName)
+		}
+		return nil, fmt.Errorf("%v '%v' was not found", kind, oldName)
+	}
+
+	err = checkRenameConflicts(occs, targets, kind, oldName, newName)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed, err := replaceIDs(src, targets, oldName, newName)
+	if err != nil {
+		return nil, err
+	}
+	_, err = parser.Parse(bytes.NewReader(renamed))
+	if err != nil {
+		return nil, fmt.Errorf("renaming breaks the grammar: %w", err)
+	}
+	return renamed, nil
+}
+
+func hasIDOccurrence(occs []*idOccurrence, kind idKind, name string) bool {
+	for _, occ := range occs {
+		if occ.kind == kind && occ.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRenameConflicts returns an error when a new name collides with an identifier in the same namespace or differs
+// from an identifier only in spelling. Symbols, labels, and ordered symbols share the check of spelling
+// inconsistencies, and lex modes have their own.
+func checkRenameConflicts(occs []*idOccurrence, targets []*idOccurrence, kind idKind, oldName, newName string) error {
+	targetAlts := map[*parser.AlternativeNode]struct{}{}
+	for _, t := range targets {
+		if t.alt != nil {
+			targetAlts[t.alt] = struct{}{}
+		}
+	}
+
+	// A label of an alternative must be unique only in the alternative.
+	collides := func(occ *idOccurrence) bool {
+		switch kind {
+		case idKindSymbol:
+			return occ.kind == idKindSymbol || occ.kind == idKindLabel
+		case idKindLabel:
+			if occ.kind == idKindLabel {
+				_, ok := targetAlts[occ.alt]
+				return ok
+			}
+			return occ.kind == idKindSymbol
+		default:
+			return occ.kind == kind
+		}
+	}
+	sameSpace := func(occ *idOccurrence) bool {
+		if kind == idKindMode {
+			return occ.kind == idKindMode
+		}
+		return occ.kind != idKindMode
+	}
+
+	newCamel := lexical.SnakeCaseToUpperCamelCase(newName)
+	if kind == idKindMode && newCamel == lexical.SnakeCaseToUpperCamelCase(spec.LexModeNameDefault.String()) {
+		return fmt.Errorf("'%v' differs from the predefined mode '%v' only in spelling", newName, spec.LexModeNameDefault)
+	}
+	for _, occ := range occs {
+		if !sameSpace(occ) || occ.kind == kind && occ.name == oldName {
+			continue
+		}
+		if occ.name == newName {
+			if collides(occ) {
+				return fmt.Errorf("'%v' is already used as a %v at %v:%v", newName, occ.kind, occ.pos.Row, occ.pos.Col)
+			}
+			continue
+		}
+		if lexical.SnakeCaseToUpperCamelCase(occ.name) == newCamel {
+			return fmt.Errorf("'%v' differs from the %v '%v' at %v:%v only in spelling", newName, occ.kind, occ.name, occ.pos.Row, occ.pos.Col)
+		}
+	}
+	return nil
+}
+
+// replaceIDs replaces identifiers at the positions of occurrences. A column of a position counts code points.
+func replaceIDs(src []byte, targets []*idOccurrence, oldName, newName string) ([]byte, error) {
+	var lineOffsets []int
+	lineOffsets = append(lineOffsets, 0)
+	for i, b := range src {
+		if b == '\n' {
+			lineOffsets = append(lineOffsets, i+1)
+		}
+	}
+
+	offsets := make([]int, 0, len(targets))
+	for _, t := range targets {
+		if t.pos.Row < 1 || t.pos.Row > len(lineOffsets) {
+			return nil, fmt.Errorf("invalid position of '%v': %v:%v", oldName, t.pos.Row, t.pos.Col)
+		}
+		offset := lineOffsets[t.pos.Row-1]
+		for col := 1; col < t.pos.Col && offset < len(src); col++ {
+			_, size := utf8.DecodeRune(src[offset:])
+			offset += size
+		}
+		if !bytes.HasPrefix(src[offset:], []byte(oldName)) {
+			return nil, fmt.Errorf("'%v' was not found at %v:%v", oldName, t.pos.Row, t.pos.Col)
+		}
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+
+	var b bytes.Buffer
+	prev := 0
+	for _, offset := range offsets {
+		if offset < prev {
+			continue
+		}
+		b.Write(src[prev:offset])
+		b.WriteString(newName)
+		prev = offset + len(oldName)
+	}
+	b.Write(src[prev:])
+	return b.Bytes(), nil
+}
+
+// collectIDOccurrences returns the identifiers referring to symbols, labels, ordered symbols, and lex modes. Parameters of
+// directives referring to the other things, such as a grammar name or a node name of `#rename`, aren't included.
+func collectIDOccurrences(root *parser.RootNode) []*idOccurrence {
+	var occs []*idOccurrence
+	add := func(kind idKind, name string, pos parser.Position, alt *parser.AlternativeNode) {
+		occs = append(occs, &idOccurrence{
+			kind: kind,
+			name: name,
+			pos:  pos,
+			alt:  alt,
+		})
+	}
+	addParam := func(kind idKind, name string, pos parser.Position, alt *parser.AlternativeNode) {
+		occs = append(occs, &idOccurrence{
+			kind:  kind,
+			name:  name,
+			pos:   pos,
+			alt:   alt,
+			param: true,
+		})
+	}
+	addSymbols := func(params []*parser.ParameterNode) {
+		for _, param := range params {
+			switch {
+			case param.ID != "":
+				addParam(idKindSymbol, param.ID, param.Pos, nil)
+			case param.OrderedSymbol != "":
+				addParam(idKindOrderedSymbol, param.OrderedSymbol, param.Pos, nil)
+			}
+		}
+	}
+
+	for _, dir := range root.Directives {
+		switch dir.Name {
+		case "prec", "expr":
+			// The first parameter of an `#expr` directive is a symbol.
+			addSymbols(dir.Parameters)
+			for _, param := range dir.Parameters {
+				for _, d := range param.Group {
+					addSymbols(d.Parameters)
+				}
+			}
+		case "start", "layout", "fallback":
+			addSymbols(dir.Parameters)
+		case "mode_extends":
+			for _, param := range dir.Parameters {
+				if param.ID != "" {
+					addParam(idKindMode, param.ID, param.Pos, nil)
+				}
+			}
+		}
+	}
+
+	for _, prods := range [][]*parser.ProductionNode{root.Productions, root.LexProductions} {
+		for _, prod := range prods {
+			add(idKindSymbol, prod.LHS, prod.Pos, nil)
+			for _, dir := range prod.Directives {
+				switch dir.Name {
+				case "mode", "push":
+					for _, param := range dir.Parameters {
+						if param.ID != "" {
+							addParam(idKindMode, param.ID, param.Pos, nil)
+						}
+					}
+				case "keywords":
+					addSymbols(dir.Parameters)
+				}
+			}
+			if prod.IsLexical() {
+				continue
+			}
+			for _, alt := range prod.RHS {
+				labels := map[string]struct{}{}
+				for _, elem := range alt.Elements {
+					if elem.ID != "" {
+						add(idKindSymbol, elem.ID, elem.Pos, alt)
+					}
+					if elem.Label != nil {
+						add(idKindLabel, elem.Label.Name, elem.Label.Pos, alt)
+						labels[elem.Label.Name] = struct{}{}
+					}
+				}
+				// A parameter referring to an element is a label when the alternative has the label because a label
+				// must differ from symbols.
+				addSymbolOrLabel := func(param *parser.ParameterNode) {
+					if param.ID == "" {
+						return
+					}
+					if _, ok := labels[param.ID]; ok {
+						addParam(idKindLabel, param.ID, param.Pos, alt)
+					} else {
+						addParam(idKindSymbol, param.ID, param.Pos, alt)
+					}
+				}
+				for _, dir := range alt.Directives {
+					switch dir.Name {
+					case "ast", "lift", "pop":
+						for _, param := range dir.Parameters {
+							addSymbolOrLabel(param)
+						}
+					case "push":
+						for i, param := range dir.Parameters {
+							if i == 0 {
+								if param.ID != "" {
+									addParam(idKindMode, param.ID, param.Pos, alt)
+								}
+								continue
+							}
+							addSymbolOrLabel(param)
+						}
+					case "prec", "keep":
+						addSymbols(dir.Parameters)
+					case "recover":
+						// The first parameter of `#recover until {<terminal>}` is a keyword.
+						if len(dir.Parameters) > 1 {
+							addSymbols(dir.Parameters[1:])
+						}
+					}
+				}
+			}
+		}
+	}
+	return occs
+}
@@ -0,0 +1,287 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenameSymbol(t *testing.T) {
+	tests := []struct {
+		caption  string
+		src      string
+		oldName  string
+		newName  string
+		mode     bool
+		expected string
+		errMsg   string
+	}{
+		{
+			caption: "a non-terminal symbol is renamed in productions and directives, and a label and a mode having the same name are kept",
+			src: `#name test;
+
+#start expr; // expr is an entry point.
+
+s
+    : expr@lhs eq expr #ast lhs expr
+    ;
+expr
+    : expr add expr #ast expr... expr
+    | id
+    ;
+eq: '=';
+add: '+';
+id #push expr: "[a-z]+";
+`,
+			oldName: "expr",
+			newName: "exp",
+			expected: `#name test;
+
+#start exp; // expr is an entry point.
+
+s
+    : exp@lhs eq exp #ast lhs exp
+    ;
+exp
+    : exp add exp #ast exp... exp
+    | id
+    ;
+eq: '=';
+add: '+';
+id #push expr: "[a-z]+";
+`,
+		},
+		{
+			caption: "a terminal symbol is renamed in #prec, #keywords, #ast, and #pop directives",
+			src: `#name test;
+#prec (
+    #left mul
+    #right pow
+);
+expr
+    : expr mul expr
+    | expr pow expr #prec mul
+    | id #pop id
+    ;
+mul: '*';
+pow: '^';
+id #keywords if: "[a-z]+";
+if: 'if';
+`,
+			oldName: "mul",
+			newName: "times",
+			expected: `#name test;
+#prec (
+    #left times
+    #right pow
+);
+expr
+    : expr times expr
+    | expr pow expr #prec times
+    | id #pop id
+    ;
+times: '*';
+pow: '^';
+id #keywords if: "[a-z]+";
+if: 'if';
+`,
+		},
+		{
+			caption: "a label is renamed only in alternatives having it",
+			src: `#name test;
+s
+    : a@x b #ast x b
+    | b@x a #ast a x
+    | a b #ast a b
+    ;
+a: 'a';
+b: 'b';
+`,
+			oldName: "x",
+			newName: "y",
+			expected: `#name test;
+s
+    : a@y b #ast y b
+    | b@y a #ast a y
+    | a b #ast a b
+    ;
+a: 'a';
+b: 'b';
+`,
+		},
+		{
+			caption: "an ordered symbol is renamed",
+			src: `#name test;
+#prec (
+    #left $high
+    #left $low
+);
+s
+    : a #prec $high
+    | b #prec $low
+    ;
+a: 'a';
+b: 'b';
+`,
+			oldName: "$high",
+			newName: "$top",
+			expected: `#name test;
+#prec (
+    #left $top
+    #left $low
+);
+s
+    : a #prec $top
+    | b #prec $low
+    ;
+a: 'a';
+b: 'b';
+`,
+		},
+		{
+			caption: "a mode is renamed",
+			src: `#name test;
+s
+    : open text close
+    ;
+open #push str: '"';
+text #mode str: "[^\"]+";
+close #mode str #pop: '"';
+`,
+			oldName: "str",
+			newName: "string",
+			mode:    true,
+			expected: `#name test;
+s
+    : open text close
+    ;
+open #push string: '"';
+text #mode string: "[^\"]+";
+close #mode string #pop: '"';
+`,
+		},
+		{
+			caption: "columns count code points",
+			src: `#name test;
+s: 'あ' a;
+a: "い";
+`,
+			oldName: "a",
+			newName: "b",
+			expected: `#name test;
+s: 'あ' b;
+b: "い";
+`,
+		},
+		{
+			caption: "a new name must not be an existing symbol",
+			src: `#name test;
+s: a b;
+a: 'a';
+b: 'b';
+`,
+			oldName: "a",
+			newName: "b",
+			errMsg:  "'b' is already used as a symbol",
+		},
+		{
+			caption: "a new name of a symbol must not be an existing label",
+			src: `#name test;
+s: a@x b;
+a: 'a';
+b: 'b';
+`,
+			oldName: "b",
+			newName: "x",
+			errMsg:  "'x' is already used as a label",
+		},
+		{
+			caption: "a new name of a label must be unique in the alternative",
+			src: `#name test;
+s: a@x b@y;
+a: 'a';
+b: 'b';
+`,
+			oldName: "x",
+			newName: "y",
+			errMsg:  "'y' is already used as a label",
+		},
+		{
+			caption: "a new name must not differ from another identifier only in spelling",
+			src: `#name test;
+s: foo_bar baz;
+foo_bar: 'a';
+baz: 'b';
+`,
+			oldName: "baz",
+			newName: "fooBar",
+			errMsg:  "only in spelling",
+		},
+		{
+			caption: "an undefined symbol cannot be renamed",
+			src: `#name test;
+s: a;
+a: 'a';
+`,
+			oldName: "b",
+			newName: "c",
+			errMsg:  "symbol 'b' was not found",
+		},
+		{
+			caption: "the error symbol cannot be renamed",
+			src: `#name test;
+s: a | error;
+a: 'a';
+`,
+			oldName: "error",
+			newName: "err",
+			errMsg:  "reserved",
+		},
+		{
+			caption: "a new name must be an identifier",
+			src: `#name test;
+s: a;
+a: 'a';
+`,
+			oldName: "a",
+			newName: "a-b",
+			errMsg:  "invalid identifier",
+		},
+		{
+			caption: "a new mode name must not be the default mode",
+			src: `#name test;
+s: a;
+a #mode m: 'a';
+`,
+			oldName: "m",
+			newName: "Default",
+			mode:    true,
+			errMsg:  "predefined mode",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			var renamed []byte
+			var err error
+			if tt.mode {
+				renamed, err = RenameMode([]byte(tt.src), tt.oldName, tt.newName)
+			} else {
+				renamed, err = RenameSymbol([]byte(tt.src), tt.oldName, tt.newName)
+			}
+			if tt.errMsg != "" {
+				if err == nil {
+					t.Fatalf("an error was not returned; got:\n%s", renamed)
+				}
+				if !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("unexpected error; want: %v, got: %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(renamed) != tt.expected {
+				t.Fatalf("unexpected result;\nwant:\n%v\ngot:\n%s", tt.expected, renamed)
+			}
+		})
+	}
+}
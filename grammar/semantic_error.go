@@ -1,30 +1,36 @@
 package grammar
 
-import "errors"
+import verr "github.com/nihei9/vartan/error"
 
 var (
-	semErrNoGrammarName         = errors.New("name is missing")
-	semErrSpellingInconsistency = errors.New("the identifiers are treated as the same. please use the same spelling")
-	semErrDuplicateAssoc        = errors.New("associativity and precedence cannot be specified multiple times for a symbol")
-	semErrUndefinedPrec         = errors.New("symbol must has precedence")
-	semErrUndefinedOrdSym       = errors.New("undefined ordered symbol")
-	semErrUnusedProduction      = errors.New("unused production")
-	semErrUnusedTerminal        = errors.New("unused terminal")
-	semErrTermCannotBeSkipped   = errors.New("a terminal used in productions cannot be skipped")
-	semErrNoProduction          = errors.New("a grammar needs at least one production")
-	semErrUndefinedSym          = errors.New("undefined symbol")
-	semErrDuplicateProduction   = errors.New("duplicate production")
-	semErrDuplicateTerminal     = errors.New("duplicate terminal")
-	semErrDuplicateFragment     = errors.New("duplicate fragment")
-	semErrDuplicateName         = errors.New("duplicate names are not allowed between terminals and non-terminals")
-	semErrErrSymIsReserved      = errors.New("symbol 'error' is reserved as a terminal symbol")
-	semErrDuplicateLabel        = errors.New("a label must be unique in an alternative")
-	semErrInvalidLabel          = errors.New("a label must differ from terminal symbols or non-terminal symbols")
-	semErrDirInvalidName        = errors.New("invalid directive name")
-	semErrDirInvalidParam       = errors.New("invalid parameter")
-	semErrDuplicateDir          = errors.New("a directive must not be duplicated")
-	semErrDuplicateElem         = errors.New("duplicate element")
-	semErrAmbiguousElem         = errors.New("ambiguous element")
-	semErrInvalidProdDir        = errors.New("invalid production directive")
-	semErrInvalidAltDir         = errors.New("invalid alternative directive")
+	semErrNoGrammarName         = verr.NewCodedError("V2001", "name is missing")
+	semErrSpellingInconsistency = verr.NewCodedError("V2002", "the identifiers are treated as the same. please use the same spelling")
+	semErrDuplicateAssoc        = verr.NewCodedError("V2003", "associativity and precedence cannot be specified multiple times for a symbol")
+	semErrUndefinedPrec         = verr.NewCodedError("V2004", "symbol must has precedence")
+	semErrUndefinedOrdSym       = verr.NewCodedError("V2005", "undefined ordered symbol")
+	semErrUnusedProduction      = verr.NewCodedError("V2006", "unused production")
+	semErrUnusedTerminal        = verr.NewCodedError("V2007", "unused terminal")
+	semErrTermCannotBeSkipped   = verr.NewCodedError("V2008", "a terminal used in productions cannot be skipped")
+	semErrNoProduction          = verr.NewCodedError("V2009", "a grammar needs at least one production")
+	semErrUndefinedSym          = verr.NewCodedError("V2010", "undefined symbol")
+	semErrDuplicateProduction   = verr.NewCodedError("V2011", "duplicate production")
+	semErrDuplicateTerminal     = verr.NewCodedError("V2012", "duplicate terminal")
+	semErrDuplicateFragment     = verr.NewCodedError("V2013", "duplicate fragment")
+	semErrDuplicateName         = verr.NewCodedError("V2014", "duplicate names are not allowed between terminals and non-terminals")
+	semErrErrSymIsReserved      = verr.NewCodedError("V2015", "symbol 'error' is reserved as a terminal symbol")
+	semErrDuplicateLabel        = verr.NewCodedError("V2016", "a label must be unique in an alternative")
+	semErrInvalidLabel          = verr.NewCodedError("V2017", "a label must differ from terminal symbols or non-terminal symbols")
+	semErrDirInvalidName        = verr.NewCodedError("V2018", "invalid directive name")
+	semErrDirInvalidParam       = verr.NewCodedError("V2019", "invalid parameter")
+	semErrDuplicateDir          = verr.NewCodedError("V2020", "a directive must not be duplicated")
+	semErrDuplicateMetadata     = verr.NewCodedError("V2021", "a metadata key must not be duplicated")
+	semErrDuplicateElem         = verr.NewCodedError("V2022", "duplicate element")
+	semErrAmbiguousElem         = verr.NewCodedError("V2023", "ambiguous element")
+	semErrInvalidProdDir        = verr.NewCodedError("V2024", "invalid production directive")
+	semErrInvalidAltDir         = verr.NewCodedError("V2025", "invalid alternative directive")
+	semErrRightRecursiveList    = verr.NewCodedError("V2026", "right-recursive list makes the parser stack grow with the length of the list; rewrite it into left recursion")
+	semErrUnusedLabel           = verr.NewCodedError("V2027", "unused label")
+	semErrUnusedOrdSym          = verr.NewCodedError("V2028", "unused ordered symbol")
+	semErrUnreachableTerminal   = verr.NewCodedError("V2029", "unreachable terminal")
+	semErrUndefinedPrecLevel    = verr.NewCodedError("V2030", "undefined precedence level")
 )
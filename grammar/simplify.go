@@ -0,0 +1,363 @@
+package grammar
+
+import (
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+// Simplification is a summary of the transformations Simplify applied to a grammar.
+type Simplification struct {
+	// InlinedProductions holds the LHSs of the productions Simplify inlined into the alternatives referring to them and
+	// removed.
+	InlinedProductions []string
+
+	// UnitAlternatives is the number of alternatives consisting only of a non-terminal symbol that Simplify replaced
+	// with the alternatives of the symbol.
+	UnitAlternatives int
+
+	// RemovedProductions holds the LHSs of the productions Simplify removed after replacing the unit alternatives
+	// referring to them.
+	RemovedProductions []string
+
+	// MergedAlternatives is the number of alternatives Simplify removed because they were identical to another
+	// alternative of the same production.
+	MergedAlternatives int
+}
+
+// Simplify transforms the productions of a grammar into an equivalent smaller form and returns the summary. It
+// modifies `root` in place. Simplify repeats the following transformations until none of them applies.
+//
+//   - It removes an alternative identical to a preceding one of the same production, including labels and
+//     directives.
+//   - It replaces a unit alternative, an alternative consisting only of a non-terminal symbol, with the alternatives
+//     of the symbol and removes the production of the symbol. The symbol must appear only in the alternative, the
+//     alternative must have neither a label nor directives, and the alternatives of the symbol must have neither
+//     labels, directives, `error` symbol, nor a unit alternative.
+//   - It inlines a trivial non-terminal symbol into the alternatives referring to it and removes its production. A
+//     trivial symbol has a single alternative without labels and directives, and the alternative has at most one
+//     element or the symbol appears only once in the grammar. The alternatives referring to it must have no
+//     directives.
+//
+// Simplify keeps the start symbol and the symbols directives refer to. The transformations keep the language of the grammar, but they change the shape of syntax trees: the nodes
+// of inlined and replaced symbols disappear.
+func Simplify(root *parser.RootNode) *Simplification {
+	s := &simplifier{
+		root:   root,
+		result: &Simplification{},
+	}
+	for {
+		merged := s.mergeAlternatives()
+		replaced := s.replaceUnitAlternatives()
+		inlined := s.inlineProductions()
+		if !merged && !replaced && !inlined {
+			break
+		}
+	}
+	return s.result
+}
+
+type simplifier struct {
+	root   *parser.RootNode
+	result *Simplification
+}
+
+func (s *simplifier) lookupProduction(lhs string) (*parser.ProductionNode, bool) {
+	for _, prod := range s.root.Productions {
+		if prod.LHS == lhs {
+			return prod, true
+		}
+	}
+	return nil, false
+}
+
+// isKept returns true when Simplify must keep a symbol, that is, the symbol is the start symbol or a directive refers
+// to it.
+func (s *simplifier) isKept(sym string) bool {
+	if len(s.root.Productions) > 0 && s.root.Productions[0].LHS == sym {
+		return true
+	}
+	for _, occ := range collectIDOccurrences(s.root) {
+		if occ.kind == idKindSymbol && occ.name == sym && occ.param {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *simplifier) mergeAlternatives() bool {
+	merged := false
+	for _, prod := range s.root.Productions {
+		var rhs []*parser.AlternativeNode
+	LOOP_ALT:
+		for _, alt := range prod.RHS {
+			for _, a := range rhs {
+				if equalAlternatives(a, alt) {
+					s.result.MergedAlternatives++
+					merged = true
+					continue LOOP_ALT
+				}
+			}
+			rhs = append(rhs, alt)
+		}
+		prod.RHS = rhs
+	}
+	return merged
+}
+
+// replaceUnitAlternatives replaces a unit alternative with the alternatives of the symbol and removes the production
+// of the symbol. It replaces only one alternative at a time because it changes the productions.
+func (s *simplifier) replaceUnitAlternatives() bool {
+	for _, prod := range s.root.Productions {
+		for i, alt := range prod.RHS {
+			if !s.isUnitAlternative(alt) {
+				continue
+			}
+			sym, _ := s.lookupProduction(alt.Elements[0].ID)
+			if sym == prod || len(sym.Directives) > 0 || s.countReferences(sym.LHS) > 1 || s.isKept(sym.LHS) {
+				continue
+			}
+			alts, ok := s.unitReplacement(prod, alt, sym)
+			if !ok {
+				continue
+			}
+			rhs := append([]*parser.AlternativeNode{}, prod.RHS[:i]...)
+			rhs = append(rhs, alts...)
+			rhs = append(rhs, prod.RHS[i+1:]...)
+			prod.RHS = rhs
+			s.removeProduction(sym)
+			s.result.UnitAlternatives++
+			s.result.RemovedProductions = append(s.result.RemovedProductions, sym.LHS)
+			s.result.MergedAlternatives += len(sym.RHS) - len(alts)
+			return true
+		}
+	}
+	return false
+}
+
+// countReferences returns the number of elements referring to a symbol.
+func (s *simplifier) countReferences(sym string) int {
+	n := 0
+	for _, prod := range s.root.Productions {
+		for _, alt := range prod.RHS {
+			for _, elem := range alt.Elements {
+				if elem.ID == sym {
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// unitReplacement returns the alternatives replacing a unit alternative `alt` of `prod` referring to `sym`. It omits
+// the alternatives `prod` already has. When an alternative has the same symbols as an alternative of `prod` but differs
+// in labels or directives, the replacement makes duplicate alternatives, so unitReplacement returns false.
+func (s *simplifier) unitReplacement(prod *parser.ProductionNode, alt *parser.AlternativeNode, sym *parser.ProductionNode) ([]*parser.AlternativeNode, bool) {
+	var alts []*parser.AlternativeNode
+LOOP_ALT:
+	for _, a := range sym.RHS {
+		if s.isUnitAlternative(a) || len(a.Directives) > 0 || hasLabel(a) || hasErrorSymbolOrRecover(a) {
+			return nil, false
+		}
+		for _, b := range prod.RHS {
+			if b == alt || !equalElements(a, b) {
+				continue
+			}
+			if len(b.Directives) == 0 && !hasLabel(b) {
+				continue LOOP_ALT
+			}
+			return nil, false
+		}
+		alts = append(alts, &parser.AlternativeNode{
+			Elements:   a.Elements,
+			Directives: a.Directives,
+			Pos:        alt.Pos,
+		})
+	}
+	var result []*parser.AlternativeNode
+	for _, b := range prod.RHS {
+		if b != alt {
+			result = append(result, b)
+		}
+	}
+	if isLexicalShape(append(result, alts...)) {
+		return nil, false
+	}
+	return alts, true
+}
+
+func (s *simplifier) inlineProductions() bool {
+	inlined := false
+	for i := 1; i < len(s.root.Productions); i++ {
+		prod := s.root.Productions[i]
+		if len(prod.RHS) != 1 || len(prod.Directives) > 0 {
+			continue
+		}
+		body := prod.RHS[0]
+		if len(body.Directives) > 0 || hasErrorSymbolOrRecover(body) {
+			continue
+		}
+		ok := true
+		for _, elem := range body.Elements {
+			if elem.Label != nil || elem.ID == prod.LHS {
+				ok = false
+				break
+			}
+		}
+		if !ok || s.isKept(prod.LHS) {
+			continue
+		}
+
+		// A symbol appearing more than once can be inlined only when it's short so that inlining doesn't make the
+		// grammar larger.
+		var refs []*parser.AlternativeNode
+		var refProds []*parser.ProductionNode
+		refCount := 0
+		for _, p := range s.root.Productions {
+			for _, alt := range p.RHS {
+				n := 0
+				for _, elem := range alt.Elements {
+					if elem.ID == prod.LHS {
+						n++
+						if elem.Label != nil {
+							ok = false
+						}
+					}
+				}
+				if n == 0 {
+					continue
+				}
+				if len(alt.Directives) > 0 {
+					ok = false
+				}
+				refs = append(refs, alt)
+				refProds = append(refProds, p)
+				refCount += n
+			}
+		}
+		if !ok || len(refs) == 0 || len(body.Elements) > 1 && refCount > 1 {
+			continue
+		}
+		inlinedElems := make([][]*parser.ElementNode, len(refs))
+		for j, alt := range refs {
+			var elems []*parser.ElementNode
+			for _, elem := range alt.Elements {
+				if elem.ID == prod.LHS {
+					elems = append(elems, body.Elements...)
+					continue
+				}
+				elems = append(elems, elem)
+			}
+			inlinedElems[j] = elems
+		}
+		for _, p := range refProds {
+			var alts []*parser.AlternativeNode
+			for _, alt := range p.RHS {
+				elems := alt.Elements
+				for j, ref := range refs {
+					if ref == alt {
+						elems = inlinedElems[j]
+						break
+					}
+				}
+				alts = append(alts, &parser.AlternativeNode{Elements: elems})
+			}
+			if isLexicalShape(alts) {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		for j, alt := range refs {
+			alt.Elements = inlinedElems[j]
+		}
+		s.removeProduction(prod)
+		i--
+		s.result.InlinedProductions = append(s.result.InlinedProductions, prod.LHS)
+		inlined = true
+	}
+	return inlined
+}
+
+// removeProduction removes a production and the comments in it and directly preceding it.
+func (s *simplifier) removeProduction(prod *parser.ProductionNode) {
+	var prods []*parser.ProductionNode
+	for _, p := range s.root.Productions {
+		if p != prod {
+			prods = append(prods, p)
+		}
+	}
+	s.root.Productions = prods
+
+	docRow := prod.Pos.Row
+	var comments []*parser.CommentNode
+	for j := len(s.root.Comments) - 1; j >= 0; j-- {
+		c := s.root.Comments[j]
+		inProd := !(c.Pos.Row < prod.Pos.Row || c.Pos.Row > prod.End.Row || c.Pos.Row == prod.End.Row && !c.Trailing)
+		isDoc := !c.Trailing && c.Pos.Row == docRow-1
+		if isDoc {
+			docRow = c.Pos.Row
+		}
+		if inProd || isDoc {
+			continue
+		}
+		comments = append(comments, c)
+	}
+	for l, r := 0, len(comments)-1; l < r; l, r = l+1, r-1 {
+		comments[l], comments[r] = comments[r], comments[l]
+	}
+	s.root.Comments = comments
+}
+
+func (s *simplifier) isUnitAlternative(alt *parser.AlternativeNode) bool {
+	if len(alt.Elements) != 1 || alt.Elements[0].ID == "" || alt.Elements[0].Label != nil || len(alt.Directives) > 0 {
+		return false
+	}
+	_, ok := s.lookupProduction(alt.Elements[0].ID)
+	return ok
+}
+
+func hasLabel(alt *parser.AlternativeNode) bool {
+	for _, elem := range alt.Elements {
+		if elem.Label != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasErrorSymbolOrRecover(alt *parser.AlternativeNode) bool {
+	for _, elem := range alt.Elements {
+		if elem.ID == reservedSymbolNameError {
+			return true
+		}
+	}
+	for _, dir := range alt.Directives {
+		if dir.Name == "recover" {
+			return true
+		}
+	}
+	return false
+}
+
+// equalElements returns true when two alternatives have the same symbols regardless of labels and directives.
+func equalElements(a1, a2 *parser.AlternativeNode) bool {
+	if len(a1.Elements) != len(a2.Elements) {
+		return false
+	}
+	for i, e1 := range a1.Elements {
+		e2 := a2.Elements[i]
+		if e1.ID != e2.ID || e1.Pattern != e2.Pattern || e1.Literally != e2.Literally {
+			return false
+		}
+	}
+	return true
+}
+
+// isLexicalShape returns true when alternatives look like the RHS of a lexical production, which a parser of a
+// grammar reads as a definition of a terminal symbol.
+func isLexicalShape(alts []*parser.AlternativeNode) bool {
+	return (&parser.ProductionNode{RHS: alts}).IsLexical()
+}
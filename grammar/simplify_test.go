@@ -0,0 +1,205 @@
+package grammar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		caption  string
+		src      string
+		expected string
+		result   *Simplification
+	}{
+		{
+			caption: "a trivial non-terminal symbol is inlined",
+			src: `#name test;
+stmt
+    : kw_let name eq expr semi
+    ;
+name
+    : id
+    ;
+expr
+    : id
+    | num
+    ;
+kw_let: 'let';
+eq: '=';
+semi: ';';
+id: "[a-z]+";
+num: "[0-9]+";
+`,
+			expected: `#name test;
+
+stmt
+	: kw_let id eq expr semi
+	;
+
+expr
+	: id
+	| num
+	;
+kw_let
+	: 'let';
+eq
+	: '=';
+semi
+	: ';';
+id
+	: "[a-z]+";
+num
+	: "[0-9]+";
+`,
+			result: &Simplification{
+				InlinedProductions: []string{"name"},
+			},
+		},
+		{
+			caption: "a unit alternative is replaced with the alternatives of the symbol, and identical alternatives are merged",
+			src: `#name test;
+s
+    : a
+    | x
+    | x
+    ;
+a
+    : x
+    | y
+    ;
+x: 'x';
+y: 'y';
+`,
+			expected: `#name test;
+
+s
+	: y
+	| x
+	;
+
+x
+	: 'x';
+y
+	: 'y';
+`,
+			result: &Simplification{
+				UnitAlternatives:   1,
+				RemovedProductions: []string{"a"},
+				MergedAlternatives: 2,
+			},
+		},
+		{
+			caption: "symbols referred to by directives, alternatives having directives, and the start symbol are kept",
+			src: `#name test;
+#start item;
+s
+    : list
+    ;
+list
+    : list item #ast list... item
+    | item
+    ;
+item
+    : elem@e #ast e
+    ;
+elem
+    : x
+    ;
+x: 'x';
+`,
+			expected: `#name test;
+
+#start item;
+
+s
+	: list
+	;
+list
+	: list item #ast list... item
+	| item
+	;
+item
+	: elem@e #ast e
+	;
+elem
+	: x
+	;
+x
+	: 'x';
+`,
+			result: &Simplification{},
+		},
+		{
+			caption: "a symbol appearing more than once is inlined only when it has at most one element",
+			src: `#name test;
+s
+    : pair pair comma
+    ;
+pair
+    : x y
+    ;
+comma
+    : c
+    ;
+x: 'x';
+y: 'y';
+c: ',';
+`,
+			expected: `#name test;
+
+s
+	: pair pair c
+	;
+pair
+	: x y
+	;
+
+x
+	: 'x';
+y
+	: 'y';
+c
+	: ',';
+`,
+			result: &Simplification{
+				InlinedProductions: []string{"comma"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			root, err := parser.Parse(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := Simplify(root)
+			if !reflect.DeepEqual(result, tt.result) {
+				t.Fatalf("unexpected result; want: %+v, got: %+v", tt.result, result)
+			}
+			var b strings.Builder
+			err = parser.Format(&b, root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.expected {
+				t.Fatalf("unexpected grammar;\nwant:\n%v\ngot:\n%v", tt.expected, b.String())
+			}
+
+			simplified, err := parser.Parse(strings.NewReader(b.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gb := GrammarBuilder{
+				AST: simplified,
+			}
+			_, _, err = gb.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package grammar
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+//go:embed snippets/list.vartan
+var listSnippetLibrary string
+
+//go:embed snippets/parens.vartan
+var parensSnippetLibrary string
+
+// snippetLibraries are the snippet libraries that vartan ships, keyed by their names. A library is a grammar source
+// consisting only of productions of non-terminal symbols. The productions can refer to symbols the library doesn't
+// define, which the grammar using the library defines, such as the items of a list.
+var snippetLibraries = map[string]string{
+	"list":   listSnippetLibrary,
+	"parens": parensSnippetLibrary,
+}
+
+// useSnippetLibraries returns an AST having the productions of the snippet libraries that `#use` directives name in
+// addition to the productions of a grammar. A `#use lib prefix;` directive adds `prefix_` to the names of the symbols
+// of a library `lib`. The AST has only the library productions that the grammar refers to directly or indirectly,
+// and a production of the grammar takes precedence over a library production of the same name. The library
+// productions are at the position of the directive so that errors about them point to it.
+func (b *GrammarBuilder) useSnippetLibraries(root *parser.RootNode) (*parser.RootNode, error) {
+	libProds := map[string]*parser.ProductionNode{}
+	var libProdNames []string
+	for _, dir := range root.Directives {
+		if dir.Name != "use" || len(dir.Parameters) == 0 {
+			continue
+		}
+		name := dir.Parameters[0].ID
+		if _, ok := snippetLibraries[name]; !ok {
+			continue
+		}
+		var prefix string
+		switch {
+		case len(dir.Parameters) == 2 && dir.Parameters[1].ID != "":
+			prefix = dir.Parameters[1].ID
+		case len(dir.Parameters) != 1:
+			b.errs = append(b.errs, &verr.SpecError{
+				Cause:  semErrDirInvalidParam,
+				Detail: "'use' directive of a snippet library takes a library name and an optional ID prefix",
+				Row:    dir.Pos.Row,
+				Col:    dir.Pos.Col,
+			})
+			continue
+		}
+
+		prods, err := parseSnippetLibrary(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, prod := range prods {
+			instantiateSnippet(prod, prefix, dir.Pos)
+			// The first library defining a symbol takes precedence over the later ones.
+			if _, ok := libProds[prod.LHS]; ok {
+				continue
+			}
+			libProds[prod.LHS] = prod
+			libProdNames = append(libProdNames, prod.LHS)
+		}
+	}
+	if len(libProds) == 0 {
+		return root, nil
+	}
+
+	defined := map[string]struct{}{}
+	for _, prods := range [][]*parser.ProductionNode{root.Productions, root.LexProductions} {
+		for _, prod := range prods {
+			defined[prod.LHS] = struct{}{}
+		}
+	}
+	used := map[string]struct{}{}
+	var use func(prods []*parser.ProductionNode)
+	use = func(prods []*parser.ProductionNode) {
+		for _, prod := range prods {
+			for _, alt := range prod.RHS {
+				for _, elem := range alt.Elements {
+					if elem.ID == "" {
+						continue
+					}
+					if _, ok := defined[elem.ID]; ok {
+						continue
+					}
+					if _, ok := used[elem.ID]; ok {
+						continue
+					}
+					libProd, ok := libProds[elem.ID]
+					if !ok {
+						continue
+					}
+					used[elem.ID] = struct{}{}
+					use([]*parser.ProductionNode{libProd})
+				}
+			}
+		}
+	}
+	use(root.Productions)
+	if len(used) == 0 {
+		return root, nil
+	}
+
+	r := *root
+	r.Productions = make([]*parser.ProductionNode, len(root.Productions), len(root.Productions)+len(used))
+	copy(r.Productions, root.Productions)
+	for _, name := range libProdNames {
+		if _, ok := used[name]; ok {
+			r.Productions = append(r.Productions, libProds[name])
+		}
+	}
+	return &r, nil
+}
+
+func parseSnippetLibrary(name string) ([]*parser.ProductionNode, error) {
+	lib, err := parser.Parse(strings.NewReader(snippetLibraries[name]))
+	if err != nil {
+		return nil, fmt.Errorf("the snippet library %v is broken: %w", name, err)
+	}
+	return lib.Productions, nil
+}
+
+// instantiateSnippet adds a prefix to the names of the symbols a library production refers to and moves the
+// production to a position.
+func instantiateSnippet(prod *parser.ProductionNode, prefix string, pos parser.Position) {
+	prefixed := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "_" + name
+	}
+	prod.LHS = prefixed(prod.LHS)
+	prod.Pos = pos
+	prod.End = pos
+	for _, alt := range prod.RHS {
+		alt.Pos = pos
+		for _, elem := range alt.Elements {
+			if elem.ID != "" {
+				elem.ID = prefixed(elem.ID)
+			}
+			elem.Pos = pos
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func TestSnippetLibrariesAreWellFormed(t *testing.T) {
+	// usages are grammars that use all the symbols of each library, including nested uses.
+	usages := map[string]string{
+		"list": `
+#name test;
+
+#use list;
+
+s
+    : list semicolon trailing_comma_list semicolon call
+    ;
+call
+    : id l_paren optional_list r_paren
+    ;
+list_item
+    : id
+    | call
+    ;
+
+id
+    : "[a-z]+";
+semicolon
+    : ';';
+l_paren
+    : '(';
+r_paren
+    : ')';
+`,
+		"parens": `
+#name test;
+
+#use parens;
+
+s
+    : id parens
+    ;
+parens_item
+    : id
+    | comma
+    ;
+
+id
+    : "[a-z]+";
+comma
+    : ',';
+`,
+	}
+	for name := range snippetLibraries {
+		t.Run(name, func(t *testing.T) {
+			prods, err := parseSnippetLibrary(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(prods) == 0 {
+				t.Fatalf("the snippet library %v has no productions", name)
+			}
+
+			// The shipped libraries are in the canonical layout that `vartan fmt` prints.
+			ast, err := parser.Parse(strings.NewReader(snippetLibraries[name]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			err = parser.Format(&b, ast)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != snippetLibraries[name] {
+				t.Fatalf("the snippet library %v isn't in the canonical layout; format it using vartan fmt", name)
+			}
+
+			// The productions of a library must not cause any conflicts.
+			src, ok := usages[name]
+			if !ok {
+				t.Fatalf("the snippet library %v has no usage to test", name)
+			}
+			ast, err = parser.Parse(strings.NewReader(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gb := GrammarBuilder{
+				AST: ast,
+			}
+			_, report, err := gb.Build(EnableReporting())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range report.States {
+				if len(s.SRConflict) > 0 || len(s.RRConflict) > 0 {
+					t.Fatalf("the snippet library %v causes conflicts in state %v", name, s.Number)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Package normalizer provides a lexer.Normalizer using the normalization tables of golang.org/x/text/unicode/norm.
+// The lexer doesn't import this package so that programs using no normalize transformations, including lexers that
+// vartan-go generates, depend only on the standard library. Pass Default to a lexer using lexer.NormalizeWith option
+// to lex a grammar containing `#normalize` directives.
+package normalizer
+
+import (
+	"fmt"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Default converts values into the normalization forms that `#normalize` directives specify: nfc, nfd, nfkc, or nfkd.
+var Default lexer.Normalizer = textNormalizer{}
+
+type textNormalizer struct{}
+
+func (textNormalizer) Normalize(form string, v []byte) ([]byte, error) {
+	var f norm.Form
+	switch form {
+	case "nfc":
+		f = norm.NFC
+	case "nfd":
+		f = norm.NFD
+	case "nfkc":
+		f = norm.NFKC
+	case "nfkd":
+		f = norm.NFKD
+	default:
+		return nil, fmt.Errorf("unknown normalization form: %v", form)
+	}
+	return f.Bytes(v), nil
+}
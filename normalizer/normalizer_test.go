@@ -0,0 +1,75 @@
+package normalizer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	"github.com/nihei9/vartan/grammar/lexical"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		form  string
+		src   string
+		value string
+	}{
+		{form: "nfc", src: "cafe\u0301", value: "caf\u00e9"},
+		{form: "nfd", src: "caf\u00e9", value: "cafe\u0301"},
+		// U+FB01 LATIN SMALL LIGATURE FI is a compatibility character.
+		{form: "nfc", src: "\ufb01", value: "\ufb01"},
+		{form: "nfkc", src: "\ufb01e\u0301", value: "fi\u00e9"},
+		{form: "nfkd", src: "\ufb01\u00e9", value: "fie\u0301"},
+	}
+	for _, tt := range tests {
+		v, err := Default.Normalize(tt.form, []byte(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(v) != tt.value {
+			t.Errorf("unexpected value in %v; want: %q, got: %q", tt.form, tt.value, v)
+		}
+	}
+
+	_, err := Default.Normalize("foo", []byte("é"))
+	if err == nil {
+		t.Fatal("an unknown normalization form must be an error")
+	}
+}
+
+func TestDefault_Lexer(t *testing.T) {
+	lspec := &lexical.LexSpec{
+		Entries: []*lexical.LexEntry{
+			{
+				Kind:    spec.LexKindName("id"),
+				Pattern: `[^\u{0020}]+`,
+				Modes: []spec.LexModeName{
+					spec.LexModeNameDefault,
+				},
+				Transformations: []*spec.LexTransformation{
+					{
+						Name: "normalize",
+						Form: "nfc",
+					},
+				},
+			},
+		},
+	}
+	clspec, err, _ := lexical.Compile(lspec, lexical.CompressionLevelMax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	src := "café"
+	l, err := lexer.NewLexer(lexer.NewLexSpec(clspec), strings.NewReader(src), lexer.NormalizeWith(Default))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := l.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(tok.Lexeme) != src || string(tok.Value) != "caf\u00e9" {
+		t.Fatalf("unexpected token; want: %q (%q), got: %q (%q)", src, "caf\u00e9", tok.Lexeme, tok.Value)
+	}
+}
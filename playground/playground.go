@@ -0,0 +1,319 @@
+// Package playground compiles a grammar and parses an input in one call and returns the results in a form that can be
+// serialized to JSON. It does no I/O, so a web playground can call it through an HTTP server or a WebAssembly build.
+package playground
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	driver "github.com/nihei9/vartan/driver/parser"
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/normalizer"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+// Request is a pair of a grammar and an input.
+type Request struct {
+	// Grammar is a source of a grammar written in vartan's grammar language.
+	Grammar string `json:"grammar"`
+
+	// Input is a text the parser parses. When the grammar is lexer-only, the input is only tokenized.
+	Input string `json:"input"`
+
+	// CST makes the parser build a CST instead of an AST.
+	CST bool `json:"cst,omitempty"`
+
+	// Start is a non-terminal symbol to start parsing at. It must be the start symbol or a symbol declared by `#start`.
+	Start string `json:"start,omitempty"`
+}
+
+// Response holds the results of a request. When the grammar has errors, the response contains only diagnostics.
+type Response struct {
+	// Diagnostics holds errors in the grammar.
+	Diagnostics []*Diagnostic `json:"diagnostics,omitempty"`
+
+	// Conflicts is the number of conflicts resolved implicitly.
+	Conflicts int `json:"conflicts"`
+
+	// Tokens holds the tokens the parser read, including skipped ones.
+	Tokens []*Token `json:"tokens,omitempty"`
+
+	// Tree is a syntax tree of the input. A parser can build a tree even if syntax errors occur.
+	Tree *driver.Node `json:"tree,omitempty"`
+
+	// SyntaxErrors holds syntax errors in the input.
+	SyntaxErrors []*SyntaxError `json:"syntax_errors,omitempty"`
+
+	// Error is an error that prevented the parser from reading the whole input, such as a lexer error.
+	Error string `json:"error,omitempty"`
+}
+
+// Diagnostic is an error in a grammar. Row and Col are 1-based, and they are 0 when the error has no position.
+type Diagnostic struct {
+	Message string `json:"message"`
+	Row     int    `json:"row"`
+	Col     int    `json:"col"`
+}
+
+// Token is a token of an input. Row and Col are 0-based.
+type Token struct {
+	KindName string `json:"kind_name"`
+	Text     string `json:"text"`
+	Row      int    `json:"row"`
+	Col      int    `json:"col"`
+	Skip     bool   `json:"skip,omitempty"`
+	Invalid  bool   `json:"invalid,omitempty"`
+}
+
+// SyntaxError is an error in an input. Row and Col are 0-based.
+type SyntaxError struct {
+	Message           string   `json:"message"`
+	Row               int      `json:"row"`
+	Col               int      `json:"col"`
+	Token             string   `json:"token"`
+	ExpectedTerminals []string `json:"expected_terminals,omitempty"`
+}
+
+// CompileResult holds a compiled grammar or diagnostics of a grammar.
+type CompileResult struct {
+	// Grammar is a compiled grammar. It is nil when the grammar has errors.
+	Grammar *spec.CompiledGrammar `json:"grammar,omitempty"`
+
+	// Diagnostics holds errors in the grammar.
+	Diagnostics []*Diagnostic `json:"diagnostics,omitempty"`
+
+	// Conflicts is the number of conflicts resolved implicitly.
+	Conflicts int `json:"conflicts"`
+}
+
+// Run compiles the grammar of a request and parses the input with the compiled grammar.
+func Run(req *Request) *Response {
+	return RunContext(context.Background(), req)
+}
+
+// RunContext is Run with a context. When ctx is canceled or its deadline passes, the parser stops parsing, and
+// the response holds the error of ctx in its Error field. Compilation can't be canceled, so RunContext checks ctx only
+// after compilation.
+func RunContext(ctx context.Context, req *Request) *Response {
+	c := Compile(req.Grammar)
+	if len(c.Diagnostics) > 0 {
+		return &Response{
+			Diagnostics: c.Diagnostics,
+		}
+	}
+
+	res := ParseContext(ctx, c.Grammar, req)
+	res.Conflicts = c.Conflicts
+	return res
+}
+
+// Compile compiles a source of a grammar.
+func Compile(src string) *CompileResult {
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		return &CompileResult{
+			Diagnostics: toDiagnostics(err),
+		}
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, report, err := b.Build(grammar.EnableReporting())
+	if err != nil {
+		return &CompileResult{
+			Diagnostics: toDiagnostics(err),
+		}
+	}
+	return &CompileResult{
+		Grammar:   cg,
+		Conflicts: countImplicitlyResolvedConflicts(report),
+	}
+}
+
+func toDiagnostics(err error) []*Diagnostic {
+	var specErrs verr.SpecErrors
+	if errors.As(err, &specErrs) {
+		diags := make([]*Diagnostic, 0, len(specErrs))
+		for _, e := range specErrs {
+			diags = append(diags, toDiagnostic(e))
+		}
+		return diags
+	}
+	var specErr *verr.SpecError
+	if errors.As(err, &specErr) {
+		return []*Diagnostic{toDiagnostic(specErr)}
+	}
+	return []*Diagnostic{
+		{
+			Message: err.Error(),
+		},
+	}
+}
+
+func toDiagnostic(e *verr.SpecError) *Diagnostic {
+	msg := e.Cause.Error()
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return &Diagnostic{
+		Message: msg,
+		Row:     e.Row,
+		Col:     e.Col,
+	}
+}
+
+func countImplicitlyResolvedConflicts(report *spec.Report) int {
+	count := 0
+	for _, s := range report.States {
+		for _, c := range s.SRConflict {
+			if c.ResolvedBy == grammar.ResolvedByShift.Int() {
+				count++
+			}
+		}
+		for _, c := range s.RRConflict {
+			if c.ResolvedBy == grammar.ResolvedByProdOrder.Int() {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Parse parses the input of a request with a compiled grammar. It ignores the grammar source of the request. Because
+// the response doesn't come with compilation, its Diagnostics and Conflicts fields are always empty.
+func Parse(cg *spec.CompiledGrammar, req *Request) *Response {
+	return ParseContext(context.Background(), cg, req)
+}
+
+// ParseContext is Parse with a context. When ctx is canceled or its deadline passes, the parser stops parsing, and
+// the response holds the error of ctx in its Error field.
+func ParseContext(ctx context.Context, cg *spec.CompiledGrammar, req *Request) *Response {
+	res := &Response{}
+	err := cg.CheckCompatibility()
+	if err == nil {
+		err = cg.Validate()
+	}
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	err = ctx.Err()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if cg.IsLexerOnly() {
+		tokenize(ctx, cg, req, res)
+	} else {
+		parse(ctx, cg, req, res)
+	}
+	return res
+}
+
+func parse(ctx context.Context, cg *spec.CompiledGrammar, req *Request, res *Response) {
+
+	toks, err := driver.NewTokenStream(cg, strings.NewReader(req.Input), lexer.NormalizeWith(normalizer.Default))
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
+	gram := driver.NewGrammar(cg)
+	tb := driver.NewDefaultSyntaxTreeBuilder()
+	var treeAct *driver.SyntaxTreeActionSet
+	if req.CST {
+		treeAct = driver.NewCSTActionSet(gram, tb)
+	} else {
+		treeAct = driver.NewASTActionSet(gram, tb)
+	}
+	opts := []driver.ParserOption{
+		driver.SemanticAction(treeAct),
+		driver.TokenFilters(func(next driver.TokenStream) driver.TokenStream {
+			return driver.TokenStreamFunc(func() (driver.VToken, error) {
+				tok, err := next.Next()
+				if err == nil && !tok.EOF() {
+					res.Tokens = append(res.Tokens, toToken(cg, tok, gram.SkipTerminal(tok.TerminalID())))
+				}
+				return tok, err
+			})
+		}),
+	}
+	if req.Start != "" {
+		opts = append(opts, driver.EntryPoint(req.Start))
+	}
+	p, err := driver.NewParser(toks, gram, opts...)
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
+	err = p.ParseContext(ctx)
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	res.Tree = tb.Tree()
+	for _, synErr := range p.SyntaxErrors() {
+		res.SyntaxErrors = append(res.SyntaxErrors, &SyntaxError{
+			Message:           synErr.Message,
+			Row:               synErr.Row,
+			Col:               synErr.Col,
+			Token:             toToken(cg, synErr.Token, false).KindName,
+			ExpectedTerminals: synErr.ExpectedTerminals,
+		})
+	}
+}
+
+// tokenize splits an input of a lexer-only grammar into tokens.
+func tokenize(ctx context.Context, cg *spec.CompiledGrammar, req *Request, res *Response) {
+	lexSpec := lexer.NewLexSpec(cg.Lexical)
+	lex, err := lexer.NewLexer(lexSpec, strings.NewReader(req.Input), lexer.NormalizeWith(normalizer.Default))
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
+	for {
+		tok, err := lex.NextContext(ctx)
+		if err != nil {
+			res.Error = err.Error()
+			return
+		}
+		if tok.EOF {
+			return
+		}
+		t := &Token{
+			Text:    string(tok.Lexeme),
+			Row:     tok.Row,
+			Col:     tok.Col,
+			Invalid: tok.Invalid,
+		}
+		if tok.Invalid {
+			t.KindName = "<invalid>"
+		} else {
+			_, t.KindName = lexSpec.KindIDAndName(tok.ModeID, tok.ModeKindID)
+		}
+		res.Tokens = append(res.Tokens, t)
+	}
+}
+
+func toToken(cg *spec.CompiledGrammar, tok driver.VToken, skip bool) *Token {
+	row, col := tok.Position()
+	t := &Token{
+		Text:    string(tok.Lexeme()),
+		Row:     row,
+		Col:     col,
+		Skip:    skip,
+		Invalid: tok.Invalid(),
+	}
+	switch {
+	case tok.EOF():
+		t.KindName = "<eof>"
+	case tok.Invalid():
+		t.KindName = "<invalid>"
+	default:
+		t.KindName = cg.Syntactic.Terminals[tok.TerminalID()]
+	}
+	return t
+}
@@ -0,0 +1,152 @@
+package playground
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testGrammar = `
+#name test;
+
+expr
+    : expr add num
+    | num
+    ;
+
+ws #skip
+    : "[\u{0020}]+";
+add
+    : '+';
+num
+    : "[0-9]+";
+`
+
+func TestRun(t *testing.T) {
+	t.Run("the response has tokens and a tree", func(t *testing.T) {
+		res := Run(&Request{
+			Grammar: testGrammar,
+			Input:   "1 + 2",
+			CST:     true,
+		})
+		if len(res.Diagnostics) > 0 || len(res.SyntaxErrors) > 0 || res.Error != "" {
+			t.Fatalf("unexpected errors occurred: %+v", res)
+		}
+		var kinds []string
+		for _, tok := range res.Tokens {
+			if tok.Skip {
+				continue
+			}
+			kinds = append(kinds, tok.KindName)
+		}
+		if strings.Join(kinds, " ") != "num add num" {
+			t.Fatalf("unexpected tokens: %v", kinds)
+		}
+		if res.Tree == nil || res.Tree.KindName != "expr" || len(res.Tree.Children) != 3 {
+			t.Fatalf("unexpected tree: %+v", res.Tree)
+		}
+	})
+
+	t.Run("the response has syntax errors", func(t *testing.T) {
+		res := Run(&Request{
+			Grammar: testGrammar,
+			Input:   "1 +",
+		})
+		if len(res.SyntaxErrors) != 1 {
+			t.Fatalf("unexpected syntax errors: %+v", res.SyntaxErrors)
+		}
+		synErr := res.SyntaxErrors[0]
+		if synErr.Token != "<eof>" || len(synErr.ExpectedTerminals) != 1 || synErr.ExpectedTerminals[0] != "num" {
+			t.Fatalf("unexpected syntax error: %+v", synErr)
+		}
+	})
+
+	t.Run("the response has diagnostics of the grammar", func(t *testing.T) {
+		res := Run(&Request{
+			Grammar: `
+#name test;
+
+s
+    : undefined
+    ;
+`,
+		})
+		if len(res.Diagnostics) != 1 {
+			t.Fatalf("unexpected diagnostics: %+v", res.Diagnostics)
+		}
+		if d := res.Diagnostics[0]; d.Row != 5 || d.Message == "" {
+			t.Fatalf("unexpected diagnostic: %+v", d)
+		}
+		if res.Tokens != nil || res.Tree != nil {
+			t.Fatalf("the response must contain only diagnostics: %+v", res)
+		}
+	})
+
+	t.Run("a lexer-only grammar tokenizes the input", func(t *testing.T) {
+		res := Run(&Request{
+			Grammar: `
+#name test;
+
+word
+    : "[a-z]+";
+`,
+			Input: "abc!",
+		})
+		if len(res.Tokens) != 2 || res.Tokens[0].KindName != "word" || !res.Tokens[1].Invalid {
+			t.Fatalf("unexpected tokens: %+v", res.Tokens)
+		}
+	})
+
+	t.Run("a canceled context stops parsing", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		res := RunContext(ctx, &Request{
+			Grammar: testGrammar,
+			Input:   "1 + 2",
+		})
+		if res.Error != context.Canceled.Error() || res.Tree != nil {
+			t.Fatalf("unexpected response: %+v", res)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	s := httptest.NewServer(NewHandler())
+	defer s.Close()
+
+	body, err := json.Marshal(&Request{
+		Grammar: testGrammar,
+		Input:   "1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Post(s.URL+"/api/run", "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %v", res.Status)
+	}
+	r := &Response{}
+	err = json.NewDecoder(res.Body).Decode(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Tokens) != 1 || r.Tokens[0].KindName != "num" {
+		t.Fatalf("unexpected tokens: %+v", r.Tokens)
+	}
+
+	res, err = http.Get(s.URL + "/api/run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status: %v", res.Status)
+	}
+}
@@ -0,0 +1,148 @@
+package sentence
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/nihei9/vartan/normalizer"
+)
+
+type DifferenceKind string
+
+const (
+	DifferenceKindOnlyOldAccepts = DifferenceKind("only-old-accepts")
+	DifferenceKindOnlyNewAccepts = DifferenceKind("only-new-accepts")
+	DifferenceKindTreesDiffer    = DifferenceKind("trees-differ")
+)
+
+// Difference is an input that distinguishes two grammars. When only one grammar accepts the input, the tree of
+// the other grammar is nil.
+type Difference struct {
+	Kind    DifferenceKind
+	Input   []byte
+	OldTree *driver.Node
+	NewTree *driver.Node
+}
+
+// Diff searches for a short input that one grammar accepts and the other doesn't, or that the grammars parse into
+// different concrete syntax trees. Diff raises the depth limit of derivation trees one by one up to the max depth of
+// the generators, and at each depth, it tests `samples` sentences that each generator generates. Diff returns
+// the shortest distinguishing input found at the lowest depth, or nil when it finds no difference.
+//
+// Because the generators sample sentences randomly, Diff cannot prove that the grammars are equivalent.
+func Diff(oldGen, newGen *Generator, samples int) (*Difference, error) {
+	if samples < 1 {
+		return nil, fmt.Errorf("samples must be greater than or equal to 1: %v", samples)
+	}
+
+	maxDepth := oldGen.maxDepth
+	if newGen.maxDepth > maxDepth {
+		maxDepth = newGen.maxDepth
+	}
+	defer func(oldDepth, newDepth int) {
+		oldGen.maxDepth = oldDepth
+		newGen.maxDepth = newDepth
+	}(oldGen.maxDepth, newGen.maxDepth)
+
+	for depth := 1; depth <= maxDepth; depth++ {
+		oldGen.maxDepth = depth
+		newGen.maxDepth = depth
+
+		var shortest *Difference
+		for i := 0; i < samples; i++ {
+			for _, gen := range []*Generator{oldGen, newGen} {
+				src, ok, err := gen.generate()
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+				if shortest != nil && len(src) >= len(shortest.Input) {
+					continue
+				}
+				d, err := compare(oldGen, newGen, src)
+				if err != nil {
+					return nil, err
+				}
+				if d != nil {
+					shortest = d
+				}
+			}
+		}
+		if shortest != nil {
+			return shortest, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func compare(oldGen, newGen *Generator, src []byte) (*Difference, error) {
+	oldTree, err := oldGen.parse(src)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := newGen.parse(src)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case oldTree == nil && newTree == nil:
+		return nil, nil
+	case newTree == nil:
+		return &Difference{
+			Kind:    DifferenceKindOnlyOldAccepts,
+			Input:   src,
+			OldTree: oldTree,
+		}, nil
+	case oldTree == nil:
+		return &Difference{
+			Kind:    DifferenceKindOnlyNewAccepts,
+			Input:   src,
+			NewTree: newTree,
+		}, nil
+	}
+	if !equalTrees(oldTree, newTree) {
+		return &Difference{
+			Kind:    DifferenceKindTreesDiffer,
+			Input:   src,
+			OldTree: oldTree,
+			NewTree: newTree,
+		}, nil
+	}
+	return nil, nil
+}
+
+// parse parses a source into a concrete syntax tree. When the grammar doesn't accept the source, parse returns nil.
+func (g *Generator) parse(src []byte) (*driver.Node, error) {
+	toks, err := driver.NewTokenStream(g.cg, bytes.NewReader(src), lexer.NormalizeWith(normalizer.Default))
+	if err != nil {
+		return nil, err
+	}
+	tb := driver.NewDefaultSyntaxTreeBuilder()
+	p, err := driver.NewParser(toks, g.gram, driver.SemanticAction(driver.NewCSTActionSet(g.gram, tb)))
+	if err != nil {
+		return nil, err
+	}
+	err = p.Parse()
+	if err != nil || len(p.SyntaxErrors()) > 0 {
+		return nil, nil
+	}
+	return tb.Tree(), nil
+}
+
+// equalTrees compares the structures of trees. It ignores positions of nodes.
+func equalTrees(t1, t2 *driver.Node) bool {
+	if t1.Type != t2.Type || t1.KindName != t2.KindName || t1.Text != t2.Text || len(t1.Children) != len(t2.Children) {
+		return false
+	}
+	for i, c := range t1.Children {
+		if !equalTrees(c, t2.Children[i]) {
+			return false
+		}
+	}
+	return true
+}
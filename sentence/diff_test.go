@@ -0,0 +1,125 @@
+package sentence
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	const baseSpec = `
+#name test;
+
+#prec (
+    #left mul
+    #left add
+);
+
+expr
+    : expr add expr
+    | expr mul expr
+    | id
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+add
+    : '+';
+mul
+    : '*';
+id
+    : "[a-z]+";
+`
+
+	tests := []struct {
+		caption string
+		newSpec string
+		kind    DifferenceKind
+	}{
+		{
+			caption: "the grammars are the same",
+			newSpec: baseSpec,
+		},
+		{
+			caption: "the new grammar accepts more inputs",
+			newSpec: strings.Replace(baseSpec, "| id\n", "| id\n    | num\n", 1) + `
+num
+    : "[0-9]+";
+`,
+			kind: DifferenceKindOnlyNewAccepts,
+		},
+		{
+			caption: "the new grammar accepts fewer inputs",
+			newSpec: strings.Replace(baseSpec, "[a-z]+", "[a-f]+", 1),
+			kind:    DifferenceKindOnlyOldAccepts,
+		},
+		{
+			caption: "the grammars parse inputs into different trees",
+			newSpec: strings.Replace(baseSpec, "#left add", "#right add", 1),
+			kind:    DifferenceKindTreesDiffer,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			oldCG, oldReport := build(t, baseSpec)
+			newCG, newReport := build(t, tt.newSpec)
+			oldGen, err := NewGenerator(oldCG, oldReport, Seed(1), MaxDepth(6))
+			if err != nil {
+				t.Fatal(err)
+			}
+			newGen, err := NewGenerator(newCG, newReport, Seed(1), MaxDepth(6))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := Diff(oldGen, newGen, 50)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.kind == "" {
+				if d != nil {
+					t.Fatalf("unexpected difference: %v: %q", d.Kind, d.Input)
+				}
+				return
+			}
+			if d == nil {
+				t.Fatalf("a difference must be found")
+			}
+			if d.Kind != tt.kind {
+				t.Fatalf("unexpected difference kind; want: %v, got: %v: %q", tt.kind, d.Kind, d.Input)
+			}
+			oldTree, err := oldGen.parse(d.Input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			newTree, err := newGen.parse(d.Input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch d.Kind {
+			case DifferenceKindOnlyOldAccepts:
+				if oldTree == nil || newTree != nil {
+					t.Fatalf("only the old grammar must accept the input: %q", d.Input)
+				}
+			case DifferenceKindOnlyNewAccepts:
+				if oldTree != nil || newTree == nil {
+					t.Fatalf("only the new grammar must accept the input: %q", d.Input)
+				}
+			case DifferenceKindTreesDiffer:
+				if oldTree == nil || newTree == nil || equalTrees(oldTree, newTree) {
+					t.Fatalf("the grammars must parse the input into different trees: %q", d.Input)
+				}
+			}
+		})
+	}
+
+	t.Run("samples must be positive", func(t *testing.T) {
+		cg, report := build(t, baseSpec)
+		g, err := NewGenerator(cg, report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = Diff(g, g, 0)
+		if err == nil {
+			t.Fatal("an error must occur")
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package sentence
+
+import (
+	"math/rand"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+type transition struct {
+	b    byte
+	next lexer.StateID
+}
+
+// modeWalker generates lexemes of the kinds in a lex mode by walking the DFA of the mode randomly.
+type modeWalker struct {
+	spec    lexer.LexSpec
+	modeSpc *spec.CompiledLexModeSpec
+	mode    lexer.ModeID
+
+	// trans holds the transitions of each state.
+	trans [][]*transition
+
+	// dists caches the distances from each state to the accepting states of each mode kind. The distance is the number
+	// of bytes the lexer needs to read to reach an accepting state.
+	dists map[lexer.ModeKindID][]int
+
+	// keywords maps mode kinds of keywords to their lexemes.
+	keywords map[lexer.ModeKindID][]byte
+
+	// separator is a lexeme of a skipped kind that the generator puts between lexemes to keep them from merging.
+	separator []byte
+}
+
+func newModeWalker(s lexer.LexSpec, modeSpc *spec.CompiledLexModeSpec, mode lexer.ModeID) *modeWalker {
+	w := &modeWalker{
+		spec:     s,
+		modeSpc:  modeSpc,
+		mode:     mode,
+		trans:    make([][]*transition, len(modeSpc.DFA.AcceptingStates)),
+		dists:    map[lexer.ModeKindID][]int{},
+		keywords: map[lexer.ModeKindID][]byte{},
+	}
+	for state := range w.trans {
+		if state == spec.StateIDNil.Int() {
+			continue
+		}
+		for v := 0; v < 256; v++ {
+			next, ok := s.NextState(mode, lexer.StateID(state), v)
+			if !ok || next.Int() == spec.StateIDNil.Int() {
+				continue
+			}
+			w.trans[state] = append(w.trans[state], &transition{
+				b:    byte(v),
+				next: next,
+			})
+		}
+	}
+	for _, kws := range modeSpc.Keywords {
+		for lexeme, kw := range kws {
+			w.keywords[lexer.ModeKindID(kw.Int())] = []byte(lexeme)
+		}
+	}
+	return w
+}
+
+// modeKindOf returns a mode kind corresponding to a terminal symbol `term` in the mode.
+func (w *modeWalker) modeKindOf(term int, kindToTerm []int) (lexer.ModeKindID, bool) {
+	for modeKind := range w.modeSpc.KindNames {
+		if modeKind == spec.LexModeKindIDNil.Int() {
+			continue
+		}
+		kind, _ := w.spec.KindIDAndName(w.mode, lexer.ModeKindID(modeKind))
+		if kindToTerm[kind] == term {
+			return lexer.ModeKindID(modeKind), true
+		}
+	}
+	return 0, false
+}
+
+// lexeme generates a lexeme of a mode kind `modeKind`. It prefers printable ASCII characters, and it tries to finish
+// the lexeme once the lexeme becomes long.
+func (w *modeWalker) lexeme(modeKind lexer.ModeKindID, r *rand.Rand) ([]byte, bool) {
+	if kw, ok := w.keywords[modeKind]; ok {
+		return kw, true
+	}
+
+	dist := w.distances(modeKind)
+	state := w.spec.InitialState(w.mode)
+	if dist[state] == unreachable {
+		return nil, false
+	}
+	var lexeme []byte
+	for {
+		if dist[state] == 0 && (len(lexeme) >= maxLexemeLen || r.Intn(2) == 0) {
+			return lexeme, true
+		}
+
+		var cands []*transition
+		var printables []*transition
+		for _, t := range w.trans[state] {
+			d := dist[t.next]
+			if d == unreachable {
+				continue
+			}
+			if len(lexeme) >= maxLexemeLen && d >= dist[state] {
+				continue
+			}
+			cands = append(cands, t)
+			if t.b >= 0x20 && t.b <= 0x7e {
+				printables = append(printables, t)
+			}
+		}
+		if len(cands) == 0 {
+			if dist[state] == 0 {
+				return lexeme, true
+			}
+			return nil, false
+		}
+		if len(printables) > 0 && r.Intn(10) != 0 {
+			cands = printables
+		}
+		t := cands[r.Intn(len(cands))]
+		lexeme = append(lexeme, t.b)
+		state = t.next
+	}
+}
+
+func (w *modeWalker) distances(modeKind lexer.ModeKindID) []int {
+	if dist, ok := w.dists[modeKind]; ok {
+		return dist
+	}
+
+	rev := make([][]lexer.StateID, len(w.trans))
+	for state, ts := range w.trans {
+		for _, t := range ts {
+			rev[t.next] = append(rev[t.next], lexer.StateID(state))
+		}
+	}
+	dist := make([]int, len(w.trans))
+	var queue []lexer.StateID
+	for state := range dist {
+		dist[state] = unreachable
+		if state == spec.StateIDNil.Int() {
+			continue
+		}
+		if k, ok := w.spec.Accept(w.mode, lexer.StateID(state)); ok && k == modeKind {
+			dist[state] = 0
+			queue = append(queue, lexer.StateID(state))
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for _, prev := range rev[state] {
+			if dist[prev] != unreachable {
+				continue
+			}
+			dist[prev] = dist[state] + 1
+			queue = append(queue, prev)
+		}
+	}
+	w.dists[modeKind] = dist
+	return dist
+}
@@ -0,0 +1,369 @@
+// Package sentence generates random sentences that a grammar accepts. The sentences are useful for fuzzing programs
+// consuming a language and for checking whether a grammar accepts inputs as its author expects.
+package sentence
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/nihei9/vartan/normalizer"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+const (
+	defaultMaxDepth    = 10
+	defaultMaxAttempts = 100
+
+	// maxLexemeLen is the length in bytes beyond which the generator tries to finish a lexeme as soon as possible.
+	maxLexemeLen = 16
+
+	unreachable = math.MaxInt
+)
+
+type GeneratorOption func(g *Generator) error
+
+// Seed sets a seed of the random number generator. Generators having the same seed generate the same sentences.
+func Seed(seed int64) GeneratorOption {
+	return func(g *Generator) error {
+		g.rand = rand.New(rand.NewSource(seed))
+		return nil
+	}
+}
+
+// MaxDepth limits the depth of a derivation tree. When the generator reaches the depth, it chooses the alternatives
+// that finish the derivation the soonest. Thus, when the grammar needs a deeper tree to derive any sentence, the tree
+// can exceed the limit.
+func MaxDepth(depth int) GeneratorOption {
+	return func(g *Generator) error {
+		if depth < 1 {
+			return fmt.Errorf("a max depth must be greater than or equal to 1: %v", depth)
+		}
+		g.maxDepth = depth
+		return nil
+	}
+}
+
+// Weights sets weights of productions. The keys are production numbers, and a production without a weight has a weight
+// of 1. The generator chooses an alternative with a probability proportional to its weight. A production having
+// a weight of 0 is chosen only when the generator has no other choice.
+func Weights(weights map[int]int) GeneratorOption {
+	return func(g *Generator) error {
+		for prod, w := range weights {
+			if prod < 1 || prod >= len(g.report.Productions) || g.report.Productions[prod] == nil {
+				return fmt.Errorf("invalid production number: %v", prod)
+			}
+			if w < 0 {
+				return fmt.Errorf("a weight must be greater than or equal to 0: production: %v, weight: %v", prod, w)
+			}
+			g.weights[prod] = w
+		}
+		return nil
+	}
+}
+
+// MaxAttempts sets the number of times the generator tries to generate a sentence before it gives up.
+func MaxAttempts(n int) GeneratorOption {
+	return func(g *Generator) error {
+		if n < 1 {
+			return fmt.Errorf("max attempts must be greater than or equal to 1: %v", n)
+		}
+		g.maxAttempts = n
+		return nil
+	}
+}
+
+// Generator generates random sentences from a compiled grammar and its report. The report provides the right-hand
+// sides of productions, which the compiled grammar doesn't have. The generator derives a sequence of terminal symbols
+// from the start symbol and then generates a lexeme of each terminal symbol by walking the DFA of the lexer randomly.
+// Because a lexeme can merge with the adjacent lexemes or can be remapped to a keyword, the generator parses every
+// sentence it generates and retries when the parser doesn't accept it.
+//
+// The generator cannot generate terminal symbols that the lexer doesn't recognize, such as the error symbol and layout
+// symbols. It doesn't simulate lex mode switching that `#push` and `#pop` directives on alternatives cause.
+type Generator struct {
+	cg          *spec.CompiledGrammar
+	report      *spec.Report
+	gram        driver.Grammar
+	lexSpec     lexer.LexSpec
+	rand        *rand.Rand
+	maxDepth    int
+	maxAttempts int
+	weights     map[int]int
+
+	// prods holds productions indexed by the numbers of their LHS symbols.
+	prods [][]*spec.Production
+
+	// heights holds the minimum heights of derivation trees. Heights of productions are indexed by production numbers,
+	// and heights of non-terminal symbols are indexed by symbol numbers.
+	prodHeights    []int
+	nonTermHeights []int
+
+	modes []*modeWalker
+}
+
+func NewGenerator(cg *spec.CompiledGrammar, report *spec.Report, opts ...GeneratorOption) (*Generator, error) {
+	if cg.IsLexerOnly() {
+		return nil, fmt.Errorf("a lexer-only grammar cannot generate sentences: %v", cg.Name)
+	}
+	if report == nil {
+		return nil, fmt.Errorf("a report is required to generate sentences")
+	}
+	for _, s := range cg.Lexical.Specs {
+		if s != nil && s.NFA != nil {
+			return nil, fmt.Errorf("a grammar whose lexer builds DFAs at run time cannot generate sentences: %v", cg.Name)
+		}
+	}
+
+	g := &Generator{
+		cg:          cg,
+		report:      report,
+		gram:        driver.NewGrammar(cg),
+		lexSpec:     lexer.NewLexSpec(cg.Lexical),
+		rand:        rand.New(rand.NewSource(1)),
+		maxDepth:    defaultMaxDepth,
+		maxAttempts: defaultMaxAttempts,
+		weights:     map[int]int{},
+	}
+	for _, opt := range opts {
+		err := opt(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	g.prods = make([][]*spec.Production, len(report.NonTerminals))
+	for _, p := range report.Productions {
+		if p == nil {
+			continue
+		}
+		g.prods[p.LHS] = append(g.prods[p.LHS], p)
+	}
+	g.genHeights()
+	if g.nonTermHeights[g.startSymbol()] == unreachable {
+		return nil, fmt.Errorf("the start symbol cannot derive any sentence consisting of terminal symbols the lexer recognizes")
+	}
+
+	g.modes = make([]*modeWalker, len(cg.Lexical.ModeNames))
+	for mode := range cg.Lexical.ModeNames {
+		if mode == spec.LexModeIDNil.Int() {
+			continue
+		}
+		w := newModeWalker(g.lexSpec, cg.Lexical.Specs[mode], lexer.ModeID(mode))
+		for _, sep := range []string{" ", "\n", "\t"} {
+			kind, _, ok := lexer.Classify(g.lexSpec, lexer.ModeID(mode), []byte(sep))
+			// A separator must not be a terminal symbol the parser may accept, which a `#keep` directive makes.
+			if term := cg.Syntactic.KindToTerminal[kind]; ok && g.gram.SkipTerminal(term) && !g.gram.KeepTerminal(term) {
+				w.separator = []byte(sep)
+				break
+			}
+		}
+		g.modes[mode] = w
+	}
+
+	return g, nil
+}
+
+func (g *Generator) startSymbol() int {
+	return g.report.Productions[g.cg.Syntactic.StartProduction].RHS[0] * -1
+}
+
+// genHeights computes the minimum heights of derivation trees. A production containing a terminal symbol that the lexer
+// doesn't recognize has an unreachable height.
+func (g *Generator) genHeights() {
+	lexable := make([]bool, g.cg.Syntactic.TerminalCount)
+	for kind, term := range g.cg.Syntactic.KindToTerminal {
+		if kind == spec.LexKindIDNil.Int() {
+			continue
+		}
+		lexable[term] = true
+	}
+
+	g.prodHeights = make([]int, len(g.report.Productions))
+	for i := range g.prodHeights {
+		g.prodHeights[i] = unreachable
+	}
+	g.nonTermHeights = make([]int, len(g.report.NonTerminals))
+	for i := range g.nonTermHeights {
+		g.nonTermHeights[i] = unreachable
+	}
+	for {
+		changed := false
+	LOOP_PRODS:
+		for _, p := range g.report.Productions {
+			if p == nil {
+				continue
+			}
+			h := 1
+			for _, sym := range p.RHS {
+				if sym > 0 {
+					if !lexable[sym] {
+						continue LOOP_PRODS
+					}
+					continue
+				}
+				childH := g.nonTermHeights[sym*-1]
+				if childH == unreachable {
+					continue LOOP_PRODS
+				}
+				if childH+1 > h {
+					h = childH + 1
+				}
+			}
+			if h < g.prodHeights[p.Number] {
+				g.prodHeights[p.Number] = h
+				changed = true
+			}
+			if h < g.nonTermHeights[p.LHS] {
+				g.nonTermHeights[p.LHS] = h
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+}
+
+// Generate generates a sentence the grammar accepts.
+func (g *Generator) Generate() ([]byte, error) {
+	src, ok, err := g.generate()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("failed to generate a sentence in %v attempts", g.maxAttempts)
+	}
+	return src, nil
+}
+
+func (g *Generator) generate() ([]byte, bool, error) {
+	for i := 0; i < g.maxAttempts; i++ {
+		terms := g.derive(g.startSymbol(), 1, nil)
+		src, ok := g.lexemes(terms)
+		if !ok {
+			continue
+		}
+		accepted, err := g.accepts(src)
+		if err != nil {
+			return nil, false, err
+		}
+		if accepted {
+			return src, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// derive appends terminal symbols that a non-terminal symbol `nonTerm` derives to `terms`. `depth` is the depth of
+// the node of the non-terminal symbol.
+func (g *Generator) derive(nonTerm int, depth int, terms []int) []int {
+	prod := g.chooseProduction(nonTerm, depth)
+	for _, sym := range prod.RHS {
+		if sym > 0 {
+			terms = append(terms, sym)
+			continue
+		}
+		terms = g.derive(sym*-1, depth+1, terms)
+	}
+	return terms
+}
+
+func (g *Generator) chooseProduction(nonTerm int, depth int) *spec.Production {
+	var cands []*spec.Production
+	for _, p := range g.prods[nonTerm] {
+		h := g.prodHeights[p.Number]
+		if h == unreachable || depth+h-1 > g.maxDepth {
+			continue
+		}
+		cands = append(cands, p)
+	}
+	// When the remaining depth isn't enough, choose the alternatives finishing the derivation the soonest.
+	if len(cands) == 0 {
+		minH := g.nonTermHeights[nonTerm]
+		for _, p := range g.prods[nonTerm] {
+			if g.prodHeights[p.Number] == minH {
+				cands = append(cands, p)
+			}
+		}
+	}
+
+	total := 0
+	for _, p := range cands {
+		total += g.weight(p.Number)
+	}
+	if total == 0 {
+		return cands[g.rand.Intn(len(cands))]
+	}
+	n := g.rand.Intn(total)
+	for _, p := range cands {
+		n -= g.weight(p.Number)
+		if n < 0 {
+			return p
+		}
+	}
+	return cands[len(cands)-1]
+}
+
+func (g *Generator) weight(prod int) int {
+	if w, ok := g.weights[prod]; ok {
+		return w
+	}
+	return 1
+}
+
+// lexemes generates lexemes of terminal symbols `terms` while tracking the mode stack of the lexer. When a terminal
+// symbol isn't available in the current mode, this method returns false.
+func (g *Generator) lexemes(terms []int) ([]byte, bool) {
+	var b bytes.Buffer
+	modeStack := []lexer.ModeID{
+		g.lexSpec.InitialMode(),
+	}
+	for i, term := range terms {
+		mode := modeStack[len(modeStack)-1]
+		w := g.modes[mode]
+		if i > 0 {
+			b.Write(w.separator)
+		}
+		modeKind, ok := w.modeKindOf(term, g.cg.Syntactic.KindToTerminal)
+		if !ok {
+			return nil, false
+		}
+		lexeme, ok := w.lexeme(modeKind, g.rand)
+		if !ok {
+			return nil, false
+		}
+		b.Write(lexeme)
+
+		if g.lexSpec.Pop(mode, modeKind) {
+			modeStack = modeStack[:len(modeStack)-1]
+		}
+		if next, ok := g.lexSpec.Push(mode, modeKind); ok {
+			modeStack = append(modeStack, next)
+		}
+		if len(modeStack) == 0 {
+			return nil, false
+		}
+	}
+	return b.Bytes(), true
+}
+
+func (g *Generator) accepts(src []byte) (bool, error) {
+	toks, err := driver.NewTokenStream(g.cg, bytes.NewReader(src), lexer.NormalizeWith(normalizer.Default))
+	if err != nil {
+		return false, err
+	}
+	p, err := driver.NewParser(toks, g.gram)
+	if err != nil {
+		return false, err
+	}
+	err = p.Parse()
+	if err != nil {
+		// The lexer fails when a sentence pops all the modes. It means the parser doesn't accept the sentence.
+		return false, nil
+	}
+	return len(p.SyntaxErrors()) == 0, nil
+}
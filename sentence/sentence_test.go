@@ -0,0 +1,296 @@
+package sentence
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func build(t *testing.T, src string) (*spec.CompiledGrammar, *spec.Report) {
+	t.Helper()
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, report, err := b.Build(grammar.EnableReporting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cg, report
+}
+
+func findProduction(t *testing.T, report *spec.Report, lhs string, rhs ...string) int {
+	t.Helper()
+	for _, p := range report.Productions {
+		if p == nil || report.NonTerminals[p.LHS].Name != lhs || len(p.RHS) != len(rhs) {
+			continue
+		}
+		match := true
+		for i, sym := range p.RHS {
+			var name string
+			if sym > 0 {
+				name = report.Terminals[sym].Name
+			} else {
+				name = report.NonTerminals[sym*-1].Name
+			}
+			if name != rhs[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return p.Number
+		}
+	}
+	t.Fatalf("a production was not found: %v -> %v", lhs, rhs)
+	return 0
+}
+
+const exprSpec = `
+#name test;
+
+#prec (
+    #left mul div
+    #left add sub
+);
+
+stmts
+    : stmts stmt
+    | stmt
+    ;
+stmt
+    : if expr then stmts end
+    | id assign expr semi
+    ;
+expr
+    : expr add expr
+    | expr sub expr
+    | expr mul expr
+    | expr div expr
+    | l_paren expr r_paren
+    | id
+    | num
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+if
+    : 'if';
+then
+    : 'then';
+end
+    : 'end';
+assign
+    : '=';
+semi
+    : ';';
+add
+    : '+';
+sub
+    : '-';
+mul
+    : '*';
+div
+    : '/';
+l_paren
+    : '(';
+r_paren
+    : ')';
+id
+    : "[A-Za-z_][0-9A-Za-z_]*";
+num
+    : "0|[1-9][0-9]*";
+`
+
+func TestGenerator_Generate(t *testing.T) {
+	tests := []struct {
+		caption string
+		specSrc string
+	}{
+		{
+			caption: "a grammar containing keywords and operators",
+			specSrc: exprSpec,
+		},
+		{
+			caption: "a grammar switching lex modes",
+			specSrc: `
+#name test;
+
+strings
+    : strings string
+    | string
+    ;
+string
+    : str_open chars str_close
+    | str_open str_close
+    ;
+chars
+    : chars char
+    | chars escaped_char
+    | char
+    | escaped_char
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+str_open #push string
+    : '"';
+char #mode string
+    : "[^\"\\\\]+";
+escaped_char #mode string
+    : "\\\\[\"\\\\n]";
+str_close #mode string #pop
+    : '"';
+`,
+		},
+		{
+			caption: "a grammar without skipped kinds",
+			specSrc: `
+#name test;
+
+s
+    : a s b
+    | c
+    ;
+
+a
+    : 'a';
+b
+    : 'b';
+c
+    : 'c';
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			cg, report := build(t, tt.specSrc)
+			g, err := NewGenerator(cg, report, Seed(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 50; i++ {
+				src, err := g.Generate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				accepted, err := g.accepts(src)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !accepted {
+					t.Fatalf("the grammar doesn't accept a generated sentence: %q", src)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerator_Seed(t *testing.T) {
+	cg, report := build(t, exprSpec)
+	gen := func(seed int64) []byte {
+		g, err := NewGenerator(cg, report, Seed(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		for i := 0; i < 10; i++ {
+			src, err := g.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.Write(src)
+			b.WriteByte('\n')
+		}
+		return b.Bytes()
+	}
+	s1 := gen(100)
+	s2 := gen(100)
+	if !bytes.Equal(s1, s2) {
+		t.Fatalf("generators having the same seed must generate the same sentences:\n%s\n---\n%s", s1, s2)
+	}
+}
+
+func TestGenerator_MaxDepthAndWeights(t *testing.T) {
+	cg, report := build(t, exprSpec)
+	ifStmt := findProduction(t, report, "stmt", "if", "expr", "then", "stmts", "end")
+	paren := findProduction(t, report, "expr", "l_paren", "expr", "r_paren")
+	g, err := NewGenerator(cg, report, Seed(1), MaxDepth(4), Weights(map[int]int{
+		ifStmt: 0,
+		paren:  10,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parens := 0
+	for i := 0; i < 50; i++ {
+		src, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(src, []byte("if")) {
+			t.Fatalf("a production having a weight of 0 must not be chosen: %q", src)
+		}
+		parens += bytes.Count(src, []byte("("))
+	}
+	if parens == 0 {
+		t.Fatalf("a production having a heavy weight must be chosen")
+	}
+}
+
+func TestNewGenerator_Error(t *testing.T) {
+	cg, report := build(t, exprSpec)
+	tests := []struct {
+		caption string
+		opts    []GeneratorOption
+	}{
+		{
+			caption: "a max depth must be positive",
+			opts:    []GeneratorOption{MaxDepth(0)},
+		},
+		{
+			caption: "a weight must not be negative",
+			opts:    []GeneratorOption{Weights(map[int]int{1: -1})},
+		},
+		{
+			caption: "a weight must be given to a valid production",
+			opts:    []GeneratorOption{Weights(map[int]int{len(report.Productions): 1})},
+		},
+		{
+			caption: "max attempts must be positive",
+			opts:    []GeneratorOption{MaxAttempts(0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			_, err := NewGenerator(cg, report, tt.opts...)
+			if err == nil {
+				t.Fatal("an error must occur")
+			}
+		})
+	}
+
+	t.Run("the start symbol must derive a sentence the lexer recognizes", func(t *testing.T) {
+		cg, report := build(t, `
+#name test;
+
+s
+    : error a
+    ;
+
+a
+    : 'a';
+`)
+		_, err := NewGenerator(cg, report)
+		if err == nil {
+			t.Fatal("an error must occur")
+		}
+	})
+}
@@ -0,0 +1,300 @@
+// Package skeleton generates code from a compiled grammar using a skeleton, which is a text/template template that
+// a user supplies. Skeletons let organizations generate parsers for their own runtimes, or in other languages,
+// without forking vartan.
+//
+// # Data model
+//
+// A skeleton receives a *Data as its dot. The data model is a stable contract: later versions of vartan only add
+// fields to the types in this package and functions to Funcs, and they never rename or remove them or change their
+// meanings. Data.Grammar exposes the compiled grammar itself for what the data model doesn't cover, but the compiled
+// grammar doesn't belong to the contract; its fields change when the format version of compiled grammars changes.
+//
+// IDs and numbers are the ones that the driver and `vartan const` use. Lists in Data are in order of IDs or numbers
+// and exclude the nil entries, whose IDs and numbers are 0, so a list element isn't always at the index of its ID.
+// The parsing tables, on the other hand, are indexed directly by state and symbol numbers.
+package skeleton
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/nihei9/vartan/grammar/lexical"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+// Data is the data a skeleton receives.
+type Data struct {
+	// Name is the name of the grammar that the `#name` directive declares.
+	Name string
+
+	// Hash is the content hash of the compiled grammar. It is empty for a grammar compiled before vartan recorded
+	// hashes.
+	Hash string
+
+	// Package is the package or module name that the user passes to the generator. It may be empty.
+	Package string
+
+	// LexerOnly is true when the grammar has only lexical productions. Such a grammar has no terminal symbols,
+	// non-terminal symbols, productions, or parsing tables.
+	LexerOnly bool
+
+	// Modes are the lex modes.
+	Modes []*Mode
+
+	// Kinds are the lexical kinds, which the lexer gives tokens.
+	Kinds []*Kind
+
+	// Terminals are the terminal symbols, including the EOF symbol and the error symbol.
+	Terminals []*Terminal
+
+	// NonTerminals are the non-terminal symbols, including the augmented start symbols.
+	NonTerminals []*NonTerminal
+
+	// Productions are the productions, including the augmented start productions.
+	Productions []*Production
+
+	// Tables are the parsing tables. It is nil when the grammar is lexer-only.
+	Tables *Tables
+
+	// Grammar is the compiled grammar. It doesn't belong to the stable contract.
+	Grammar *spec.CompiledGrammar
+}
+
+// Mode is a lex mode.
+type Mode struct {
+	ID   int
+	Name string
+}
+
+// Kind is a lexical kind.
+type Kind struct {
+	ID   int
+	Name string
+
+	// Terminal is the number of the terminal symbol that the kind corresponds to. It is 0 when the grammar is
+	// lexer-only.
+	Terminal int
+}
+
+// Terminal is a terminal symbol.
+type Terminal struct {
+	Number int
+	Name   string
+
+	// Literal is the string literal defining the terminal symbol, such as `(`. It is empty when a pattern defines
+	// the terminal symbol.
+	Literal string
+
+	// Skip is true when the parser skips tokens of the terminal symbol, as the `#skip` directive specifies.
+	Skip bool
+
+	// EOF and Error are true for the EOF symbol and the error symbol, respectively.
+	EOF   bool
+	Error bool
+}
+
+// NonTerminal is a non-terminal symbol.
+type NonTerminal struct {
+	Number int
+	Name   string
+}
+
+// Production is a production, that is, an alternative of a non-terminal symbol.
+type Production struct {
+	Number int
+
+	// LHS and LHSName are the number and the name of the non-terminal symbol on the left-hand side.
+	LHS     int
+	LHSName string
+
+	// Alternative is the position of the production among the alternatives of its LHS, counting from 1. It is 0 for
+	// the augmented start productions, which don't appear in the grammar.
+	Alternative int
+
+	// Length is the number of symbols on the right-hand side, which a parser pops when it reduces the production.
+	Length int
+
+	// Label is the label that the `#label` directive gives the production. It may be empty.
+	Label string
+
+	// Recover is true when the production has the `#recover` directive.
+	Recover bool
+}
+
+// Tables are the LALR parsing tables.
+type Tables struct {
+	StateCount       int
+	TerminalCount    int
+	NonTerminalCount int
+	InitialState     int
+	StartProduction  int
+	EOF              int
+	Error            int
+
+	// Action is the ACTION table. An entry corresponding to a (state, terminal symbol) pair is at
+	// `state * TerminalCount + terminal`. A negative entry `-s` means shifting and going to a state `s`, a positive
+	// entry `p` means reducing a production `p`, and 0 means an error. Reducing the start production means accepting
+	// an input.
+	Action []int
+
+	// GoTo is the GOTO table. An entry corresponding to a (state, non-terminal symbol) pair is at
+	// `state * NonTerminalCount + nonTerminal`. 0 means no transition.
+	GoTo []int
+
+	// ErrorTrapperStates holds 1 for the states that can shift the error symbol, indexed by states.
+	ErrorTrapperStates []int
+}
+
+// NewData returns the data that a skeleton receives for a compiled grammar. `pkgName` becomes Data.Package.
+func NewData(cg *spec.CompiledGrammar, pkgName string) *Data {
+	d := &Data{
+		Name:      cg.Name,
+		Hash:      cg.Hash,
+		Package:   pkgName,
+		LexerOnly: cg.IsLexerOnly(),
+		Grammar:   cg,
+	}
+
+	lexSpec := cg.Lexical
+	for id, name := range lexSpec.ModeNames {
+		if id == spec.LexModeIDNil.Int() {
+			continue
+		}
+		d.Modes = append(d.Modes, &Mode{
+			ID:   id,
+			Name: name.String(),
+		})
+	}
+	for id, name := range lexSpec.KindNames {
+		if id == spec.LexKindIDNil.Int() {
+			continue
+		}
+		k := &Kind{
+			ID:   id,
+			Name: name.String(),
+		}
+		if !d.LexerOnly {
+			k.Terminal = cg.Syntactic.KindToTerminal[id]
+		}
+		d.Kinds = append(d.Kinds, k)
+	}
+	if d.LexerOnly {
+		return d
+	}
+
+	syn := cg.Syntactic
+	for num, name := range syn.Terminals {
+		if num == 0 {
+			continue
+		}
+		t := &Terminal{
+			Number: num,
+			Name:   name,
+			Skip:   syn.TerminalSkip[num] == 1,
+			EOF:    num == syn.EOFSymbol,
+			Error:  num == syn.ErrorSymbol,
+		}
+		if num < len(syn.TerminalLiterals) {
+			t.Literal = syn.TerminalLiterals[num]
+		}
+		d.Terminals = append(d.Terminals, t)
+	}
+	for num, name := range syn.NonTerminals {
+		if num == 0 {
+			continue
+		}
+		d.NonTerminals = append(d.NonTerminals, &NonTerminal{
+			Number: num,
+			Name:   name,
+		})
+	}
+	augStartProds := map[int]struct{}{
+		syn.StartProduction: {},
+	}
+	for _, e := range syn.EntryPoints {
+		augStartProds[e.StartProduction] = struct{}{}
+	}
+	altNums := map[int]int{}
+	for num, lhs := range syn.LHSSymbols {
+		if num == 0 {
+			continue
+		}
+		p := &Production{
+			Number:  num,
+			LHS:     lhs,
+			LHSName: syn.NonTerminals[lhs],
+			Length:  syn.AlternativeSymbolCounts[num],
+			Recover: syn.RecoverProductions[num] != 0,
+		}
+		if _, ok := augStartProds[num]; !ok {
+			altNums[lhs]++
+			p.Alternative = altNums[lhs]
+		}
+		if num < len(syn.AlternativeLabels) {
+			p.Label = syn.AlternativeLabels[num]
+		}
+		d.Productions = append(d.Productions, p)
+	}
+	d.Tables = &Tables{
+		StateCount:         syn.StateCount,
+		TerminalCount:      syn.TerminalCount,
+		NonTerminalCount:   syn.NonTerminalCount,
+		InitialState:       syn.InitialState,
+		StartProduction:    syn.StartProduction,
+		EOF:                syn.EOFSymbol,
+		Error:              syn.ErrorSymbol,
+		Action:             syn.Action,
+		GoTo:               syn.GoTo,
+		ErrorTrapperStates: syn.ErrorTrapperStates,
+	}
+	return d
+}
+
+// Funcs returns the functions available in skeletons in addition to the built-in functions of text/template:
+//
+//   - camel converts a snake case name into an upper camel case one, such as `l_paren` into `LParen`, as the
+//     generated Go code does.
+//   - quote quotes a string as a Go string literal, which is also valid in many other languages.
+//   - ints formats integers separated by commas, such as `1, -2, 3`, which is useful to write a table.
+//   - add returns the sum of integers.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"camel": lexical.SnakeCaseToUpperCamelCase,
+		"quote": strconv.Quote,
+		"ints": func(vs []int) string {
+			ss := make([]string, len(vs))
+			for i, v := range vs {
+				ss[i] = strconv.Itoa(v)
+			}
+			return strings.Join(ss, ", ")
+		},
+		"add": func(vs ...int) int {
+			sum := 0
+			for _, v := range vs {
+				sum += v
+			}
+			return sum
+		},
+	}
+}
+
+// Parse parses a skeleton. `name` is the name of the skeleton used in error messages, such as a file name.
+func Parse(name, src string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(Funcs()).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse a skeleton: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Execute applies a skeleton to the data of a compiled grammar and writes the output to `w`.
+func Execute(w io.Writer, tmpl *template.Template, cg *spec.CompiledGrammar, pkgName string) error {
+	err := tmpl.Execute(w, NewData(cg, pkgName))
+	if err != nil {
+		return fmt.Errorf("cannot execute a skeleton: %w", err)
+	}
+	return nil
+}
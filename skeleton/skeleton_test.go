@@ -0,0 +1,154 @@
+package skeleton
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nihei9/vartan/grammar"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+func compile(t *testing.T, src string) *spec.CompiledGrammar {
+	t.Helper()
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cg
+}
+
+func TestExecute(t *testing.T) {
+	cg := compile(t, `
+#name expr;
+
+expr
+    : expr add term
+    | term #recover
+    ;
+term
+    : l_paren expr r_paren
+    | id
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+add
+    : '+';
+l_paren
+    : '(';
+r_paren
+    : ')';
+id
+    : "[a-z]+";
+`)
+
+	tmpl, err := Parse("test", `package {{ .Package }} // {{ .Name }}
+{{ range .Kinds }}kind {{ camel .Name }} -> {{ (index $.Grammar.Syntactic.Terminals .Terminal) }}
+{{ end -}}
+{{ range .Terminals }}terminal {{ .Name }}{{ if .Literal }} {{ quote .Literal }}{{ end }}{{ if .Skip }} skip{{ end }}{{ if .EOF }} eof{{ end }}{{ if .Error }} error{{ end }}
+{{ end -}}
+{{ range .Productions }}{{ if .Alternative }}production {{ .LHSName }}{{ .Alternative }}: {{ .Length }}{{ if .Recover }} recover{{ end }}
+{{ end }}{{ end -}}
+tables: {{ .Tables.StateCount }} {{ len .Tables.Action }} {{ add .Tables.StateCount 1 }}
+goto: {{ ints .Tables.GoTo }}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	err = Execute(&b, tmpl, cg, "exprparser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	syn := cg.Syntactic
+	var gotos []string
+	for _, v := range syn.GoTo {
+		gotos = append(gotos, fmt.Sprint(v))
+	}
+	expected := fmt.Sprintf(`package exprparser // expr
+kind Ws -> ws
+kind Add -> add
+kind LParen -> l_paren
+kind RParen -> r_paren
+kind Id -> id
+terminal <eof> eof
+terminal error error
+terminal ws skip
+terminal add "+"
+terminal l_paren "("
+terminal r_paren ")"
+terminal id
+production expr1: 3
+production expr2: 1 recover
+production term1: 3
+production term2: 1
+tables: %v %v %v
+goto: %v
+`, syn.StateCount, len(syn.Action), syn.StateCount+1, strings.Join(gotos, ", "))
+	if b.String() != expected {
+		t.Fatalf("unexpected output:\nwant:\n%v\ngot:\n%v", expected, b.String())
+	}
+}
+
+func TestExecute_LexerOnly(t *testing.T) {
+	cg := compile(t, `
+#name lexer_only;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+id
+    : "[a-z]+";
+`)
+
+	tmpl, err := Parse("test", `{{ if .LexerOnly }}{{ range .Kinds }}{{ .Name }} {{ .Terminal }}
+{{ end }}{{ len .Terminals }} {{ .Tables }}{{ end }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	err = Execute(&b, tmpl, cg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "ws 0\nid 0\n0 <nil>"
+	if b.String() != expected {
+		t.Fatalf("unexpected output:\nwant:\n%v\ngot:\n%v", expected, b.String())
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	_, err := Parse("test", `{{ .Name `)
+	if err == nil {
+		t.Fatal("a malformed skeleton must be an error")
+	}
+
+	cg := compile(t, `
+#name test;
+
+s
+    : foo
+    ;
+
+foo
+    : 'foo';
+`)
+	tmpl, err := Parse("test", `{{ .Undefined }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	err = Execute(&b, tmpl, cg, "")
+	if err == nil {
+		t.Fatal("a skeleton referring to an undefined field must fail")
+	}
+}
@@ -6,6 +6,10 @@ type Terminal struct {
 	Pattern       string `json:"pattern"`
 	Precedence    int    `json:"prec"`
 	Associativity string `json:"assoc"`
+
+	// Literal is the string literal in syntactic rules that defined the terminal implicitly. It is empty when
+	// a lexical production defines the terminal.
+	Literal string `json:"literal,omitempty"`
 }
 
 type NonTerminal struct {
@@ -19,6 +23,7 @@ type Production struct {
 	RHS           []int  `json:"rhs"`
 	Precedence    int    `json:"prec"`
 	Associativity string `json:"assoc"`
+	Label         string `json:"label,omitempty"`
 }
 
 type Item struct {
@@ -43,6 +48,14 @@ type SRConflict struct {
 	AdoptedState      *int `json:"adopted_state"`
 	AdoptedProduction *int `json:"adopted_production"`
 	ResolvedBy        int  `json:"resolved_by"`
+
+	// SymbolPrecedence, SymbolAssociativity, ProductionPrecedence, and ProductionAssociativity are the precedences
+	// and the associativities the parser generator compared to resolve the conflict. A smaller precedence means
+	// a higher precedence, and 0 means the symbol or the production has no precedence.
+	SymbolPrecedence        int    `json:"symbol_precedence,omitempty"`
+	SymbolAssociativity     string `json:"symbol_associativity,omitempty"`
+	ProductionPrecedence    int    `json:"production_precedence,omitempty"`
+	ProductionAssociativity string `json:"production_associativity,omitempty"`
 }
 
 type RRConflict struct {
@@ -63,9 +76,87 @@ type State struct {
 	RRConflict []*RRConflict `json:"rr_conflict"`
 }
 
+// LexKindReport describes how the DFA of a lex mode recognizes a kind. Keywords don't have reports because the lexer
+// recognizes them by looking up keyword tables.
+type LexKindReport struct {
+	Name string `json:"name"`
+
+	// AcceptingStateCount is the number of DFA states accepting the kind. When it is 0, the lexer never produces
+	// the kind because other kinds always win.
+	AcceptingStateCount int `json:"accepting_state_count"`
+
+	// ReachingStateCount is the number of DFA states from which the lexer can reach a state accepting the kind.
+	ReachingStateCount int `json:"reaching_state_count"`
+
+	// ShadowedBy holds the kinds that win over the kind on some inputs both patterns match. When the lexer reads
+	// lexemes of the same length, a kind having a higher priority wins, and among kinds having the same priority, a kind
+	// defined earlier wins.
+	ShadowedBy []string `json:"shadowed_by,omitempty"`
+}
+
+// LexPriorityReport holds the priority of a kind that the `#priority` directive gives.
+type LexPriorityReport struct {
+	Kind     string `json:"kind"`
+	Priority int    `json:"priority"`
+}
+
+// LexBacktrackReport describes inputs on which the lexer reads beyond a lexeme of a kind and then goes back to the end
+// of the lexeme because it fails to find a longer lexeme. The lexer reads the bytes beyond the lexeme again as the
+// beginning of the next token.
+type LexBacktrackReport struct {
+	// Kind is the kind whose lexeme the lexer reads beyond.
+	Kind string `json:"kind"`
+
+	// Lexeme and Beyond are the shortest example. After reading Lexeme, the lexer reads Beyond and then may go back.
+	// They are quoted as Go string literals because they may contain bytes that aren't valid UTF-8.
+	Lexeme string `json:"lexeme"`
+	Beyond string `json:"beyond"`
+
+	// MaxRescan is the maximum number of bytes the lexer reads beyond a lexeme of the kind before going back. When it is
+	// -1, the number is unbounded, and tokenizing an input can take quadratic time in the length of the input.
+	MaxRescan int `json:"max_rescan"`
+}
+
+// LexTableSizeReport holds the number of integers the transition table of a lex mode consists of at each compression
+// level.
+type LexTableSizeReport struct {
+	Uncompressed    int `json:"uncompressed"`
+	UniqueEntries   int `json:"unique_entries"`
+	RowDisplacement int `json:"row_displacement"`
+	BaseCheck       int `json:"base_check"`
+}
+
+type LexModeReport struct {
+	Name       string           `json:"name"`
+	StateCount int              `json:"state_count"`
+	Kinds      []*LexKindReport `json:"kinds"`
+
+	// TableSizes is nil when Lazy is true.
+	TableSizes *LexTableSizeReport `json:"table_sizes,omitempty"`
+
+	// When Lazy is true, a lexer builds the DFA of the mode at run time, so the report has neither states nor kinds.
+	Lazy bool `json:"lazy,omitempty"`
+
+	// Backtracks holds the kinds whose lexemes the lexer may read beyond and go back. When it is empty, the lexer never
+	// backtracks in the mode.
+	Backtracks []*LexBacktrackReport `json:"backtracks,omitempty"`
+
+	// Priorities holds the kinds of the mode in the order in which they win over each other on lexemes of the same
+	// length. It is empty when no kind of the mode has the `#priority` directive, and then kinds defined earlier win.
+	Priorities []*LexPriorityReport `json:"priorities,omitempty"`
+}
+
+type LexicalReport struct {
+	Modes []*LexModeReport `json:"modes"`
+}
+
 type Report struct {
+	Metadata     *Metadata      `json:"metadata,omitempty"`
 	Terminals    []*Terminal    `json:"terminals"`
 	NonTerminals []*NonTerminal `json:"non_terminals"`
 	Productions  []*Production  `json:"productions"`
 	States       []*State       `json:"states"`
+	Lexical      *LexicalReport `json:"lexical,omitempty"`
+	SourceMap    *SourceMap     `json:"source_map,omitempty"`
+	BuildInfo    *BuildInfo     `json:"build_info,omitempty"`
 }
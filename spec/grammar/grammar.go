@@ -1,12 +1,159 @@
 package grammar
 
-import "strconv"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// FormatVersion is the version of the format of compiled grammars that this package defines. Increment it whenever
+	// a change to the format makes drivers misread compiled grammars of other versions.
+	//
+	// Version 4 adds the following fields, which drivers of version 3 ignore and then produce wrong syntax trees:
+	// `terminal_keep`, `kind_transformations` (including the `normalize` transformation), `error_sync_terminals`,
+	// `fallback_terminal`, and `case_insensitive_keywords`.
+	FormatVersion = 4
+
+	// MinFormatVersion is the oldest format version that drivers can read. Version 0 means a compiled grammar produced
+	// before vartan recorded format versions.
+	//
+	// Version 4 is the oldest one because a grammar of an older version lacks `case_insensitive_keywords`, so a lexer
+	// running case-insensitively would miss its case-configurable keywords. Compile such a grammar again.
+	MinFormatVersion = 4
+)
 
 type CompiledGrammar struct {
+	// FormatVersion is the version of the format the grammar was compiled in.
+	FormatVersion int `json:"format_version,omitempty"`
+
+	// Hash identifies the content of the grammar. Tools can use it as a key to cache results of parsing. See ComputeHash.
+	Hash string `json:"hash,omitempty"`
+
 	Name      string         `json:"name"`
+	Metadata  *Metadata      `json:"metadata,omitempty"`
 	Lexical   *LexicalSpec   `json:"lexical"`
-	Syntactic *SyntacticSpec `json:"syntactic"`
-	ASTAction *ASTAction     `json:"ast_action"`
+	Syntactic *SyntacticSpec `json:"syntactic,omitempty"`
+	ASTAction *ASTAction     `json:"ast_action,omitempty"`
+	SourceMap *SourceMap     `json:"source_map,omitempty"`
+	BuildInfo *BuildInfo     `json:"build_info,omitempty"`
+
+	// Alternatives is indexed by production numbers and describes the alternatives the productions come from. An entry
+	// is nil when the compiler generated the production, such as an augmented start production. Tools such as code
+	// generators can use it to learn which alternative a production number corresponds to without parsing the grammar
+	// source. The key `alternatives` and the fields of Alternative are stable; later versions only add fields.
+	Alternatives []*Alternative `json:"alternatives,omitempty"`
+}
+
+// IsLexerOnly returns true when the grammar has no syntactic part. A grammar consisting only of lexical productions
+// compiles into such a lexer-only grammar.
+func (g *CompiledGrammar) IsLexerOnly() bool {
+	return g.Syntactic == nil
+}
+
+// CheckCompatibility returns an error when drivers built with this package cannot read the grammar because of its
+// format version.
+func (g *CompiledGrammar) CheckCompatibility() error {
+	if g.FormatVersion < MinFormatVersion || g.FormatVersion > FormatVersion {
+		return fmt.Errorf("the compiled grammar has format version %v, but this driver supports format versions %v through %v; compile the grammar with the version of vartan matching the driver", g.FormatVersion, MinFormatVersion, FormatVersion)
+	}
+	return nil
+}
+
+// ComputeHash returns a SHA-256 hash of the content of the grammar in hex. The hash doesn't depend on the Hash field,
+// so the hash of a compiled grammar equals its Hash field unless the grammar is modified after compilation. The hash
+// doesn't depend on the SourceMap, BuildInfo, and Alternatives fields either because moving rules in a grammar source
+// or compiling a grammar with another version of vartan doesn't change how the grammar parses an input.
+func (g *CompiledGrammar) ComputeHash() (string, error) {
+	c := *g
+	c.Hash = ""
+	c.SourceMap = nil
+	c.BuildInfo = nil
+	c.Alternatives = nil
+	data, err := json.Marshal(&c)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// BuildInfo describes how a grammar was compiled, so a compiled grammar deployed alone can tell where it came from.
+// `vartan compile` embeds it only when `--embed-source` option is specified.
+type BuildInfo struct {
+	// Source is the grammar source the grammar was compiled from.
+	Source string `json:"source,omitempty"`
+
+	// VartanVersion is the version of vartan that compiled the grammar. It is "(devel)" when vartan was built from
+	// a source tree instead of being installed as a module of a specific version.
+	VartanVersion string `json:"vartan_version,omitempty"`
+
+	// Options holds the command-line options that affect the compiled grammar, such as `--lexer-table=base-check`.
+	Options []string `json:"options,omitempty"`
+}
+
+// SourceMap maps the elements of a compiled grammar back to their positions in the grammar source.
+type SourceMap struct {
+	// File is the name of the grammar source. It is empty when the compiler didn't know the name.
+	File string `json:"file,omitempty"`
+
+	// Productions is indexed by production numbers. The range of a production starts at its first element, or at
+	// the LHS of its rule when the alternative is empty, and has no end. An entry is nil when the compiler generated
+	// the production, such as an augmented start production.
+	Productions []*SourceRange `json:"productions,omitempty"`
+
+	// NonTerminals is indexed by non-terminal symbol numbers. The range of a non-terminal symbol covers its whole rule
+	// from the LHS to the semicolon. An entry is nil when the compiler generated the symbol.
+	NonTerminals []*SourceRange `json:"non_terminals,omitempty"`
+
+	// Kinds is indexed by lexical kind IDs. The range of a kind covers its whole lexical production.
+	Kinds []*SourceRange `json:"kinds,omitempty"`
+}
+
+// Alternative describes an alternative of a rule in a grammar source.
+type Alternative struct {
+	// LHS is the name of the non-terminal symbol on the left-hand side of the rule.
+	LHS string `json:"lhs"`
+
+	// Index is the position of the alternative in the rule, counting from 1.
+	Index int `json:"index"`
+
+	// Label is the label that the `#label` directive gives the alternative. It is empty when the alternative has no
+	// label.
+	Label string `json:"label,omitempty"`
+
+	// Directives are the directives of the alternative in the order they appear.
+	Directives []*AlternativeDirective `json:"directives,omitempty"`
+
+	// Position is the position of the alternative, which starts at its first element, or at the LHS of the rule when
+	// the alternative is empty, and has no end.
+	Position *SourceRange `json:"position,omitempty"`
+}
+
+// AlternativeDirective is a directive of an alternative, such as `#ast expr...`.
+type AlternativeDirective struct {
+	Name string `json:"name"`
+
+	// Parameters are the parameters as written in the grammar source, such as `expr...`, `'+'`, and `$high`.
+	Parameters []string `json:"parameters,omitempty"`
+}
+
+// SourceRange is a range in a grammar source. Rows and columns are 1-based. EndRow and EndCol point to the last
+// character of the range, and they are 0 when the range has no end.
+type SourceRange struct {
+	Row    int `json:"row"`
+	Col    int `json:"col"`
+	EndRow int `json:"end_row,omitempty"`
+	EndCol int `json:"end_col,omitempty"`
+}
+
+// Metadata represents provenance information of a grammar. A grammar specifies it using `#meta` directives.
+type Metadata struct {
+	Author  string `json:"author,omitempty"`
+	License string `json:"license,omitempty"`
+	Version string `json:"version,omitempty"`
 }
 
 // StateID represents an ID of a state of a transition table.
@@ -110,6 +257,17 @@ type UniqueEntriesTable struct {
 	EmptyValue                int                   `json:"empty_value"`
 }
 
+// BaseCheckTable is a transition table compressed by the two-level scheme. A lexer looks up the next state of a state
+// `s` reading a byte `b` as follows. When `Check[Base[s]+b]` is `s`, the next state is `Next[Base[s]+b]`. Otherwise,
+// the lexer looks up the next state of the state `Default[s]` reading `b` in the same way. When `Default[s]` is -1,
+// no next state exists.
+type BaseCheckTable struct {
+	Base    []int     `json:"base"`
+	Default []int     `json:"default"`
+	Next    []StateID `json:"next"`
+	Check   []int     `json:"check"`
+}
+
 type TransitionTable struct {
 	InitialStateID         StateID             `json:"initial_state_id"`
 	AcceptingStates        []LexModeKindID     `json:"accepting_states"`
@@ -117,13 +275,70 @@ type TransitionTable struct {
 	ColCount               int                 `json:"col_count"`
 	Transition             *UniqueEntriesTable `json:"transition,omitempty"`
 	UncompressedTransition []StateID           `json:"uncompressed_transition,omitempty"`
+
+	// BaseCheckTransition is used instead of Transition when the compression level is 3.
+	BaseCheckTransition *BaseCheckTable `json:"base_check_transition,omitempty"`
+
+	// CaseInsensitiveAcceptingStates is used instead of AcceptingStates when a lexer runs case-insensitively.
+	// This field is empty when no kind in the mode is case-configurable.
+	CaseInsensitiveAcceptingStates []LexModeKindID `json:"case_insensitive_accepting_states,omitempty"`
+}
+
+// NFA is a position automaton of the patterns of a lex mode. Each position reads a range of bytes or, when it is an end
+// marker, accepts a kind. A lexer regards a set of positions as a DFA state and builds the transitions of the state
+// the first time it reaches the state.
+type NFA struct {
+	// InitialPositions holds the positions a lexer is at before reading a token.
+	InitialPositions []int `json:"initial_positions"`
+
+	// From and To hold the range of bytes each position reads. The ranges of end markers are empty.
+	From []int `json:"from"`
+	To   []int `json:"to"`
+
+	// Follow holds the positions following each position.
+	Follow [][]int `json:"follow"`
+
+	// Accept holds the kind each end marker accepts. It is LexModeKindIDNil for the other positions.
+	Accept []LexModeKindID `json:"accept"`
+
+	// Folded holds the end markers of case-configurable patterns. They accept a kind only when a lexer runs
+	// case-insensitively.
+	Folded []int `json:"folded,omitempty"`
+
+	// Tags holds the indexes of the capture groups containing each position. Only the NFAs of LexCaptures have
+	// this field.
+	Tags [][]int `json:"tags,omitempty"`
 }
 
 type CompiledLexModeSpec struct {
 	KindNames []LexKindName    `json:"kind_names"`
 	Push      []LexModeID      `json:"push"`
 	Pop       []int            `json:"pop"`
-	DFA       *TransitionTable `json:"dfa"`
+	DFA       *TransitionTable `json:"dfa,omitempty"`
+
+	// NFA is available instead of DFA when the lexical specification is compiled with lazy DFA construction.
+	NFA *NFA `json:"nfa,omitempty"`
+
+	// Keywords is keyword tables indexed by mode kind IDs. Each table maps a lexeme to a mode kind ID of a keyword.
+	// This field is empty when no kind in the mode has keywords.
+	Keywords []map[string]LexModeKindID `json:"keywords,omitempty"`
+
+	// CaseInsensitiveKeywords is used in addition to Keywords when a lexer runs case-insensitively. Each table maps
+	// a lexeme that FoldKeyword folds to a mode kind ID of a case-configurable keyword. This field is empty when no
+	// keyword in the mode is case-configurable.
+	CaseInsensitiveKeywords []map[string]LexModeKindID `json:"case_insensitive_keywords,omitempty"`
+}
+
+// FoldKeyword folds the ASCII letters of a lexeme into lower case to look up the CaseInsensitiveKeywords tables.
+// Like case-configurable patterns, it leaves the other characters as they are.
+func FoldKeyword(lexeme string) string {
+	b := []byte(lexeme)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
 }
 
 type LexicalSpec struct {
@@ -133,28 +348,125 @@ type LexicalSpec struct {
 	KindIDs          [][]LexKindID          `json:"kind_ids"`
 	CompressionLevel int                    `json:"compression_level"`
 	Specs            []*CompiledLexModeSpec `json:"specs"`
+
+	// KindClasses holds the classes that `#class` directives assign to kinds for syntax highlighting, indexed by kind
+	// IDs. An empty string means a kind has no class. When no kind has a class, this field is nil.
+	KindClasses []string `json:"kind_classes,omitempty"`
+
+	// KindTransformations holds the transformations that directives such as `#trim` apply to lexemes, indexed by kind
+	// IDs. A lexer applies the transformations of a kind in order. When no kind has a transformation, this field is nil.
+	KindTransformations [][]*LexTransformation `json:"kind_transformations,omitempty"`
+
+	// KindCaptures holds the capture groups of the patterns of kinds, indexed by kind IDs. An element is nil when
+	// the pattern of a kind has no capture group. When no pattern has a capture group, this field is nil.
+	KindCaptures []*LexCaptures `json:"kind_captures,omitempty"`
+}
+
+// LexCaptures holds the capture groups `(?<name>...)` of the pattern of a kind.
+type LexCaptures struct {
+	// Names holds the names of the capture groups in the order their opening parentheses appear.
+	Names []string `json:"names"`
+
+	// NFA is the position automaton of the pattern. Its tags refer to the indexes of Names, and its end markers
+	// accept LexModeKindIDMin.
+	NFA *NFA `json:"nfa"`
+}
+
+// LexTransformation is a built-in transformation of lexemes.
+type LexTransformation struct {
+	// Name is the name of the transformation: trim, dedent, unescape, or normalize.
+	Name string `json:"name"`
+
+	// Delimiter is a string that an unescape transformation removes from both ends of a lexeme before interpreting
+	// escape sequences. An empty string means the transformation removes nothing.
+	Delimiter string `json:"delimiter,omitempty"`
+
+	// Form is a Unicode normalization form that a normalize transformation converts a lexeme into: nfc, nfd, nfkc,
+	// or nfkd.
+	Form string `json:"form,omitempty"`
 }
 
 type SyntacticSpec struct {
-	Action                  []int    `json:"action"`
-	GoTo                    []int    `json:"goto"`
-	StateCount              int      `json:"state_count"`
-	InitialState            int      `json:"initial_state"`
-	StartProduction         int      `json:"start_production"`
-	LHSSymbols              []int    `json:"lhs_symbols"`
-	AlternativeSymbolCounts []int    `json:"alternative_symbol_counts"`
-	Terminals               []string `json:"terminals"`
-	TerminalCount           int      `json:"terminal_count"`
-	TerminalSkip            []int    `json:"terminal_skip"`
-	KindToTerminal          []int    `json:"kind_to_terminal"`
-	NonTerminals            []string `json:"non_terminals"`
-	NonTerminalCount        int      `json:"non_terminal_count"`
-	EOFSymbol               int      `json:"eof_symbol"`
-	ErrorSymbol             int      `json:"error_symbol"`
-	ErrorTrapperStates      []int    `json:"error_trapper_states"`
-	RecoverProductions      []int    `json:"recover_productions"`
+	Action                  []int         `json:"action"`
+	GoTo                    []int         `json:"goto"`
+	StateCount              int           `json:"state_count"`
+	InitialState            int           `json:"initial_state"`
+	StartProduction         int           `json:"start_production"`
+	EntryPoints             []*EntryPoint `json:"entry_points,omitempty"`
+	LHSSymbols              []int         `json:"lhs_symbols"`
+	AlternativeSymbolCounts []int         `json:"alternative_symbol_counts"`
+	Terminals               []string      `json:"terminals"`
+	TerminalCount           int           `json:"terminal_count"`
+	TerminalSkip            []int         `json:"terminal_skip"`
+	KindToTerminal          []int         `json:"kind_to_terminal"`
+	NonTerminals            []string      `json:"non_terminals"`
+	NonTerminalCount        int           `json:"non_terminal_count"`
+	EOFSymbol               int           `json:"eof_symbol"`
+	ErrorSymbol             int           `json:"error_symbol"`
+	ErrorTrapperStates      []int         `json:"error_trapper_states"`
+	RecoverProductions      []int         `json:"recover_productions"`
+
+	// LexModeActions holds operations on the mode stack of the lexer that a parser performs when it shifts a terminal
+	// symbol. An entry corresponding to a (state, terminal symbol) pair is at `state * TerminalCount + terminal`, and
+	// it is the ID of a lex mode to push, -1 to pop a lex mode, or 0 to do nothing. When a grammar has no such
+	// operations, this field is nil.
+	LexModeActions []int `json:"lex_mode_actions,omitempty"`
+
+	// Layout holds the terminal symbols a `#layout` directive declares. When a grammar has no `#layout` directive,
+	// this field is nil.
+	Layout *Layout `json:"layout,omitempty"`
+
+	// FallbackTerminal is the terminal symbol a `#fallback` directive declares. A token stream maps invalid tokens to
+	// the terminal symbol so that grammars can handle them. When a grammar has no `#fallback` directive, this field is
+	// 0.
+	FallbackTerminal int `json:"fallback_terminal,omitempty"`
+
+	// ErrorSyncTerminals holds the synchronization sets of the error symbol that `#recover until` directives declare,
+	// indexed by states. After a parser shifts the error symbol into a state having a synchronization set, the parser
+	// discards tokens until it reads one of the terminal symbols in the set. When no state has a synchronization set,
+	// this field is nil.
+	ErrorSyncTerminals [][]int `json:"error_sync_terminals,omitempty"`
+
+	// TerminalLiterals holds the string literals defining terminal symbols, indexed by terminal symbol IDs. An empty
+	// string means a pattern defines a terminal symbol. Tools use the literals to show terminal symbols as users
+	// write them, such as `')'` instead of `r_paren`. When no string literal defines a terminal symbol, this field is
+	// nil.
+	TerminalLiterals []string `json:"terminal_literals,omitempty"`
+
+	// TerminalKeep holds 1 for the terminal symbols having the skip directive that `#keep` directives allow
+	// alternatives to contain, indexed by terminal symbol IDs. A parser doesn't skip tokens of such a terminal symbol
+	// when it can accept them in the current state. When no `#keep` directive exists, this field is nil.
+	TerminalKeep []int `json:"terminal_keep,omitempty"`
+
+	// AlternativeLabels holds the labels that `#label` directives give alternatives, indexed by production numbers. An
+	// empty string means a production has no label. When no production has a label, this field is nil.
+	AlternativeLabels []string `json:"alternative_labels,omitempty"`
+}
+
+// Layout holds terminal symbols a parser synthesizes from leading white spaces of lines instead of a lexer.
+type Layout struct {
+	Indent  int `json:"indent"`
+	Dedent  int `json:"dedent"`
+	Newline int `json:"newline"`
+}
+
+// EntryPoint is an additional entry point declared by a `#start` directive. A parser starting at InitialState accepts
+// an input when it reduces StartProduction.
+type EntryPoint struct {
+	Symbol          string `json:"symbol"`
+	InitialState    int    `json:"initial_state"`
+	StartProduction int    `json:"start_production"`
 }
 
 type ASTAction struct {
 	Entries [][]int `json:"entries"`
+
+	// NodeNames is a list of names of AST nodes indexed by production numbers. An empty string means a production
+	// doesn't rename its node. When no production renames its node, this field is nil.
+	NodeNames []string `json:"node_names,omitempty"`
+
+	// Lifts is a list of positions of elements that replace AST nodes, indexed by production numbers. The positions
+	// start from 1, and 0 means a production doesn't replace its node. When no production replaces its node, this
+	// field is nil.
+	Lifts []int `json:"lifts,omitempty"`
 }
@@ -0,0 +1,55 @@
+package grammar
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestCompiledGrammar_CheckCompatibility(t *testing.T) {
+	tests := []struct {
+		formatVersion int
+		ok            bool
+	}{
+		{
+			formatVersion: FormatVersion,
+			ok:            true,
+		},
+		// A grammar compiled before vartan recorded format versions has no `format_version` key. Drivers no longer read
+		// it because it may lack the tables later versions added.
+		{
+			formatVersion: 0,
+			ok:            false,
+		},
+		{
+			formatVersion: MinFormatVersion - 1,
+			ok:            false,
+		},
+		// A driver must reject a grammar compiled by a newer vartan because it may not know the tables the grammar
+		// consists of, such as the NFA of a lazily built DFA or the base/check tables.
+		{
+			formatVersion: FormatVersion + 1,
+			ok:            false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("format version %v", tt.formatVersion), func(t *testing.T) {
+			src := fmt.Sprintf(`{"format_version":%v,"name":"test","lexical":{}}`, tt.formatVersion)
+			var cg CompiledGrammar
+			err := json.Unmarshal([]byte(src), &cg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cg.FormatVersion != tt.formatVersion {
+				t.Fatalf("unexpected format version; want: %v, got: %v", tt.formatVersion, cg.FormatVersion)
+			}
+			err = cg.CheckCompatibility()
+			if tt.ok && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("an expected error didn't occur")
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package parser
+
+import (
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Format writes a grammar in the canonical layout. The top-level directives come first, and `#name` directive
+// precedes the others sorted by their names. Productions and fragments keep their order, and each alternative of
+// a production is on its own line, indented by a tab. The directives of alternatives of a production are aligned in
+// a column. Format keeps comments and at most one blank line between top-level items.
+func Format(w io.Writer, root *RootNode) error {
+	f := &formatter{}
+	f.formatRoot(root)
+	_, err := io.WriteString(w, f.String())
+	return err
+}
+
+type formattedLine struct {
+	indent  int
+	text    string
+	comment string
+
+	// commentRow is the row where the trailing comment appears in the source.
+	commentRow int
+}
+
+type formatter struct {
+	lines []*formattedLine
+
+	// comments holds the comments of the top-level item the formatter is writing that it hasn't written yet.
+	comments []*CommentNode
+
+	// itemLineCount is the number of lines the formatter has written for the current top-level item.
+	itemLineCount int
+
+	// lastRow is the last row of the source the formatter has written. The formatter keeps a blank line between
+	// top-level lines when the source has one. It is 0 when the formatter doesn't keep a blank line.
+	lastRow int
+}
+
+// topLevelItem is a top-level directive, a fragment, or a production with the comments in and before it.
+type topLevelItem struct {
+	dir      *DirectiveNode
+	frag     *FragmentNode
+	prod     *ProductionNode
+	start    Position
+	end      Position
+	comments []*CommentNode
+}
+
+func (f *formatter) formatRoot(root *RootNode) {
+	var items []*topLevelItem
+	for _, dir := range root.Directives {
+		items = append(items, &topLevelItem{
+			dir:   dir,
+			start: dir.Pos,
+			end:   dir.End,
+		})
+	}
+	for _, frag := range root.Fragments {
+		items = append(items, &topLevelItem{
+			frag:  frag,
+			start: frag.Pos,
+			end:   frag.End,
+		})
+	}
+	for _, prods := range [][]*ProductionNode{root.Productions, root.LexProductions} {
+		for _, prod := range prods {
+			items = append(items, &topLevelItem{
+				prod:  prod,
+				start: prod.Pos,
+				end:   prod.End,
+			})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].start.before(items[j].start)
+	})
+
+	// A comment belongs to the first item ending after it. A trailing comment on the line where an item ends belongs
+	// to the item too.
+	var tail []*CommentNode
+	{
+		k := 0
+		for _, c := range root.Comments {
+			for k < len(items) && !c.Pos.before(items[k].end) && !(c.Trailing && c.Pos.Row == items[k].end.Row) {
+				k++
+			}
+			if k == len(items) {
+				tail = append(tail, c)
+				continue
+			}
+			items[k].comments = append(items[k].comments, c)
+		}
+	}
+
+	// The comments at the beginning of a grammar followed by a blank line stay at the beginning, such as a license
+	// header. The other comments move with the items following them.
+	if len(items) > 0 {
+		first := items[0]
+		n := 0
+		for i, c := range first.comments {
+			if !c.Pos.before(first.start) {
+				break
+			}
+			nextRow := first.start.Row
+			if i+1 < len(first.comments) && first.comments[i+1].Pos.before(first.start) {
+				nextRow = first.comments[i+1].Pos.Row
+			}
+			if nextRow > c.Pos.Row+1 {
+				n = i + 1
+			}
+		}
+		f.comments = first.comments[:n]
+		first.comments = first.comments[n:]
+		f.flushComments(Position{Row: math.MaxInt}, 0)
+		f.blank()
+	}
+
+	var dirs []*topLevelItem
+	var others []*topLevelItem
+	for _, item := range items {
+		if item.dir != nil {
+			dirs = append(dirs, item)
+		} else {
+			others = append(others, item)
+		}
+	}
+	sort.SliceStable(dirs, func(i, j int) bool {
+		if dirs[i].dir.Name == "name" || dirs[j].dir.Name == "name" {
+			return dirs[i].dir.Name == "name" && dirs[j].dir.Name != "name"
+		}
+		return dirs[i].dir.Name < dirs[j].dir.Name
+	})
+
+	prevMultiLine := false
+	for i, item := range dirs {
+		multiLine := hasGroup(item.dir)
+		if i > 0 && (dirs[i-1].dir.Name == "name" || multiLine || prevMultiLine) {
+			f.blank()
+		}
+		f.beginItem(item, 0)
+		f.formatTopLevelDirective(item.dir)
+		f.endItem()
+		prevMultiLine = multiLine
+	}
+	if len(dirs) > 0 {
+		f.blank()
+	}
+	lastRow := 0
+	for _, item := range others {
+		f.beginItem(item, lastRow)
+		if item.frag != nil {
+			f.formatFragment(item.frag)
+		} else {
+			f.formatProduction(item.prod)
+		}
+		f.endItem()
+		lastRow = f.lastRow
+	}
+	f.comments = tail
+	f.itemLineCount = 0
+	f.flushComments(Position{Row: math.MaxInt}, 0)
+}
+
+func (f *formatter) beginItem(item *topLevelItem, lastRow int) {
+	f.comments = item.comments
+	f.itemLineCount = 0
+	f.lastRow = lastRow
+}
+
+func (f *formatter) endItem() {
+	f.flushComments(Position{Row: math.MaxInt}, 0)
+}
+
+func (f *formatter) formatTopLevelDirective(dir *DirectiveNode) {
+	var b strings.Builder
+	b.WriteString("#" + dir.Name)
+	for _, param := range dir.Parameters {
+		if len(param.Group) == 0 {
+			b.WriteString(" " + FormatParameter(param))
+			continue
+		}
+		b.WriteString(" (")
+		f.line(dir.Pos, 0, b.String())
+		b.Reset()
+		for _, d := range param.Group {
+			f.line(d.Pos, 1, formatDirective(d))
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(";")
+	pos := dir.Pos
+	if hasGroup(dir) {
+		pos = dir.End
+	}
+	f.line(pos, 0, b.String())
+}
+
+func (f *formatter) formatFragment(frag *FragmentNode) {
+	f.line(frag.Pos, 0, "fragment "+frag.LHS)
+	rhs := formatPattern(frag.RHS)
+	if frag.Literal != "" {
+		rhs = formatString(frag.Literal)
+	}
+	f.line(frag.End, 1, ": "+rhs+";")
+}
+
+func (f *formatter) formatProduction(prod *ProductionNode) {
+	lhs := prod.LHS
+	for _, dir := range prod.Directives {
+		lhs += " " + formatDirective(dir)
+	}
+	f.line(prod.Pos, 0, lhs)
+
+	if prod.IsLexical() && len(prod.RHS) == 1 {
+		f.line(prod.RHS[0].Elements[0].Pos, 1, ": "+formatAlternative(prod.RHS[0], 0)+";")
+		return
+	}
+
+	width := 0
+	for _, alt := range prod.RHS {
+		if len(alt.Directives) == 0 {
+			continue
+		}
+		if w := utf8.RuneCountInString(formatElements(alt)); w > width {
+			width = w
+		}
+	}
+	for i, alt := range prod.RHS {
+		op := "|"
+		if i == 0 {
+			op = ":"
+		}
+		pos := alt.Pos
+		if pos.Row == 0 && len(alt.Directives) > 0 {
+			pos = alt.Directives[0].Pos
+		}
+		f.line(pos, 1, strings.TrimRight(op+" "+formatAlternative(alt, width), " "))
+	}
+	f.line(prod.End, 1, ";")
+}
+
+// formatAlternative formats an alternative. When `width` is greater than the width of the elements, the function
+// pads them with spaces so that the directives start at the same column.
+func formatAlternative(alt *AlternativeNode, width int) string {
+	elems := formatElements(alt)
+	if len(alt.Directives) == 0 {
+		return elems
+	}
+	var dirs []string
+	for _, dir := range alt.Directives {
+		dirs = append(dirs, formatDirective(dir))
+	}
+	if pad := width - utf8.RuneCountInString(elems); pad > 0 {
+		elems += strings.Repeat(" ", pad)
+	}
+	if elems == "" {
+		return strings.Join(dirs, " ")
+	}
+	return elems + " " + strings.Join(dirs, " ")
+}
+
+func formatElements(alt *AlternativeNode) string {
+	var elems []string
+	for _, elem := range alt.Elements {
+		var s string
+		switch {
+		case elem.ID != "":
+			s = elem.ID
+		case elem.Literally:
+			s = formatString(elem.Pattern)
+		default:
+			s = formatPattern(elem.Pattern)
+		}
+		if elem.Label != nil {
+			s += "@" + elem.Label.Name
+		}
+		elems = append(elems, s)
+	}
+	return strings.Join(elems, " ")
+}
+
+func formatDirective(dir *DirectiveNode) string {
+	var b strings.Builder
+	b.WriteString("#" + dir.Name)
+	for _, param := range dir.Parameters {
+		b.WriteString(" " + FormatParameter(param))
+	}
+	return b.String()
+}
+
+// FormatParameter returns a directive parameter as written in a grammar source, such as `expr...`, `'+'`, `$high`, or
+// `:additive`.
+func FormatParameter(param *ParameterNode) string {
+	var s string
+	switch {
+	case param.ID != "":
+		s = param.ID
+	case param.Number != "":
+		s = param.Number
+	case param.Pattern != "":
+		s = formatPattern(param.Pattern)
+	case param.String != "":
+		s = formatString(param.String)
+	case param.OrderedSymbol != "":
+		s = "$" + param.OrderedSymbol
+	case param.LevelName != "":
+		s = ":" + param.LevelName
+	case param.Group != nil:
+		var dirs []string
+		for _, dir := range param.Group {
+			dirs = append(dirs, formatDirective(dir))
+		}
+		s = "(" + strings.Join(dirs, " ") + ")"
+	}
+	if param.Expansion {
+		s += "..."
+	}
+	return s
+}
+
+// formatPattern encloses a pattern in double quotes. The lexer of the grammar interprets `\"` in a pattern as `"`, so
+// the function escapes double quotes.
+func formatPattern(pat string) string {
+	return `"` + strings.ReplaceAll(pat, `"`, `\"`) + `"`
+}
+
+func formatString(str string) string {
+	return "'" + str + "'"
+}
+
+func hasGroup(dir *DirectiveNode) bool {
+	for _, param := range dir.Parameters {
+		if len(param.Group) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// line writes a line representing the source at `pos` after the comments preceding `pos`.
+func (f *formatter) line(pos Position, indent int, text string) {
+	f.flushComments(pos, indent)
+	f.keepBlank(pos.Row, indent)
+	f.lines = append(f.lines, &formattedLine{
+		indent: indent,
+		text:   text,
+	})
+	f.itemLineCount++
+}
+
+// flushComments writes the comments preceding `pos`. A trailing comment follows the last line of the current item
+// when the line has no comment or has the comments on the same row as the trailing comment.
+func (f *formatter) flushComments(pos Position, indent int) {
+	for len(f.comments) > 0 && f.comments[0].Pos.before(pos) {
+		c := f.comments[0]
+		f.comments = f.comments[1:]
+		if c.Trailing && f.itemLineCount > 0 {
+			last := f.lines[len(f.lines)-1]
+			if last.text != "" && last.comment == "" {
+				last.comment = c.Text
+				last.commentRow = c.Pos.Row
+				continue
+			}
+			// A line can have a block comment followed by another comment.
+			if last.text != "" && last.commentRow == c.Pos.Row {
+				last.comment += " " + c.Text
+				continue
+			}
+		}
+		f.keepBlank(c.Pos.Row, indent)
+		f.lines = append(f.lines, &formattedLine{
+			indent: indent,
+			text:   c.Text,
+		})
+		f.itemLineCount++
+	}
+}
+
+// keepBlank writes a blank line when the source has blank lines between the last top-level line and a top-level line
+// at `row`.
+func (f *formatter) keepBlank(row int, indent int) {
+	if row == 0 {
+		return
+	}
+	if f.lastRow > 0 && indent == 0 && row > f.lastRow+1 {
+		f.blank()
+	}
+	if row > f.lastRow {
+		f.lastRow = row
+	}
+}
+
+func (f *formatter) blank() {
+	if len(f.lines) == 0 || f.lines[len(f.lines)-1].text == "" {
+		return
+	}
+	f.lines = append(f.lines, &formattedLine{})
+}
+
+func (f *formatter) String() string {
+	lines := f.lines
+	for len(lines) > 0 && lines[len(lines)-1].text == "" {
+		lines = lines[:len(lines)-1]
+	}
+	var b strings.Builder
+	for _, l := range lines {
+		if l.text != "" {
+			b.WriteString(strings.Repeat("\t", l.indent))
+			b.WriteString(l.text)
+		}
+		if l.comment != "" {
+			b.WriteString(" " + l.comment)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (p Position) before(q Position) bool {
+	return p.Row < q.Row || p.Row == q.Row && p.Col < q.Col
+}
@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		caption  string
+		src      string
+		expected string
+	}{
+		{
+			caption: "productions are indented and directives of alternatives are aligned",
+			src: `#name test;
+expr: expr add expr | expr mul expr
+| func_call | l_paren expr r_paren #ast expr;
+func_call: id l_paren args r_paren #ast id args | id l_paren r_paren #ast id;
+opt: a@x b | ;
+ws #skip: "[\u{0009}\u{0020}]+";
+`,
+			expected: `#name test;
+
+expr
+	: expr add expr
+	| expr mul expr
+	| func_call
+	| l_paren expr r_paren #ast expr
+	;
+func_call
+	: id l_paren args r_paren #ast id args
+	| id l_paren r_paren      #ast id
+	;
+opt
+	: a@x b
+	|
+	;
+ws #skip
+	: "[\u{0009}\u{0020}]+";
+`,
+		},
+		{
+			caption: "#name directive comes first and the other top-level directives are sorted by their names",
+			src: `#prec (#left mul div #right pow);
+#omit_punctuation;
+#name test;
+#keywords id 'if' 'else';
+
+s: id;
+`,
+			expected: `#name test;
+
+#keywords id 'if' 'else';
+#omit_punctuation;
+
+#prec (
+	#left mul div
+	#right pow
+);
+
+s
+	: id
+	;
+`,
+		},
+		{
+			caption: "comments are kept",
+			src: `// A header comment
+
+#name test;
+
+#prec (
+    // Operators
+    #left mul // multiplicative
+    #left add
+);
+
+// The start symbol.
+s
+    : a // first
+    // before b
+    | b
+    ; // end of s
+
+
+// Lexical productions
+a: 'a';
+b: "b"; // trailing
+// last comment
+`,
+			expected: `// A header comment
+
+#name test;
+
+#prec (
+	// Operators
+	#left mul // multiplicative
+	#left add
+);
+
+// The start symbol.
+s
+	: a // first
+	// before b
+	| b
+	; // end of s
+
+// Lexical productions
+a
+	: 'a';
+b
+	: "b"; // trailing
+// last comment
+`,
+		},
+		{
+			caption: "block comments are kept",
+			src: `#name test; /* name */
+
+#prec (
+    /*
+    #left mul
+    */
+    #left add /* additive */ // add
+);
+
+s
+    : a /* first */
+    ;
+
+a: '/*'; /* trailing */
+`,
+			expected: `#name test; /* name */
+
+#prec (
+	/*
+    #left mul
+    */
+	#left add /* additive */ // add
+);
+
+s
+	: a /* first */
+	;
+
+a
+	: '/*'; /* trailing */
+`,
+		},
+		{
+			caption: "fragments, escaped double quotes, ordered symbols, and expansions are kept",
+			src: `fragment digit: "[0-9]";
+fragment quote: '"';
+s: list #ast list...;
+list: list elem #ast list... elem | elem #prec $x;
+str: "\"[^\"]*\"";
+`,
+			expected: `fragment digit
+	: "[0-9]";
+fragment quote
+	: '"';
+s
+	: list #ast list...
+	;
+list
+	: list elem #ast list... elem
+	| elem      #prec $x
+	;
+str
+	: "\"[^\"]*\"";
+`,
+		},
+		{
+			caption: "level names of precedence levels are kept",
+			src: `#prec (#left :additive add);
+s: s add s #prec :additive | a;
+`,
+			expected: `#prec (
+	#left :additive add
+);
+
+s
+	: s add s #prec :additive
+	| a
+	;
+`,
+		},
+		{
+			caption: "lexical productions having multiple alternatives are laid out like syntactic ones",
+			src: `s: bool;
+bool #class literal: 'true' | 'false';
+`,
+			expected: `s
+	: bool
+	;
+bool #class literal
+	: 'true'
+	| 'false'
+	;
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			root, err := Parse(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			err = Format(&b, root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatted := b.String()
+			if formatted != tt.expected {
+				t.Fatalf("unexpected result;\nwant:\n%v\ngot:\n%v", tt.expected, formatted)
+			}
+
+			// The formatted grammar must be the same grammar except for the order of the top-level directives, and
+			// formatting it again must not change it.
+			root2, err := Parse(strings.NewReader(formatted))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sortedDirs := func(root *RootNode) []*DirectiveNode {
+				dirs := append([]*DirectiveNode{}, root.Directives...)
+				sort.SliceStable(dirs, func(i, j int) bool {
+					return dirs[i].Name < dirs[j].Name
+				})
+				return dirs
+			}
+			if len(root2.Directives) != len(root.Directives) {
+				t.Fatalf("unexpected length of top-level directives; want: %v, got: %v", len(root.Directives), len(root2.Directives))
+			}
+			testDirectives(t, sortedDirs(root2), sortedDirs(root), false)
+			withoutDirs := func(root *RootNode) *RootNode {
+				r := *root
+				r.Directives = nil
+				return &r
+			}
+			testRootNode(t, withoutDirs(root2), withoutDirs(root), false)
+			if len(root2.Comments) != len(root.Comments) {
+				t.Fatalf("unexpected comment count; want: %v, got: %v", len(root.Comments), len(root2.Comments))
+			}
+			b.Reset()
+			err = Format(&b, root2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != formatted {
+				t.Fatalf("formatting is not idempotent;\nfirst:\n%v\nsecond:\n%v", formatted, b.String())
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@
 package parser
 
 import (
+	"bytes"
 	_ "embed"
 	"fmt"
 	"io"
@@ -18,6 +19,7 @@ type tokenKind string
 const (
 	tokenKindKWFragment          = tokenKind("fragment")
 	tokenKindID                  = tokenKind("id")
+	tokenKindNumber              = tokenKind("number")
 	tokenKindTerminalPattern     = tokenKind("terminal pattern")
 	tokenKindStringLiteral       = tokenKind("string")
 	tokenKindColon               = tokenKind(":")
@@ -37,6 +39,7 @@ const (
 var (
 	reIDChar             = regexp.MustCompile(`^[0-9a-z_]+$`)
 	reIDInvalidDigitsPos = regexp.MustCompile(`^[0-9]`)
+	reNumber             = regexp.MustCompile(`^[0-9]+$`)
 )
 
 type Position struct {
@@ -72,6 +75,14 @@ func newIDToken(text string, pos Position) *token {
 	}
 }
 
+func newNumberToken(text string, pos Position) *token {
+	return &token{
+		kind: tokenKindNumber,
+		text: text,
+		pos:  pos,
+	}
+}
+
 func newTerminalPatternToken(text string, pos Position) *token {
 	return &token{
 		kind: tokenKindTerminalPattern,
@@ -105,18 +116,147 @@ func newInvalidToken(text string, pos Position) *token {
 type lexer struct {
 	d   *Lexer
 	buf *token
+
+	// comments holds the comments the lexer has skipped.
+	comments []*CommentNode
+
+	// blockComments holds the block comments the lexer hasn't reached yet. newLexer blanks them out of the source in
+	// advance, and the lexer moves them to `comments` when it reads a token following them.
+	blockComments []*CommentNode
+
+	// unclosedComment is the position of a block comment that lacks its closing `*/`, if any.
+	unclosedComment *Position
+
+	// lastRow is the row of the last token other than white spaces, newlines, and comments.
+	lastRow int
 }
 
 func newLexer(src io.Reader) (*lexer, error) {
-	d, err := NewLexer(NewLexSpec(), src)
+	b, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	b, blockComments, unclosed := blankBlockComments(b)
+	d, err := NewLexer(NewLexSpec(), bytes.NewReader(b))
 	if err != nil {
 		return nil, err
 	}
 	return &lexer{
-		d: d,
+		d:               d,
+		blockComments:   blockComments,
+		unclosedComment: unclosed,
 	}, nil
 }
 
+// blankBlockComments replaces the block comments in a source with white spaces and returns them. A block comment
+// starts with `/*` outside of patterns, string literals, and line comments, and it ends with the first `*/`. The
+// replacement keeps line feeds and replaces each other character with one space, so the tokens following a block
+// comment keep their positions. When the last block comment isn't closed, blankBlockComments returns its position.
+func blankBlockComments(src []byte) ([]byte, []*CommentNode, *Position) {
+	const (
+		stateDefault = iota
+		statePattern
+		stateString
+		stateLineComment
+	)
+
+	var dst []byte
+	var comments []*CommentNode
+	state := stateDefault
+	row := 1
+	col := 0
+	copied := 0
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if c == '\n' {
+			row++
+			col = 0
+		} else if c < 0x80 || c>>6 == 0x3 {
+			// Count columns in code points like the lexer does.
+			col++
+		}
+
+		switch state {
+		case statePattern:
+			switch c {
+			case '\\':
+				// An escaped character cannot close a pattern.
+				if i+1 < len(src) && src[i+1] != '\n' {
+					i++
+					if src[i] < 0x80 || src[i]>>6 == 0x3 {
+						col++
+					}
+				}
+			case '"':
+				state = stateDefault
+			}
+			continue
+		case stateString:
+			if c == '\'' {
+				state = stateDefault
+			}
+			continue
+		case stateLineComment:
+			if c == '\n' {
+				state = stateDefault
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			state = statePattern
+		case c == '\'':
+			state = stateString
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			state = stateLineComment
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			dst = append(dst, src[copied:i]...)
+			pos := newPosition(row, col)
+			n := bytes.Index(src[i+2:], []byte("*/"))
+			if n < 0 {
+				return append(dst, blank(src[i:])...), comments, &pos
+			}
+			text := src[i : i+2+n+2]
+			comments = append(comments, &CommentNode{
+				Text: string(text),
+				Pos:  pos,
+			})
+			dst = append(dst, blank(text)...)
+			copied = i + len(text)
+
+			// The loop has already counted the first character of the comment.
+			col--
+			for _, r := range string(text) {
+				if r == '\n' {
+					row++
+					col = 0
+				} else {
+					col++
+				}
+			}
+			i = copied - 1
+		}
+	}
+	if len(comments) == 0 {
+		return src, nil, nil
+	}
+	return append(dst, src[copied:]...), comments, nil
+}
+
+// blank returns a text that has the same lines as `text` and consists only of white spaces.
+func blank(text []byte) []byte {
+	var b []byte
+	for _, r := range string(text) {
+		if r == '\n' || r == '\r' {
+			b = append(b, byte(r))
+			continue
+		}
+		b = append(b, ' ')
+	}
+	return b
+}
+
 func (l *lexer) next() (*token, error) {
 	if l.buf != nil {
 		tok := l.buf
@@ -143,6 +283,26 @@ func (l *lexer) next() (*token, error) {
 	}
 }
 
+// flushBlockComments moves the block comments preceding a token to the skipped comments. At the end of a source, it
+// reports an unclosed block comment.
+func (l *lexer) flushBlockComments(tok *Token) error {
+	pos := newPosition(tok.Row+1, tok.Col+1)
+	for len(l.blockComments) > 0 && (tok.EOF || l.blockComments[0].Pos.before(pos)) {
+		c := l.blockComments[0]
+		l.blockComments = l.blockComments[1:]
+		c.Trailing = c.Pos.Row == l.lastRow
+		l.comments = append(l.comments, c)
+	}
+	if tok.EOF && l.unclosedComment != nil {
+		return &verr.SpecError{
+			Cause: synErrUnclosedBlockComment,
+			Row:   l.unclosedComment.Row,
+			Col:   l.unclosedComment.Col,
+		}
+	}
+	return nil
+}
+
 func (l *lexer) lexAndSkipWSs() (*token, error) {
 	var tok *Token
 	for {
@@ -154,6 +314,10 @@ func (l *lexer) lexAndSkipWSs() (*token, error) {
 		if tok.Invalid {
 			return newInvalidToken(string(tok.Lexeme), newPosition(tok.Row+1, tok.Col+1)), nil
 		}
+		err = l.flushBlockComments(tok)
+		if err != nil {
+			return nil, err
+		}
 		if tok.EOF {
 			return newEOFToken(), nil
 		}
@@ -161,11 +325,20 @@ func (l *lexer) lexAndSkipWSs() (*token, error) {
 		case KindIDWhiteSpace:
 			continue
 		case KindIDLineComment:
+			row := tok.Row + 1
+			l.comments = append(l.comments, &CommentNode{
+				Text:     strings.TrimRight(string(tok.Lexeme), " \t"),
+				Pos:      newPosition(row, tok.Col+1),
+				Trailing: row == l.lastRow,
+			})
 			continue
 		}
 
 		break
 	}
+	if tok.KindID != KindIDNewline {
+		l.lastRow = tok.Row + 1
+	}
 
 	switch tok.KindID {
 	case KindIDNewline:
@@ -173,6 +346,10 @@ func (l *lexer) lexAndSkipWSs() (*token, error) {
 	case KindIDKwFragment:
 		return newSymbolToken(tokenKindKWFragment, newPosition(tok.Row+1, tok.Col+1)), nil
 	case KindIDIdentifier:
+		// A sequence of digits is a number, which only directives take as a parameter.
+		if reNumber.Match(tok.Lexeme) {
+			return newNumberToken(string(tok.Lexeme), newPosition(tok.Row+1, tok.Col+1)), nil
+		}
 		if !reIDChar.Match(tok.Lexeme) {
 			return nil, &verr.SpecError{
 				Cause:  synErrIDInvalidChar,
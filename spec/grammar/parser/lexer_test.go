@@ -53,6 +53,15 @@ func TestLexer_Run(t *testing.T) {
 				newEOFToken(),
 			},
 		},
+		{
+			caption: "the lexer can recognize a sequence of digits as a number",
+			src:     `10 a10`,
+			tokens: []*token{
+				newNumberToken("10", newPosition(1, 0)),
+				idTok("a10"),
+				newEOFToken(),
+			},
+		},
 		{
 			caption: "the lexer can recognize keywords",
 			src:     `fragment`,
@@ -119,6 +128,32 @@ bar // This is the fourth comment.
 				newEOFToken(),
 			},
 		},
+		{
+			caption: "the lexer ignores block comments",
+			src: `/* This is the first comment. */ foo /* This is
+the second comment. */ bar
+/* "This is not a pattern. */ 'baz' "/*" '*/' // /* This is a line comment.
+`,
+			tokens: []*token{
+				idTok("foo"),
+				symTok(tokenKindNewline),
+				idTok("bar"),
+				symTok(tokenKindNewline),
+				strTok("baz"),
+				termPatTok("/*"),
+				strTok("*/"),
+				symTok(tokenKindNewline),
+				newEOFToken(),
+			},
+		},
+		{
+			caption: "a block comment must be closed",
+			src:     `foo /* bar`,
+			tokens: []*token{
+				idTok("foo"),
+			},
+			err: synErrUnclosedBlockComment,
+		},
 		{
 			caption: "an identifier cannot contain the capital-case letters",
 			src:     `Abc`,
@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	verr "github.com/nihei9/vartan/error"
 	spec "github.com/nihei9/vartan/spec/grammar"
@@ -13,6 +14,9 @@ type RootNode struct {
 	Productions    []*ProductionNode
 	LexProductions []*ProductionNode
 	Fragments      []*FragmentNode
+
+	// Comments holds all comments in a grammar in the order of their positions.
+	Comments []*CommentNode
 }
 
 type ProductionNode struct {
@@ -20,13 +24,38 @@ type ProductionNode struct {
 	LHS        string
 	RHS        []*AlternativeNode
 	Pos        Position
+
+	// End is the position of the semicolon terminating the production.
+	End Position
+
+	// DocComment is the text of the comments on the lines directly preceding the production, without the `//`
+	// markers. The lines are joined by newlines. Comments separated from the production by a blank line or by another
+	// token aren't doc comments.
+	DocComment string
 }
 
-func (n *ProductionNode) isLexical() bool {
-	if len(n.RHS) == 1 && len(n.RHS[0].Elements) == 1 && n.RHS[0].Elements[0].Pattern != "" {
-		return true
+// IsLexical returns true when a production is a lexical production, that is, each alternative of the production
+// consists of just one pattern or string literal. A lexical production having multiple alternatives, such as
+// `bool: 'true' | 'false';`, defines one terminal symbol matching any of them.
+func (n *ProductionNode) IsLexical() bool {
+	if len(n.RHS) == 0 {
+		return false
 	}
-	return false
+	for _, alt := range n.RHS {
+		if len(alt.Elements) != 1 || alt.Elements[0].Pattern == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Literal returns the string literal defining a lexical production. It returns false when a pattern or multiple
+// alternatives define the production.
+func (n *ProductionNode) Literal() (string, bool) {
+	if !n.IsLexical() || len(n.RHS) != 1 || !n.RHS[0].Elements[0].Literally {
+		return "", false
+	}
+	return n.RHS[0].Elements[0].Pattern, true
 }
 
 type AlternativeNode struct {
@@ -52,22 +81,47 @@ type DirectiveNode struct {
 	Name       string
 	Parameters []*ParameterNode
 	Pos        Position
+
+	// End is the position of the semicolon terminating a top-level directive. It is zero for the other directives.
+	End Position
 }
 
 type ParameterNode struct {
 	ID            string
+	Number        string
 	Pattern       string
 	String        string
 	OrderedSymbol string
-	Group         []*DirectiveNode
-	Expansion     bool
-	Pos           Position
+
+	// LevelName is the name of a precedence level written with the leading colon, such as `:additive`.
+	LevelName string
+
+	Group     []*DirectiveNode
+	Expansion bool
+	Pos       Position
 }
 
 type FragmentNode struct {
 	LHS string
 	RHS string
 	Pos Position
+
+	// Literal is the string literal defining a fragment when the fragment is defined by a string literal. RHS holds
+	// the pattern matching the literal in that case.
+	Literal string
+
+	// End is the position of the semicolon terminating the fragment.
+	End Position
+}
+
+// CommentNode represents a line comment or a block comment.
+type CommentNode struct {
+	// Text is the comment including the leading `//` or the enclosing `/*` and `*/`. A block comment can span lines.
+	Text string
+	Pos  Position
+
+	// Trailing is true when the comment follows another token on the same line.
+	Trailing bool
 }
 
 func raiseSyntaxError(row int, synErr *SyntaxError) {
@@ -155,7 +209,7 @@ func (p *parser) parseRoot() *RootNode {
 
 		prod := p.parseProduction()
 		if prod != nil {
-			if prod.isLexical() {
+			if prod.IsLexical() {
 				lexProds = append(lexProds, prod)
 			} else {
 				prods = append(prods, prod)
@@ -168,11 +222,41 @@ func (p *parser) parseRoot() *RootNode {
 		}
 	}
 
+	attachDocComments(prods, p.lex.comments)
+	attachDocComments(lexProds, p.lex.comments)
+
 	return &RootNode{
 		Directives:     dirs,
 		Productions:    prods,
 		LexProductions: lexProds,
 		Fragments:      fragments,
+		Comments:       p.lex.comments,
+	}
+}
+
+// attachDocComments sets the comments on the lines directly preceding each production to the production as its doc
+// comment.
+func attachDocComments(prods []*ProductionNode, comments []*CommentNode) {
+	row2Comment := map[int]*CommentNode{}
+	for _, c := range comments {
+		// Block comments often comment out regions, so only line comments form doc comments.
+		if c.Trailing || !strings.HasPrefix(c.Text, "//") {
+			continue
+		}
+		row2Comment[c.Pos.Row] = c
+	}
+	for _, prod := range prods {
+		var lines []string
+		for row := prod.Pos.Row - 1; ; row-- {
+			c, ok := row2Comment[row]
+			if !ok {
+				break
+			}
+			text := strings.TrimPrefix(c.Text, "//")
+			text = strings.TrimPrefix(text, " ")
+			lines = append([]string{text}, lines...)
+		}
+		prod.DocComment = strings.Join(lines, "\n")
 	}
 }
 
@@ -192,7 +276,7 @@ func (p *parser) parseTopLevelDirective() *DirectiveNode {
 		p.skipOverTo(tokenKindSemicolon)
 	}()
 
-	dir := p.parseDirective()
+	dir := p.parseDirective(true)
 	if dir == nil {
 		return nil
 	}
@@ -202,6 +286,7 @@ func (p *parser) parseTopLevelDirective() *DirectiveNode {
 	if !p.consume(tokenKindSemicolon) {
 		raiseSyntaxError(p.pos.Row, synErrTopLevelDirNoSemicolon)
 	}
+	dir.End = p.lastTok.pos
 
 	return dir
 }
@@ -243,11 +328,13 @@ func (p *parser) parseFragment() *FragmentNode {
 	}
 
 	var rhs string
+	var lit string
 	switch {
 	case p.consume(tokenKindTerminalPattern):
 		rhs = p.lastTok.text
 	case p.consume(tokenKindStringLiteral):
 		rhs = spec.EscapePattern(p.lastTok.text)
+		lit = p.lastTok.text
 	default:
 		raiseSyntaxError(p.pos.Row, synErrFragmentNoPattern)
 	}
@@ -257,6 +344,7 @@ func (p *parser) parseFragment() *FragmentNode {
 	if !p.consume(tokenKindSemicolon) {
 		raiseSyntaxError(p.pos.Row, synErrNoSemicolon)
 	}
+	endPos := p.lastTok.pos
 
 	if !p.consume(tokenKindNewline) {
 		if !p.consume(tokenKindEOF) {
@@ -265,9 +353,11 @@ func (p *parser) parseFragment() *FragmentNode {
 	}
 
 	return &FragmentNode{
-		LHS: lhs,
-		RHS: rhs,
-		Pos: lhsPos,
+		LHS:     lhs,
+		RHS:     rhs,
+		Pos:     lhsPos,
+		Literal: lit,
+		End:     endPos,
 	}
 }
 
@@ -299,9 +389,10 @@ func (p *parser) parseProduction() *ProductionNode {
 	lhs := p.lastTok.text
 	lhsPos := p.lastTok.pos
 
+	// The colon following the directives of a production begins alternatives, so it isn't a level name.
 	var dirs []*DirectiveNode
 	for {
-		dir := p.parseDirective()
+		dir := p.parseDirective(false)
 		if dir == nil {
 			break
 		}
@@ -331,6 +422,7 @@ func (p *parser) parseProduction() *ProductionNode {
 	if !p.consume(tokenKindSemicolon) {
 		raiseSyntaxError(p.pos.Row, synErrNoSemicolon)
 	}
+	endPos := p.lastTok.pos
 
 	if !p.consume(tokenKindNewline) {
 		if !p.consume(tokenKindEOF) {
@@ -343,15 +435,17 @@ func (p *parser) parseProduction() *ProductionNode {
 		LHS:        lhs,
 		RHS:        rhs,
 		Pos:        lhsPos,
+		End:        endPos,
 	}
 
 	// Vartan's driver must provide a user with the names of expected tokens when a syntax error occurs.
 	// However, if a pattern appears directly in an alternative, Vartan's compiler cannot assign an appropriate
-	// name to the pattern. Therefore, this code prohibits alternatives from containing patterns.
-	if !prod.isLexical() {
+	// name to the pattern. Therefore, this code prohibits alternatives from containing patterns. A string literal
+	// is allowed because the compiler can name it after its characters.
+	if !prod.IsLexical() {
 		for _, alt := range prod.RHS {
 			for _, elem := range alt.Elements {
-				if elem.Pattern != "" {
+				if elem.Pattern != "" && !elem.Literally {
 					raiseSyntaxError(elem.Pos.Row, synErrPatternInAlt)
 				}
 			}
@@ -379,7 +473,7 @@ func (p *parser) parseAlternative() *AlternativeNode {
 
 	var dirs []*DirectiveNode
 	for {
-		dir := p.parseDirective()
+		dir := p.parseDirective(true)
 		if dir == nil {
 			break
 		}
@@ -430,7 +524,9 @@ func (p *parser) parseElement() *ElementNode {
 	return elem
 }
 
-func (p *parser) parseDirective() *DirectiveNode {
+// parseDirective parses a directive. When `allowLevelName` is true, a colon followed by an identifier is a level name
+// parameter.
+func (p *parser) parseDirective(allowLevelName bool) *DirectiveNode {
 	p.consume(tokenKindNewline)
 
 	if !p.consume(tokenKindDirectiveMarker) {
@@ -445,7 +541,7 @@ func (p *parser) parseDirective() *DirectiveNode {
 
 	var params []*ParameterNode
 	for {
-		param := p.parseParameter()
+		param := p.parseParameter(allowLevelName)
 		if param == nil {
 			break
 		}
@@ -459,7 +555,7 @@ func (p *parser) parseDirective() *DirectiveNode {
 	}
 }
 
-func (p *parser) parseParameter() *ParameterNode {
+func (p *parser) parseParameter(allowLevelName bool) *ParameterNode {
 	var param *ParameterNode
 	switch {
 	case p.consume(tokenKindID):
@@ -467,6 +563,11 @@ func (p *parser) parseParameter() *ParameterNode {
 			ID:  p.lastTok.text,
 			Pos: p.lastTok.pos,
 		}
+	case p.consume(tokenKindNumber):
+		param = &ParameterNode{
+			Number: p.lastTok.text,
+			Pos:    p.lastTok.pos,
+		}
 	case p.consume(tokenKindTerminalPattern):
 		param = &ParameterNode{
 			Pattern: p.lastTok.text,
@@ -485,11 +586,19 @@ func (p *parser) parseParameter() *ParameterNode {
 			OrderedSymbol: p.lastTok.text,
 			Pos:           p.lastTok.pos,
 		}
+	case allowLevelName && p.consume(tokenKindColon):
+		if !p.consume(tokenKindID) {
+			raiseSyntaxError(p.pos.Row, synErrNoLevelName)
+		}
+		param = &ParameterNode{
+			LevelName: p.lastTok.text,
+			Pos:       p.lastTok.pos,
+		}
 	case p.consume(tokenKindLParen):
 		pos := p.lastTok.pos
 		var g []*DirectiveNode
 		for {
-			dir := p.parseDirective()
+			dir := p.parseDirective(true)
 			if dir == nil {
 				break
 			}
@@ -85,6 +85,11 @@ func TestParse(t *testing.T) {
 			OrderedSymbol: id,
 		}
 	}
+	levelParam := func(name string) *ParameterNode {
+		return &ParameterNode{
+			LevelName: name,
+		}
+	}
 	exp := func(param *ParameterNode) *ParameterNode {
 		param.Expansion = true
 		return param
@@ -260,6 +265,15 @@ func TestParse(t *testing.T) {
 `,
 			synErr: synErrNoOrderedSymbolName,
 		},
+		{
+			caption: "a level name marker ':' must be followed by an ID",
+			src: `
+#prec (
+    #left a :
+);
+`,
+			synErr: synErrNoLevelName,
+		},
 		{
 			caption: "single production is a valid grammar",
 			src:     `a: "a";`,
@@ -330,6 +344,37 @@ id
 				},
 			},
 		},
+		{
+			caption: "a production whose alternatives are all single patterns or strings is a lexical production",
+			src: `
+s
+    : bool
+    | num
+    | bool num
+    ;
+bool
+    : 'true'
+    | 'false'
+    ;
+num
+    : "[0-9]+"
+    | '-'
+    ;
+`,
+			ast: &RootNode{
+				Productions: []*ProductionNode{
+					prod("s",
+						alt(id("bool")),
+						alt(id("num")),
+						alt(id("bool"), id("num")),
+					),
+				},
+				LexProductions: []*ProductionNode{
+					prod("bool", alt(pat(`true`)), alt(pat(`false`))),
+					prod("num", alt(pat(`[0-9]+`)), alt(pat(`-`))),
+				},
+			},
+		},
 		{
 			caption: "productions can contain the empty alternative",
 			src: `
@@ -390,7 +435,7 @@ bar
 			synErr: synErrPatternInAlt,
 		},
 		{
-			caption: "an alternative cannot contain a string directly",
+			caption: "an alternative can contain a string directly",
 			src: `
 s
     : 'foo' bar
@@ -398,7 +443,18 @@ s
 bar
     : "bar";
 `,
-			synErr: synErrPatternInAlt,
+			ast: &RootNode{
+				Productions: []*ProductionNode{
+					prod("s",
+						alt(pat(`foo`), id("bar")),
+					),
+				},
+				LexProductions: []*ProductionNode{
+					prod("bar",
+						alt(pat(`bar`)),
+					),
+				},
+			},
 		},
 		{
 			caption: "a terminal symbol can be defined using a string literal",
@@ -630,6 +686,66 @@ s
 				},
 			},
 		},
+		{
+			caption: "a directive in a precedence group can name its level, and an alternative can refer to the level",
+			src: `
+#prec (
+    #left :additive add sub
+);
+
+s
+    : s add s #prec :additive
+    ;
+`,
+			ast: &RootNode{
+				Directives: []*DirectiveNode{
+					withDirPos(
+						prec(
+							withParamPos(
+								group(
+									withDirPos(
+										leftAssoc(
+											withParamPos(levelParam("additive"), newPos(3)),
+											withParamPos(idParam("add"), newPos(3)),
+											withParamPos(idParam("sub"), newPos(3)),
+										),
+										newPos(3),
+									),
+								),
+								newPos(2),
+							),
+						),
+						newPos(2),
+					),
+				},
+				Productions: []*ProductionNode{
+					prod("s",
+						withAltDir(
+							alt(id("s"), id("add"), id("s")),
+							dir("prec", levelParam("additive")),
+						),
+					),
+				},
+			},
+		},
+		{
+			caption: "a colon following production directives begins alternatives even when an ID follows it",
+			src: `
+s #foo
+    :bar baz
+    ;
+`,
+			ast: &RootNode{
+				Productions: []*ProductionNode{
+					withProdDir(
+						prod("s",
+							alt(id("bar"), id("baz")),
+						),
+						dir("foo"),
+					),
+				},
+			},
+		},
 		{
 			caption: "a lexical production can have multiple production directives",
 			src: `
@@ -1195,6 +1311,9 @@ func testParameter(t *testing.T, param, expected *ParameterNode, checkPosition b
 	if param.String != expected.String {
 		t.Fatalf("unexpected string parameter; want: %v, got: %v", expected.ID, param.ID)
 	}
+	if param.LevelName != expected.LevelName {
+		t.Fatalf("unexpected level name parameter; want: %v, got: %v", expected.LevelName, param.LevelName)
+	}
 	if param.Expansion != expected.Expansion {
 		t.Fatalf("unexpected expansion; want: %v, got: %v", expected.Expansion, param.Expansion)
 	}
@@ -1209,3 +1328,171 @@ func testPosition(t *testing.T, pos, expected Position) {
 		t.Fatalf("unexpected position want: %+v, got: %+v", expected, pos)
 	}
 }
+
+func TestParse_Comments(t *testing.T) {
+	src := `// leading
+s // after s
+    : a
+    ; // after the semicolon
+// between
+a: 'a';
+`
+	root, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*CommentNode{
+		{Text: "// leading", Pos: newPosition(1, 1)},
+		{Text: "// after s", Pos: newPosition(2, 3), Trailing: true},
+		{Text: "// after the semicolon", Pos: newPosition(4, 7), Trailing: true},
+		{Text: "// between", Pos: newPosition(5, 1)},
+	}
+	if len(root.Comments) != len(expected) {
+		t.Fatalf("unexpected comment count; want: %v, got: %v", len(expected), len(root.Comments))
+	}
+	for i, c := range root.Comments {
+		if *c != *expected[i] {
+			t.Fatalf("unexpected comment; want: %+v, got: %+v", expected[i], c)
+		}
+	}
+}
+
+func TestParse_BlockComments(t *testing.T) {
+	src := `#name test; /* after #name */
+/* between
+   directives */
+#prec (
+    /* #left mul */ #left add
+    /*
+    #right pow
+    */
+);
+/* é */ s
+    : a /* after a */ // after the block comment
+    ;
+a: 'a';
+`
+	root, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*CommentNode{
+		{Text: "/* after #name */", Pos: newPosition(1, 13), Trailing: true},
+		{Text: "/* between\n   directives */", Pos: newPosition(2, 1)},
+		{Text: "/* #left mul */", Pos: newPosition(5, 5)},
+		{Text: "/*\n    #right pow\n    */", Pos: newPosition(6, 5)},
+		{Text: "/* é */", Pos: newPosition(10, 1)},
+		{Text: "/* after a */", Pos: newPosition(11, 9), Trailing: true},
+		{Text: "// after the block comment", Pos: newPosition(11, 23), Trailing: true},
+	}
+	if len(root.Comments) != len(expected) {
+		t.Fatalf("unexpected comment count; want: %v, got: %v", len(expected), len(root.Comments))
+	}
+	for i, c := range root.Comments {
+		if *c != *expected[i] {
+			t.Fatalf("unexpected comment; want: %+v, got: %+v", expected[i], c)
+		}
+	}
+
+	prec := root.Directives[1]
+	if len(prec.Parameters) != 1 || len(prec.Parameters[0].Group) != 1 {
+		t.Fatalf("a #prec directive must have one precedence group: %+v", prec)
+	}
+	if pos := prec.Parameters[0].Group[0].Pos; pos != newPosition(5, 21) {
+		t.Fatalf("unexpected position of #left directive; want: %+v, got: %+v", newPosition(5, 21), pos)
+	}
+	if pos := root.Productions[0].Pos; pos != newPosition(10, 9) {
+		t.Fatalf("unexpected position of s; want: %+v, got: %+v", newPosition(10, 9), pos)
+	}
+}
+
+func TestParse_DocComment(t *testing.T) {
+	src := `#name test;
+
+// s is the start symbol.
+//
+//   It has two alternatives.
+s
+    : a // not a doc comment
+    | b
+    ;
+// This comment is separated from a.
+
+a: 'a';
+// b matches
+//b
+b #skip
+    : "b";
+#prec (
+    #left a
+    // This comment belongs to #prec.
+);
+c
+    : a
+    ;
+`
+	root, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"s": "s is the start symbol.\n\n  It has two alternatives.",
+		"a": "",
+		"b": "b matches\nb",
+		"c": "",
+	}
+	for _, prods := range [][]*ProductionNode{root.Productions, root.LexProductions} {
+		for _, prod := range prods {
+			doc, ok := expected[prod.LHS]
+			if !ok {
+				t.Fatalf("unexpected production: %v", prod.LHS)
+			}
+			if prod.DocComment != doc {
+				t.Fatalf("unexpected doc comment of %v; want: %q, got: %q", prod.LHS, doc, prod.DocComment)
+			}
+			delete(expected, prod.LHS)
+		}
+	}
+	if len(expected) > 0 {
+		t.Fatalf("productions not found: %v", expected)
+	}
+}
+
+func FuzzSpecParse(f *testing.F) {
+	f.Add(`
+#name test;
+
+#prec (
+    #left mul div
+    #left add sub
+);
+
+expr
+    : expr add expr
+    | expr mul expr
+    | l_paren expr r_paren #ast expr
+    | id #label primary
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+add: '+';
+sub: '-';
+mul: '*';
+div: '/';
+l_paren: '(';
+r_paren: ')';
+id #mode default
+    : "[A-Za-z_][0-9A-Za-z_]*";
+fragment digit
+    : "[0-9]";
+`)
+	f.Add(`#name test; s: foo | ; foo #push m: "foo"; bar #mode m #pop: 'bar';`)
+	f.Add(`#name test; s: a error semi #recover; a: 'a'; semi: ';'; // comment`)
+	f.Add(`#name "test"; s: "\u{10FFFF}" 'x\'' ;`)
+
+	f.Fuzz(func(t *testing.T, src string) {
+		// The parser must return an error instead of panicking on any input.
+		_, _ = Parse(strings.NewReader(src))
+	})
+}
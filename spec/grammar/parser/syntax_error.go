@@ -1,11 +1,13 @@
 package parser
 
 type SyntaxError struct {
+	code    string
 	message string
 }
 
-func newSyntaxError(message string) *SyntaxError {
+func newSyntaxError(code string, message string) *SyntaxError {
 	return &SyntaxError{
+		code:    code,
 		message: message,
 	}
 }
@@ -14,32 +16,39 @@ func (e *SyntaxError) Error() string {
 	return e.message
 }
 
+// Code returns a stable code of the error.
+func (e *SyntaxError) Code() string {
+	return e.code
+}
+
 var (
 	// lexical errors
-	synErrIDInvalidChar            = newSyntaxError("an identifier can contain only the lower-case letter, the digits, and the underscore")
-	synErrIDInvalidUnderscorePos   = newSyntaxError("the underscore cannot be placed at the beginning or end of an identifier")
-	synErrIDConsecutiveUnderscores = newSyntaxError("the underscore cannot be placed consecutively")
-	synErrIDInvalidDigitsPos       = newSyntaxError("the digits cannot be placed at the biginning of an identifier")
-	synErrUnclosedTerminal         = newSyntaxError("unclosed terminal")
-	synErrUnclosedString           = newSyntaxError("unclosed string")
-	synErrIncompletedEscSeq        = newSyntaxError("incompleted escape sequence; unexpected EOF following a backslash")
-	synErrEmptyPattern             = newSyntaxError("a pattern must include at least one character")
-	synErrEmptyString              = newSyntaxError("a string must include at least one character")
+	synErrIDInvalidChar            = newSyntaxError("V1001", "an identifier can contain only the lower-case letter, the digits, and the underscore")
+	synErrIDInvalidUnderscorePos   = newSyntaxError("V1002", "the underscore cannot be placed at the beginning or end of an identifier")
+	synErrIDConsecutiveUnderscores = newSyntaxError("V1003", "the underscore cannot be placed consecutively")
+	synErrIDInvalidDigitsPos       = newSyntaxError("V1004", "the digits cannot be placed at the biginning of an identifier")
+	synErrUnclosedTerminal         = newSyntaxError("V1005", "unclosed terminal")
+	synErrUnclosedString           = newSyntaxError("V1006", "unclosed string")
+	synErrIncompletedEscSeq        = newSyntaxError("V1007", "incompleted escape sequence; unexpected EOF following a backslash")
+	synErrEmptyPattern             = newSyntaxError("V1008", "a pattern must include at least one character")
+	synErrEmptyString              = newSyntaxError("V1009", "a string must include at least one character")
+	synErrUnclosedBlockComment     = newSyntaxError("V1025", "unclosed block comment")
 
 	// syntax errors
-	synErrInvalidToken           = newSyntaxError("invalid token")
-	synErrTopLevelDirNoSemicolon = newSyntaxError("a top-level directive must be followed by ;")
-	synErrNoProductionName       = newSyntaxError("a production name is missing")
-	synErrNoColon                = newSyntaxError("the colon must precede alternatives")
-	synErrNoSemicolon            = newSyntaxError("the semicolon is missing at the last of an alternative")
-	synErrLabelWithNoSymbol      = newSyntaxError("a label must follow a symbol")
-	synErrNoLabel                = newSyntaxError("an identifier that represents a label is missing after the label marker @")
-	synErrNoDirectiveName        = newSyntaxError("a directive needs a name")
-	synErrNoOrderedSymbolName    = newSyntaxError("an ordered symbol name is missing")
-	synErrUnclosedDirGroup       = newSyntaxError("a directive group must be closed by )")
-	synErrPatternInAlt           = newSyntaxError("a pattern literal cannot appear directly in an alternative. instead, please define a terminal symbol with the pattern literal")
-	synErrStrayExpOp             = newSyntaxError("an expansion operator ... must be preceded by an identifier")
-	synErrInvalidExpOperand      = newSyntaxError("an expansion operator ... can be applied to only an identifier")
-	synErrSemicolonNoNewline     = newSyntaxError("a semicolon must be followed by a newline")
-	synErrFragmentNoPattern      = newSyntaxError("a fragment needs one pattern element")
+	synErrInvalidToken           = newSyntaxError("V1010", "invalid token")
+	synErrTopLevelDirNoSemicolon = newSyntaxError("V1011", "a top-level directive must be followed by ;")
+	synErrNoProductionName       = newSyntaxError("V1012", "a production name is missing")
+	synErrNoColon                = newSyntaxError("V1013", "the colon must precede alternatives")
+	synErrNoSemicolon            = newSyntaxError("V1014", "the semicolon is missing at the last of an alternative")
+	synErrLabelWithNoSymbol      = newSyntaxError("V1015", "a label must follow a symbol")
+	synErrNoLabel                = newSyntaxError("V1016", "an identifier that represents a label is missing after the label marker @")
+	synErrNoDirectiveName        = newSyntaxError("V1017", "a directive needs a name")
+	synErrNoOrderedSymbolName    = newSyntaxError("V1018", "an ordered symbol name is missing")
+	synErrUnclosedDirGroup       = newSyntaxError("V1019", "a directive group must be closed by )")
+	synErrPatternInAlt           = newSyntaxError("V1020", "a pattern literal cannot appear directly in an alternative. instead, please define a terminal symbol with the pattern literal")
+	synErrStrayExpOp             = newSyntaxError("V1021", "an expansion operator ... must be preceded by an identifier")
+	synErrInvalidExpOperand      = newSyntaxError("V1022", "an expansion operator ... can be applied to only an identifier")
+	synErrSemicolonNoNewline     = newSyntaxError("V1023", "a semicolon must be followed by a newline")
+	synErrFragmentNoPattern      = newSyntaxError("V1024", "a fragment needs one pattern element")
+	synErrNoLevelName            = newSyntaxError("V1026", "a precedence level name is missing after the colon")
 )
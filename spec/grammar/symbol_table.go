@@ -0,0 +1,160 @@
+package grammar
+
+// Symbol is a terminal symbol or a non-terminal symbol of a compiled grammar.
+type Symbol struct {
+	// Num is the number of a terminal symbol or a non-terminal symbol. Terminal symbols and non-terminal symbols are
+	// numbered separately, and parsers refer to symbols by these numbers.
+	Num int
+
+	Name     string
+	Terminal bool
+
+	// Kind is the kind of tokens the lexer generates for a terminal symbol. It is LexKindIDNil for the non-terminal
+	// symbols and for the terminal symbols the lexer never generates, such as the EOF symbol, the error symbol, and
+	// the symbols of a `#layout` directive.
+	Kind LexKindID
+
+	// Skip is true when the parser skips the tokens of a terminal symbol, that is, the kind of the terminal symbol has
+	// a `#skip` directive.
+	Skip bool
+
+	// Literal is the string literal defining a terminal symbol. It is empty when a pattern defines the symbol.
+	Literal string
+
+	// Class is the class a `#class` directive assigns to the kind of a terminal symbol.
+	Class string
+}
+
+// SymbolTable is a read-only view of the symbols of a compiled grammar. Tools such as highlighters and debuggers can
+// enumerate the symbols and look them up by names, numbers, and kinds without knowing the layout of a compiled grammar.
+type SymbolTable struct {
+	terms        []Symbol
+	nonTerms     []Symbol
+	name2Term    map[string]int
+	name2NonTerm map[string]int
+	kind2Term    map[LexKindID]int
+}
+
+// SymbolTable returns the symbol table of a grammar. The terminal symbols include the EOF symbol and the error symbol,
+// and the non-terminal symbols include the augmented start symbols. A lexer-only grammar has no non-terminal symbols,
+// and its terminal symbols are the kinds of the lexer numbered by their kind IDs.
+func (g *CompiledGrammar) SymbolTable() *SymbolTable {
+	t := &SymbolTable{
+		name2Term:    map[string]int{},
+		name2NonTerm: map[string]int{},
+		kind2Term:    map[LexKindID]int{},
+	}
+	kindClass := func(kind LexKindID) string {
+		if int(kind) >= len(g.Lexical.KindClasses) {
+			return ""
+		}
+		return g.Lexical.KindClasses[kind]
+	}
+
+	if g.IsLexerOnly() {
+		for i := 1; i < len(g.Lexical.KindNames); i++ {
+			kind := LexKindID(i)
+			t.addTerminal(Symbol{
+				Num:      i,
+				Name:     g.Lexical.KindNames[i].String(),
+				Terminal: true,
+				Kind:     kind,
+				Class:    kindClass(kind),
+			})
+		}
+		return t
+	}
+
+	term2Kind := map[int]LexKindID{}
+	for kind, term := range g.Syntactic.KindToTerminal {
+		if kind == LexKindIDNil.Int() || term == 0 {
+			continue
+		}
+		term2Kind[term] = LexKindID(kind)
+	}
+	for i := 1; i < len(g.Syntactic.Terminals); i++ {
+		sym := Symbol{
+			Num:      i,
+			Name:     g.Syntactic.Terminals[i],
+			Terminal: true,
+			Kind:     term2Kind[i],
+			Skip:     i < len(g.Syntactic.TerminalSkip) && g.Syntactic.TerminalSkip[i] == 1,
+		}
+		if i < len(g.Syntactic.TerminalLiterals) {
+			sym.Literal = g.Syntactic.TerminalLiterals[i]
+		}
+		if sym.Kind != LexKindIDNil {
+			sym.Class = kindClass(sym.Kind)
+		}
+		t.addTerminal(sym)
+	}
+	for i := 1; i < len(g.Syntactic.NonTerminals); i++ {
+		t.nonTerms = append(t.nonTerms, Symbol{
+			Num:  i,
+			Name: g.Syntactic.NonTerminals[i],
+		})
+		t.name2NonTerm[g.Syntactic.NonTerminals[i]] = len(t.nonTerms) - 1
+	}
+	return t
+}
+
+func (t *SymbolTable) addTerminal(sym Symbol) {
+	t.terms = append(t.terms, sym)
+	t.name2Term[sym.Name] = len(t.terms) - 1
+	if sym.Kind != LexKindIDNil {
+		t.kind2Term[sym.Kind] = len(t.terms) - 1
+	}
+}
+
+// Terminals returns the terminal symbols in the order of their numbers.
+func (t *SymbolTable) Terminals() []Symbol {
+	return append([]Symbol{}, t.terms...)
+}
+
+// NonTerminals returns the non-terminal symbols in the order of their numbers.
+func (t *SymbolTable) NonTerminals() []Symbol {
+	return append([]Symbol{}, t.nonTerms...)
+}
+
+// Terminal returns the terminal symbol numbered `num`.
+func (t *SymbolTable) Terminal(num int) (Symbol, bool) {
+	if num < 1 || num > len(t.terms) {
+		return Symbol{}, false
+	}
+	return t.terms[num-1], true
+}
+
+// NonTerminal returns the non-terminal symbol numbered `num`.
+func (t *SymbolTable) NonTerminal(num int) (Symbol, bool) {
+	if num < 1 || num > len(t.nonTerms) {
+		return Symbol{}, false
+	}
+	return t.nonTerms[num-1], true
+}
+
+// TerminalByName returns the terminal symbol named `name`.
+func (t *SymbolTable) TerminalByName(name string) (Symbol, bool) {
+	i, ok := t.name2Term[name]
+	if !ok {
+		return Symbol{}, false
+	}
+	return t.terms[i], true
+}
+
+// NonTerminalByName returns the non-terminal symbol named `name`.
+func (t *SymbolTable) NonTerminalByName(name string) (Symbol, bool) {
+	i, ok := t.name2NonTerm[name]
+	if !ok {
+		return Symbol{}, false
+	}
+	return t.nonTerms[i], true
+}
+
+// TerminalByKind returns the terminal symbol whose tokens have the kind `kind`.
+func (t *SymbolTable) TerminalByKind(kind LexKindID) (Symbol, bool) {
+	i, ok := t.kind2Term[kind]
+	if !ok {
+		return Symbol{}, false
+	}
+	return t.terms[i], true
+}
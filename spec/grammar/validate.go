@@ -0,0 +1,492 @@
+package grammar
+
+import "fmt"
+
+// Validate checks the internal consistency of a compiled grammar, such as the dimensions of tables and the ranges of
+// the states, symbols, and productions that the tables refer to. The compiler always produces consistent grammars,
+// so Validate is for tools constructing or transforming compiled grammars and for drivers reading grammars from files.
+func (g *CompiledGrammar) Validate() error {
+	if g.Lexical == nil {
+		return fmt.Errorf("invalid compiled grammar: the lexical specification is missing")
+	}
+	err := g.Lexical.validate()
+	if err != nil {
+		return fmt.Errorf("invalid lexical specification: %w", err)
+	}
+	if g.Syntactic == nil {
+		if g.ASTAction != nil {
+			return fmt.Errorf("invalid compiled grammar: a lexer-only grammar cannot have AST actions")
+		}
+		return nil
+	}
+	err = g.Syntactic.validate(g.Lexical)
+	if err != nil {
+		return fmt.Errorf("invalid syntactic specification: %w", err)
+	}
+	if g.ASTAction != nil {
+		err := g.ASTAction.validate(g.Syntactic)
+		if err != nil {
+			return fmt.Errorf("invalid AST actions: %w", err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the internal consistency of a lexical specification like CompiledGrammar.Validate. Use it when
+// a lexer-only program loads a lexical specification alone.
+func (s *LexicalSpec) Validate() error {
+	err := s.validate()
+	if err != nil {
+		return fmt.Errorf("invalid lexical specification: %w", err)
+	}
+	return nil
+}
+
+func (s *LexicalSpec) validate() error {
+	modeCount := len(s.ModeNames)
+	kindCount := len(s.KindNames)
+	if modeCount < 2 {
+		return fmt.Errorf("the specification must have at least one lex mode")
+	}
+	if kindCount < 1 || s.KindNames[LexKindIDNil] != LexKindNameNil {
+		return fmt.Errorf("kind names must start with the nil kind")
+	}
+	if s.InitialModeID <= LexModeIDNil || s.InitialModeID.Int() >= modeCount {
+		return fmt.Errorf("initial mode ID out of range: %v", s.InitialModeID)
+	}
+	if len(s.Specs) != modeCount {
+		return fmt.Errorf("the number of mode specifications (%v) must equal the number of modes (%v)", len(s.Specs), modeCount)
+	}
+	if len(s.KindIDs) != modeCount {
+		return fmt.Errorf("the number of kind ID tables (%v) must equal the number of modes (%v)", len(s.KindIDs), modeCount)
+	}
+	for mode, modeSpec := range s.Specs[1:] {
+		mode++
+		if modeSpec == nil {
+			return fmt.Errorf("mode %v: the specification is missing", s.ModeNames[mode])
+		}
+		err := modeSpec.validate(modeCount, kindCount, s.CompressionLevel, s.KindIDs[mode])
+		if err != nil {
+			return fmt.Errorf("mode %v: %w", s.ModeNames[mode], err)
+		}
+	}
+	if s.KindClasses != nil && len(s.KindClasses) != kindCount {
+		return fmt.Errorf("the number of kind classes (%v) must equal the number of kinds (%v)", len(s.KindClasses), kindCount)
+	}
+	if s.KindTransformations != nil && len(s.KindTransformations) != kindCount {
+		return fmt.Errorf("the number of kind transformations (%v) must equal the number of kinds (%v)", len(s.KindTransformations), kindCount)
+	}
+	if s.KindCaptures != nil {
+		if len(s.KindCaptures) != kindCount {
+			return fmt.Errorf("the number of kind captures (%v) must equal the number of kinds (%v)", len(s.KindCaptures), kindCount)
+		}
+		for kind, caps := range s.KindCaptures {
+			if caps == nil {
+				continue
+			}
+			if caps.NFA == nil {
+				return fmt.Errorf("kind %v: the capture groups have no NFA", s.KindNames[kind])
+			}
+			err := caps.NFA.validate(LexModeKindIDMin.Int()+1, len(caps.Names))
+			if err != nil {
+				return fmt.Errorf("kind %v: capture groups: %w", s.KindNames[kind], err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *CompiledLexModeSpec) validate(modeCount, kindCount, compLv int, kindIDs []LexKindID) error {
+	modeKindCount := len(s.KindNames)
+	if modeKindCount < 1 {
+		return fmt.Errorf("kind names must start with the nil kind")
+	}
+	if len(s.Push) != modeKindCount || len(s.Pop) != modeKindCount {
+		return fmt.Errorf("the push and pop tables must have %v entries", modeKindCount)
+	}
+	for _, m := range s.Push {
+		if m < LexModeIDNil || m.Int() >= modeCount {
+			return fmt.Errorf("push table: mode ID out of range: %v", m)
+		}
+	}
+	if len(kindIDs) != modeKindCount {
+		return fmt.Errorf("the kind ID table must have %v entries", modeKindCount)
+	}
+	for _, id := range kindIDs {
+		if id < LexKindIDNil || id.Int() >= kindCount {
+			return fmt.Errorf("kind ID table: kind ID out of range: %v", id)
+		}
+	}
+	for modeKind, tab := range s.Keywords {
+		for lexeme, kw := range tab {
+			if kw <= LexModeKindIDNil || kw.Int() >= modeKindCount {
+				return fmt.Errorf("keyword %q of mode kind %v: mode kind ID out of range: %v", lexeme, modeKind, kw)
+			}
+		}
+	}
+	if s.Keywords != nil && len(s.Keywords) != modeKindCount {
+		return fmt.Errorf("the keyword table must have %v entries", modeKindCount)
+	}
+	for modeKind, tab := range s.CaseInsensitiveKeywords {
+		for lexeme, kw := range tab {
+			if kw <= LexModeKindIDNil || kw.Int() >= modeKindCount {
+				return fmt.Errorf("case-insensitive keyword %q of mode kind %v: mode kind ID out of range: %v", lexeme, modeKind, kw)
+			}
+		}
+	}
+	if s.CaseInsensitiveKeywords != nil && len(s.CaseInsensitiveKeywords) != modeKindCount {
+		return fmt.Errorf("the case-insensitive keyword table must have %v entries", modeKindCount)
+	}
+	switch {
+	case s.DFA != nil && s.NFA != nil:
+		return fmt.Errorf("a mode cannot have both a DFA and an NFA")
+	case s.DFA != nil:
+		return s.DFA.validate(modeKindCount, compLv)
+	case s.NFA != nil:
+		return s.NFA.validate(modeKindCount, 0)
+	default:
+		return fmt.Errorf("a mode must have either a DFA or an NFA")
+	}
+}
+
+func (t *TransitionTable) validate(modeKindCount, compLv int) error {
+	if t.RowCount < 1 || t.ColCount != 256 {
+		return fmt.Errorf("DFA: invalid table size: %v x %v", t.RowCount, t.ColCount)
+	}
+	validState := func(s StateID) bool {
+		return s >= StateIDNil && s.Int() < t.RowCount
+	}
+	if t.InitialStateID < StateIDMin || !validState(t.InitialStateID) {
+		return fmt.Errorf("DFA: initial state ID out of range: %v", t.InitialStateID)
+	}
+	for _, accs := range [][]LexModeKindID{t.AcceptingStates, t.CaseInsensitiveAcceptingStates} {
+		if accs == nil {
+			continue
+		}
+		if len(accs) != t.RowCount {
+			return fmt.Errorf("DFA: the accepting state table must have %v entries", t.RowCount)
+		}
+		for _, id := range accs {
+			if id < LexModeKindIDNil || id.Int() >= modeKindCount {
+				return fmt.Errorf("DFA: accepting state table: mode kind ID out of range: %v", id)
+			}
+		}
+	}
+	if t.AcceptingStates == nil {
+		return fmt.Errorf("DFA: the accepting state table is missing")
+	}
+	validEntries := func(entries []StateID) error {
+		for _, s := range entries {
+			if !validState(s) {
+				return fmt.Errorf("DFA: state ID out of range: %v", s)
+			}
+		}
+		return nil
+	}
+	switch compLv {
+	case 0:
+		if len(t.UncompressedTransition) != t.RowCount*t.ColCount {
+			return fmt.Errorf("DFA: the transition table must have %v entries", t.RowCount*t.ColCount)
+		}
+		return validEntries(t.UncompressedTransition)
+	case 1, 2:
+		tab := t.Transition
+		if tab == nil {
+			return fmt.Errorf("DFA: the transition table is missing")
+		}
+		if tab.OriginalRowCount != t.RowCount || tab.OriginalColCount != t.ColCount || len(tab.RowNums) != t.RowCount {
+			return fmt.Errorf("DFA: the unique entry table has an invalid size")
+		}
+		uniqueRowCount := 0
+		if compLv == 1 {
+			if len(tab.UncompressedUniqueEntries)%t.ColCount != 0 {
+				return fmt.Errorf("DFA: the unique entry table has an invalid size")
+			}
+			uniqueRowCount = len(tab.UncompressedUniqueEntries) / t.ColCount
+			err := validEntries(tab.UncompressedUniqueEntries)
+			if err != nil {
+				return err
+			}
+		} else {
+			rd := tab.UniqueEntries
+			if rd == nil {
+				return fmt.Errorf("DFA: the unique entry table is missing")
+			}
+			if rd.OriginalColCount != t.ColCount || len(rd.RowDisplacement) != rd.OriginalRowCount || len(rd.Bounds) != len(rd.Entries) {
+				return fmt.Errorf("DFA: the row displacement table has an invalid size")
+			}
+			for _, d := range rd.RowDisplacement {
+				if d < 0 || d+rd.OriginalColCount > len(rd.Entries) {
+					return fmt.Errorf("DFA: row displacement out of range: %v", d)
+				}
+			}
+			if !validState(rd.EmptyValue) {
+				return fmt.Errorf("DFA: state ID out of range: %v", rd.EmptyValue)
+			}
+			uniqueRowCount = rd.OriginalRowCount
+			err := validEntries(rd.Entries)
+			if err != nil {
+				return err
+			}
+		}
+		for _, n := range tab.RowNums {
+			if n < 0 || n >= uniqueRowCount {
+				return fmt.Errorf("DFA: row number out of range: %v", n)
+			}
+		}
+		return nil
+	case 3:
+		tab := t.BaseCheckTransition
+		if tab == nil {
+			return fmt.Errorf("DFA: the transition table is missing")
+		}
+		if len(tab.Base) != t.RowCount || len(tab.Default) != t.RowCount || len(tab.Next) != len(tab.Check) {
+			return fmt.Errorf("DFA: the base-check table has an invalid size")
+		}
+		for _, b := range tab.Base {
+			if b < 0 || b+t.ColCount > len(tab.Next) {
+				return fmt.Errorf("DFA: base out of range: %v", b)
+			}
+		}
+		for _, d := range tab.Default {
+			if d < -1 || d >= t.RowCount {
+				return fmt.Errorf("DFA: default state out of range: %v", d)
+			}
+		}
+		// A lexer follows default states until it finds a transition, so the default states must not form a cycle.
+		done := make([]bool, t.RowCount)
+		for row := range tab.Default {
+			n := 0
+			for r := row; r != -1 && !done[r]; r = tab.Default[r] {
+				n++
+				if n > t.RowCount {
+					return fmt.Errorf("DFA: default states form a cycle: %v", row)
+				}
+			}
+			for r := row; r != -1 && !done[r]; r = tab.Default[r] {
+				done[r] = true
+			}
+		}
+		return validEntries(tab.Next)
+	default:
+		return fmt.Errorf("invalid compression level: %v", compLv)
+	}
+}
+
+// validate checks an NFA. Its end markers must accept mode kind IDs less than `modeKindCount`, and its tags must refer
+// to capture groups less than `captureCount`.
+func (a *NFA) validate(modeKindCount, captureCount int) error {
+	n := len(a.From)
+	if len(a.To) != n || len(a.Follow) != n || len(a.Accept) != n {
+		return fmt.Errorf("NFA: the position tables must have the same number of entries")
+	}
+	validPos := func(ps []int) bool {
+		for _, p := range ps {
+			if p < 0 || p >= n {
+				return false
+			}
+		}
+		return true
+	}
+	if !validPos(a.InitialPositions) || !validPos(a.Folded) {
+		return fmt.Errorf("NFA: position out of range")
+	}
+	for p := 0; p < n; p++ {
+		if !validPos(a.Follow[p]) {
+			return fmt.Errorf("NFA: position %v: a following position is out of range", p)
+		}
+		if a.Accept[p] < LexModeKindIDNil || a.Accept[p].Int() >= modeKindCount {
+			return fmt.Errorf("NFA: position %v: mode kind ID out of range: %v", p, a.Accept[p])
+		}
+		if a.Accept[p] == LexModeKindIDNil && (a.From[p] < 0 || a.From[p] > a.To[p] || a.To[p] > 255) {
+			return fmt.Errorf("NFA: position %v: invalid byte range: %v..%v", p, a.From[p], a.To[p])
+		}
+	}
+	if a.Tags != nil {
+		if len(a.Tags) != n {
+			return fmt.Errorf("NFA: the tag table must have %v entries", n)
+		}
+		for p, tags := range a.Tags {
+			for _, c := range tags {
+				if c < 0 || c >= captureCount {
+					return fmt.Errorf("NFA: position %v: capture group out of range: %v", p, c)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *SyntacticSpec) validate(lex *LexicalSpec) error {
+	if s.StateCount < 1 {
+		return fmt.Errorf("the parsing table must have at least one state")
+	}
+	if s.TerminalCount != len(s.Terminals) {
+		return fmt.Errorf("the terminal count (%v) must equal the number of terminal symbols (%v)", s.TerminalCount, len(s.Terminals))
+	}
+	if s.NonTerminalCount != len(s.NonTerminals) {
+		return fmt.Errorf("the non-terminal count (%v) must equal the number of non-terminal symbols (%v)", s.NonTerminalCount, len(s.NonTerminals))
+	}
+	validState := func(state int) bool {
+		return state >= 0 && state < s.StateCount
+	}
+	validTerm := func(term int) bool {
+		return term >= 0 && term < s.TerminalCount
+	}
+	prodCount := len(s.LHSSymbols)
+	validProd := func(prod int) bool {
+		return prod > 0 && prod < prodCount
+	}
+	if len(s.AlternativeSymbolCounts) != prodCount || len(s.RecoverProductions) != prodCount {
+		return fmt.Errorf("the production tables must have the same number of entries")
+	}
+	for prod, lhs := range s.LHSSymbols {
+		if prod == 0 {
+			continue
+		}
+		if lhs <= 0 || lhs >= s.NonTerminalCount {
+			return fmt.Errorf("production %v: LHS symbol out of range: %v", prod, lhs)
+		}
+		if s.AlternativeSymbolCounts[prod] < 0 {
+			return fmt.Errorf("production %v: negative symbol count: %v", prod, s.AlternativeSymbolCounts[prod])
+		}
+	}
+
+	if len(s.Action) != s.StateCount*s.TerminalCount {
+		return fmt.Errorf("the action table must have %v x %v entries", s.StateCount, s.TerminalCount)
+	}
+	for i, act := range s.Action {
+		switch {
+		case act < 0 && !validState(-act):
+			return fmt.Errorf("state %v: shift to a state out of range: %v", i/s.TerminalCount, -act)
+		case act > 0 && !validProd(act):
+			return fmt.Errorf("state %v: reduce by a production out of range: %v", i/s.TerminalCount, act)
+		}
+	}
+	if len(s.GoTo) != s.StateCount*s.NonTerminalCount {
+		return fmt.Errorf("the goto table must have %v x %v entries", s.StateCount, s.NonTerminalCount)
+	}
+	for i, next := range s.GoTo {
+		if !validState(next) {
+			return fmt.Errorf("state %v: goto a state out of range: %v", i/s.NonTerminalCount, next)
+		}
+	}
+	if len(s.ErrorTrapperStates) != s.StateCount {
+		return fmt.Errorf("the error trapper state table must have %v entries", s.StateCount)
+	}
+
+	if !validState(s.InitialState) {
+		return fmt.Errorf("initial state out of range: %v", s.InitialState)
+	}
+	if !validProd(s.StartProduction) {
+		return fmt.Errorf("start production out of range: %v", s.StartProduction)
+	}
+	for _, e := range s.EntryPoints {
+		if !validState(e.InitialState) || !validProd(e.StartProduction) {
+			return fmt.Errorf("entry point %v: state or production out of range", e.Symbol)
+		}
+	}
+	if !validTerm(s.EOFSymbol) || !validTerm(s.ErrorSymbol) {
+		return fmt.Errorf("the EOF or error symbol is out of range")
+	}
+	// The parser accepts an input by reducing the start production, so shifting the EOF symbol makes the parser read
+	// the EOF forever.
+	for state := 0; state < s.StateCount; state++ {
+		if s.Action[state*s.TerminalCount+s.EOFSymbol] < 0 {
+			return fmt.Errorf("state %v: shift by the EOF symbol", state)
+		}
+	}
+	if len(s.TerminalSkip) != s.TerminalCount {
+		return fmt.Errorf("the skip table must have %v entries", s.TerminalCount)
+	}
+	if s.TerminalLiterals != nil && len(s.TerminalLiterals) != s.TerminalCount {
+		return fmt.Errorf("the terminal literal table must have %v entries", s.TerminalCount)
+	}
+	if s.TerminalKeep != nil && len(s.TerminalKeep) != s.TerminalCount {
+		return fmt.Errorf("the keep table must have %v entries", s.TerminalCount)
+	}
+	if s.AlternativeLabels != nil && len(s.AlternativeLabels) != prodCount {
+		return fmt.Errorf("the label table must have %v entries", prodCount)
+	}
+	if s.LexModeActions != nil {
+		if len(s.LexModeActions) != len(s.Action) {
+			return fmt.Errorf("the lex mode action table must have %v x %v entries", s.StateCount, s.TerminalCount)
+		}
+		for _, act := range s.LexModeActions {
+			if act < -1 || act >= len(lex.ModeNames) {
+				return fmt.Errorf("lex mode action out of range: %v", act)
+			}
+		}
+	}
+	if s.ErrorSyncTerminals != nil {
+		if len(s.ErrorSyncTerminals) != s.StateCount {
+			return fmt.Errorf("the synchronization set table must have %v entries", s.StateCount)
+		}
+		for state, terms := range s.ErrorSyncTerminals {
+			for _, term := range terms {
+				if !validTerm(term) {
+					return fmt.Errorf("state %v: synchronization terminal out of range: %v", state, term)
+				}
+			}
+		}
+	}
+	if s.Layout != nil && (!validTerm(s.Layout.Indent) || !validTerm(s.Layout.Dedent) || !validTerm(s.Layout.Newline)) {
+		return fmt.Errorf("a layout terminal is out of range")
+	}
+	if !validTerm(s.FallbackTerminal) {
+		return fmt.Errorf("the fallback terminal is out of range: %v", s.FallbackTerminal)
+	}
+
+	// Each kind maps to the terminal symbol having the same name, so the mapping is a bijection between kinds and
+	// the terminal symbols the lexer produces.
+	if len(s.KindToTerminal) != len(lex.KindNames) {
+		return fmt.Errorf("the kind-to-terminal table (%v entries) must have an entry per kind (%v)", len(s.KindToTerminal), len(lex.KindNames))
+	}
+	if s.KindToTerminal[LexKindIDNil] != 0 {
+		return fmt.Errorf("the nil kind must map to the nil terminal symbol")
+	}
+	mapped := make(map[int]LexKindName, len(s.KindToTerminal))
+	for kind, term := range s.KindToTerminal[1:] {
+		kindName := lex.KindNames[kind+1]
+		if term <= 0 || !validTerm(term) {
+			return fmt.Errorf("kind %v: terminal symbol out of range: %v", kindName, term)
+		}
+		if k, ok := mapped[term]; ok {
+			return fmt.Errorf("kinds %v and %v map to the same terminal symbol %v", k, kindName, s.Terminals[term])
+		}
+		mapped[term] = kindName
+		if s.Terminals[term] != kindName.String() {
+			return fmt.Errorf("kind %v maps to a terminal symbol having another name: %v", kindName, s.Terminals[term])
+		}
+	}
+	return nil
+}
+
+func (a *ASTAction) validate(syn *SyntacticSpec) error {
+	prodCount := len(syn.LHSSymbols)
+	if len(a.Entries) != prodCount {
+		return fmt.Errorf("the entry table must have %v entries", prodCount)
+	}
+	for prod, es := range a.Entries {
+		symCount := syn.AlternativeSymbolCounts[prod]
+		for _, e := range es {
+			if e == 0 || e > symCount || -e > symCount {
+				return fmt.Errorf("production %v: element position out of range: %v", prod, e)
+			}
+		}
+	}
+	if a.NodeNames != nil && len(a.NodeNames) != prodCount {
+		return fmt.Errorf("the node name table must have %v entries", prodCount)
+	}
+	if a.Lifts != nil {
+		if len(a.Lifts) != prodCount {
+			return fmt.Errorf("the lift table must have %v entries", prodCount)
+		}
+		for prod, lift := range a.Lifts {
+			if lift < 0 || lift > syn.AlternativeSymbolCounts[prod] {
+				return fmt.Errorf("production %v: lifted element position out of range: %v", prod, lift)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	driver "github.com/nihei9/vartan/driver/parser"
+)
+
+// TreeDifference is the first difference CompareTrees finds between two syntax trees.
+type TreeDifference struct {
+	// Path is the path from the root to the diverging nodes, such as `stmts.[1]stmt.[0]id`. Each step consists of
+	// the index of a child in brackets and its kind name.
+	Path    string
+	Message string
+
+	// Old and New are the diverging nodes of the old tree and the new tree.
+	Old *driver.Node
+	New *driver.Node
+}
+
+// ReadTree reads a syntax tree in the JSON format that `vartan parse --format json` prints.
+func ReadTree(r io.Reader) (*driver.Node, error) {
+	var tree *driver.Node
+	err := json.NewDecoder(r).Decode(&tree)
+	if err != nil {
+		return nil, err
+	}
+	if tree == nil {
+		return nil, fmt.Errorf("the input contains no tree")
+	}
+	return tree, nil
+}
+
+// CompareTrees compares two syntax trees structurally and returns the first difference in pre-order. The comparison
+// ignores the positions of nodes, so it only looks at node types, kind names, labels, texts of terminal nodes, and
+// the structure. When the trees are the same, CompareTrees returns nil.
+func CompareTrees(oldTree, newTree *driver.Node) *TreeDifference {
+	if oldTree == nil || newTree == nil {
+		if oldTree == newTree {
+			return nil
+		}
+		return &TreeDifference{
+			Message: "only one of the trees exists",
+			Old:     oldTree,
+			New:     newTree,
+		}
+	}
+	return compareNodes(oldTree.KindName, oldTree, newTree)
+}
+
+func compareNodes(path string, oldNode, newNode *driver.Node) *TreeDifference {
+	diff := func(format string, a ...interface{}) *TreeDifference {
+		return &TreeDifference{
+			Path:    path,
+			Message: fmt.Sprintf(format, a...),
+			Old:     oldNode,
+			New:     newNode,
+		}
+	}
+	switch {
+	case oldNode.KindName != newNode.KindName:
+		return diff("the kinds differ: %v and %v", oldNode.KindName, newNode.KindName)
+	case oldNode.Type != newNode.Type:
+		return diff("the node types differ: %v and %v", nodeTypeName(oldNode.Type), nodeTypeName(newNode.Type))
+	case oldNode.Label != newNode.Label:
+		return diff("the labels differ: %q and %q", oldNode.Label, newNode.Label)
+	case oldNode.Type == driver.NodeTypeTerminal && oldNode.Text != newNode.Text:
+		return diff("the texts differ: %v and %v", strconv.Quote(oldNode.Text), strconv.Quote(newNode.Text))
+	}
+
+	for i := 0; i < len(oldNode.Children) && i < len(newNode.Children); i++ {
+		c := oldNode.Children[i]
+		d := compareNodes(fmt.Sprintf("%v.[%v]%v", path, i, c.KindName), c, newNode.Children[i])
+		if d != nil {
+			return d
+		}
+	}
+	if len(oldNode.Children) != len(newNode.Children) {
+		return diff("the child counts differ: %v and %v", len(oldNode.Children), len(newNode.Children))
+	}
+	return nil
+}
+
+func nodeTypeName(t driver.NodeType) string {
+	switch t {
+	case driver.NodeTypeError:
+		return "error"
+	case driver.NodeTypeTerminal:
+		return "terminal"
+	case driver.NodeTypeNonTerminal:
+		return "non-terminal"
+	}
+	return fmt.Sprintf("unknown (%v)", int(t))
+}
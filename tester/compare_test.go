@@ -0,0 +1,115 @@
+package tester
+
+import (
+	"strings"
+	"testing"
+
+	driver "github.com/nihei9/vartan/driver/parser"
+)
+
+func TestCompareTrees(t *testing.T) {
+	// The trees are `a = 1;` and its variants printed by `vartan parse --format json`.
+	const base = `{"type":2,"kind_name":"stmt","row":0,"col":0,"byte_pos":0,"byte_len":5,"children":[
+	{"type":1,"kind_name":"id","text":"a","row":0,"col":0,"byte_pos":0,"byte_len":1},
+	{"type":1,"kind_name":"int","text":"1","row":0,"col":4,"byte_pos":4,"byte_len":1}]}`
+
+	tests := []struct {
+		caption string
+		newTree string
+		path    string
+		message string
+	}{
+		{
+			caption: "the comparison ignores positions",
+			newTree: `{"type":2,"kind_name":"stmt","row":1,"col":2,"byte_pos":9,"byte_len":7,"children":[
+	{"type":1,"kind_name":"id","text":"a","row":1,"col":2,"byte_pos":9,"byte_len":1},
+	{"type":1,"kind_name":"int","text":"1","row":1,"col":8,"byte_pos":15,"byte_len":1}]}`,
+		},
+		{
+			caption: "a different text",
+			newTree: `{"type":2,"kind_name":"stmt","children":[
+	{"type":1,"kind_name":"id","text":"a"},
+	{"type":1,"kind_name":"int","text":"2"}]}`,
+			path:    "stmt.[1]int",
+			message: `the texts differ: "1" and "2"`,
+		},
+		{
+			caption: "a different kind",
+			newTree: `{"type":2,"kind_name":"stmt","children":[
+	{"type":1,"kind_name":"name","text":"a"},
+	{"type":1,"kind_name":"int","text":"1"}]}`,
+			path:    "stmt.[0]id",
+			message: "the kinds differ: id and name",
+		},
+		{
+			caption: "a different label",
+			newTree: `{"type":2,"kind_name":"stmt","label":"assign","children":[
+	{"type":1,"kind_name":"id","text":"a"},
+	{"type":1,"kind_name":"int","text":"1"}]}`,
+			path:    "stmt",
+			message: `the labels differ: "" and "assign"`,
+		},
+		{
+			caption: "an extra child",
+			newTree: `{"type":2,"kind_name":"stmt","children":[
+	{"type":1,"kind_name":"id","text":"a"},
+	{"type":1,"kind_name":"int","text":"1"},
+	{"type":0,"kind_name":"error"}]}`,
+			path:    "stmt",
+			message: "the child counts differ: 2 and 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			oldTree, err := ReadTree(strings.NewReader(base))
+			if err != nil {
+				t.Fatal(err)
+			}
+			newTree, err := ReadTree(strings.NewReader(tt.newTree))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := CompareTrees(oldTree, newTree)
+			if tt.message == "" {
+				if d != nil {
+					t.Fatalf("unexpected difference: %v: %v", d.Path, d.Message)
+				}
+				return
+			}
+			if d == nil {
+				t.Fatal("a difference must be found")
+			}
+			if d.Path != tt.path || d.Message != tt.message {
+				t.Fatalf("unexpected difference; want: %v: %v, got: %v: %v", tt.path, tt.message, d.Path, d.Message)
+			}
+		})
+	}
+
+	t.Run("ReadTree rejects a node without a type", func(t *testing.T) {
+		_, err := ReadTree(strings.NewReader(`{"kind_name":"stmt"}`))
+		if err == nil {
+			t.Fatal("an error must occur")
+		}
+	})
+
+	t.Run("a tree survives a round trip through JSON", func(t *testing.T) {
+		tree, err := ReadTree(strings.NewReader(base))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := tree.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree2, err := ReadTree(strings.NewReader(string(b)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := CompareTrees(tree, tree2); d != nil {
+			t.Fatalf("unexpected difference: %v: %v", d.Path, d.Message)
+		}
+		if tree2.Children[1].BytePos != 4 || tree2.Type != driver.NodeTypeNonTerminal {
+			t.Fatalf("unexpected tree: %+v", tree2)
+		}
+	})
+}
@@ -8,7 +8,9 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"github.com/nihei9/vartan/driver/lexer"
 	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/nihei9/vartan/normalizer"
 	gspec "github.com/nihei9/vartan/spec/grammar"
 	tspec "github.com/nihei9/vartan/spec/test"
 )
@@ -96,22 +98,26 @@ func parseTestCase(testCasePath string) (*tspec.TestCase, error) {
 type Tester struct {
 	Grammar *gspec.CompiledGrammar
 	Cases   []*TestCaseWithMetadata
+
+	// EntryPoint is a non-terminal symbol the parser starts parsing test cases at. It must be the start symbol or
+	// a symbol declared by a `#start` directive. An empty string means the start symbol.
+	EntryPoint string
 }
 
 func (t *Tester) Run() []*TestResult {
 	var rs []*TestResult
 	for _, c := range t.Cases {
-		rs = append(rs, runTest(t.Grammar, c))
+		rs = append(rs, runTest(t.Grammar, c, t.EntryPoint))
 	}
 	return rs
 }
 
-func runTest(g *gspec.CompiledGrammar, c *TestCaseWithMetadata) *TestResult {
+func runTest(g *gspec.CompiledGrammar, c *TestCaseWithMetadata, entryPoint string) *TestResult {
 	var p *driver.Parser
 	var tb *driver.DefaultSyntaxTreeBuilder
 	{
 		gram := driver.NewGrammar(g)
-		toks, err := driver.NewTokenStream(g, bytes.NewReader(c.TestCase.Source))
+		toks, err := driver.NewTokenStream(g, bytes.NewReader(c.TestCase.Source), lexer.NormalizeWith(normalizer.Default))
 		if err != nil {
 			return &TestResult{
 				TestCasePath: c.FilePath,
@@ -119,7 +125,13 @@ func runTest(g *gspec.CompiledGrammar, c *TestCaseWithMetadata) *TestResult {
 			}
 		}
 		tb = driver.NewDefaultSyntaxTreeBuilder()
-		p, err = driver.NewParser(toks, gram, driver.SemanticAction(driver.NewASTActionSet(gram, tb)))
+		opts := []driver.ParserOption{
+			driver.SemanticAction(driver.NewASTActionSet(gram, tb)),
+		}
+		if entryPoint != "" {
+			opts = append(opts, driver.EntryPoint(entryPoint))
+		}
+		p, err = driver.NewParser(toks, gram, opts...)
 		if err != nil {
 			return &TestResult{
 				TestCasePath: c.FilePath,
@@ -167,3 +167,89 @@ foo foo foo
 		})
 	}
 }
+
+func TestTester_Run_EntryPoint(t *testing.T) {
+	ast, err := parser.Parse(strings.NewReader(`
+#name test;
+#start expr;
+
+stmt
+    : id eq expr
+    ;
+expr
+    : expr plus id
+    | id
+    ;
+
+ws #skip
+    : "[\u{0009}\u{0020}]+";
+eq
+    : '=';
+plus
+    : '+';
+id
+    : "[a-z]+";
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, _, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tspec.ParseTestCase(strings.NewReader(`test
+---
+a + b
+---
+(expr (expr (id 'a')) (plus '+') (id 'b'))
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := []*TestCaseWithMetadata{
+		{
+			TestCase: c,
+		},
+	}
+
+	t.Run("the tester parses test cases at an entry point", func(t *testing.T) {
+		tester := &Tester{
+			Grammar:    cg,
+			Cases:      cs,
+			EntryPoint: "expr",
+		}
+		for _, r := range tester.Run() {
+			if r.Error != nil {
+				t.Fatalf("unexpected error occurred: %v", r.Error)
+			}
+		}
+	})
+
+	t.Run("the tester parses test cases at the start symbol by default", func(t *testing.T) {
+		tester := &Tester{
+			Grammar: cg,
+			Cases:   cs,
+		}
+		for _, r := range tester.Run() {
+			if r.Error == nil {
+				t.Fatal("this test must fail, but it passed")
+			}
+		}
+	})
+
+	t.Run("an undeclared entry point is an error", func(t *testing.T) {
+		tester := &Tester{
+			Grammar:    cg,
+			Cases:      cs,
+			EntryPoint: "foo",
+		}
+		for _, r := range tester.Run() {
+			if r.Error == nil {
+				t.Fatal("this test must fail, but it passed")
+			}
+		}
+	})
+}
@@ -1,5 +1,6 @@
 //go:generate go run ../cmd/ucdgen/main.go
 //go:generate go fmt codepoint.go
+//go:generate go run ../cmd/graphemegen/main.go -o ../driver/lexer/grapheme.go
 
 package ucd
 
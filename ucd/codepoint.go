@@ -440,18 +440,12 @@ var generalCategoryCodePoints = map[string][]*CodePointRange{
 		&CodePointRange{From: rune(917536), To: rune(917631)},
 	},
 	"co": {
-		&CodePointRange{From: rune(57344), To: rune(57344)},
-		&CodePointRange{From: rune(63743), To: rune(63743)},
-		&CodePointRange{From: rune(983040), To: rune(983040)},
-		&CodePointRange{From: rune(1048573), To: rune(1048573)},
-		&CodePointRange{From: rune(1048576), To: rune(1048576)},
-		&CodePointRange{From: rune(1114109), To: rune(1114109)},
+		&CodePointRange{From: rune(57344), To: rune(63743)},
+		&CodePointRange{From: rune(983040), To: rune(1048573)},
+		&CodePointRange{From: rune(1048576), To: rune(1114109)},
 	},
 	"cs": {
-		&CodePointRange{From: rune(55296), To: rune(55296)},
-		&CodePointRange{From: rune(56191), To: rune(56192)},
-		&CodePointRange{From: rune(56319), To: rune(56320)},
-		&CodePointRange{From: rune(57343), To: rune(57343)},
+		&CodePointRange{From: rune(55296), To: rune(57343)},
 	},
 	"ll": {
 		&CodePointRange{From: rune(97), To: rune(122)},
@@ -1379,10 +1373,8 @@ var generalCategoryCodePoints = map[string][]*CodePointRange{
 		&CodePointRange{From: rune(12593), To: rune(12686)},
 		&CodePointRange{From: rune(12704), To: rune(12735)},
 		&CodePointRange{From: rune(12784), To: rune(12799)},
-		&CodePointRange{From: rune(13312), To: rune(13312)},
-		&CodePointRange{From: rune(19903), To: rune(19903)},
-		&CodePointRange{From: rune(19968), To: rune(19968)},
-		&CodePointRange{From: rune(40956), To: rune(40956)},
+		&CodePointRange{From: rune(13312), To: rune(19903)},
+		&CodePointRange{From: rune(19968), To: rune(40956)},
 		&CodePointRange{From: rune(40960), To: rune(40980)},
 		&CodePointRange{From: rune(40982), To: rune(42124)},
 		&CodePointRange{From: rune(42192), To: rune(42231)},
@@ -1430,8 +1422,7 @@ var generalCategoryCodePoints = map[string][]*CodePointRange{
 		&CodePointRange{From: rune(43808), To: rune(43814)},
 		&CodePointRange{From: rune(43816), To: rune(43822)},
 		&CodePointRange{From: rune(43968), To: rune(44002)},
-		&CodePointRange{From: rune(44032), To: rune(44032)},
-		&CodePointRange{From: rune(55203), To: rune(55203)},
+		&CodePointRange{From: rune(44032), To: rune(55203)},
 		&CodePointRange{From: rune(55216), To: rune(55238)},
 		&CodePointRange{From: rune(55243), To: rune(55291)},
 		&CodePointRange{From: rune(63744), To: rune(64109)},
@@ -1601,11 +1592,9 @@ var generalCategoryCodePoints = map[string][]*CodePointRange{
 		&CodePointRange{From: rune(93053), To: rune(93071)},
 		&CodePointRange{From: rune(93952), To: rune(94026)},
 		&CodePointRange{From: rune(94032), To: rune(94032)},
-		&CodePointRange{From: rune(94208), To: rune(94208)},
-		&CodePointRange{From: rune(100343), To: rune(100343)},
+		&CodePointRange{From: rune(94208), To: rune(100343)},
 		&CodePointRange{From: rune(100352), To: rune(101589)},
-		&CodePointRange{From: rune(101632), To: rune(101632)},
-		&CodePointRange{From: rune(101640), To: rune(101640)},
+		&CodePointRange{From: rune(101632), To: rune(101640)},
 		&CodePointRange{From: rune(110592), To: rune(110878)},
 		&CodePointRange{From: rune(110928), To: rune(110930)},
 		&CodePointRange{From: rune(110948), To: rune(110951)},
@@ -1651,19 +1640,13 @@ var generalCategoryCodePoints = map[string][]*CodePointRange{
 		&CodePointRange{From: rune(126625), To: rune(126627)},
 		&CodePointRange{From: rune(126629), To: rune(126633)},
 		&CodePointRange{From: rune(126635), To: rune(126651)},
-		&CodePointRange{From: rune(131072), To: rune(131072)},
-		&CodePointRange{From: rune(173789), To: rune(173789)},
-		&CodePointRange{From: rune(173824), To: rune(173824)},
-		&CodePointRange{From: rune(177972), To: rune(177972)},
-		&CodePointRange{From: rune(177984), To: rune(177984)},
-		&CodePointRange{From: rune(178205), To: rune(178205)},
-		&CodePointRange{From: rune(178208), To: rune(178208)},
-		&CodePointRange{From: rune(183969), To: rune(183969)},
-		&CodePointRange{From: rune(183984), To: rune(183984)},
-		&CodePointRange{From: rune(191456), To: rune(191456)},
+		&CodePointRange{From: rune(131072), To: rune(173789)},
+		&CodePointRange{From: rune(173824), To: rune(177972)},
+		&CodePointRange{From: rune(177984), To: rune(178205)},
+		&CodePointRange{From: rune(178208), To: rune(183969)},
+		&CodePointRange{From: rune(183984), To: rune(191456)},
 		&CodePointRange{From: rune(194560), To: rune(195101)},
-		&CodePointRange{From: rune(196608), To: rune(196608)},
-		&CodePointRange{From: rune(201546), To: rune(201546)},
+		&CodePointRange{From: rune(196608), To: rune(201546)},
 	},
 	"lt": {
 		&CodePointRange{From: rune(453), To: rune(453)},
@@ -6550,3 +6533,1459 @@ var whiteSpaceCodePoints = []*CodePointRange{
 	&CodePointRange{From: rune(8287), To: rune(8287)},
 	&CodePointRange{From: rune(12288), To: rune(12288)},
 }
+
+// https://www.unicode.org/Public/13.0.0/ucd/auxiliary/GraphemeBreakProperty.txt
+var graphemeClusterBreakCodePoints = map[string][]*CodePointRange{
+	"control": {
+		&CodePointRange{From: rune(0), To: rune(9)},
+		&CodePointRange{From: rune(11), To: rune(12)},
+		&CodePointRange{From: rune(14), To: rune(31)},
+		&CodePointRange{From: rune(127), To: rune(159)},
+		&CodePointRange{From: rune(173), To: rune(173)},
+		&CodePointRange{From: rune(1564), To: rune(1564)},
+		&CodePointRange{From: rune(6158), To: rune(6158)},
+		&CodePointRange{From: rune(8203), To: rune(8203)},
+		&CodePointRange{From: rune(8206), To: rune(8207)},
+		&CodePointRange{From: rune(8232), To: rune(8238)},
+		&CodePointRange{From: rune(8288), To: rune(8303)},
+		&CodePointRange{From: rune(65279), To: rune(65279)},
+		&CodePointRange{From: rune(65520), To: rune(65531)},
+		&CodePointRange{From: rune(78896), To: rune(78904)},
+		&CodePointRange{From: rune(113824), To: rune(113827)},
+		&CodePointRange{From: rune(119155), To: rune(119162)},
+		&CodePointRange{From: rune(917504), To: rune(917535)},
+		&CodePointRange{From: rune(917632), To: rune(917759)},
+		&CodePointRange{From: rune(918000), To: rune(921599)},
+	},
+	"cr": {
+		&CodePointRange{From: rune(13), To: rune(13)},
+	},
+	"extend": {
+		&CodePointRange{From: rune(768), To: rune(879)},
+		&CodePointRange{From: rune(1155), To: rune(1161)},
+		&CodePointRange{From: rune(1425), To: rune(1469)},
+		&CodePointRange{From: rune(1471), To: rune(1471)},
+		&CodePointRange{From: rune(1473), To: rune(1474)},
+		&CodePointRange{From: rune(1476), To: rune(1477)},
+		&CodePointRange{From: rune(1479), To: rune(1479)},
+		&CodePointRange{From: rune(1552), To: rune(1562)},
+		&CodePointRange{From: rune(1611), To: rune(1631)},
+		&CodePointRange{From: rune(1648), To: rune(1648)},
+		&CodePointRange{From: rune(1750), To: rune(1756)},
+		&CodePointRange{From: rune(1759), To: rune(1764)},
+		&CodePointRange{From: rune(1767), To: rune(1768)},
+		&CodePointRange{From: rune(1770), To: rune(1773)},
+		&CodePointRange{From: rune(1809), To: rune(1809)},
+		&CodePointRange{From: rune(1840), To: rune(1866)},
+		&CodePointRange{From: rune(1958), To: rune(1968)},
+		&CodePointRange{From: rune(2027), To: rune(2035)},
+		&CodePointRange{From: rune(2045), To: rune(2045)},
+		&CodePointRange{From: rune(2070), To: rune(2073)},
+		&CodePointRange{From: rune(2075), To: rune(2083)},
+		&CodePointRange{From: rune(2085), To: rune(2087)},
+		&CodePointRange{From: rune(2089), To: rune(2093)},
+		&CodePointRange{From: rune(2137), To: rune(2139)},
+		&CodePointRange{From: rune(2259), To: rune(2273)},
+		&CodePointRange{From: rune(2275), To: rune(2306)},
+		&CodePointRange{From: rune(2362), To: rune(2362)},
+		&CodePointRange{From: rune(2364), To: rune(2364)},
+		&CodePointRange{From: rune(2369), To: rune(2376)},
+		&CodePointRange{From: rune(2381), To: rune(2381)},
+		&CodePointRange{From: rune(2385), To: rune(2391)},
+		&CodePointRange{From: rune(2402), To: rune(2403)},
+		&CodePointRange{From: rune(2433), To: rune(2433)},
+		&CodePointRange{From: rune(2492), To: rune(2492)},
+		&CodePointRange{From: rune(2494), To: rune(2494)},
+		&CodePointRange{From: rune(2497), To: rune(2500)},
+		&CodePointRange{From: rune(2509), To: rune(2509)},
+		&CodePointRange{From: rune(2519), To: rune(2519)},
+		&CodePointRange{From: rune(2530), To: rune(2531)},
+		&CodePointRange{From: rune(2558), To: rune(2558)},
+		&CodePointRange{From: rune(2561), To: rune(2562)},
+		&CodePointRange{From: rune(2620), To: rune(2620)},
+		&CodePointRange{From: rune(2625), To: rune(2626)},
+		&CodePointRange{From: rune(2631), To: rune(2632)},
+		&CodePointRange{From: rune(2635), To: rune(2637)},
+		&CodePointRange{From: rune(2641), To: rune(2641)},
+		&CodePointRange{From: rune(2672), To: rune(2673)},
+		&CodePointRange{From: rune(2677), To: rune(2677)},
+		&CodePointRange{From: rune(2689), To: rune(2690)},
+		&CodePointRange{From: rune(2748), To: rune(2748)},
+		&CodePointRange{From: rune(2753), To: rune(2757)},
+		&CodePointRange{From: rune(2759), To: rune(2760)},
+		&CodePointRange{From: rune(2765), To: rune(2765)},
+		&CodePointRange{From: rune(2786), To: rune(2787)},
+		&CodePointRange{From: rune(2810), To: rune(2815)},
+		&CodePointRange{From: rune(2817), To: rune(2817)},
+		&CodePointRange{From: rune(2876), To: rune(2876)},
+		&CodePointRange{From: rune(2878), To: rune(2879)},
+		&CodePointRange{From: rune(2881), To: rune(2884)},
+		&CodePointRange{From: rune(2893), To: rune(2893)},
+		&CodePointRange{From: rune(2901), To: rune(2903)},
+		&CodePointRange{From: rune(2914), To: rune(2915)},
+		&CodePointRange{From: rune(2946), To: rune(2946)},
+		&CodePointRange{From: rune(3006), To: rune(3006)},
+		&CodePointRange{From: rune(3008), To: rune(3008)},
+		&CodePointRange{From: rune(3021), To: rune(3021)},
+		&CodePointRange{From: rune(3031), To: rune(3031)},
+		&CodePointRange{From: rune(3072), To: rune(3072)},
+		&CodePointRange{From: rune(3076), To: rune(3076)},
+		&CodePointRange{From: rune(3134), To: rune(3136)},
+		&CodePointRange{From: rune(3142), To: rune(3144)},
+		&CodePointRange{From: rune(3146), To: rune(3149)},
+		&CodePointRange{From: rune(3157), To: rune(3158)},
+		&CodePointRange{From: rune(3170), To: rune(3171)},
+		&CodePointRange{From: rune(3201), To: rune(3201)},
+		&CodePointRange{From: rune(3260), To: rune(3260)},
+		&CodePointRange{From: rune(3263), To: rune(3263)},
+		&CodePointRange{From: rune(3266), To: rune(3266)},
+		&CodePointRange{From: rune(3270), To: rune(3270)},
+		&CodePointRange{From: rune(3276), To: rune(3277)},
+		&CodePointRange{From: rune(3285), To: rune(3286)},
+		&CodePointRange{From: rune(3298), To: rune(3299)},
+		&CodePointRange{From: rune(3328), To: rune(3329)},
+		&CodePointRange{From: rune(3387), To: rune(3388)},
+		&CodePointRange{From: rune(3390), To: rune(3390)},
+		&CodePointRange{From: rune(3393), To: rune(3396)},
+		&CodePointRange{From: rune(3405), To: rune(3405)},
+		&CodePointRange{From: rune(3415), To: rune(3415)},
+		&CodePointRange{From: rune(3426), To: rune(3427)},
+		&CodePointRange{From: rune(3457), To: rune(3457)},
+		&CodePointRange{From: rune(3530), To: rune(3530)},
+		&CodePointRange{From: rune(3535), To: rune(3535)},
+		&CodePointRange{From: rune(3538), To: rune(3540)},
+		&CodePointRange{From: rune(3542), To: rune(3542)},
+		&CodePointRange{From: rune(3551), To: rune(3551)},
+		&CodePointRange{From: rune(3633), To: rune(3633)},
+		&CodePointRange{From: rune(3636), To: rune(3642)},
+		&CodePointRange{From: rune(3655), To: rune(3662)},
+		&CodePointRange{From: rune(3761), To: rune(3761)},
+		&CodePointRange{From: rune(3764), To: rune(3772)},
+		&CodePointRange{From: rune(3784), To: rune(3789)},
+		&CodePointRange{From: rune(3864), To: rune(3865)},
+		&CodePointRange{From: rune(3893), To: rune(3893)},
+		&CodePointRange{From: rune(3895), To: rune(3895)},
+		&CodePointRange{From: rune(3897), To: rune(3897)},
+		&CodePointRange{From: rune(3953), To: rune(3966)},
+		&CodePointRange{From: rune(3968), To: rune(3972)},
+		&CodePointRange{From: rune(3974), To: rune(3975)},
+		&CodePointRange{From: rune(3981), To: rune(3991)},
+		&CodePointRange{From: rune(3993), To: rune(4028)},
+		&CodePointRange{From: rune(4038), To: rune(4038)},
+		&CodePointRange{From: rune(4141), To: rune(4144)},
+		&CodePointRange{From: rune(4146), To: rune(4151)},
+		&CodePointRange{From: rune(4153), To: rune(4154)},
+		&CodePointRange{From: rune(4157), To: rune(4158)},
+		&CodePointRange{From: rune(4184), To: rune(4185)},
+		&CodePointRange{From: rune(4190), To: rune(4192)},
+		&CodePointRange{From: rune(4209), To: rune(4212)},
+		&CodePointRange{From: rune(4226), To: rune(4226)},
+		&CodePointRange{From: rune(4229), To: rune(4230)},
+		&CodePointRange{From: rune(4237), To: rune(4237)},
+		&CodePointRange{From: rune(4253), To: rune(4253)},
+		&CodePointRange{From: rune(4957), To: rune(4959)},
+		&CodePointRange{From: rune(5906), To: rune(5908)},
+		&CodePointRange{From: rune(5938), To: rune(5939)},
+		&CodePointRange{From: rune(5970), To: rune(5971)},
+		&CodePointRange{From: rune(6002), To: rune(6003)},
+		&CodePointRange{From: rune(6068), To: rune(6069)},
+		&CodePointRange{From: rune(6071), To: rune(6077)},
+		&CodePointRange{From: rune(6086), To: rune(6086)},
+		&CodePointRange{From: rune(6089), To: rune(6099)},
+		&CodePointRange{From: rune(6109), To: rune(6109)},
+		&CodePointRange{From: rune(6155), To: rune(6157)},
+		&CodePointRange{From: rune(6277), To: rune(6278)},
+		&CodePointRange{From: rune(6313), To: rune(6313)},
+		&CodePointRange{From: rune(6432), To: rune(6434)},
+		&CodePointRange{From: rune(6439), To: rune(6440)},
+		&CodePointRange{From: rune(6450), To: rune(6450)},
+		&CodePointRange{From: rune(6457), To: rune(6459)},
+		&CodePointRange{From: rune(6679), To: rune(6680)},
+		&CodePointRange{From: rune(6683), To: rune(6683)},
+		&CodePointRange{From: rune(6742), To: rune(6742)},
+		&CodePointRange{From: rune(6744), To: rune(6750)},
+		&CodePointRange{From: rune(6752), To: rune(6752)},
+		&CodePointRange{From: rune(6754), To: rune(6754)},
+		&CodePointRange{From: rune(6757), To: rune(6764)},
+		&CodePointRange{From: rune(6771), To: rune(6780)},
+		&CodePointRange{From: rune(6783), To: rune(6783)},
+		&CodePointRange{From: rune(6832), To: rune(6848)},
+		&CodePointRange{From: rune(6912), To: rune(6915)},
+		&CodePointRange{From: rune(6964), To: rune(6970)},
+		&CodePointRange{From: rune(6972), To: rune(6972)},
+		&CodePointRange{From: rune(6978), To: rune(6978)},
+		&CodePointRange{From: rune(7019), To: rune(7027)},
+		&CodePointRange{From: rune(7040), To: rune(7041)},
+		&CodePointRange{From: rune(7074), To: rune(7077)},
+		&CodePointRange{From: rune(7080), To: rune(7081)},
+		&CodePointRange{From: rune(7083), To: rune(7085)},
+		&CodePointRange{From: rune(7142), To: rune(7142)},
+		&CodePointRange{From: rune(7144), To: rune(7145)},
+		&CodePointRange{From: rune(7149), To: rune(7149)},
+		&CodePointRange{From: rune(7151), To: rune(7153)},
+		&CodePointRange{From: rune(7212), To: rune(7219)},
+		&CodePointRange{From: rune(7222), To: rune(7223)},
+		&CodePointRange{From: rune(7376), To: rune(7378)},
+		&CodePointRange{From: rune(7380), To: rune(7392)},
+		&CodePointRange{From: rune(7394), To: rune(7400)},
+		&CodePointRange{From: rune(7405), To: rune(7405)},
+		&CodePointRange{From: rune(7412), To: rune(7412)},
+		&CodePointRange{From: rune(7416), To: rune(7417)},
+		&CodePointRange{From: rune(7616), To: rune(7673)},
+		&CodePointRange{From: rune(7675), To: rune(7679)},
+		&CodePointRange{From: rune(8204), To: rune(8204)},
+		&CodePointRange{From: rune(8400), To: rune(8432)},
+		&CodePointRange{From: rune(11503), To: rune(11505)},
+		&CodePointRange{From: rune(11647), To: rune(11647)},
+		&CodePointRange{From: rune(11744), To: rune(11775)},
+		&CodePointRange{From: rune(12330), To: rune(12335)},
+		&CodePointRange{From: rune(12441), To: rune(12442)},
+		&CodePointRange{From: rune(42607), To: rune(42610)},
+		&CodePointRange{From: rune(42612), To: rune(42621)},
+		&CodePointRange{From: rune(42654), To: rune(42655)},
+		&CodePointRange{From: rune(42736), To: rune(42737)},
+		&CodePointRange{From: rune(43010), To: rune(43010)},
+		&CodePointRange{From: rune(43014), To: rune(43014)},
+		&CodePointRange{From: rune(43019), To: rune(43019)},
+		&CodePointRange{From: rune(43045), To: rune(43046)},
+		&CodePointRange{From: rune(43052), To: rune(43052)},
+		&CodePointRange{From: rune(43204), To: rune(43205)},
+		&CodePointRange{From: rune(43232), To: rune(43249)},
+		&CodePointRange{From: rune(43263), To: rune(43263)},
+		&CodePointRange{From: rune(43302), To: rune(43309)},
+		&CodePointRange{From: rune(43335), To: rune(43345)},
+		&CodePointRange{From: rune(43392), To: rune(43394)},
+		&CodePointRange{From: rune(43443), To: rune(43443)},
+		&CodePointRange{From: rune(43446), To: rune(43449)},
+		&CodePointRange{From: rune(43452), To: rune(43453)},
+		&CodePointRange{From: rune(43493), To: rune(43493)},
+		&CodePointRange{From: rune(43561), To: rune(43566)},
+		&CodePointRange{From: rune(43569), To: rune(43570)},
+		&CodePointRange{From: rune(43573), To: rune(43574)},
+		&CodePointRange{From: rune(43587), To: rune(43587)},
+		&CodePointRange{From: rune(43596), To: rune(43596)},
+		&CodePointRange{From: rune(43644), To: rune(43644)},
+		&CodePointRange{From: rune(43696), To: rune(43696)},
+		&CodePointRange{From: rune(43698), To: rune(43700)},
+		&CodePointRange{From: rune(43703), To: rune(43704)},
+		&CodePointRange{From: rune(43710), To: rune(43711)},
+		&CodePointRange{From: rune(43713), To: rune(43713)},
+		&CodePointRange{From: rune(43756), To: rune(43757)},
+		&CodePointRange{From: rune(43766), To: rune(43766)},
+		&CodePointRange{From: rune(44005), To: rune(44005)},
+		&CodePointRange{From: rune(44008), To: rune(44008)},
+		&CodePointRange{From: rune(44013), To: rune(44013)},
+		&CodePointRange{From: rune(64286), To: rune(64286)},
+		&CodePointRange{From: rune(65024), To: rune(65039)},
+		&CodePointRange{From: rune(65056), To: rune(65071)},
+		&CodePointRange{From: rune(65438), To: rune(65439)},
+		&CodePointRange{From: rune(66045), To: rune(66045)},
+		&CodePointRange{From: rune(66272), To: rune(66272)},
+		&CodePointRange{From: rune(66422), To: rune(66426)},
+		&CodePointRange{From: rune(68097), To: rune(68099)},
+		&CodePointRange{From: rune(68101), To: rune(68102)},
+		&CodePointRange{From: rune(68108), To: rune(68111)},
+		&CodePointRange{From: rune(68152), To: rune(68154)},
+		&CodePointRange{From: rune(68159), To: rune(68159)},
+		&CodePointRange{From: rune(68325), To: rune(68326)},
+		&CodePointRange{From: rune(68900), To: rune(68903)},
+		&CodePointRange{From: rune(69291), To: rune(69292)},
+		&CodePointRange{From: rune(69446), To: rune(69456)},
+		&CodePointRange{From: rune(69633), To: rune(69633)},
+		&CodePointRange{From: rune(69688), To: rune(69702)},
+		&CodePointRange{From: rune(69759), To: rune(69761)},
+		&CodePointRange{From: rune(69811), To: rune(69814)},
+		&CodePointRange{From: rune(69817), To: rune(69818)},
+		&CodePointRange{From: rune(69888), To: rune(69890)},
+		&CodePointRange{From: rune(69927), To: rune(69931)},
+		&CodePointRange{From: rune(69933), To: rune(69940)},
+		&CodePointRange{From: rune(70003), To: rune(70003)},
+		&CodePointRange{From: rune(70016), To: rune(70017)},
+		&CodePointRange{From: rune(70070), To: rune(70078)},
+		&CodePointRange{From: rune(70089), To: rune(70092)},
+		&CodePointRange{From: rune(70095), To: rune(70095)},
+		&CodePointRange{From: rune(70191), To: rune(70193)},
+		&CodePointRange{From: rune(70196), To: rune(70196)},
+		&CodePointRange{From: rune(70198), To: rune(70199)},
+		&CodePointRange{From: rune(70206), To: rune(70206)},
+		&CodePointRange{From: rune(70367), To: rune(70367)},
+		&CodePointRange{From: rune(70371), To: rune(70378)},
+		&CodePointRange{From: rune(70400), To: rune(70401)},
+		&CodePointRange{From: rune(70459), To: rune(70460)},
+		&CodePointRange{From: rune(70462), To: rune(70462)},
+		&CodePointRange{From: rune(70464), To: rune(70464)},
+		&CodePointRange{From: rune(70487), To: rune(70487)},
+		&CodePointRange{From: rune(70502), To: rune(70508)},
+		&CodePointRange{From: rune(70512), To: rune(70516)},
+		&CodePointRange{From: rune(70712), To: rune(70719)},
+		&CodePointRange{From: rune(70722), To: rune(70724)},
+		&CodePointRange{From: rune(70726), To: rune(70726)},
+		&CodePointRange{From: rune(70750), To: rune(70750)},
+		&CodePointRange{From: rune(70832), To: rune(70832)},
+		&CodePointRange{From: rune(70835), To: rune(70840)},
+		&CodePointRange{From: rune(70842), To: rune(70842)},
+		&CodePointRange{From: rune(70845), To: rune(70845)},
+		&CodePointRange{From: rune(70847), To: rune(70848)},
+		&CodePointRange{From: rune(70850), To: rune(70851)},
+		&CodePointRange{From: rune(71087), To: rune(71087)},
+		&CodePointRange{From: rune(71090), To: rune(71093)},
+		&CodePointRange{From: rune(71100), To: rune(71101)},
+		&CodePointRange{From: rune(71103), To: rune(71104)},
+		&CodePointRange{From: rune(71132), To: rune(71133)},
+		&CodePointRange{From: rune(71219), To: rune(71226)},
+		&CodePointRange{From: rune(71229), To: rune(71229)},
+		&CodePointRange{From: rune(71231), To: rune(71232)},
+		&CodePointRange{From: rune(71339), To: rune(71339)},
+		&CodePointRange{From: rune(71341), To: rune(71341)},
+		&CodePointRange{From: rune(71344), To: rune(71349)},
+		&CodePointRange{From: rune(71351), To: rune(71351)},
+		&CodePointRange{From: rune(71453), To: rune(71455)},
+		&CodePointRange{From: rune(71458), To: rune(71461)},
+		&CodePointRange{From: rune(71463), To: rune(71467)},
+		&CodePointRange{From: rune(71727), To: rune(71735)},
+		&CodePointRange{From: rune(71737), To: rune(71738)},
+		&CodePointRange{From: rune(71984), To: rune(71984)},
+		&CodePointRange{From: rune(71995), To: rune(71996)},
+		&CodePointRange{From: rune(71998), To: rune(71998)},
+		&CodePointRange{From: rune(72003), To: rune(72003)},
+		&CodePointRange{From: rune(72148), To: rune(72151)},
+		&CodePointRange{From: rune(72154), To: rune(72155)},
+		&CodePointRange{From: rune(72160), To: rune(72160)},
+		&CodePointRange{From: rune(72193), To: rune(72202)},
+		&CodePointRange{From: rune(72243), To: rune(72248)},
+		&CodePointRange{From: rune(72251), To: rune(72254)},
+		&CodePointRange{From: rune(72263), To: rune(72263)},
+		&CodePointRange{From: rune(72273), To: rune(72278)},
+		&CodePointRange{From: rune(72281), To: rune(72283)},
+		&CodePointRange{From: rune(72330), To: rune(72342)},
+		&CodePointRange{From: rune(72344), To: rune(72345)},
+		&CodePointRange{From: rune(72752), To: rune(72758)},
+		&CodePointRange{From: rune(72760), To: rune(72765)},
+		&CodePointRange{From: rune(72767), To: rune(72767)},
+		&CodePointRange{From: rune(72850), To: rune(72871)},
+		&CodePointRange{From: rune(72874), To: rune(72880)},
+		&CodePointRange{From: rune(72882), To: rune(72883)},
+		&CodePointRange{From: rune(72885), To: rune(72886)},
+		&CodePointRange{From: rune(73009), To: rune(73014)},
+		&CodePointRange{From: rune(73018), To: rune(73018)},
+		&CodePointRange{From: rune(73020), To: rune(73021)},
+		&CodePointRange{From: rune(73023), To: rune(73029)},
+		&CodePointRange{From: rune(73031), To: rune(73031)},
+		&CodePointRange{From: rune(73104), To: rune(73105)},
+		&CodePointRange{From: rune(73109), To: rune(73109)},
+		&CodePointRange{From: rune(73111), To: rune(73111)},
+		&CodePointRange{From: rune(73459), To: rune(73460)},
+		&CodePointRange{From: rune(92912), To: rune(92916)},
+		&CodePointRange{From: rune(92976), To: rune(92982)},
+		&CodePointRange{From: rune(94031), To: rune(94031)},
+		&CodePointRange{From: rune(94095), To: rune(94098)},
+		&CodePointRange{From: rune(94180), To: rune(94180)},
+		&CodePointRange{From: rune(113821), To: rune(113822)},
+		&CodePointRange{From: rune(119141), To: rune(119141)},
+		&CodePointRange{From: rune(119143), To: rune(119145)},
+		&CodePointRange{From: rune(119150), To: rune(119154)},
+		&CodePointRange{From: rune(119163), To: rune(119170)},
+		&CodePointRange{From: rune(119173), To: rune(119179)},
+		&CodePointRange{From: rune(119210), To: rune(119213)},
+		&CodePointRange{From: rune(119362), To: rune(119364)},
+		&CodePointRange{From: rune(121344), To: rune(121398)},
+		&CodePointRange{From: rune(121403), To: rune(121452)},
+		&CodePointRange{From: rune(121461), To: rune(121461)},
+		&CodePointRange{From: rune(121476), To: rune(121476)},
+		&CodePointRange{From: rune(121499), To: rune(121503)},
+		&CodePointRange{From: rune(121505), To: rune(121519)},
+		&CodePointRange{From: rune(122880), To: rune(122886)},
+		&CodePointRange{From: rune(122888), To: rune(122904)},
+		&CodePointRange{From: rune(122907), To: rune(122913)},
+		&CodePointRange{From: rune(122915), To: rune(122916)},
+		&CodePointRange{From: rune(122918), To: rune(122922)},
+		&CodePointRange{From: rune(123184), To: rune(123190)},
+		&CodePointRange{From: rune(123628), To: rune(123631)},
+		&CodePointRange{From: rune(125136), To: rune(125142)},
+		&CodePointRange{From: rune(125252), To: rune(125258)},
+		&CodePointRange{From: rune(127995), To: rune(127999)},
+		&CodePointRange{From: rune(917536), To: rune(917631)},
+		&CodePointRange{From: rune(917760), To: rune(917999)},
+	},
+	"l": {
+		&CodePointRange{From: rune(4352), To: rune(4447)},
+		&CodePointRange{From: rune(43360), To: rune(43388)},
+	},
+	"lf": {
+		&CodePointRange{From: rune(10), To: rune(10)},
+	},
+	"lv": {
+		&CodePointRange{From: rune(44032), To: rune(44032)},
+		&CodePointRange{From: rune(44060), To: rune(44060)},
+		&CodePointRange{From: rune(44088), To: rune(44088)},
+		&CodePointRange{From: rune(44116), To: rune(44116)},
+		&CodePointRange{From: rune(44144), To: rune(44144)},
+		&CodePointRange{From: rune(44172), To: rune(44172)},
+		&CodePointRange{From: rune(44200), To: rune(44200)},
+		&CodePointRange{From: rune(44228), To: rune(44228)},
+		&CodePointRange{From: rune(44256), To: rune(44256)},
+		&CodePointRange{From: rune(44284), To: rune(44284)},
+		&CodePointRange{From: rune(44312), To: rune(44312)},
+		&CodePointRange{From: rune(44340), To: rune(44340)},
+		&CodePointRange{From: rune(44368), To: rune(44368)},
+		&CodePointRange{From: rune(44396), To: rune(44396)},
+		&CodePointRange{From: rune(44424), To: rune(44424)},
+		&CodePointRange{From: rune(44452), To: rune(44452)},
+		&CodePointRange{From: rune(44480), To: rune(44480)},
+		&CodePointRange{From: rune(44508), To: rune(44508)},
+		&CodePointRange{From: rune(44536), To: rune(44536)},
+		&CodePointRange{From: rune(44564), To: rune(44564)},
+		&CodePointRange{From: rune(44592), To: rune(44592)},
+		&CodePointRange{From: rune(44620), To: rune(44620)},
+		&CodePointRange{From: rune(44648), To: rune(44648)},
+		&CodePointRange{From: rune(44676), To: rune(44676)},
+		&CodePointRange{From: rune(44704), To: rune(44704)},
+		&CodePointRange{From: rune(44732), To: rune(44732)},
+		&CodePointRange{From: rune(44760), To: rune(44760)},
+		&CodePointRange{From: rune(44788), To: rune(44788)},
+		&CodePointRange{From: rune(44816), To: rune(44816)},
+		&CodePointRange{From: rune(44844), To: rune(44844)},
+		&CodePointRange{From: rune(44872), To: rune(44872)},
+		&CodePointRange{From: rune(44900), To: rune(44900)},
+		&CodePointRange{From: rune(44928), To: rune(44928)},
+		&CodePointRange{From: rune(44956), To: rune(44956)},
+		&CodePointRange{From: rune(44984), To: rune(44984)},
+		&CodePointRange{From: rune(45012), To: rune(45012)},
+		&CodePointRange{From: rune(45040), To: rune(45040)},
+		&CodePointRange{From: rune(45068), To: rune(45068)},
+		&CodePointRange{From: rune(45096), To: rune(45096)},
+		&CodePointRange{From: rune(45124), To: rune(45124)},
+		&CodePointRange{From: rune(45152), To: rune(45152)},
+		&CodePointRange{From: rune(45180), To: rune(45180)},
+		&CodePointRange{From: rune(45208), To: rune(45208)},
+		&CodePointRange{From: rune(45236), To: rune(45236)},
+		&CodePointRange{From: rune(45264), To: rune(45264)},
+		&CodePointRange{From: rune(45292), To: rune(45292)},
+		&CodePointRange{From: rune(45320), To: rune(45320)},
+		&CodePointRange{From: rune(45348), To: rune(45348)},
+		&CodePointRange{From: rune(45376), To: rune(45376)},
+		&CodePointRange{From: rune(45404), To: rune(45404)},
+		&CodePointRange{From: rune(45432), To: rune(45432)},
+		&CodePointRange{From: rune(45460), To: rune(45460)},
+		&CodePointRange{From: rune(45488), To: rune(45488)},
+		&CodePointRange{From: rune(45516), To: rune(45516)},
+		&CodePointRange{From: rune(45544), To: rune(45544)},
+		&CodePointRange{From: rune(45572), To: rune(45572)},
+		&CodePointRange{From: rune(45600), To: rune(45600)},
+		&CodePointRange{From: rune(45628), To: rune(45628)},
+		&CodePointRange{From: rune(45656), To: rune(45656)},
+		&CodePointRange{From: rune(45684), To: rune(45684)},
+		&CodePointRange{From: rune(45712), To: rune(45712)},
+		&CodePointRange{From: rune(45740), To: rune(45740)},
+		&CodePointRange{From: rune(45768), To: rune(45768)},
+		&CodePointRange{From: rune(45796), To: rune(45796)},
+		&CodePointRange{From: rune(45824), To: rune(45824)},
+		&CodePointRange{From: rune(45852), To: rune(45852)},
+		&CodePointRange{From: rune(45880), To: rune(45880)},
+		&CodePointRange{From: rune(45908), To: rune(45908)},
+		&CodePointRange{From: rune(45936), To: rune(45936)},
+		&CodePointRange{From: rune(45964), To: rune(45964)},
+		&CodePointRange{From: rune(45992), To: rune(45992)},
+		&CodePointRange{From: rune(46020), To: rune(46020)},
+		&CodePointRange{From: rune(46048), To: rune(46048)},
+		&CodePointRange{From: rune(46076), To: rune(46076)},
+		&CodePointRange{From: rune(46104), To: rune(46104)},
+		&CodePointRange{From: rune(46132), To: rune(46132)},
+		&CodePointRange{From: rune(46160), To: rune(46160)},
+		&CodePointRange{From: rune(46188), To: rune(46188)},
+		&CodePointRange{From: rune(46216), To: rune(46216)},
+		&CodePointRange{From: rune(46244), To: rune(46244)},
+		&CodePointRange{From: rune(46272), To: rune(46272)},
+		&CodePointRange{From: rune(46300), To: rune(46300)},
+		&CodePointRange{From: rune(46328), To: rune(46328)},
+		&CodePointRange{From: rune(46356), To: rune(46356)},
+		&CodePointRange{From: rune(46384), To: rune(46384)},
+		&CodePointRange{From: rune(46412), To: rune(46412)},
+		&CodePointRange{From: rune(46440), To: rune(46440)},
+		&CodePointRange{From: rune(46468), To: rune(46468)},
+		&CodePointRange{From: rune(46496), To: rune(46496)},
+		&CodePointRange{From: rune(46524), To: rune(46524)},
+		&CodePointRange{From: rune(46552), To: rune(46552)},
+		&CodePointRange{From: rune(46580), To: rune(46580)},
+		&CodePointRange{From: rune(46608), To: rune(46608)},
+		&CodePointRange{From: rune(46636), To: rune(46636)},
+		&CodePointRange{From: rune(46664), To: rune(46664)},
+		&CodePointRange{From: rune(46692), To: rune(46692)},
+		&CodePointRange{From: rune(46720), To: rune(46720)},
+		&CodePointRange{From: rune(46748), To: rune(46748)},
+		&CodePointRange{From: rune(46776), To: rune(46776)},
+		&CodePointRange{From: rune(46804), To: rune(46804)},
+		&CodePointRange{From: rune(46832), To: rune(46832)},
+		&CodePointRange{From: rune(46860), To: rune(46860)},
+		&CodePointRange{From: rune(46888), To: rune(46888)},
+		&CodePointRange{From: rune(46916), To: rune(46916)},
+		&CodePointRange{From: rune(46944), To: rune(46944)},
+		&CodePointRange{From: rune(46972), To: rune(46972)},
+		&CodePointRange{From: rune(47000), To: rune(47000)},
+		&CodePointRange{From: rune(47028), To: rune(47028)},
+		&CodePointRange{From: rune(47056), To: rune(47056)},
+		&CodePointRange{From: rune(47084), To: rune(47084)},
+		&CodePointRange{From: rune(47112), To: rune(47112)},
+		&CodePointRange{From: rune(47140), To: rune(47140)},
+		&CodePointRange{From: rune(47168), To: rune(47168)},
+		&CodePointRange{From: rune(47196), To: rune(47196)},
+		&CodePointRange{From: rune(47224), To: rune(47224)},
+		&CodePointRange{From: rune(47252), To: rune(47252)},
+		&CodePointRange{From: rune(47280), To: rune(47280)},
+		&CodePointRange{From: rune(47308), To: rune(47308)},
+		&CodePointRange{From: rune(47336), To: rune(47336)},
+		&CodePointRange{From: rune(47364), To: rune(47364)},
+		&CodePointRange{From: rune(47392), To: rune(47392)},
+		&CodePointRange{From: rune(47420), To: rune(47420)},
+		&CodePointRange{From: rune(47448), To: rune(47448)},
+		&CodePointRange{From: rune(47476), To: rune(47476)},
+		&CodePointRange{From: rune(47504), To: rune(47504)},
+		&CodePointRange{From: rune(47532), To: rune(47532)},
+		&CodePointRange{From: rune(47560), To: rune(47560)},
+		&CodePointRange{From: rune(47588), To: rune(47588)},
+		&CodePointRange{From: rune(47616), To: rune(47616)},
+		&CodePointRange{From: rune(47644), To: rune(47644)},
+		&CodePointRange{From: rune(47672), To: rune(47672)},
+		&CodePointRange{From: rune(47700), To: rune(47700)},
+		&CodePointRange{From: rune(47728), To: rune(47728)},
+		&CodePointRange{From: rune(47756), To: rune(47756)},
+		&CodePointRange{From: rune(47784), To: rune(47784)},
+		&CodePointRange{From: rune(47812), To: rune(47812)},
+		&CodePointRange{From: rune(47840), To: rune(47840)},
+		&CodePointRange{From: rune(47868), To: rune(47868)},
+		&CodePointRange{From: rune(47896), To: rune(47896)},
+		&CodePointRange{From: rune(47924), To: rune(47924)},
+		&CodePointRange{From: rune(47952), To: rune(47952)},
+		&CodePointRange{From: rune(47980), To: rune(47980)},
+		&CodePointRange{From: rune(48008), To: rune(48008)},
+		&CodePointRange{From: rune(48036), To: rune(48036)},
+		&CodePointRange{From: rune(48064), To: rune(48064)},
+		&CodePointRange{From: rune(48092), To: rune(48092)},
+		&CodePointRange{From: rune(48120), To: rune(48120)},
+		&CodePointRange{From: rune(48148), To: rune(48148)},
+		&CodePointRange{From: rune(48176), To: rune(48176)},
+		&CodePointRange{From: rune(48204), To: rune(48204)},
+		&CodePointRange{From: rune(48232), To: rune(48232)},
+		&CodePointRange{From: rune(48260), To: rune(48260)},
+		&CodePointRange{From: rune(48288), To: rune(48288)},
+		&CodePointRange{From: rune(48316), To: rune(48316)},
+		&CodePointRange{From: rune(48344), To: rune(48344)},
+		&CodePointRange{From: rune(48372), To: rune(48372)},
+		&CodePointRange{From: rune(48400), To: rune(48400)},
+		&CodePointRange{From: rune(48428), To: rune(48428)},
+		&CodePointRange{From: rune(48456), To: rune(48456)},
+		&CodePointRange{From: rune(48484), To: rune(48484)},
+		&CodePointRange{From: rune(48512), To: rune(48512)},
+		&CodePointRange{From: rune(48540), To: rune(48540)},
+		&CodePointRange{From: rune(48568), To: rune(48568)},
+		&CodePointRange{From: rune(48596), To: rune(48596)},
+		&CodePointRange{From: rune(48624), To: rune(48624)},
+		&CodePointRange{From: rune(48652), To: rune(48652)},
+		&CodePointRange{From: rune(48680), To: rune(48680)},
+		&CodePointRange{From: rune(48708), To: rune(48708)},
+		&CodePointRange{From: rune(48736), To: rune(48736)},
+		&CodePointRange{From: rune(48764), To: rune(48764)},
+		&CodePointRange{From: rune(48792), To: rune(48792)},
+		&CodePointRange{From: rune(48820), To: rune(48820)},
+		&CodePointRange{From: rune(48848), To: rune(48848)},
+		&CodePointRange{From: rune(48876), To: rune(48876)},
+		&CodePointRange{From: rune(48904), To: rune(48904)},
+		&CodePointRange{From: rune(48932), To: rune(48932)},
+		&CodePointRange{From: rune(48960), To: rune(48960)},
+		&CodePointRange{From: rune(48988), To: rune(48988)},
+		&CodePointRange{From: rune(49016), To: rune(49016)},
+		&CodePointRange{From: rune(49044), To: rune(49044)},
+		&CodePointRange{From: rune(49072), To: rune(49072)},
+		&CodePointRange{From: rune(49100), To: rune(49100)},
+		&CodePointRange{From: rune(49128), To: rune(49128)},
+		&CodePointRange{From: rune(49156), To: rune(49156)},
+		&CodePointRange{From: rune(49184), To: rune(49184)},
+		&CodePointRange{From: rune(49212), To: rune(49212)},
+		&CodePointRange{From: rune(49240), To: rune(49240)},
+		&CodePointRange{From: rune(49268), To: rune(49268)},
+		&CodePointRange{From: rune(49296), To: rune(49296)},
+		&CodePointRange{From: rune(49324), To: rune(49324)},
+		&CodePointRange{From: rune(49352), To: rune(49352)},
+		&CodePointRange{From: rune(49380), To: rune(49380)},
+		&CodePointRange{From: rune(49408), To: rune(49408)},
+		&CodePointRange{From: rune(49436), To: rune(49436)},
+		&CodePointRange{From: rune(49464), To: rune(49464)},
+		&CodePointRange{From: rune(49492), To: rune(49492)},
+		&CodePointRange{From: rune(49520), To: rune(49520)},
+		&CodePointRange{From: rune(49548), To: rune(49548)},
+		&CodePointRange{From: rune(49576), To: rune(49576)},
+		&CodePointRange{From: rune(49604), To: rune(49604)},
+		&CodePointRange{From: rune(49632), To: rune(49632)},
+		&CodePointRange{From: rune(49660), To: rune(49660)},
+		&CodePointRange{From: rune(49688), To: rune(49688)},
+		&CodePointRange{From: rune(49716), To: rune(49716)},
+		&CodePointRange{From: rune(49744), To: rune(49744)},
+		&CodePointRange{From: rune(49772), To: rune(49772)},
+		&CodePointRange{From: rune(49800), To: rune(49800)},
+		&CodePointRange{From: rune(49828), To: rune(49828)},
+		&CodePointRange{From: rune(49856), To: rune(49856)},
+		&CodePointRange{From: rune(49884), To: rune(49884)},
+		&CodePointRange{From: rune(49912), To: rune(49912)},
+		&CodePointRange{From: rune(49940), To: rune(49940)},
+		&CodePointRange{From: rune(49968), To: rune(49968)},
+		&CodePointRange{From: rune(49996), To: rune(49996)},
+		&CodePointRange{From: rune(50024), To: rune(50024)},
+		&CodePointRange{From: rune(50052), To: rune(50052)},
+		&CodePointRange{From: rune(50080), To: rune(50080)},
+		&CodePointRange{From: rune(50108), To: rune(50108)},
+		&CodePointRange{From: rune(50136), To: rune(50136)},
+		&CodePointRange{From: rune(50164), To: rune(50164)},
+		&CodePointRange{From: rune(50192), To: rune(50192)},
+		&CodePointRange{From: rune(50220), To: rune(50220)},
+		&CodePointRange{From: rune(50248), To: rune(50248)},
+		&CodePointRange{From: rune(50276), To: rune(50276)},
+		&CodePointRange{From: rune(50304), To: rune(50304)},
+		&CodePointRange{From: rune(50332), To: rune(50332)},
+		&CodePointRange{From: rune(50360), To: rune(50360)},
+		&CodePointRange{From: rune(50388), To: rune(50388)},
+		&CodePointRange{From: rune(50416), To: rune(50416)},
+		&CodePointRange{From: rune(50444), To: rune(50444)},
+		&CodePointRange{From: rune(50472), To: rune(50472)},
+		&CodePointRange{From: rune(50500), To: rune(50500)},
+		&CodePointRange{From: rune(50528), To: rune(50528)},
+		&CodePointRange{From: rune(50556), To: rune(50556)},
+		&CodePointRange{From: rune(50584), To: rune(50584)},
+		&CodePointRange{From: rune(50612), To: rune(50612)},
+		&CodePointRange{From: rune(50640), To: rune(50640)},
+		&CodePointRange{From: rune(50668), To: rune(50668)},
+		&CodePointRange{From: rune(50696), To: rune(50696)},
+		&CodePointRange{From: rune(50724), To: rune(50724)},
+		&CodePointRange{From: rune(50752), To: rune(50752)},
+		&CodePointRange{From: rune(50780), To: rune(50780)},
+		&CodePointRange{From: rune(50808), To: rune(50808)},
+		&CodePointRange{From: rune(50836), To: rune(50836)},
+		&CodePointRange{From: rune(50864), To: rune(50864)},
+		&CodePointRange{From: rune(50892), To: rune(50892)},
+		&CodePointRange{From: rune(50920), To: rune(50920)},
+		&CodePointRange{From: rune(50948), To: rune(50948)},
+		&CodePointRange{From: rune(50976), To: rune(50976)},
+		&CodePointRange{From: rune(51004), To: rune(51004)},
+		&CodePointRange{From: rune(51032), To: rune(51032)},
+		&CodePointRange{From: rune(51060), To: rune(51060)},
+		&CodePointRange{From: rune(51088), To: rune(51088)},
+		&CodePointRange{From: rune(51116), To: rune(51116)},
+		&CodePointRange{From: rune(51144), To: rune(51144)},
+		&CodePointRange{From: rune(51172), To: rune(51172)},
+		&CodePointRange{From: rune(51200), To: rune(51200)},
+		&CodePointRange{From: rune(51228), To: rune(51228)},
+		&CodePointRange{From: rune(51256), To: rune(51256)},
+		&CodePointRange{From: rune(51284), To: rune(51284)},
+		&CodePointRange{From: rune(51312), To: rune(51312)},
+		&CodePointRange{From: rune(51340), To: rune(51340)},
+		&CodePointRange{From: rune(51368), To: rune(51368)},
+		&CodePointRange{From: rune(51396), To: rune(51396)},
+		&CodePointRange{From: rune(51424), To: rune(51424)},
+		&CodePointRange{From: rune(51452), To: rune(51452)},
+		&CodePointRange{From: rune(51480), To: rune(51480)},
+		&CodePointRange{From: rune(51508), To: rune(51508)},
+		&CodePointRange{From: rune(51536), To: rune(51536)},
+		&CodePointRange{From: rune(51564), To: rune(51564)},
+		&CodePointRange{From: rune(51592), To: rune(51592)},
+		&CodePointRange{From: rune(51620), To: rune(51620)},
+		&CodePointRange{From: rune(51648), To: rune(51648)},
+		&CodePointRange{From: rune(51676), To: rune(51676)},
+		&CodePointRange{From: rune(51704), To: rune(51704)},
+		&CodePointRange{From: rune(51732), To: rune(51732)},
+		&CodePointRange{From: rune(51760), To: rune(51760)},
+		&CodePointRange{From: rune(51788), To: rune(51788)},
+		&CodePointRange{From: rune(51816), To: rune(51816)},
+		&CodePointRange{From: rune(51844), To: rune(51844)},
+		&CodePointRange{From: rune(51872), To: rune(51872)},
+		&CodePointRange{From: rune(51900), To: rune(51900)},
+		&CodePointRange{From: rune(51928), To: rune(51928)},
+		&CodePointRange{From: rune(51956), To: rune(51956)},
+		&CodePointRange{From: rune(51984), To: rune(51984)},
+		&CodePointRange{From: rune(52012), To: rune(52012)},
+		&CodePointRange{From: rune(52040), To: rune(52040)},
+		&CodePointRange{From: rune(52068), To: rune(52068)},
+		&CodePointRange{From: rune(52096), To: rune(52096)},
+		&CodePointRange{From: rune(52124), To: rune(52124)},
+		&CodePointRange{From: rune(52152), To: rune(52152)},
+		&CodePointRange{From: rune(52180), To: rune(52180)},
+		&CodePointRange{From: rune(52208), To: rune(52208)},
+		&CodePointRange{From: rune(52236), To: rune(52236)},
+		&CodePointRange{From: rune(52264), To: rune(52264)},
+		&CodePointRange{From: rune(52292), To: rune(52292)},
+		&CodePointRange{From: rune(52320), To: rune(52320)},
+		&CodePointRange{From: rune(52348), To: rune(52348)},
+		&CodePointRange{From: rune(52376), To: rune(52376)},
+		&CodePointRange{From: rune(52404), To: rune(52404)},
+		&CodePointRange{From: rune(52432), To: rune(52432)},
+		&CodePointRange{From: rune(52460), To: rune(52460)},
+		&CodePointRange{From: rune(52488), To: rune(52488)},
+		&CodePointRange{From: rune(52516), To: rune(52516)},
+		&CodePointRange{From: rune(52544), To: rune(52544)},
+		&CodePointRange{From: rune(52572), To: rune(52572)},
+		&CodePointRange{From: rune(52600), To: rune(52600)},
+		&CodePointRange{From: rune(52628), To: rune(52628)},
+		&CodePointRange{From: rune(52656), To: rune(52656)},
+		&CodePointRange{From: rune(52684), To: rune(52684)},
+		&CodePointRange{From: rune(52712), To: rune(52712)},
+		&CodePointRange{From: rune(52740), To: rune(52740)},
+		&CodePointRange{From: rune(52768), To: rune(52768)},
+		&CodePointRange{From: rune(52796), To: rune(52796)},
+		&CodePointRange{From: rune(52824), To: rune(52824)},
+		&CodePointRange{From: rune(52852), To: rune(52852)},
+		&CodePointRange{From: rune(52880), To: rune(52880)},
+		&CodePointRange{From: rune(52908), To: rune(52908)},
+		&CodePointRange{From: rune(52936), To: rune(52936)},
+		&CodePointRange{From: rune(52964), To: rune(52964)},
+		&CodePointRange{From: rune(52992), To: rune(52992)},
+		&CodePointRange{From: rune(53020), To: rune(53020)},
+		&CodePointRange{From: rune(53048), To: rune(53048)},
+		&CodePointRange{From: rune(53076), To: rune(53076)},
+		&CodePointRange{From: rune(53104), To: rune(53104)},
+		&CodePointRange{From: rune(53132), To: rune(53132)},
+		&CodePointRange{From: rune(53160), To: rune(53160)},
+		&CodePointRange{From: rune(53188), To: rune(53188)},
+		&CodePointRange{From: rune(53216), To: rune(53216)},
+		&CodePointRange{From: rune(53244), To: rune(53244)},
+		&CodePointRange{From: rune(53272), To: rune(53272)},
+		&CodePointRange{From: rune(53300), To: rune(53300)},
+		&CodePointRange{From: rune(53328), To: rune(53328)},
+		&CodePointRange{From: rune(53356), To: rune(53356)},
+		&CodePointRange{From: rune(53384), To: rune(53384)},
+		&CodePointRange{From: rune(53412), To: rune(53412)},
+		&CodePointRange{From: rune(53440), To: rune(53440)},
+		&CodePointRange{From: rune(53468), To: rune(53468)},
+		&CodePointRange{From: rune(53496), To: rune(53496)},
+		&CodePointRange{From: rune(53524), To: rune(53524)},
+		&CodePointRange{From: rune(53552), To: rune(53552)},
+		&CodePointRange{From: rune(53580), To: rune(53580)},
+		&CodePointRange{From: rune(53608), To: rune(53608)},
+		&CodePointRange{From: rune(53636), To: rune(53636)},
+		&CodePointRange{From: rune(53664), To: rune(53664)},
+		&CodePointRange{From: rune(53692), To: rune(53692)},
+		&CodePointRange{From: rune(53720), To: rune(53720)},
+		&CodePointRange{From: rune(53748), To: rune(53748)},
+		&CodePointRange{From: rune(53776), To: rune(53776)},
+		&CodePointRange{From: rune(53804), To: rune(53804)},
+		&CodePointRange{From: rune(53832), To: rune(53832)},
+		&CodePointRange{From: rune(53860), To: rune(53860)},
+		&CodePointRange{From: rune(53888), To: rune(53888)},
+		&CodePointRange{From: rune(53916), To: rune(53916)},
+		&CodePointRange{From: rune(53944), To: rune(53944)},
+		&CodePointRange{From: rune(53972), To: rune(53972)},
+		&CodePointRange{From: rune(54000), To: rune(54000)},
+		&CodePointRange{From: rune(54028), To: rune(54028)},
+		&CodePointRange{From: rune(54056), To: rune(54056)},
+		&CodePointRange{From: rune(54084), To: rune(54084)},
+		&CodePointRange{From: rune(54112), To: rune(54112)},
+		&CodePointRange{From: rune(54140), To: rune(54140)},
+		&CodePointRange{From: rune(54168), To: rune(54168)},
+		&CodePointRange{From: rune(54196), To: rune(54196)},
+		&CodePointRange{From: rune(54224), To: rune(54224)},
+		&CodePointRange{From: rune(54252), To: rune(54252)},
+		&CodePointRange{From: rune(54280), To: rune(54280)},
+		&CodePointRange{From: rune(54308), To: rune(54308)},
+		&CodePointRange{From: rune(54336), To: rune(54336)},
+		&CodePointRange{From: rune(54364), To: rune(54364)},
+		&CodePointRange{From: rune(54392), To: rune(54392)},
+		&CodePointRange{From: rune(54420), To: rune(54420)},
+		&CodePointRange{From: rune(54448), To: rune(54448)},
+		&CodePointRange{From: rune(54476), To: rune(54476)},
+		&CodePointRange{From: rune(54504), To: rune(54504)},
+		&CodePointRange{From: rune(54532), To: rune(54532)},
+		&CodePointRange{From: rune(54560), To: rune(54560)},
+		&CodePointRange{From: rune(54588), To: rune(54588)},
+		&CodePointRange{From: rune(54616), To: rune(54616)},
+		&CodePointRange{From: rune(54644), To: rune(54644)},
+		&CodePointRange{From: rune(54672), To: rune(54672)},
+		&CodePointRange{From: rune(54700), To: rune(54700)},
+		&CodePointRange{From: rune(54728), To: rune(54728)},
+		&CodePointRange{From: rune(54756), To: rune(54756)},
+		&CodePointRange{From: rune(54784), To: rune(54784)},
+		&CodePointRange{From: rune(54812), To: rune(54812)},
+		&CodePointRange{From: rune(54840), To: rune(54840)},
+		&CodePointRange{From: rune(54868), To: rune(54868)},
+		&CodePointRange{From: rune(54896), To: rune(54896)},
+		&CodePointRange{From: rune(54924), To: rune(54924)},
+		&CodePointRange{From: rune(54952), To: rune(54952)},
+		&CodePointRange{From: rune(54980), To: rune(54980)},
+		&CodePointRange{From: rune(55008), To: rune(55008)},
+		&CodePointRange{From: rune(55036), To: rune(55036)},
+		&CodePointRange{From: rune(55064), To: rune(55064)},
+		&CodePointRange{From: rune(55092), To: rune(55092)},
+		&CodePointRange{From: rune(55120), To: rune(55120)},
+		&CodePointRange{From: rune(55148), To: rune(55148)},
+		&CodePointRange{From: rune(55176), To: rune(55176)},
+	},
+	"lvt": {
+		&CodePointRange{From: rune(44033), To: rune(44059)},
+		&CodePointRange{From: rune(44061), To: rune(44087)},
+		&CodePointRange{From: rune(44089), To: rune(44115)},
+		&CodePointRange{From: rune(44117), To: rune(44143)},
+		&CodePointRange{From: rune(44145), To: rune(44171)},
+		&CodePointRange{From: rune(44173), To: rune(44199)},
+		&CodePointRange{From: rune(44201), To: rune(44227)},
+		&CodePointRange{From: rune(44229), To: rune(44255)},
+		&CodePointRange{From: rune(44257), To: rune(44283)},
+		&CodePointRange{From: rune(44285), To: rune(44311)},
+		&CodePointRange{From: rune(44313), To: rune(44339)},
+		&CodePointRange{From: rune(44341), To: rune(44367)},
+		&CodePointRange{From: rune(44369), To: rune(44395)},
+		&CodePointRange{From: rune(44397), To: rune(44423)},
+		&CodePointRange{From: rune(44425), To: rune(44451)},
+		&CodePointRange{From: rune(44453), To: rune(44479)},
+		&CodePointRange{From: rune(44481), To: rune(44507)},
+		&CodePointRange{From: rune(44509), To: rune(44535)},
+		&CodePointRange{From: rune(44537), To: rune(44563)},
+		&CodePointRange{From: rune(44565), To: rune(44591)},
+		&CodePointRange{From: rune(44593), To: rune(44619)},
+		&CodePointRange{From: rune(44621), To: rune(44647)},
+		&CodePointRange{From: rune(44649), To: rune(44675)},
+		&CodePointRange{From: rune(44677), To: rune(44703)},
+		&CodePointRange{From: rune(44705), To: rune(44731)},
+		&CodePointRange{From: rune(44733), To: rune(44759)},
+		&CodePointRange{From: rune(44761), To: rune(44787)},
+		&CodePointRange{From: rune(44789), To: rune(44815)},
+		&CodePointRange{From: rune(44817), To: rune(44843)},
+		&CodePointRange{From: rune(44845), To: rune(44871)},
+		&CodePointRange{From: rune(44873), To: rune(44899)},
+		&CodePointRange{From: rune(44901), To: rune(44927)},
+		&CodePointRange{From: rune(44929), To: rune(44955)},
+		&CodePointRange{From: rune(44957), To: rune(44983)},
+		&CodePointRange{From: rune(44985), To: rune(45011)},
+		&CodePointRange{From: rune(45013), To: rune(45039)},
+		&CodePointRange{From: rune(45041), To: rune(45067)},
+		&CodePointRange{From: rune(45069), To: rune(45095)},
+		&CodePointRange{From: rune(45097), To: rune(45123)},
+		&CodePointRange{From: rune(45125), To: rune(45151)},
+		&CodePointRange{From: rune(45153), To: rune(45179)},
+		&CodePointRange{From: rune(45181), To: rune(45207)},
+		&CodePointRange{From: rune(45209), To: rune(45235)},
+		&CodePointRange{From: rune(45237), To: rune(45263)},
+		&CodePointRange{From: rune(45265), To: rune(45291)},
+		&CodePointRange{From: rune(45293), To: rune(45319)},
+		&CodePointRange{From: rune(45321), To: rune(45347)},
+		&CodePointRange{From: rune(45349), To: rune(45375)},
+		&CodePointRange{From: rune(45377), To: rune(45403)},
+		&CodePointRange{From: rune(45405), To: rune(45431)},
+		&CodePointRange{From: rune(45433), To: rune(45459)},
+		&CodePointRange{From: rune(45461), To: rune(45487)},
+		&CodePointRange{From: rune(45489), To: rune(45515)},
+		&CodePointRange{From: rune(45517), To: rune(45543)},
+		&CodePointRange{From: rune(45545), To: rune(45571)},
+		&CodePointRange{From: rune(45573), To: rune(45599)},
+		&CodePointRange{From: rune(45601), To: rune(45627)},
+		&CodePointRange{From: rune(45629), To: rune(45655)},
+		&CodePointRange{From: rune(45657), To: rune(45683)},
+		&CodePointRange{From: rune(45685), To: rune(45711)},
+		&CodePointRange{From: rune(45713), To: rune(45739)},
+		&CodePointRange{From: rune(45741), To: rune(45767)},
+		&CodePointRange{From: rune(45769), To: rune(45795)},
+		&CodePointRange{From: rune(45797), To: rune(45823)},
+		&CodePointRange{From: rune(45825), To: rune(45851)},
+		&CodePointRange{From: rune(45853), To: rune(45879)},
+		&CodePointRange{From: rune(45881), To: rune(45907)},
+		&CodePointRange{From: rune(45909), To: rune(45935)},
+		&CodePointRange{From: rune(45937), To: rune(45963)},
+		&CodePointRange{From: rune(45965), To: rune(45991)},
+		&CodePointRange{From: rune(45993), To: rune(46019)},
+		&CodePointRange{From: rune(46021), To: rune(46047)},
+		&CodePointRange{From: rune(46049), To: rune(46075)},
+		&CodePointRange{From: rune(46077), To: rune(46103)},
+		&CodePointRange{From: rune(46105), To: rune(46131)},
+		&CodePointRange{From: rune(46133), To: rune(46159)},
+		&CodePointRange{From: rune(46161), To: rune(46187)},
+		&CodePointRange{From: rune(46189), To: rune(46215)},
+		&CodePointRange{From: rune(46217), To: rune(46243)},
+		&CodePointRange{From: rune(46245), To: rune(46271)},
+		&CodePointRange{From: rune(46273), To: rune(46299)},
+		&CodePointRange{From: rune(46301), To: rune(46327)},
+		&CodePointRange{From: rune(46329), To: rune(46355)},
+		&CodePointRange{From: rune(46357), To: rune(46383)},
+		&CodePointRange{From: rune(46385), To: rune(46411)},
+		&CodePointRange{From: rune(46413), To: rune(46439)},
+		&CodePointRange{From: rune(46441), To: rune(46467)},
+		&CodePointRange{From: rune(46469), To: rune(46495)},
+		&CodePointRange{From: rune(46497), To: rune(46523)},
+		&CodePointRange{From: rune(46525), To: rune(46551)},
+		&CodePointRange{From: rune(46553), To: rune(46579)},
+		&CodePointRange{From: rune(46581), To: rune(46607)},
+		&CodePointRange{From: rune(46609), To: rune(46635)},
+		&CodePointRange{From: rune(46637), To: rune(46663)},
+		&CodePointRange{From: rune(46665), To: rune(46691)},
+		&CodePointRange{From: rune(46693), To: rune(46719)},
+		&CodePointRange{From: rune(46721), To: rune(46747)},
+		&CodePointRange{From: rune(46749), To: rune(46775)},
+		&CodePointRange{From: rune(46777), To: rune(46803)},
+		&CodePointRange{From: rune(46805), To: rune(46831)},
+		&CodePointRange{From: rune(46833), To: rune(46859)},
+		&CodePointRange{From: rune(46861), To: rune(46887)},
+		&CodePointRange{From: rune(46889), To: rune(46915)},
+		&CodePointRange{From: rune(46917), To: rune(46943)},
+		&CodePointRange{From: rune(46945), To: rune(46971)},
+		&CodePointRange{From: rune(46973), To: rune(46999)},
+		&CodePointRange{From: rune(47001), To: rune(47027)},
+		&CodePointRange{From: rune(47029), To: rune(47055)},
+		&CodePointRange{From: rune(47057), To: rune(47083)},
+		&CodePointRange{From: rune(47085), To: rune(47111)},
+		&CodePointRange{From: rune(47113), To: rune(47139)},
+		&CodePointRange{From: rune(47141), To: rune(47167)},
+		&CodePointRange{From: rune(47169), To: rune(47195)},
+		&CodePointRange{From: rune(47197), To: rune(47223)},
+		&CodePointRange{From: rune(47225), To: rune(47251)},
+		&CodePointRange{From: rune(47253), To: rune(47279)},
+		&CodePointRange{From: rune(47281), To: rune(47307)},
+		&CodePointRange{From: rune(47309), To: rune(47335)},
+		&CodePointRange{From: rune(47337), To: rune(47363)},
+		&CodePointRange{From: rune(47365), To: rune(47391)},
+		&CodePointRange{From: rune(47393), To: rune(47419)},
+		&CodePointRange{From: rune(47421), To: rune(47447)},
+		&CodePointRange{From: rune(47449), To: rune(47475)},
+		&CodePointRange{From: rune(47477), To: rune(47503)},
+		&CodePointRange{From: rune(47505), To: rune(47531)},
+		&CodePointRange{From: rune(47533), To: rune(47559)},
+		&CodePointRange{From: rune(47561), To: rune(47587)},
+		&CodePointRange{From: rune(47589), To: rune(47615)},
+		&CodePointRange{From: rune(47617), To: rune(47643)},
+		&CodePointRange{From: rune(47645), To: rune(47671)},
+		&CodePointRange{From: rune(47673), To: rune(47699)},
+		&CodePointRange{From: rune(47701), To: rune(47727)},
+		&CodePointRange{From: rune(47729), To: rune(47755)},
+		&CodePointRange{From: rune(47757), To: rune(47783)},
+		&CodePointRange{From: rune(47785), To: rune(47811)},
+		&CodePointRange{From: rune(47813), To: rune(47839)},
+		&CodePointRange{From: rune(47841), To: rune(47867)},
+		&CodePointRange{From: rune(47869), To: rune(47895)},
+		&CodePointRange{From: rune(47897), To: rune(47923)},
+		&CodePointRange{From: rune(47925), To: rune(47951)},
+		&CodePointRange{From: rune(47953), To: rune(47979)},
+		&CodePointRange{From: rune(47981), To: rune(48007)},
+		&CodePointRange{From: rune(48009), To: rune(48035)},
+		&CodePointRange{From: rune(48037), To: rune(48063)},
+		&CodePointRange{From: rune(48065), To: rune(48091)},
+		&CodePointRange{From: rune(48093), To: rune(48119)},
+		&CodePointRange{From: rune(48121), To: rune(48147)},
+		&CodePointRange{From: rune(48149), To: rune(48175)},
+		&CodePointRange{From: rune(48177), To: rune(48203)},
+		&CodePointRange{From: rune(48205), To: rune(48231)},
+		&CodePointRange{From: rune(48233), To: rune(48259)},
+		&CodePointRange{From: rune(48261), To: rune(48287)},
+		&CodePointRange{From: rune(48289), To: rune(48315)},
+		&CodePointRange{From: rune(48317), To: rune(48343)},
+		&CodePointRange{From: rune(48345), To: rune(48371)},
+		&CodePointRange{From: rune(48373), To: rune(48399)},
+		&CodePointRange{From: rune(48401), To: rune(48427)},
+		&CodePointRange{From: rune(48429), To: rune(48455)},
+		&CodePointRange{From: rune(48457), To: rune(48483)},
+		&CodePointRange{From: rune(48485), To: rune(48511)},
+		&CodePointRange{From: rune(48513), To: rune(48539)},
+		&CodePointRange{From: rune(48541), To: rune(48567)},
+		&CodePointRange{From: rune(48569), To: rune(48595)},
+		&CodePointRange{From: rune(48597), To: rune(48623)},
+		&CodePointRange{From: rune(48625), To: rune(48651)},
+		&CodePointRange{From: rune(48653), To: rune(48679)},
+		&CodePointRange{From: rune(48681), To: rune(48707)},
+		&CodePointRange{From: rune(48709), To: rune(48735)},
+		&CodePointRange{From: rune(48737), To: rune(48763)},
+		&CodePointRange{From: rune(48765), To: rune(48791)},
+		&CodePointRange{From: rune(48793), To: rune(48819)},
+		&CodePointRange{From: rune(48821), To: rune(48847)},
+		&CodePointRange{From: rune(48849), To: rune(48875)},
+		&CodePointRange{From: rune(48877), To: rune(48903)},
+		&CodePointRange{From: rune(48905), To: rune(48931)},
+		&CodePointRange{From: rune(48933), To: rune(48959)},
+		&CodePointRange{From: rune(48961), To: rune(48987)},
+		&CodePointRange{From: rune(48989), To: rune(49015)},
+		&CodePointRange{From: rune(49017), To: rune(49043)},
+		&CodePointRange{From: rune(49045), To: rune(49071)},
+		&CodePointRange{From: rune(49073), To: rune(49099)},
+		&CodePointRange{From: rune(49101), To: rune(49127)},
+		&CodePointRange{From: rune(49129), To: rune(49155)},
+		&CodePointRange{From: rune(49157), To: rune(49183)},
+		&CodePointRange{From: rune(49185), To: rune(49211)},
+		&CodePointRange{From: rune(49213), To: rune(49239)},
+		&CodePointRange{From: rune(49241), To: rune(49267)},
+		&CodePointRange{From: rune(49269), To: rune(49295)},
+		&CodePointRange{From: rune(49297), To: rune(49323)},
+		&CodePointRange{From: rune(49325), To: rune(49351)},
+		&CodePointRange{From: rune(49353), To: rune(49379)},
+		&CodePointRange{From: rune(49381), To: rune(49407)},
+		&CodePointRange{From: rune(49409), To: rune(49435)},
+		&CodePointRange{From: rune(49437), To: rune(49463)},
+		&CodePointRange{From: rune(49465), To: rune(49491)},
+		&CodePointRange{From: rune(49493), To: rune(49519)},
+		&CodePointRange{From: rune(49521), To: rune(49547)},
+		&CodePointRange{From: rune(49549), To: rune(49575)},
+		&CodePointRange{From: rune(49577), To: rune(49603)},
+		&CodePointRange{From: rune(49605), To: rune(49631)},
+		&CodePointRange{From: rune(49633), To: rune(49659)},
+		&CodePointRange{From: rune(49661), To: rune(49687)},
+		&CodePointRange{From: rune(49689), To: rune(49715)},
+		&CodePointRange{From: rune(49717), To: rune(49743)},
+		&CodePointRange{From: rune(49745), To: rune(49771)},
+		&CodePointRange{From: rune(49773), To: rune(49799)},
+		&CodePointRange{From: rune(49801), To: rune(49827)},
+		&CodePointRange{From: rune(49829), To: rune(49855)},
+		&CodePointRange{From: rune(49857), To: rune(49883)},
+		&CodePointRange{From: rune(49885), To: rune(49911)},
+		&CodePointRange{From: rune(49913), To: rune(49939)},
+		&CodePointRange{From: rune(49941), To: rune(49967)},
+		&CodePointRange{From: rune(49969), To: rune(49995)},
+		&CodePointRange{From: rune(49997), To: rune(50023)},
+		&CodePointRange{From: rune(50025), To: rune(50051)},
+		&CodePointRange{From: rune(50053), To: rune(50079)},
+		&CodePointRange{From: rune(50081), To: rune(50107)},
+		&CodePointRange{From: rune(50109), To: rune(50135)},
+		&CodePointRange{From: rune(50137), To: rune(50163)},
+		&CodePointRange{From: rune(50165), To: rune(50191)},
+		&CodePointRange{From: rune(50193), To: rune(50219)},
+		&CodePointRange{From: rune(50221), To: rune(50247)},
+		&CodePointRange{From: rune(50249), To: rune(50275)},
+		&CodePointRange{From: rune(50277), To: rune(50303)},
+		&CodePointRange{From: rune(50305), To: rune(50331)},
+		&CodePointRange{From: rune(50333), To: rune(50359)},
+		&CodePointRange{From: rune(50361), To: rune(50387)},
+		&CodePointRange{From: rune(50389), To: rune(50415)},
+		&CodePointRange{From: rune(50417), To: rune(50443)},
+		&CodePointRange{From: rune(50445), To: rune(50471)},
+		&CodePointRange{From: rune(50473), To: rune(50499)},
+		&CodePointRange{From: rune(50501), To: rune(50527)},
+		&CodePointRange{From: rune(50529), To: rune(50555)},
+		&CodePointRange{From: rune(50557), To: rune(50583)},
+		&CodePointRange{From: rune(50585), To: rune(50611)},
+		&CodePointRange{From: rune(50613), To: rune(50639)},
+		&CodePointRange{From: rune(50641), To: rune(50667)},
+		&CodePointRange{From: rune(50669), To: rune(50695)},
+		&CodePointRange{From: rune(50697), To: rune(50723)},
+		&CodePointRange{From: rune(50725), To: rune(50751)},
+		&CodePointRange{From: rune(50753), To: rune(50779)},
+		&CodePointRange{From: rune(50781), To: rune(50807)},
+		&CodePointRange{From: rune(50809), To: rune(50835)},
+		&CodePointRange{From: rune(50837), To: rune(50863)},
+		&CodePointRange{From: rune(50865), To: rune(50891)},
+		&CodePointRange{From: rune(50893), To: rune(50919)},
+		&CodePointRange{From: rune(50921), To: rune(50947)},
+		&CodePointRange{From: rune(50949), To: rune(50975)},
+		&CodePointRange{From: rune(50977), To: rune(51003)},
+		&CodePointRange{From: rune(51005), To: rune(51031)},
+		&CodePointRange{From: rune(51033), To: rune(51059)},
+		&CodePointRange{From: rune(51061), To: rune(51087)},
+		&CodePointRange{From: rune(51089), To: rune(51115)},
+		&CodePointRange{From: rune(51117), To: rune(51143)},
+		&CodePointRange{From: rune(51145), To: rune(51171)},
+		&CodePointRange{From: rune(51173), To: rune(51199)},
+		&CodePointRange{From: rune(51201), To: rune(51227)},
+		&CodePointRange{From: rune(51229), To: rune(51255)},
+		&CodePointRange{From: rune(51257), To: rune(51283)},
+		&CodePointRange{From: rune(51285), To: rune(51311)},
+		&CodePointRange{From: rune(51313), To: rune(51339)},
+		&CodePointRange{From: rune(51341), To: rune(51367)},
+		&CodePointRange{From: rune(51369), To: rune(51395)},
+		&CodePointRange{From: rune(51397), To: rune(51423)},
+		&CodePointRange{From: rune(51425), To: rune(51451)},
+		&CodePointRange{From: rune(51453), To: rune(51479)},
+		&CodePointRange{From: rune(51481), To: rune(51507)},
+		&CodePointRange{From: rune(51509), To: rune(51535)},
+		&CodePointRange{From: rune(51537), To: rune(51563)},
+		&CodePointRange{From: rune(51565), To: rune(51591)},
+		&CodePointRange{From: rune(51593), To: rune(51619)},
+		&CodePointRange{From: rune(51621), To: rune(51647)},
+		&CodePointRange{From: rune(51649), To: rune(51675)},
+		&CodePointRange{From: rune(51677), To: rune(51703)},
+		&CodePointRange{From: rune(51705), To: rune(51731)},
+		&CodePointRange{From: rune(51733), To: rune(51759)},
+		&CodePointRange{From: rune(51761), To: rune(51787)},
+		&CodePointRange{From: rune(51789), To: rune(51815)},
+		&CodePointRange{From: rune(51817), To: rune(51843)},
+		&CodePointRange{From: rune(51845), To: rune(51871)},
+		&CodePointRange{From: rune(51873), To: rune(51899)},
+		&CodePointRange{From: rune(51901), To: rune(51927)},
+		&CodePointRange{From: rune(51929), To: rune(51955)},
+		&CodePointRange{From: rune(51957), To: rune(51983)},
+		&CodePointRange{From: rune(51985), To: rune(52011)},
+		&CodePointRange{From: rune(52013), To: rune(52039)},
+		&CodePointRange{From: rune(52041), To: rune(52067)},
+		&CodePointRange{From: rune(52069), To: rune(52095)},
+		&CodePointRange{From: rune(52097), To: rune(52123)},
+		&CodePointRange{From: rune(52125), To: rune(52151)},
+		&CodePointRange{From: rune(52153), To: rune(52179)},
+		&CodePointRange{From: rune(52181), To: rune(52207)},
+		&CodePointRange{From: rune(52209), To: rune(52235)},
+		&CodePointRange{From: rune(52237), To: rune(52263)},
+		&CodePointRange{From: rune(52265), To: rune(52291)},
+		&CodePointRange{From: rune(52293), To: rune(52319)},
+		&CodePointRange{From: rune(52321), To: rune(52347)},
+		&CodePointRange{From: rune(52349), To: rune(52375)},
+		&CodePointRange{From: rune(52377), To: rune(52403)},
+		&CodePointRange{From: rune(52405), To: rune(52431)},
+		&CodePointRange{From: rune(52433), To: rune(52459)},
+		&CodePointRange{From: rune(52461), To: rune(52487)},
+		&CodePointRange{From: rune(52489), To: rune(52515)},
+		&CodePointRange{From: rune(52517), To: rune(52543)},
+		&CodePointRange{From: rune(52545), To: rune(52571)},
+		&CodePointRange{From: rune(52573), To: rune(52599)},
+		&CodePointRange{From: rune(52601), To: rune(52627)},
+		&CodePointRange{From: rune(52629), To: rune(52655)},
+		&CodePointRange{From: rune(52657), To: rune(52683)},
+		&CodePointRange{From: rune(52685), To: rune(52711)},
+		&CodePointRange{From: rune(52713), To: rune(52739)},
+		&CodePointRange{From: rune(52741), To: rune(52767)},
+		&CodePointRange{From: rune(52769), To: rune(52795)},
+		&CodePointRange{From: rune(52797), To: rune(52823)},
+		&CodePointRange{From: rune(52825), To: rune(52851)},
+		&CodePointRange{From: rune(52853), To: rune(52879)},
+		&CodePointRange{From: rune(52881), To: rune(52907)},
+		&CodePointRange{From: rune(52909), To: rune(52935)},
+		&CodePointRange{From: rune(52937), To: rune(52963)},
+		&CodePointRange{From: rune(52965), To: rune(52991)},
+		&CodePointRange{From: rune(52993), To: rune(53019)},
+		&CodePointRange{From: rune(53021), To: rune(53047)},
+		&CodePointRange{From: rune(53049), To: rune(53075)},
+		&CodePointRange{From: rune(53077), To: rune(53103)},
+		&CodePointRange{From: rune(53105), To: rune(53131)},
+		&CodePointRange{From: rune(53133), To: rune(53159)},
+		&CodePointRange{From: rune(53161), To: rune(53187)},
+		&CodePointRange{From: rune(53189), To: rune(53215)},
+		&CodePointRange{From: rune(53217), To: rune(53243)},
+		&CodePointRange{From: rune(53245), To: rune(53271)},
+		&CodePointRange{From: rune(53273), To: rune(53299)},
+		&CodePointRange{From: rune(53301), To: rune(53327)},
+		&CodePointRange{From: rune(53329), To: rune(53355)},
+		&CodePointRange{From: rune(53357), To: rune(53383)},
+		&CodePointRange{From: rune(53385), To: rune(53411)},
+		&CodePointRange{From: rune(53413), To: rune(53439)},
+		&CodePointRange{From: rune(53441), To: rune(53467)},
+		&CodePointRange{From: rune(53469), To: rune(53495)},
+		&CodePointRange{From: rune(53497), To: rune(53523)},
+		&CodePointRange{From: rune(53525), To: rune(53551)},
+		&CodePointRange{From: rune(53553), To: rune(53579)},
+		&CodePointRange{From: rune(53581), To: rune(53607)},
+		&CodePointRange{From: rune(53609), To: rune(53635)},
+		&CodePointRange{From: rune(53637), To: rune(53663)},
+		&CodePointRange{From: rune(53665), To: rune(53691)},
+		&CodePointRange{From: rune(53693), To: rune(53719)},
+		&CodePointRange{From: rune(53721), To: rune(53747)},
+		&CodePointRange{From: rune(53749), To: rune(53775)},
+		&CodePointRange{From: rune(53777), To: rune(53803)},
+		&CodePointRange{From: rune(53805), To: rune(53831)},
+		&CodePointRange{From: rune(53833), To: rune(53859)},
+		&CodePointRange{From: rune(53861), To: rune(53887)},
+		&CodePointRange{From: rune(53889), To: rune(53915)},
+		&CodePointRange{From: rune(53917), To: rune(53943)},
+		&CodePointRange{From: rune(53945), To: rune(53971)},
+		&CodePointRange{From: rune(53973), To: rune(53999)},
+		&CodePointRange{From: rune(54001), To: rune(54027)},
+		&CodePointRange{From: rune(54029), To: rune(54055)},
+		&CodePointRange{From: rune(54057), To: rune(54083)},
+		&CodePointRange{From: rune(54085), To: rune(54111)},
+		&CodePointRange{From: rune(54113), To: rune(54139)},
+		&CodePointRange{From: rune(54141), To: rune(54167)},
+		&CodePointRange{From: rune(54169), To: rune(54195)},
+		&CodePointRange{From: rune(54197), To: rune(54223)},
+		&CodePointRange{From: rune(54225), To: rune(54251)},
+		&CodePointRange{From: rune(54253), To: rune(54279)},
+		&CodePointRange{From: rune(54281), To: rune(54307)},
+		&CodePointRange{From: rune(54309), To: rune(54335)},
+		&CodePointRange{From: rune(54337), To: rune(54363)},
+		&CodePointRange{From: rune(54365), To: rune(54391)},
+		&CodePointRange{From: rune(54393), To: rune(54419)},
+		&CodePointRange{From: rune(54421), To: rune(54447)},
+		&CodePointRange{From: rune(54449), To: rune(54475)},
+		&CodePointRange{From: rune(54477), To: rune(54503)},
+		&CodePointRange{From: rune(54505), To: rune(54531)},
+		&CodePointRange{From: rune(54533), To: rune(54559)},
+		&CodePointRange{From: rune(54561), To: rune(54587)},
+		&CodePointRange{From: rune(54589), To: rune(54615)},
+		&CodePointRange{From: rune(54617), To: rune(54643)},
+		&CodePointRange{From: rune(54645), To: rune(54671)},
+		&CodePointRange{From: rune(54673), To: rune(54699)},
+		&CodePointRange{From: rune(54701), To: rune(54727)},
+		&CodePointRange{From: rune(54729), To: rune(54755)},
+		&CodePointRange{From: rune(54757), To: rune(54783)},
+		&CodePointRange{From: rune(54785), To: rune(54811)},
+		&CodePointRange{From: rune(54813), To: rune(54839)},
+		&CodePointRange{From: rune(54841), To: rune(54867)},
+		&CodePointRange{From: rune(54869), To: rune(54895)},
+		&CodePointRange{From: rune(54897), To: rune(54923)},
+		&CodePointRange{From: rune(54925), To: rune(54951)},
+		&CodePointRange{From: rune(54953), To: rune(54979)},
+		&CodePointRange{From: rune(54981), To: rune(55007)},
+		&CodePointRange{From: rune(55009), To: rune(55035)},
+		&CodePointRange{From: rune(55037), To: rune(55063)},
+		&CodePointRange{From: rune(55065), To: rune(55091)},
+		&CodePointRange{From: rune(55093), To: rune(55119)},
+		&CodePointRange{From: rune(55121), To: rune(55147)},
+		&CodePointRange{From: rune(55149), To: rune(55175)},
+		&CodePointRange{From: rune(55177), To: rune(55203)},
+	},
+	"prepend": {
+		&CodePointRange{From: rune(1536), To: rune(1541)},
+		&CodePointRange{From: rune(1757), To: rune(1757)},
+		&CodePointRange{From: rune(1807), To: rune(1807)},
+		&CodePointRange{From: rune(2274), To: rune(2274)},
+		&CodePointRange{From: rune(3406), To: rune(3406)},
+		&CodePointRange{From: rune(69821), To: rune(69821)},
+		&CodePointRange{From: rune(69837), To: rune(69837)},
+		&CodePointRange{From: rune(70082), To: rune(70083)},
+		&CodePointRange{From: rune(71999), To: rune(71999)},
+		&CodePointRange{From: rune(72001), To: rune(72001)},
+		&CodePointRange{From: rune(72250), To: rune(72250)},
+		&CodePointRange{From: rune(72324), To: rune(72329)},
+		&CodePointRange{From: rune(73030), To: rune(73030)},
+	},
+	"regionalindicator": {
+		&CodePointRange{From: rune(127462), To: rune(127487)},
+	},
+	"spacingmark": {
+		&CodePointRange{From: rune(2307), To: rune(2307)},
+		&CodePointRange{From: rune(2363), To: rune(2363)},
+		&CodePointRange{From: rune(2366), To: rune(2368)},
+		&CodePointRange{From: rune(2377), To: rune(2380)},
+		&CodePointRange{From: rune(2382), To: rune(2383)},
+		&CodePointRange{From: rune(2434), To: rune(2435)},
+		&CodePointRange{From: rune(2495), To: rune(2496)},
+		&CodePointRange{From: rune(2503), To: rune(2504)},
+		&CodePointRange{From: rune(2507), To: rune(2508)},
+		&CodePointRange{From: rune(2563), To: rune(2563)},
+		&CodePointRange{From: rune(2622), To: rune(2624)},
+		&CodePointRange{From: rune(2691), To: rune(2691)},
+		&CodePointRange{From: rune(2750), To: rune(2752)},
+		&CodePointRange{From: rune(2761), To: rune(2761)},
+		&CodePointRange{From: rune(2763), To: rune(2764)},
+		&CodePointRange{From: rune(2818), To: rune(2819)},
+		&CodePointRange{From: rune(2880), To: rune(2880)},
+		&CodePointRange{From: rune(2887), To: rune(2888)},
+		&CodePointRange{From: rune(2891), To: rune(2892)},
+		&CodePointRange{From: rune(3007), To: rune(3007)},
+		&CodePointRange{From: rune(3009), To: rune(3010)},
+		&CodePointRange{From: rune(3014), To: rune(3016)},
+		&CodePointRange{From: rune(3018), To: rune(3020)},
+		&CodePointRange{From: rune(3073), To: rune(3075)},
+		&CodePointRange{From: rune(3137), To: rune(3140)},
+		&CodePointRange{From: rune(3202), To: rune(3203)},
+		&CodePointRange{From: rune(3262), To: rune(3262)},
+		&CodePointRange{From: rune(3264), To: rune(3265)},
+		&CodePointRange{From: rune(3267), To: rune(3268)},
+		&CodePointRange{From: rune(3271), To: rune(3272)},
+		&CodePointRange{From: rune(3274), To: rune(3275)},
+		&CodePointRange{From: rune(3330), To: rune(3331)},
+		&CodePointRange{From: rune(3391), To: rune(3392)},
+		&CodePointRange{From: rune(3398), To: rune(3400)},
+		&CodePointRange{From: rune(3402), To: rune(3404)},
+		&CodePointRange{From: rune(3458), To: rune(3459)},
+		&CodePointRange{From: rune(3536), To: rune(3537)},
+		&CodePointRange{From: rune(3544), To: rune(3550)},
+		&CodePointRange{From: rune(3570), To: rune(3571)},
+		&CodePointRange{From: rune(3635), To: rune(3635)},
+		&CodePointRange{From: rune(3763), To: rune(3763)},
+		&CodePointRange{From: rune(3902), To: rune(3903)},
+		&CodePointRange{From: rune(3967), To: rune(3967)},
+		&CodePointRange{From: rune(4145), To: rune(4145)},
+		&CodePointRange{From: rune(4155), To: rune(4156)},
+		&CodePointRange{From: rune(4182), To: rune(4183)},
+		&CodePointRange{From: rune(4228), To: rune(4228)},
+		&CodePointRange{From: rune(5940), To: rune(5940)},
+		&CodePointRange{From: rune(6070), To: rune(6070)},
+		&CodePointRange{From: rune(6078), To: rune(6085)},
+		&CodePointRange{From: rune(6087), To: rune(6088)},
+		&CodePointRange{From: rune(6435), To: rune(6438)},
+		&CodePointRange{From: rune(6441), To: rune(6443)},
+		&CodePointRange{From: rune(6448), To: rune(6449)},
+		&CodePointRange{From: rune(6451), To: rune(6456)},
+		&CodePointRange{From: rune(6681), To: rune(6682)},
+		&CodePointRange{From: rune(6741), To: rune(6741)},
+		&CodePointRange{From: rune(6743), To: rune(6743)},
+		&CodePointRange{From: rune(6765), To: rune(6770)},
+		&CodePointRange{From: rune(6916), To: rune(6916)},
+		&CodePointRange{From: rune(6971), To: rune(6971)},
+		&CodePointRange{From: rune(6973), To: rune(6977)},
+		&CodePointRange{From: rune(6979), To: rune(6980)},
+		&CodePointRange{From: rune(7042), To: rune(7042)},
+		&CodePointRange{From: rune(7073), To: rune(7073)},
+		&CodePointRange{From: rune(7078), To: rune(7079)},
+		&CodePointRange{From: rune(7082), To: rune(7082)},
+		&CodePointRange{From: rune(7143), To: rune(7143)},
+		&CodePointRange{From: rune(7146), To: rune(7148)},
+		&CodePointRange{From: rune(7150), To: rune(7150)},
+		&CodePointRange{From: rune(7154), To: rune(7155)},
+		&CodePointRange{From: rune(7204), To: rune(7211)},
+		&CodePointRange{From: rune(7220), To: rune(7221)},
+		&CodePointRange{From: rune(7393), To: rune(7393)},
+		&CodePointRange{From: rune(7415), To: rune(7415)},
+		&CodePointRange{From: rune(43043), To: rune(43044)},
+		&CodePointRange{From: rune(43047), To: rune(43047)},
+		&CodePointRange{From: rune(43136), To: rune(43137)},
+		&CodePointRange{From: rune(43188), To: rune(43203)},
+		&CodePointRange{From: rune(43346), To: rune(43347)},
+		&CodePointRange{From: rune(43395), To: rune(43395)},
+		&CodePointRange{From: rune(43444), To: rune(43445)},
+		&CodePointRange{From: rune(43450), To: rune(43451)},
+		&CodePointRange{From: rune(43454), To: rune(43456)},
+		&CodePointRange{From: rune(43567), To: rune(43568)},
+		&CodePointRange{From: rune(43571), To: rune(43572)},
+		&CodePointRange{From: rune(43597), To: rune(43597)},
+		&CodePointRange{From: rune(43755), To: rune(43755)},
+		&CodePointRange{From: rune(43758), To: rune(43759)},
+		&CodePointRange{From: rune(43765), To: rune(43765)},
+		&CodePointRange{From: rune(44003), To: rune(44004)},
+		&CodePointRange{From: rune(44006), To: rune(44007)},
+		&CodePointRange{From: rune(44009), To: rune(44010)},
+		&CodePointRange{From: rune(44012), To: rune(44012)},
+		&CodePointRange{From: rune(69632), To: rune(69632)},
+		&CodePointRange{From: rune(69634), To: rune(69634)},
+		&CodePointRange{From: rune(69762), To: rune(69762)},
+		&CodePointRange{From: rune(69808), To: rune(69810)},
+		&CodePointRange{From: rune(69815), To: rune(69816)},
+		&CodePointRange{From: rune(69932), To: rune(69932)},
+		&CodePointRange{From: rune(69957), To: rune(69958)},
+		&CodePointRange{From: rune(70018), To: rune(70018)},
+		&CodePointRange{From: rune(70067), To: rune(70069)},
+		&CodePointRange{From: rune(70079), To: rune(70080)},
+		&CodePointRange{From: rune(70094), To: rune(70094)},
+		&CodePointRange{From: rune(70188), To: rune(70190)},
+		&CodePointRange{From: rune(70194), To: rune(70195)},
+		&CodePointRange{From: rune(70197), To: rune(70197)},
+		&CodePointRange{From: rune(70368), To: rune(70370)},
+		&CodePointRange{From: rune(70402), To: rune(70403)},
+		&CodePointRange{From: rune(70463), To: rune(70463)},
+		&CodePointRange{From: rune(70465), To: rune(70468)},
+		&CodePointRange{From: rune(70471), To: rune(70472)},
+		&CodePointRange{From: rune(70475), To: rune(70477)},
+		&CodePointRange{From: rune(70498), To: rune(70499)},
+		&CodePointRange{From: rune(70709), To: rune(70711)},
+		&CodePointRange{From: rune(70720), To: rune(70721)},
+		&CodePointRange{From: rune(70725), To: rune(70725)},
+		&CodePointRange{From: rune(70833), To: rune(70834)},
+		&CodePointRange{From: rune(70841), To: rune(70841)},
+		&CodePointRange{From: rune(70843), To: rune(70844)},
+		&CodePointRange{From: rune(70846), To: rune(70846)},
+		&CodePointRange{From: rune(70849), To: rune(70849)},
+		&CodePointRange{From: rune(71088), To: rune(71089)},
+		&CodePointRange{From: rune(71096), To: rune(71099)},
+		&CodePointRange{From: rune(71102), To: rune(71102)},
+		&CodePointRange{From: rune(71216), To: rune(71218)},
+		&CodePointRange{From: rune(71227), To: rune(71228)},
+		&CodePointRange{From: rune(71230), To: rune(71230)},
+		&CodePointRange{From: rune(71340), To: rune(71340)},
+		&CodePointRange{From: rune(71342), To: rune(71343)},
+		&CodePointRange{From: rune(71350), To: rune(71350)},
+		&CodePointRange{From: rune(71462), To: rune(71462)},
+		&CodePointRange{From: rune(71724), To: rune(71726)},
+		&CodePointRange{From: rune(71736), To: rune(71736)},
+		&CodePointRange{From: rune(71985), To: rune(71989)},
+		&CodePointRange{From: rune(71991), To: rune(71992)},
+		&CodePointRange{From: rune(71997), To: rune(71997)},
+		&CodePointRange{From: rune(72000), To: rune(72000)},
+		&CodePointRange{From: rune(72002), To: rune(72002)},
+		&CodePointRange{From: rune(72145), To: rune(72147)},
+		&CodePointRange{From: rune(72156), To: rune(72159)},
+		&CodePointRange{From: rune(72164), To: rune(72164)},
+		&CodePointRange{From: rune(72249), To: rune(72249)},
+		&CodePointRange{From: rune(72279), To: rune(72280)},
+		&CodePointRange{From: rune(72343), To: rune(72343)},
+		&CodePointRange{From: rune(72751), To: rune(72751)},
+		&CodePointRange{From: rune(72766), To: rune(72766)},
+		&CodePointRange{From: rune(72873), To: rune(72873)},
+		&CodePointRange{From: rune(72881), To: rune(72881)},
+		&CodePointRange{From: rune(72884), To: rune(72884)},
+		&CodePointRange{From: rune(73098), To: rune(73102)},
+		&CodePointRange{From: rune(73107), To: rune(73108)},
+		&CodePointRange{From: rune(73110), To: rune(73110)},
+		&CodePointRange{From: rune(73461), To: rune(73462)},
+		&CodePointRange{From: rune(94033), To: rune(94087)},
+		&CodePointRange{From: rune(94192), To: rune(94193)},
+		&CodePointRange{From: rune(119142), To: rune(119142)},
+		&CodePointRange{From: rune(119149), To: rune(119149)},
+	},
+	"t": {
+		&CodePointRange{From: rune(4520), To: rune(4607)},
+		&CodePointRange{From: rune(55243), To: rune(55291)},
+	},
+	"v": {
+		&CodePointRange{From: rune(4448), To: rune(4519)},
+		&CodePointRange{From: rune(55216), To: rune(55238)},
+	},
+	"zwj": {
+		&CodePointRange{From: rune(8205), To: rune(8205)},
+	},
+}
+
+// https://www.unicode.org/Public/13.0.0/ucd/emoji/emoji-data.txt
+var extendedPictographicCodePoints = []*CodePointRange{
+	&CodePointRange{From: rune(169), To: rune(169)},
+	&CodePointRange{From: rune(174), To: rune(174)},
+	&CodePointRange{From: rune(8252), To: rune(8252)},
+	&CodePointRange{From: rune(8265), To: rune(8265)},
+	&CodePointRange{From: rune(8482), To: rune(8482)},
+	&CodePointRange{From: rune(8505), To: rune(8505)},
+	&CodePointRange{From: rune(8596), To: rune(8601)},
+	&CodePointRange{From: rune(8617), To: rune(8618)},
+	&CodePointRange{From: rune(8986), To: rune(8987)},
+	&CodePointRange{From: rune(9000), To: rune(9000)},
+	&CodePointRange{From: rune(9096), To: rune(9096)},
+	&CodePointRange{From: rune(9167), To: rune(9167)},
+	&CodePointRange{From: rune(9193), To: rune(9203)},
+	&CodePointRange{From: rune(9208), To: rune(9210)},
+	&CodePointRange{From: rune(9410), To: rune(9410)},
+	&CodePointRange{From: rune(9642), To: rune(9643)},
+	&CodePointRange{From: rune(9654), To: rune(9654)},
+	&CodePointRange{From: rune(9664), To: rune(9664)},
+	&CodePointRange{From: rune(9723), To: rune(9726)},
+	&CodePointRange{From: rune(9728), To: rune(9733)},
+	&CodePointRange{From: rune(9735), To: rune(9746)},
+	&CodePointRange{From: rune(9748), To: rune(9861)},
+	&CodePointRange{From: rune(9872), To: rune(9989)},
+	&CodePointRange{From: rune(9992), To: rune(10002)},
+	&CodePointRange{From: rune(10004), To: rune(10004)},
+	&CodePointRange{From: rune(10006), To: rune(10006)},
+	&CodePointRange{From: rune(10013), To: rune(10013)},
+	&CodePointRange{From: rune(10017), To: rune(10017)},
+	&CodePointRange{From: rune(10024), To: rune(10024)},
+	&CodePointRange{From: rune(10035), To: rune(10036)},
+	&CodePointRange{From: rune(10052), To: rune(10052)},
+	&CodePointRange{From: rune(10055), To: rune(10055)},
+	&CodePointRange{From: rune(10060), To: rune(10060)},
+	&CodePointRange{From: rune(10062), To: rune(10062)},
+	&CodePointRange{From: rune(10067), To: rune(10069)},
+	&CodePointRange{From: rune(10071), To: rune(10071)},
+	&CodePointRange{From: rune(10083), To: rune(10087)},
+	&CodePointRange{From: rune(10133), To: rune(10135)},
+	&CodePointRange{From: rune(10145), To: rune(10145)},
+	&CodePointRange{From: rune(10160), To: rune(10160)},
+	&CodePointRange{From: rune(10175), To: rune(10175)},
+	&CodePointRange{From: rune(10548), To: rune(10549)},
+	&CodePointRange{From: rune(11013), To: rune(11015)},
+	&CodePointRange{From: rune(11035), To: rune(11036)},
+	&CodePointRange{From: rune(11088), To: rune(11088)},
+	&CodePointRange{From: rune(11093), To: rune(11093)},
+	&CodePointRange{From: rune(12336), To: rune(12336)},
+	&CodePointRange{From: rune(12349), To: rune(12349)},
+	&CodePointRange{From: rune(12951), To: rune(12951)},
+	&CodePointRange{From: rune(12953), To: rune(12953)},
+	&CodePointRange{From: rune(126976), To: rune(127231)},
+	&CodePointRange{From: rune(127245), To: rune(127247)},
+	&CodePointRange{From: rune(127279), To: rune(127279)},
+	&CodePointRange{From: rune(127340), To: rune(127345)},
+	&CodePointRange{From: rune(127358), To: rune(127359)},
+	&CodePointRange{From: rune(127374), To: rune(127374)},
+	&CodePointRange{From: rune(127377), To: rune(127386)},
+	&CodePointRange{From: rune(127405), To: rune(127461)},
+	&CodePointRange{From: rune(127489), To: rune(127503)},
+	&CodePointRange{From: rune(127514), To: rune(127514)},
+	&CodePointRange{From: rune(127535), To: rune(127535)},
+	&CodePointRange{From: rune(127538), To: rune(127546)},
+	&CodePointRange{From: rune(127548), To: rune(127551)},
+	&CodePointRange{From: rune(127561), To: rune(127994)},
+	&CodePointRange{From: rune(128000), To: rune(128317)},
+	&CodePointRange{From: rune(128326), To: rune(128591)},
+	&CodePointRange{From: rune(128640), To: rune(128767)},
+	&CodePointRange{From: rune(128884), To: rune(128895)},
+	&CodePointRange{From: rune(128981), To: rune(129023)},
+	&CodePointRange{From: rune(129036), To: rune(129039)},
+	&CodePointRange{From: rune(129096), To: rune(129103)},
+	&CodePointRange{From: rune(129114), To: rune(129119)},
+	&CodePointRange{From: rune(129160), To: rune(129167)},
+	&CodePointRange{From: rune(129198), To: rune(129279)},
+	&CodePointRange{From: rune(129292), To: rune(129338)},
+	&CodePointRange{From: rune(129340), To: rune(129349)},
+	&CodePointRange{From: rune(129351), To: rune(129791)},
+	&CodePointRange{From: rune(130048), To: rune(131069)},
+}
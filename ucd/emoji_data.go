@@ -0,0 +1,35 @@
+package ucd
+
+import "io"
+
+type EmojiData struct {
+	ExtendedPictographic []*CodePointRange
+}
+
+// ParseEmojiData parses the emoji-data.txt.
+func ParseEmojiData(r io.Reader) (*EmojiData, error) {
+	var ep []*CodePointRange
+	p := newParser(r)
+	for p.parse() {
+		if len(p.fields) == 0 {
+			continue
+		}
+
+		cp, err := p.fields[0].codePointRange()
+		if err != nil {
+			return nil, err
+		}
+
+		switch p.fields[1].symbol() {
+		case "Extended_Pictographic":
+			ep = appendCodePointRange(ep, cp)
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return &EmojiData{
+		ExtendedPictographic: ep,
+	}, nil
+}
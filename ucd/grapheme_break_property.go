@@ -0,0 +1,35 @@
+package ucd
+
+import "io"
+
+type GraphemeBreakProperty struct {
+	// GraphemeClusterBreak holds the code point ranges indexed by the values of the Grapheme_Cluster_Break property,
+	// which are in lower case without underscores, such as `extend` and `regionalindicator`.
+	GraphemeClusterBreak map[string][]*CodePointRange
+}
+
+// ParseGraphemeBreakProperty parses the GraphemeBreakProperty.txt.
+func ParseGraphemeBreakProperty(r io.Reader) (*GraphemeBreakProperty, error) {
+	gcb := map[string][]*CodePointRange{}
+	p := newParser(r)
+	for p.parse() {
+		if len(p.fields) == 0 {
+			continue
+		}
+
+		cp, err := p.fields[0].codePointRange()
+		if err != nil {
+			return nil, err
+		}
+
+		val := p.fields[1].normalizedSymbol()
+		gcb[val] = appendCodePointRange(gcb[val], cp)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return &GraphemeBreakProperty{
+		GraphemeClusterBreak: gcb,
+	}, nil
+}
@@ -0,0 +1,226 @@
+package ucd
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Properties is the values of the character properties of a code point. General category and script values are
+// abbreviations in lower case, such as `lu` and `latn`, as in PropertyValueAliases.txt.
+type Properties struct {
+	GeneralCategory string
+	Script          string
+
+	Alphabetic bool
+	Lowercase  bool
+	Uppercase  bool
+	WhiteSpace bool
+
+	// OtherAlphabetic, OtherLowercase, and OtherUppercase are contributory properties, which derive Alphabetic,
+	// Lowercase, and Uppercase.
+	OtherAlphabetic bool
+	OtherLowercase  bool
+	OtherUppercase  bool
+}
+
+// valueRange is a code point range having a property value.
+type valueRange struct {
+	from  rune
+	to    rune
+	value string
+}
+
+var (
+	lookupTablesOnce sync.Once
+	gcRanges         []valueRange
+	scRanges         []valueRange
+	gcbRanges        []valueRange
+)
+
+// genLookupTables sorts the code point ranges of the enumerated properties by their first code points so that Lookup
+// can find a range by binary search.
+func genLookupTables() {
+	lookupTablesOnce.Do(func() {
+		gcRanges = genValueRanges(generalCategoryCodePoints)
+		scRanges = genValueRanges(scriptCodepoints)
+		gcbRanges = genValueRanges(graphemeClusterBreakCodePoints)
+	})
+}
+
+func genValueRanges(cps map[string][]*CodePointRange) []valueRange {
+	var rs []valueRange
+	for val, ranges := range cps {
+		for _, r := range ranges {
+			rs = append(rs, valueRange{
+				from:  r.From,
+				to:    r.To,
+				value: val,
+			})
+		}
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].from < rs[j].from
+	})
+	return rs
+}
+
+func findValue(rs []valueRange, r rune, defaultValue string) string {
+	i := sort.Search(len(rs), func(i int) bool {
+		return rs[i].to >= r
+	})
+	if i < len(rs) && rs[i].from <= r {
+		return rs[i].value
+	}
+	return defaultValue
+}
+
+func containsCodePoint(ranges []*CodePointRange, r rune) bool {
+	for _, cp := range ranges {
+		if cp.From <= r && r <= cp.To {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the character properties of a code point. A code point out of the Unicode codespace has
+// the default values, that is, the general category `cn` and the script `zzzz`.
+func Lookup(r rune) Properties {
+	genLookupTables()
+
+	props := Properties{
+		GeneralCategory: generalCategoryValueAbbs[normalizeSymbolicValue(generalCategoryDefaultValue)],
+		Script:          scriptValueAbbs[normalizeSymbolicValue(scriptDefaultValue)],
+	}
+	if r < codePointMin || r > codePointMax {
+		return props
+	}
+	props.GeneralCategory = findValue(gcRanges, r, props.GeneralCategory)
+	props.Script = findValue(scRanges, r, props.Script)
+	props.OtherAlphabetic = containsCodePoint(otherAlphabeticCodePoints, r)
+	props.OtherLowercase = containsCodePoint(otherLowercaseCodePoints, r)
+	props.OtherUppercase = containsCodePoint(otherUppercaseCodePoints, r)
+	props.WhiteSpace = containsCodePoint(whiteSpaceCodePoints, r)
+
+	// These derivations follow derivedCoreProperties.
+	props.Lowercase = props.GeneralCategory == "ll" || props.OtherLowercase
+	props.Uppercase = props.GeneralCategory == "lu" || props.OtherUppercase
+	switch props.GeneralCategory {
+	case "lt", "lm", "lo", "nl":
+		props.Alphabetic = true
+	default:
+		props.Alphabetic = props.Lowercase || props.Uppercase || props.OtherAlphabetic
+	}
+	return props
+}
+
+// GraphemeClusterBreak returns the Grapheme_Cluster_Break property value of a code point. The values are in lower case
+// without underscores, such as `extend` and `regionalindicator`, and a code point GraphemeBreakProperty.txt doesn't
+// list has the default value `other`.
+func GraphemeClusterBreak(r rune) string {
+	genLookupTables()
+	return findValue(gcbRanges, r, "other")
+}
+
+// IsExtendedPictographic reports whether a code point has the Extended_Pictographic property, which UAX #29 uses to
+// keep emoji ZWJ sequences in a grapheme cluster.
+func IsExtendedPictographic(r rune) bool {
+	return containsCodePoint(extendedPictographicCodePoints, r)
+}
+
+// derivedCoreRanges holds the properties and values that derive each derived core property. It is the same as
+// derivedCoreProperties but in the form RangesFor can look up.
+var derivedCoreRanges = map[string][][2]string{
+	"alpha": {{"lower", "yes"}, {"upper", "yes"}, {"gc", "lt"}, {"gc", "lm"}, {"gc", "lo"}, {"gc", "nl"}, {"oalpha", "yes"}},
+	"lower": {{"gc", "ll"}, {"olower", "yes"}},
+	"upper": {{"gc", "lu"}, {"oupper", "yes"}},
+}
+
+// RangesFor returns the code point ranges having a property value, such as `gc=Lu` and `Script=Latin`. The ranges
+// are sorted and don't overlap or adjoin each other. Like `\p{...}` in a pattern, an empty property name means
+// General_Category, and names and values are case-insensitive and ignore white spaces, hyphens, and underscores.
+func RangesFor(propName, propVal string) ([]CodePointRange, error) {
+	if propName == "" {
+		propName = "gc"
+	}
+	name, ok := propertyNameAbbs[normalizeSymbolicValue(propName)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported character property name: %v", propName)
+	}
+
+	var ranges []CodePointRange
+	inverse := false
+	if components, ok := derivedCoreRanges[name]; ok {
+		yes, ok := binaryValues[normalizeSymbolicValue(propVal)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported character property value: %v", propVal)
+		}
+		for _, c := range components {
+			rs, err := RangesFor(c[0], c[1])
+			if err != nil {
+				return nil, err
+			}
+			ranges = append(ranges, rs...)
+		}
+		inverse = !yes
+	} else {
+		cps, inv, err := FindCodePointRanges(name, propVal)
+		if err != nil {
+			return nil, err
+		}
+		for _, cp := range cps {
+			ranges = append(ranges, *cp)
+		}
+		inverse = inv
+	}
+
+	ranges = mergeCodePointRanges(ranges)
+	if inverse {
+		ranges = invertCodePointRanges(ranges)
+	}
+	return ranges, nil
+}
+
+func mergeCodePointRanges(ranges []CodePointRange) []CodePointRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].From < ranges[j].From
+	})
+	merged := []CodePointRange{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.From <= last.To+1 {
+			if r.To > last.To {
+				last.To = r.To
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// invertCodePointRanges returns the complement of sorted and merged ranges in the Unicode codespace.
+func invertCodePointRanges(ranges []CodePointRange) []CodePointRange {
+	var inverted []CodePointRange
+	from := rune(codePointMin)
+	for _, r := range ranges {
+		if r.From > from {
+			inverted = append(inverted, CodePointRange{
+				From: from,
+				To:   r.From - 1,
+			})
+		}
+		from = r.To + 1
+	}
+	if from <= codePointMax {
+		inverted = append(inverted, CodePointRange{
+			From: from,
+			To:   codePointMax,
+		})
+	}
+	return inverted
+}
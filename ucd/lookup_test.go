@@ -0,0 +1,218 @@
+package ucd
+
+import (
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		r     rune
+		props Properties
+	}{
+		{
+			r: 'A',
+			props: Properties{
+				GeneralCategory: "lu",
+				Script:          "latn",
+				Alphabetic:      true,
+				Uppercase:       true,
+			},
+		},
+		{
+			r: ' ',
+			props: Properties{
+				GeneralCategory: "zs",
+				Script:          "zyyy",
+				WhiteSpace:      true,
+			},
+		},
+		{
+			r: 'あ',
+			props: Properties{
+				GeneralCategory: "lo",
+				Script:          "hira",
+				Alphabetic:      true,
+			},
+		},
+		// UnicodeData.txt lists CJK ideographs and Hangul syllables as ranges.
+		{
+			r: '東',
+			props: Properties{
+				GeneralCategory: "lo",
+				Script:          "hani",
+				Alphabetic:      true,
+			},
+		},
+		{
+			r: '中',
+			props: Properties{
+				GeneralCategory: "lo",
+				Script:          "hani",
+				Alphabetic:      true,
+			},
+		},
+		{
+			r: '文',
+			props: Properties{
+				GeneralCategory: "lo",
+				Script:          "hani",
+				Alphabetic:      true,
+			},
+		},
+		{
+			r: '한',
+			props: Properties{
+				GeneralCategory: "lo",
+				Script:          "hang",
+				Alphabetic:      true,
+			},
+		},
+		{
+			// U+00AA FEMININE ORDINAL INDICATOR is Lo but also Other_Lowercase.
+			r: 'ª',
+			props: Properties{
+				GeneralCategory: "lo",
+				Script:          "latn",
+				Alphabetic:      true,
+				Lowercase:       true,
+				OtherLowercase:  true,
+			},
+		},
+		{
+			// U+0345 COMBINING GREEK YPOGEGRAMMENI is Mn but also Other_Alphabetic and Other_Lowercase.
+			r: 'ͅ',
+			props: Properties{
+				GeneralCategory: "mn",
+				Script:          "zinh",
+				Alphabetic:      true,
+				Lowercase:       true,
+				OtherAlphabetic: true,
+				OtherLowercase:  true,
+			},
+		},
+		{
+			// U+0378 is unassigned.
+			r: '͸',
+			props: Properties{
+				GeneralCategory: "cn",
+				Script:          "zzzz",
+			},
+		},
+		{
+			r: -1,
+			props: Properties{
+				GeneralCategory: "cn",
+				Script:          "zzzz",
+			},
+		},
+	}
+	for _, tt := range tests {
+		props := Lookup(tt.r)
+		if props != tt.props {
+			t.Errorf("unexpected properties of %U: want: %+v, got: %+v", tt.r, tt.props, props)
+		}
+	}
+}
+
+func TestGraphemeClusterBreak(t *testing.T) {
+	tests := []struct {
+		r       rune
+		gcb     string
+		extPict bool
+	}{
+		{r: 'a', gcb: "other"},
+		{r: '\r', gcb: "cr"},
+		{r: '\n', gcb: "lf"},
+		{r: '\t', gcb: "control"},
+		// U+0301 COMBINING ACUTE ACCENT
+		{r: 0x0301, gcb: "extend"},
+		// U+0600 ARABIC NUMBER SIGN is a prepended concatenation mark.
+		{r: 0x0600, gcb: "prepend"},
+		// U+0903 DEVANAGARI SIGN VISARGA
+		{r: 0x0903, gcb: "spacingmark"},
+		{r: 0x1100, gcb: "l"},
+		{r: 0x1161, gcb: "v"},
+		{r: 0x11a8, gcb: "t"},
+		// U+AC00 HANGUL SYLLABLE GA and U+AC01 HANGUL SYLLABLE GAG
+		{r: 0xac00, gcb: "lv"},
+		{r: 0xac01, gcb: "lvt"},
+		{r: 0x200d, gcb: "zwj"},
+		{r: 0x1f1ef, gcb: "regionalindicator"},
+		// U+1F3FB EMOJI MODIFIER FITZPATRICK TYPE-1-2
+		{r: 0x1f3fb, gcb: "extend"},
+		// U+00A9 COPYRIGHT SIGN and U+1F600 GRINNING FACE
+		{r: 0xa9, gcb: "other", extPict: true},
+		{r: 0x1f600, gcb: "other", extPict: true},
+		// Extended_Pictographic reserves unassigned code points for future emoji.
+		{r: 0x1fffd, gcb: "other", extPict: true},
+		{r: -1, gcb: "other"},
+	}
+	for _, tt := range tests {
+		if gcb := GraphemeClusterBreak(tt.r); gcb != tt.gcb {
+			t.Errorf("unexpected Grapheme_Cluster_Break of %U: want: %v, got: %v", tt.r, tt.gcb, gcb)
+		}
+		if extPict := IsExtendedPictographic(tt.r); extPict != tt.extPict {
+			t.Errorf("unexpected Extended_Pictographic of %U: want: %v, got: %v", tt.r, tt.extPict, extPict)
+		}
+	}
+}
+
+func TestRangesFor(t *testing.T) {
+	ranges, err := RangesFor("", "Lu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ranges[0] != (CodePointRange{From: 'A', To: 'Z'}) {
+		t.Fatalf("unexpected first range: %+v", ranges[0])
+	}
+
+	for _, tt := range []struct {
+		propName string
+		propVal  string
+		has      func(p Properties) bool
+	}{
+		{propName: "gc", propVal: "Letter", has: func(p Properties) bool {
+			switch p.GeneralCategory {
+			case "lu", "ll", "lt", "lm", "lo":
+				return true
+			}
+			return false
+		}},
+		{propName: "General_Category", propVal: "unassigned", has: func(p Properties) bool { return p.GeneralCategory == "cn" }},
+		{propName: "Script", propVal: "Hiragana", has: func(p Properties) bool { return p.Script == "hira" }},
+		{propName: "sc", propVal: "Unknown", has: func(p Properties) bool { return p.Script == "zzzz" }},
+		{propName: "Alphabetic", propVal: "yes", has: func(p Properties) bool { return p.Alphabetic }},
+		{propName: "Lowercase", propVal: "no", has: func(p Properties) bool { return !p.Lowercase }},
+		{propName: "White_Space", propVal: "yes", has: func(p Properties) bool { return p.WhiteSpace }},
+	} {
+		ranges, err := RangesFor(tt.propName, tt.propVal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i < len(ranges); i++ {
+			if ranges[i].From <= ranges[i-1].To+1 {
+				t.Fatalf("%v=%v: ranges must be sorted and merged: %+v, %+v", tt.propName, tt.propVal, ranges[i-1], ranges[i])
+			}
+		}
+		// Lookup and RangesFor must agree on every code point.
+		i := 0
+		for r := rune(codePointMin); r <= codePointMax; r++ {
+			for i < len(ranges) && ranges[i].To < r {
+				i++
+			}
+			inRanges := i < len(ranges) && ranges[i].From <= r
+			if inRanges != tt.has(Lookup(r)) {
+				t.Fatalf("%v=%v: Lookup and RangesFor disagree on %U", tt.propName, tt.propVal, r)
+			}
+		}
+	}
+
+	_, err = RangesFor("foo", "yes")
+	if err == nil {
+		t.Fatal("an unknown property name must be an error")
+	}
+	_, err = RangesFor("gc", "foo")
+	if err == nil {
+		t.Fatal("an unknown property value must be an error")
+	}
+}
@@ -19,6 +19,20 @@ var codePointRangeNil = &CodePointRange{
 	To:   0,
 }
 
+// appendCodePointRange appends a code point range to ranges, merging it into the last range when they adjoin. The data
+// files may split a range into several lines, for instance, by general categories, and this makes the result
+// independent of such splitting.
+func appendCodePointRange(ranges []*CodePointRange, cp *CodePointRange) []*CodePointRange {
+	if len(ranges) > 0 {
+		last := ranges[len(ranges)-1]
+		if cp.From-last.To == 1 {
+			last.To = cp.To
+			return ranges
+		}
+	}
+	return append(ranges, cp)
+}
+
 type field string
 
 func (f field) codePointRange() (*CodePointRange, error) {
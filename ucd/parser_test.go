@@ -0,0 +1,185 @@
+package ucd
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPropertyValueAliases = `# PropertyValueAliases-13.0.0.txt
+
+# General_Category (gc)
+
+# @missing: 0000..10FFFF; General_Category; Unassigned
+gc ; Cn                               ; Unassigned
+gc ; L                                ; Letter                           # Ll | Lm | Lo | Lt | Lu
+gc ; Lo                               ; Other_Letter
+gc ; Lu                               ; Uppercase_Letter
+gc ; Nd                               ; Decimal_Number                   ; digit
+
+# Script (sc)
+
+sc ; Grek                             ; Greek
+sc ; Latn                             ; Latin
+sc ; Zyyy                             ; Common
+`
+
+func parseTestPropertyValueAliases(t *testing.T) *PropertyValueAliases {
+	t.Helper()
+	a, err := ParsePropertyValueAliases(strings.NewReader(testPropertyValueAliases))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func testCodePointRanges(t *testing.T, expected, actual []*CodePointRange) {
+	t.Helper()
+	if len(actual) != len(expected) {
+		t.Fatalf("unexpected code point ranges; want: %v ranges, got: %v ranges", len(expected), len(actual))
+	}
+	for i, e := range expected {
+		if *actual[i] != *e {
+			t.Fatalf("unexpected code point range; want: %X..%X, got: %X..%X", e.From, e.To, actual[i].From, actual[i].To)
+		}
+	}
+}
+
+func TestParsePropertyValueAliases(t *testing.T) {
+	a := parseTestPropertyValueAliases(t)
+
+	gcs := map[string]string{
+		"cn":              "cn",
+		"unassigned":      "cn",
+		"letter":          "l",
+		"lu":              "lu",
+		"uppercaseletter": "lu",
+		"decimalnumber":   "nd",
+		"digit":           "nd",
+	}
+	for name, abb := range gcs {
+		if a.GeneralCategory[name] != abb {
+			t.Errorf("unexpected abbreviation of %v; want: %v, got: %v", name, abb, a.GeneralCategory[name])
+		}
+	}
+	if a.Script["greek"] != "grek" || a.Script["latn"] != "latn" {
+		t.Errorf("unexpected script aliases: %v", a.Script)
+	}
+	if a.GeneralCategoryDefaultValue != "unassigned" {
+		t.Errorf("unexpected default value: %v", a.GeneralCategoryDefaultValue)
+	}
+	testCodePointRanges(t, []*CodePointRange{{From: 0, To: 0x10ffff}}, []*CodePointRange{a.GeneralCategoryDefaultRange})
+}
+
+func TestParseUnicodeData(t *testing.T) {
+	src := `0030;DIGIT ZERO;Nd;0;EN;;0;0;0;N;;;;;
+0031;DIGIT ONE;Nd;0;EN;;1;1;1;N;;;;;
+0041;LATIN CAPITAL LETTER A;Lu;0;L;;;;;N;;;;0061;
+0042;LATIN CAPITAL LETTER B;Lu;0;L;;;;;N;;;;0062;
+0391;GREEK CAPITAL LETTER ALPHA;Lu;0;L;;;;;N;;;;03B1;
+4E00;<CJK Ideograph, First>;Lo;0;L;;;;;N;;;;;
+9FFC;<CJK Ideograph, Last>;Lo;0;L;;;;;N;;;;;
+AC00;<Hangul Syllable, First>;Lo;0;L;;;;;N;;;;;
+D7A3;<Hangul Syllable, Last>;Lo;0;L;;;;;N;;;;;
+`
+	u, err := ParseUnicodeData(strings.NewReader(src), parseTestPropertyValueAliases(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(u.GeneralCategory) != 3 {
+		t.Fatalf("unexpected general categories: %v", u.GeneralCategory)
+	}
+	// Consecutive code points of the same category make one range.
+	testCodePointRanges(t, []*CodePointRange{{From: '0', To: '1'}}, u.GeneralCategory["nd"])
+	testCodePointRanges(t, []*CodePointRange{{From: 'A', To: 'B'}, {From: 0x0391, To: 0x0391}}, u.GeneralCategory["lu"])
+	// A pair of lines whose names end with `, First>` and `, Last>` makes a range.
+	testCodePointRanges(t, []*CodePointRange{{From: 0x4E00, To: 0x9FFC}, {From: 0xAC00, To: 0xD7A3}}, u.GeneralCategory["lo"])
+}
+
+func TestParseScripts(t *testing.T) {
+	src := `# Scripts-13.0.0.txt
+
+# @missing: 0000..10FFFF; Unknown
+
+0000..001F    ; Common # Cc  [32] <control-0000>..<control-001F>
+0041..005A    ; Latin # L&  [26] LATIN CAPITAL LETTER A..LATIN CAPITAL LETTER Z
+0061..007A    ; Latin # L&  [26] LATIN SMALL LETTER A..LATIN SMALL LETTER Z
+0391..03A1    ; Greek # L&  [17] GREEK CAPITAL LETTER ALPHA..GREEK CAPITAL LETTER RHO
+`
+	s, err := ParseScripts(strings.NewReader(src), parseTestPropertyValueAliases(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCodePointRanges(t, []*CodePointRange{{From: 0, To: 0x1f}}, s.Script["zyyy"])
+	testCodePointRanges(t, []*CodePointRange{{From: 'A', To: 'Z'}, {From: 'a', To: 'z'}}, s.Script["latn"])
+	testCodePointRanges(t, []*CodePointRange{{From: 0x0391, To: 0x03a1}}, s.Script["grek"])
+	if s.ScriptDefaultValue != "unknown" {
+		t.Errorf("unexpected default value: %v", s.ScriptDefaultValue)
+	}
+	testCodePointRanges(t, []*CodePointRange{{From: 0, To: 0x10ffff}}, []*CodePointRange{s.ScriptDefaultRange})
+
+	_, err = ParseScripts(strings.NewReader("0041 ; Undefined_Script\n"), parseTestPropertyValueAliases(t))
+	if err == nil {
+		t.Fatal("an unknown script must be an error")
+	}
+}
+
+func TestParsePropList(t *testing.T) {
+	src := `# PropList-13.0.0.txt
+
+0009..000D    ; White_Space # Cc   [5] <control-0009>..<control-000D>
+0020          ; White_Space # Zs       SPACE
+0345          ; Other_Alphabetic # Mn       COMBINING GREEK YPOGEGRAMMENI
+00AA          ; Other_Lowercase # Lo       FEMININE ORDINAL INDICATOR
+2160..216F    ; Other_Uppercase # Nl  [16] ROMAN NUMERAL ONE..ROMAN NUMERAL ONE THOUSAND
+002D          ; Dash # Pd       HYPHEN-MINUS
+`
+	p, err := ParsePropList(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCodePointRanges(t, []*CodePointRange{{From: 0x09, To: 0x0d}, {From: 0x20, To: 0x20}}, p.WhiteSpace)
+	testCodePointRanges(t, []*CodePointRange{{From: 0x0345, To: 0x0345}}, p.OtherAlphabetic)
+	testCodePointRanges(t, []*CodePointRange{{From: 0xaa, To: 0xaa}}, p.OtherLowercase)
+	testCodePointRanges(t, []*CodePointRange{{From: 0x2160, To: 0x216f}}, p.OtherUppercase)
+}
+
+func TestParseGraphemeBreakProperty(t *testing.T) {
+	src := `# GraphemeBreakProperty-13.0.0.txt
+
+# @missing: 0000..10FFFF; Other
+
+000D          ; CR # Cc       <control-000D>
+0300..036F    ; Extend # Mn [112] COMBINING GRAVE ACCENT..COMBINING LATIN SMALL LETTER X
+1F1E6..1F1FF  ; Regional_Indicator # So  [26] REGIONAL INDICATOR SYMBOL LETTER A..REGIONAL INDICATOR SYMBOL LETTER Z
+2060..2064    ; Control # Cf   [5] WORD JOINER..INVISIBLE PLUS
+2065          ; Control # Cn       <reserved-2065>
+2066..206F    ; Control # Cf  [10] LEFT-TO-RIGHT ISOLATE..NOMINAL DIGIT SHAPES
+`
+	p, err := ParseGraphemeBreakProperty(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.GraphemeClusterBreak) != 4 {
+		t.Fatalf("unexpected values: %v", p.GraphemeClusterBreak)
+	}
+	testCodePointRanges(t, []*CodePointRange{{From: 0x0d, To: 0x0d}}, p.GraphemeClusterBreak["cr"])
+	testCodePointRanges(t, []*CodePointRange{{From: 0x0300, To: 0x036f}}, p.GraphemeClusterBreak["extend"])
+	testCodePointRanges(t, []*CodePointRange{{From: 0x1f1e6, To: 0x1f1ff}}, p.GraphemeClusterBreak["regionalindicator"])
+	// Adjoining lines of the same value make one range.
+	testCodePointRanges(t, []*CodePointRange{{From: 0x2060, To: 0x206f}}, p.GraphemeClusterBreak["control"])
+}
+
+func TestParseEmojiData(t *testing.T) {
+	src := `# emoji-data-13.0.0.txt
+
+231A..231B    ; Emoji                # E0.6   [2] (⌚..⌛)    watch..hourglass done
+00A9          ; Extended_Pictographic# E0.6   [1] (©️)       copyright
+1F000..1F003  ; Extended_Pictographic# E0.0   [4] (🀀..🀃)    MAHJONG TILE EAST WIND..MAHJONG TILE NORTH WIND
+1F004         ; Extended_Pictographic# E0.6   [1] (🀄)       mahjong red dragon
+`
+	e, err := ParseEmojiData(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCodePointRanges(t, []*CodePointRange{{From: 0xa9, To: 0xa9}, {From: 0x1f000, To: 0x1f004}}, e.ExtendedPictographic)
+}
@@ -1,6 +1,9 @@
 package ucd
 
-import "io"
+import (
+	"io"
+	"strings"
+)
 
 type UnicodeData struct {
 	GeneralCategory map[string][]*CodePointRange
@@ -16,6 +19,7 @@ func ParseUnicodeData(r io.Reader, propValAliases *PropertyValueAliases) (*Unico
 	}
 
 	p := newParser(r)
+	var first *CodePointRange
 	for p.parse() {
 		if len(p.fields) == 0 {
 			continue
@@ -24,6 +28,24 @@ func ParseUnicodeData(r io.Reader, propValAliases *PropertyValueAliases) (*Unico
 		if err != nil {
 			return nil, err
 		}
+
+		// https://www.unicode.org/reports/tr44/#Code_Point_Ranges
+		// UnicodeData.txt lists a large range of code points having the same properties, such as CJK ideographs and
+		// Hangul syllables, as a pair of lines. The name of the first line ends with `, First>`, and the name of the
+		// second line ends with `, Last>`.
+		name := p.fields[1].symbol()
+		if strings.HasSuffix(name, ", First>") {
+			first = cp
+			continue
+		}
+		if strings.HasSuffix(name, ", Last>") && first != nil {
+			cp = &CodePointRange{
+				From: first.From,
+				To:   cp.To,
+			}
+		}
+		first = nil
+
 		gc := p.fields[2].normalizedSymbol()
 		unicodeData.addGC(gc, cp)
 	}
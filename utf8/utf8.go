@@ -54,6 +54,11 @@ type cpRange struct {
 // the code point but non-continuous in the UTF-8 byte sequence (In UTF-8, <U+0000..U+007F> is encoded <00..7F>,
 // and <U+0080..U+07FF> is encoded <C2 80..DF BF>).
 //
+// Moreover, this function splits a range so that each byte of the encodings of the code points a block contains
+// ranges independently of the other bytes. For instance, this function splits <U+03AC..U+03CE> (<CE AC..CF 8E>) into
+// <U+03AC..U+03BF> (<CE AC..CE BF>) and <U+03C0..U+03CE> (<CF 80..CF 8E>) because <CE..CF> followed by <AC..8E>
+// doesn't represent the range.
+//
 // The blocks don't contain surrogate code points <U+D800..U+DFFF> because byte sequences encoding them are
 // ill-formed in UTF-8. For instance, <U+D000..U+FFFF> is split into <U+D000..U+D7FF> and <U+E000..U+FFFF>.
 // However, when `from` or `to` itself is the surrogate code point, this function returns an error.
@@ -100,7 +105,7 @@ func splitCodePoint(from, to rune) ([]*cpRange, error) {
 		case in.from <= 0xfffff && in.to > 0xfffff:
 			r.to = 0xfffff
 		}
-		rs = append(rs, r)
+		rs = append(rs, splitByContinuationBytes(r)...)
 		in.from = r.to + 1
 
 		// Skip surrogate code points U+D800..U+DFFF.
@@ -110,3 +115,35 @@ func splitCodePoint(from, to rune) ([]*cpRange, error) {
 	}
 	return rs, nil
 }
+
+// splitByContinuationBytes splits a range of code points whose encodings have the same length into ranges each of which
+// is a product of byte ranges.
+func splitByContinuationBytes(r *cpRange) []*cpRange {
+	n := len(string(r.to))
+	for i := 1; i < n; i++ {
+		// `m` masks the bits the last `i` continuation bytes encode.
+		m := rune(1)<<(6*i) - 1
+		if r.from&^m == r.to&^m {
+			continue
+		}
+		if r.from&m != 0 {
+			return append(splitByContinuationBytes(&cpRange{
+				from: r.from,
+				to:   r.from | m,
+			}), splitByContinuationBytes(&cpRange{
+				from: r.from | m + 1,
+				to:   r.to,
+			})...)
+		}
+		if r.to&m != m {
+			return append(splitByContinuationBytes(&cpRange{
+				from: r.from,
+				to:   r.to&^m - 1,
+			}), splitByContinuationBytes(&cpRange{
+				from: r.to &^ m,
+				to:   r.to,
+			})...)
+		}
+	}
+	return []*cpRange{r}
+}
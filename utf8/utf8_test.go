@@ -100,6 +100,32 @@ func TestGenCharBlocks_WellFormed(t *testing.T) {
 				cBlk(seq(0xf4, 0x80, 0x80, 0x80), seq(0xf4, 0x8f, 0xbf, 0xbf)),
 			},
 		},
+		{
+			from: '\u03ac',
+			to:   '\u03ce',
+			blocks: []*CharBlock{
+				cBlk(seq(0xce, 0xac), seq(0xce, 0xbf)),
+				cBlk(seq(0xcf, 0x80), seq(0xcf, 0x8e)),
+			},
+		},
+		{
+			from: '\u0430',
+			to:   '\u045f',
+			blocks: []*CharBlock{
+				cBlk(seq(0xd0, 0xb0), seq(0xd0, 0xbf)),
+				cBlk(seq(0xd1, 0x80), seq(0xd1, 0x9f)),
+			},
+		},
+		{
+			from: '\u3042',
+			to:   '\u5000',
+			blocks: []*CharBlock{
+				cBlk(seq(0xe3, 0x81, 0x82), seq(0xe3, 0x81, 0xbf)),
+				cBlk(seq(0xe3, 0x82, 0x80), seq(0xe3, 0xbf, 0xbf)),
+				cBlk(seq(0xe4, 0x80, 0x80), seq(0xe4, 0xbf, 0xbf)),
+				cBlk(seq(0xe5, 0x80, 0x80), seq(0xe5, 0x80, 0x80)),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%v..%v", tt.from, tt.to), func(t *testing.T) {
@@ -0,0 +1,286 @@
+package vartantest
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const (
+	defaultTerminalCount        = 5
+	defaultNonTerminalCount     = 4
+	defaultMaxAlternativeCount  = 3
+	defaultMaxAlternativeLength = 3
+	defaultMaxGrammarAttempts   = 1000
+
+	// maxTerminalCount is the number of letters that the terminal symbols can start with.
+	maxTerminalCount = 26
+)
+
+type GrammarGeneratorOption func(g *GrammarGenerator) error
+
+// GrammarSeed sets a seed of the random number generator. Generators having the same seed and the same options
+// generate the same grammars.
+func GrammarSeed(seed int64) GrammarGeneratorOption {
+	return func(g *GrammarGenerator) error {
+		g.rand = rand.New(rand.NewSource(seed))
+		return nil
+	}
+}
+
+// TerminalCount sets the number of terminal symbols a grammar has, excluding a skipped white space symbol.
+func TerminalCount(n int) GrammarGeneratorOption {
+	return func(g *GrammarGenerator) error {
+		if n < 1 || n > maxTerminalCount {
+			return fmt.Errorf("a terminal count must be between 1 and %v: %v", maxTerminalCount, n)
+		}
+		g.termCount = n
+		return nil
+	}
+}
+
+// NonTerminalCount sets the number of non-terminal symbols a grammar has.
+func NonTerminalCount(n int) GrammarGeneratorOption {
+	return func(g *GrammarGenerator) error {
+		if n < 1 {
+			return fmt.Errorf("a non-terminal count must be greater than or equal to 1: %v", n)
+		}
+		g.nonTermCount = n
+		return nil
+	}
+}
+
+// MaxAlternativeCount limits the number of alternatives of each non-terminal symbol. A non-terminal symbol can have
+// more alternatives than the limit when the generator adds alternatives to make every symbol reachable.
+func MaxAlternativeCount(n int) GrammarGeneratorOption {
+	return func(g *GrammarGenerator) error {
+		if n < 1 {
+			return fmt.Errorf("a max alternative count must be greater than or equal to 1: %v", n)
+		}
+		g.maxAltCount = n
+		return nil
+	}
+}
+
+// MaxAlternativeLength limits the number of symbols in each alternative. An alternative can be longer than the limit
+// when the generator adds symbols to make every symbol reachable.
+func MaxAlternativeLength(n int) GrammarGeneratorOption {
+	return func(g *GrammarGenerator) error {
+		if n < 0 {
+			return fmt.Errorf("a max alternative length must be greater than or equal to 0: %v", n)
+		}
+		g.maxAltLen = n
+		return nil
+	}
+}
+
+// MaxGrammarAttempts sets the number of times the generator tries to generate a grammar without conflicts before it
+// gives up. Grammars having few terminal symbols and many non-terminal symbols tend to have conflicts.
+func MaxGrammarAttempts(n int) GrammarGeneratorOption {
+	return func(g *GrammarGenerator) error {
+		if n < 1 {
+			return fmt.Errorf("max attempts must be greater than or equal to 1: %v", n)
+		}
+		g.maxAttempts = n
+		return nil
+	}
+}
+
+// GrammarGenerator generates random grammar sources that vartan can compile. The grammars are useful for testing
+// programs that consume arbitrary grammars, such as the compiler and the drivers.
+//
+// Every symbol of a generated grammar is reachable from the start symbol, and every non-terminal symbol derives some
+// sentence. A terminal symbol matches a distinct lowercase letter, optionally followed by digits, so the lexer splits
+// any sequence of lexemes into the original tokens. The grammars are LALR(1), that is, their parsing tables have no
+// conflicts, because a parser driven by a table whose conflicts are resolved can reduce forever on a grammar like
+// `a: b a c | ; b: ;`.
+type GrammarGenerator struct {
+	rand         *rand.Rand
+	termCount    int
+	nonTermCount int
+	maxAltCount  int
+	maxAltLen    int
+	maxAttempts  int
+}
+
+func NewGrammarGenerator(opts ...GrammarGeneratorOption) (*GrammarGenerator, error) {
+	g := &GrammarGenerator{
+		rand:         rand.New(rand.NewSource(1)),
+		termCount:    defaultTerminalCount,
+		nonTermCount: defaultNonTerminalCount,
+		maxAltCount:  defaultMaxAlternativeCount,
+		maxAltLen:    defaultMaxAlternativeLength,
+		maxAttempts:  defaultMaxGrammarAttempts,
+	}
+	for _, opt := range opts {
+		err := opt(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// Generate generates a grammar source. The name of the grammar is `test`, the non-terminal symbols are `n0`, `n1`,
+// and so on, and `n0` is the start symbol. The terminal symbols are `t0`, `t1`, and so on.
+func (g *GrammarGenerator) Generate() (string, error) {
+	for i := 0; i < g.maxAttempts; i++ {
+		src := g.format(g.genAlternatives())
+		if conflictFree(src) {
+			return src, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate a grammar without conflicts in %v attempts", g.maxAttempts)
+}
+
+// genAlternatives generates the alternatives of each non-terminal symbol. In an alternative, a non-negative number i
+// means the terminal symbol `t`i, and a negative number -(i+1) means the non-terminal symbol `n`i.
+func (g *GrammarGenerator) genAlternatives() [][][]int {
+	alts := make([][][]int, g.nonTermCount)
+	for i := range alts {
+		// The first alternative consists only of terminal symbols, so every non-terminal symbol derives a sentence.
+		alt := make([]int, g.rand.Intn(g.maxAltLen+1))
+		for j := range alt {
+			alt[j] = g.rand.Intn(g.termCount)
+		}
+		alts[i] = append(alts[i], alt)
+
+		for n := g.rand.Intn(g.maxAltCount); n > 0; n-- {
+			alt := make([]int, g.rand.Intn(g.maxAltLen+1))
+			for j := range alt {
+				if g.rand.Intn(2) == 0 {
+					alt[j] = g.rand.Intn(g.termCount)
+				} else {
+					alt[j] = -(g.rand.Intn(g.nonTermCount) + 1)
+				}
+			}
+			alts[i] = append(alts[i], alt)
+		}
+	}
+
+	// Make every symbol reachable from the start symbol. Each unreachable non-terminal symbol gets referenced by
+	// a new alternative of a preceding non-terminal symbol, and each unused terminal symbol gets appended to some
+	// alternative.
+	for i := 1; i < g.nonTermCount; i++ {
+		if reachable(alts, i) {
+			continue
+		}
+		from := g.rand.Intn(i)
+		alts[from] = append(alts[from], []int{g.rand.Intn(g.termCount), -(i + 1)})
+	}
+	used := make([]bool, g.termCount)
+	for _, nonTermAlts := range alts {
+		for _, alt := range nonTermAlts {
+			for _, sym := range alt {
+				if sym >= 0 {
+					used[sym] = true
+				}
+			}
+		}
+	}
+	for term, ok := range used {
+		if ok {
+			continue
+		}
+		nonTerm := g.rand.Intn(g.nonTermCount)
+		alt := g.rand.Intn(len(alts[nonTerm]))
+		alts[nonTerm][alt] = append(alts[nonTerm][alt], term)
+	}
+
+	return alts
+}
+
+func (g *GrammarGenerator) format(alts [][][]int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#name test;\n")
+	for i, nonTermAlts := range alts {
+		fmt.Fprintf(&b, "\nn%v\n", i)
+		seen := map[string]bool{}
+		first := true
+		for _, alt := range nonTermAlts {
+			// A non-terminal symbol deriving only itself makes the grammar meaningless, and the compiler rejects
+			// duplicate alternatives.
+			if isSelfDerivation(alt, i) {
+				continue
+			}
+			syms := make([]string, len(alt))
+			for j, sym := range alt {
+				if sym >= 0 {
+					syms[j] = fmt.Sprintf("t%v", sym)
+				} else {
+					syms[j] = fmt.Sprintf("n%v", -sym-1)
+				}
+			}
+			rhs := strings.Join(syms, " ")
+			if seen[rhs] {
+				continue
+			}
+			seen[rhs] = true
+			if first {
+				fmt.Fprintf(&b, "    :")
+				first = false
+			} else {
+				fmt.Fprintf(&b, "    |")
+			}
+			if rhs != "" {
+				fmt.Fprintf(&b, " %v", rhs)
+			}
+			fmt.Fprintf(&b, "\n")
+		}
+		fmt.Fprintf(&b, "    ;\n")
+	}
+	fmt.Fprintf(&b, "\nws #skip\n    : \"[\\u{0009}\\u{000A}\\u{0020}]+\";\n")
+	for term := 0; term < g.termCount; term++ {
+		letter := string(rune('a' + term))
+		if g.rand.Intn(2) == 0 {
+			fmt.Fprintf(&b, "t%v\n    : '%v';\n", term, letter)
+		} else {
+			fmt.Fprintf(&b, "t%v\n    : \"%v[0-9]*\";\n", term, letter)
+		}
+	}
+	return b.String()
+}
+
+// reachable reports whether the start symbol reaches the non-terminal symbol `n`i through the alternatives.
+func reachable(alts [][][]int, i int) bool {
+	visited := make([]bool, len(alts))
+	var visit func(nonTerm int) bool
+	visit = func(nonTerm int) bool {
+		if nonTerm == i {
+			return true
+		}
+		if visited[nonTerm] {
+			return false
+		}
+		visited[nonTerm] = true
+		for _, alt := range alts[nonTerm] {
+			if isSelfDerivation(alt, nonTerm) {
+				continue
+			}
+			for _, sym := range alt {
+				if sym < 0 && visit(-sym-1) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return visit(0)
+}
+
+func isSelfDerivation(alt []int, nonTerm int) bool {
+	return len(alt) == 1 && alt[0] == -(nonTerm+1)
+}
+
+func conflictFree(src string) bool {
+	g, err := Compile(src)
+	if err != nil {
+		return false
+	}
+	for _, state := range g.Report.States {
+		if len(state.SRConflict) > 0 || len(state.RRConflict) > 0 {
+			return false
+		}
+	}
+	return true
+}
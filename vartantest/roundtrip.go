@@ -0,0 +1,124 @@
+package vartantest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/nihei9/vartan/sentence"
+	spec "github.com/nihei9/vartan/spec/grammar"
+)
+
+// CheckRoundTrip checks the properties that a grammar and a source the grammar accepts must satisfy:
+//
+//   - The compiled grammar is consistent, that is, CompiledGrammar.Validate succeeds.
+//   - Encoding the compiled grammar in JSON and decoding it gives a grammar that encodes to the same JSON and parses
+//     the source into the same tree.
+//   - The terminal nodes of the tree and the tokens the parser skips cover the source in order without gaps or
+//     overlaps, and their texts are the same as the source.
+//
+// CheckRoundTrip returns an error describing the first property that doesn't hold.
+func CheckRoundTrip(g *Grammar, src []byte) error {
+	err := g.Compiled.Validate()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(g.Compiled)
+	if err != nil {
+		return err
+	}
+	decoded := &spec.CompiledGrammar{}
+	err = json.Unmarshal(data, decoded)
+	if err != nil {
+		return fmt.Errorf("cannot decode the compiled grammar: %w", err)
+	}
+	redata, err := json.Marshal(decoded)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(data, redata) {
+		return fmt.Errorf("the decoded grammar encodes to different JSON")
+	}
+
+	tree, trivia, err := parse(g.Compiled, src)
+	if err != nil {
+		return fmt.Errorf("cannot parse the source: %w", err)
+	}
+	decodedTree, _, err := parse(decoded, src)
+	if err != nil {
+		return fmt.Errorf("the decoded grammar cannot parse the source: %w", err)
+	}
+	if d := CompareTrees(tree, decodedTree); d != nil {
+		return fmt.Errorf("the decoded grammar parses the source into another tree: %v: %v", d.Path, d.Message)
+	}
+
+	return checkCoverage(tree, trivia, src)
+}
+
+// CheckSentences generates `samples` random sentences that a grammar accepts and checks CheckRoundTrip for each of
+// them. The options are those of sentence.NewGenerator. The error contains the sentence that breaks a property.
+func CheckSentences(g *Grammar, samples int, opts ...sentence.GeneratorOption) error {
+	srcs, err := Sentences(g, samples, opts...)
+	if err != nil {
+		return err
+	}
+	for _, src := range srcs {
+		err := CheckRoundTrip(g, src)
+		if err != nil {
+			return fmt.Errorf("%w; source: %q", err, src)
+		}
+	}
+	return nil
+}
+
+type span struct {
+	pos  int
+	text string
+}
+
+// checkCoverage checks that the terminal nodes of a tree and skipped tokens cover the source.
+func checkCoverage(tree *driver.Node, trivia []driver.VToken, src []byte) error {
+	var spans []span
+	var collect func(n *driver.Node)
+	collect = func(n *driver.Node) {
+		if n.Type == driver.NodeTypeTerminal {
+			spans = append(spans, span{
+				pos:  n.BytePos,
+				text: n.Text,
+			})
+			return
+		}
+		for _, c := range n.Children {
+			collect(c)
+		}
+	}
+	collect(tree)
+	for _, tok := range trivia {
+		pos, _ := tok.BytePosition()
+		spans = append(spans, span{
+			pos:  pos,
+			text: string(tok.Lexeme()),
+		})
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].pos < spans[j].pos
+	})
+
+	pos := 0
+	for _, s := range spans {
+		if s.pos != pos {
+			return fmt.Errorf("a token must start at byte %v but starts at byte %v: %q", pos, s.pos, s.text)
+		}
+		if pos+len(s.text) > len(src) || string(src[pos:pos+len(s.text)]) != s.text {
+			return fmt.Errorf("the text of a token differs from the source at byte %v: %q", pos, s.text)
+		}
+		pos += len(s.text)
+	}
+	if pos != len(src) {
+		return fmt.Errorf("the tokens cover only %v bytes of the %v-byte source", pos, len(src))
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package vartantest provides helpers for property-based testing of grammars. It generates random grammars and random
+// sentences that a grammar accepts, parses sentences into concrete syntax trees, and compares the trees, so downstream
+// users can check that a change of their grammar keeps the properties they expect on many inputs.
+package vartantest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/nihei9/vartan/driver/lexer"
+	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/normalizer"
+	"github.com/nihei9/vartan/sentence"
+	spec "github.com/nihei9/vartan/spec/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+	"github.com/nihei9/vartan/tester"
+)
+
+// Grammar is a compiled grammar and the report that the compiler generated with it. Generating sentences needs
+// the report because the compiled grammar doesn't have the right-hand sides of productions.
+type Grammar struct {
+	Compiled *spec.CompiledGrammar
+	Report   *spec.Report
+}
+
+// Compile compiles a grammar source. It returns an error when the grammar has errors, and it ignores warnings.
+func Compile(src string) (*Grammar, error) {
+	ast, err := parser.Parse(strings.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	b := grammar.GrammarBuilder{
+		AST: ast,
+	}
+	cg, report, err := b.Build(grammar.EnableReporting())
+	if err != nil {
+		return nil, err
+	}
+	return &Grammar{
+		Compiled: cg,
+		Report:   report,
+	}, nil
+}
+
+// Sentences generates `n` random sentences that a grammar accepts. The options are those of sentence.NewGenerator,
+// such as sentence.Seed and sentence.MaxDepth.
+func Sentences(g *Grammar, n int, opts ...sentence.GeneratorOption) ([][]byte, error) {
+	gen, err := sentence.NewGenerator(g.Compiled, g.Report, opts...)
+	if err != nil {
+		return nil, err
+	}
+	srcs := make([][]byte, n)
+	for i := range srcs {
+		srcs[i], err = gen.Generate()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return srcs, nil
+}
+
+// Parse parses a source into a concrete syntax tree. Unlike the parser, it returns an error when the source has
+// syntax errors.
+func Parse(g *Grammar, src []byte) (*driver.Node, error) {
+	tree, _, err := parse(g.Compiled, src)
+	return tree, err
+}
+
+// parse parses a source into a concrete syntax tree and also returns the tokens the parser skipped.
+func parse(cg *spec.CompiledGrammar, src []byte) (*driver.Node, []driver.VToken, error) {
+	toks, err := driver.NewTokenStream(cg, bytes.NewReader(src), lexer.NormalizeWith(normalizer.Default))
+	if err != nil {
+		return nil, nil, err
+	}
+	gram := driver.NewGrammar(cg)
+	tb := driver.NewDefaultSyntaxTreeBuilder()
+	p, err := driver.NewParser(toks, gram, driver.SemanticAction(driver.NewCSTActionSet(gram, tb)), driver.KeepTrivia())
+	if err != nil {
+		return nil, nil, err
+	}
+	err = p.Parse()
+	if err != nil {
+		return nil, nil, err
+	}
+	if synErrs := p.SyntaxErrors(); len(synErrs) > 0 {
+		return nil, nil, fmt.Errorf("%v:%v: %v", synErrs[0].Row+1, synErrs[0].Col+1, synErrs[0].Message)
+	}
+	return tb.Tree(), p.Trivia(), nil
+}
+
+// CompareTrees compares two syntax trees structurally, ignoring the positions of nodes, and returns the first
+// difference in pre-order. It returns nil when the trees are the same.
+func CompareTrees(oldTree, newTree *driver.Node) *tester.TreeDifference {
+	return tester.CompareTrees(oldTree, newTree)
+}
+
+// EqualTrees reports whether two syntax trees are structurally the same.
+func EqualTrees(oldTree, newTree *driver.Node) bool {
+	return tester.CompareTrees(oldTree, newTree) == nil
+}
@@ -0,0 +1,168 @@
+package vartantest
+
+import (
+	"strings"
+	"testing"
+
+	driver "github.com/nihei9/vartan/driver/parser"
+	"github.com/nihei9/vartan/sentence"
+)
+
+func TestGrammarGenerator_Generate(t *testing.T) {
+	gen := func(seed int64) string {
+		t.Helper()
+		g, err := NewGrammarGenerator(GrammarSeed(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return src
+	}
+
+	if gen(1) != gen(1) {
+		t.Fatal("generators having the same seed must generate the same grammar")
+	}
+	for seed := int64(1); seed <= 200; seed++ {
+		src := gen(seed)
+		_, err := Compile(src)
+		if err != nil {
+			t.Fatalf("a generated grammar must be valid: %v\n%v", err, src)
+		}
+	}
+
+	g, err := NewGrammarGenerator(TerminalCount(1), NonTerminalCount(8), MaxGrammarAttempts(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.Generate()
+	if err == nil {
+		t.Fatal("the generator must give up generating a grammar that has few terminal symbols")
+	}
+}
+
+func TestNewGrammarGenerator_Error(t *testing.T) {
+	tests := []struct {
+		caption string
+		opt     GrammarGeneratorOption
+	}{
+		{
+			caption: "a grammar needs at least one terminal symbol",
+			opt:     TerminalCount(0),
+		},
+		{
+			caption: "a terminal count cannot exceed the number of letters",
+			opt:     TerminalCount(27),
+		},
+		{
+			caption: "a grammar needs at least one non-terminal symbol",
+			opt:     NonTerminalCount(0),
+		},
+		{
+			caption: "a non-terminal symbol needs at least one alternative",
+			opt:     MaxAlternativeCount(0),
+		},
+		{
+			caption: "an alternative length cannot be negative",
+			opt:     MaxAlternativeLength(-1),
+		},
+		{
+			caption: "the generator needs at least one attempt",
+			opt:     MaxGrammarAttempts(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			_, err := NewGrammarGenerator(tt.opt)
+			if err == nil {
+				t.Fatal("an error must occur")
+			}
+		})
+	}
+}
+
+// TestCheckSentences_RandomGrammars fuzzes the round trip from compiling a grammar to parsing sentences it accepts.
+func TestCheckSentences_RandomGrammars(t *testing.T) {
+	for seed := int64(1); seed <= 100; seed++ {
+		gen, err := NewGrammarGenerator(GrammarSeed(seed), TerminalCount(int(seed%6)+3), NonTerminalCount(int(seed%5)+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := gen.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := Compile(src)
+		if err != nil {
+			t.Fatalf("%v\n%v", err, src)
+		}
+		err = CheckSentences(g, 20, sentence.Seed(seed))
+		if err != nil {
+			t.Fatalf("%v\n%v", err, src)
+		}
+	}
+}
+
+func TestCheckRoundTrip(t *testing.T) {
+	g, err := Compile(`
+#name test;
+
+list
+    : list comma elem
+    | elem
+    ;
+elem
+    : id
+    | l_bracket list r_bracket
+    ;
+
+ws #skip
+    : "[\u{0009}\u{000A}\u{0020}]+";
+comma
+    : ',';
+l_bracket
+    : '[';
+r_bracket
+    : ']';
+id
+    : "[a-z]+";
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = CheckRoundTrip(g, []byte(" a, [b ,c] "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = CheckRoundTrip(g, []byte("a,,"))
+	if err == nil {
+		t.Fatal("an input having syntax errors must fail the check")
+	}
+
+	tree, err := Parse(g, []byte("a, b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Parse(g, []byte("a,  b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualTrees(tree, other) {
+		t.Fatal("trees differing only in positions must be equal")
+	}
+	err = checkCoverage(tree, nil, []byte("a, b"))
+	if err == nil || !strings.Contains(err.Error(), "must start at byte 2") {
+		t.Fatalf("the check must find a gap of a skipped white space: %v", err)
+	}
+
+	tree.Children[1].Text = ";"
+	if EqualTrees(tree, other) {
+		t.Fatal("trees having different texts must differ")
+	}
+	if d := CompareTrees(tree, other); d == nil || d.Old.Type != driver.NodeTypeTerminal {
+		t.Fatalf("the difference must be the terminal node: %+v", d)
+	}
+}
@@ -0,0 +1,78 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// StateFileName is the name of a file where `vartan build` records the grammars it compiled. The file is placed in
+// the directory containing the manifest.
+const StateFileName = ".vartan-build.json"
+
+// State records the fingerprints and the outputs of the grammars compiled last time, so a build can skip grammars
+// that haven't changed since then.
+type State struct {
+	Grammars map[string]*GrammarState `json:"grammars"`
+}
+
+// GrammarState is the result of the last compilation of a grammar.
+type GrammarState struct {
+	Fingerprint string   `json:"fingerprint"`
+	Outputs     []string `json:"outputs"`
+}
+
+// LoadState reads the state of a manifest. When the state file doesn't exist, it returns an empty state.
+func LoadState(m *Manifest) (*State, error) {
+	s := &State{
+		Grammars: map[string]*GrammarState{},
+	}
+	data, err := os.ReadFile(filepath.Join(m.Dir, StateFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s, nil
+		}
+		return nil, err
+	}
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, err
+	}
+	if s.Grammars == nil {
+		s.Grammars = map[string]*GrammarState{}
+	}
+	return s, nil
+}
+
+// Save writes the state of a manifest.
+func (s *State) Save(m *Manifest) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.Dir, StateFileName), append(data, '\n'), 0644)
+}
+
+// UpToDate returns true when a grammar has the same fingerprint as the last time and all of its outputs exist.
+func (s *State) UpToDate(g *Grammar, fingerprint string) bool {
+	gs, ok := s.Grammars[g.Path]
+	if !ok || gs.Fingerprint != fingerprint {
+		return false
+	}
+	for _, out := range gs.Outputs {
+		if _, err := os.Stat(out); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Record records the result of a compilation of a grammar.
+func (s *State) Record(g *Grammar, fingerprint string, outputs []string) {
+	s.Grammars[g.Path] = &GrammarState{
+		Fingerprint: fingerprint,
+		Outputs:     outputs,
+	}
+}
@@ -0,0 +1,329 @@
+package workspace
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The decoder supports the subset of TOML that manifests need: key/value pairs whose values are strings, booleans, or
+// arrays of strings, and arrays of tables such as `[[grammar]]`. Other constructs are reported as errors so that
+// a manifest doesn't silently mean something else.
+
+type tomlValueKind int
+
+const (
+	tomlString tomlValueKind = iota
+	tomlBool
+	tomlStringArray
+)
+
+func (k tomlValueKind) String() string {
+	switch k {
+	case tomlString:
+		return "a string"
+	case tomlBool:
+		return "a boolean"
+	default:
+		return "an array of strings"
+	}
+}
+
+type tomlValue struct {
+	kind tomlValueKind
+	str  string
+	b    bool
+	arr  []string
+	line int
+}
+
+type tomlTable struct {
+	// keys holds the keys in order of appearance.
+	keys   []string
+	values map[string]*tomlValue
+
+	// arrays holds arrays of tables, and arrayKeys holds their names in order of appearance. Only the root table has
+	// them.
+	arrays    map[string][]*tomlTable
+	arrayKeys []string
+
+	line int
+}
+
+func newTOMLTable(line int) *tomlTable {
+	return &tomlTable{
+		values: map[string]*tomlValue{},
+		arrays: map[string][]*tomlTable{},
+		line:   line,
+	}
+}
+
+type tomlError struct {
+	line int
+	msg  string
+}
+
+func (e *tomlError) Error() string {
+	return fmt.Sprintf("%v: %v", e.line, e.msg)
+}
+
+type tomlDecoder struct {
+	src  string
+	pos  int
+	line int
+}
+
+func decodeTOML(src string) (*tomlTable, error) {
+	d := &tomlDecoder{
+		src:  src,
+		line: 1,
+	}
+	return d.decode()
+}
+
+func (d *tomlDecoder) errorf(format string, a ...interface{}) error {
+	return &tomlError{
+		line: d.line,
+		msg:  fmt.Sprintf(format, a...),
+	}
+}
+
+func (d *tomlDecoder) decode() (*tomlTable, error) {
+	root := newTOMLTable(1)
+	cur := root
+	for {
+		d.skipBlank(true)
+		if d.pos >= len(d.src) {
+			return root, nil
+		}
+		if d.src[d.pos] == '[' {
+			if !strings.HasPrefix(d.src[d.pos:], "[[") {
+				return nil, d.errorf("tables are unsupported; use arrays of tables such as [[grammar]]")
+			}
+			d.pos += 2
+			d.skipBlank(false)
+			name, err := d.key()
+			if err != nil {
+				return nil, err
+			}
+			d.skipBlank(false)
+			if !strings.HasPrefix(d.src[d.pos:], "]]") {
+				return nil, d.errorf("an array of tables must be closed with ]]")
+			}
+			d.pos += 2
+			if _, ok := root.values[name]; ok {
+				return nil, d.errorf("%v is already defined as a key", name)
+			}
+			cur = newTOMLTable(d.line)
+			if _, ok := root.arrays[name]; !ok {
+				root.arrayKeys = append(root.arrayKeys, name)
+			}
+			root.arrays[name] = append(root.arrays[name], cur)
+			if err := d.endOfLine(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		line := d.line
+		key, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		d.skipBlank(false)
+		if d.pos >= len(d.src) || d.src[d.pos] != '=' {
+			return nil, d.errorf("a key must be followed by =")
+		}
+		d.pos++
+		d.skipBlank(false)
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		v.line = line
+		if _, ok := cur.values[key]; ok {
+			return nil, &tomlError{line: line, msg: fmt.Sprintf("duplicate key: %v", key)}
+		}
+		if _, ok := cur.arrays[key]; ok {
+			return nil, &tomlError{line: line, msg: fmt.Sprintf("%v is already defined as an array of tables", key)}
+		}
+		cur.keys = append(cur.keys, key)
+		cur.values[key] = v
+		if err := d.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// skipBlank skips white spaces and comments. When `newline` is true, it also skips newlines.
+func (d *tomlDecoder) skipBlank(newline bool) {
+	for d.pos < len(d.src) {
+		switch c := d.src[d.pos]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			d.pos++
+		case c == '\n' && newline:
+			d.pos++
+			d.line++
+		case c == '#':
+			for d.pos < len(d.src) && d.src[d.pos] != '\n' {
+				d.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (d *tomlDecoder) endOfLine() error {
+	d.skipBlank(false)
+	if d.pos < len(d.src) && d.src[d.pos] != '\n' {
+		return d.errorf("unexpected characters at the end of a line")
+	}
+	return nil
+}
+
+func (d *tomlDecoder) key() (string, error) {
+	if d.pos < len(d.src) && d.src[d.pos] == '"' {
+		return d.basicString()
+	}
+	start := d.pos
+	for d.pos < len(d.src) {
+		c := d.src[d.pos]
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			break
+		}
+		d.pos++
+	}
+	if d.pos == start {
+		return "", d.errorf("a key is missing")
+	}
+	if d.pos < len(d.src) && d.src[d.pos] == '.' {
+		return "", d.errorf("dotted keys are unsupported")
+	}
+	return d.src[start:d.pos], nil
+}
+
+func (d *tomlDecoder) value() (*tomlValue, error) {
+	if d.pos >= len(d.src) {
+		return nil, d.errorf("a value is missing")
+	}
+	switch c := d.src[d.pos]; {
+	case c == '"' || c == '\'':
+		s, err := d.str()
+		if err != nil {
+			return nil, err
+		}
+		return &tomlValue{kind: tomlString, str: s}, nil
+	case strings.HasPrefix(d.src[d.pos:], "true"):
+		d.pos += len("true")
+		return &tomlValue{kind: tomlBool, b: true}, nil
+	case strings.HasPrefix(d.src[d.pos:], "false"):
+		d.pos += len("false")
+		return &tomlValue{kind: tomlBool, b: false}, nil
+	case c == '[':
+		d.pos++
+		arr := []string{}
+		for {
+			d.skipBlank(true)
+			if d.pos >= len(d.src) {
+				return nil, d.errorf("an array must be closed with ]")
+			}
+			if d.src[d.pos] == ']' {
+				d.pos++
+				return &tomlValue{kind: tomlStringArray, arr: arr}, nil
+			}
+			if c := d.src[d.pos]; c != '"' && c != '\'' {
+				return nil, d.errorf("an array can contain only strings")
+			}
+			s, err := d.str()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, s)
+			d.skipBlank(true)
+			if d.pos < len(d.src) && d.src[d.pos] == ',' {
+				d.pos++
+			} else if d.pos < len(d.src) && d.src[d.pos] != ']' {
+				return nil, d.errorf("elements of an array must be separated by ,")
+			}
+		}
+	default:
+		return nil, d.errorf("unsupported value; a value must be a string, a boolean, or an array of strings")
+	}
+}
+
+func (d *tomlDecoder) str() (string, error) {
+	if strings.HasPrefix(d.src[d.pos:], `"""`) || strings.HasPrefix(d.src[d.pos:], `'''`) {
+		return "", d.errorf("multi-line strings are unsupported")
+	}
+	if d.src[d.pos] == '"' {
+		return d.basicString()
+	}
+	d.pos++
+	start := d.pos
+	for d.pos < len(d.src) && d.src[d.pos] != '\'' {
+		if d.src[d.pos] == '\n' {
+			return "", d.errorf("a string must be closed on the same line")
+		}
+		d.pos++
+	}
+	if d.pos >= len(d.src) {
+		return "", d.errorf("a string must be closed with '")
+	}
+	s := d.src[start:d.pos]
+	d.pos++
+	return s, nil
+}
+
+func (d *tomlDecoder) basicString() (string, error) {
+	d.pos++
+	var b strings.Builder
+	for {
+		if d.pos >= len(d.src) || d.src[d.pos] == '\n' {
+			return "", d.errorf(`a string must be closed with " on the same line`)
+		}
+		c := d.src[d.pos]
+		if c == '"' {
+			d.pos++
+			return b.String(), nil
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			d.pos++
+			continue
+		}
+		d.pos++
+		if d.pos >= len(d.src) {
+			return "", d.errorf("an escape sequence is incomplete")
+		}
+		esc := d.src[d.pos]
+		d.pos++
+		switch esc {
+		case '"', '\\':
+			b.WriteByte(esc)
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'u', 'U':
+			n := 4
+			if esc == 'U' {
+				n = 8
+			}
+			if d.pos+n > len(d.src) {
+				return "", d.errorf("an escape sequence is incomplete")
+			}
+			cp, err := strconv.ParseUint(d.src[d.pos:d.pos+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(cp)) {
+				return "", d.errorf("invalid escape sequence: \\%c%v", esc, d.src[d.pos:d.pos+n])
+			}
+			b.WriteRune(rune(cp))
+			d.pos += n
+		default:
+			return "", d.errorf("invalid escape sequence: \\%c", esc)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+// Package workspace reads vartan.toml, a manifest of a project consisting of multiple grammars. A manifest lists
+// grammars with their outputs and compile options, and fragment libraries that the grammars share. `vartan build`
+// compiles the grammars a manifest lists.
+//
+// A manifest looks like the following. Paths are relative to the directory containing the manifest.
+//
+//	# The directory compiled grammars and reports are written to (default the directory of the manifest).
+//	out_dir = "build"
+//	# Files consisting only of fragments. Every grammar can use the fragments.
+//	fragments = ["lib/chars.vartan"]
+//
+//	[[grammar]]
+//	path = "expr.vartan"
+//	const_out = "exprconst/consts.go"
+//	lexer_table = "base-check"
+//
+//	[[grammar]]
+//	path = "json.vartan"
+//	output = "build/json/json.json"
+//	unused = "warn"
+//
+// A grammar entry accepts the keys `path` (required), `output`, `const_out`, `const_package`, `fragments`,
+// `duplicate_alternatives`, `unused`, `unused_annotations`, `right_recursion`, `unreachable_terminals`, `lexer_table`,
+// `lazy_lexer`, and `embed_source`. They correspond to the options of `vartan compile` command. `fragments` of a grammar entry adds
+// fragment libraries to the ones declared at the top level.
+package workspace
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/grammar"
+	"github.com/nihei9/vartan/spec/grammar/parser"
+)
+
+// ManifestFileName is the name of a manifest file.
+const ManifestFileName = "vartan.toml"
+
+// Manifest is a decoded manifest. All paths in it are resolved against the directory containing the manifest.
+type Manifest struct {
+	// Dir is the directory containing the manifest.
+	Dir string
+
+	// OutDir is the directory compiled grammars and reports are written to when a grammar has no output.
+	OutDir string
+
+	Grammars []*Grammar
+}
+
+// Grammar is a grammar entry of a manifest.
+type Grammar struct {
+	Path string
+
+	// Output is the output path of the compiled grammar. When it is empty, the compiled grammar and the report are
+	// written to the OutDir of the manifest.
+	Output string
+
+	ConstOut     string
+	ConstPackage string
+
+	// Fragments holds the fragment libraries the grammar uses, including the ones declared at the top level.
+	Fragments []string
+
+	DuplicateAlternatives grammar.DuplicateAlternativePolicy
+	Unused                grammar.Severity
+	UnusedAnnotations     grammar.Severity
+	RightRecursion        grammar.Severity
+	UnreachableTerminals  grammar.Severity
+	LexerTable            grammar.LexerTable
+	LazyLexer             bool
+	EmbedSource           bool
+}
+
+// Load reads a manifest file.
+func Load(path string) (*Manifest, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Parse(src, filepath.Dir(path))
+	if err != nil {
+		if tErr, ok := err.(*tomlError); ok {
+			return nil, fmt.Errorf("%v:%v", path, tErr)
+		}
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	return m, nil
+}
+
+// Parse decodes a manifest. `dir` is the directory the paths in the manifest are relative to.
+func Parse(src []byte, dir string) (*Manifest, error) {
+	root, err := decodeTOML(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Dir:    dir,
+		OutDir: dir,
+	}
+	var fragments []string
+	for _, key := range root.keys {
+		v := root.values[key]
+		switch key {
+		case "out_dir":
+			s, err := v.stringValue(key)
+			if err != nil {
+				return nil, err
+			}
+			m.OutDir = m.resolve(s)
+		case "fragments":
+			arr, err := v.arrayValue(key)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range arr {
+				fragments = append(fragments, m.resolve(s))
+			}
+		default:
+			return nil, &tomlError{line: v.line, msg: fmt.Sprintf("unknown key: %v", key)}
+		}
+	}
+	for _, name := range root.arrayKeys {
+		if name != "grammar" {
+			return nil, &tomlError{line: root.arrays[name][0].line, msg: fmt.Sprintf("unknown array of tables: %v", name)}
+		}
+	}
+	paths := map[string]int{}
+	for _, t := range root.arrays["grammar"] {
+		g, err := m.parseGrammar(t, fragments)
+		if err != nil {
+			return nil, err
+		}
+		if line, ok := paths[g.Path]; ok {
+			return nil, &tomlError{line: t.line, msg: fmt.Sprintf("the grammar is already listed at line %v: %v", line, g.Path)}
+		}
+		paths[g.Path] = t.line
+		m.Grammars = append(m.Grammars, g)
+	}
+	if len(m.Grammars) == 0 {
+		return nil, fmt.Errorf("a manifest must list at least one grammar using [[grammar]]")
+	}
+	return m, nil
+}
+
+func (m *Manifest) parseGrammar(t *tomlTable, fragments []string) (*Grammar, error) {
+	g := &Grammar{
+		Fragments:             append([]string{}, fragments...),
+		DuplicateAlternatives: grammar.DuplicateAlternativePolicySymbols,
+		Unused:                grammar.SeverityError,
+		UnusedAnnotations:     grammar.SeverityWarn,
+		RightRecursion:        grammar.SeverityWarn,
+		UnreachableTerminals:  grammar.SeverityWarn,
+		LexerTable:            grammar.LexerTableRowDisplacement,
+	}
+	for _, key := range t.keys {
+		v := t.values[key]
+		var err error
+		var s string
+		switch key {
+		case "path", "output", "const_out", "const_package", "duplicate_alternatives", "unused", "unused_annotations", "right_recursion", "unreachable_terminals", "lexer_table":
+			s, err = v.stringValue(key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "path":
+			g.Path = m.resolve(s)
+		case "output":
+			g.Output = m.resolve(s)
+		case "const_out":
+			g.ConstOut = m.resolve(s)
+		case "const_package":
+			g.ConstPackage = s
+		case "duplicate_alternatives":
+			g.DuplicateAlternatives = grammar.DuplicateAlternativePolicy(s)
+		case "unused":
+			g.Unused = grammar.Severity(s)
+		case "unused_annotations":
+			g.UnusedAnnotations = grammar.Severity(s)
+		case "right_recursion":
+			g.RightRecursion = grammar.Severity(s)
+		case "unreachable_terminals":
+			g.UnreachableTerminals = grammar.Severity(s)
+		case "lexer_table":
+			g.LexerTable = grammar.LexerTable(s)
+		case "lazy_lexer":
+			g.LazyLexer, err = v.boolValue(key)
+		case "embed_source":
+			g.EmbedSource, err = v.boolValue(key)
+		case "fragments":
+			var arr []string
+			arr, err = v.arrayValue(key)
+			for _, s := range arr {
+				g.Fragments = append(g.Fragments, m.resolve(s))
+			}
+		default:
+			return nil, &tomlError{line: v.line, msg: fmt.Sprintf("unknown key of a grammar: %v", key)}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if g.Path == "" {
+		return nil, &tomlError{line: t.line, msg: "a grammar must have a path"}
+	}
+	return g, nil
+}
+
+func (m *Manifest) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(m.Dir, path)
+}
+
+func (v *tomlValue) stringValue(key string) (string, error) {
+	if v.kind != tomlString {
+		return "", &tomlError{line: v.line, msg: fmt.Sprintf("%v must be a string, but it is %v", key, v.kind)}
+	}
+	return v.str, nil
+}
+
+func (v *tomlValue) boolValue(key string) (bool, error) {
+	if v.kind != tomlBool {
+		return false, &tomlError{line: v.line, msg: fmt.Sprintf("%v must be a boolean, but it is %v", key, v.kind)}
+	}
+	return v.b, nil
+}
+
+func (v *tomlValue) arrayValue(key string) ([]string, error) {
+	if v.kind != tomlStringArray {
+		return nil, &tomlError{line: v.line, msg: fmt.Sprintf("%v must be an array of strings, but it is %v", key, v.kind)}
+	}
+	return v.arr, nil
+}
+
+// BuildOptions returns the options for GrammarBuilder.Build corresponding to the compile options of the grammar.
+func (g *Grammar) BuildOptions() []grammar.BuildOption {
+	opts := []grammar.BuildOption{
+		grammar.DetectDuplicateAlternativesBy(g.DuplicateAlternatives),
+		grammar.TreatUnusedSymbolsAs(g.Unused),
+		grammar.TreatUnusedAnnotationsAs(g.UnusedAnnotations),
+		grammar.TreatRightRecursiveListsAs(g.RightRecursion),
+		grammar.TreatUnreachableTerminalsAs(g.UnreachableTerminals),
+		grammar.CompressLexerTablesBy(g.LexerTable),
+	}
+	if g.LazyLexer {
+		opts = append(opts, grammar.BuildLexerLazily())
+	}
+	return opts
+}
+
+// Source is a grammar source combined with the fragment libraries it uses.
+type Source struct {
+	// Grammar is the content of the grammar file.
+	Grammar []byte
+
+	// AST is the grammar with the fragments of the libraries appended. A fragment the grammar defines by itself
+	// takes precedence over the fragments of the same name in the libraries.
+	AST *parser.RootNode
+
+	path      string
+	libraries []*library
+}
+
+type library struct {
+	path      string
+	content   []byte
+	fragments []*parser.FragmentNode
+}
+
+// ReadSource reads a grammar and its fragment libraries. A fragment library must consist only of fragments.
+func (g *Grammar) ReadSource() (*Source, error) {
+	src, err := os.ReadFile(g.Path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the grammar file %s: %w", g.Path, err)
+	}
+	ast, err := parser.Parse(bytes.NewReader(src))
+	if err != nil {
+		setFilePath(err, g.Path)
+		return nil, err
+	}
+
+	s := &Source{
+		Grammar: src,
+		AST:     ast,
+		path:    g.Path,
+	}
+	defined := map[string]struct{}{}
+	for _, f := range ast.Fragments {
+		defined[f.LHS] = struct{}{}
+	}
+	for _, path := range g.Fragments {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the fragment library %s: %w", path, err)
+		}
+		lib, err := parser.Parse(bytes.NewReader(content))
+		if err != nil {
+			setFilePath(err, path)
+			return nil, err
+		}
+		if len(lib.Directives) > 0 || len(lib.Productions) > 0 || len(lib.LexProductions) > 0 {
+			return nil, fmt.Errorf("%v: a fragment library must consist only of fragments", path)
+		}
+		l := &library{
+			path:    path,
+			content: content,
+		}
+		for _, f := range lib.Fragments {
+			if _, ok := defined[f.LHS]; ok {
+				continue
+			}
+			defined[f.LHS] = struct{}{}
+			l.fragments = append(l.fragments, f)
+			ast.Fragments = append(ast.Fragments, f)
+		}
+		s.libraries = append(s.libraries, l)
+	}
+	return s, nil
+}
+
+func setFilePath(err error, path string) {
+	specErrs, ok := err.(verr.SpecErrors)
+	if !ok {
+		return
+	}
+	for _, e := range specErrs {
+		e.FilePath = path
+		e.SourceName = path
+	}
+}
+
+// Locate sets the file of an error in the source. An error located in a fragment taken from a library belongs to
+// the library, and the other errors belong to the grammar.
+func (s *Source) Locate(err *verr.SpecError) {
+	err.FilePath = s.path
+	err.SourceName = s.path
+	if s.inGrammar(err.Row) {
+		return
+	}
+	for _, l := range s.libraries {
+		for _, f := range l.fragments {
+			if err.Row >= f.Pos.Row && err.Row <= f.End.Row {
+				err.FilePath = l.path
+				err.SourceName = l.path
+				return
+			}
+		}
+	}
+}
+
+// inGrammar returns true when a row is within a node of the grammar itself.
+func (s *Source) inGrammar(row int) bool {
+	ast := s.AST
+	for _, d := range ast.Directives {
+		if row >= d.Pos.Row && row <= d.End.Row {
+			return true
+		}
+	}
+	for _, prods := range [][]*parser.ProductionNode{ast.Productions, ast.LexProductions} {
+		for _, p := range prods {
+			if row >= p.Pos.Row && row <= p.End.Row {
+				return true
+			}
+		}
+	}
+	fromLibraries := map[*parser.FragmentNode]struct{}{}
+	for _, l := range s.libraries {
+		for _, f := range l.fragments {
+			fromLibraries[f] = struct{}{}
+		}
+	}
+	for _, f := range ast.Fragments {
+		if _, ok := fromLibraries[f]; ok {
+			continue
+		}
+		if row >= f.Pos.Row && row <= f.End.Row {
+			return true
+		}
+	}
+	return false
+}
+
+// Fingerprint returns a hash of everything affecting the outputs of the grammar: the grammar, the fragment
+// libraries, the options, and `version`, the version of vartan.
+func (g *Grammar) Fingerprint(s *Source, version string) (string, error) {
+	opts, err := json.Marshal(g)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, b := range [][]byte{[]byte(version), opts, s.Grammar} {
+		fmt.Fprintf(h, "%v:", len(b))
+		h.Write(b)
+	}
+	for _, l := range s.libraries {
+		fmt.Fprintf(h, "%v:%v%v:", len(l.path), l.path, len(l.content))
+		h.Write(l.content)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,292 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	verr "github.com/nihei9/vartan/error"
+	"github.com/nihei9/vartan/grammar"
+)
+
+func TestParse(t *testing.T) {
+	src := `
+# comment
+out_dir = "build"
+fragments = [
+    "lib/a.vartan", # comment
+    'lib/b.vartan',
+]
+
+[[grammar]]
+path = "expr.vartan"
+
+[[ grammar ]]
+path = "json.vartan"
+output = "out/json.json"
+const_out = "jsonconst/consts.go"
+const_package = "jsonconst"
+fragments = ["lib/c.vartan"]
+duplicate_alternatives = "exact"
+unused = "warn"
+unused_annotations = "error"
+right_recursion = "error"
+unreachable_terminals = "ignore"
+lexer_table = "base-check"
+lazy_lexer = true
+embed_source = true
+`
+	m, err := Parse([]byte(src), "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Manifest{
+		Dir:    "proj",
+		OutDir: filepath.Join("proj", "build"),
+		Grammars: []*Grammar{
+			{
+				Path:                  filepath.Join("proj", "expr.vartan"),
+				Fragments:             []string{filepath.Join("proj", "lib/a.vartan"), filepath.Join("proj", "lib/b.vartan")},
+				DuplicateAlternatives: grammar.DuplicateAlternativePolicySymbols,
+				Unused:                grammar.SeverityError,
+				UnusedAnnotations:     grammar.SeverityWarn,
+				RightRecursion:        grammar.SeverityWarn,
+				UnreachableTerminals:  grammar.SeverityWarn,
+				LexerTable:            grammar.LexerTableRowDisplacement,
+			},
+			{
+				Path:                  filepath.Join("proj", "json.vartan"),
+				Output:                filepath.Join("proj", "out/json.json"),
+				ConstOut:              filepath.Join("proj", "jsonconst/consts.go"),
+				ConstPackage:          "jsonconst",
+				Fragments:             []string{filepath.Join("proj", "lib/a.vartan"), filepath.Join("proj", "lib/b.vartan"), filepath.Join("proj", "lib/c.vartan")},
+				DuplicateAlternatives: grammar.DuplicateAlternativePolicyExact,
+				Unused:                grammar.SeverityWarn,
+				UnusedAnnotations:     grammar.SeverityError,
+				RightRecursion:        grammar.SeverityError,
+				UnreachableTerminals:  grammar.SeverityIgnore,
+				LexerTable:            grammar.LexerTableBaseCheck,
+				LazyLexer:             true,
+				EmbedSource:           true,
+			},
+		},
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("unexpected manifest;\nwant: %+v\ngot: %+v", expected, m)
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	tests := []struct {
+		caption string
+		src     string
+		line    int
+	}{
+		{
+			caption: "a manifest must list a grammar",
+			src:     `out_dir = "build"`,
+		},
+		{
+			caption: "a grammar must have a path",
+			src: `
+[[grammar]]
+output = "a.json"
+`,
+			line: 2,
+		},
+		{
+			caption: "an unknown key is an error",
+			src: `
+[[grammar]]
+path = "a.vartan"
+lexer = "base-check"
+`,
+			line: 4,
+		},
+		{
+			caption: "a value must have the type of its key",
+			src: `
+[[grammar]]
+path = "a.vartan"
+lazy_lexer = "true"
+`,
+			line: 4,
+		},
+		{
+			caption: "a key cannot be duplicated",
+			src: `
+[[grammar]]
+path = "a.vartan"
+path = "b.vartan"
+`,
+			line: 4,
+		},
+		{
+			caption: "a grammar cannot be listed twice",
+			src: `
+[[grammar]]
+path = "a.vartan"
+
+[[grammar]]
+path = "./a.vartan"
+`,
+			line: 5,
+		},
+		{
+			caption: "tables are unsupported",
+			src: `
+[grammar]
+path = "a.vartan"
+`,
+			line: 2,
+		},
+		{
+			caption: "a string must be closed",
+			src: `
+[[grammar]]
+path = "a.vartan
+`,
+			line: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			_, err := Parse([]byte(tt.src), ".")
+			if err == nil {
+				t.Fatal("an error must occur")
+			}
+			if tt.line == 0 {
+				return
+			}
+			tErr, ok := err.(*tomlError)
+			if !ok || tErr.line != tt.line {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestGrammar_ReadSource(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("lib.vartan", `
+fragment digit
+    : "[0-9]";
+fragment letter
+    : "[a-z]";
+`)
+	writeFile("test.vartan", `
+#name test;
+
+s
+    : id
+    ;
+
+id
+    : "\f{letter}(\f{letter}|\f{digit})*";
+fragment letter
+    : "[A-Za-z]";
+`)
+	writeFile("bad_lib.vartan", `
+foo
+    : 'foo';
+`)
+
+	m, err := Parse([]byte(`
+fragments = ["lib.vartan"]
+
+[[grammar]]
+path = "test.vartan"
+
+[[grammar]]
+path = "test.vartan~"
+fragments = ["bad_lib.vartan"]
+`), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("the fragments of a grammar take precedence over the ones of libraries", func(t *testing.T) {
+		src, err := m.Grammars[0].ReadSource()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var frags []string
+		for _, f := range src.AST.Fragments {
+			frags = append(frags, f.LHS+":"+f.RHS)
+		}
+		if strings.Join(frags, " ") != "letter:[A-Za-z] digit:[0-9]" {
+			t.Fatalf("unexpected fragments: %v", frags)
+		}
+		b := grammar.GrammarBuilder{
+			AST: src.AST,
+		}
+		_, _, err = b.Build(m.Grammars[0].BuildOptions()...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		e := &verr.SpecError{
+			Row: 3,
+		}
+		src.Locate(e)
+		if e.FilePath != filepath.Join(dir, "lib.vartan") {
+			t.Fatalf("an error in a fragment of a library must belong to the library: %v", e.FilePath)
+		}
+		e = &verr.SpecError{
+			Row: 5,
+		}
+		src.Locate(e)
+		if e.FilePath != filepath.Join(dir, "test.vartan") {
+			t.Fatalf("an error in the grammar must belong to the grammar: %v", e.FilePath)
+		}
+	})
+
+	t.Run("a fragment library must consist only of fragments", func(t *testing.T) {
+		writeFile("test.vartan~", "#name test;\n")
+		_, err := m.Grammars[1].ReadSource()
+		if err == nil || !strings.Contains(err.Error(), "only of fragments") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("the fingerprint changes when a library changes", func(t *testing.T) {
+		src, err := m.Grammars[0].ReadSource()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fp1, err := m.Grammars[0].Fingerprint(src, "v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile("lib.vartan", `
+fragment digit
+    : "[0-9_]";
+`)
+		src, err = m.Grammars[0].ReadSource()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fp2, err := m.Grammars[0].Fingerprint(src, "v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fp1 == fp2 {
+			t.Fatal("the fingerprint must change")
+		}
+		fp3, err := m.Grammars[0].Fingerprint(src, "v2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fp2 == fp3 {
+			t.Fatal("the fingerprint must depend on the version")
+		}
+	})
+}